@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/declarative"
+)
+
+// applyAuthor is recorded as the author of policy versions created from the command line
+const applyAuthor = "mattrax apply"
+
+// apply applies the configuration file and prints the changes made as a diff
+func apply(ctx context.Context, srv *mattrax.Server, cmd *mattrax.ApplyCommand) error {
+	data, err := ioutil.ReadFile(cmd.File)
+	if err != nil {
+		return err
+	}
+
+	var format = declarative.FormatYAML
+	if strings.ToLower(filepath.Ext(cmd.File)) == ".json" {
+		format = declarative.FormatJSON
+	}
+
+	config, err := declarative.Parse(data, format)
+	if err != nil {
+		return err
+	} else if err := config.ValidateCatalog(srv.Catalog); err != nil {
+		return err
+	}
+
+	var changes []declarative.Change
+	if cmd.DryRun {
+		changes, err = declarative.Plan(ctx, srv.DB, config)
+	} else {
+		err = srv.Tx(ctx, func(ctx context.Context, q *db.Queries) error {
+			var err error
+			changes, err = declarative.Apply(ctx, q, config, applyAuthor)
+			return err
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No changes. Mattrax matches the configuration.")
+	} else if cmd.DryRun {
+		fmt.Printf("%d changes would be made\n", len(changes))
+	} else {
+		fmt.Printf("%d changes were made\n", len(changes))
+	}
+	return nil
+}
+
+// export writes the current configuration to stdout
+func export(ctx context.Context, srv *mattrax.Server, cmd *mattrax.ExportCommand) error {
+	var format = declarative.Format(cmd.Format)
+	if format != declarative.FormatYAML && format != declarative.FormatJSON {
+		return fmt.Errorf("unsupported format '%s'", cmd.Format)
+	}
+
+	config, err := declarative.Export(ctx, srv.DB)
+	if err != nil {
+		return err
+	}
+
+	data, err := config.Marshal(format)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(data)
+	return err
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"os"
 	"time"
@@ -11,9 +12,11 @@ import (
 	mattrax "github.com/mattrax/Mattrax/internal"
 	"github.com/mattrax/Mattrax/internal/api"
 	"github.com/mattrax/Mattrax/internal/authentication"
+	"github.com/mattrax/Mattrax/internal/catalog"
 	"github.com/mattrax/Mattrax/internal/certificates"
 	"github.com/mattrax/Mattrax/internal/db"
 	"github.com/mattrax/Mattrax/internal/middleware"
+	"github.com/mattrax/Mattrax/internal/secrets"
 	"github.com/mattrax/Mattrax/internal/settings"
 	"github.com/mattrax/Mattrax/mdm"
 	"github.com/patrickmn/go-cache"
@@ -51,22 +54,52 @@ func main() {
 		Args:         args,
 		GlobalRouter: mux.NewRouter(),
 		DB:           q,
+		DBConn:       dbconn,
 		Cache:        cache.New(5*time.Minute, 10*time.Minute),
 	}
+
+	if srv.Catalog, err = catalog.New(args.DDF); err != nil {
+		log.Fatal().Err(err).Msg("Error loading the policy catalog")
+	} else if srv.Catalog.Len() == 0 {
+		log.Warn().Str("dir", args.DDF).Msg("No DDF files were found so policy payloads won't be validated against the catalog")
+	}
+
+	if args.Apply != nil {
+		if err := apply(context.Background(), srv, args.Apply); err != nil {
+			log.Fatal().Err(err).Msg("Error applying configuration")
+		}
+		return
+	} else if args.Export != nil {
+		if err := export(context.Background(), srv, args.Export); err != nil {
+			log.Fatal().Err(err).Msg("Error exporting configuration")
+		}
+		return
+	}
+
 	if srv.Settings, err = settings.New(srv.DB); err != nil {
 		log.Fatal().Err(err).Msg("Error starting settings service")
 	}
 	if srv.Cert, err = certificates.New(srv.DB); err != nil {
 		log.Fatal().Err(err).Msg("Error starting certificates service")
 	}
+	if srv.Secrets, err = secrets.New(args.Secrets); err != nil {
+		log.Fatal().Err(err).Msg("Error starting secrets service")
+	}
 	if srv.Auth, err = authentication.New(srv.Cert, srv.Cache, srv.DB, args.Domain, args.Debug); err != nil {
 		log.Fatal().Err(err).Msg("Error starting authentication service")
 	}
 	srv.GlobalRouter.Use(middleware.Logging())
 	srv.GlobalRouter.Use(middleware.Headers())
 	srv.Router = srv.GlobalRouter.Schemes("https").Host(args.Domain).Subrouter()
+	srv.HTTPRouter = mux.NewRouter()
+	srv.HTTPRouter.Use(middleware.Logging())
+	srv.HTTPRouter.Use(middleware.Headers())
+	var agentRouter = mux.NewRouter()
+	agentRouter.Use(middleware.Logging())
+	agentRouter.Use(middleware.Headers())
+	srv.AgentRouter = agentRouter.Host(args.Domain).Subrouter()
 	api.Mount(srv)
 	mdm.Mount(srv)
 
-	serve(args.Addr, args.Domain, args.TLSCert, args.TLSKey, nil, srv.GlobalRouter)
+	serve(args.Addr, args.HTTPAddr, args.AgentAddr, args.Domain, args.TLSCert, args.TLSKey, nil, srv.GlobalRouter, srv.HTTPRouter, agentRouter)
 }
@@ -13,9 +13,36 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-// Serve uses the arguments to create a HTTPS server that uses secure defaults and has gracefully shutdown support
-func serve(addr string, domain string, httpsCertPath string, httpsKeyPath string, caCertPool *x509.CertPool, r http.Handler) {
-	var srv = &http.Server{
+// tlsConfig returns a TLS configuration that uses secure defaults and the client authentication
+func tlsConfig(clientAuth tls.ClientAuthType, caCertPool *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		PreferServerCipherSuites: true,
+		NextProtos:               []string{"h2", "http/1.1"},
+		// Mutual TLS
+		ClientCAs:  caCertPool,
+		ClientAuth: clientAuth,
+		// Standards from https://wiki.mozilla.org/Security/Server_Side_TLS
+		MinVersion: tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{
+			tls.CurveP256,
+			tls.CurveP384,
+			tls.CurveP521,
+			tls.X25519,
+		},
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		},
+	}
+}
+
+// httpServer returns a server which uses the timeouts shared by all of Mattrax's servers
+func httpServer(addr string, r http.Handler, tlsConfig *tls.Config) *http.Server {
+	return &http.Server{
 		Addr:              addr,
 		Handler:           r,
 		ReadHeaderTimeout: 5 * time.Second,
@@ -23,30 +50,18 @@ func serve(addr string, domain string, httpsCertPath string, httpsKeyPath string
 		WriteTimeout:      60 * time.Second,
 		IdleTimeout:       60 * time.Second,
 		MaxHeaderBytes:    1 << 20,
-		TLSConfig: &tls.Config{
-			PreferServerCipherSuites: true,
-			NextProtos:               []string{"h2", "http/1.1"},
-			// Mutual TLS
-			ClientCAs: caCertPool,
-			// ClientAuth:            tls.VerifyClientCertIfGiven, // tls.NoClientCert,
-			// Standards from https://wiki.mozilla.org/Security/Server_Side_TLS
-			MinVersion: tls.VersionTLS12,
-			CurvePreferences: []tls.CurveID{
-				tls.CurveP256,
-				tls.CurveP384,
-				tls.CurveP521,
-				tls.X25519,
-			},
-			CipherSuites: []uint16{
-				tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
-				tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
-				tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
-				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-			},
-		},
+		TLSConfig:         tlsConfig,
 	}
+}
+
+// Serve uses the arguments to create a HTTPS server that uses secure defaults and has gracefully shutdown support.
+// A HTTP server is also started for the resources which must be fetched without TLS and a HTTPS server for the Mattrax agent's API.
+// Only the agent's server requests client certificates so browsers opening the dashboard or the enrollment pages aren't asked for one.
+func serve(addr string, httpAddr string, agentAddr string, domain string, httpsCertPath string, httpsKeyPath string, caCertPool *x509.CertPool, r http.Handler, httpRouter http.Handler, agentRouter http.Handler) {
+	var srv = httpServer(addr, r, tlsConfig(tls.NoClientCert, nil))
+	var httpSrv = httpServer(httpAddr, httpRouter, nil)
+	// Client certificates are required but only verified by the handlers which authenticate with them as they are issued by different CAs
+	var agentSrv = httpServer(agentAddr, agentRouter, tlsConfig(tls.RequireAnyClientCert, caCertPool))
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
@@ -56,7 +71,17 @@ func serve(addr string, domain string, httpsCertPath string, httpsKeyPath string
 			log.Fatal().Err(err).Msg("Server encountered an error")
 		}
 	}()
-	log.Info().Str("addr", addr).Str("host", domain).Msg("Listening...")
+	go func() {
+		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("HTTP server encountered an error")
+		}
+	}()
+	go func() {
+		if err := agentSrv.ListenAndServeTLS(httpsCertPath, httpsKeyPath); err != nil && err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("Agent server encountered an error")
+		}
+	}()
+	log.Info().Str("addr", addr).Str("http_addr", httpAddr).Str("agent_addr", agentAddr).Str("host", domain).Msg("Listening...")
 
 	<-done
 	log.Info().Msg("Finishing active connections. Please wait...")
@@ -67,4 +92,10 @@ func serve(addr string, domain string, httpsCertPath string, httpsKeyPath string
 	if err := srv.Shutdown(ctx); err != nil && err != http.ErrServerClosed {
 		log.Fatal().Err(err).Msg("Failed to shutdown server")
 	}
+	if err := httpSrv.Shutdown(ctx); err != nil && err != http.ErrServerClosed {
+		log.Fatal().Err(err).Msg("Failed to shutdown HTTP server")
+	}
+	if err := agentSrv.Shutdown(ctx); err != nil && err != http.ErrServerClosed {
+		log.Fatal().Err(err).Msg("Failed to shutdown agent server")
+	}
 }
@@ -14,4 +14,5 @@ require (
 	github.com/rs/zerolog v1.19.0
 	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2
 	gopkg.in/square/go-jose.v2 v2.5.1
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,265 @@
+// Package admx parses ADMX/ADML files so their policies can be ingested by Windows and configured through the Policy CSP.
+// See https://docs.microsoft.com/en-us/windows/client-management/mdm/win32-and-centennial-app-policy-configuration
+package admx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ElementType is the type of value a policy element configures
+type ElementType string
+
+const (
+	ElementText      ElementType = "text"
+	ElementDecimal   ElementType = "decimal"
+	ElementBoolean   ElementType = "boolean"
+	ElementEnum      ElementType = "enum"
+	ElementList      ElementType = "list"
+	ElementMultiText ElementType = "multiText"
+)
+
+// Class is whether a policy applies to the device, the user or both
+type Class string
+
+const (
+	ClassMachine Class = "Machine"
+	ClassUser    Class = "User"
+	ClassBoth    Class = "Both"
+)
+
+// Definitions are the categories and policies defined by an ADMX file with their display text from its ADML file
+type Definitions struct {
+	Prefix   string   `json:"prefix"`
+	Policies []Policy `json:"policies"`
+}
+
+// Policy is a setting defined by the ADMX file. Category is the path of the categories (defined in the file) which contain it.
+type Policy struct {
+	Name        string    `json:"name"`
+	DisplayName string    `json:"display_name"`
+	ExplainText string    `json:"explain_text,omitempty"`
+	Class       Class     `json:"class"`
+	Category    string    `json:"category"`
+	Elements    []Element `json:"elements"`
+}
+
+// Element is a value of a policy which can be set while it's enabled
+type Element struct {
+	ID        string      `json:"id"`
+	Type      ElementType `json:"type"`
+	Label     string      `json:"label,omitempty"`
+	Required  bool        `json:"required"`
+	MinValue  *uint64     `json:"min_value,omitempty"`
+	MaxValue  *uint64     `json:"max_value,omitempty"`
+	MaxLength int         `json:"max_length,omitempty"`
+	Items     []EnumItem  `json:"items,omitempty"`
+}
+
+// EnumItem is one of the values an enum element can be set to
+type EnumItem struct {
+	DisplayName string `json:"display_name"`
+	Value       string `json:"value"`
+}
+
+type admxFile struct {
+	XMLName    xml.Name `xml:"policyDefinitions"`
+	Namespaces struct {
+		Target struct {
+			Prefix string `xml:"prefix,attr"`
+		} `xml:"target"`
+	} `xml:"policyNamespaces"`
+	Categories []struct {
+		Name   string `xml:"name,attr"`
+		Parent struct {
+			Ref string `xml:"ref,attr"`
+		} `xml:"parentCategory"`
+	} `xml:"categories>category"`
+	Policies []struct {
+		Name         string `xml:"name,attr"`
+		Class        Class  `xml:"class,attr"`
+		DisplayName  string `xml:"displayName,attr"`
+		ExplainText  string `xml:"explainText,attr"`
+		Presentation string `xml:"presentation,attr"`
+		Parent       struct {
+			Ref string `xml:"ref,attr"`
+		} `xml:"parentCategory"`
+		Elements struct {
+			Elements []admxElement `xml:",any"`
+		} `xml:"elements"`
+	} `xml:"policies>policy"`
+}
+
+type admxElement struct {
+	XMLName   xml.Name
+	ID        string `xml:"id,attr"`
+	Required  bool   `xml:"required,attr"`
+	MinValue  string `xml:"minValue,attr"`
+	MaxValue  string `xml:"maxValue,attr"`
+	MaxLength int    `xml:"maxLength,attr"`
+	Items     []struct {
+		DisplayName string `xml:"displayName,attr"`
+		Value       struct {
+			Decimal struct {
+				Value string `xml:"value,attr"`
+			} `xml:"decimal"`
+			String string `xml:"string"`
+		} `xml:"value"`
+	} `xml:"item"`
+}
+
+type admlFile struct {
+	XMLName   xml.Name `xml:"policyDefinitionResources"`
+	Resources struct {
+		Strings []struct {
+			ID    string `xml:"id,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"stringTable>string"`
+		Presentations []struct {
+			ID       string `xml:"id,attr"`
+			Elements []struct {
+				RefID string `xml:"refId,attr"`
+				Label string `xml:"label"`
+				Text  string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:"presentationTable>presentation"`
+	} `xml:"resources"`
+}
+
+var referenceRegex = regexp.MustCompile(`^\$\((string|presentation)\.([^)]+)\)$`)
+
+// Parse decodes the ADMX file and its ADML file. The ADML file is optional and is only used for the display text.
+func Parse(admx, adml io.Reader) (Definitions, error) {
+	var file admxFile
+	if err := xml.NewDecoder(admx).Decode(&file); err != nil {
+		return Definitions{}, fmt.Errorf("error parsing ADMX file: %w", err)
+	}
+
+	var resources admlFile
+	if adml != nil {
+		if err := xml.NewDecoder(adml).Decode(&resources); err != nil {
+			return Definitions{}, fmt.Errorf("error parsing ADML file: %w", err)
+		}
+	}
+
+	var stringTable = make(map[string]string, len(resources.Resources.Strings))
+	for _, s := range resources.Resources.Strings {
+		stringTable[s.ID] = strings.TrimSpace(s.Value)
+	}
+
+	var labels = make(map[string]map[string]string, len(resources.Resources.Presentations))
+	for _, presentation := range resources.Resources.Presentations {
+		labels[presentation.ID] = make(map[string]string, len(presentation.Elements))
+		for _, element := range presentation.Elements {
+			var label = strings.TrimSpace(element.Label)
+			if label == "" {
+				label = strings.TrimSpace(element.Text)
+			}
+			labels[presentation.ID][element.RefID] = label
+		}
+	}
+
+	var resolve = func(reference string) string {
+		if matches := referenceRegex.FindStringSubmatch(reference); matches != nil {
+			if s, found := stringTable[matches[2]]; found {
+				return s
+			}
+			return matches[2]
+		}
+		return reference
+	}
+
+	// Only categories defined in this file are part of the category path. Categories referenced from other files (eg. "Google:Cat_Google") are not.
+	var prefix = file.Namespaces.Target.Prefix
+	var parents = make(map[string]string, len(file.Categories))
+	for _, category := range file.Categories {
+		parents[category.Name] = localReference(category.Parent.Ref, prefix)
+	}
+
+	var definitions = Definitions{
+		Prefix:   prefix,
+		Policies: make([]Policy, 0, len(file.Policies)),
+	}
+	for _, p := range file.Policies {
+		var policy = Policy{
+			Name:        p.Name,
+			DisplayName: resolve(p.DisplayName),
+			ExplainText: resolve(p.ExplainText),
+			Class:       p.Class,
+			Category:    categoryPath(parents, localReference(p.Parent.Ref, prefix)),
+			Elements:    make([]Element, 0, len(p.Elements.Elements)),
+		}
+
+		var presentation map[string]string
+		if matches := referenceRegex.FindStringSubmatch(p.Presentation); matches != nil {
+			presentation = labels[matches[2]]
+		}
+
+		for _, e := range p.Elements.Elements {
+			var element = Element{
+				ID:        e.ID,
+				Type:      ElementType(e.XMLName.Local),
+				Label:     presentation[e.ID],
+				Required:  e.Required,
+				MaxLength: e.MaxLength,
+				MinValue:  parseUint(e.MinValue),
+				MaxValue:  parseUint(e.MaxValue),
+			}
+
+			switch element.Type {
+			case ElementText, ElementDecimal, ElementBoolean, ElementList, ElementMultiText:
+			case ElementEnum:
+				for _, item := range e.Items {
+					var value = item.Value.String
+					if value == "" {
+						value = item.Value.Decimal.Value
+					}
+
+					element.Items = append(element.Items, EnumItem{
+						DisplayName: resolve(item.DisplayName),
+						Value:       value,
+					})
+				}
+			default:
+				return Definitions{}, fmt.Errorf("policy '%s' has the unsupported element '%s'", p.Name, e.XMLName.Local)
+			}
+			policy.Elements = append(policy.Elements, element)
+		}
+		definitions.Policies = append(definitions.Policies, policy)
+	}
+	return definitions, nil
+}
+
+// Policy returns the policy with the name
+func (d Definitions) Policy(name string) (Policy, bool) {
+	for _, policy := range d.Policies {
+		if policy.Name == name {
+			return policy, true
+		}
+	}
+	return Policy{}, false
+}
+
+// localReference returns the name of the category reference if it is defined in the same file otherwise an empty string
+func localReference(ref, prefix string) string {
+	if i := strings.Index(ref, ":"); i != -1 {
+		if ref[:i] != prefix {
+			return ""
+		}
+		return ref[i+1:]
+	}
+	return ref
+}
+
+// categoryPath returns the path of the category (eg. "googlechrome~Startup") from the root category defined in the file
+func categoryPath(parents map[string]string, category string) string {
+	var path []string
+	for seen := make(map[string]bool); category != "" && !seen[category]; category = parents[category] {
+		seen[category] = true
+		path = append([]string{category}, path...)
+	}
+	return strings.Join(path, "~")
+}
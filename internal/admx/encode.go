@@ -0,0 +1,147 @@
+package admx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// listSeparator separates the items of list and multiText elements in the encoded data
+const listSeparator = "&#xF000;"
+
+var nameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ValidateName verifies the app name or file UID can be used in the Policy CSP URIs
+func ValidateName(name string) error {
+	if !nameRegex.MatchString(name) {
+		return fmt.Errorf("the name '%s' must only contain letters, numbers, '_', '.' and '-'", name)
+	}
+	return nil
+}
+
+// InstallURI returns the URI which the ADMX file is ingested into the device through
+func InstallURI(appName, fileUID string) string {
+	return "./Vendor/MSFT/Policy/ConfigOperations/ADMXInstall/" + appName + "/Policy/" + fileUID
+}
+
+// IsInstallURI returns whether the URI ingests an ADMX file
+func IsInstallURI(uri string) bool {
+	return strings.HasPrefix(uri, "./Vendor/MSFT/Policy/ConfigOperations/ADMXInstall/")
+}
+
+// URI returns the Policy CSP URI which configures the policy once the ADMX file is ingested. Policies for both the device and user are configured on the device.
+func (p Policy) URI(appName string) string {
+	var area = appName + "~Policy"
+	if p.Category != "" {
+		area += "~" + p.Category
+	}
+
+	var prefix = "./Vendor/MSFT/Policy/Config/"
+	if p.Class == ClassUser {
+		prefix = "./User/Vendor/MSFT/Policy/Config/"
+	}
+	return prefix + area + "/" + p.Name
+}
+
+// Encode returns the data which enables the policy with the values of its elements or disables it.
+// The values of list and multiText elements are one item per line.
+func (p Policy) Encode(enabled bool, values map[string]string) (string, error) {
+	if !enabled {
+		return "<disabled/>", nil
+	}
+
+	var known = make(map[string]bool, len(p.Elements))
+	var data = "<enabled/>"
+	for _, element := range p.Elements {
+		known[element.ID] = true
+
+		value, found := values[element.ID]
+		if !found {
+			if element.Required {
+				return "", fmt.Errorf("the element '%s' is required", element.ID)
+			}
+			continue
+		}
+
+		encoded, err := element.encode(value)
+		if err != nil {
+			return "", fmt.Errorf("element '%s': %w", element.ID, err)
+		}
+		data += `<data id="` + escape(element.ID) + `" value="` + encoded + `"/>`
+	}
+
+	var unknown []string
+	for id := range values {
+		if !known[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		return "", fmt.Errorf("the policy '%s' has no elements '%s'", p.Name, strings.Join(unknown, "', '"))
+	}
+	return data, nil
+}
+
+// encode validates the value and returns it escaped for the data element's value attribute
+func (e Element) encode(value string) (string, error) {
+	switch e.Type {
+	case ElementDecimal:
+		v, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("the value '%s' is not a valid decimal", value)
+		} else if (e.MinValue != nil && v < *e.MinValue) || (e.MaxValue != nil && v > *e.MaxValue) {
+			return "", fmt.Errorf("the value '%s' is out of range", value)
+		}
+	case ElementBoolean:
+		if value != "true" && value != "false" {
+			return "", fmt.Errorf("the value '%s' is not a valid boolean", value)
+		}
+	case ElementEnum:
+		var allowed = make([]string, len(e.Items))
+		for i, item := range e.Items {
+			allowed[i] = item.Value
+		}
+		if !contains(allowed, value) {
+			return "", fmt.Errorf("the value '%s' must be one of: %s", value, strings.Join(allowed, ", "))
+		}
+	case ElementList, ElementMultiText:
+		var lines = strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n")
+		for i, line := range lines {
+			lines[i] = escape(line)
+		}
+		return strings.Join(lines, listSeparator), nil
+	}
+
+	if e.MaxLength != 0 && len(value) > e.MaxLength {
+		return "", fmt.Errorf("the value must not be longer than %d characters", e.MaxLength)
+	}
+	return escape(value), nil
+}
+
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func parseUint(s string) *uint64 {
+	v, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/admx"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/policies"
+)
+
+type ADMXRequest struct {
+	ADMX string `json:"admx"`
+	ADML string `json:"adml"`
+}
+
+// parse decodes the ADMX file and its optional ADML file
+func (a ADMXRequest) parse() (admx.Definitions, error) {
+	if a.ADML == "" {
+		return admx.Parse(strings.NewReader(a.ADMX), nil)
+	}
+	return admx.Parse(strings.NewReader(a.ADMX), strings.NewReader(a.ADML))
+}
+
+type ADMXSetting struct {
+	Policy  string            `json:"policy"`
+	Enabled bool              `json:"enabled"`
+	Values  map[string]string `json:"values"`
+}
+
+type PolicyADMXRequest struct {
+	ADMXRequest
+	AppName  string        `json:"app_name"`
+	FileUID  string        `json:"file_uid"`
+	Settings []ADMXSetting `json:"settings"`
+}
+
+type PayloadsResponse struct {
+	IDs []int32 `json:"ids"`
+}
+
+// ADMXParse returns the policies defined by an ADMX file so they can be picked and configured
+func ADMXParse(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var cmd ADMXRequest
+		if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+			log.Printf("[JsonDecode Error]: %s\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		definitions, err := cmd.parse()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(definitions); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// PolicyADMX adds the payloads which ingest the ADMX file and configure its policies to the policy.
+// Uploading the same file again updates the ingested file and the configured policies.
+func PolicyADMX(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if _, err := srv.DB.GetPolicy(r.Context(), int32(id)); err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetPolicy Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var cmd PolicyADMXRequest
+		if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+			log.Printf("[JsonDecode Error]: %s\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		definitions, err := cmd.parse()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if cmd.FileUID == "" {
+			cmd.FileUID = definitions.Prefix
+		}
+		if err := admx.ValidateName(cmd.AppName); err != nil {
+			http.Error(w, "app_name: "+err.Error(), http.StatusBadRequest)
+			return
+		} else if err := admx.ValidateName(cmd.FileUID); err != nil {
+			http.Error(w, "file_uid: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var payloads = []policies.VersionPayload{
+			{
+				Uri:    admx.InstallURI(cmd.AppName, cmd.FileUID),
+				Format: "chr",
+				Value:  cmd.ADMX,
+			},
+		}
+		for _, setting := range cmd.Settings {
+			policy, found := definitions.Policy(setting.Policy)
+			if !found {
+				http.Error(w, "the ADMX file doesn't define the policy '"+setting.Policy+"'", http.StatusBadRequest)
+				return
+			}
+
+			data, err := policy.Encode(setting.Enabled, setting.Values)
+			if err != nil {
+				http.Error(w, "policy '"+setting.Policy+"': "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			payloads = append(payloads, policies.VersionPayload{
+				Uri:    policy.URI(cmd.AppName),
+				Format: "chr",
+				Value:  data,
+			})
+		}
+
+		var ids []int32
+		if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+			if err := policies.Baseline(ctx, q, int32(id)); err != nil {
+				return err
+			}
+
+			var err error
+			if ids, err = policies.SetPayloads(ctx, q, int32(id), payloads); err != nil {
+				return err
+			}
+			_, err = policies.Snapshot(ctx, q, int32(id), requestAuthor(r))
+			return err
+		}); err != nil {
+			log.Printf("[SetPayloads Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(PayloadsResponse{
+			IDs: ids,
+		}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -4,15 +4,20 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/lib/pq"
 	mattrax "github.com/mattrax/Mattrax/internal"
 )
 
 const MaxJSONBodySize = 2097152
 
+// appsRoute is the name of the route apps are uploaded to
+const appsRoute = "api-apps"
+
 // Mount initialises the API
 func Mount(srv *mattrax.Server) {
 	r := srv.Router.PathPrefix("/api").Subrouter()
 	r.Use(Headers(srv))
+	r.Use(LimitBody)
 	r.Use(mux.CORSMethodMiddleware(r))
 
 	r.HandleFunc("/login", Login(srv)).Methods(http.MethodPost, http.MethodOptions)
@@ -22,12 +27,87 @@ func Mount(srv *mattrax.Server) {
 
 	rAuthed.HandleFunc("/devices", Devices(srv)).Methods(http.MethodGet, http.MethodOptions)
 	rAuthed.HandleFunc("/device/{id}", Device(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/apps", DeviceApps(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/app/{app}/install", DeviceAppInstall(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/scripts", DeviceScripts(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/certificates", DeviceCertificates(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/bitlocker", DeviceBitLocker(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/bitlocker/rotate", DeviceBitLockerRotate(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/bitlocker/key/{protector}", DeviceBitLockerRecoveryKey(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/bitlocker/access", DeviceBitLockerAccess(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/localadmin", DeviceLocalAdmins(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/localadmin/{profile}/password", DeviceLocalAdminPassword(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/localadmin/{profile}/rotate", DeviceLocalAdminRotate(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/localadmin/access", DeviceLocalAdminAccess(srv)).Methods(http.MethodGet, http.MethodOptions)
 	rAuthed.HandleFunc("/device/{id}/info", DeviceInformation(srv)).Methods(http.MethodGet, http.MethodOptions)
 	rAuthed.HandleFunc("/device/{id}/scope", DeviceScope(srv)).Methods(http.MethodGet, http.MethodOptions)
-	rAuthed.HandleFunc("/groups", Groups(srv)).Methods(http.MethodGet, http.MethodOptions)
-	rAuthed.HandleFunc("/group/{id}", Group(srv)).Methods(http.MethodGet, http.MethodOptions)
-	rAuthed.HandleFunc("/policies", Policies(srv)).Methods(http.MethodGet, http.MethodOptions)
-	rAuthed.HandleFunc("/policy/{id}", Policy(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/conflicts", DeviceConflicts(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/effective", DeviceEffective(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/device/{id}/effective/preview", DeviceEffectivePreview(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/groups", Groups(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/group/{id}", Group(srv)).Methods(http.MethodGet, http.MethodPatch, http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/group/{id}/devices", GroupDevices(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/group/{id}/policies", GroupPolicies(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/group/{id}/policy/{policy}", GroupPolicy(srv)).Methods(http.MethodGet, http.MethodPut, http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/policies", Policies(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/policy/{id}", Policy(srv)).Methods(http.MethodGet, http.MethodPatch, http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/policy/{id}/payloads", PolicyPayloads(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/policy/{id}/payloads/upload", PolicyPayloadUpload(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/apps", Apps(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions).Name(appsRoute)
+	rAuthed.HandleFunc("/app/{id}", App(srv)).Methods(http.MethodGet, http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/app/{id}/installs", AppInstalls(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/app/{id}/assignments", AppAssignments(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/app/{id}/assignment/{gid}", AppAssignment(srv)).Methods(http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/scripts", Scripts(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/scripts/failures", ScriptFailures(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/script/{id}", Script(srv)).Methods(http.MethodGet, http.MethodPatch, http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/script/{id}/runs", ScriptRuns(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/certificateprofiles", CertificateProfiles(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/certificateprofile/{id}", CertificateProfile(srv)).Methods(http.MethodGet, http.MethodPatch, http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/certificateprofile/{id}/certificates", CertificateProfileCertificates(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/certificates/expiring", ExpiringCertificates(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/certificate/{serial}", IssuedCertificate(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/certificate/{serial}/revoke", RevokeCertificate(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/localadminprofiles", LocalAdminProfiles(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/localadminprofile/{id}", LocalAdminProfile(srv)).Methods(http.MethodGet, http.MethodPatch, http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/policy/{id}/admx", PolicyADMX(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/admx/parse", ADMXParse(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/policy/{id}/profile", PolicyProfile(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/profile/render", ProfileRender(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/policy/{id}/payload/{payload}", PolicyPayload(srv)).Methods(http.MethodGet, http.MethodPut, http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/policy/{id}/versions", PolicyVersions(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/policy/{id}/version/{version}", PolicyVersion(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/policy/{id}/version/{version}/diff", PolicyVersionDiff(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/policy/{id}/version/{version}/rollback", PolicyVersionRollback(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/rollouts", Rollouts(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/rollout/{id}", Rollout(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/rollout/{id}/{action}", RolloutAction(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/usergroups", UserGroups(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/usergroup/{id}", UserGroup(srv)).Methods(http.MethodGet, http.MethodPatch, http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/usergroup/{id}/members", UserGroupMembers(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/usergroup/{id}/policies", UserGroupPolicies(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/usergroup/{id}/policy/{policy}", UserGroupPolicy(srv)).Methods(http.MethodPut, http.MethodDelete, http.MethodOptions)
 	rAuthed.HandleFunc("/users", Users(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
 	rAuthed.HandleFunc("/user/{upn}", User(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/user/{upn}/conflicts", UserConflicts(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/catalog", Catalog(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/catalog/search", CatalogSearch(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/config", Configuration(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/config/plan", ConfigurationPlan(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/config/apply", ConfigurationApply(srv)).Methods(http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/branding", Brandings(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/branding/{language}", Branding(srv)).Methods(http.MethodGet, http.MethodPut, http.MethodDelete, http.MethodOptions)
+	rAuthed.HandleFunc("/settings/naming", DeviceNaming(srv)).Methods(http.MethodGet, http.MethodPut, http.MethodOptions)
+	rAuthed.HandleFunc("/terms", TermsOfService(srv)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	rAuthed.HandleFunc("/terms/report", TermsOfServiceReport(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/terms/{version}", TermsOfServiceVersion(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/terms/{version}/acceptances", TermsOfServiceAcceptances(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/enrollment/attempts", EnrollmentAttempts(srv)).Methods(http.MethodGet, http.MethodOptions)
+	rAuthed.HandleFunc("/enrollment/attempt/{traceid}", EnrollmentAttempt(srv)).Methods(http.MethodGet, http.MethodOptions)
+}
+
+// isUniqueViolation returns whether the database error was caused by a UNIQUE constraint (eg. a duplicate name)
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code.Name() == "unique_violation"
 }
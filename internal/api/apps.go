@@ -0,0 +1,388 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/apps"
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+// maxAppSize is the largest installer which can be uploaded
+const maxAppSize = 2 << 30
+
+type AppAssignmentRequest struct {
+	GroupID int32        `json:"group_id"`
+	Intent  db.AppIntent `json:"intent"`
+}
+
+type DeviceAppsResponse struct {
+	Installs  []db.GetDeviceAppInstallsRow   `json:"installs"`
+	Inventory []db.GetDeviceInventoryAppsRow `json:"inventory"`
+}
+
+// Apps lists the apps or creates an app. The app's details are query parameters (type, name, publisher, version, user and the type's parameters below).
+// MSI and appx/msix apps are uploaded with the installer as the request body. MSIs use product_code and command_line, appx/msix packages use package_family_name and file_name and Store apps use package_family_name, store_id and store_sku.
+func Apps(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			apps, err := srv.DB.GetApps(r.Context())
+			if err != nil {
+				log.Printf("[GetApps Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(apps); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			var query = r.URL.Query()
+			var cmd = db.CreateAppParams{
+				Type:        db.AppType(query.Get("type")),
+				Name:        query.Get("name"),
+				Publisher:   query.Get("publisher"),
+				Version:     query.Get("version"),
+				UserContext: query.Get("user") == "true",
+			}
+			if cmd.Type == "" {
+				cmd.Type = db.AppTypeMsi
+			}
+
+			if cmd.Name == "" {
+				http.Error(w, "the app must have a name", http.StatusBadRequest)
+				return
+			}
+
+			var err error
+			switch cmd.Type {
+			case db.AppTypeMsi:
+				cmd.Identifier, cmd.CommandLine = query.Get("product_code"), query.Get("command_line")
+				if cmd.CommandLine == "" {
+					cmd.CommandLine = "/quiet"
+				}
+				if cmd.Version == "" {
+					err = errors.New("the app must have a version")
+				} else {
+					err = apps.ValidateProductCode(cmd.Identifier)
+				}
+			case db.AppTypeAppx:
+				cmd.Identifier, cmd.FileName = query.Get("package_family_name"), query.Get("file_name")
+				if err = apps.ValidatePackageFamilyName(cmd.Identifier); err == nil {
+					err = apps.ValidateAppxFileName(cmd.FileName)
+				}
+			case db.AppTypeStore:
+				cmd.Identifier, cmd.StoreID, cmd.StoreSku = query.Get("package_family_name"), query.Get("store_id"), apps.DefaultStoreSku(query.Get("store_sku"))
+				if cmd.StoreID == "" {
+					err = errors.New("the Store app must have a store id")
+				} else {
+					err = apps.ValidatePackageFamilyName(cmd.Identifier)
+				}
+			default:
+				err = errors.New("the app type must be one of: msi, appx, store")
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			// Store apps are downloaded from the Microsoft Store so they have no installer
+			if cmd.Type != db.AppTypeStore {
+				if cmd.FileHash, cmd.FileSize, err = apps.Store(srv.Args.AppsDir, http.MaxBytesReader(w, r.Body, maxAppSize)); err != nil {
+					log.Printf("[StoreApp Error]: %s\n", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				} else if cmd.FileSize == 0 {
+					http.Error(w, "the request body must be the installer", http.StatusBadRequest)
+					return
+				}
+			}
+
+			id, err := srv.DB.CreateApp(r.Context(), cmd)
+			if err != nil {
+				if err := apps.Remove(r.Context(), srv.DB, srv.Args.AppsDir, cmd.FileHash); err != nil {
+					log.Printf("[RemoveApp Error]: %s\n", err)
+				}
+
+				if isUniqueViolation(err) {
+					w.WriteHeader(http.StatusConflict)
+					return
+				}
+				log.Printf("[CreateApp Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusCreated)
+			if err := json.NewEncoder(w).Encode(CreatedResponse{
+				ID: id,
+			}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+}
+
+// App returns or deletes an app. Apps can't be deleted while they are assigned to a group.
+func App(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		app, err := srv.DB.GetApp(r.Context(), int32(id))
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetApp Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(app); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodDelete {
+			assignments, err := srv.DB.GetAppAssignments(r.Context(), app.ID)
+			if err != nil {
+				log.Printf("[GetAppAssignments Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			} else if len(assignments) != 0 {
+				http.Error(w, "the app is assigned to a group", http.StatusConflict)
+				return
+			}
+
+			if err := srv.DB.DeleteApp(r.Context(), app.ID); err != nil {
+				log.Printf("[DeleteApp Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if err := apps.Remove(r.Context(), srv.DB, srv.Args.AppsDir, app.FileHash); err != nil {
+				log.Printf("[RemoveApp Error]: %s\n", err)
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// AppInstalls returns the install state of the app on each device it was sent to
+func AppInstalls(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		installs, err := srv.DB.GetAppInstalls(r.Context(), int32(id))
+		if err != nil {
+			log.Printf("[GetAppInstalls Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(installs); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// AppAssignments lists or sets the intent of the app for each group it is assigned to. Assigning the app to a group again replaces its intent.
+func AppAssignments(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if _, err := srv.DB.GetApp(r.Context(), int32(id)); err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetApp Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			assignments, err := srv.DB.GetAppAssignments(r.Context(), int32(id))
+			if err != nil {
+				log.Printf("[GetAppAssignments Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(assignments); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			var cmd AppAssignmentRequest
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if cmd.Intent != db.AppIntentRequired && cmd.Intent != db.AppIntentAvailable && cmd.Intent != db.AppIntentUninstall {
+				http.Error(w, "the intent must be one of: required, available, uninstall", http.StatusBadRequest)
+				return
+			}
+
+			if _, err := srv.DB.GetGroup(r.Context(), cmd.GroupID); err == sql.ErrNoRows {
+				http.Error(w, "group does not exist", http.StatusBadRequest)
+				return
+			} else if err != nil {
+				log.Printf("[GetGroup Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if err := srv.DB.SetAppAssignment(r.Context(), db.SetAppAssignmentParams{
+				AppID:   int32(id),
+				GroupID: cmd.GroupID,
+				Intent:  cmd.Intent,
+			}); err != nil {
+				log.Printf("[SetAppAssignment Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// AppAssignment removes the app's assignment to a group. Devices keep the app installed unless another group uninstalls it.
+func AppAssignment(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		gid, err := strconv.Atoi(vars["gid"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if err := srv.DB.DeleteAppAssignment(r.Context(), db.DeleteAppAssignmentParams{
+			AppID:   int32(id),
+			GroupID: int32(gid),
+		}); err != nil {
+			log.Printf("[DeleteAppAssignment Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeviceApps returns the install state of each app sent to the device and the modern apps the device reported as installed
+func DeviceApps(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		installs, err := srv.DB.GetDeviceAppInstalls(r.Context(), int32(id))
+		if err != nil {
+			log.Printf("[GetDeviceAppInstalls Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		inventory, err := srv.DB.GetDeviceInventoryApps(r.Context(), int32(id))
+		if err != nil {
+			log.Printf("[GetDeviceInventoryApps Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(DeviceAppsResponse{
+			Installs:  installs,
+			Inventory: inventory,
+		}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// DeviceAppInstall requests an app which is available to the device is installed. It is installed at the device's next checkin.
+func DeviceAppInstall(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		appID, err := strconv.Atoi(vars["app"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		assignments, err := srv.DB.GetDeviceAppAssignments(r.Context(), int32(id))
+		if err != nil {
+			log.Printf("[GetDeviceAppAssignments Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var available bool
+		for _, app := range apps.Resolve(assignments) {
+			if app.ID == int32(appID) && app.Intent == db.AppIntentAvailable {
+				available = true
+			}
+		}
+		if !available {
+			http.Error(w, "the app is not available to the device", http.StatusNotFound)
+			return
+		}
+
+		if err := apps.SetState(r.Context(), srv.DB, int32(appID), int32(id), db.AppInstallStatePending, ""); err != nil {
+			log.Printf("[SetAppInstallState Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
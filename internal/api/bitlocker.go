@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/bitlocker"
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+type DeviceBitLockerResponse struct {
+	RecoveryKeys []db.GetDeviceBitLockerRecoveryKeysRow `json:"recovery_keys"`
+	Rotation     *db.BitlockerRotation                  `json:"rotation"` // The pending rotation if one has been requested
+}
+
+type RevealRequest struct {
+	Reason string `json:"reason"`
+}
+
+type RecoveryKeyResponse struct {
+	ProtectorID       string               `json:"protector_id"`
+	RecoveryPasswords []bitlocker.Revealed `json:"recovery_passwords"` // Newest first. Earlier passwords are kept if the agent reported a different password for the protector.
+}
+
+// DeviceBitLocker returns the device's escrowed recovery keys (without their recovery passwords) and its pending recovery password rotation
+func DeviceBitLocker(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := requestDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		var res DeviceBitLockerResponse
+		var err error
+		if res.RecoveryKeys, err = srv.DB.GetDeviceBitLockerRecoveryKeys(r.Context(), device.ID); err != nil {
+			log.Printf("[GetDeviceBitLockerRecoveryKeys Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		rotation, err := srv.DB.GetBitLockerRotation(r.Context(), device.ID)
+		if err == nil {
+			res.Rotation = &rotation
+		} else if err != sql.ErrNoRows {
+			log.Printf("[GetBitLockerRotation Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// DeviceBitLockerRotate asks the device's agent to replace its recovery passwords. The rotation is pending until the agent escrows the new passwords.
+// Passwords should be rotated after they are revealed as the user they were given to can unlock the drive with them.
+func DeviceBitLockerRotate(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := requestDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		if err := srv.DB.RequestBitLockerRotation(r.Context(), db.RequestBitLockerRotationParams{
+			DeviceID:    device.ID,
+			RequestedBy: requestAuthor(r),
+		}); err != nil {
+			log.Printf("[RequestBitLockerRotation Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeviceBitLockerRecoveryKey reveals the recovery passwords of a protector. Only administrators can reveal passwords and they must give a reason which is logged with their access.
+func DeviceBitLockerRecoveryKey(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := requestDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		upn, ok := requireAdministrator(srv, w, r)
+		if !ok {
+			return
+		}
+
+		var cmd RevealRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxJSONBodySize)).Decode(&cmd); err != nil {
+			log.Printf("[JsonDecode Error]: %s\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		} else if cmd.Reason == "" {
+			http.Error(w, "a reason must be given to reveal the recovery password", http.StatusBadRequest)
+			return
+		}
+
+		var res = RecoveryKeyResponse{
+			ProtectorID: bitlocker.NormaliseProtectorID(mux.Vars(r)["protector"]),
+		}
+		if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+			var err error
+			res.RecoveryPasswords, err = bitlocker.Reveal(ctx, q, srv.Secrets, device.ID, res.ProtectorID, upn, cmd.Reason)
+			return err
+		}); err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[RevealBitLockerRecoveryKey Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// DeviceBitLockerAccess returns who revealed the device's recovery passwords and why
+func DeviceBitLockerAccess(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := requestDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		access, err := srv.DB.GetBitLockerRecoveryKeyAccess(r.Context(), device.ID)
+		if err != nil {
+			log.Printf("[GetBitLockerRecoveryKeyAccess Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(access); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+// DefaultBrandingLanguage is used in the URL to refer to the branding used when no language specific variant matches
+const DefaultBrandingLanguage = "default"
+
+func Brandings(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		brandings, err := srv.DB.GetEnrollmentBrandings(r.Context())
+		if err != nil {
+			log.Printf("[GetEnrollmentBrandings Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(brandings); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func Branding(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		var language = vars["language"]
+		if language == DefaultBrandingLanguage {
+			language = ""
+		}
+
+		if r.Method == http.MethodGet {
+			branding, err := srv.DB.GetEnrollmentBranding(r.Context(), language)
+			if err == sql.ErrNoRows {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			} else if err != nil {
+				log.Printf("[GetEnrollmentBranding Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(branding); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPut {
+			var cmd db.SetEnrollmentBrandingParams
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			cmd.Language = language
+
+			if err := srv.DB.SetEnrollmentBranding(r.Context(), cmd); err != nil {
+				log.Printf("[SetEnrollmentBranding Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		} else if r.Method == http.MethodDelete {
+			if err := srv.DB.DeleteEnrollmentBranding(r.Context(), language); err != nil {
+				log.Printf("[DeleteEnrollmentBranding Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/catalog"
+)
+
+// catalogSearchLimit is the maximum number of nodes returned by a catalog search
+const catalogSearchLimit = 100
+
+// Catalog returns the catalog node at the "uri" query parameter (the root by default) and its children for browsing the settings
+func Catalog(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var uri = r.URL.Query().Get("uri")
+		if uri == "" {
+			uri = "."
+		}
+
+		node, found := srv.Catalog.Get(uri)
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(struct {
+			*catalog.Node
+			Children []*catalog.Node `json:"children"`
+		}{
+			Node:     node,
+			Children: node.Children(),
+		}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// CatalogSearch returns the catalog nodes matching the "q" query parameter
+func CatalogSearch(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var query = r.URL.Query().Get("q")
+		if query == "" {
+			http.Error(w, "the search query must not be empty", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(srv.Catalog.Search(query, catalogSearchLimit)); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/scep"
+)
+
+type CertificateProfileRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	scep.Profile
+	UserContext bool  `json:"user_context"`
+	GroupID     int32 `json:"group_id"`
+}
+
+// validate verifies the certificate profile and that its group exists. It writes the error response and returns false if the profile is invalid.
+func (c CertificateProfileRequest) validate(srv *mattrax.Server, w http.ResponseWriter, r *http.Request) bool {
+	if c.Name == "" {
+		http.Error(w, "the certificate profile must have a name", http.StatusBadRequest)
+		return false
+	} else if err := c.Profile.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	ca, _ := srv.Cert.SCEP()
+	if err := c.Profile.ValidateCA(ca, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	if _, err := srv.DB.GetGroup(r.Context(), c.GroupID); err == sql.ErrNoRows {
+		http.Error(w, "group does not exist", http.StatusBadRequest)
+		return false
+	} else if err != nil {
+		log.Printf("[GetGroup Error]: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// CertificateProfiles lists or creates the profiles which issue certificates to devices through SCEP. Unset certificate settings default to a client authentication certificate.
+func CertificateProfiles(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			certificateProfiles, err := srv.DB.GetCertificateProfiles(r.Context())
+			if err != nil {
+				log.Printf("[GetCertificateProfiles Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(certificateProfiles); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			var cmd CertificateProfileRequest
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxJSONBodySize)).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			cmd.Profile.Defaults()
+			if !cmd.validate(srv, w, r) {
+				return
+			}
+
+			id, err := srv.DB.CreateCertificateProfile(r.Context(), db.CreateCertificateProfileParams{
+				Name:              cmd.Name,
+				Description:       cmd.Description,
+				SubjectTemplate:   cmd.SubjectTemplate,
+				KeyUsages:         cmd.KeyUsages,
+				ExtendedKeyUsages: cmd.ExtendedKeyUsages,
+				KeyLength:         cmd.KeyLength,
+				ValidityDays:      cmd.ValidityDays,
+				RenewalThreshold:  cmd.RenewalThreshold,
+				UserContext:       cmd.UserContext,
+				GroupID:           cmd.GroupID,
+			})
+			if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[CreateCertificateProfile Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusCreated)
+			if err := json.NewEncoder(w).Encode(CreatedResponse{
+				ID: id,
+			}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+}
+
+// CertificateProfile returns, updates or deletes a certificate profile. Devices are issued a new certificate when the rendered subject of their certificate changes.
+// Deleting a profile doesn't revoke the certificates it issued.
+func CertificateProfile(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		certificateProfile, err := srv.DB.GetCertificateProfile(r.Context(), int32(id))
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetCertificateProfile Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(certificateProfile); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPatch {
+			var cmd = CertificateProfileRequest{
+				Name:        certificateProfile.Name,
+				Description: certificateProfile.Description,
+				Profile: scep.Profile{
+					SubjectTemplate:   certificateProfile.SubjectTemplate,
+					KeyUsages:         certificateProfile.KeyUsages,
+					ExtendedKeyUsages: certificateProfile.ExtendedKeyUsages,
+					KeyLength:         certificateProfile.KeyLength,
+					ValidityDays:      certificateProfile.ValidityDays,
+					RenewalThreshold:  certificateProfile.RenewalThreshold,
+				},
+				UserContext: certificateProfile.UserContext,
+				GroupID:     certificateProfile.GroupID,
+			}
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxJSONBodySize)).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if !cmd.validate(srv, w, r) {
+				return
+			}
+
+			err := srv.DB.UpdateCertificateProfile(r.Context(), db.UpdateCertificateProfileParams{
+				ID:                certificateProfile.ID,
+				Name:              cmd.Name,
+				Description:       cmd.Description,
+				SubjectTemplate:   cmd.SubjectTemplate,
+				KeyUsages:         cmd.KeyUsages,
+				ExtendedKeyUsages: cmd.ExtendedKeyUsages,
+				KeyLength:         cmd.KeyLength,
+				ValidityDays:      cmd.ValidityDays,
+				RenewalThreshold:  cmd.RenewalThreshold,
+				UserContext:       cmd.UserContext,
+				GroupID:           cmd.GroupID,
+			})
+			if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[UpdateCertificateProfile Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		} else if r.Method == http.MethodDelete {
+			if err := srv.DB.DeleteCertificateProfile(r.Context(), certificateProfile.ID); err != nil {
+				log.Printf("[DeleteCertificateProfile Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// CertificateProfileCertificates returns the certificates issued by the certificate profile
+func CertificateProfileCertificates(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		certificates, err := srv.DB.GetProfileIssuedCertificates(r.Context(), sql.NullInt32{Int32: int32(id), Valid: true})
+		if err != nil {
+			log.Printf("[GetProfileIssuedCertificates Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(certificates); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// ExpiringCertificates returns the current certificates of devices which expire within the number of days (30 by default).
+// Devices in their profile's group renew them automatically so certificates listed here belong to devices which are offline or left the group.
+func ExpiringCertificates(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var days = 30
+		if value := r.URL.Query().Get("days"); value != "" {
+			var err error
+			if days, err = strconv.Atoi(value); err != nil || days < 1 {
+				http.Error(w, "days must be a positive number", http.StatusBadRequest)
+				return
+			}
+		}
+
+		certificates, err := srv.DB.GetExpiringIssuedCertificates(r.Context(), time.Now().Add(time.Duration(days)*24*time.Hour))
+		if err != nil {
+			log.Printf("[GetExpiringIssuedCertificates Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(certificates); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// IssuedCertificate returns a certificate issued through SCEP by its (hex encoded) serial number
+func IssuedCertificate(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		certificate, err := srv.DB.GetIssuedCertificate(r.Context(), vars["serial"])
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetIssuedCertificate Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(certificate); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// RevokeCertificate revokes a certificate issued through SCEP which adds it to the SCEP CA's CRL.
+// If the device is still in the profile's group it is issued a replacement certificate on its next checkin.
+func RevokeCertificate(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		certificate, err := srv.DB.GetIssuedCertificate(r.Context(), vars["serial"])
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetIssuedCertificate Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else if certificate.RevokedAt.Valid {
+			http.Error(w, "the certificate is already revoked", http.StatusConflict)
+			return
+		}
+
+		if err := srv.DB.RevokeIssuedCertificate(r.Context(), certificate.Serial); err != nil {
+			log.Printf("[RevokeIssuedCertificate Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeviceCertificates returns the certificates issued to the device (and the users signed into it) through SCEP
+func DeviceCertificates(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		certificates, err := srv.DB.GetDeviceIssuedCertificates(r.Context(), int32(id))
+		if err != nil {
+			log.Printf("[GetDeviceIssuedCertificates Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(certificates); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/catalog"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/declarative"
+)
+
+// requestConfiguration decodes and validates the declarative configuration in the request body. YAML is used when the Content-Type contains "yaml" otherwise JSON.
+func requestConfiguration(r *http.Request, catalog *catalog.Service) (declarative.Config, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return declarative.Config{}, err
+	}
+
+	var format = declarative.FormatJSON
+	if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+		format = declarative.FormatYAML
+	}
+	config, err := declarative.Parse(data, format)
+	if err != nil {
+		return declarative.Config{}, err
+	}
+	return config, config.ValidateCatalog(catalog)
+}
+
+// Configuration exports the declarative configuration. The "format" query parameter can be "json" (the default) or "yaml".
+func Configuration(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var format, contentType = declarative.FormatJSON, "application/json; charset=UTF-8"
+		if r.URL.Query().Get("format") == string(declarative.FormatYAML) {
+			format, contentType = declarative.FormatYAML, "application/yaml; charset=UTF-8"
+		}
+
+		config, err := declarative.Export(r.Context(), srv.DB)
+		if err != nil {
+			log.Printf("[ExportConfiguration Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		data, err := config.Marshal(format)
+		if err != nil {
+			log.Printf("[MarshalConfiguration Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Write(data)
+	}
+}
+
+// ConfigurationPlan returns the changes applying the declarative configuration would make
+func ConfigurationPlan(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		config, err := requestConfiguration(r, srv.Catalog)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		changes, err := declarative.Plan(r.Context(), srv.DB, config)
+		if err != nil {
+			log.Printf("[PlanConfiguration Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(changes); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// ConfigurationApply applies the declarative configuration in a single transaction and returns the changes made
+func ConfigurationApply(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		config, err := requestConfiguration(r, srv.Catalog)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var changes []declarative.Change
+		if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+			var err error
+			changes, err = declarative.Apply(ctx, q, config, requestAuthor(r))
+			return err
+		}); err != nil {
+			log.Printf("[ApplyConfiguration Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(changes); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
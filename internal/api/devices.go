@@ -6,11 +6,20 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/policies"
+	"github.com/mattrax/Mattrax/pkg/null"
 )
 
+type DevicePreviewRequest struct {
+	AddGroups    []int32 `json:"add_groups"`
+	RemoveGroups []int32 `json:"remove_groups"`
+}
+
 func Devices(srv *mattrax.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		devices, err := srv.DB.GetDevices(r.Context())
@@ -131,3 +140,179 @@ func DeviceScope(srv *mattrax.Server) http.HandlerFunc {
 		}
 	}
 }
+
+// DeviceConflicts returns the URIs set by multiple of the device's policies along with the winning and losing payloads
+func DeviceConflicts(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := requestDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		effective, err := policies.DevicePayloads(r.Context(), srv.DB, device.ID)
+		if err != nil {
+			log.Printf("[GetDevicesPayloadCandidates Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(policies.Conflicts(effective)); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// DeviceEffective returns the payloads which the device will receive once its policies are deployed (its resultant set of policy).
+// The user payloads are those deployed to the session of the user in the upn query parameter, which defaults to the user who enrolled the device.
+func DeviceEffective(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := requestDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		effective, err := policies.DevicePayloads(r.Context(), srv.DB, device.ID)
+		if err != nil {
+			log.Printf("[GetDevicesPayloadCandidates Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		payloads, removing, err := policies.Resultant(r.Context(), srv.DB, device.ID, null.String{}, effective)
+		if err != nil {
+			log.Printf("[GetDeployedPayloads Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		schedules, err := policies.DeviceSchedules(r.Context(), srv.DB, device.ID)
+		if err != nil {
+			log.Printf("[GetDevicesAssignmentSchedules Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var now = time.Now()
+		for i, payload := range payloads {
+			if payload.Status == policies.StatusPending && !schedules.Allows(payload.EffectivePayload, now) {
+				payloads[i].Status = policies.StatusScheduled
+			}
+		}
+
+		// The session user isn't known outside of a management session so the payloads of the user given in the query (or the enrolling user) are included
+		var upn = r.URL.Query().Get("upn")
+		if upn == "" {
+			upn = device.EnrolledBy.String
+		} else if _, err := srv.DB.GetUser(r.Context(), upn); err == sql.ErrNoRows {
+			http.Error(w, "user '"+upn+"' does not exist", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("[GetUser Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var userPayloads = make([]policies.ResultantPayload, 0)
+		var userRemoving = make([]db.GetDeployedPayloadsRow, 0)
+		if upn != "" {
+			effectiveUser, err := policies.UserPayloads(r.Context(), srv.DB, upn)
+			if err != nil {
+				log.Printf("[GetUsersPayloadCandidates Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			userPayloads, userRemoving, err = policies.Resultant(r.Context(), srv.DB, device.ID, null.String{String: upn, Valid: true}, effectiveUser)
+			if err != nil {
+				log.Printf("[GetDeployedPayloads Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"payloads":      payloads,
+			"removing":      removing,
+			"upn":           upn,
+			"user_payloads": userPayloads,
+			"user_removing": userRemoving,
+		}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// DeviceEffectivePreview returns how the device's effective payloads would change if its group membership was changed. Nothing is modified.
+func DeviceEffectivePreview(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := requestDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		var cmd DevicePreviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+			log.Printf("[JsonDecode Error]: %s\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		for _, groupID := range append(cmd.AddGroups, cmd.RemoveGroups...) {
+			if _, err := srv.DB.GetGroup(r.Context(), groupID); err == sql.ErrNoRows {
+				http.Error(w, "group '"+strconv.Itoa(int(groupID))+"' does not exist", http.StatusBadRequest)
+				return
+			} else if err != nil {
+				log.Printf("[GetGroup Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		before, err := policies.DevicePayloads(r.Context(), srv.DB, device.ID)
+		if err != nil {
+			log.Printf("[GetDevicesPayloadCandidates Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		after, err := policies.PreviewDevicePayloads(r.Context(), srv.DB, device.ID, cmd.AddGroups, cmd.RemoveGroups)
+		if err != nil {
+			log.Printf("[GetGroupsPayloadCandidates Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"payloads": after,
+			"changes":  policies.Diff(before, after),
+		}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// requestDevice returns the device in the request's URL. It writes the error response and returns false if the device doesn't exist.
+func requestDevice(srv *mattrax.Server, w http.ResponseWriter, r *http.Request) (db.Device, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return db.Device{}, false
+	}
+
+	device, err := srv.DB.GetDevice(r.Context(), int32(id))
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		return db.Device{}, false
+	} else if err != nil {
+		log.Printf("[GetDevice Error]: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return db.Device{}, false
+	}
+	return device, true
+}
@@ -1,34 +1,344 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/dynamicgroups"
+	"github.com/mattrax/Mattrax/internal/policies"
 )
 
+type GroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Priority    int16  `json:"priority"`
+	Rules       string `json:"rules"`
+	Timezone    string `json:"timezone"`
+}
+
+// Validate verifies the group's rules and timezone
+func (g GroupRequest) Validate() error {
+	if g.Rules != "" {
+		if err := dynamicgroups.ValidateRules(g.Rules); err != nil {
+			return err
+		}
+	}
+
+	if _, err := time.LoadLocation(g.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone '%s'", g.Timezone)
+	}
+	return nil
+}
+
+type GroupPolicyRequest struct {
+	NotBefore *time.Time        `json:"not_before"`
+	Windows   []policies.Window `json:"windows"`
+}
+
+type GroupDevicesRequest struct {
+	Devices []int32 `json:"devices"`
+}
+
 func Groups(srv *mattrax.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		users, err := srv.DB.GetGroups(r.Context())
+		if r.Method == http.MethodGet {
+			groups, err := srv.DB.GetGroups(r.Context())
+			if err != nil {
+				log.Printf("[GetGroups Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(groups); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			var cmd = GroupRequest{
+				Timezone: "UTC",
+			}
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if cmd.Name == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if err := cmd.Validate(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			var id int32
+			err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				if cmd.Rules != "" {
+					if err := dynamicgroups.ValidateReferences(ctx, q, cmd.Name, cmd.Rules); err != nil {
+						return err
+					}
+				}
+
+				var err error
+				if id, err = q.CreateGroup(ctx, db.CreateGroupParams(cmd)); err != nil || cmd.Rules == "" {
+					return err
+				}
+				return dynamicgroups.EvaluateGroup(ctx, q, id, cmd.Rules)
+			})
+			var refErr dynamicgroups.ReferenceError
+			if errors.As(err, &refErr) {
+				http.Error(w, refErr.Error(), http.StatusBadRequest)
+				return
+			} else if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[CreateGroup Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusCreated)
+			if err := json.NewEncoder(w).Encode(CreatedResponse{
+				ID: id,
+			}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+}
+
+func Group(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		group, err := srv.DB.GetGroup(r.Context(), int32(id))
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetGroup Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(group); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPatch {
+			var cmd = GroupRequest{
+				Name:        group.Name,
+				Description: group.Description,
+				Priority:    group.Priority,
+				Rules:       group.Rules,
+				Timezone:    group.Timezone,
+			}
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if cmd.Name == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if err := cmd.Validate(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			// Groups which become static keep their current members
+			err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				if cmd.Rules != "" {
+					if err := dynamicgroups.ValidateReferences(ctx, q, cmd.Name, cmd.Rules); err != nil {
+						return err
+					}
+				}
+
+				if err := q.UpdateGroup(ctx, db.UpdateGroupParams{
+					ID:          group.ID,
+					Name:        cmd.Name,
+					Description: cmd.Description,
+					Priority:    cmd.Priority,
+					Rules:       cmd.Rules,
+					Timezone:    cmd.Timezone,
+				}); err != nil || cmd.Rules == "" || cmd.Rules == group.Rules {
+					return err
+				}
+				return dynamicgroups.EvaluateGroup(ctx, q, group.ID, cmd.Rules)
+			})
+			var refErr dynamicgroups.ReferenceError
+			if errors.As(err, &refErr) {
+				http.Error(w, refErr.Error(), http.StatusBadRequest)
+				return
+			} else if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[UpdateGroup Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		} else if r.Method == http.MethodDelete {
+			// The groups devices have the groups policies removed on their next checkin
+			if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				if err := q.DeleteGroupDevices(ctx, group.ID); err != nil {
+					return err
+				}
+				if err := q.DeleteGroupPolicies(ctx, sql.NullInt32{Int32: group.ID, Valid: true}); err != nil {
+					return err
+				}
+				if err := q.DeleteGroupRollouts(ctx, group.ID); err != nil {
+					return err
+				}
+				if err := q.DeleteGroupAppAssignments(ctx, group.ID); err != nil {
+					return err
+				}
+				if err := q.DeleteGroupScripts(ctx, group.ID); err != nil {
+					return err
+				}
+				if err := q.DeleteGroupCertificateProfiles(ctx, group.ID); err != nil {
+					return err
+				}
+				if err := q.DeleteGroupLocalAdminProfiles(ctx, group.ID); err != nil {
+					return err
+				}
+				return q.DeleteGroup(ctx, group.ID)
+			}); err != nil {
+				log.Printf("[DeleteGroup Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+func GroupDevices(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		group, err := srv.DB.GetGroup(r.Context(), int32(id))
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetGroup Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			devices, err := srv.DB.GetGroupDevices(r.Context(), int32(id))
+			if err != nil {
+				log.Printf("[GetGroupDevices Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(devices); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
+		// The members of dynamic groups are managed by their rules
+		if group.Rules != "" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		var cmd GroupDevicesRequest
+		if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+			log.Printf("[JsonDecode Error]: %s\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			if err := srv.DB.AddGroupDevices(r.Context(), db.AddGroupDevicesParams{
+				GroupID:   int32(id),
+				DeviceIds: cmd.Devices,
+			}); err != nil {
+				log.Printf("[AddGroupDevices Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodDelete {
+			if err := srv.DB.RemoveGroupDevices(r.Context(), db.RemoveGroupDevicesParams{
+				GroupID:   int32(id),
+				DeviceIds: cmd.Devices,
+			}); err != nil {
+				log.Printf("[RemoveGroupDevices Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func GroupPolicies(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
 		if err != nil {
-			log.Printf("[GetGroups Error]: %s\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		policies, err := srv.DB.GetGroupPolicies(r.Context(), sql.NullInt32{Int32: int32(id), Valid: true})
+		if err != nil {
+			log.Printf("[GetGroupPolicies Error]: %s\n", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-		if err := json.NewEncoder(w).Encode(users); err != nil {
+		if err := json.NewEncoder(w).Encode(policies); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 	}
 }
 
-func Group(srv *mattrax.Server) http.HandlerFunc {
+// GroupPolicy attaches (PUT) or detaches (DELETE) a policy from the group. Attaching can optionally schedule when the policy is deployed.
+func GroupPolicy(srv *mattrax.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		id, err := strconv.Atoi(vars["id"])
@@ -36,8 +346,13 @@ func Group(srv *mattrax.Server) http.HandlerFunc {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		user, err := srv.DB.GetGroup(r.Context(), int32(id))
-		if err == sql.ErrNoRows {
+		policyID, err := strconv.Atoi(vars["policy"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if _, err := srv.DB.GetGroup(r.Context(), int32(id)); err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		} else if err != nil {
@@ -46,10 +361,131 @@ func Group(srv *mattrax.Server) http.HandlerFunc {
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-		if err := json.NewEncoder(w).Encode(user); err != nil {
+		if _, err := srv.DB.GetPolicy(r.Context(), int32(policyID)); err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetPolicy Error]: %s\n", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+
+		var nullGroupID, nullPolicyID = sql.NullInt32{Int32: int32(id), Valid: true}, sql.NullInt32{Int32: int32(policyID), Valid: true}
+		if r.Method == http.MethodGet {
+			notBefore, err := srv.DB.GetGroupPolicySchedule(r.Context(), db.GetGroupPolicyScheduleParams{
+				GroupID:  nullGroupID,
+				PolicyID: nullPolicyID,
+			})
+			if err == sql.ErrNoRows {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			} else if err != nil {
+				log.Printf("[GetGroupPolicySchedule Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			windows, err := srv.DB.GetGroupPolicyWindows(r.Context(), db.GetGroupPolicyWindowsParams{
+				GroupID:  int32(id),
+				PolicyID: int32(policyID),
+			})
+			if err != nil {
+				log.Printf("[GetGroupPolicyWindows Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			var cmd = GroupPolicyRequest{
+				Windows: make([]policies.Window, len(windows)),
+			}
+			if notBefore.Valid {
+				cmd.NotBefore = &notBefore.Time
+			}
+			for i, window := range windows {
+				cmd.Windows[i] = policies.Window{
+					Weekday: window.Weekday,
+					Start:   window.StartMinute,
+					End:     window.EndMinute,
+				}
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(cmd); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			return
+		} else if r.Method == http.MethodPut {
+			// The schedule is optional so an empty body attaches the policy without one
+			var cmd GroupPolicyRequest
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil && err != io.EOF {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			for _, window := range cmd.Windows {
+				if err := window.Validate(); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+
+			if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				if err := q.AttachGroupPolicy(ctx, db.AttachGroupPolicyParams{
+					GroupID:  nullGroupID,
+					PolicyID: nullPolicyID,
+				}); err != nil {
+					return err
+				}
+
+				var notBefore sql.NullTime
+				if cmd.NotBefore != nil {
+					notBefore = sql.NullTime{Time: *cmd.NotBefore, Valid: true}
+				}
+				if err := q.SetGroupPolicySchedule(ctx, db.SetGroupPolicyScheduleParams{
+					GroupID:   nullGroupID,
+					PolicyID:  nullPolicyID,
+					NotBefore: notBefore,
+				}); err != nil {
+					return err
+				}
+
+				if err := q.DeleteGroupPolicyWindows(ctx, db.DeleteGroupPolicyWindowsParams{
+					GroupID:  int32(id),
+					PolicyID: int32(policyID),
+				}); err != nil {
+					return err
+				}
+
+				for _, window := range cmd.Windows {
+					if err := q.CreateGroupPolicyWindow(ctx, db.CreateGroupPolicyWindowParams{
+						GroupID:     int32(id),
+						PolicyID:    int32(policyID),
+						Weekday:     window.Weekday,
+						StartMinute: window.Start,
+						EndMinute:   window.End,
+					}); err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				log.Printf("[AttachGroupPolicy Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodDelete {
+			if err := srv.DB.DetachGroupPolicy(r.Context(), db.DetachGroupPolicyParams{
+				GroupID:  nullGroupID,
+				PolicyID: nullPolicyID,
+			}); err != nil {
+				log.Printf("[DetachGroupPolicy Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
 	}
 }
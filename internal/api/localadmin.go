@@ -0,0 +1,333 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/localadmin"
+)
+
+type LocalAdminProfileRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	localadmin.Profile
+	GroupID int32 `json:"group_id"`
+}
+
+// validate verifies the local admin profile and that its group exists. It writes the error response and returns false if the profile is invalid.
+func (l LocalAdminProfileRequest) validate(srv *mattrax.Server, w http.ResponseWriter, r *http.Request) bool {
+	if l.Name == "" {
+		http.Error(w, "the local admin profile must have a name", http.StatusBadRequest)
+		return false
+	} else if err := l.Profile.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	if _, err := srv.DB.GetGroup(r.Context(), l.GroupID); err == sql.ErrNoRows {
+		http.Error(w, "group does not exist", http.StatusBadRequest)
+		return false
+	} else if err != nil {
+		log.Printf("[GetGroup Error]: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// LocalAdminProfiles lists or creates the profiles which manage a local administrator account with a unique password on each device in their group.
+// Unset password settings default to a 20 character password rotated every 30 days and 24 hours after it is revealed.
+func LocalAdminProfiles(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			localAdminProfiles, err := srv.DB.GetLocalAdminProfiles(r.Context())
+			if err != nil {
+				log.Printf("[GetLocalAdminProfiles Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(localAdminProfiles); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			var cmd = LocalAdminProfileRequest{
+				Profile: localadmin.DefaultProfile(),
+			}
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxJSONBodySize)).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if !cmd.validate(srv, w, r) {
+				return
+			}
+
+			id, err := srv.DB.CreateLocalAdminProfile(r.Context(), db.CreateLocalAdminProfileParams{
+				Name:                 cmd.Name,
+				Description:          cmd.Description,
+				AccountName:          cmd.AccountName,
+				PasswordLength:       cmd.PasswordLength,
+				RotationDays:         cmd.RotationDays,
+				RotateAfterViewHours: cmd.RotateAfterViewHours,
+				GroupID:              cmd.GroupID,
+			})
+			if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[CreateLocalAdminProfile Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusCreated)
+			if err := json.NewEncoder(w).Encode(CreatedResponse{
+				ID: id,
+			}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+}
+
+// LocalAdminProfile returns, updates or deletes a local admin profile. The account name can't be changed as devices would keep the previous account.
+// Deleting a profile deletes the passwords it set so the accounts should be removed from devices first.
+func LocalAdminProfile(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		localAdminProfile, err := srv.DB.GetLocalAdminProfile(r.Context(), int32(id))
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetLocalAdminProfile Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(localAdminProfile); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPatch {
+			var cmd = LocalAdminProfileRequest{
+				Name:        localAdminProfile.Name,
+				Description: localAdminProfile.Description,
+				Profile: localadmin.Profile{
+					AccountName:          localAdminProfile.AccountName,
+					PasswordLength:       localAdminProfile.PasswordLength,
+					RotationDays:         localAdminProfile.RotationDays,
+					RotateAfterViewHours: localAdminProfile.RotateAfterViewHours,
+				},
+				GroupID: localAdminProfile.GroupID,
+			}
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxJSONBodySize)).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if cmd.AccountName != localAdminProfile.AccountName {
+				http.Error(w, "the account name can't be changed", http.StatusBadRequest)
+				return
+			} else if !cmd.validate(srv, w, r) {
+				return
+			}
+
+			err := srv.DB.UpdateLocalAdminProfile(r.Context(), db.UpdateLocalAdminProfileParams{
+				ID:                   localAdminProfile.ID,
+				Name:                 cmd.Name,
+				Description:          cmd.Description,
+				PasswordLength:       cmd.PasswordLength,
+				RotationDays:         cmd.RotationDays,
+				RotateAfterViewHours: cmd.RotateAfterViewHours,
+				GroupID:              cmd.GroupID,
+			})
+			if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[UpdateLocalAdminProfile Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		} else if r.Method == http.MethodDelete {
+			if err := srv.DB.DeleteLocalAdminProfile(r.Context(), localAdminProfile.ID); err != nil {
+				log.Printf("[DeleteLocalAdminProfile Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// DeviceLocalAdmins returns the state of the device's managed local administrator accounts without their passwords
+func DeviceLocalAdmins(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := requestDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		passwords, err := srv.DB.GetDeviceLocalAdminPasswords(r.Context(), device.ID)
+		if err != nil {
+			log.Printf("[GetDeviceLocalAdminPasswords Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(passwords); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// requestLocalAdminProfile returns the local admin profile in the request's URL. It writes the error response and returns false if the profile doesn't exist.
+func requestLocalAdminProfile(srv *mattrax.Server, w http.ResponseWriter, r *http.Request) (db.LocalAdminProfile, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["profile"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return db.LocalAdminProfile{}, false
+	}
+
+	localAdminProfile, err := srv.DB.GetLocalAdminProfile(r.Context(), int32(id))
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		return db.LocalAdminProfile{}, false
+	} else if err != nil {
+		log.Printf("[GetLocalAdminProfile Error]: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return db.LocalAdminProfile{}, false
+	}
+	return localAdminProfile, true
+}
+
+// DeviceLocalAdminPassword reveals the device's local administrator password. Only administrators can reveal passwords and they must give a reason which is logged with their access.
+// The password is rotated after the profile's rotate after view hours.
+func DeviceLocalAdminPassword(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := requestDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		localAdminProfile, ok := requestLocalAdminProfile(srv, w, r)
+		if !ok {
+			return
+		}
+
+		upn, ok := requireAdministrator(srv, w, r)
+		if !ok {
+			return
+		}
+
+		var cmd RevealRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxJSONBodySize)).Decode(&cmd); err != nil {
+			log.Printf("[JsonDecode Error]: %s\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		} else if cmd.Reason == "" {
+			http.Error(w, "a reason must be given to reveal the password", http.StatusBadRequest)
+			return
+		}
+
+		var revealed localadmin.Revealed
+		if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+			var err error
+			revealed, err = localadmin.Reveal(ctx, q, srv.Secrets, device.ID, localAdminProfile, upn, cmd.Reason)
+			return err
+		}); err == localadmin.ErrNoPassword {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[RevealLocalAdminPassword Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(revealed); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// DeviceLocalAdminRotate rotates the device's local administrator password on its next checkin
+func DeviceLocalAdminRotate(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := requestDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		localAdminProfile, ok := requestLocalAdminProfile(srv, w, r)
+		if !ok {
+			return
+		}
+
+		if err := srv.DB.ScheduleLocalAdminRotation(r.Context(), db.ScheduleLocalAdminRotationParams{
+			DeviceID:  device.ID,
+			ProfileID: localAdminProfile.ID,
+			RotateAt:  sql.NullTime{Time: time.Now(), Valid: true},
+		}); err != nil {
+			log.Printf("[ScheduleLocalAdminRotation Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeviceLocalAdminAccess returns who revealed the device's local administrator passwords and why
+func DeviceLocalAdminAccess(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := requestDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		access, err := srv.DB.GetLocalAdminPasswordAccess(r.Context(), device.ID)
+		if err != nil {
+			log.Printf("[GetLocalAdminPasswordAccess Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(access); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -1,14 +1,22 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"log"
 	"net/http"
 	"strings"
 
 	"github.com/gorilla/mux"
 	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/authentication"
+	"github.com/mattrax/Mattrax/internal/db"
 )
 
+type contextKey int
+
+const claimsContextKey contextKey = iota
+
 func Headers(srv *mattrax.Server) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -27,12 +35,26 @@ func Headers(srv *mattrax.Server) mux.MiddlewareFunc {
 				w.WriteHeader(http.StatusNoContent)
 				return
 			}
-			r.Body = http.MaxBytesReader(w, r.Body, MaxJSONBodySize)
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// uploadRoutes are the names of the routes which accept uploads larger than MaxJSONBodySize. Their handlers limit the size of the request body themselves.
+var uploadRoutes = map[string]bool{
+	appsRoute: true,
+}
+
+// LimitBody limits the size of request bodies to MaxJSONBodySize unless the request is to an upload route
+func LimitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route == nil || !uploadRoutes[route.GetName()] {
+			r.Body = http.MaxBytesReader(w, r.Body, MaxJSONBodySize)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func RequireAuthentication(srv *mattrax.Server) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -54,7 +76,32 @@ func RequireAuthentication(srv *mattrax.Server) mux.MiddlewareFunc {
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsContextKey, claims)))
 		})
 	}
 }
+
+// requestAuthor returns the user who made the authenticated request
+func requestAuthor(r *http.Request) string {
+	claims, _ := r.Context().Value(claimsContextKey).(authentication.AuthClaims)
+	return claims.Subject
+}
+
+// requireAdministrator returns the user who made the request if they are still an administrator as their token outlives them being removed as one.
+// It is used before revealing secrets. It writes the error response and returns false if they aren't.
+func requireAdministrator(srv *mattrax.Server, w http.ResponseWriter, r *http.Request) (string, bool) {
+	var upn = requestAuthor(r)
+	user, err := srv.DB.GetUser(r.Context(), upn)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusForbidden)
+		return "", false
+	} else if err != nil {
+		log.Printf("[GetUser Error]: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return "", false
+	} else if user.PermissionLevel != db.UserPermissionLevelAdministrator {
+		w.WriteHeader(http.StatusForbidden)
+		return "", false
+	}
+	return upn, true
+}
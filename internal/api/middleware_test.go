@@ -0,0 +1,73 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/apps"
+)
+
+func TestAppsRouteIsUploadRoute(t *testing.T) {
+	var srv = &mattrax.Server{Router: mux.NewRouter()}
+	Mount(srv)
+
+	route := srv.Router.Get(appsRoute)
+	if route == nil {
+		t.Fatalf("the %q route isn't mounted", appsRoute)
+	} else if path, _ := route.GetPathTemplate(); path != "/api/apps" {
+		t.Fatalf("the %q route has the path %q, expected /api/apps", appsRoute, path)
+	} else if !uploadRoutes[appsRoute] {
+		t.Fatalf("the %q route isn't an upload route", appsRoute)
+	}
+}
+
+func TestLimitBody(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mattrax-apps-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var r = mux.NewRouter()
+	r.Use(LimitBody)
+	r.HandleFunc("/apps", func(w http.ResponseWriter, r *http.Request) {
+		_, size, err := apps.Store(dir, http.MaxBytesReader(w, r.Body, maxAppSize))
+		if err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Write([]byte(strconv.FormatInt(size, 10)))
+	}).Name(appsRoute)
+	r.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.Copy(ioutil.Discard, r.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+	})
+
+	var body = make([]byte, MaxJSONBodySize+1<<20)
+	var tests = []struct {
+		path   string
+		status int
+	}{
+		{"/apps", http.StatusOK},
+		{"/json", http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, bytes.NewReader(body)))
+		if w.Code != tt.status {
+			t.Errorf("uploading %d bytes to %s returned %d, expected %d", len(body), tt.path, w.Code, tt.status)
+		} else if tt.status == http.StatusOK && w.Body.String() != strconv.Itoa(len(body)) {
+			t.Errorf("uploading %d bytes to %s stored %s bytes", len(body), tt.path, w.Body.String())
+		}
+	}
+}
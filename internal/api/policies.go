@@ -1,34 +1,453 @@
 package api
 
 import (
+	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"regexp"
 	"strconv"
 
 	"github.com/gorilla/mux"
 	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/catalog"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/policies"
+	"github.com/mattrax/Mattrax/pkg/syncml"
 )
 
+type PolicyRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Priority    int16  `json:"priority"`
+}
+
+// localAdminPasswordURIRegex matches the Accounts CSP nodes which set the password of a local account
+var localAdminPasswordURIRegex = regexp.MustCompile(`(?i)/Vendor/MSFT/Accounts/Users/[^/]+/Password$`)
+
+type PayloadRequest struct {
+	URI    string `json:"uri"`
+	Format string `json:"format"`
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	Exec   bool   `json:"exec"`
+}
+
+// Normalise converts the value to its canonical representation in the payload's format
+func (p *PayloadRequest) Normalise() {
+	p.Value = syncml.NormaliseValue(p.Format, p.Value)
+}
+
+// Validate verifies the payload can be deployed to a device and matches the setting described by the catalog
+func (p PayloadRequest) Validate(catalog *catalog.Service) error {
+	if err := syncml.ValidateURI(p.URI); err != nil {
+		return err
+	} else if err := syncml.ValidateFormat(p.Format, p.Value); err != nil {
+		return err
+	} else if err := syncml.ValidateType(p.Type); err != nil {
+		return err
+	} else if localAdminPasswordURIRegex.MatchString(p.URI) {
+		return errors.New("local account passwords would be stored in plaintext, use a local admin profile instead")
+	}
+	return catalog.Validate(p.URI, p.Format, p.Value, p.Exec)
+}
+
+type CreatedResponse struct {
+	ID int32 `json:"id"`
+}
+
 func Policies(srv *mattrax.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		users, err := srv.DB.GetPolicies(r.Context())
+		if r.Method == http.MethodGet {
+			policies, err := srv.DB.GetPolicies(r.Context())
+			if err != nil {
+				log.Printf("[GetPolicies Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(policies); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			var cmd PolicyRequest
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if cmd.Name == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			var id int32
+			err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				var err error
+				if id, err = q.CreatePolicy(ctx, db.CreatePolicyParams(cmd)); err != nil {
+					return err
+				}
+				_, err = policies.Snapshot(ctx, q, id, requestAuthor(r))
+				return err
+			})
+			if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[CreatePolicy Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusCreated)
+			if err := json.NewEncoder(w).Encode(CreatedResponse{
+				ID: id,
+			}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+}
+
+func Policy(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		policy, err := srv.DB.GetPolicy(r.Context(), int32(id))
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetPolicy Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(policy); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPatch {
+			var cmd = PolicyRequest{
+				Name:        policy.Name,
+				Description: policy.Description,
+				Priority:    policy.Priority,
+			}
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if cmd.Name == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				if err := policies.Baseline(ctx, q, policy.ID); err != nil {
+					return err
+				}
+
+				if err := q.UpdatePolicy(ctx, db.UpdatePolicyParams{
+					ID:          policy.ID,
+					Name:        cmd.Name,
+					Description: cmd.Description,
+					Priority:    cmd.Priority,
+				}); err != nil {
+					return err
+				}
+				_, err := policies.Snapshot(ctx, q, policy.ID, requestAuthor(r))
+				return err
+			})
+			if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[UpdatePolicy Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		} else if r.Method == http.MethodDelete {
+			if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				return policies.Delete(ctx, q, policy.ID)
+			}); err != nil {
+				log.Printf("[DeletePolicy Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+func PolicyPayloads(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var policyID = sql.NullInt32{Int32: int32(id), Valid: true}
+
+		if _, err := srv.DB.GetPolicy(r.Context(), int32(id)); err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetPolicy Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			payloads, err := srv.DB.GetPoliciesPayloads(r.Context(), policyID)
+			if err != nil {
+				log.Printf("[GetPoliciesPayloads Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(payloads); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			var cmd PayloadRequest
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			cmd.Normalise()
+			if err := cmd.Validate(srv.Catalog); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			var payloadID int32
+			err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				if err := policies.Baseline(ctx, q, int32(id)); err != nil {
+					return err
+				}
+
+				var err error
+				if payloadID, err = q.CreatePolicyPayload(ctx, db.CreatePolicyPayloadParams{
+					PolicyID: policyID,
+					Uri:      cmd.URI,
+					Format:   cmd.Format,
+					Type:     cmd.Type,
+					Value:    cmd.Value,
+					Exec:     cmd.Exec,
+				}); err != nil {
+					return err
+				}
+				_, err = policies.Snapshot(ctx, q, int32(id), requestAuthor(r))
+				return err
+			})
+			if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[CreatePolicyPayload Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusCreated)
+			if err := json.NewEncoder(w).Encode(CreatedResponse{
+				ID: payloadID,
+			}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+}
+
+func PolicyPayload(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		payloadID, err := strconv.Atoi(vars["payload"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		payload, err := srv.DB.GetPolicyPayload(r.Context(), db.GetPolicyPayloadParams{
+			ID:       int32(payloadID),
+			PolicyID: sql.NullInt32{Int32: int32(id), Valid: true},
+		})
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetPolicyPayload Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(payload); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPut {
+			var cmd PayloadRequest
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			cmd.Normalise()
+			if err := cmd.Validate(srv.Catalog); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			// A payload with a new uri replaces the old one so the old node is deleted from devices.
+			// Otherwise the devices cache of the payload is cleared so it is redeployed on their next checkin.
+			var newPayloadID = payload.ID
+			if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				if err := policies.Baseline(ctx, q, payload.PolicyID.Int32); err != nil {
+					return err
+				}
+
+				if cmd.URI != payload.Uri {
+					if err := q.DetachPolicyPayload(ctx, payload.ID); err != nil {
+						return err
+					}
+
+					if err := q.DeleteOrphanedPayloads(ctx); err != nil {
+						return err
+					}
+
+					var err error
+					if newPayloadID, err = q.CreatePolicyPayload(ctx, db.CreatePolicyPayloadParams{
+						PolicyID: payload.PolicyID,
+						Uri:      cmd.URI,
+						Format:   cmd.Format,
+						Type:     cmd.Type,
+						Value:    cmd.Value,
+						Exec:     cmd.Exec,
+					}); err != nil {
+						return err
+					}
+				} else {
+					if err := q.UpdatePolicyPayload(ctx, db.UpdatePolicyPayloadParams{
+						ID:     payload.ID,
+						Format: cmd.Format,
+						Type:   cmd.Type,
+						Value:  cmd.Value,
+						Exec:   cmd.Exec,
+					}); err != nil {
+						return err
+					}
+
+					if err := q.InvalidatePayloadCache(ctx, sql.NullInt32{Int32: payload.ID, Valid: true}); err != nil {
+						return err
+					}
+				}
+
+				_, err := policies.Snapshot(ctx, q, payload.PolicyID.Int32, requestAuthor(r))
+				return err
+			}); isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[UpdatePolicyPayload Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(CreatedResponse{
+				ID: newPayloadID,
+			}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodDelete {
+			if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				if err := policies.Baseline(ctx, q, payload.PolicyID.Int32); err != nil {
+					return err
+				}
+
+				if err := q.DetachPolicyPayload(ctx, payload.ID); err != nil {
+					return err
+				}
+				if err := q.DeleteOrphanedPayloads(ctx); err != nil {
+					return err
+				}
+				_, err := policies.Snapshot(ctx, q, payload.PolicyID.Int32, requestAuthor(r))
+				return err
+			}); err != nil {
+				log.Printf("[DetachPolicyPayload Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+type VersionResponse struct {
+	Version int32 `json:"version"`
+}
+
+func PolicyVersions(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
 		if err != nil {
-			log.Printf("[GetPolicies Error]: %s\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		versions, err := srv.DB.GetPolicyVersions(r.Context(), int32(id))
+		if err != nil {
+			log.Printf("[GetPolicyVersions Error]: %s\n", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-		if err := json.NewEncoder(w).Encode(users); err != nil {
+		if err := json.NewEncoder(w).Encode(versions); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 	}
 }
 
-func Policy(srv *mattrax.Server) http.HandlerFunc {
+func PolicyVersion(srv *mattrax.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		id, err := strconv.Atoi(vars["id"])
@@ -36,7 +455,48 @@ func Policy(srv *mattrax.Server) http.HandlerFunc {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		user, err := srv.DB.GetPolicy(r.Context(), int32(id))
+		versionNumber, err := strconv.Atoi(vars["version"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		version, err := srv.DB.GetPolicyVersion(r.Context(), db.GetPolicyVersionParams{
+			PolicyID: int32(id),
+			Version:  int32(versionNumber),
+		})
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetPolicyVersion Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(version); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// PolicyVersionDiff compares a version of the policy to the version in the "to" query parameter or otherwise the latest version
+func PolicyVersionDiff(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fromNumber, err := strconv.Atoi(vars["version"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		policy, err := srv.DB.GetPolicy(r.Context(), int32(id))
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusNotFound)
 			return
@@ -46,8 +506,172 @@ func Policy(srv *mattrax.Server) http.HandlerFunc {
 			return
 		}
 
+		var toNumber = int(policy.Version)
+		if to := r.URL.Query().Get("to"); to != "" {
+			if toNumber, err = strconv.Atoi(to); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		var versions [2]db.PolicyVersion
+		for i, number := range []int{fromNumber, toNumber} {
+			versions[i], err = srv.DB.GetPolicyVersion(r.Context(), db.GetPolicyVersionParams{
+				PolicyID: policy.ID,
+				Version:  int32(number),
+			})
+			if err == sql.ErrNoRows {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			} else if err != nil {
+				log.Printf("[GetPolicyVersion Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		diff, err := policies.DiffVersions(versions[0], versions[1])
+		if err != nil {
+			log.Printf("[DiffVersions Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(diff); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// PolicyVersionRollback restores the policy to the version. This is stored as a new version and devices are redeployed on their next checkin.
+func PolicyVersionRollback(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		versionNumber, err := strconv.Atoi(vars["version"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if _, err := srv.DB.GetPolicy(r.Context(), int32(id)); err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetPolicy Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var version int32
+		err = srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+			var err error
+			version, err = policies.Rollback(ctx, q, int32(id), int32(versionNumber), requestAuthor(r))
+			return err
+		})
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if isUniqueViolation(err) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		} else if err != nil {
+			log.Printf("[RollbackPolicy Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(VersionResponse{
+			Version: version,
+		}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// maxPayloadUploadSize is the largest file which can be uploaded as a payload. Once base64 encoded it must fit in a single SyncML message.
+const maxPayloadUploadSize = 256 * 1024
+
+// PolicyPayloadUpload sets the payload at the "uri" query parameter to the base64 encoded request body. The "format" query parameter can be "b64" (the default) or "bin".
+func PolicyPayloadUpload(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if _, err := srv.DB.GetPolicy(r.Context(), int32(id)); err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetPolicy Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var cmd = PayloadRequest{
+			URI:    r.URL.Query().Get("uri"),
+			Format: r.URL.Query().Get("format"),
+			Type:   r.URL.Query().Get("type"),
+		}
+		if cmd.Format == "" {
+			cmd.Format = "b64"
+		} else if cmd.Format != "b64" && cmd.Format != "bin" {
+			http.Error(w, "uploaded payloads must use the 'b64' or 'bin' format", http.StatusBadRequest)
+			return
+		}
+
+		data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadUploadSize))
+		if err != nil {
+			http.Error(w, "the uploaded file must not be larger than "+strconv.Itoa(maxPayloadUploadSize)+" bytes", http.StatusRequestEntityTooLarge)
+			return
+		}
+		cmd.Value = base64.StdEncoding.EncodeToString(data)
+
+		if err := cmd.Validate(srv.Catalog); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var ids []int32
+		if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+			if err := policies.Baseline(ctx, q, int32(id)); err != nil {
+				return err
+			}
+
+			var err error
+			if ids, err = policies.SetPayloads(ctx, q, int32(id), []policies.VersionPayload{
+				{
+					Uri:    cmd.URI,
+					Format: cmd.Format,
+					Type:   cmd.Type,
+					Value:  cmd.Value,
+				},
+			}); err != nil {
+				return err
+			}
+			_, err = policies.Snapshot(ctx, q, int32(id), requestAuthor(r))
+			return err
+		}); err != nil {
+			log.Printf("[SetPayloads Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-		if err := json.NewEncoder(w).Encode(user); err != nil {
+		if err := json.NewEncoder(w).Encode(CreatedResponse{
+			ID: ids[0],
+		}); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/policies"
+	"github.com/mattrax/Mattrax/internal/profiles"
+)
+
+// renderProfile renders the profile's payloads and validates them like payloads added by hand. It writes the error response and returns false if the profile is invalid.
+func renderProfile(srv *mattrax.Server, w http.ResponseWriter, profile profiles.Profile) ([]policies.VersionPayload, bool) {
+	payloads, err := profile.Payloads()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	for _, payload := range payloads {
+		if err := (PayloadRequest{
+			URI:    payload.Uri,
+			Format: payload.Format,
+			Type:   payload.Type,
+			Value:  payload.Value,
+			Exec:   payload.Exec,
+		}).Validate(srv.Catalog); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	return payloads, true
+}
+
+// ProfileRender returns the payloads a profile renders to without adding them to a policy
+func ProfileRender(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var cmd profiles.Profile
+		if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+			log.Printf("[JsonDecode Error]: %s\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		payloads, ok := renderProfile(srv, w, cmd)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(payloads); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// PolicyProfile adds the payloads rendered from the profile to the policy. Adding a profile with the same name (eg. SSID) again updates its payloads.
+func PolicyProfile(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if _, err := srv.DB.GetPolicy(r.Context(), int32(id)); err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetPolicy Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var cmd profiles.Profile
+		if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+			log.Printf("[JsonDecode Error]: %s\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		payloads, ok := renderProfile(srv, w, cmd)
+		if !ok {
+			return
+		}
+
+		var ids []int32
+		if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+			if err := policies.Baseline(ctx, q, int32(id)); err != nil {
+				return err
+			}
+
+			var err error
+			if ids, err = policies.SetPayloads(ctx, q, int32(id), payloads); err != nil {
+				return err
+			}
+			_, err = policies.Snapshot(ctx, q, int32(id), requestAuthor(r))
+			return err
+		}); err != nil {
+			log.Printf("[SetPayloads Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(PayloadsResponse{
+			IDs: ids,
+		}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/rollouts"
+)
+
+type RolloutRequest struct {
+	PolicyID         int32            `json:"policy_id"`
+	GroupID          int32            `json:"group_id"`
+	FailureThreshold int16            `json:"failure_threshold"`
+	SuccessThreshold int16            `json:"success_threshold"`
+	Stages           []rollouts.Stage `json:"stages"`
+}
+
+func Rollouts(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			rollouts, err := srv.DB.GetRollouts(r.Context())
+			if err != nil {
+				log.Printf("[GetRollouts Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(rollouts); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			var cmd = RolloutRequest{
+				FailureThreshold: 10,
+				SuccessThreshold: 90,
+			}
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if cmd.FailureThreshold < 0 || cmd.FailureThreshold > 100 || cmd.SuccessThreshold < 0 || cmd.SuccessThreshold > 100 {
+				http.Error(w, "thresholds must be percentages between 0 and 100", http.StatusBadRequest)
+				return
+			} else if err := rollouts.ValidateStages(cmd.Stages); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if _, err := srv.DB.GetPolicy(r.Context(), cmd.PolicyID); err == sql.ErrNoRows {
+				http.Error(w, "policy does not exist", http.StatusBadRequest)
+				return
+			} else if err != nil {
+				log.Printf("[GetPolicy Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			var groupIDs = []int32{cmd.GroupID}
+			for _, stage := range cmd.Stages {
+				if stage.RingGroupID != 0 {
+					groupIDs = append(groupIDs, stage.RingGroupID)
+				}
+			}
+
+			for _, groupID := range groupIDs {
+				if _, err := srv.DB.GetGroup(r.Context(), groupID); err == sql.ErrNoRows {
+					http.Error(w, "group '"+strconv.Itoa(int(groupID))+"' does not exist", http.StatusBadRequest)
+					return
+				} else if err != nil {
+					log.Printf("[GetGroup Error]: %s\n", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+			}
+
+			// The policy can't be rolled out to a group which it is already assigned to or being rolled out to
+			groupPolicies, err := srv.DB.GetGroupPolicies(r.Context(), sql.NullInt32{Int32: cmd.GroupID, Valid: true})
+			if err != nil {
+				log.Printf("[GetGroupPolicies Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			for _, policy := range groupPolicies {
+				if policy.ID == cmd.PolicyID {
+					w.WriteHeader(http.StatusConflict)
+					return
+				}
+			}
+
+			if _, err := srv.DB.GetUnfinishedRollout(r.Context(), db.GetUnfinishedRolloutParams{
+				PolicyID: cmd.PolicyID,
+				GroupID:  cmd.GroupID,
+			}); err == nil {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != sql.ErrNoRows {
+				log.Printf("[GetUnfinishedRollout Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			var id int32
+			if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				var err error
+				if id, err = q.CreateRollout(ctx, db.CreateRolloutParams{
+					PolicyID:         cmd.PolicyID,
+					GroupID:          cmd.GroupID,
+					FailureThreshold: cmd.FailureThreshold,
+					SuccessThreshold: cmd.SuccessThreshold,
+				}); err != nil {
+					return err
+				}
+
+				for i, stage := range cmd.Stages {
+					if err := q.CreateRolloutStage(ctx, db.CreateRolloutStageParams{
+						RolloutID:   id,
+						Stage:       int16(i),
+						Percentage:  stage.Percentage,
+						RingGroupID: sql.NullInt32{Int32: stage.RingGroupID, Valid: stage.RingGroupID != 0},
+					}); err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				log.Printf("[CreateRollout Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusCreated)
+			if err := json.NewEncoder(w).Encode(CreatedResponse{
+				ID: id,
+			}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+}
+
+func Rollout(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		rollout, err := srv.DB.GetRollout(r.Context(), int32(id))
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetRollout Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		stages, err := srv.DB.GetRolloutStages(r.Context(), rollout.ID)
+		if err != nil {
+			log.Printf("[GetRolloutStages Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		results, err := srv.DB.GetRolloutResults(r.Context(), rollout.ID)
+		if err != nil {
+			log.Printf("[GetRolloutResults Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"rollout": rollout,
+			"stages":  stages,
+			"results": results,
+		}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// RolloutAction promotes, pauses, resumes or aborts the rollout. Promoting with the "force" query parameter skips the success threshold.
+// Aborted rollouts are removed from the devices which received them on their next checkin.
+func RolloutAction(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		rollout, err := srv.DB.GetRollout(r.Context(), int32(id))
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetRollout Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if vars["action"] == "promote" {
+			err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				return rollouts.Promote(ctx, q, rollout, r.URL.Query().Get("force") == "true")
+			})
+			if err == rollouts.ErrFinished || err == rollouts.ErrThresholdNotMet {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[PromoteRollout Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var state db.RolloutState
+		var allowed bool
+		switch vars["action"] {
+		case "pause":
+			state, allowed = db.RolloutStatePaused, rollout.State == db.RolloutStateActive
+		case "resume":
+			state, allowed = db.RolloutStateActive, rollout.State == db.RolloutStatePaused
+		case "abort":
+			state, allowed = db.RolloutStateAborted, rollout.State == db.RolloutStateActive || rollout.State == db.RolloutStatePaused
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if !allowed {
+			http.Error(w, "the rollout can't be changed from its '"+string(rollout.State)+"' state", http.StatusConflict)
+			return
+		}
+
+		if err := srv.DB.SetRolloutState(r.Context(), db.SetRolloutStateParams{
+			ID:    rollout.ID,
+			State: state,
+		}); err != nil {
+			log.Printf("[SetRolloutState Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/scripts"
+)
+
+type ScriptRequest struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Content     string            `json:"content"`
+	RunContext  db.ScriptContext  `json:"run_context"`
+	Schedule    db.ScriptSchedule `json:"schedule"`
+	GroupID     int32             `json:"group_id"`
+}
+
+// validate verifies the script and that its group exists. It writes the error response and returns false if the script is invalid.
+func (s ScriptRequest) validate(srv *mattrax.Server, w http.ResponseWriter, r *http.Request) bool {
+	if s.Name == "" {
+		http.Error(w, "the script must have a name", http.StatusBadRequest)
+		return false
+	} else if err := scripts.Validate(s.Content, s.RunContext, s.Schedule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	if _, err := srv.DB.GetGroup(r.Context(), s.GroupID); err == sql.ErrNoRows {
+		http.Error(w, "group does not exist", http.StatusBadRequest)
+		return false
+	} else if err != nil {
+		log.Printf("[GetGroup Error]: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// Scripts lists the scripts (without their content) or creates a script
+func Scripts(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			scripts, err := srv.DB.GetScripts(r.Context())
+			if err != nil {
+				log.Printf("[GetScripts Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(scripts); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			var cmd ScriptRequest
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*scripts.MaxSize)).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if !cmd.validate(srv, w, r) {
+				return
+			}
+
+			id, err := srv.DB.CreateScript(r.Context(), db.CreateScriptParams{
+				Name:        cmd.Name,
+				Description: cmd.Description,
+				Content:     cmd.Content,
+				RunContext:  cmd.RunContext,
+				Schedule:    cmd.Schedule,
+				GroupID:     cmd.GroupID,
+			})
+			if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[CreateScript Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusCreated)
+			if err := json.NewEncoder(w).Encode(CreatedResponse{
+				ID: id,
+			}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+}
+
+// Script returns, updates or deletes a script. Changing the content of a script runs it again on every device.
+func Script(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		script, err := srv.DB.GetScript(r.Context(), int32(id))
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetScript Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(script); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPatch {
+			var cmd = ScriptRequest{
+				Name:        script.Name,
+				Description: script.Description,
+				Content:     script.Content,
+				RunContext:  script.RunContext,
+				Schedule:    script.Schedule,
+				GroupID:     script.GroupID,
+			}
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*scripts.MaxSize)).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if !cmd.validate(srv, w, r) {
+				return
+			}
+
+			err := srv.DB.UpdateScript(r.Context(), db.UpdateScriptParams{
+				ID:          script.ID,
+				Name:        cmd.Name,
+				Description: cmd.Description,
+				Content:     cmd.Content,
+				RunContext:  cmd.RunContext,
+				Schedule:    cmd.Schedule,
+				GroupID:     cmd.GroupID,
+			})
+			if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[UpdateScript Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		} else if r.Method == http.MethodDelete {
+			if err := srv.DB.DeleteScript(r.Context(), script.ID); err != nil {
+				log.Printf("[DeleteScript Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// ScriptRuns returns the result of the last run of the script on each device. Only failed runs (with a non-zero exit code) are returned if failed is set.
+func ScriptRuns(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		runs, err := srv.DB.GetScriptRuns(r.Context(), db.GetScriptRunsParams{
+			ScriptID: int32(id),
+			Failed:   r.URL.Query().Get("failed") == "true",
+		})
+		if err != nil {
+			log.Printf("[GetScriptRuns Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(runs); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// ScriptFailures returns the failed runs of every script
+func ScriptFailures(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		runs, err := srv.DB.GetFailedScriptRuns(r.Context())
+		if err != nil {
+			log.Printf("[GetFailedScriptRuns Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(runs); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// DeviceScripts returns the result of the last run of each script on the device
+func DeviceScripts(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		runs, err := srv.DB.GetDeviceScriptRuns(r.Context(), int32(id))
+		if err != nil {
+			log.Printf("[GetDeviceScriptRuns Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(runs); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
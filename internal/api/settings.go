@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/mdm/windows"
+)
+
+// DeviceNaming manages the naming template and prefix which devices are renamed with when they enroll
+func DeviceNaming(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var settings = srv.Settings.Get()
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(db.SetDeviceNamingParams{
+				DeviceNameTemplate: settings.DeviceNameTemplate,
+				DeviceNamePrefix:   settings.DeviceNamePrefix,
+			}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPut {
+			var cmd db.SetDeviceNamingParams
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if err := windows.ValidateNaming(cmd.DeviceNameTemplate, cmd.DeviceNamePrefix); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if err := srv.DB.SetDeviceNaming(r.Context(), cmd); err != nil {
+				log.Printf("[SetDeviceNaming Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			settings.DeviceNameTemplate, settings.DeviceNamePrefix = cmd.DeviceNameTemplate, cmd.DeviceNamePrefix
+			srv.Settings.Set(settings)
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+func TermsOfService(srv *mattrax.Server) http.HandlerFunc {
+	type CreateTermsRequest struct {
+		Title   string `json:"title"`
+		Content string `json:"content"`
+	}
+
+	type CreateTermsResponse struct {
+		Version int32 `json:"version"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			versions, err := srv.DB.GetTermsOfServiceVersions(r.Context())
+			if err != nil {
+				log.Printf("[GetTermsOfServiceVersions Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(versions); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			return
+		} else if r.Method == http.MethodPost {
+			var cmd CreateTermsRequest
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if cmd.Title == "" || cmd.Content == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			// Every edit creates a new version so all users are required to accept the terms again
+			version, err := srv.DB.CreateTermsOfService(r.Context(), db.CreateTermsOfServiceParams{
+				Title:   cmd.Title,
+				Content: cmd.Content,
+			})
+			if err != nil {
+				log.Printf("[CreateTermsOfService Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(CreateTermsResponse{
+				Version: version,
+			}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+}
+
+func TermsOfServiceVersion(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		version, err := strconv.Atoi(vars["version"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		terms, err := srv.DB.GetTermsOfService(r.Context(), int32(version))
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetTermsOfService Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(terms); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func TermsOfServiceAcceptances(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		version, err := strconv.Atoi(vars["version"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		acceptances, err := srv.DB.GetTermsOfServiceAcceptances(r.Context(), int32(version))
+		if err != nil {
+			log.Printf("[GetTermsOfServiceAcceptances Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(acceptances); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+type TermsOfServiceReportEntry struct {
+	Upn        string     `json:"upn"`
+	Fullname   string     `json:"fullname"`
+	Version    *int32     `json:"version"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+}
+
+// TermsOfServiceReport returns the latest version of the terms of service every user has accepted. The version is null if the user has never accepted them.
+func TermsOfServiceReport(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rows, err := srv.DB.GetTermsOfServiceReport(r.Context())
+		if err != nil {
+			log.Printf("[GetTermsOfServiceReport Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var report = make([]TermsOfServiceReportEntry, len(rows))
+		for i, row := range rows {
+			report[i] = TermsOfServiceReportEntry{
+				Upn:      row.Upn,
+				Fullname: row.Fullname,
+			}
+			if row.Accepted {
+				var version, acceptedAt = row.Version, row.AcceptedAt
+				report[i].Version, report[i].AcceptedAt = &version, &acceptedAt
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/dynamicgroups"
+)
+
+type UserGroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Priority    int16  `json:"priority"`
+}
+
+type UserGroupMembersRequest struct {
+	Users []string `json:"users"`
+}
+
+func UserGroups(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			groups, err := srv.DB.GetUserGroups(r.Context())
+			if err != nil {
+				log.Printf("[GetUserGroups Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(groups); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			var cmd UserGroupRequest
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if cmd.Name == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			id, err := srv.DB.CreateUserGroup(r.Context(), db.CreateUserGroupParams(cmd))
+			if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[CreateUserGroup Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusCreated)
+			if err := json.NewEncoder(w).Encode(CreatedResponse{
+				ID: id,
+			}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+}
+
+func UserGroup(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		group, err := srv.DB.GetUserGroup(r.Context(), int32(id))
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetUserGroup Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(group); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPatch {
+			var cmd = UserGroupRequest{
+				Name:        group.Name,
+				Description: group.Description,
+				Priority:    group.Priority,
+			}
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+				log.Printf("[JsonDecode Error]: %s\n", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if cmd.Name == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			// Dynamic groups reference user groups by name so they are reevaluated when it changes
+			err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				if err := q.UpdateUserGroup(ctx, db.UpdateUserGroupParams{
+					ID:          group.ID,
+					Name:        cmd.Name,
+					Description: cmd.Description,
+					Priority:    cmd.Priority,
+				}); err != nil || cmd.Name == group.Name {
+					return err
+				}
+				return dynamicgroups.EvaluateAll(ctx, q)
+			})
+			if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Printf("[UpdateUserGroup Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		} else if r.Method == http.MethodDelete {
+			// The groups users have the groups policies removed the next time they are logged in during a checkin
+			if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+				if err := q.DeleteUserGroupMembers(ctx, group.ID); err != nil {
+					return err
+				}
+				if err := q.DeleteUserGroupPolicies(ctx, group.ID); err != nil {
+					return err
+				}
+				if err := q.DeleteUserGroup(ctx, group.ID); err != nil {
+					return err
+				}
+				return dynamicgroups.EvaluateAll(ctx, q)
+			}); err != nil {
+				log.Printf("[DeleteUserGroup Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+func UserGroupMembers(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if _, err := srv.DB.GetUserGroup(r.Context(), int32(id)); err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetUserGroup Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			members, err := srv.DB.GetUserGroupMembers(r.Context(), int32(id))
+			if err != nil {
+				log.Printf("[GetUserGroupMembers Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			if err := json.NewEncoder(w).Encode(members); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
+		var cmd UserGroupMembersRequest
+		if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+			log.Printf("[JsonDecode Error]: %s\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+			if r.Method == http.MethodPost {
+				if err := q.AddUserGroupMembers(ctx, db.AddUserGroupMembersParams{
+					GroupID: int32(id),
+					Upns:    cmd.Users,
+				}); err != nil {
+					return err
+				}
+			} else if r.Method == http.MethodDelete {
+				if err := q.RemoveUserGroupMembers(ctx, db.RemoveUserGroupMembersParams{
+					GroupID: int32(id),
+					Upns:    cmd.Users,
+				}); err != nil {
+					return err
+				}
+			}
+			return dynamicgroups.EvaluateAll(ctx, q)
+		}); err != nil {
+			log.Printf("[UserGroupMembers Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func UserGroupPolicies(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		policies, err := srv.DB.GetUserGroupPolicies(r.Context(), int32(id))
+		if err != nil {
+			log.Printf("[GetUserGroupPolicies Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(policies); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// UserGroupPolicy assigns (PUT) or unassigns (DELETE) a policy to the group's users
+func UserGroupPolicy(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		policyID, err := strconv.Atoi(vars["policy"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if _, err := srv.DB.GetUserGroup(r.Context(), int32(id)); err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetUserGroup Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if _, err := srv.DB.GetPolicy(r.Context(), int32(policyID)); err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetPolicy Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			if err := srv.DB.AttachUserGroupPolicy(r.Context(), db.AttachUserGroupPolicyParams{
+				GroupID:  int32(id),
+				PolicyID: int32(policyID),
+			}); err != nil {
+				log.Printf("[AttachUserGroupPolicy Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodDelete {
+			if err := srv.DB.DetachUserGroupPolicy(r.Context(), db.DetachUserGroupPolicyParams{
+				GroupID:  int32(id),
+				PolicyID: int32(policyID),
+			}); err != nil {
+				log.Printf("[DetachUserGroupPolicy Error]: %s\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/policies"
 	"github.com/mattrax/Mattrax/pkg/null"
 	"golang.org/x/crypto/bcrypt"
 
@@ -94,3 +95,31 @@ func User(srv *mattrax.Server) http.HandlerFunc {
 		}
 	}
 }
+
+// UserConflicts returns the URIs set by multiple of the user's policies along with the winning and losing payloads
+func UserConflicts(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		if _, err := srv.DB.GetUser(r.Context(), vars["upn"]); err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("[GetUser Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		effective, err := policies.UserPayloads(r.Context(), srv.DB, vars["upn"])
+		if err != nil {
+			log.Printf("[GetUsersPayloadCandidates Error]: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(policies.Conflicts(effective)); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
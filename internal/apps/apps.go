@@ -0,0 +1,95 @@
+// Package apps stores the installers uploaded to Mattrax and generates the payloads which install them on devices
+package apps
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+var hashRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ValidHash returns whether the hash is a hex encoded SHA256 hash. It is used to verify a hash is safe to use as a file name.
+func ValidHash(hash string) bool {
+	return hashRegex.MatchString(hash)
+}
+
+// DownloadPath is the path devices download installers from. The file's hash and name are appended to it.
+const DownloadPath = "/ManagementServer/Apps/"
+
+// ContentURL returns the URL devices download the installer with the hash from. The file name is included as Windows uses its extension to detect the package type.
+func ContentURL(domain, hash, fileName string) string {
+	if fileName == "" {
+		return "https://" + domain + DownloadPath + hash
+	}
+	return "https://" + domain + DownloadPath + hash + "/" + url.PathEscape(fileName)
+}
+
+var appxFileNameRegex = regexp.MustCompile(`(?i)^[^/\\]+\.(appx|msix|appxbundle|msixbundle)$`)
+
+// ValidateAppxFileName verifies the file name of an appx/msix package has one of their extensions. Windows uses the extension to detect the package type.
+func ValidateAppxFileName(fileName string) error {
+	if !appxFileNameRegex.MatchString(fileName) {
+		return errors.New("the file name must end in .appx, .msix, .appxbundle or .msixbundle")
+	}
+	return nil
+}
+
+// Path returns the path of the installer with the hash in the storage directory
+func Path(dir, hash string) string {
+	return filepath.Join(dir, hash)
+}
+
+// Store writes the installer to the storage directory and returns its hex encoded SHA256 hash and size.
+// The file is named by its hash so uploading the same installer twice only stores it once.
+func Store(dir string, r io.Reader) (string, int64, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", 0, err
+	}
+
+	f, err := ioutil.TempFile(dir, ".upload-")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(f.Name())
+
+	var h = sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, h), r)
+	if err != nil {
+		f.Close()
+		return "", 0, err
+	} else if err := f.Close(); err != nil {
+		return "", 0, err
+	}
+
+	var hash = hex.EncodeToString(h.Sum(nil))
+	return hash, size, os.Rename(f.Name(), Path(dir, hash))
+}
+
+// Remove deletes the installer from the storage directory unless another app uses the same file
+func Remove(ctx context.Context, q *db.Queries, dir, hash string) error {
+	if hash == "" {
+		return nil
+	}
+
+	if _, err := q.GetAppByHash(ctx, hash); err == nil {
+		return nil
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+
+	if err := os.Remove(Path(dir, hash)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package apps
+
+import (
+	"context"
+	"encoding/xml"
+	"time"
+
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+// RetryInterval is how long after a failed install a required app is installed again
+const RetryInterval = time.Hour
+
+// InstallTimeout is how long a required modern app can be installing without appearing in the device's inventory before it is installed again
+const InstallTimeout = 24 * time.Hour
+
+// intentPriority is used to resolve an app assigned to multiple groups of a device. Required wins over uninstall so an app is never removed from a device which needs it.
+var intentPriority = map[db.AppIntent]int{
+	db.AppIntentRequired:  3,
+	db.AppIntentUninstall: 2,
+	db.AppIntentAvailable: 1,
+}
+
+// Resolve returns each app assigned to the device once with the intent which wins out of the groups it is assigned to
+func Resolve(assignments []db.GetDeviceAppAssignmentsRow) []db.GetDeviceAppAssignmentsRow {
+	var resolved = make([]db.GetDeviceAppAssignmentsRow, 0, len(assignments))
+	var index = make(map[int32]int, len(assignments))
+	for _, assignment := range assignments {
+		if i, found := index[assignment.ID]; found {
+			if intentPriority[assignment.Intent] > intentPriority[resolved[i].Intent] {
+				resolved[i] = assignment
+			}
+			continue
+		}
+		index[assignment.ID] = len(resolved)
+		resolved = append(resolved, assignment)
+	}
+	return resolved
+}
+
+// Action is what has to be done on the device for an app to match its intent
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionInstall
+	ActionUninstall
+)
+
+// ActionFor returns what has to be done on the device for the app to match its intent
+func ActionFor(app db.GetDeviceAppAssignmentsRow, now time.Time) Action {
+	var state = db.AppInstallState(app.InstallState)
+	switch app.Intent {
+	case db.AppIntentRequired:
+		switch {
+		case app.InstallState == "", state == db.AppInstallStatePending, state == db.AppInstallStateUninstalling, state == db.AppInstallStateUninstalled:
+			if app.InInventory {
+				return ActionNone
+			}
+			return ActionInstall
+		case state == db.AppInstallStateFailed:
+			if now.Sub(app.InstallUpdatedAt) > RetryInterval {
+				return ActionInstall
+			}
+		case state == db.AppInstallStateInstalling:
+			if app.Type != db.AppTypeMsi && !app.InInventory && now.Sub(app.InstallUpdatedAt) > InstallTimeout {
+				return ActionInstall
+			}
+		}
+	case db.AppIntentAvailable:
+		if state == db.AppInstallStatePending {
+			return ActionInstall
+		}
+	case db.AppIntentUninstall:
+		switch {
+		case state == db.AppInstallStateUninstalled, app.InstallState == "" && !app.InInventory:
+			return ActionNone
+		case state == db.AppInstallStateUninstalling:
+			if now.Sub(app.InstallUpdatedAt) > InstallTimeout {
+				return ActionUninstall
+			}
+			return ActionNone
+		case state == db.AppInstallStateFailed:
+			if now.Sub(app.InstallUpdatedAt) > RetryInterval {
+				return ActionUninstall
+			}
+			return ActionNone
+		}
+		return ActionUninstall
+	}
+	return ActionNone
+}
+
+// Command is a SyncML command which installs or uninstalls an app. Exec commands are the ones whose Status reports if the install failed.
+type Command struct {
+	Command string
+	URI     string
+	Type    string
+	Format  string
+	Value   string
+}
+
+// InstallCommands returns the commands which install the app. Installers hosted by Mattrax are downloaded from the domain.
+func InstallCommands(app db.GetDeviceAppAssignmentsRow, domain string) ([]Command, error) {
+	var add, uri, value string
+	switch app.Type {
+	case db.AppTypeMsi:
+		job, err := MSIInstallJob(app, domain)
+		if err != nil {
+			return nil, err
+		}
+		uri, value = MSIInstallURI(app.Identifier, app.UserContext), job
+		add = uri
+	case db.AppTypeStore:
+		data, err := xml.Marshal(storeInstall{
+			ID:    app.StoreID,
+			SkuID: DefaultStoreSku(app.StoreSku),
+		})
+		if err != nil {
+			return nil, err
+		}
+		uri, value = AppInstallationURI(app.Identifier, app.UserContext)+storeInstallNode, string(data)
+		add = AppInstallationURI(app.Identifier, app.UserContext)
+	case db.AppTypeAppx:
+		data, err := xml.Marshal(hostedInstall{
+			PackageURI: ContentURL(domain, app.FileHash, app.FileName),
+		})
+		if err != nil {
+			return nil, err
+		}
+		uri, value = AppInstallationURI(app.Identifier, app.UserContext)+hostedInstallNode, string(data)
+		add = AppInstallationURI(app.Identifier, app.UserContext)
+	}
+
+	// The node being executed (or for modern apps its AppInstallation node) must be added before it can be executed
+	return []Command{
+		{Command: "Add", URI: add},
+		{Command: "Exec", URI: uri, Type: "text/plain", Format: "xml", Value: value},
+	}, nil
+}
+
+// UninstallCommands returns the commands which uninstall the app. Store apps are listed under the AppStore source and LOB apps under nonStore.
+func UninstallCommands(app db.GetDeviceAppAssignmentsRow) []Command {
+	if app.Type == db.AppTypeMsi {
+		return []Command{{Command: "Delete", URI: MSIURI(app.Identifier, app.UserContext)}}
+	}
+
+	var source = SourceNonStore
+	if app.Type == db.AppTypeStore {
+		source = SourceStore
+	}
+	return []Command{{Command: "Delete", URI: AppManagementURI(source, app.UserContext) + "/" + app.Identifier}}
+}
+
+// SetState records the install state of the app on the device
+func SetState(ctx context.Context, q *db.Queries, appID, deviceID int32, state db.AppInstallState, status string) error {
+	return q.SetAppInstallState(ctx, db.SetAppInstallStateParams{
+		AppID:    appID,
+		DeviceID: deviceID,
+		State:    state,
+		Status:   status,
+	})
+}
@@ -0,0 +1,142 @@
+package apps
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+// See https://docs.microsoft.com/en-us/windows/client-management/mdm/enterprisemodernappmanagement-csp
+const (
+	modernAppsURI        = "/Vendor/MSFT/EnterpriseModernAppManagement"
+	appInstallationNode  = "/AppInstallation/"
+	appManagementNode    = "/AppManagement/"
+	storeInstallNode     = "/StoreInstall"
+	hostedInstallNode    = "/HostedInstall"
+	defaultStoreSku      = "0016"
+	deviceScopeURIPrefix = "./Device"
+	userScopeURIPrefix   = "./User"
+)
+
+// Sources are the AppManagement nodes which list the installed modern apps
+const (
+	SourceStore    = "AppStore"
+	SourceNonStore = "nonStore"
+)
+
+var packageFamilyNameRegex = regexp.MustCompile(`^[A-Za-z0-9.\-]+_[a-z0-9]{13}$`)
+
+// ValidatePackageFamilyName verifies the package family name is a package name followed by the publisher ID (eg. SpotifyAB.SpotifyMusic_zpdnekdrzrea0)
+func ValidatePackageFamilyName(packageFamilyName string) error {
+	if !packageFamilyNameRegex.MatchString(packageFamilyName) {
+		return errors.New("the package family name must be the package name followed by an underscore and the publisher ID (eg. SpotifyAB.SpotifyMusic_zpdnekdrzrea0)")
+	}
+	return nil
+}
+
+// DefaultStoreSku is the SKU used when a Store app doesn't specify one
+func DefaultStoreSku(sku string) string {
+	if sku == "" {
+		return defaultStoreSku
+	}
+	return sku
+}
+
+func scopeURIPrefix(user bool) string {
+	if user {
+		return userScopeURIPrefix
+	}
+	return deviceScopeURIPrefix
+}
+
+// AppInstallationURI returns the URI of the node which installs the modern app for the device or, if user is set, the user signed into the device
+func AppInstallationURI(packageFamilyName string, user bool) string {
+	return scopeURIPrefix(user) + modernAppsURI + appInstallationNode + packageFamilyName
+}
+
+// AppManagementURI returns the URI of the source which lists the installed modern apps. Deleting an app below it uninstalls the app.
+func AppManagementURI(source string, user bool) string {
+	return scopeURIPrefix(user) + modernAppsURI + appManagementNode + source
+}
+
+type storeInstall struct {
+	XMLName xml.Name `xml:"Application"`
+	ID      string   `xml:"id,attr"`
+	Flags   int      `xml:"flags,attr"`
+	SkuID   string   `xml:"skuid,attr"`
+}
+
+type hostedInstall struct {
+	XMLName    xml.Name `xml:"Application"`
+	PackageURI string   `xml:"PackageUri,attr"`
+}
+
+// InventoryURIs returns the nodes which are retrieved from the device to report its installed modern apps. The user's apps are only listed in a user's session.
+func InventoryURIs(user bool) []string {
+	var uris = []string{AppManagementURI(SourceStore, false), AppManagementURI(SourceNonStore, false)}
+	if user {
+		uris = append(uris, AppManagementURI(SourceStore, true), AppManagementURI(SourceNonStore, true))
+	}
+	return uris
+}
+
+// parseInventoryURI returns the source and scope of an AppManagement URI returned by InventoryURIs
+func parseInventoryURI(uri string) (string, bool, bool) {
+	for _, user := range []bool{false, true} {
+		for _, source := range []string{SourceStore, SourceNonStore} {
+			if uri == AppManagementURI(source, user) {
+				return source, user, true
+			}
+		}
+	}
+	return "", false, false
+}
+
+// RecordInventory replaces the device's installed modern apps with the result of retrieving an inventory URI and marks the apps being installed which it contains as installed.
+// The user's apps are recorded for the session's user (upn) so the inventory of each user signed into the device is kept separately. It returns false if the URI isn't an inventory URI.
+// It must be called within a transaction so the inventory is never left cleared.
+func RecordInventory(ctx context.Context, q *db.Queries, deviceID int32, upn, uri, data string) (bool, error) {
+	source, user, ok := parseInventoryURI(uri)
+	if !ok {
+		return false, nil
+	} else if !user {
+		upn = ""
+	} else if upn == "" {
+		return true, errors.New("the user's apps can't be recorded without the session's user")
+	}
+
+	if err := q.ClearDeviceInventoryApps(ctx, db.ClearDeviceInventoryAppsParams{
+		DeviceID: deviceID,
+		Source:   source,
+		Upn:      upn,
+	}); err != nil {
+		return true, err
+	}
+
+	// Retrieving an interior node returns the names of its children separated by slashes
+	var packageFamilyNames = make([]string, 0)
+	for _, name := range strings.Split(data, "/") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		packageFamilyNames = append(packageFamilyNames, name)
+
+		if err := q.AddDeviceInventoryApp(ctx, db.AddDeviceInventoryAppParams{
+			DeviceID:          deviceID,
+			PackageFamilyName: name,
+			Source:            source,
+			Upn:               upn,
+		}); err != nil {
+			return true, err
+		}
+	}
+
+	return true, q.InstalledDeviceApps(ctx, db.InstalledDeviceAppsParams{
+		DeviceID:           deviceID,
+		PackageFamilyNames: packageFamilyNames,
+	})
+}
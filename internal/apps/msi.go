@@ -0,0 +1,129 @@
+package apps
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+// msiInstallTimeout is how many minutes the device waits for the MSI to install
+const msiInstallTimeout = 10
+
+const (
+	msiDeviceURIPrefix = "./Device/Vendor/MSFT/EnterpriseDesktopAppManagement/MSI/"
+	msiUserURIPrefix   = "./User/Vendor/MSFT/EnterpriseDesktopAppManagement/MSI/"
+	msiInstallNode     = "/DownloadInstall"
+)
+
+var productCodeRegex = regexp.MustCompile(`^\{[0-9A-F]{8}-[0-9A-F]{4}-[0-9A-F]{4}-[0-9A-F]{4}-[0-9A-F]{12}\}$`)
+
+// ValidateProductCode verifies the MSI ProductCode is an uppercase GUID in braces (eg. {9BE518E6-ECC6-35A9-88E4-87755C07200F})
+func ValidateProductCode(productCode string) error {
+	if !productCodeRegex.MatchString(productCode) {
+		return errors.New("the product code must be an uppercase GUID in braces (eg. {9BE518E6-ECC6-35A9-88E4-87755C07200F})")
+	}
+	return nil
+}
+
+// MSIInstallURI returns the URI which installs the MSI for the device or, if user is set, the user signed into the device
+func MSIInstallURI(productCode string, user bool) string {
+	var prefix = msiDeviceURIPrefix
+	if user {
+		prefix = msiUserURIPrefix
+	}
+	return prefix + url.PathEscape(productCode) + msiInstallNode
+}
+
+// MSIURI returns the URI of the MSI's node. Deleting it uninstalls the MSI.
+func MSIURI(productCode string, user bool) string {
+	return strings.TrimSuffix(MSIInstallURI(productCode, user), msiInstallNode)
+}
+
+// ParseMSIInstallURI returns the ProductCode of the MSI an install URI (or a node below the MSI) refers to
+func ParseMSIInstallURI(uri string) (string, bool) {
+	var rest string
+	if strings.HasPrefix(uri, msiDeviceURIPrefix) {
+		rest = uri[len(msiDeviceURIPrefix):]
+	} else if strings.HasPrefix(uri, msiUserURIPrefix) {
+		rest = uri[len(msiUserURIPrefix):]
+	} else {
+		return "", false
+	}
+
+	productCode, err := url.PathUnescape(strings.SplitN(rest, "/", 2)[0])
+	if err != nil || productCode == "" {
+		return "", false
+	}
+	return strings.ToUpper(productCode), true
+}
+
+type msiInstallJob struct {
+	XMLName xml.Name `xml:"MsiInstallJob"`
+	ID      string   `xml:"id,attr"`
+	Product struct {
+		Version       string   `xml:"Version,attr"`
+		ContentURLs   []string `xml:"Download>ContentURLList>ContentURL"`
+		FileHash      string   `xml:"Validation>FileHash"`
+		CommandLine   string   `xml:"Enforcement>CommandLine"`
+		TimeOut       int      `xml:"Enforcement>TimeOut"`
+		RetryCount    int      `xml:"Enforcement>RetryCount"`
+		RetryInterval int      `xml:"Enforcement>RetryInterval"`
+	} `xml:"Product"`
+}
+
+// MSIInstallJob returns the MsiInstallJob which is executed on the DownloadInstall node to install the app
+func MSIInstallJob(app db.GetDeviceAppAssignmentsRow, domain string) (string, error) {
+	var job = msiInstallJob{
+		ID: app.Identifier,
+	}
+	job.Product.Version = app.Version
+	job.Product.ContentURLs = []string{ContentURL(domain, app.FileHash, app.FileName)}
+	job.Product.FileHash = strings.ToUpper(app.FileHash)
+	job.Product.CommandLine = app.CommandLine
+	job.Product.TimeOut = msiInstallTimeout
+	job.Product.RetryCount = 3
+	job.Product.RetryInterval = 5
+
+	data, err := xml.Marshal(job)
+	return string(data), err
+}
+
+// RecordInstallStatus records the result of an MSI install which the device reports asynchronously using a "com.microsoft.mdm.win32csp_install" alert.
+// A status of 0 is a successful install and any other value is the error which caused it to fail.
+func RecordInstallStatus(ctx context.Context, q *db.Queries, deviceID int32, uri, status string) error {
+	var state = db.AppInstallStateInstalled
+	if status != "0" {
+		state = db.AppInstallStateFailed
+	}
+	return setInstallState(ctx, q, deviceID, uri, state, status)
+}
+
+func setInstallState(ctx context.Context, q *db.Queries, deviceID int32, uri string, state db.AppInstallState, status string) error {
+	productCode, ok := ParseMSIInstallURI(uri)
+	if !ok {
+		return nil
+	}
+
+	app, err := q.GetAppByIdentifier(ctx, db.GetAppByIdentifierParams{
+		Type:       db.AppTypeMsi,
+		Identifier: productCode,
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return q.SetAppInstallState(ctx, db.SetAppInstallStateParams{
+		AppID:    app.ID,
+		DeviceID: deviceID,
+		State:    state,
+		Status:   status,
+	})
+}
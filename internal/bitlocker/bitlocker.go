@@ -0,0 +1,204 @@
+// Package bitlocker escrows the BitLocker recovery passwords reported by the Mattrax agent. The passwords are encrypted by the secrets service and every time one is revealed it is logged.
+// The agent isn't part of this repository and must be deployed to devices as a required MSI app (see the README) as no recovery passwords are escrowed without it.
+package bitlocker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/secrets"
+)
+
+var (
+	protectorIDRegex      = regexp.MustCompile(`^\{?[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}\}?$`)
+	recoveryPasswordRegex = regexp.MustCompile(`^\d{6}(-\d{6}){7}$`)
+)
+
+// RecoveryKey is a recovery password key protector of an encrypted volume
+type RecoveryKey struct {
+	Volume           string `json:"volume"`
+	ProtectorID      string `json:"protector_id"`
+	RecoveryPassword string `json:"recovery_password"`
+}
+
+// Validate verifies the protector ID is a GUID and the recovery password is 8 blocks of 6 digits. Each block of a valid password is a multiple of 11 less than 720896.
+func (k RecoveryKey) Validate() error {
+	if k.Volume == "" {
+		return errors.New("the recovery key must have a volume")
+	} else if !protectorIDRegex.MatchString(k.ProtectorID) {
+		return errors.New("the protector id must be a GUID")
+	} else if !recoveryPasswordRegex.MatchString(k.RecoveryPassword) {
+		return errors.New("the recovery password must be 8 blocks of 6 digits separated by hyphens")
+	}
+
+	for _, block := range strings.Split(k.RecoveryPassword, "-") {
+		if n, _ := strconv.Atoi(block); n%11 != 0 || n >= 720896 {
+			return errors.New("the recovery password is invalid")
+		}
+	}
+	return nil
+}
+
+// NormaliseProtectorID returns the upper case protector ID in braces which is how Windows displays it
+func NormaliseProtectorID(protectorID string) string {
+	return "{" + strings.ToUpper(strings.Trim(protectorID, "{}")) + "}"
+}
+
+// associatedData binds an encrypted recovery password to its device and protector
+func associatedData(deviceID int32, protectorID string) []byte {
+	return []byte(strconv.Itoa(int(deviceID)) + "/" + protectorID)
+}
+
+// EscrowQueries are the queries used by Escrow. They are implemented by *db.Queries.
+type EscrowQueries interface {
+	GetDeviceBitLockerRecoveryPasswords(ctx context.Context, deviceID int32) ([]db.BitlockerRecoveryKey, error)
+	CreateBitLockerRecoveryKey(ctx context.Context, arg db.CreateBitLockerRecoveryKeyParams) error
+	RemoveBitLockerRecoveryKey(ctx context.Context, arg db.RemoveBitLockerRecoveryKeyParams) error
+	RestoreBitLockerRecoveryKey(ctx context.Context, arg db.RestoreBitLockerRecoveryKeyParams) error
+	GetBitLockerRotation(ctx context.Context, deviceID int32) (db.BitlockerRotation, error)
+	DeleteBitLockerRotation(ctx context.Context, deviceID int32) error
+}
+
+// Escrow stores the recovery passwords the agent reported which haven't been escrowed. Escrow is append-only so a password is never replaced:
+// if the agent reports a different password for an escrowed protector it is stored alongside the earlier ones.
+// Protectors are only marked as removed when the agent completes a rotation, which is when it reports passwords which were all escrowed after the rotation was requested,
+// as a report which leaves out protectors may be partial. It returns whether the agent should rotate the recovery passwords which is true while a rotation is pending
+// and the agent reports a password escrowed before it was requested. The keys must be valid. It must be called within a transaction.
+func Escrow(ctx context.Context, q EscrowQueries, s *secrets.Service, deviceID int32, keys []RecoveryKey) (bool, error) {
+	escrowed, err := q.GetDeviceBitLockerRecoveryPasswords(ctx, deviceID)
+	if err != nil {
+		return false, err
+	}
+
+	var existing = make(map[string][]db.BitlockerRecoveryKey, len(escrowed))
+	for _, key := range escrowed {
+		existing[key.ProtectorID] = append(existing[key.ProtectorID], key)
+	}
+
+	var reported = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		var protectorID = NormaliseProtectorID(key.ProtectorID)
+		if reported[protectorID] {
+			continue
+		}
+		reported[protectorID] = true
+
+		var found, removed bool
+		for _, previous := range existing[protectorID] {
+			password, err := s.Decrypt(previous.EncryptedPassword, associatedData(deviceID, protectorID))
+			if err != nil {
+				return false, err
+			} else if string(password) == key.RecoveryPassword {
+				found = true
+			}
+			removed = removed || previous.RemovedAt.Valid
+		}
+
+		// A protector which was replaced by a rotation but is still on the device is no longer removed
+		if removed {
+			if err := q.RestoreBitLockerRecoveryKey(ctx, db.RestoreBitLockerRecoveryKeyParams{
+				DeviceID:    deviceID,
+				ProtectorID: protectorID,
+			}); err != nil {
+				return false, err
+			}
+		}
+
+		if found {
+			continue
+		}
+
+		encryptedPassword, err := s.Encrypt([]byte(key.RecoveryPassword), associatedData(deviceID, protectorID))
+		if err != nil {
+			return false, err
+		}
+
+		if err := q.CreateBitLockerRecoveryKey(ctx, db.CreateBitLockerRecoveryKeyParams{
+			DeviceID:          deviceID,
+			ProtectorID:       protectorID,
+			Volume:            key.Volume,
+			EncryptedPassword: encryptedPassword,
+		}); err != nil {
+			return false, err
+		}
+	}
+
+	rotation, err := q.GetBitLockerRotation(ctx, deviceID)
+	if err == sql.ErrNoRows || len(keys) == 0 {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	for protectorID := range reported {
+		for _, previous := range existing[protectorID] {
+			if !previous.EscrowedAt.After(rotation.RequestedAt) {
+				return true, nil
+			}
+		}
+	}
+
+	for protectorID, previous := range existing {
+		if !reported[protectorID] && !previous[0].RemovedAt.Valid {
+			if err := q.RemoveBitLockerRecoveryKey(ctx, db.RemoveBitLockerRecoveryKeyParams{
+				DeviceID:    deviceID,
+				ProtectorID: protectorID,
+			}); err != nil {
+				return false, err
+			}
+		}
+	}
+	return false, q.DeleteBitLockerRotation(ctx, deviceID)
+}
+
+// Revealed is an escrowed recovery password of a protector
+type Revealed struct {
+	Volume           string    `json:"volume"`
+	RecoveryPassword string    `json:"recovery_password"`
+	EscrowedAt       time.Time `json:"escrowed_at"`
+}
+
+// Reveal decrypts the protector's recovery passwords, newest first, and logs that the user accessed them. A protector has more than one password if the agent reported a different one after it was escrowed.
+// It must be called within a transaction so the access is only logged if the passwords are revealed.
+func Reveal(ctx context.Context, q *db.Queries, s *secrets.Service, deviceID int32, protectorID, upn, reason string) ([]Revealed, error) {
+	protectorID = NormaliseProtectorID(protectorID)
+	keys, err := q.GetBitLockerRecoveryKeys(ctx, db.GetBitLockerRecoveryKeysParams{
+		DeviceID:    deviceID,
+		ProtectorID: protectorID,
+	})
+	if err != nil {
+		return nil, err
+	} else if len(keys) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	var revealed = make([]Revealed, 0, len(keys))
+	for _, key := range keys {
+		password, err := s.Decrypt(key.EncryptedPassword, associatedData(key.DeviceID, key.ProtectorID))
+		if err != nil {
+			return nil, err
+		}
+
+		revealed = append(revealed, Revealed{
+			Volume:           key.Volume,
+			RecoveryPassword: string(password),
+			EscrowedAt:       key.EscrowedAt,
+		})
+	}
+
+	if err := q.LogBitLockerRecoveryKeyAccess(ctx, db.LogBitLockerRecoveryKeyAccessParams{
+		DeviceID:    deviceID,
+		ProtectorID: protectorID,
+		Upn:         upn,
+		Reason:      reason,
+	}); err != nil {
+		return nil, err
+	}
+	return revealed, nil
+}
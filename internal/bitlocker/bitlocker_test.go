@@ -0,0 +1,250 @@
+package bitlocker
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/secrets"
+)
+
+const (
+	deviceID = 1
+
+	protectorA = "{6E9B3AC4-2B61-4D4A-9F8A-1A0F3C1E7B21}"
+	protectorB = "{0B7D5E8E-3C4F-4A7E-8B12-9D6C2F4E1A33}"
+	protectorC = "{A1C2E3F4-5B6D-4E7F-8A9B-0C1D2E3F4A5B}"
+
+	passwordA  = "000011-000022-000033-000044-000055-000066-000077-000088"
+	passwordA2 = "111111-222222-333333-444444-555555-666666-000000-000011"
+	passwordB  = "715891-000000-123453-000011-654335-000022-000033-000044"
+	passwordC  = "000099-000099-000099-000099-000099-000099-000099-000099"
+)
+
+// fakeEscrowQueries stores the device's recovery keys and rotation in memory. Keys are escrowed at now.
+type fakeEscrowQueries struct {
+	now      time.Time
+	keys     []db.BitlockerRecoveryKey
+	rotation *db.BitlockerRotation
+}
+
+func (q *fakeEscrowQueries) GetDeviceBitLockerRecoveryPasswords(ctx context.Context, deviceID int32) ([]db.BitlockerRecoveryKey, error) {
+	return append([]db.BitlockerRecoveryKey(nil), q.keys...), nil
+}
+
+func (q *fakeEscrowQueries) CreateBitLockerRecoveryKey(ctx context.Context, arg db.CreateBitLockerRecoveryKeyParams) error {
+	q.keys = append(q.keys, db.BitlockerRecoveryKey{
+		ID:                int32(len(q.keys) + 1),
+		DeviceID:          arg.DeviceID,
+		ProtectorID:       arg.ProtectorID,
+		Volume:            arg.Volume,
+		EncryptedPassword: arg.EncryptedPassword,
+		EscrowedAt:        q.now,
+	})
+	return nil
+}
+
+func (q *fakeEscrowQueries) RemoveBitLockerRecoveryKey(ctx context.Context, arg db.RemoveBitLockerRecoveryKeyParams) error {
+	for i, key := range q.keys {
+		if key.ProtectorID == arg.ProtectorID && !key.RemovedAt.Valid {
+			q.keys[i].RemovedAt = sql.NullTime{Time: q.now, Valid: true}
+		}
+	}
+	return nil
+}
+
+func (q *fakeEscrowQueries) RestoreBitLockerRecoveryKey(ctx context.Context, arg db.RestoreBitLockerRecoveryKeyParams) error {
+	for i, key := range q.keys {
+		if key.ProtectorID == arg.ProtectorID {
+			q.keys[i].RemovedAt = sql.NullTime{}
+		}
+	}
+	return nil
+}
+
+func (q *fakeEscrowQueries) GetBitLockerRotation(ctx context.Context, deviceID int32) (db.BitlockerRotation, error) {
+	if q.rotation == nil {
+		return db.BitlockerRotation{}, sql.ErrNoRows
+	}
+	return *q.rotation, nil
+}
+
+func (q *fakeEscrowQueries) DeleteBitLockerRotation(ctx context.Context, deviceID int32) error {
+	q.rotation = nil
+	return nil
+}
+
+// requestRotation requests a rotation now and moves the clock forward so later keys are escrowed after it
+func (q *fakeEscrowQueries) requestRotation() {
+	q.rotation = &db.BitlockerRotation{DeviceID: deviceID, RequestedBy: "admin@example.com", RequestedAt: q.now}
+	q.now = q.now.Add(time.Minute)
+}
+
+// state returns the escrowed protectors and their passwords, with removed protectors prefixed by "removed "
+func (q *fakeEscrowQueries) state(t *testing.T, s *secrets.Service) []string {
+	t.Helper()
+	var state []string
+	for _, key := range q.keys {
+		password, err := s.Decrypt(key.EncryptedPassword, associatedData(key.DeviceID, key.ProtectorID))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var entry = key.ProtectorID + " " + string(password)
+		if key.RemovedAt.Valid {
+			entry = "removed " + entry
+		}
+		state = append(state, entry)
+	}
+	sort.Strings(state)
+	return state
+}
+
+func newSecrets(t *testing.T) *secrets.Service {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "bitlocker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	s, err := secrets.New(filepath.Join(dir, "secrets.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// escrow reports the keys and verifies whether the agent was asked to rotate them
+func escrow(t *testing.T, q *fakeEscrowQueries, s *secrets.Service, rotate bool, keys ...RecoveryKey) {
+	t.Helper()
+	if result, err := Escrow(context.Background(), q, s, deviceID, keys); err != nil {
+		t.Fatal(err)
+	} else if result != rotate {
+		t.Errorf("Escrow returned rotate %v, expected %v", result, rotate)
+	}
+	q.now = q.now.Add(time.Minute)
+}
+
+// expectState verifies the escrowed protectors
+func expectState(t *testing.T, q *fakeEscrowQueries, s *secrets.Service, expected ...string) {
+	t.Helper()
+	sort.Strings(expected)
+	var state = q.state(t, s)
+	if len(state) != len(expected) {
+		t.Fatalf("the escrowed keys were %q, expected %q", state, expected)
+	}
+	for i := range state {
+		if state[i] != expected[i] {
+			t.Fatalf("the escrowed keys were %q, expected %q", state, expected)
+		}
+	}
+}
+
+func key(protectorID, password string) RecoveryKey {
+	return RecoveryKey{Volume: "C:", ProtectorID: protectorID, RecoveryPassword: password}
+}
+
+func TestEscrowWithoutRotation(t *testing.T) {
+	var s = newSecrets(t)
+	var q = &fakeEscrowQueries{now: time.Now()}
+
+	escrow(t, q, s, false, key(protectorA, passwordA), key(protectorB, passwordB))
+	expectState(t, q, s, protectorA+" "+passwordA, protectorB+" "+passwordB)
+
+	// Reporting the same passwords again, with the protector ID in another format, doesn't escrow them twice
+	escrow(t, q, s, false, key("6e9b3ac4-2b61-4d4a-9f8a-1a0f3c1e7b21", passwordA), key(protectorB, passwordB), key(protectorB, passwordB))
+	expectState(t, q, s, protectorA+" "+passwordA, protectorB+" "+passwordB)
+
+	// A different password for an escrowed protector is kept alongside the earlier one
+	escrow(t, q, s, false, key(protectorA, passwordA2), key(protectorB, passwordB))
+	expectState(t, q, s, protectorA+" "+passwordA, protectorA+" "+passwordA2, protectorB+" "+passwordB)
+
+	// A report which leaves out a protector may be partial so the protector isn't removed without a rotation
+	escrow(t, q, s, false, key(protectorA, passwordA))
+	escrow(t, q, s, false)
+	expectState(t, q, s, protectorA+" "+passwordA, protectorA+" "+passwordA2, protectorB+" "+passwordB)
+}
+
+func TestEscrowCompletedRotation(t *testing.T) {
+	var s = newSecrets(t)
+	var q = &fakeEscrowQueries{now: time.Now()}
+
+	escrow(t, q, s, false, key(protectorA, passwordA), key(protectorB, passwordB))
+	q.requestRotation()
+
+	// The agent replaced both protectors so the old ones are removed and the rotation is complete
+	escrow(t, q, s, false, key(protectorC, passwordC))
+	expectState(t, q, s, "removed "+protectorA+" "+passwordA, "removed "+protectorB+" "+passwordB, protectorC+" "+passwordC)
+	if q.rotation != nil {
+		t.Error("the rotation is still pending after it was completed")
+	}
+
+	// A removed protector which is reported again is still on the device
+	escrow(t, q, s, false, key(protectorA, passwordA), key(protectorC, passwordC))
+	expectState(t, q, s, protectorA+" "+passwordA, "removed "+protectorB+" "+passwordB, protectorC+" "+passwordC)
+}
+
+func TestEscrowFailedRotation(t *testing.T) {
+	var s = newSecrets(t)
+	var q = &fakeEscrowQueries{now: time.Now()}
+
+	escrow(t, q, s, false, key(protectorA, passwordA), key(protectorB, passwordB))
+	q.requestRotation()
+
+	// The agent hasn't rotated the passwords yet (or failed to) so it is asked to rotate them
+	escrow(t, q, s, true, key(protectorA, passwordA), key(protectorB, passwordB))
+
+	// A report without keys doesn't complete the rotation
+	escrow(t, q, s, false)
+
+	// The agent only replaced one of the protectors so the rotation stays pending and nothing is removed
+	escrow(t, q, s, true, key(protectorA, passwordA), key(protectorC, passwordC))
+	expectState(t, q, s, protectorA+" "+passwordA, protectorB+" "+passwordB, protectorC+" "+passwordC)
+	if q.rotation == nil {
+		t.Fatal("the rotation was completed while the agent still reports a password escrowed before it")
+	}
+
+	// Once the remaining protector is replaced the rotation completes
+	escrow(t, q, s, false, key(protectorC, passwordC))
+	expectState(t, q, s, "removed "+protectorA+" "+passwordA, "removed "+protectorB+" "+passwordB, protectorC+" "+passwordC)
+	if q.rotation != nil {
+		t.Error("the rotation is still pending after it was completed")
+	}
+}
+
+func TestEscrowUndecryptablePassword(t *testing.T) {
+	var q = &fakeEscrowQueries{now: time.Now()}
+	escrow(t, q, newSecrets(t), false, key(protectorA, passwordA))
+
+	// Passwords encrypted with another key (eg. a lost secrets key) aren't silently escrowed again
+	if _, err := Escrow(context.Background(), q, newSecrets(t), deviceID, []RecoveryKey{key(protectorA, passwordA)}); err != secrets.ErrInvalidCiphertext {
+		t.Errorf("Escrow with another secrets key returned %v, expected %q", err, secrets.ErrInvalidCiphertext)
+	}
+}
+
+func TestRecoveryKeyValidate(t *testing.T) {
+	var tests = []struct {
+		key   RecoveryKey
+		valid bool
+	}{
+		{key(protectorA, passwordA), true},
+		{key("6e9b3ac4-2b61-4d4a-9f8a-1a0f3c1e7b21", passwordB), true},
+		{RecoveryKey{ProtectorID: protectorA, RecoveryPassword: passwordA}, false},
+		{key("not-a-guid", passwordA), false},
+		{key(protectorA, "000011-000022-000033-000044-000055-000066-000077"), false},
+		{key(protectorA, "000012-000022-000033-000044-000055-000066-000077-000088"), false}, // not a multiple of 11
+		{key(protectorA, "720896-000022-000033-000044-000055-000066-000077-000088"), false}, // too large
+	}
+	for _, tt := range tests {
+		if err := tt.key.Validate(); (err == nil) != tt.valid {
+			t.Errorf("validating %+v returned %v, expected valid to be %v", tt.key, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+// Package catalog is a searchable tree of the Configuration Service Provider (CSP) nodes described by Microsoft's DDF v2 files.
+// It is used to validate policy payloads before they are deployed and to help admins find the setting they want.
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Scope is whether a node configures the device or the user signed into it
+type Scope string
+
+const (
+	ScopeDevice Scope = "Device"
+	ScopeUser   Scope = "User"
+)
+
+// Node is a setting or interior node in a CSP
+type Node struct {
+	Name           string         `json:"name"`
+	URI            string         `json:"uri"`
+	Title          string         `json:"title,omitempty"`
+	Description    string         `json:"description,omitempty"`
+	Format         string         `json:"format"`
+	Scope          Scope          `json:"scope"`
+	Dynamic        bool           `json:"dynamic"`
+	AccessTypes    []string       `json:"access_types"`
+	DefaultValue   string         `json:"default_value,omitempty"`
+	OSBuildVersion string         `json:"os_build_version,omitempty"`
+	CSPVersion     string         `json:"csp_version,omitempty"`
+	AllowedValues  *AllowedValues `json:"allowed_values,omitempty"`
+
+	children map[string]*Node
+	defined  bool // Whether the node is described by a DDF file or only exists as the parent of one
+}
+
+// AllowedValues restricts the values of a node. Value is the range (eg. "[0-100]") or regular expression for those types.
+type AllowedValues struct {
+	Type  string      `json:"type"`
+	Value string      `json:"value,omitempty"`
+	Enum  []EnumValue `json:"enum,omitempty"`
+}
+
+// EnumValue is one of the values allowed by an ENUM node
+type EnumValue struct {
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// key is the name the node is stored under in its parent. Dynamic nodes match any name so they share one key.
+func (n *Node) key() string {
+	if n.Dynamic {
+		return ""
+	}
+	return n.Name
+}
+
+// add merges the node into the children. Nodes are merged when multiple DDF files describe the same path (eg. the Policy CSP's areas).
+func (n *Node) add(child *Node) {
+	existing, found := n.children[child.key()]
+	if !found {
+		n.children[child.key()] = child
+		return
+	}
+
+	if child.defined && !existing.defined {
+		var children = existing.children
+		*existing = *child
+		existing.children = children
+	}
+
+	for _, grandchild := range child.children {
+		existing.add(grandchild)
+	}
+}
+
+// Children returns the node's children sorted by name
+func (n *Node) Children() []*Node {
+	var children = make([]*Node, 0, len(n.children))
+	for _, child := range n.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Name < children[j].Name
+	})
+	return children
+}
+
+// Service holds the catalog loaded from the DDF files
+type Service struct {
+	root *Node
+}
+
+// New loads the catalog from the DDF files (*.xml) in the directory. A directory which doesn't exist results in an empty catalog which doesn't restrict payloads.
+func New(dir string) (*Service, error) {
+	var s = &Service{
+		root: &Node{
+			Name:     ".",
+			URI:      ".",
+			Format:   "node",
+			Scope:    ScopeDevice,
+			children: make(map[string]*Node),
+		},
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return s, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.xml"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes, err := Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing DDF file '%s': %w", file, err)
+		}
+
+		for _, node := range nodes {
+			s.insert(node)
+		}
+	}
+	return s, nil
+}
+
+// insert adds a DDF root node to the tree creating any parents which aren't described by a DDF file
+func (s *Service) insert(node *Node) {
+	var parent = s.root
+	for _, segment := range segments(strings.TrimSuffix(node.URI, "/"+node.Name)) {
+		child, found := parent.children[segment]
+		if !found {
+			child = &Node{
+				Name:     segment,
+				URI:      parent.URI + "/" + segment,
+				Format:   "node",
+				Scope:    node.Scope,
+				children: make(map[string]*Node),
+			}
+			parent.children[segment] = child
+		}
+		parent = child
+	}
+	parent.add(node)
+}
+
+// Len returns the number of nodes described by the DDF files
+func (s *Service) Len() int {
+	var count int
+	s.walk(s.root, func(n *Node) {
+		if n.defined {
+			count++
+		}
+	})
+	return count
+}
+
+func (s *Service) walk(node *Node, fn func(n *Node)) {
+	fn(node)
+	for _, child := range node.children {
+		s.walk(child, fn)
+	}
+}
+
+// segments splits the URI into its path segments after removing the "./Device" prefix
+func segments(uri string) []string {
+	var path, _ = normalisePath(strings.TrimSuffix(uri, "/"))
+	if path == "." {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(path, "./"), "/")
+}
+
+// lookup returns the deepest node matching the URI and whether it matched the whole URI
+func (s *Service) lookup(uri string) (*Node, bool) {
+	var node = s.root
+	for _, segment := range segments(uri) {
+		child, found := node.children[segment]
+		if !found {
+			if child, found = node.children[""]; !found {
+				return node, false
+			}
+		}
+		node = child
+	}
+	return node, true
+}
+
+// Get returns the node at the URI. Dynamic nodes match any name.
+func (s *Service) Get(uri string) (*Node, bool) {
+	node, found := s.lookup(uri)
+	if !found {
+		return nil, false
+	}
+	return node, true
+}
+
+// Search returns up to limit nodes described by the DDF files whose URI, title or description contains the query (case insensitive) sorted by URI
+func (s *Service) Search(query string, limit int) []*Node {
+	var results = make([]*Node, 0)
+	query = strings.ToLower(query)
+	s.walk(s.root, func(n *Node) {
+		if !n.defined {
+			return
+		}
+
+		if strings.Contains(strings.ToLower(n.URI), query) || strings.Contains(strings.ToLower(n.Title), query) || strings.Contains(strings.ToLower(n.Description), query) {
+			results = append(results, n)
+		}
+	})
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].URI < results[j].URI
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// Validate verifies the payload against the node it sets. URIs in CSPs which the catalog doesn't describe aren't restricted.
+func (s *Service) Validate(uri, format, value string, exec bool) error {
+	// The areas of ingested ADMX files (eg. "Chrome~Policy~googlechrome") are defined by the ADMX file instead of a DDF file
+	if strings.Contains(uri, "~Policy") {
+		return nil
+	}
+
+	node, found := s.lookup(uri)
+	if !found {
+		if node.defined {
+			return fmt.Errorf("'%s' doesn't exist in the catalog. The closest setting is '%s'", uri, node.URI)
+		}
+		return nil
+	} else if !node.defined {
+		return nil
+	}
+
+	if exec {
+		if !node.hasAccessType("Exec") {
+			return fmt.Errorf("'%s' can't be executed", node.URI)
+		}
+	} else if !node.hasAccessType("Add") && !node.hasAccessType("Replace") {
+		return fmt.Errorf("'%s' is read only", node.URI)
+	}
+
+	if format == "" {
+		format = "chr"
+	}
+	if node.Format != "" && format != node.Format {
+		return fmt.Errorf("'%s' must use the '%s' format", node.URI, node.Format)
+	}
+
+	if node.AllowedValues != nil {
+		return node.AllowedValues.validate(value)
+	}
+	return nil
+}
+
+func (n *Node) hasAccessType(accessType string) bool {
+	for _, t := range n.AccessTypes {
+		if t == accessType {
+			return true
+		}
+	}
+	return false
+}
+
+var rangeRegex = regexp.MustCompile(`^\[(-?\d+)-(-?\d+)\]$`)
+
+// validate verifies the value is allowed. Types which can't be checked without the device (eg. XSD, ADMX) are always allowed.
+func (a AllowedValues) validate(value string) error {
+	switch strings.ToLower(a.Type) {
+	case "enum":
+		var allowed = make([]string, len(a.Enum))
+		for i, enum := range a.Enum {
+			if enum.Value == value {
+				return nil
+			}
+			allowed[i] = enum.Value
+		}
+		return fmt.Errorf("the value '%s' must be one of: %s", value, strings.Join(allowed, ", "))
+	case "range":
+		var matches = rangeRegex.FindStringSubmatch(a.Value)
+		if matches == nil {
+			return nil
+		}
+		min, _ := strconv.ParseInt(matches[1], 10, 64)
+		max, _ := strconv.ParseInt(matches[2], 10, 64)
+
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || v < min || v > max {
+			return fmt.Errorf("the value '%s' must be between %d and %d", value, min, max)
+		}
+	case "regex":
+		regex, err := regexp.Compile("^(?:" + a.Value + ")$")
+		if err != nil {
+			return nil
+		} else if !regex.MatchString(value) {
+			return fmt.Errorf("the value '%s' must match '%s'", value, a.Value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package catalog
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// ddfTree is the root of a Microsoft DDF (Device Description Framework) v2 file
+type ddfTree struct {
+	XMLName xml.Name  `xml:"MgmtTree"`
+	Nodes   []ddfNode `xml:"Node"`
+}
+
+type ddfNode struct {
+	NodeName   string        `xml:"NodeName"`
+	Path       string        `xml:"Path"`
+	Properties ddfProperties `xml:"DFProperties"`
+	Nodes      []ddfNode     `xml:"Node"`
+}
+
+type ddfProperties struct {
+	AccessType    ddfElements       `xml:"AccessType"`
+	DefaultValue  string            `xml:"DefaultValue"`
+	Description   string            `xml:"Description"`
+	Format        ddfElements       `xml:"DFFormat"`
+	Title         string            `xml:"DFTitle"`
+	Applicability ddfApplicability  `xml:"Applicability"`
+	AllowedValues *ddfAllowedValues `xml:"AllowedValues"`
+}
+
+// ddfElements are the names of empty elements which DDF uses as values (eg. <AccessType><Get /><Replace /></AccessType>)
+type ddfElements struct {
+	Elements []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+func (e ddfElements) names() []string {
+	var names = make([]string, len(e.Elements))
+	for i, element := range e.Elements {
+		names[i] = element.XMLName.Local
+	}
+	return names
+}
+
+func (e ddfElements) first() string {
+	if len(e.Elements) == 0 {
+		return ""
+	}
+	return e.Elements[0].XMLName.Local
+}
+
+type ddfApplicability struct {
+	OSBuildVersion string `xml:"OsBuildVersion"`
+	CSPVersion     string `xml:"CspVersion"`
+}
+
+type ddfAllowedValues struct {
+	ValueType string `xml:"ValueType,attr"`
+	Value     string `xml:"Value"`
+	Enums     []struct {
+		Value       string `xml:"Value"`
+		Description string `xml:"ValueDescription"`
+	} `xml:"Enum"`
+}
+
+// Parse decodes a DDF v2 file into its nodes. The returned nodes are the roots of the file with their URI set from the file's Path.
+func Parse(r io.Reader) ([]*Node, error) {
+	var tree ddfTree
+	if err := xml.NewDecoder(r).Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	var nodes = make([]*Node, 0, len(tree.Nodes))
+	for _, n := range tree.Nodes {
+		var path, scope = normalisePath(strings.TrimSuffix(strings.TrimSpace(n.Path), "/"))
+		nodes = append(nodes, newNode(n, path, scope))
+	}
+	return nodes, nil
+}
+
+// newNode converts the DDF node and its children into catalog nodes
+func newNode(n ddfNode, parent string, scope Scope) *Node {
+	var name = strings.TrimSpace(n.NodeName)
+	var node = &Node{
+		Name:           name,
+		Title:          strings.TrimSpace(n.Properties.Title),
+		Description:    strings.TrimSpace(n.Properties.Description),
+		Format:         n.Properties.Format.first(),
+		Scope:          scope,
+		AccessTypes:    n.Properties.AccessType.names(),
+		DefaultValue:   n.Properties.DefaultValue,
+		OSBuildVersion: strings.TrimSpace(n.Properties.Applicability.OSBuildVersion),
+		CSPVersion:     strings.TrimSpace(n.Properties.Applicability.CSPVersion),
+		children:       make(map[string]*Node, len(n.Nodes)),
+		defined:        true,
+	}
+
+	// Nodes without a name are dynamic so their name is chosen by whoever creates them (eg. a Wi-Fi profile's SSID)
+	if name == "" {
+		node.Dynamic = true
+		node.Name = "{" + node.Title + "}"
+		if node.Title == "" {
+			node.Name = "{name}"
+		}
+	}
+	node.URI = parent + "/" + node.Name
+
+	if values := n.Properties.AllowedValues; values != nil {
+		node.AllowedValues = &AllowedValues{
+			Type:  values.ValueType,
+			Value: strings.TrimSpace(values.Value),
+		}
+		for _, enum := range values.Enums {
+			node.AllowedValues.Enum = append(node.AllowedValues.Enum, EnumValue{
+				Value:       strings.TrimSpace(enum.Value),
+				Description: strings.TrimSpace(enum.Description),
+			})
+		}
+	}
+
+	for _, child := range n.Nodes {
+		node.add(newNode(child, node.URI, scope))
+	}
+	return node
+}
+
+// normalisePath removes the "./Device" prefix from a path as Windows treats "./Device/Vendor/MSFT" the same as "./Vendor/MSFT" and returns the scope of the path
+func normalisePath(path string) (string, Scope) {
+	if path == "" || path == "." {
+		return ".", ScopeDevice
+	} else if path == "./User" || strings.HasPrefix(path, "./User/") {
+		return path, ScopeUser
+	} else if path == "./Device" {
+		return ".", ScopeDevice
+	}
+	return "./" + strings.TrimPrefix(strings.TrimPrefix(path, "./"), "Device/"), ScopeDevice
+}
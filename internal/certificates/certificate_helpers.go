@@ -17,11 +17,21 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// caKeyUsage is the key usage of generated CA certificates
+const caKeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+// caLifetime is how long generated CA certificates are valid for
+const caLifetime = 365 * 24 * time.Hour
+
 // LoadOrGenerate retrieves a certificate by id and if it is not found generates a new one
 func LoadOrGenerate(ctx context.Context, q *db.Queries, id string, subject pkix.Name) (cert *x509.Certificate, key *rsa.PrivateKey, err error) {
+	return loadOrGenerate(ctx, q, id, subject, caKeyUsage, caLifetime)
+}
+
+func loadOrGenerate(ctx context.Context, q *db.Queries, id string, subject pkix.Name, keyUsage x509.KeyUsage, lifetime time.Duration) (cert *x509.Certificate, key *rsa.PrivateKey, err error) {
 	rawCert, err := q.GetRawCert(ctx, id)
 	if err == sql.ErrNoRows {
-		cert, certRaw, key, keyRaw, err := GenerateCertificate(subject)
+		cert, certRaw, key, keyRaw, err := generateCertificate(subject, keyUsage, lifetime)
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "Error generating new certificate")
 		}
@@ -59,6 +69,10 @@ func LoadOrGenerate(ctx context.Context, q *db.Queries, id string, subject pkix.
 
 // GenerateCertificate takes care of generating a new CA certificate
 func GenerateCertificate(subject pkix.Name) (cert *x509.Certificate, certRaw []byte, key *rsa.PrivateKey, keyRaw []byte, err error) {
+	return generateCertificate(subject, caKeyUsage, caLifetime)
+}
+
+func generateCertificate(subject pkix.Name, keyUsage x509.KeyUsage, lifetime time.Duration) (cert *x509.Certificate, certRaw []byte, key *rsa.PrivateKey, keyRaw []byte, err error) {
 	key, err = rsa.GenerateKey(rand.Reader, 4096)
 	if err != nil {
 		return nil, nil, nil, nil, err
@@ -71,11 +85,11 @@ func GenerateCertificate(subject pkix.Name) (cert *x509.Certificate, certRaw []b
 		SerialNumber:                big.NewInt(1),
 		Subject:                     subject,
 		NotBefore:                   notBefore,
-		NotAfter:                    notBefore.Add(365 * 24 * time.Hour),
-		KeyUsage:                    x509.KeyUsageCertSign | x509.KeyUsageCRLSign, // TODO: Are they required
-		ExtKeyUsage:                 nil,                                          // TODO: What does it do
-		UnknownExtKeyUsage:          nil,                                          // TODO: What does it do
-		BasicConstraintsValid:       true,                                         // TODO: What does it do
+		NotAfter:                    notBefore.Add(lifetime),
+		KeyUsage:                    keyUsage, // TODO: Are they required
+		ExtKeyUsage:                 nil,      // TODO: What does it do
+		UnknownExtKeyUsage:          nil,      // TODO: What does it do
+		BasicConstraintsValid:       true,     // TODO: What does it do
 		IsCA:                        true,
 		MaxPathLen:                  0, // TODO: What does it do
 		SubjectKeyId:                subjectKeyIDRaw[:],
@@ -88,5 +102,10 @@ func GenerateCertificate(subject pkix.Name) (cert *x509.Certificate, certRaw []b
 		return nil, nil, nil, nil, err
 	}
 
+	// The parsed certificate is returned as the template is missing the raw encoding which the certificate is sent with
+	if cert, err = x509.ParseCertificate(certRaw); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
 	return cert, certRaw, key, x509.MarshalPKCS1PrivateKey(key), nil
 }
@@ -22,8 +22,19 @@ type Service struct {
 	identityCertificate *x509.Certificate
 	identityPrivateKey  *rsa.PrivateKey
 	identityLock        sync.RWMutex
+
+	scepCertificate *x509.Certificate
+	scepPrivateKey  *rsa.PrivateKey
+	scepLock        sync.RWMutex
 }
 
+// scepKeyUsage is the key usage of the SCEP CA certificate. Unlike the other CAs it also signs SCEP responses and decrypts the requests which are encrypted to it.
+const scepKeyUsage = caKeyUsage | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+
+// scepLifetime is how long the SCEP CA certificate is valid for. Certificates can't outlive the CA which issued them so it is twice the longest validity
+// of a certificate profile, which leaves 10 years of certificates to be issued with any validity before the CA must be replaced.
+const scepLifetime = 20 * 365 * 24 * time.Hour
+
 // IsIssuerIdentity verifies if the certificate was issued by the Identity certificate
 func (s *Service) IsIssuerIdentity(cert *x509.Certificate) error {
 	signerVerificationOpts := x509.VerifyOptions{
@@ -38,6 +49,21 @@ func (s *Service) IsIssuerIdentity(cert *x509.Certificate) error {
 	return err
 }
 
+// VerifyIdentityClient verifies the certificate is a client certificate issued by the Identity certificate (eg. the MDM client certificate of a device)
+func (s *Service) VerifyIdentityClient(cert *x509.Certificate) error {
+	opts := x509.VerifyOptions{
+		Roots:     x509.NewCertPool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	s.identityLock.RLock()
+	opts.Roots.AddCert(s.identityCertificate)
+	s.identityLock.RUnlock()
+
+	_, err := cert.Verify(opts)
+	return err
+}
+
 // IdentitySignCSR will sign a csr with the Identity certificate
 func (s *Service) IdentitySignCSR(csr *x509.CertificateRequest, subject pkix.Name) (*x509.Certificate, *x509.Certificate, []byte, error) {
 	s.identityLock.RLock()
@@ -82,6 +108,13 @@ func (s *Service) AuthenticationKey() *rsa.PrivateKey {
 	return pk
 }
 
+// SCEP returns the certificate and private key of the CA which issues certificates through the SCEP endpoint
+func (s *Service) SCEP() (*x509.Certificate, *rsa.PrivateKey) {
+	s.scepLock.RLock()
+	defer s.scepLock.RUnlock()
+	return s.scepCertificate, s.scepPrivateKey
+}
+
 // New initialises a new certificate service
 func New(q *db.Queries) (s *Service, err error) {
 	s = &Service{}
@@ -95,6 +128,11 @@ func New(q *db.Queries) (s *Service, err error) {
 	}); err != nil {
 		return nil, err
 	}
+	if s.scepCertificate, s.scepPrivateKey, err = loadOrGenerate(context.Background(), q, "scep", pkix.Name{
+		CommonName: "Mattrax SCEP CA",
+	}, scepKeyUsage, scepLifetime); err != nil {
+		return nil, err
+	}
 
 	return s, nil
 }
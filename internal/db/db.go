@@ -22,21 +22,216 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.acceptTermsOfServiceStmt, err = db.PrepareContext(ctx, acceptTermsOfService); err != nil {
+		return nil, fmt.Errorf("error preparing query AcceptTermsOfService: %w", err)
+	}
+	if q.addDeviceInventoryAppStmt, err = db.PrepareContext(ctx, addDeviceInventoryApp); err != nil {
+		return nil, fmt.Errorf("error preparing query AddDeviceInventoryApp: %w", err)
+	}
+	if q.addGroupDevicesStmt, err = db.PrepareContext(ctx, addGroupDevices); err != nil {
+		return nil, fmt.Errorf("error preparing query AddGroupDevices: %w", err)
+	}
+	if q.addUserGroupMembersStmt, err = db.PrepareContext(ctx, addUserGroupMembers); err != nil {
+		return nil, fmt.Errorf("error preparing query AddUserGroupMembers: %w", err)
+	}
+	if q.attachGroupPolicyStmt, err = db.PrepareContext(ctx, attachGroupPolicy); err != nil {
+		return nil, fmt.Errorf("error preparing query AttachGroupPolicy: %w", err)
+	}
+	if q.attachUserGroupPolicyStmt, err = db.PrepareContext(ctx, attachUserGroupPolicy); err != nil {
+		return nil, fmt.Errorf("error preparing query AttachUserGroupPolicy: %w", err)
+	}
+	if q.claimCertificateChallengeStmt, err = db.PrepareContext(ctx, claimCertificateChallenge); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimCertificateChallenge: %w", err)
+	}
+	if q.clearDeviceInventoryAppsStmt, err = db.PrepareContext(ctx, clearDeviceInventoryApps); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearDeviceInventoryApps: %w", err)
+	}
+	if q.confirmLocalAdminPasswordStmt, err = db.PrepareContext(ctx, confirmLocalAdminPassword); err != nil {
+		return nil, fmt.Errorf("error preparing query ConfirmLocalAdminPassword: %w", err)
+	}
+	if q.countDeviceScriptsStmt, err = db.PrepareContext(ctx, countDeviceScripts); err != nil {
+		return nil, fmt.Errorf("error preparing query CountDeviceScripts: %w", err)
+	}
+	if q.createAppStmt, err = db.PrepareContext(ctx, createApp); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateApp: %w", err)
+	}
+	if q.createBitLockerRecoveryKeyStmt, err = db.PrepareContext(ctx, createBitLockerRecoveryKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateBitLockerRecoveryKey: %w", err)
+	}
+	if q.createCertificateProfileStmt, err = db.PrepareContext(ctx, createCertificateProfile); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateCertificateProfile: %w", err)
+	}
+	if q.createGroupStmt, err = db.PrepareContext(ctx, createGroup); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateGroup: %w", err)
+	}
+	if q.createGroupPolicyWindowStmt, err = db.PrepareContext(ctx, createGroupPolicyWindow); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateGroupPolicyWindow: %w", err)
+	}
+	if q.createIssuedCertificateStmt, err = db.PrepareContext(ctx, createIssuedCertificate); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateIssuedCertificate: %w", err)
+	}
+	if q.createLocalAdminProfileStmt, err = db.PrepareContext(ctx, createLocalAdminProfile); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateLocalAdminProfile: %w", err)
+	}
+	if q.createPolicyStmt, err = db.PrepareContext(ctx, createPolicy); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePolicy: %w", err)
+	}
+	if q.createPolicyPayloadStmt, err = db.PrepareContext(ctx, createPolicyPayload); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePolicyPayload: %w", err)
+	}
+	if q.createPolicyVersionStmt, err = db.PrepareContext(ctx, createPolicyVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePolicyVersion: %w", err)
+	}
 	if q.createRawCertStmt, err = db.PrepareContext(ctx, createRawCert); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateRawCert: %w", err)
 	}
+	if q.createRolloutStmt, err = db.PrepareContext(ctx, createRollout); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateRollout: %w", err)
+	}
+	if q.createRolloutStageStmt, err = db.PrepareContext(ctx, createRolloutStage); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateRolloutStage: %w", err)
+	}
+	if q.createScriptStmt, err = db.PrepareContext(ctx, createScript); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateScript: %w", err)
+	}
+	if q.createTermsOfServiceStmt, err = db.PrepareContext(ctx, createTermsOfService); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTermsOfService: %w", err)
+	}
 	if q.createUserStmt, err = db.PrepareContext(ctx, createUser); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateUser: %w", err)
 	}
+	if q.createUserGroupStmt, err = db.PrepareContext(ctx, createUserGroup); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateUserGroup: %w", err)
+	}
+	if q.deleteAppStmt, err = db.PrepareContext(ctx, deleteApp); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteApp: %w", err)
+	}
+	if q.deleteAppAssignmentStmt, err = db.PrepareContext(ctx, deleteAppAssignment); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAppAssignment: %w", err)
+	}
+	if q.deleteBitLockerRotationStmt, err = db.PrepareContext(ctx, deleteBitLockerRotation); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteBitLockerRotation: %w", err)
+	}
+	if q.deleteCertificateProfileStmt, err = db.PrepareContext(ctx, deleteCertificateProfile); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteCertificateProfile: %w", err)
+	}
 	if q.deleteDeviceCacheNodeStmt, err = db.PrepareContext(ctx, deleteDeviceCacheNode); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteDeviceCacheNode: %w", err)
 	}
+	if q.deleteEnrollmentBrandingStmt, err = db.PrepareContext(ctx, deleteEnrollmentBranding); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteEnrollmentBranding: %w", err)
+	}
+	if q.deleteGroupStmt, err = db.PrepareContext(ctx, deleteGroup); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGroup: %w", err)
+	}
+	if q.deleteGroupAppAssignmentsStmt, err = db.PrepareContext(ctx, deleteGroupAppAssignments); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGroupAppAssignments: %w", err)
+	}
+	if q.deleteGroupCertificateProfilesStmt, err = db.PrepareContext(ctx, deleteGroupCertificateProfiles); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGroupCertificateProfiles: %w", err)
+	}
+	if q.deleteGroupDevicesStmt, err = db.PrepareContext(ctx, deleteGroupDevices); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGroupDevices: %w", err)
+	}
+	if q.deleteGroupLocalAdminProfilesStmt, err = db.PrepareContext(ctx, deleteGroupLocalAdminProfiles); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGroupLocalAdminProfiles: %w", err)
+	}
+	if q.deleteGroupPoliciesStmt, err = db.PrepareContext(ctx, deleteGroupPolicies); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGroupPolicies: %w", err)
+	}
+	if q.deleteGroupPolicyWindowsStmt, err = db.PrepareContext(ctx, deleteGroupPolicyWindows); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGroupPolicyWindows: %w", err)
+	}
+	if q.deleteGroupRolloutsStmt, err = db.PrepareContext(ctx, deleteGroupRollouts); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGroupRollouts: %w", err)
+	}
+	if q.deleteGroupScriptsStmt, err = db.PrepareContext(ctx, deleteGroupScripts); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGroupScripts: %w", err)
+	}
+	if q.deleteLocalAdminProfileStmt, err = db.PrepareContext(ctx, deleteLocalAdminProfile); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLocalAdminProfile: %w", err)
+	}
+	if q.deleteOrphanedPayloadsStmt, err = db.PrepareContext(ctx, deleteOrphanedPayloads); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteOrphanedPayloads: %w", err)
+	}
+	if q.deletePolicyStmt, err = db.PrepareContext(ctx, deletePolicy); err != nil {
+		return nil, fmt.Errorf("error preparing query DeletePolicy: %w", err)
+	}
+	if q.deletePolicyGroupsStmt, err = db.PrepareContext(ctx, deletePolicyGroups); err != nil {
+		return nil, fmt.Errorf("error preparing query DeletePolicyGroups: %w", err)
+	}
+	if q.deletePolicyRolloutsStmt, err = db.PrepareContext(ctx, deletePolicyRollouts); err != nil {
+		return nil, fmt.Errorf("error preparing query DeletePolicyRollouts: %w", err)
+	}
+	if q.deletePolicyUserGroupsStmt, err = db.PrepareContext(ctx, deletePolicyUserGroups); err != nil {
+		return nil, fmt.Errorf("error preparing query DeletePolicyUserGroups: %w", err)
+	}
+	if q.deleteScriptStmt, err = db.PrepareContext(ctx, deleteScript); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteScript: %w", err)
+	}
+	if q.deleteUserGroupStmt, err = db.PrepareContext(ctx, deleteUserGroup); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserGroup: %w", err)
+	}
+	if q.deleteUserGroupMembersStmt, err = db.PrepareContext(ctx, deleteUserGroupMembers); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserGroupMembers: %w", err)
+	}
+	if q.deleteUserGroupPoliciesStmt, err = db.PrepareContext(ctx, deleteUserGroupPolicies); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserGroupPolicies: %w", err)
+	}
+	if q.detachGroupPolicyStmt, err = db.PrepareContext(ctx, detachGroupPolicy); err != nil {
+		return nil, fmt.Errorf("error preparing query DetachGroupPolicy: %w", err)
+	}
+	if q.detachPoliciesPayloadsStmt, err = db.PrepareContext(ctx, detachPoliciesPayloads); err != nil {
+		return nil, fmt.Errorf("error preparing query DetachPoliciesPayloads: %w", err)
+	}
+	if q.detachPolicyPayloadStmt, err = db.PrepareContext(ctx, detachPolicyPayload); err != nil {
+		return nil, fmt.Errorf("error preparing query DetachPolicyPayload: %w", err)
+	}
+	if q.detachUserGroupPolicyStmt, err = db.PrepareContext(ctx, detachUserGroupPolicy); err != nil {
+		return nil, fmt.Errorf("error preparing query DetachUserGroupPolicy: %w", err)
+	}
 	if q.deviceCheckinStatusStmt, err = db.PrepareContext(ctx, deviceCheckinStatus); err != nil {
 		return nil, fmt.Errorf("error preparing query DeviceCheckinStatus: %w", err)
 	}
+	if q.deviceCommandSentStmt, err = db.PrepareContext(ctx, deviceCommandSent); err != nil {
+		return nil, fmt.Errorf("error preparing query DeviceCommandSent: %w", err)
+	}
+	if q.deviceNameInUseStmt, err = db.PrepareContext(ctx, deviceNameInUse); err != nil {
+		return nil, fmt.Errorf("error preparing query DeviceNameInUse: %w", err)
+	}
 	if q.deviceUserUnenrollmentStmt, err = db.PrepareContext(ctx, deviceUserUnenrollment); err != nil {
 		return nil, fmt.Errorf("error preparing query DeviceUserUnenrollment: %w", err)
 	}
+	if q.getAllDevicesStmt, err = db.PrepareContext(ctx, getAllDevices); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAllDevices: %w", err)
+	}
+	if q.getAllGroupsStmt, err = db.PrepareContext(ctx, getAllGroups); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAllGroups: %w", err)
+	}
+	if q.getAllPoliciesStmt, err = db.PrepareContext(ctx, getAllPolicies); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAllPolicies: %w", err)
+	}
+	if q.getAllUserGroupsStmt, err = db.PrepareContext(ctx, getAllUserGroups); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAllUserGroups: %w", err)
+	}
+	if q.getAppStmt, err = db.PrepareContext(ctx, getApp); err != nil {
+		return nil, fmt.Errorf("error preparing query GetApp: %w", err)
+	}
+	if q.getAppAssignmentsStmt, err = db.PrepareContext(ctx, getAppAssignments); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAppAssignments: %w", err)
+	}
+	if q.getAppByHashStmt, err = db.PrepareContext(ctx, getAppByHash); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAppByHash: %w", err)
+	}
+	if q.getAppByIdentifierStmt, err = db.PrepareContext(ctx, getAppByIdentifier); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAppByIdentifier: %w", err)
+	}
+	if q.getAppInstallsStmt, err = db.PrepareContext(ctx, getAppInstalls); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAppInstalls: %w", err)
+	}
+	if q.getAppsStmt, err = db.PrepareContext(ctx, getApps); err != nil {
+		return nil, fmt.Errorf("error preparing query GetApps: %w", err)
+	}
 	if q.getBasicDeviceStmt, err = db.PrepareContext(ctx, getBasicDevice); err != nil {
 		return nil, fmt.Errorf("error preparing query GetBasicDevice: %w", err)
 	}
@@ -46,30 +241,162 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getBasicDeviceScopedPoliciesStmt, err = db.PrepareContext(ctx, getBasicDeviceScopedPolicies); err != nil {
 		return nil, fmt.Errorf("error preparing query GetBasicDeviceScopedPolicies: %w", err)
 	}
+	if q.getBitLockerRecoveryKeyAccessStmt, err = db.PrepareContext(ctx, getBitLockerRecoveryKeyAccess); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBitLockerRecoveryKeyAccess: %w", err)
+	}
+	if q.getBitLockerRecoveryKeysStmt, err = db.PrepareContext(ctx, getBitLockerRecoveryKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBitLockerRecoveryKeys: %w", err)
+	}
+	if q.getBitLockerRotationStmt, err = db.PrepareContext(ctx, getBitLockerRotation); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBitLockerRotation: %w", err)
+	}
+	if q.getCertificateChallengeStmt, err = db.PrepareContext(ctx, getCertificateChallenge); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCertificateChallenge: %w", err)
+	}
+	if q.getCertificateProfileStmt, err = db.PrepareContext(ctx, getCertificateProfile); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCertificateProfile: %w", err)
+	}
+	if q.getCertificateProfilesStmt, err = db.PrepareContext(ctx, getCertificateProfiles); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCertificateProfiles: %w", err)
+	}
+	if q.getDeployedPayloadsStmt, err = db.PrepareContext(ctx, getDeployedPayloads); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeployedPayloads: %w", err)
+	}
 	if q.getDeviceStmt, err = db.PrepareContext(ctx, getDevice); err != nil {
 		return nil, fmt.Errorf("error preparing query GetDevice: %w", err)
 	}
+	if q.getDeviceAppAssignmentsStmt, err = db.PrepareContext(ctx, getDeviceAppAssignments); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceAppAssignments: %w", err)
+	}
+	if q.getDeviceAppInstallsStmt, err = db.PrepareContext(ctx, getDeviceAppInstalls); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceAppInstalls: %w", err)
+	}
+	if q.getDeviceBitLockerRecoveryKeysStmt, err = db.PrepareContext(ctx, getDeviceBitLockerRecoveryKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceBitLockerRecoveryKeys: %w", err)
+	}
+	if q.getDeviceBitLockerRecoveryPasswordsStmt, err = db.PrepareContext(ctx, getDeviceBitLockerRecoveryPasswords); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceBitLockerRecoveryPasswords: %w", err)
+	}
 	if q.getDeviceByUDIDStmt, err = db.PrepareContext(ctx, getDeviceByUDID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetDeviceByUDID: %w", err)
 	}
+	if q.getDeviceByUDIDForUpdateStmt, err = db.PrepareContext(ctx, getDeviceByUDIDForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceByUDIDForUpdate: %w", err)
+	}
+	if q.getDeviceCertificateProfilesStmt, err = db.PrepareContext(ctx, getDeviceCertificateProfiles); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceCertificateProfiles: %w", err)
+	}
+	if q.getDeviceInventoryStmt, err = db.PrepareContext(ctx, getDeviceInventory); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceInventory: %w", err)
+	}
+	if q.getDeviceInventoryAppsStmt, err = db.PrepareContext(ctx, getDeviceInventoryApps); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceInventoryApps: %w", err)
+	}
+	if q.getDeviceIssuedCertificatesStmt, err = db.PrepareContext(ctx, getDeviceIssuedCertificates); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceIssuedCertificates: %w", err)
+	}
+	if q.getDeviceLocalAdminPasswordsStmt, err = db.PrepareContext(ctx, getDeviceLocalAdminPasswords); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceLocalAdminPasswords: %w", err)
+	}
+	if q.getDeviceLocalAdminProfilesStmt, err = db.PrepareContext(ctx, getDeviceLocalAdminProfiles); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceLocalAdminProfiles: %w", err)
+	}
+	if q.getDeviceScriptRunsStmt, err = db.PrepareContext(ctx, getDeviceScriptRuns); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceScriptRuns: %w", err)
+	}
+	if q.getDeviceScriptsStmt, err = db.PrepareContext(ctx, getDeviceScripts); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeviceScripts: %w", err)
+	}
 	if q.getDevicesStmt, err = db.PrepareContext(ctx, getDevices); err != nil {
 		return nil, fmt.Errorf("error preparing query GetDevices: %w", err)
 	}
-	if q.getDevicesDetachedPayloadsStmt, err = db.PrepareContext(ctx, getDevicesDetachedPayloads); err != nil {
-		return nil, fmt.Errorf("error preparing query GetDevicesDetachedPayloads: %w", err)
+	if q.getDevicesAssignmentSchedulesStmt, err = db.PrepareContext(ctx, getDevicesAssignmentSchedules); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDevicesAssignmentSchedules: %w", err)
+	}
+	if q.getDevicesAssignmentWindowsStmt, err = db.PrepareContext(ctx, getDevicesAssignmentWindows); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDevicesAssignmentWindows: %w", err)
+	}
+	if q.getDevicesPayloadCandidatesStmt, err = db.PrepareContext(ctx, getDevicesPayloadCandidates); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDevicesPayloadCandidates: %w", err)
 	}
 	if q.getDevicesPayloadsStmt, err = db.PrepareContext(ctx, getDevicesPayloads); err != nil {
 		return nil, fmt.Errorf("error preparing query GetDevicesPayloads: %w", err)
 	}
-	if q.getDevicesPayloadsAwaitingDeploymentStmt, err = db.PrepareContext(ctx, getDevicesPayloadsAwaitingDeployment); err != nil {
-		return nil, fmt.Errorf("error preparing query GetDevicesPayloadsAwaitingDeployment: %w", err)
+	if q.getDevicesPendingCommandsStmt, err = db.PrepareContext(ctx, getDevicesPendingCommands); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDevicesPendingCommands: %w", err)
+	}
+	if q.getDevicesRolloutCandidatesStmt, err = db.PrepareContext(ctx, getDevicesRolloutCandidates); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDevicesRolloutCandidates: %w", err)
+	}
+	if q.getDynamicGroupsStmt, err = db.PrepareContext(ctx, getDynamicGroups); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDynamicGroups: %w", err)
+	}
+	if q.getEnrollmentAttemptStmt, err = db.PrepareContext(ctx, getEnrollmentAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEnrollmentAttempt: %w", err)
+	}
+	if q.getEnrollmentAttemptsStmt, err = db.PrepareContext(ctx, getEnrollmentAttempts); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEnrollmentAttempts: %w", err)
+	}
+	if q.getEnrollmentBrandingStmt, err = db.PrepareContext(ctx, getEnrollmentBranding); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEnrollmentBranding: %w", err)
+	}
+	if q.getEnrollmentBrandingsStmt, err = db.PrepareContext(ctx, getEnrollmentBrandings); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEnrollmentBrandings: %w", err)
+	}
+	if q.getEnrollmentResponseStmt, err = db.PrepareContext(ctx, getEnrollmentResponse); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEnrollmentResponse: %w", err)
+	}
+	if q.getExpiringIssuedCertificatesStmt, err = db.PrepareContext(ctx, getExpiringIssuedCertificates); err != nil {
+		return nil, fmt.Errorf("error preparing query GetExpiringIssuedCertificates: %w", err)
+	}
+	if q.getFailedScriptRunsStmt, err = db.PrepareContext(ctx, getFailedScriptRuns); err != nil {
+		return nil, fmt.Errorf("error preparing query GetFailedScriptRuns: %w", err)
 	}
 	if q.getGroupStmt, err = db.PrepareContext(ctx, getGroup); err != nil {
 		return nil, fmt.Errorf("error preparing query GetGroup: %w", err)
 	}
+	if q.getGroupDevicesStmt, err = db.PrepareContext(ctx, getGroupDevices); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGroupDevices: %w", err)
+	}
+	if q.getGroupPoliciesStmt, err = db.PrepareContext(ctx, getGroupPolicies); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGroupPolicies: %w", err)
+	}
+	if q.getGroupPolicyScheduleStmt, err = db.PrepareContext(ctx, getGroupPolicySchedule); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGroupPolicySchedule: %w", err)
+	}
+	if q.getGroupPolicyWindowsStmt, err = db.PrepareContext(ctx, getGroupPolicyWindows); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGroupPolicyWindows: %w", err)
+	}
 	if q.getGroupsStmt, err = db.PrepareContext(ctx, getGroups); err != nil {
 		return nil, fmt.Errorf("error preparing query GetGroups: %w", err)
 	}
+	if q.getGroupsPayloadCandidatesStmt, err = db.PrepareContext(ctx, getGroupsPayloadCandidates); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGroupsPayloadCandidates: %w", err)
+	}
+	if q.getIssuedCertificateStmt, err = db.PrepareContext(ctx, getIssuedCertificate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetIssuedCertificate: %w", err)
+	}
+	if q.getLatestIssuedCertificateStmt, err = db.PrepareContext(ctx, getLatestIssuedCertificate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestIssuedCertificate: %w", err)
+	}
+	if q.getLatestTermsOfServiceStmt, err = db.PrepareContext(ctx, getLatestTermsOfService); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestTermsOfService: %w", err)
+	}
+	if q.getLocalAdminPasswordStmt, err = db.PrepareContext(ctx, getLocalAdminPassword); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLocalAdminPassword: %w", err)
+	}
+	if q.getLocalAdminPasswordAccessStmt, err = db.PrepareContext(ctx, getLocalAdminPasswordAccess); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLocalAdminPasswordAccess: %w", err)
+	}
+	if q.getLocalAdminProfileStmt, err = db.PrepareContext(ctx, getLocalAdminProfile); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLocalAdminProfile: %w", err)
+	}
+	if q.getLocalAdminProfilesStmt, err = db.PrepareContext(ctx, getLocalAdminProfiles); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLocalAdminProfiles: %w", err)
+	}
+	if q.getPayloadsRolloutsStmt, err = db.PrepareContext(ctx, getPayloadsRollouts); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPayloadsRollouts: %w", err)
+	}
 	if q.getPoliciesStmt, err = db.PrepareContext(ctx, getPolicies); err != nil {
 		return nil, fmt.Errorf("error preparing query GetPolicies: %w", err)
 	}
@@ -79,18 +406,117 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getPolicyStmt, err = db.PrepareContext(ctx, getPolicy); err != nil {
 		return nil, fmt.Errorf("error preparing query GetPolicy: %w", err)
 	}
+	if q.getPolicyPayloadStmt, err = db.PrepareContext(ctx, getPolicyPayload); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPolicyPayload: %w", err)
+	}
+	if q.getPolicyVersionStmt, err = db.PrepareContext(ctx, getPolicyVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPolicyVersion: %w", err)
+	}
+	if q.getPolicyVersionsStmt, err = db.PrepareContext(ctx, getPolicyVersions); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPolicyVersions: %w", err)
+	}
+	if q.getProfileIssuedCertificatesStmt, err = db.PrepareContext(ctx, getProfileIssuedCertificates); err != nil {
+		return nil, fmt.Errorf("error preparing query GetProfileIssuedCertificates: %w", err)
+	}
 	if q.getRawCertStmt, err = db.PrepareContext(ctx, getRawCert); err != nil {
 		return nil, fmt.Errorf("error preparing query GetRawCert: %w", err)
 	}
+	if q.getRevokedCertificatesStmt, err = db.PrepareContext(ctx, getRevokedCertificates); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRevokedCertificates: %w", err)
+	}
+	if q.getRolloutStmt, err = db.PrepareContext(ctx, getRollout); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRollout: %w", err)
+	}
+	if q.getRolloutResultsStmt, err = db.PrepareContext(ctx, getRolloutResults); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRolloutResults: %w", err)
+	}
+	if q.getRolloutStageResultsStmt, err = db.PrepareContext(ctx, getRolloutStageResults); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRolloutStageResults: %w", err)
+	}
+	if q.getRolloutStagesStmt, err = db.PrepareContext(ctx, getRolloutStages); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRolloutStages: %w", err)
+	}
+	if q.getRolloutsStmt, err = db.PrepareContext(ctx, getRollouts); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRollouts: %w", err)
+	}
+	if q.getScriptStmt, err = db.PrepareContext(ctx, getScript); err != nil {
+		return nil, fmt.Errorf("error preparing query GetScript: %w", err)
+	}
+	if q.getScriptRunsStmt, err = db.PrepareContext(ctx, getScriptRuns); err != nil {
+		return nil, fmt.Errorf("error preparing query GetScriptRuns: %w", err)
+	}
+	if q.getScriptsStmt, err = db.PrepareContext(ctx, getScripts); err != nil {
+		return nil, fmt.Errorf("error preparing query GetScripts: %w", err)
+	}
+	if q.getTermsOfServiceStmt, err = db.PrepareContext(ctx, getTermsOfService); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTermsOfService: %w", err)
+	}
+	if q.getTermsOfServiceAcceptancesStmt, err = db.PrepareContext(ctx, getTermsOfServiceAcceptances); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTermsOfServiceAcceptances: %w", err)
+	}
+	if q.getTermsOfServiceReportStmt, err = db.PrepareContext(ctx, getTermsOfServiceReport); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTermsOfServiceReport: %w", err)
+	}
+	if q.getTermsOfServiceVersionsStmt, err = db.PrepareContext(ctx, getTermsOfServiceVersions); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTermsOfServiceVersions: %w", err)
+	}
+	if q.getUnfinishedRolloutStmt, err = db.PrepareContext(ctx, getUnfinishedRollout); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUnfinishedRollout: %w", err)
+	}
 	if q.getUserStmt, err = db.PrepareContext(ctx, getUser); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUser: %w", err)
 	}
 	if q.getUserForLoginStmt, err = db.PrepareContext(ctx, getUserForLogin); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserForLogin: %w", err)
 	}
+	if q.getUserGroupStmt, err = db.PrepareContext(ctx, getUserGroup); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserGroup: %w", err)
+	}
+	if q.getUserGroupMembersStmt, err = db.PrepareContext(ctx, getUserGroupMembers); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserGroupMembers: %w", err)
+	}
+	if q.getUserGroupPoliciesStmt, err = db.PrepareContext(ctx, getUserGroupPolicies); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserGroupPolicies: %w", err)
+	}
+	if q.getUserGroupsStmt, err = db.PrepareContext(ctx, getUserGroups); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserGroups: %w", err)
+	}
 	if q.getUsersStmt, err = db.PrepareContext(ctx, getUsers); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUsers: %w", err)
 	}
+	if q.getUsersPayloadCandidatesStmt, err = db.PrepareContext(ctx, getUsersPayloadCandidates); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUsersPayloadCandidates: %w", err)
+	}
+	if q.hasAcceptedTermsOfServiceStmt, err = db.PrepareContext(ctx, hasAcceptedTermsOfService); err != nil {
+		return nil, fmt.Errorf("error preparing query HasAcceptedTermsOfService: %w", err)
+	}
+	if q.incrementPolicyVersionStmt, err = db.PrepareContext(ctx, incrementPolicyVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query IncrementPolicyVersion: %w", err)
+	}
+	if q.installedDeviceAppsStmt, err = db.PrepareContext(ctx, installedDeviceApps); err != nil {
+		return nil, fmt.Errorf("error preparing query InstalledDeviceApps: %w", err)
+	}
+	if q.invalidatePayloadCacheStmt, err = db.PrepareContext(ctx, invalidatePayloadCache); err != nil {
+		return nil, fmt.Errorf("error preparing query InvalidatePayloadCache: %w", err)
+	}
+	if q.isDeviceInGroupStmt, err = db.PrepareContext(ctx, isDeviceInGroup); err != nil {
+		return nil, fmt.Errorf("error preparing query IsDeviceInGroup: %w", err)
+	}
+	if q.isUserInGroupStmt, err = db.PrepareContext(ctx, isUserInGroup); err != nil {
+		return nil, fmt.Errorf("error preparing query IsUserInGroup: %w", err)
+	}
+	if q.localAdminPasswordFailedStmt, err = db.PrepareContext(ctx, localAdminPasswordFailed); err != nil {
+		return nil, fmt.Errorf("error preparing query LocalAdminPasswordFailed: %w", err)
+	}
+	if q.lockDeviceNamesStmt, err = db.PrepareContext(ctx, lockDeviceNames); err != nil {
+		return nil, fmt.Errorf("error preparing query LockDeviceNames: %w", err)
+	}
+	if q.logBitLockerRecoveryKeyAccessStmt, err = db.PrepareContext(ctx, logBitLockerRecoveryKeyAccess); err != nil {
+		return nil, fmt.Errorf("error preparing query LogBitLockerRecoveryKeyAccess: %w", err)
+	}
+	if q.logLocalAdminPasswordAccessStmt, err = db.PrepareContext(ctx, logLocalAdminPasswordAccess); err != nil {
+		return nil, fmt.Errorf("error preparing query LogLocalAdminPasswordAccess: %w", err)
+	}
 	if q.newAzureADUserStmt, err = db.PrepareContext(ctx, newAzureADUser); err != nil {
 		return nil, fmt.Errorf("error preparing query NewAzureADUser: %w", err)
 	}
@@ -100,189 +526,1155 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.newDeviceCacheNodeStmt, err = db.PrepareContext(ctx, newDeviceCacheNode); err != nil {
 		return nil, fmt.Errorf("error preparing query NewDeviceCacheNode: %w", err)
 	}
+	if q.newDeviceCommandStmt, err = db.PrepareContext(ctx, newDeviceCommand); err != nil {
+		return nil, fmt.Errorf("error preparing query NewDeviceCommand: %w", err)
+	}
 	if q.newDeviceReplacingExistingStmt, err = db.PrepareContext(ctx, newDeviceReplacingExisting); err != nil {
 		return nil, fmt.Errorf("error preparing query NewDeviceReplacingExisting: %w", err)
 	}
 	if q.newDeviceReplacingExistingResetCacheStmt, err = db.PrepareContext(ctx, newDeviceReplacingExistingResetCache); err != nil {
 		return nil, fmt.Errorf("error preparing query NewDeviceReplacingExistingResetCache: %w", err)
 	}
+	if q.newDeviceReplacingExistingResetCommandsStmt, err = db.PrepareContext(ctx, newDeviceReplacingExistingResetCommands); err != nil {
+		return nil, fmt.Errorf("error preparing query NewDeviceReplacingExistingResetCommands: %w", err)
+	}
 	if q.newDeviceReplacingExistingResetInventoryStmt, err = db.PrepareContext(ctx, newDeviceReplacingExistingResetInventory); err != nil {
 		return nil, fmt.Errorf("error preparing query NewDeviceReplacingExistingResetInventory: %w", err)
 	}
+	if q.newEnrollmentAttemptStmt, err = db.PrepareContext(ctx, newEnrollmentAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query NewEnrollmentAttempt: %w", err)
+	}
+	if q.promoteRolloutStmt, err = db.PrepareContext(ctx, promoteRollout); err != nil {
+		return nil, fmt.Errorf("error preparing query PromoteRollout: %w", err)
+	}
+	if q.pruneEnrollmentAttemptsStmt, err = db.PrepareContext(ctx, pruneEnrollmentAttempts); err != nil {
+		return nil, fmt.Errorf("error preparing query PruneEnrollmentAttempts: %w", err)
+	}
+	if q.recordRolloutResultStmt, err = db.PrepareContext(ctx, recordRolloutResult); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordRolloutResult: %w", err)
+	}
+	if q.recordScriptRunStmt, err = db.PrepareContext(ctx, recordScriptRun); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordScriptRun: %w", err)
+	}
+	if q.removeBitLockerRecoveryKeyStmt, err = db.PrepareContext(ctx, removeBitLockerRecoveryKey); err != nil {
+		return nil, fmt.Errorf("error preparing query RemoveBitLockerRecoveryKey: %w", err)
+	}
+	if q.removeGroupDevicesStmt, err = db.PrepareContext(ctx, removeGroupDevices); err != nil {
+		return nil, fmt.Errorf("error preparing query RemoveGroupDevices: %w", err)
+	}
+	if q.removeUserGroupMembersStmt, err = db.PrepareContext(ctx, removeUserGroupMembers); err != nil {
+		return nil, fmt.Errorf("error preparing query RemoveUserGroupMembers: %w", err)
+	}
+	if q.requestBitLockerRotationStmt, err = db.PrepareContext(ctx, requestBitLockerRotation); err != nil {
+		return nil, fmt.Errorf("error preparing query RequestBitLockerRotation: %w", err)
+	}
+	if q.restoreBitLockerRecoveryKeyStmt, err = db.PrepareContext(ctx, restoreBitLockerRecoveryKey); err != nil {
+		return nil, fmt.Errorf("error preparing query RestoreBitLockerRecoveryKey: %w", err)
+	}
+	if q.revokeIssuedCertificateStmt, err = db.PrepareContext(ctx, revokeIssuedCertificate); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeIssuedCertificate: %w", err)
+	}
+	if q.scheduleLocalAdminRotationStmt, err = db.PrepareContext(ctx, scheduleLocalAdminRotation); err != nil {
+		return nil, fmt.Errorf("error preparing query ScheduleLocalAdminRotation: %w", err)
+	}
+	if q.setAppAssignmentStmt, err = db.PrepareContext(ctx, setAppAssignment); err != nil {
+		return nil, fmt.Errorf("error preparing query SetAppAssignment: %w", err)
+	}
+	if q.setAppInstallStateStmt, err = db.PrepareContext(ctx, setAppInstallState); err != nil {
+		return nil, fmt.Errorf("error preparing query SetAppInstallState: %w", err)
+	}
+	if q.setCertificateChallengeStmt, err = db.PrepareContext(ctx, setCertificateChallenge); err != nil {
+		return nil, fmt.Errorf("error preparing query SetCertificateChallenge: %w", err)
+	}
+	if q.setDeviceNameStmt, err = db.PrepareContext(ctx, setDeviceName); err != nil {
+		return nil, fmt.Errorf("error preparing query SetDeviceName: %w", err)
+	}
+	if q.setDeviceNamingStmt, err = db.PrepareContext(ctx, setDeviceNaming); err != nil {
+		return nil, fmt.Errorf("error preparing query SetDeviceNaming: %w", err)
+	}
 	if q.setDeviceStateStmt, err = db.PrepareContext(ctx, setDeviceState); err != nil {
 		return nil, fmt.Errorf("error preparing query SetDeviceState: %w", err)
 	}
+	if q.setEnrollmentBrandingStmt, err = db.PrepareContext(ctx, setEnrollmentBranding); err != nil {
+		return nil, fmt.Errorf("error preparing query SetEnrollmentBranding: %w", err)
+	}
+	if q.setEnrollmentResponseStmt, err = db.PrepareContext(ctx, setEnrollmentResponse); err != nil {
+		return nil, fmt.Errorf("error preparing query SetEnrollmentResponse: %w", err)
+	}
+	if q.setGroupPolicyScheduleStmt, err = db.PrepareContext(ctx, setGroupPolicySchedule); err != nil {
+		return nil, fmt.Errorf("error preparing query SetGroupPolicySchedule: %w", err)
+	}
+	if q.setLocalAdminPendingPasswordStmt, err = db.PrepareContext(ctx, setLocalAdminPendingPassword); err != nil {
+		return nil, fmt.Errorf("error preparing query SetLocalAdminPendingPassword: %w", err)
+	}
+	if q.setRolloutStateStmt, err = db.PrepareContext(ctx, setRolloutState); err != nil {
+		return nil, fmt.Errorf("error preparing query SetRolloutState: %w", err)
+	}
 	if q.settingsStmt, err = db.PrepareContext(ctx, settings); err != nil {
 		return nil, fmt.Errorf("error preparing query Settings: %w", err)
 	}
+	if q.updateCertificateProfileStmt, err = db.PrepareContext(ctx, updateCertificateProfile); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateCertificateProfile: %w", err)
+	}
+	if q.updateDeployedPolicyVersionStmt, err = db.PrepareContext(ctx, updateDeployedPolicyVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateDeployedPolicyVersion: %w", err)
+	}
 	if q.updateDeviceInventoryNodeStmt, err = db.PrepareContext(ctx, updateDeviceInventoryNode); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateDeviceInventoryNode: %w", err)
 	}
+	if q.updateGroupStmt, err = db.PrepareContext(ctx, updateGroup); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateGroup: %w", err)
+	}
+	if q.updateLocalAdminProfileStmt, err = db.PrepareContext(ctx, updateLocalAdminProfile); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateLocalAdminProfile: %w", err)
+	}
+	if q.updatePolicyStmt, err = db.PrepareContext(ctx, updatePolicy); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdatePolicy: %w", err)
+	}
+	if q.updatePolicyPayloadStmt, err = db.PrepareContext(ctx, updatePolicyPayload); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdatePolicyPayload: %w", err)
+	}
+	if q.updateScriptStmt, err = db.PrepareContext(ctx, updateScript); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScript: %w", err)
+	}
+	if q.updateUserGroupStmt, err = db.PrepareContext(ctx, updateUserGroup); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserGroup: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
-	if q.createRawCertStmt != nil {
-		if cerr := q.createRawCertStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing createRawCertStmt: %w", cerr)
+	if q.acceptTermsOfServiceStmt != nil {
+		if cerr := q.acceptTermsOfServiceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing acceptTermsOfServiceStmt: %w", cerr)
 		}
 	}
-	if q.createUserStmt != nil {
-		if cerr := q.createUserStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing createUserStmt: %w", cerr)
+	if q.addDeviceInventoryAppStmt != nil {
+		if cerr := q.addDeviceInventoryAppStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addDeviceInventoryAppStmt: %w", cerr)
 		}
 	}
-	if q.deleteDeviceCacheNodeStmt != nil {
-		if cerr := q.deleteDeviceCacheNodeStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing deleteDeviceCacheNodeStmt: %w", cerr)
+	if q.addGroupDevicesStmt != nil {
+		if cerr := q.addGroupDevicesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addGroupDevicesStmt: %w", cerr)
 		}
 	}
-	if q.deviceCheckinStatusStmt != nil {
-		if cerr := q.deviceCheckinStatusStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing deviceCheckinStatusStmt: %w", cerr)
+	if q.addUserGroupMembersStmt != nil {
+		if cerr := q.addUserGroupMembersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addUserGroupMembersStmt: %w", cerr)
 		}
 	}
-	if q.deviceUserUnenrollmentStmt != nil {
-		if cerr := q.deviceUserUnenrollmentStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing deviceUserUnenrollmentStmt: %w", cerr)
+	if q.attachGroupPolicyStmt != nil {
+		if cerr := q.attachGroupPolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing attachGroupPolicyStmt: %w", cerr)
 		}
 	}
-	if q.getBasicDeviceStmt != nil {
-		if cerr := q.getBasicDeviceStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getBasicDeviceStmt: %w", cerr)
+	if q.attachUserGroupPolicyStmt != nil {
+		if cerr := q.attachUserGroupPolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing attachUserGroupPolicyStmt: %w", cerr)
 		}
 	}
-	if q.getBasicDeviceScopedGroupsStmt != nil {
-		if cerr := q.getBasicDeviceScopedGroupsStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getBasicDeviceScopedGroupsStmt: %w", cerr)
+	if q.claimCertificateChallengeStmt != nil {
+		if cerr := q.claimCertificateChallengeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimCertificateChallengeStmt: %w", cerr)
 		}
 	}
-	if q.getBasicDeviceScopedPoliciesStmt != nil {
-		if cerr := q.getBasicDeviceScopedPoliciesStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getBasicDeviceScopedPoliciesStmt: %w", cerr)
+	if q.clearDeviceInventoryAppsStmt != nil {
+		if cerr := q.clearDeviceInventoryAppsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearDeviceInventoryAppsStmt: %w", cerr)
 		}
 	}
-	if q.getDeviceStmt != nil {
-		if cerr := q.getDeviceStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getDeviceStmt: %w", cerr)
+	if q.confirmLocalAdminPasswordStmt != nil {
+		if cerr := q.confirmLocalAdminPasswordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing confirmLocalAdminPasswordStmt: %w", cerr)
 		}
 	}
-	if q.getDeviceByUDIDStmt != nil {
-		if cerr := q.getDeviceByUDIDStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getDeviceByUDIDStmt: %w", cerr)
+	if q.countDeviceScriptsStmt != nil {
+		if cerr := q.countDeviceScriptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countDeviceScriptsStmt: %w", cerr)
 		}
 	}
-	if q.getDevicesStmt != nil {
-		if cerr := q.getDevicesStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getDevicesStmt: %w", cerr)
+	if q.createAppStmt != nil {
+		if cerr := q.createAppStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAppStmt: %w", cerr)
 		}
 	}
-	if q.getDevicesDetachedPayloadsStmt != nil {
-		if cerr := q.getDevicesDetachedPayloadsStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getDevicesDetachedPayloadsStmt: %w", cerr)
+	if q.createBitLockerRecoveryKeyStmt != nil {
+		if cerr := q.createBitLockerRecoveryKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createBitLockerRecoveryKeyStmt: %w", cerr)
 		}
 	}
-	if q.getDevicesPayloadsStmt != nil {
-		if cerr := q.getDevicesPayloadsStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getDevicesPayloadsStmt: %w", cerr)
+	if q.createCertificateProfileStmt != nil {
+		if cerr := q.createCertificateProfileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createCertificateProfileStmt: %w", cerr)
 		}
 	}
-	if q.getDevicesPayloadsAwaitingDeploymentStmt != nil {
-		if cerr := q.getDevicesPayloadsAwaitingDeploymentStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getDevicesPayloadsAwaitingDeploymentStmt: %w", cerr)
+	if q.createGroupStmt != nil {
+		if cerr := q.createGroupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createGroupStmt: %w", cerr)
 		}
 	}
-	if q.getGroupStmt != nil {
-		if cerr := q.getGroupStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getGroupStmt: %w", cerr)
+	if q.createGroupPolicyWindowStmt != nil {
+		if cerr := q.createGroupPolicyWindowStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createGroupPolicyWindowStmt: %w", cerr)
 		}
 	}
-	if q.getGroupsStmt != nil {
-		if cerr := q.getGroupsStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getGroupsStmt: %w", cerr)
+	if q.createIssuedCertificateStmt != nil {
+		if cerr := q.createIssuedCertificateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createIssuedCertificateStmt: %w", cerr)
 		}
 	}
-	if q.getPoliciesStmt != nil {
-		if cerr := q.getPoliciesStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getPoliciesStmt: %w", cerr)
+	if q.createLocalAdminProfileStmt != nil {
+		if cerr := q.createLocalAdminProfileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createLocalAdminProfileStmt: %w", cerr)
 		}
 	}
-	if q.getPoliciesPayloadsStmt != nil {
-		if cerr := q.getPoliciesPayloadsStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getPoliciesPayloadsStmt: %w", cerr)
+	if q.createPolicyStmt != nil {
+		if cerr := q.createPolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPolicyStmt: %w", cerr)
 		}
 	}
-	if q.getPolicyStmt != nil {
-		if cerr := q.getPolicyStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getPolicyStmt: %w", cerr)
+	if q.createPolicyPayloadStmt != nil {
+		if cerr := q.createPolicyPayloadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPolicyPayloadStmt: %w", cerr)
 		}
 	}
-	if q.getRawCertStmt != nil {
-		if cerr := q.getRawCertStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getRawCertStmt: %w", cerr)
+	if q.createPolicyVersionStmt != nil {
+		if cerr := q.createPolicyVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPolicyVersionStmt: %w", cerr)
 		}
 	}
-	if q.getUserStmt != nil {
-		if cerr := q.getUserStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getUserStmt: %w", cerr)
+	if q.createRawCertStmt != nil {
+		if cerr := q.createRawCertStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createRawCertStmt: %w", cerr)
 		}
 	}
-	if q.getUserForLoginStmt != nil {
-		if cerr := q.getUserForLoginStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getUserForLoginStmt: %w", cerr)
+	if q.createRolloutStmt != nil {
+		if cerr := q.createRolloutStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createRolloutStmt: %w", cerr)
 		}
 	}
-	if q.getUsersStmt != nil {
-		if cerr := q.getUsersStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getUsersStmt: %w", cerr)
+	if q.createRolloutStageStmt != nil {
+		if cerr := q.createRolloutStageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createRolloutStageStmt: %w", cerr)
 		}
 	}
-	if q.newAzureADUserStmt != nil {
-		if cerr := q.newAzureADUserStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing newAzureADUserStmt: %w", cerr)
+	if q.createScriptStmt != nil {
+		if cerr := q.createScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createScriptStmt: %w", cerr)
 		}
 	}
-	if q.newDeviceStmt != nil {
-		if cerr := q.newDeviceStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing newDeviceStmt: %w", cerr)
+	if q.createTermsOfServiceStmt != nil {
+		if cerr := q.createTermsOfServiceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTermsOfServiceStmt: %w", cerr)
 		}
 	}
-	if q.newDeviceCacheNodeStmt != nil {
-		if cerr := q.newDeviceCacheNodeStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing newDeviceCacheNodeStmt: %w", cerr)
+	if q.createUserStmt != nil {
+		if cerr := q.createUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createUserStmt: %w", cerr)
 		}
 	}
-	if q.newDeviceReplacingExistingStmt != nil {
-		if cerr := q.newDeviceReplacingExistingStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing newDeviceReplacingExistingStmt: %w", cerr)
+	if q.createUserGroupStmt != nil {
+		if cerr := q.createUserGroupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createUserGroupStmt: %w", cerr)
 		}
 	}
-	if q.newDeviceReplacingExistingResetCacheStmt != nil {
+	if q.deleteAppStmt != nil {
+		if cerr := q.deleteAppStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAppStmt: %w", cerr)
+		}
+	}
+	if q.deleteAppAssignmentStmt != nil {
+		if cerr := q.deleteAppAssignmentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAppAssignmentStmt: %w", cerr)
+		}
+	}
+	if q.deleteBitLockerRotationStmt != nil {
+		if cerr := q.deleteBitLockerRotationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteBitLockerRotationStmt: %w", cerr)
+		}
+	}
+	if q.deleteCertificateProfileStmt != nil {
+		if cerr := q.deleteCertificateProfileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteCertificateProfileStmt: %w", cerr)
+		}
+	}
+	if q.deleteDeviceCacheNodeStmt != nil {
+		if cerr := q.deleteDeviceCacheNodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteDeviceCacheNodeStmt: %w", cerr)
+		}
+	}
+	if q.deleteEnrollmentBrandingStmt != nil {
+		if cerr := q.deleteEnrollmentBrandingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteEnrollmentBrandingStmt: %w", cerr)
+		}
+	}
+	if q.deleteGroupStmt != nil {
+		if cerr := q.deleteGroupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGroupStmt: %w", cerr)
+		}
+	}
+	if q.deleteGroupAppAssignmentsStmt != nil {
+		if cerr := q.deleteGroupAppAssignmentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGroupAppAssignmentsStmt: %w", cerr)
+		}
+	}
+	if q.deleteGroupCertificateProfilesStmt != nil {
+		if cerr := q.deleteGroupCertificateProfilesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGroupCertificateProfilesStmt: %w", cerr)
+		}
+	}
+	if q.deleteGroupDevicesStmt != nil {
+		if cerr := q.deleteGroupDevicesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGroupDevicesStmt: %w", cerr)
+		}
+	}
+	if q.deleteGroupLocalAdminProfilesStmt != nil {
+		if cerr := q.deleteGroupLocalAdminProfilesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGroupLocalAdminProfilesStmt: %w", cerr)
+		}
+	}
+	if q.deleteGroupPoliciesStmt != nil {
+		if cerr := q.deleteGroupPoliciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGroupPoliciesStmt: %w", cerr)
+		}
+	}
+	if q.deleteGroupPolicyWindowsStmt != nil {
+		if cerr := q.deleteGroupPolicyWindowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGroupPolicyWindowsStmt: %w", cerr)
+		}
+	}
+	if q.deleteGroupRolloutsStmt != nil {
+		if cerr := q.deleteGroupRolloutsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGroupRolloutsStmt: %w", cerr)
+		}
+	}
+	if q.deleteGroupScriptsStmt != nil {
+		if cerr := q.deleteGroupScriptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGroupScriptsStmt: %w", cerr)
+		}
+	}
+	if q.deleteLocalAdminProfileStmt != nil {
+		if cerr := q.deleteLocalAdminProfileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLocalAdminProfileStmt: %w", cerr)
+		}
+	}
+	if q.deleteOrphanedPayloadsStmt != nil {
+		if cerr := q.deleteOrphanedPayloadsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteOrphanedPayloadsStmt: %w", cerr)
+		}
+	}
+	if q.deletePolicyStmt != nil {
+		if cerr := q.deletePolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deletePolicyStmt: %w", cerr)
+		}
+	}
+	if q.deletePolicyGroupsStmt != nil {
+		if cerr := q.deletePolicyGroupsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deletePolicyGroupsStmt: %w", cerr)
+		}
+	}
+	if q.deletePolicyRolloutsStmt != nil {
+		if cerr := q.deletePolicyRolloutsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deletePolicyRolloutsStmt: %w", cerr)
+		}
+	}
+	if q.deletePolicyUserGroupsStmt != nil {
+		if cerr := q.deletePolicyUserGroupsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deletePolicyUserGroupsStmt: %w", cerr)
+		}
+	}
+	if q.deleteScriptStmt != nil {
+		if cerr := q.deleteScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteScriptStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserGroupStmt != nil {
+		if cerr := q.deleteUserGroupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserGroupStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserGroupMembersStmt != nil {
+		if cerr := q.deleteUserGroupMembersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserGroupMembersStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserGroupPoliciesStmt != nil {
+		if cerr := q.deleteUserGroupPoliciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserGroupPoliciesStmt: %w", cerr)
+		}
+	}
+	if q.detachGroupPolicyStmt != nil {
+		if cerr := q.detachGroupPolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing detachGroupPolicyStmt: %w", cerr)
+		}
+	}
+	if q.detachPoliciesPayloadsStmt != nil {
+		if cerr := q.detachPoliciesPayloadsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing detachPoliciesPayloadsStmt: %w", cerr)
+		}
+	}
+	if q.detachPolicyPayloadStmt != nil {
+		if cerr := q.detachPolicyPayloadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing detachPolicyPayloadStmt: %w", cerr)
+		}
+	}
+	if q.detachUserGroupPolicyStmt != nil {
+		if cerr := q.detachUserGroupPolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing detachUserGroupPolicyStmt: %w", cerr)
+		}
+	}
+	if q.deviceCheckinStatusStmt != nil {
+		if cerr := q.deviceCheckinStatusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deviceCheckinStatusStmt: %w", cerr)
+		}
+	}
+	if q.deviceCommandSentStmt != nil {
+		if cerr := q.deviceCommandSentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deviceCommandSentStmt: %w", cerr)
+		}
+	}
+	if q.deviceNameInUseStmt != nil {
+		if cerr := q.deviceNameInUseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deviceNameInUseStmt: %w", cerr)
+		}
+	}
+	if q.deviceUserUnenrollmentStmt != nil {
+		if cerr := q.deviceUserUnenrollmentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deviceUserUnenrollmentStmt: %w", cerr)
+		}
+	}
+	if q.getAllDevicesStmt != nil {
+		if cerr := q.getAllDevicesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAllDevicesStmt: %w", cerr)
+		}
+	}
+	if q.getAllGroupsStmt != nil {
+		if cerr := q.getAllGroupsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAllGroupsStmt: %w", cerr)
+		}
+	}
+	if q.getAllPoliciesStmt != nil {
+		if cerr := q.getAllPoliciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAllPoliciesStmt: %w", cerr)
+		}
+	}
+	if q.getAllUserGroupsStmt != nil {
+		if cerr := q.getAllUserGroupsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAllUserGroupsStmt: %w", cerr)
+		}
+	}
+	if q.getAppStmt != nil {
+		if cerr := q.getAppStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAppStmt: %w", cerr)
+		}
+	}
+	if q.getAppAssignmentsStmt != nil {
+		if cerr := q.getAppAssignmentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAppAssignmentsStmt: %w", cerr)
+		}
+	}
+	if q.getAppByHashStmt != nil {
+		if cerr := q.getAppByHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAppByHashStmt: %w", cerr)
+		}
+	}
+	if q.getAppByIdentifierStmt != nil {
+		if cerr := q.getAppByIdentifierStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAppByIdentifierStmt: %w", cerr)
+		}
+	}
+	if q.getAppInstallsStmt != nil {
+		if cerr := q.getAppInstallsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAppInstallsStmt: %w", cerr)
+		}
+	}
+	if q.getAppsStmt != nil {
+		if cerr := q.getAppsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAppsStmt: %w", cerr)
+		}
+	}
+	if q.getBasicDeviceStmt != nil {
+		if cerr := q.getBasicDeviceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBasicDeviceStmt: %w", cerr)
+		}
+	}
+	if q.getBasicDeviceScopedGroupsStmt != nil {
+		if cerr := q.getBasicDeviceScopedGroupsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBasicDeviceScopedGroupsStmt: %w", cerr)
+		}
+	}
+	if q.getBasicDeviceScopedPoliciesStmt != nil {
+		if cerr := q.getBasicDeviceScopedPoliciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBasicDeviceScopedPoliciesStmt: %w", cerr)
+		}
+	}
+	if q.getBitLockerRecoveryKeyAccessStmt != nil {
+		if cerr := q.getBitLockerRecoveryKeyAccessStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBitLockerRecoveryKeyAccessStmt: %w", cerr)
+		}
+	}
+	if q.getBitLockerRecoveryKeysStmt != nil {
+		if cerr := q.getBitLockerRecoveryKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBitLockerRecoveryKeysStmt: %w", cerr)
+		}
+	}
+	if q.getBitLockerRotationStmt != nil {
+		if cerr := q.getBitLockerRotationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBitLockerRotationStmt: %w", cerr)
+		}
+	}
+	if q.getCertificateChallengeStmt != nil {
+		if cerr := q.getCertificateChallengeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCertificateChallengeStmt: %w", cerr)
+		}
+	}
+	if q.getCertificateProfileStmt != nil {
+		if cerr := q.getCertificateProfileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCertificateProfileStmt: %w", cerr)
+		}
+	}
+	if q.getCertificateProfilesStmt != nil {
+		if cerr := q.getCertificateProfilesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCertificateProfilesStmt: %w", cerr)
+		}
+	}
+	if q.getDeployedPayloadsStmt != nil {
+		if cerr := q.getDeployedPayloadsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeployedPayloadsStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceStmt != nil {
+		if cerr := q.getDeviceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceAppAssignmentsStmt != nil {
+		if cerr := q.getDeviceAppAssignmentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceAppAssignmentsStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceAppInstallsStmt != nil {
+		if cerr := q.getDeviceAppInstallsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceAppInstallsStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceBitLockerRecoveryKeysStmt != nil {
+		if cerr := q.getDeviceBitLockerRecoveryKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceBitLockerRecoveryKeysStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceBitLockerRecoveryPasswordsStmt != nil {
+		if cerr := q.getDeviceBitLockerRecoveryPasswordsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceBitLockerRecoveryPasswordsStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceByUDIDStmt != nil {
+		if cerr := q.getDeviceByUDIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceByUDIDStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceByUDIDForUpdateStmt != nil {
+		if cerr := q.getDeviceByUDIDForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceByUDIDForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceCertificateProfilesStmt != nil {
+		if cerr := q.getDeviceCertificateProfilesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceCertificateProfilesStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceInventoryStmt != nil {
+		if cerr := q.getDeviceInventoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceInventoryStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceInventoryAppsStmt != nil {
+		if cerr := q.getDeviceInventoryAppsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceInventoryAppsStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceIssuedCertificatesStmt != nil {
+		if cerr := q.getDeviceIssuedCertificatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceIssuedCertificatesStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceLocalAdminPasswordsStmt != nil {
+		if cerr := q.getDeviceLocalAdminPasswordsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceLocalAdminPasswordsStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceLocalAdminProfilesStmt != nil {
+		if cerr := q.getDeviceLocalAdminProfilesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceLocalAdminProfilesStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceScriptRunsStmt != nil {
+		if cerr := q.getDeviceScriptRunsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceScriptRunsStmt: %w", cerr)
+		}
+	}
+	if q.getDeviceScriptsStmt != nil {
+		if cerr := q.getDeviceScriptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeviceScriptsStmt: %w", cerr)
+		}
+	}
+	if q.getDevicesStmt != nil {
+		if cerr := q.getDevicesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDevicesStmt: %w", cerr)
+		}
+	}
+	if q.getDevicesAssignmentSchedulesStmt != nil {
+		if cerr := q.getDevicesAssignmentSchedulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDevicesAssignmentSchedulesStmt: %w", cerr)
+		}
+	}
+	if q.getDevicesAssignmentWindowsStmt != nil {
+		if cerr := q.getDevicesAssignmentWindowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDevicesAssignmentWindowsStmt: %w", cerr)
+		}
+	}
+	if q.getDevicesPayloadCandidatesStmt != nil {
+		if cerr := q.getDevicesPayloadCandidatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDevicesPayloadCandidatesStmt: %w", cerr)
+		}
+	}
+	if q.getDevicesPayloadsStmt != nil {
+		if cerr := q.getDevicesPayloadsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDevicesPayloadsStmt: %w", cerr)
+		}
+	}
+	if q.getDevicesPendingCommandsStmt != nil {
+		if cerr := q.getDevicesPendingCommandsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDevicesPendingCommandsStmt: %w", cerr)
+		}
+	}
+	if q.getDevicesRolloutCandidatesStmt != nil {
+		if cerr := q.getDevicesRolloutCandidatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDevicesRolloutCandidatesStmt: %w", cerr)
+		}
+	}
+	if q.getDynamicGroupsStmt != nil {
+		if cerr := q.getDynamicGroupsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDynamicGroupsStmt: %w", cerr)
+		}
+	}
+	if q.getEnrollmentAttemptStmt != nil {
+		if cerr := q.getEnrollmentAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEnrollmentAttemptStmt: %w", cerr)
+		}
+	}
+	if q.getEnrollmentAttemptsStmt != nil {
+		if cerr := q.getEnrollmentAttemptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEnrollmentAttemptsStmt: %w", cerr)
+		}
+	}
+	if q.getEnrollmentBrandingStmt != nil {
+		if cerr := q.getEnrollmentBrandingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEnrollmentBrandingStmt: %w", cerr)
+		}
+	}
+	if q.getEnrollmentBrandingsStmt != nil {
+		if cerr := q.getEnrollmentBrandingsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEnrollmentBrandingsStmt: %w", cerr)
+		}
+	}
+	if q.getEnrollmentResponseStmt != nil {
+		if cerr := q.getEnrollmentResponseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEnrollmentResponseStmt: %w", cerr)
+		}
+	}
+	if q.getExpiringIssuedCertificatesStmt != nil {
+		if cerr := q.getExpiringIssuedCertificatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getExpiringIssuedCertificatesStmt: %w", cerr)
+		}
+	}
+	if q.getFailedScriptRunsStmt != nil {
+		if cerr := q.getFailedScriptRunsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getFailedScriptRunsStmt: %w", cerr)
+		}
+	}
+	if q.getGroupStmt != nil {
+		if cerr := q.getGroupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGroupStmt: %w", cerr)
+		}
+	}
+	if q.getGroupDevicesStmt != nil {
+		if cerr := q.getGroupDevicesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGroupDevicesStmt: %w", cerr)
+		}
+	}
+	if q.getGroupPoliciesStmt != nil {
+		if cerr := q.getGroupPoliciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGroupPoliciesStmt: %w", cerr)
+		}
+	}
+	if q.getGroupPolicyScheduleStmt != nil {
+		if cerr := q.getGroupPolicyScheduleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGroupPolicyScheduleStmt: %w", cerr)
+		}
+	}
+	if q.getGroupPolicyWindowsStmt != nil {
+		if cerr := q.getGroupPolicyWindowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGroupPolicyWindowsStmt: %w", cerr)
+		}
+	}
+	if q.getGroupsStmt != nil {
+		if cerr := q.getGroupsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGroupsStmt: %w", cerr)
+		}
+	}
+	if q.getGroupsPayloadCandidatesStmt != nil {
+		if cerr := q.getGroupsPayloadCandidatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGroupsPayloadCandidatesStmt: %w", cerr)
+		}
+	}
+	if q.getIssuedCertificateStmt != nil {
+		if cerr := q.getIssuedCertificateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getIssuedCertificateStmt: %w", cerr)
+		}
+	}
+	if q.getLatestIssuedCertificateStmt != nil {
+		if cerr := q.getLatestIssuedCertificateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestIssuedCertificateStmt: %w", cerr)
+		}
+	}
+	if q.getLatestTermsOfServiceStmt != nil {
+		if cerr := q.getLatestTermsOfServiceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestTermsOfServiceStmt: %w", cerr)
+		}
+	}
+	if q.getLocalAdminPasswordStmt != nil {
+		if cerr := q.getLocalAdminPasswordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLocalAdminPasswordStmt: %w", cerr)
+		}
+	}
+	if q.getLocalAdminPasswordAccessStmt != nil {
+		if cerr := q.getLocalAdminPasswordAccessStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLocalAdminPasswordAccessStmt: %w", cerr)
+		}
+	}
+	if q.getLocalAdminProfileStmt != nil {
+		if cerr := q.getLocalAdminProfileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLocalAdminProfileStmt: %w", cerr)
+		}
+	}
+	if q.getLocalAdminProfilesStmt != nil {
+		if cerr := q.getLocalAdminProfilesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLocalAdminProfilesStmt: %w", cerr)
+		}
+	}
+	if q.getPayloadsRolloutsStmt != nil {
+		if cerr := q.getPayloadsRolloutsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPayloadsRolloutsStmt: %w", cerr)
+		}
+	}
+	if q.getPoliciesStmt != nil {
+		if cerr := q.getPoliciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPoliciesStmt: %w", cerr)
+		}
+	}
+	if q.getPoliciesPayloadsStmt != nil {
+		if cerr := q.getPoliciesPayloadsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPoliciesPayloadsStmt: %w", cerr)
+		}
+	}
+	if q.getPolicyStmt != nil {
+		if cerr := q.getPolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPolicyStmt: %w", cerr)
+		}
+	}
+	if q.getPolicyPayloadStmt != nil {
+		if cerr := q.getPolicyPayloadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPolicyPayloadStmt: %w", cerr)
+		}
+	}
+	if q.getPolicyVersionStmt != nil {
+		if cerr := q.getPolicyVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPolicyVersionStmt: %w", cerr)
+		}
+	}
+	if q.getPolicyVersionsStmt != nil {
+		if cerr := q.getPolicyVersionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPolicyVersionsStmt: %w", cerr)
+		}
+	}
+	if q.getProfileIssuedCertificatesStmt != nil {
+		if cerr := q.getProfileIssuedCertificatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getProfileIssuedCertificatesStmt: %w", cerr)
+		}
+	}
+	if q.getRawCertStmt != nil {
+		if cerr := q.getRawCertStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRawCertStmt: %w", cerr)
+		}
+	}
+	if q.getRevokedCertificatesStmt != nil {
+		if cerr := q.getRevokedCertificatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRevokedCertificatesStmt: %w", cerr)
+		}
+	}
+	if q.getRolloutStmt != nil {
+		if cerr := q.getRolloutStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRolloutStmt: %w", cerr)
+		}
+	}
+	if q.getRolloutResultsStmt != nil {
+		if cerr := q.getRolloutResultsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRolloutResultsStmt: %w", cerr)
+		}
+	}
+	if q.getRolloutStageResultsStmt != nil {
+		if cerr := q.getRolloutStageResultsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRolloutStageResultsStmt: %w", cerr)
+		}
+	}
+	if q.getRolloutStagesStmt != nil {
+		if cerr := q.getRolloutStagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRolloutStagesStmt: %w", cerr)
+		}
+	}
+	if q.getRolloutsStmt != nil {
+		if cerr := q.getRolloutsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRolloutsStmt: %w", cerr)
+		}
+	}
+	if q.getScriptStmt != nil {
+		if cerr := q.getScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getScriptStmt: %w", cerr)
+		}
+	}
+	if q.getScriptRunsStmt != nil {
+		if cerr := q.getScriptRunsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getScriptRunsStmt: %w", cerr)
+		}
+	}
+	if q.getScriptsStmt != nil {
+		if cerr := q.getScriptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getScriptsStmt: %w", cerr)
+		}
+	}
+	if q.getTermsOfServiceStmt != nil {
+		if cerr := q.getTermsOfServiceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTermsOfServiceStmt: %w", cerr)
+		}
+	}
+	if q.getTermsOfServiceAcceptancesStmt != nil {
+		if cerr := q.getTermsOfServiceAcceptancesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTermsOfServiceAcceptancesStmt: %w", cerr)
+		}
+	}
+	if q.getTermsOfServiceReportStmt != nil {
+		if cerr := q.getTermsOfServiceReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTermsOfServiceReportStmt: %w", cerr)
+		}
+	}
+	if q.getTermsOfServiceVersionsStmt != nil {
+		if cerr := q.getTermsOfServiceVersionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTermsOfServiceVersionsStmt: %w", cerr)
+		}
+	}
+	if q.getUnfinishedRolloutStmt != nil {
+		if cerr := q.getUnfinishedRolloutStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUnfinishedRolloutStmt: %w", cerr)
+		}
+	}
+	if q.getUserStmt != nil {
+		if cerr := q.getUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserStmt: %w", cerr)
+		}
+	}
+	if q.getUserForLoginStmt != nil {
+		if cerr := q.getUserForLoginStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserForLoginStmt: %w", cerr)
+		}
+	}
+	if q.getUserGroupStmt != nil {
+		if cerr := q.getUserGroupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserGroupStmt: %w", cerr)
+		}
+	}
+	if q.getUserGroupMembersStmt != nil {
+		if cerr := q.getUserGroupMembersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserGroupMembersStmt: %w", cerr)
+		}
+	}
+	if q.getUserGroupPoliciesStmt != nil {
+		if cerr := q.getUserGroupPoliciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserGroupPoliciesStmt: %w", cerr)
+		}
+	}
+	if q.getUserGroupsStmt != nil {
+		if cerr := q.getUserGroupsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserGroupsStmt: %w", cerr)
+		}
+	}
+	if q.getUsersStmt != nil {
+		if cerr := q.getUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUsersStmt: %w", cerr)
+		}
+	}
+	if q.getUsersPayloadCandidatesStmt != nil {
+		if cerr := q.getUsersPayloadCandidatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUsersPayloadCandidatesStmt: %w", cerr)
+		}
+	}
+	if q.hasAcceptedTermsOfServiceStmt != nil {
+		if cerr := q.hasAcceptedTermsOfServiceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing hasAcceptedTermsOfServiceStmt: %w", cerr)
+		}
+	}
+	if q.incrementPolicyVersionStmt != nil {
+		if cerr := q.incrementPolicyVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing incrementPolicyVersionStmt: %w", cerr)
+		}
+	}
+	if q.installedDeviceAppsStmt != nil {
+		if cerr := q.installedDeviceAppsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing installedDeviceAppsStmt: %w", cerr)
+		}
+	}
+	if q.invalidatePayloadCacheStmt != nil {
+		if cerr := q.invalidatePayloadCacheStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing invalidatePayloadCacheStmt: %w", cerr)
+		}
+	}
+	if q.isDeviceInGroupStmt != nil {
+		if cerr := q.isDeviceInGroupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing isDeviceInGroupStmt: %w", cerr)
+		}
+	}
+	if q.isUserInGroupStmt != nil {
+		if cerr := q.isUserInGroupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing isUserInGroupStmt: %w", cerr)
+		}
+	}
+	if q.localAdminPasswordFailedStmt != nil {
+		if cerr := q.localAdminPasswordFailedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing localAdminPasswordFailedStmt: %w", cerr)
+		}
+	}
+	if q.lockDeviceNamesStmt != nil {
+		if cerr := q.lockDeviceNamesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockDeviceNamesStmt: %w", cerr)
+		}
+	}
+	if q.logBitLockerRecoveryKeyAccessStmt != nil {
+		if cerr := q.logBitLockerRecoveryKeyAccessStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing logBitLockerRecoveryKeyAccessStmt: %w", cerr)
+		}
+	}
+	if q.logLocalAdminPasswordAccessStmt != nil {
+		if cerr := q.logLocalAdminPasswordAccessStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing logLocalAdminPasswordAccessStmt: %w", cerr)
+		}
+	}
+	if q.newAzureADUserStmt != nil {
+		if cerr := q.newAzureADUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing newAzureADUserStmt: %w", cerr)
+		}
+	}
+	if q.newDeviceStmt != nil {
+		if cerr := q.newDeviceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing newDeviceStmt: %w", cerr)
+		}
+	}
+	if q.newDeviceCacheNodeStmt != nil {
+		if cerr := q.newDeviceCacheNodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing newDeviceCacheNodeStmt: %w", cerr)
+		}
+	}
+	if q.newDeviceCommandStmt != nil {
+		if cerr := q.newDeviceCommandStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing newDeviceCommandStmt: %w", cerr)
+		}
+	}
+	if q.newDeviceReplacingExistingStmt != nil {
+		if cerr := q.newDeviceReplacingExistingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing newDeviceReplacingExistingStmt: %w", cerr)
+		}
+	}
+	if q.newDeviceReplacingExistingResetCacheStmt != nil {
 		if cerr := q.newDeviceReplacingExistingResetCacheStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing newDeviceReplacingExistingResetCacheStmt: %w", cerr)
 		}
 	}
+	if q.newDeviceReplacingExistingResetCommandsStmt != nil {
+		if cerr := q.newDeviceReplacingExistingResetCommandsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing newDeviceReplacingExistingResetCommandsStmt: %w", cerr)
+		}
+	}
 	if q.newDeviceReplacingExistingResetInventoryStmt != nil {
 		if cerr := q.newDeviceReplacingExistingResetInventoryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing newDeviceReplacingExistingResetInventoryStmt: %w", cerr)
 		}
 	}
+	if q.newEnrollmentAttemptStmt != nil {
+		if cerr := q.newEnrollmentAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing newEnrollmentAttemptStmt: %w", cerr)
+		}
+	}
+	if q.promoteRolloutStmt != nil {
+		if cerr := q.promoteRolloutStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing promoteRolloutStmt: %w", cerr)
+		}
+	}
+	if q.pruneEnrollmentAttemptsStmt != nil {
+		if cerr := q.pruneEnrollmentAttemptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing pruneEnrollmentAttemptsStmt: %w", cerr)
+		}
+	}
+	if q.recordRolloutResultStmt != nil {
+		if cerr := q.recordRolloutResultStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordRolloutResultStmt: %w", cerr)
+		}
+	}
+	if q.recordScriptRunStmt != nil {
+		if cerr := q.recordScriptRunStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordScriptRunStmt: %w", cerr)
+		}
+	}
+	if q.removeBitLockerRecoveryKeyStmt != nil {
+		if cerr := q.removeBitLockerRecoveryKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing removeBitLockerRecoveryKeyStmt: %w", cerr)
+		}
+	}
+	if q.removeGroupDevicesStmt != nil {
+		if cerr := q.removeGroupDevicesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing removeGroupDevicesStmt: %w", cerr)
+		}
+	}
+	if q.removeUserGroupMembersStmt != nil {
+		if cerr := q.removeUserGroupMembersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing removeUserGroupMembersStmt: %w", cerr)
+		}
+	}
+	if q.requestBitLockerRotationStmt != nil {
+		if cerr := q.requestBitLockerRotationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing requestBitLockerRotationStmt: %w", cerr)
+		}
+	}
+	if q.restoreBitLockerRecoveryKeyStmt != nil {
+		if cerr := q.restoreBitLockerRecoveryKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing restoreBitLockerRecoveryKeyStmt: %w", cerr)
+		}
+	}
+	if q.revokeIssuedCertificateStmt != nil {
+		if cerr := q.revokeIssuedCertificateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeIssuedCertificateStmt: %w", cerr)
+		}
+	}
+	if q.scheduleLocalAdminRotationStmt != nil {
+		if cerr := q.scheduleLocalAdminRotationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing scheduleLocalAdminRotationStmt: %w", cerr)
+		}
+	}
+	if q.setAppAssignmentStmt != nil {
+		if cerr := q.setAppAssignmentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setAppAssignmentStmt: %w", cerr)
+		}
+	}
+	if q.setAppInstallStateStmt != nil {
+		if cerr := q.setAppInstallStateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setAppInstallStateStmt: %w", cerr)
+		}
+	}
+	if q.setCertificateChallengeStmt != nil {
+		if cerr := q.setCertificateChallengeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setCertificateChallengeStmt: %w", cerr)
+		}
+	}
+	if q.setDeviceNameStmt != nil {
+		if cerr := q.setDeviceNameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setDeviceNameStmt: %w", cerr)
+		}
+	}
+	if q.setDeviceNamingStmt != nil {
+		if cerr := q.setDeviceNamingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setDeviceNamingStmt: %w", cerr)
+		}
+	}
 	if q.setDeviceStateStmt != nil {
 		if cerr := q.setDeviceStateStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing setDeviceStateStmt: %w", cerr)
 		}
 	}
+	if q.setEnrollmentBrandingStmt != nil {
+		if cerr := q.setEnrollmentBrandingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setEnrollmentBrandingStmt: %w", cerr)
+		}
+	}
+	if q.setEnrollmentResponseStmt != nil {
+		if cerr := q.setEnrollmentResponseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setEnrollmentResponseStmt: %w", cerr)
+		}
+	}
+	if q.setGroupPolicyScheduleStmt != nil {
+		if cerr := q.setGroupPolicyScheduleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setGroupPolicyScheduleStmt: %w", cerr)
+		}
+	}
+	if q.setLocalAdminPendingPasswordStmt != nil {
+		if cerr := q.setLocalAdminPendingPasswordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setLocalAdminPendingPasswordStmt: %w", cerr)
+		}
+	}
+	if q.setRolloutStateStmt != nil {
+		if cerr := q.setRolloutStateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setRolloutStateStmt: %w", cerr)
+		}
+	}
 	if q.settingsStmt != nil {
 		if cerr := q.settingsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing settingsStmt: %w", cerr)
 		}
 	}
+	if q.updateCertificateProfileStmt != nil {
+		if cerr := q.updateCertificateProfileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateCertificateProfileStmt: %w", cerr)
+		}
+	}
+	if q.updateDeployedPolicyVersionStmt != nil {
+		if cerr := q.updateDeployedPolicyVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateDeployedPolicyVersionStmt: %w", cerr)
+		}
+	}
 	if q.updateDeviceInventoryNodeStmt != nil {
 		if cerr := q.updateDeviceInventoryNodeStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateDeviceInventoryNodeStmt: %w", cerr)
 		}
 	}
+	if q.updateGroupStmt != nil {
+		if cerr := q.updateGroupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateGroupStmt: %w", cerr)
+		}
+	}
+	if q.updateLocalAdminProfileStmt != nil {
+		if cerr := q.updateLocalAdminProfileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateLocalAdminProfileStmt: %w", cerr)
+		}
+	}
+	if q.updatePolicyStmt != nil {
+		if cerr := q.updatePolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updatePolicyStmt: %w", cerr)
+		}
+	}
+	if q.updatePolicyPayloadStmt != nil {
+		if cerr := q.updatePolicyPayloadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updatePolicyPayloadStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptStmt != nil {
+		if cerr := q.updateScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptStmt: %w", cerr)
+		}
+	}
+	if q.updateUserGroupStmt != nil {
+		if cerr := q.updateUserGroupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserGroupStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -322,75 +1714,423 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 type Queries struct {
 	db                                           DBTX
 	tx                                           *sql.Tx
+	acceptTermsOfServiceStmt                     *sql.Stmt
+	addDeviceInventoryAppStmt                    *sql.Stmt
+	addGroupDevicesStmt                          *sql.Stmt
+	addUserGroupMembersStmt                      *sql.Stmt
+	attachGroupPolicyStmt                        *sql.Stmt
+	attachUserGroupPolicyStmt                    *sql.Stmt
+	claimCertificateChallengeStmt                *sql.Stmt
+	clearDeviceInventoryAppsStmt                 *sql.Stmt
+	confirmLocalAdminPasswordStmt                *sql.Stmt
+	countDeviceScriptsStmt                       *sql.Stmt
+	createAppStmt                                *sql.Stmt
+	createBitLockerRecoveryKeyStmt               *sql.Stmt
+	createCertificateProfileStmt                 *sql.Stmt
+	createGroupStmt                              *sql.Stmt
+	createGroupPolicyWindowStmt                  *sql.Stmt
+	createIssuedCertificateStmt                  *sql.Stmt
+	createLocalAdminProfileStmt                  *sql.Stmt
+	createPolicyStmt                             *sql.Stmt
+	createPolicyPayloadStmt                      *sql.Stmt
+	createPolicyVersionStmt                      *sql.Stmt
 	createRawCertStmt                            *sql.Stmt
+	createRolloutStmt                            *sql.Stmt
+	createRolloutStageStmt                       *sql.Stmt
+	createScriptStmt                             *sql.Stmt
+	createTermsOfServiceStmt                     *sql.Stmt
 	createUserStmt                               *sql.Stmt
+	createUserGroupStmt                          *sql.Stmt
+	deleteAppStmt                                *sql.Stmt
+	deleteAppAssignmentStmt                      *sql.Stmt
+	deleteBitLockerRotationStmt                  *sql.Stmt
+	deleteCertificateProfileStmt                 *sql.Stmt
 	deleteDeviceCacheNodeStmt                    *sql.Stmt
+	deleteEnrollmentBrandingStmt                 *sql.Stmt
+	deleteGroupStmt                              *sql.Stmt
+	deleteGroupAppAssignmentsStmt                *sql.Stmt
+	deleteGroupCertificateProfilesStmt           *sql.Stmt
+	deleteGroupDevicesStmt                       *sql.Stmt
+	deleteGroupLocalAdminProfilesStmt            *sql.Stmt
+	deleteGroupPoliciesStmt                      *sql.Stmt
+	deleteGroupPolicyWindowsStmt                 *sql.Stmt
+	deleteGroupRolloutsStmt                      *sql.Stmt
+	deleteGroupScriptsStmt                       *sql.Stmt
+	deleteLocalAdminProfileStmt                  *sql.Stmt
+	deleteOrphanedPayloadsStmt                   *sql.Stmt
+	deletePolicyStmt                             *sql.Stmt
+	deletePolicyGroupsStmt                       *sql.Stmt
+	deletePolicyRolloutsStmt                     *sql.Stmt
+	deletePolicyUserGroupsStmt                   *sql.Stmt
+	deleteScriptStmt                             *sql.Stmt
+	deleteUserGroupStmt                          *sql.Stmt
+	deleteUserGroupMembersStmt                   *sql.Stmt
+	deleteUserGroupPoliciesStmt                  *sql.Stmt
+	detachGroupPolicyStmt                        *sql.Stmt
+	detachPoliciesPayloadsStmt                   *sql.Stmt
+	detachPolicyPayloadStmt                      *sql.Stmt
+	detachUserGroupPolicyStmt                    *sql.Stmt
 	deviceCheckinStatusStmt                      *sql.Stmt
+	deviceCommandSentStmt                        *sql.Stmt
+	deviceNameInUseStmt                          *sql.Stmt
 	deviceUserUnenrollmentStmt                   *sql.Stmt
+	getAllDevicesStmt                            *sql.Stmt
+	getAllGroupsStmt                             *sql.Stmt
+	getAllPoliciesStmt                           *sql.Stmt
+	getAllUserGroupsStmt                         *sql.Stmt
+	getAppStmt                                   *sql.Stmt
+	getAppAssignmentsStmt                        *sql.Stmt
+	getAppByHashStmt                             *sql.Stmt
+	getAppByIdentifierStmt                       *sql.Stmt
+	getAppInstallsStmt                           *sql.Stmt
+	getAppsStmt                                  *sql.Stmt
 	getBasicDeviceStmt                           *sql.Stmt
 	getBasicDeviceScopedGroupsStmt               *sql.Stmt
 	getBasicDeviceScopedPoliciesStmt             *sql.Stmt
+	getBitLockerRecoveryKeyAccessStmt            *sql.Stmt
+	getBitLockerRecoveryKeysStmt                 *sql.Stmt
+	getBitLockerRotationStmt                     *sql.Stmt
+	getCertificateChallengeStmt                  *sql.Stmt
+	getCertificateProfileStmt                    *sql.Stmt
+	getCertificateProfilesStmt                   *sql.Stmt
+	getDeployedPayloadsStmt                      *sql.Stmt
 	getDeviceStmt                                *sql.Stmt
+	getDeviceAppAssignmentsStmt                  *sql.Stmt
+	getDeviceAppInstallsStmt                     *sql.Stmt
+	getDeviceBitLockerRecoveryKeysStmt           *sql.Stmt
+	getDeviceBitLockerRecoveryPasswordsStmt      *sql.Stmt
 	getDeviceByUDIDStmt                          *sql.Stmt
+	getDeviceByUDIDForUpdateStmt                 *sql.Stmt
+	getDeviceCertificateProfilesStmt             *sql.Stmt
+	getDeviceInventoryStmt                       *sql.Stmt
+	getDeviceInventoryAppsStmt                   *sql.Stmt
+	getDeviceIssuedCertificatesStmt              *sql.Stmt
+	getDeviceLocalAdminPasswordsStmt             *sql.Stmt
+	getDeviceLocalAdminProfilesStmt              *sql.Stmt
+	getDeviceScriptRunsStmt                      *sql.Stmt
+	getDeviceScriptsStmt                         *sql.Stmt
 	getDevicesStmt                               *sql.Stmt
-	getDevicesDetachedPayloadsStmt               *sql.Stmt
+	getDevicesAssignmentSchedulesStmt            *sql.Stmt
+	getDevicesAssignmentWindowsStmt              *sql.Stmt
+	getDevicesPayloadCandidatesStmt              *sql.Stmt
 	getDevicesPayloadsStmt                       *sql.Stmt
-	getDevicesPayloadsAwaitingDeploymentStmt     *sql.Stmt
+	getDevicesPendingCommandsStmt                *sql.Stmt
+	getDevicesRolloutCandidatesStmt              *sql.Stmt
+	getDynamicGroupsStmt                         *sql.Stmt
+	getEnrollmentAttemptStmt                     *sql.Stmt
+	getEnrollmentAttemptsStmt                    *sql.Stmt
+	getEnrollmentBrandingStmt                    *sql.Stmt
+	getEnrollmentBrandingsStmt                   *sql.Stmt
+	getEnrollmentResponseStmt                    *sql.Stmt
+	getExpiringIssuedCertificatesStmt            *sql.Stmt
+	getFailedScriptRunsStmt                      *sql.Stmt
 	getGroupStmt                                 *sql.Stmt
+	getGroupDevicesStmt                          *sql.Stmt
+	getGroupPoliciesStmt                         *sql.Stmt
+	getGroupPolicyScheduleStmt                   *sql.Stmt
+	getGroupPolicyWindowsStmt                    *sql.Stmt
 	getGroupsStmt                                *sql.Stmt
+	getGroupsPayloadCandidatesStmt               *sql.Stmt
+	getIssuedCertificateStmt                     *sql.Stmt
+	getLatestIssuedCertificateStmt               *sql.Stmt
+	getLatestTermsOfServiceStmt                  *sql.Stmt
+	getLocalAdminPasswordStmt                    *sql.Stmt
+	getLocalAdminPasswordAccessStmt              *sql.Stmt
+	getLocalAdminProfileStmt                     *sql.Stmt
+	getLocalAdminProfilesStmt                    *sql.Stmt
+	getPayloadsRolloutsStmt                      *sql.Stmt
 	getPoliciesStmt                              *sql.Stmt
 	getPoliciesPayloadsStmt                      *sql.Stmt
 	getPolicyStmt                                *sql.Stmt
+	getPolicyPayloadStmt                         *sql.Stmt
+	getPolicyVersionStmt                         *sql.Stmt
+	getPolicyVersionsStmt                        *sql.Stmt
+	getProfileIssuedCertificatesStmt             *sql.Stmt
 	getRawCertStmt                               *sql.Stmt
+	getRevokedCertificatesStmt                   *sql.Stmt
+	getRolloutStmt                               *sql.Stmt
+	getRolloutResultsStmt                        *sql.Stmt
+	getRolloutStageResultsStmt                   *sql.Stmt
+	getRolloutStagesStmt                         *sql.Stmt
+	getRolloutsStmt                              *sql.Stmt
+	getScriptStmt                                *sql.Stmt
+	getScriptRunsStmt                            *sql.Stmt
+	getScriptsStmt                               *sql.Stmt
+	getTermsOfServiceStmt                        *sql.Stmt
+	getTermsOfServiceAcceptancesStmt             *sql.Stmt
+	getTermsOfServiceReportStmt                  *sql.Stmt
+	getTermsOfServiceVersionsStmt                *sql.Stmt
+	getUnfinishedRolloutStmt                     *sql.Stmt
 	getUserStmt                                  *sql.Stmt
 	getUserForLoginStmt                          *sql.Stmt
+	getUserGroupStmt                             *sql.Stmt
+	getUserGroupMembersStmt                      *sql.Stmt
+	getUserGroupPoliciesStmt                     *sql.Stmt
+	getUserGroupsStmt                            *sql.Stmt
 	getUsersStmt                                 *sql.Stmt
+	getUsersPayloadCandidatesStmt                *sql.Stmt
+	hasAcceptedTermsOfServiceStmt                *sql.Stmt
+	incrementPolicyVersionStmt                   *sql.Stmt
+	installedDeviceAppsStmt                      *sql.Stmt
+	invalidatePayloadCacheStmt                   *sql.Stmt
+	isDeviceInGroupStmt                          *sql.Stmt
+	isUserInGroupStmt                            *sql.Stmt
+	localAdminPasswordFailedStmt                 *sql.Stmt
+	lockDeviceNamesStmt                          *sql.Stmt
+	logBitLockerRecoveryKeyAccessStmt            *sql.Stmt
+	logLocalAdminPasswordAccessStmt              *sql.Stmt
 	newAzureADUserStmt                           *sql.Stmt
 	newDeviceStmt                                *sql.Stmt
 	newDeviceCacheNodeStmt                       *sql.Stmt
+	newDeviceCommandStmt                         *sql.Stmt
 	newDeviceReplacingExistingStmt               *sql.Stmt
 	newDeviceReplacingExistingResetCacheStmt     *sql.Stmt
+	newDeviceReplacingExistingResetCommandsStmt  *sql.Stmt
 	newDeviceReplacingExistingResetInventoryStmt *sql.Stmt
+	newEnrollmentAttemptStmt                     *sql.Stmt
+	promoteRolloutStmt                           *sql.Stmt
+	pruneEnrollmentAttemptsStmt                  *sql.Stmt
+	recordRolloutResultStmt                      *sql.Stmt
+	recordScriptRunStmt                          *sql.Stmt
+	removeBitLockerRecoveryKeyStmt               *sql.Stmt
+	removeGroupDevicesStmt                       *sql.Stmt
+	removeUserGroupMembersStmt                   *sql.Stmt
+	requestBitLockerRotationStmt                 *sql.Stmt
+	restoreBitLockerRecoveryKeyStmt              *sql.Stmt
+	revokeIssuedCertificateStmt                  *sql.Stmt
+	scheduleLocalAdminRotationStmt               *sql.Stmt
+	setAppAssignmentStmt                         *sql.Stmt
+	setAppInstallStateStmt                       *sql.Stmt
+	setCertificateChallengeStmt                  *sql.Stmt
+	setDeviceNameStmt                            *sql.Stmt
+	setDeviceNamingStmt                          *sql.Stmt
 	setDeviceStateStmt                           *sql.Stmt
+	setEnrollmentBrandingStmt                    *sql.Stmt
+	setEnrollmentResponseStmt                    *sql.Stmt
+	setGroupPolicyScheduleStmt                   *sql.Stmt
+	setLocalAdminPendingPasswordStmt             *sql.Stmt
+	setRolloutStateStmt                          *sql.Stmt
 	settingsStmt                                 *sql.Stmt
+	updateCertificateProfileStmt                 *sql.Stmt
+	updateDeployedPolicyVersionStmt              *sql.Stmt
 	updateDeviceInventoryNodeStmt                *sql.Stmt
+	updateGroupStmt                              *sql.Stmt
+	updateLocalAdminProfileStmt                  *sql.Stmt
+	updatePolicyStmt                             *sql.Stmt
+	updatePolicyPayloadStmt                      *sql.Stmt
+	updateScriptStmt                             *sql.Stmt
+	updateUserGroupStmt                          *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                                       tx,
-		tx:                                       tx,
-		createRawCertStmt:                        q.createRawCertStmt,
-		createUserStmt:                           q.createUserStmt,
-		deleteDeviceCacheNodeStmt:                q.deleteDeviceCacheNodeStmt,
-		deviceCheckinStatusStmt:                  q.deviceCheckinStatusStmt,
-		deviceUserUnenrollmentStmt:               q.deviceUserUnenrollmentStmt,
-		getBasicDeviceStmt:                       q.getBasicDeviceStmt,
-		getBasicDeviceScopedGroupsStmt:           q.getBasicDeviceScopedGroupsStmt,
-		getBasicDeviceScopedPoliciesStmt:         q.getBasicDeviceScopedPoliciesStmt,
-		getDeviceStmt:                            q.getDeviceStmt,
-		getDeviceByUDIDStmt:                      q.getDeviceByUDIDStmt,
-		getDevicesStmt:                           q.getDevicesStmt,
-		getDevicesDetachedPayloadsStmt:           q.getDevicesDetachedPayloadsStmt,
-		getDevicesPayloadsStmt:                   q.getDevicesPayloadsStmt,
-		getDevicesPayloadsAwaitingDeploymentStmt: q.getDevicesPayloadsAwaitingDeploymentStmt,
-		getGroupStmt:                             q.getGroupStmt,
-		getGroupsStmt:                            q.getGroupsStmt,
-		getPoliciesStmt:                          q.getPoliciesStmt,
-		getPoliciesPayloadsStmt:                  q.getPoliciesPayloadsStmt,
-		getPolicyStmt:                            q.getPolicyStmt,
-		getRawCertStmt:                           q.getRawCertStmt,
-		getUserStmt:                              q.getUserStmt,
-		getUserForLoginStmt:                      q.getUserForLoginStmt,
-		getUsersStmt:                             q.getUsersStmt,
-		newAzureADUserStmt:                       q.newAzureADUserStmt,
-		newDeviceStmt:                            q.newDeviceStmt,
-		newDeviceCacheNodeStmt:                   q.newDeviceCacheNodeStmt,
-		newDeviceReplacingExistingStmt:           q.newDeviceReplacingExistingStmt,
-		newDeviceReplacingExistingResetCacheStmt: q.newDeviceReplacingExistingResetCacheStmt,
+		db:                                           tx,
+		tx:                                           tx,
+		acceptTermsOfServiceStmt:                     q.acceptTermsOfServiceStmt,
+		addDeviceInventoryAppStmt:                    q.addDeviceInventoryAppStmt,
+		addGroupDevicesStmt:                          q.addGroupDevicesStmt,
+		addUserGroupMembersStmt:                      q.addUserGroupMembersStmt,
+		attachGroupPolicyStmt:                        q.attachGroupPolicyStmt,
+		attachUserGroupPolicyStmt:                    q.attachUserGroupPolicyStmt,
+		claimCertificateChallengeStmt:                q.claimCertificateChallengeStmt,
+		clearDeviceInventoryAppsStmt:                 q.clearDeviceInventoryAppsStmt,
+		confirmLocalAdminPasswordStmt:                q.confirmLocalAdminPasswordStmt,
+		countDeviceScriptsStmt:                       q.countDeviceScriptsStmt,
+		createAppStmt:                                q.createAppStmt,
+		createBitLockerRecoveryKeyStmt:               q.createBitLockerRecoveryKeyStmt,
+		createCertificateProfileStmt:                 q.createCertificateProfileStmt,
+		createGroupStmt:                              q.createGroupStmt,
+		createGroupPolicyWindowStmt:                  q.createGroupPolicyWindowStmt,
+		createIssuedCertificateStmt:                  q.createIssuedCertificateStmt,
+		createLocalAdminProfileStmt:                  q.createLocalAdminProfileStmt,
+		createPolicyStmt:                             q.createPolicyStmt,
+		createPolicyPayloadStmt:                      q.createPolicyPayloadStmt,
+		createPolicyVersionStmt:                      q.createPolicyVersionStmt,
+		createRawCertStmt:                            q.createRawCertStmt,
+		createRolloutStmt:                            q.createRolloutStmt,
+		createRolloutStageStmt:                       q.createRolloutStageStmt,
+		createScriptStmt:                             q.createScriptStmt,
+		createTermsOfServiceStmt:                     q.createTermsOfServiceStmt,
+		createUserStmt:                               q.createUserStmt,
+		createUserGroupStmt:                          q.createUserGroupStmt,
+		deleteAppStmt:                                q.deleteAppStmt,
+		deleteAppAssignmentStmt:                      q.deleteAppAssignmentStmt,
+		deleteBitLockerRotationStmt:                  q.deleteBitLockerRotationStmt,
+		deleteCertificateProfileStmt:                 q.deleteCertificateProfileStmt,
+		deleteDeviceCacheNodeStmt:                    q.deleteDeviceCacheNodeStmt,
+		deleteEnrollmentBrandingStmt:                 q.deleteEnrollmentBrandingStmt,
+		deleteGroupStmt:                              q.deleteGroupStmt,
+		deleteGroupAppAssignmentsStmt:                q.deleteGroupAppAssignmentsStmt,
+		deleteGroupCertificateProfilesStmt:           q.deleteGroupCertificateProfilesStmt,
+		deleteGroupDevicesStmt:                       q.deleteGroupDevicesStmt,
+		deleteGroupLocalAdminProfilesStmt:            q.deleteGroupLocalAdminProfilesStmt,
+		deleteGroupPoliciesStmt:                      q.deleteGroupPoliciesStmt,
+		deleteGroupPolicyWindowsStmt:                 q.deleteGroupPolicyWindowsStmt,
+		deleteGroupRolloutsStmt:                      q.deleteGroupRolloutsStmt,
+		deleteGroupScriptsStmt:                       q.deleteGroupScriptsStmt,
+		deleteLocalAdminProfileStmt:                  q.deleteLocalAdminProfileStmt,
+		deleteOrphanedPayloadsStmt:                   q.deleteOrphanedPayloadsStmt,
+		deletePolicyStmt:                             q.deletePolicyStmt,
+		deletePolicyGroupsStmt:                       q.deletePolicyGroupsStmt,
+		deletePolicyRolloutsStmt:                     q.deletePolicyRolloutsStmt,
+		deletePolicyUserGroupsStmt:                   q.deletePolicyUserGroupsStmt,
+		deleteScriptStmt:                             q.deleteScriptStmt,
+		deleteUserGroupStmt:                          q.deleteUserGroupStmt,
+		deleteUserGroupMembersStmt:                   q.deleteUserGroupMembersStmt,
+		deleteUserGroupPoliciesStmt:                  q.deleteUserGroupPoliciesStmt,
+		detachGroupPolicyStmt:                        q.detachGroupPolicyStmt,
+		detachPoliciesPayloadsStmt:                   q.detachPoliciesPayloadsStmt,
+		detachPolicyPayloadStmt:                      q.detachPolicyPayloadStmt,
+		detachUserGroupPolicyStmt:                    q.detachUserGroupPolicyStmt,
+		deviceCheckinStatusStmt:                      q.deviceCheckinStatusStmt,
+		deviceCommandSentStmt:                        q.deviceCommandSentStmt,
+		deviceNameInUseStmt:                          q.deviceNameInUseStmt,
+		deviceUserUnenrollmentStmt:                   q.deviceUserUnenrollmentStmt,
+		getAllDevicesStmt:                            q.getAllDevicesStmt,
+		getAllGroupsStmt:                             q.getAllGroupsStmt,
+		getAllPoliciesStmt:                           q.getAllPoliciesStmt,
+		getAllUserGroupsStmt:                         q.getAllUserGroupsStmt,
+		getAppStmt:                                   q.getAppStmt,
+		getAppAssignmentsStmt:                        q.getAppAssignmentsStmt,
+		getAppByHashStmt:                             q.getAppByHashStmt,
+		getAppByIdentifierStmt:                       q.getAppByIdentifierStmt,
+		getAppInstallsStmt:                           q.getAppInstallsStmt,
+		getAppsStmt:                                  q.getAppsStmt,
+		getBasicDeviceStmt:                           q.getBasicDeviceStmt,
+		getBasicDeviceScopedGroupsStmt:               q.getBasicDeviceScopedGroupsStmt,
+		getBasicDeviceScopedPoliciesStmt:             q.getBasicDeviceScopedPoliciesStmt,
+		getBitLockerRecoveryKeyAccessStmt:            q.getBitLockerRecoveryKeyAccessStmt,
+		getBitLockerRecoveryKeysStmt:                 q.getBitLockerRecoveryKeysStmt,
+		getBitLockerRotationStmt:                     q.getBitLockerRotationStmt,
+		getCertificateChallengeStmt:                  q.getCertificateChallengeStmt,
+		getCertificateProfileStmt:                    q.getCertificateProfileStmt,
+		getCertificateProfilesStmt:                   q.getCertificateProfilesStmt,
+		getDeployedPayloadsStmt:                      q.getDeployedPayloadsStmt,
+		getDeviceStmt:                                q.getDeviceStmt,
+		getDeviceAppAssignmentsStmt:                  q.getDeviceAppAssignmentsStmt,
+		getDeviceAppInstallsStmt:                     q.getDeviceAppInstallsStmt,
+		getDeviceBitLockerRecoveryKeysStmt:           q.getDeviceBitLockerRecoveryKeysStmt,
+		getDeviceBitLockerRecoveryPasswordsStmt:      q.getDeviceBitLockerRecoveryPasswordsStmt,
+		getDeviceByUDIDStmt:                          q.getDeviceByUDIDStmt,
+		getDeviceByUDIDForUpdateStmt:                 q.getDeviceByUDIDForUpdateStmt,
+		getDeviceCertificateProfilesStmt:             q.getDeviceCertificateProfilesStmt,
+		getDeviceInventoryStmt:                       q.getDeviceInventoryStmt,
+		getDeviceInventoryAppsStmt:                   q.getDeviceInventoryAppsStmt,
+		getDeviceIssuedCertificatesStmt:              q.getDeviceIssuedCertificatesStmt,
+		getDeviceLocalAdminPasswordsStmt:             q.getDeviceLocalAdminPasswordsStmt,
+		getDeviceLocalAdminProfilesStmt:              q.getDeviceLocalAdminProfilesStmt,
+		getDeviceScriptRunsStmt:                      q.getDeviceScriptRunsStmt,
+		getDeviceScriptsStmt:                         q.getDeviceScriptsStmt,
+		getDevicesStmt:                               q.getDevicesStmt,
+		getDevicesAssignmentSchedulesStmt:            q.getDevicesAssignmentSchedulesStmt,
+		getDevicesAssignmentWindowsStmt:              q.getDevicesAssignmentWindowsStmt,
+		getDevicesPayloadCandidatesStmt:              q.getDevicesPayloadCandidatesStmt,
+		getDevicesPayloadsStmt:                       q.getDevicesPayloadsStmt,
+		getDevicesPendingCommandsStmt:                q.getDevicesPendingCommandsStmt,
+		getDevicesRolloutCandidatesStmt:              q.getDevicesRolloutCandidatesStmt,
+		getDynamicGroupsStmt:                         q.getDynamicGroupsStmt,
+		getEnrollmentAttemptStmt:                     q.getEnrollmentAttemptStmt,
+		getEnrollmentAttemptsStmt:                    q.getEnrollmentAttemptsStmt,
+		getEnrollmentBrandingStmt:                    q.getEnrollmentBrandingStmt,
+		getEnrollmentBrandingsStmt:                   q.getEnrollmentBrandingsStmt,
+		getEnrollmentResponseStmt:                    q.getEnrollmentResponseStmt,
+		getExpiringIssuedCertificatesStmt:            q.getExpiringIssuedCertificatesStmt,
+		getFailedScriptRunsStmt:                      q.getFailedScriptRunsStmt,
+		getGroupStmt:                                 q.getGroupStmt,
+		getGroupDevicesStmt:                          q.getGroupDevicesStmt,
+		getGroupPoliciesStmt:                         q.getGroupPoliciesStmt,
+		getGroupPolicyScheduleStmt:                   q.getGroupPolicyScheduleStmt,
+		getGroupPolicyWindowsStmt:                    q.getGroupPolicyWindowsStmt,
+		getGroupsStmt:                                q.getGroupsStmt,
+		getGroupsPayloadCandidatesStmt:               q.getGroupsPayloadCandidatesStmt,
+		getIssuedCertificateStmt:                     q.getIssuedCertificateStmt,
+		getLatestIssuedCertificateStmt:               q.getLatestIssuedCertificateStmt,
+		getLatestTermsOfServiceStmt:                  q.getLatestTermsOfServiceStmt,
+		getLocalAdminPasswordStmt:                    q.getLocalAdminPasswordStmt,
+		getLocalAdminPasswordAccessStmt:              q.getLocalAdminPasswordAccessStmt,
+		getLocalAdminProfileStmt:                     q.getLocalAdminProfileStmt,
+		getLocalAdminProfilesStmt:                    q.getLocalAdminProfilesStmt,
+		getPayloadsRolloutsStmt:                      q.getPayloadsRolloutsStmt,
+		getPoliciesStmt:                              q.getPoliciesStmt,
+		getPoliciesPayloadsStmt:                      q.getPoliciesPayloadsStmt,
+		getPolicyStmt:                                q.getPolicyStmt,
+		getPolicyPayloadStmt:                         q.getPolicyPayloadStmt,
+		getPolicyVersionStmt:                         q.getPolicyVersionStmt,
+		getPolicyVersionsStmt:                        q.getPolicyVersionsStmt,
+		getProfileIssuedCertificatesStmt:             q.getProfileIssuedCertificatesStmt,
+		getRawCertStmt:                               q.getRawCertStmt,
+		getRevokedCertificatesStmt:                   q.getRevokedCertificatesStmt,
+		getRolloutStmt:                               q.getRolloutStmt,
+		getRolloutResultsStmt:                        q.getRolloutResultsStmt,
+		getRolloutStageResultsStmt:                   q.getRolloutStageResultsStmt,
+		getRolloutStagesStmt:                         q.getRolloutStagesStmt,
+		getRolloutsStmt:                              q.getRolloutsStmt,
+		getScriptStmt:                                q.getScriptStmt,
+		getScriptRunsStmt:                            q.getScriptRunsStmt,
+		getScriptsStmt:                               q.getScriptsStmt,
+		getTermsOfServiceStmt:                        q.getTermsOfServiceStmt,
+		getTermsOfServiceAcceptancesStmt:             q.getTermsOfServiceAcceptancesStmt,
+		getTermsOfServiceReportStmt:                  q.getTermsOfServiceReportStmt,
+		getTermsOfServiceVersionsStmt:                q.getTermsOfServiceVersionsStmt,
+		getUnfinishedRolloutStmt:                     q.getUnfinishedRolloutStmt,
+		getUserStmt:                                  q.getUserStmt,
+		getUserForLoginStmt:                          q.getUserForLoginStmt,
+		getUserGroupStmt:                             q.getUserGroupStmt,
+		getUserGroupMembersStmt:                      q.getUserGroupMembersStmt,
+		getUserGroupPoliciesStmt:                     q.getUserGroupPoliciesStmt,
+		getUserGroupsStmt:                            q.getUserGroupsStmt,
+		getUsersStmt:                                 q.getUsersStmt,
+		getUsersPayloadCandidatesStmt:                q.getUsersPayloadCandidatesStmt,
+		hasAcceptedTermsOfServiceStmt:                q.hasAcceptedTermsOfServiceStmt,
+		incrementPolicyVersionStmt:                   q.incrementPolicyVersionStmt,
+		installedDeviceAppsStmt:                      q.installedDeviceAppsStmt,
+		invalidatePayloadCacheStmt:                   q.invalidatePayloadCacheStmt,
+		isDeviceInGroupStmt:                          q.isDeviceInGroupStmt,
+		isUserInGroupStmt:                            q.isUserInGroupStmt,
+		localAdminPasswordFailedStmt:                 q.localAdminPasswordFailedStmt,
+		lockDeviceNamesStmt:                          q.lockDeviceNamesStmt,
+		logBitLockerRecoveryKeyAccessStmt:            q.logBitLockerRecoveryKeyAccessStmt,
+		logLocalAdminPasswordAccessStmt:              q.logLocalAdminPasswordAccessStmt,
+		newAzureADUserStmt:                           q.newAzureADUserStmt,
+		newDeviceStmt:                                q.newDeviceStmt,
+		newDeviceCacheNodeStmt:                       q.newDeviceCacheNodeStmt,
+		newDeviceCommandStmt:                         q.newDeviceCommandStmt,
+		newDeviceReplacingExistingStmt:               q.newDeviceReplacingExistingStmt,
+		newDeviceReplacingExistingResetCacheStmt:     q.newDeviceReplacingExistingResetCacheStmt,
+		newDeviceReplacingExistingResetCommandsStmt:  q.newDeviceReplacingExistingResetCommandsStmt,
 		newDeviceReplacingExistingResetInventoryStmt: q.newDeviceReplacingExistingResetInventoryStmt,
+		newEnrollmentAttemptStmt:                     q.newEnrollmentAttemptStmt,
+		promoteRolloutStmt:                           q.promoteRolloutStmt,
+		pruneEnrollmentAttemptsStmt:                  q.pruneEnrollmentAttemptsStmt,
+		recordRolloutResultStmt:                      q.recordRolloutResultStmt,
+		recordScriptRunStmt:                          q.recordScriptRunStmt,
+		removeBitLockerRecoveryKeyStmt:               q.removeBitLockerRecoveryKeyStmt,
+		removeGroupDevicesStmt:                       q.removeGroupDevicesStmt,
+		removeUserGroupMembersStmt:                   q.removeUserGroupMembersStmt,
+		requestBitLockerRotationStmt:                 q.requestBitLockerRotationStmt,
+		restoreBitLockerRecoveryKeyStmt:              q.restoreBitLockerRecoveryKeyStmt,
+		revokeIssuedCertificateStmt:                  q.revokeIssuedCertificateStmt,
+		scheduleLocalAdminRotationStmt:               q.scheduleLocalAdminRotationStmt,
+		setAppAssignmentStmt:                         q.setAppAssignmentStmt,
+		setAppInstallStateStmt:                       q.setAppInstallStateStmt,
+		setCertificateChallengeStmt:                  q.setCertificateChallengeStmt,
+		setDeviceNameStmt:                            q.setDeviceNameStmt,
+		setDeviceNamingStmt:                          q.setDeviceNamingStmt,
 		setDeviceStateStmt:                           q.setDeviceStateStmt,
+		setEnrollmentBrandingStmt:                    q.setEnrollmentBrandingStmt,
+		setEnrollmentResponseStmt:                    q.setEnrollmentResponseStmt,
+		setGroupPolicyScheduleStmt:                   q.setGroupPolicyScheduleStmt,
+		setLocalAdminPendingPasswordStmt:             q.setLocalAdminPendingPasswordStmt,
+		setRolloutStateStmt:                          q.setRolloutStateStmt,
 		settingsStmt:                                 q.settingsStmt,
+		updateCertificateProfileStmt:                 q.updateCertificateProfileStmt,
+		updateDeployedPolicyVersionStmt:              q.updateDeployedPolicyVersionStmt,
 		updateDeviceInventoryNodeStmt:                q.updateDeviceInventoryNodeStmt,
+		updateGroupStmt:                              q.updateGroupStmt,
+		updateLocalAdminProfileStmt:                  q.updateLocalAdminProfileStmt,
+		updatePolicyStmt:                             q.updatePolicyStmt,
+		updatePolicyPayloadStmt:                      q.updatePolicyPayloadStmt,
+		updateScriptStmt:                             q.updateScriptStmt,
+		updateUserGroupStmt:                          q.updateUserGroupStmt,
 	}
 }
@@ -4,12 +4,76 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/mattrax/Mattrax/pkg/null"
 )
 
+type AppInstallState string
+
+const (
+	AppInstallStatePending      AppInstallState = "pending"
+	AppInstallStateInstalling   AppInstallState = "installing"
+	AppInstallStateInstalled    AppInstallState = "installed"
+	AppInstallStateFailed       AppInstallState = "failed"
+	AppInstallStateUninstalling AppInstallState = "uninstalling"
+	AppInstallStateUninstalled  AppInstallState = "uninstalled"
+)
+
+func (e *AppInstallState) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AppInstallState(s)
+	case string:
+		*e = AppInstallState(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AppInstallState: %T", src)
+	}
+	return nil
+}
+
+type AppIntent string
+
+const (
+	AppIntentRequired  AppIntent = "required"
+	AppIntentAvailable AppIntent = "available"
+	AppIntentUninstall AppIntent = "uninstall"
+)
+
+func (e *AppIntent) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AppIntent(s)
+	case string:
+		*e = AppIntent(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AppIntent: %T", src)
+	}
+	return nil
+}
+
+type AppType string
+
+const (
+	AppTypeMsi   AppType = "msi"
+	AppTypeStore AppType = "store"
+	AppTypeAppx  AppType = "appx"
+)
+
+func (e *AppType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AppType(s)
+	case string:
+		*e = AppType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AppType: %T", src)
+	}
+	return nil
+}
+
 type DeviceState string
 
 const (
@@ -31,6 +95,25 @@ func (e *DeviceState) Scan(src interface{}) error {
 	return nil
 }
 
+type EnrollmentAttemptResult string
+
+const (
+	EnrollmentAttemptResultSuccess EnrollmentAttemptResult = "success"
+	EnrollmentAttemptResultFault   EnrollmentAttemptResult = "fault"
+)
+
+func (e *EnrollmentAttemptResult) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = EnrollmentAttemptResult(s)
+	case string:
+		*e = EnrollmentAttemptResult(s)
+	default:
+		return fmt.Errorf("unsupported scan type for EnrollmentAttemptResult: %T", src)
+	}
+	return nil
+}
+
 type EnrollmentType string
 
 const (
@@ -51,6 +134,67 @@ func (e *EnrollmentType) Scan(src interface{}) error {
 	return nil
 }
 
+type RolloutState string
+
+const (
+	RolloutStateActive    RolloutState = "active"
+	RolloutStatePaused    RolloutState = "paused"
+	RolloutStateAborted   RolloutState = "aborted"
+	RolloutStateCompleted RolloutState = "completed"
+)
+
+func (e *RolloutState) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RolloutState(s)
+	case string:
+		*e = RolloutState(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RolloutState: %T", src)
+	}
+	return nil
+}
+
+type ScriptContext string
+
+const (
+	ScriptContextSystem ScriptContext = "system"
+	ScriptContextUser   ScriptContext = "user"
+)
+
+func (e *ScriptContext) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ScriptContext(s)
+	case string:
+		*e = ScriptContext(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ScriptContext: %T", src)
+	}
+	return nil
+}
+
+type ScriptSchedule string
+
+const (
+	ScriptScheduleOnce   ScriptSchedule = "once"
+	ScriptScheduleHourly ScriptSchedule = "hourly"
+	ScriptScheduleDaily  ScriptSchedule = "daily"
+	ScriptScheduleWeekly ScriptSchedule = "weekly"
+)
+
+func (e *ScriptSchedule) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ScriptSchedule(s)
+	case string:
+		*e = ScriptSchedule(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ScriptSchedule: %T", src)
+	}
+	return nil
+}
+
 type UserPermissionLevel string
 
 const (
@@ -70,12 +214,93 @@ func (e *UserPermissionLevel) Scan(src interface{}) error {
 	return nil
 }
 
+type App struct {
+	ID          int32     `json:"id"`
+	Type        AppType   `json:"type"`
+	Name        string    `json:"name"`
+	Publisher   string    `json:"publisher"`
+	Identifier  string    `json:"identifier"`
+	Version     string    `json:"version"`
+	FileHash    string    `json:"file_hash"`
+	FileSize    int64     `json:"file_size"`
+	FileName    string    `json:"file_name"`
+	CommandLine string    `json:"command_line"`
+	StoreID     string    `json:"store_id"`
+	StoreSku    string    `json:"store_sku"`
+	UserContext bool      `json:"user_context"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AppAssignment struct {
+	AppID   int32     `json:"app_id"`
+	GroupID int32     `json:"group_id"`
+	Intent  AppIntent `json:"intent"`
+}
+
+type AppInstall struct {
+	AppID     int32           `json:"app_id"`
+	DeviceID  int32           `json:"device_id"`
+	State     AppInstallState `json:"state"`
+	Status    string          `json:"status"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type BitlockerRecoveryKey struct {
+	ID                int32        `json:"id"`
+	DeviceID          int32        `json:"device_id"`
+	ProtectorID       string       `json:"protector_id"`
+	Volume            string       `json:"volume"`
+	EncryptedPassword []byte       `json:"encrypted_password"`
+	EscrowedAt        time.Time    `json:"escrowed_at"`
+	RemovedAt         sql.NullTime `json:"removed_at"`
+}
+
+type BitlockerRecoveryKeyAccess struct {
+	ID          int32     `json:"id"`
+	DeviceID    int32     `json:"device_id"`
+	ProtectorID string    `json:"protector_id"`
+	Upn         string    `json:"upn"`
+	Reason      string    `json:"reason"`
+	AccessedAt  time.Time `json:"accessed_at"`
+}
+
+type BitlockerRotation struct {
+	DeviceID    int32     `json:"device_id"`
+	RequestedBy string    `json:"requested_by"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
 type Certificate struct {
 	ID   string `json:"id"`
 	Cert []byte `json:"cert"`
 	Key  []byte `json:"key"`
 }
 
+type CertificateChallenge struct {
+	DeviceID      int32     `json:"device_id"`
+	ProfileID     int32     `json:"profile_id"`
+	Upn           string    `json:"upn"`
+	ChallengeHash string    `json:"challenge_hash"`
+	Subject       string    `json:"subject"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type CertificateProfile struct {
+	ID                int32     `json:"id"`
+	Name              string    `json:"name"`
+	Description       string    `json:"description"`
+	SubjectTemplate   string    `json:"subject_template"`
+	KeyUsages         []string  `json:"key_usages"`
+	ExtendedKeyUsages []string  `json:"extended_key_usages"`
+	KeyLength         int32     `json:"key_length"`
+	ValidityDays      int32     `json:"validity_days"`
+	RenewalThreshold  int32     `json:"renewal_threshold"`
+	UserContext       bool      `json:"user_context"`
+	GroupID           int32     `json:"group_id"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
 type Device struct {
 	ID               int32          `json:"id"`
 	Udid             string         `json:"udid"`
@@ -94,11 +319,33 @@ type Device struct {
 	EnrolledBy       null.String    `json:"enrolled_by"`
 }
 
+type DeviceApp struct {
+	DeviceID          int32     `json:"device_id"`
+	PackageFamilyName string    `json:"package_family_name"`
+	Source            string    `json:"source"`
+	Upn               string    `json:"upn"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
 type DeviceCache struct {
-	DeviceID    int32         `json:"device_id"`
-	PayloadID   sql.NullInt32 `json:"payload_id"`
-	InventoryID sql.NullInt32 `json:"inventory_id"`
-	CacheID     int32         `json:"cache_id"`
+	DeviceID      int32         `json:"device_id"`
+	PayloadID     sql.NullInt32 `json:"payload_id"`
+	InventoryID   sql.NullInt32 `json:"inventory_id"`
+	Upn           null.String   `json:"upn"`
+	PolicyVersion int32         `json:"policy_version"`
+	CacheID       int32         `json:"cache_id"`
+}
+
+type DeviceCommand struct {
+	ID        int32        `json:"id"`
+	DeviceID  int32        `json:"device_id"`
+	Command   string       `json:"command"`
+	Uri       string       `json:"uri"`
+	Format    string       `json:"format"`
+	Type      string       `json:"type"`
+	Value     string       `json:"value"`
+	CreatedAt time.Time    `json:"created_at"`
+	SentAt    sql.NullTime `json:"sent_at"`
 }
 
 type DeviceInventory struct {
@@ -112,11 +359,47 @@ type DeviceInventory struct {
 type DeviceSessionCache struct {
 }
 
+type EnrollmentAttempt struct {
+	TraceID     string                  `json:"trace_id"`
+	Endpoint    string                  `json:"endpoint"`
+	MessageID   string                  `json:"message_id"`
+	Upn         string                  `json:"upn"`
+	Udid        string                  `json:"udid"`
+	HwDevID     string                  `json:"hw_dev_id"`
+	DeviceID    sql.NullInt32           `json:"device_id"`
+	Result      EnrollmentAttemptResult `json:"result"`
+	FaultCode   string                  `json:"fault_code"`
+	FaultType   string                  `json:"fault_type"`
+	FaultReason string                  `json:"fault_reason"`
+	Error       string                  `json:"error"`
+	CreatedAt   time.Time               `json:"created_at"`
+}
+
+type EnrollmentBranding struct {
+	Language        string `json:"language"`
+	ApplicationName string `json:"application_name"`
+	Title           string `json:"title"`
+	BodyTemplate    string `json:"body_template"`
+	SupportUrl      string `json:"support_url"`
+	LogoUrl         string `json:"logo_url"`
+	SupportText     string `json:"support_text"`
+}
+
+type EnrollmentResponse struct {
+	DeviceID            int32     `json:"device_id"`
+	MessageID           string    `json:"message_id"`
+	Upn                 string    `json:"upn"`
+	ProvisioningProfile []byte    `json:"provisioning_profile"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
 type Group struct {
 	ID          int32  `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Priority    int16  `json:"priority"`
+	Rules       string `json:"rules"`
+	Timezone    string `json:"timezone"`
 }
 
 type GroupDevice struct {
@@ -125,8 +408,64 @@ type GroupDevice struct {
 }
 
 type GroupPolicy struct {
-	GroupID  sql.NullInt32 `json:"group_id"`
-	PolicyID sql.NullInt32 `json:"policy_id"`
+	GroupID   sql.NullInt32 `json:"group_id"`
+	PolicyID  sql.NullInt32 `json:"policy_id"`
+	NotBefore sql.NullTime  `json:"not_before"`
+}
+
+type GroupPolicyWindow struct {
+	GroupID     int32 `json:"group_id"`
+	PolicyID    int32 `json:"policy_id"`
+	Weekday     int16 `json:"weekday"`
+	StartMinute int16 `json:"start_minute"`
+	EndMinute   int16 `json:"end_minute"`
+}
+
+type IssuedCertificate struct {
+	Serial     string        `json:"serial"`
+	DeviceID   int32         `json:"device_id"`
+	ProfileID  sql.NullInt32 `json:"profile_id"`
+	Upn        string        `json:"upn"`
+	Subject    string        `json:"subject"`
+	Thumbprint string        `json:"thumbprint"`
+	NotBefore  time.Time     `json:"not_before"`
+	NotAfter   time.Time     `json:"not_after"`
+	IssuedAt   time.Time     `json:"issued_at"`
+	RevokedAt  sql.NullTime  `json:"revoked_at"`
+}
+
+type LocalAdminPassword struct {
+	DeviceID                 int32        `json:"device_id"`
+	ProfileID                int32        `json:"profile_id"`
+	EncryptedPassword        []byte       `json:"encrypted_password"`
+	SetAt                    sql.NullTime `json:"set_at"`
+	PendingEncryptedPassword []byte       `json:"pending_encrypted_password"`
+	PendingSince             sql.NullTime `json:"pending_since"`
+	RotateAt                 sql.NullTime `json:"rotate_at"`
+	LastError                string       `json:"last_error"`
+}
+
+type LocalAdminPasswordAccess struct {
+	ID          int32     `json:"id"`
+	DeviceID    int32     `json:"device_id"`
+	ProfileID   int32     `json:"profile_id"`
+	AccountName string    `json:"account_name"`
+	Upn         string    `json:"upn"`
+	Reason      string    `json:"reason"`
+	AccessedAt  time.Time `json:"accessed_at"`
+}
+
+type LocalAdminProfile struct {
+	ID                   int32     `json:"id"`
+	Name                 string    `json:"name"`
+	Description          string    `json:"description"`
+	AccountName          string    `json:"account_name"`
+	PasswordLength       int32     `json:"password_length"`
+	RotationDays         int32     `json:"rotation_days"`
+	RotateAfterViewHours int32     `json:"rotate_after_view_hours"`
+	GroupID              int32     `json:"group_id"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
 }
 
 type PoliciesPayload struct {
@@ -144,15 +483,92 @@ type Policy struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Priority    int16  `json:"priority"`
+	Version     int32  `json:"version"`
+}
+
+type PolicyVersion struct {
+	PolicyID    int32           `json:"policy_id"`
+	Version     int32           `json:"version"`
+	Author      string          `json:"author"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Priority    int16           `json:"priority"`
+	Payloads    json.RawMessage `json:"payloads"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type Rollout struct {
+	ID               int32        `json:"id"`
+	PolicyID         int32        `json:"policy_id"`
+	GroupID          int32        `json:"group_id"`
+	Stage            int16        `json:"stage"`
+	State            RolloutState `json:"state"`
+	FailureThreshold int16        `json:"failure_threshold"`
+	SuccessThreshold int16        `json:"success_threshold"`
+	CreatedAt        time.Time    `json:"created_at"`
+}
+
+type RolloutResult struct {
+	RolloutID int32     `json:"rollout_id"`
+	DeviceID  int32     `json:"device_id"`
+	Stage     int16     `json:"stage"`
+	Status    int32     `json:"status"`
+	Failed    bool      `json:"failed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type RolloutStage struct {
+	RolloutID   int32         `json:"rollout_id"`
+	Stage       int16         `json:"stage"`
+	Percentage  int16         `json:"percentage"`
+	RingGroupID sql.NullInt32 `json:"ring_group_id"`
+}
+
+type Script struct {
+	ID          int32          `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Content     string         `json:"content"`
+	RunContext  ScriptContext  `json:"run_context"`
+	Schedule    ScriptSchedule `json:"schedule"`
+	GroupID     int32          `json:"group_id"`
+	Version     int32          `json:"version"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type ScriptRun struct {
+	ScriptID   int32     `json:"script_id"`
+	DeviceID   int32     `json:"device_id"`
+	Version    int32     `json:"version"`
+	ExitCode   int32     `json:"exit_code"`
+	Stdout     string    `json:"stdout"`
+	Stderr     string    `json:"stderr"`
+	FinishedAt time.Time `json:"finished_at"`
 }
 
 type Setting struct {
-	TenantName        string `json:"tenant_name"`
-	TenantEmail       string `json:"tenant_email"`
-	TenantWebsite     string `json:"tenant_website"`
-	TenantPhone       string `json:"tenant_phone"`
-	TenantAzureid     string `json:"tenant_azureid"`
-	DisableEnrollment bool   `json:"disable_enrollment"`
+	TenantName         string `json:"tenant_name"`
+	TenantEmail        string `json:"tenant_email"`
+	TenantWebsite      string `json:"tenant_website"`
+	TenantPhone        string `json:"tenant_phone"`
+	TenantAzureid      string `json:"tenant_azureid"`
+	DisableEnrollment  bool   `json:"disable_enrollment"`
+	DeviceNameTemplate string `json:"device_name_template"`
+	DeviceNamePrefix   string `json:"device_name_prefix"`
+}
+
+type TermsOfService struct {
+	Version   int32     `json:"version"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TermsOfServiceAcceptance struct {
+	Upn        string    `json:"upn"`
+	Version    int32     `json:"version"`
+	AcceptedAt time.Time `json:"accepted_at"`
 }
 
 type User struct {
@@ -163,3 +579,20 @@ type User struct {
 	AzureadOid      null.String         `json:"azuread_oid"`
 	PermissionLevel UserPermissionLevel `json:"permission_level"`
 }
+
+type UserGroup struct {
+	ID          int32  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Priority    int16  `json:"priority"`
+}
+
+type UserGroupMember struct {
+	GroupID int32  `json:"group_id"`
+	Upn     string `json:"upn"`
+}
+
+type UserGroupPolicy struct {
+	GroupID  int32 `json:"group_id"`
+	PolicyID int32 `json:"policy_id"`
+}
@@ -6,744 +6,4827 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/mattrax/Mattrax/pkg/null"
 )
 
-const createRawCert = `-- name: CreateRawCert :exec
-INSERT INTO certificates(id, cert, key) VALUES ($1, $2, $3)
+const acceptTermsOfService = `-- name: AcceptTermsOfService :exec
+INSERT INTO terms_of_service_acceptances(upn, version) VALUES ($1, $2) ON CONFLICT DO NOTHING
 `
 
-type CreateRawCertParams struct {
-	ID   string `json:"id"`
-	Cert []byte `json:"cert"`
-	Key  []byte `json:"key"`
+type AcceptTermsOfServiceParams struct {
+	Upn     string `json:"upn"`
+	Version int32  `json:"version"`
 }
 
-func (q *Queries) CreateRawCert(ctx context.Context, arg CreateRawCertParams) error {
-	_, err := q.exec(ctx, q.createRawCertStmt, createRawCert, arg.ID, arg.Cert, arg.Key)
+func (q *Queries) AcceptTermsOfService(ctx context.Context, arg AcceptTermsOfServiceParams) error {
+	_, err := q.exec(ctx, q.acceptTermsOfServiceStmt, acceptTermsOfService, arg.Upn, arg.Version)
 	return err
 }
 
-const createUser = `-- name: CreateUser :exec
-INSERT INTO users(upn, fullname, password) VALUES ($1, $2, $3)
+const addDeviceInventoryApp = `-- name: AddDeviceInventoryApp :exec
+INSERT INTO device_apps(device_id, package_family_name, source, upn) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING
 `
 
-type CreateUserParams struct {
-	Upn      string      `json:"upn"`
-	Fullname string      `json:"fullname"`
-	Password null.String `json:"password"`
+type AddDeviceInventoryAppParams struct {
+	DeviceID          int32  `json:"device_id"`
+	PackageFamilyName string `json:"package_family_name"`
+	Source            string `json:"source"`
+	Upn               string `json:"upn"`
 }
 
-// Exposed via API
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
-	_, err := q.exec(ctx, q.createUserStmt, createUser, arg.Upn, arg.Fullname, arg.Password)
+func (q *Queries) AddDeviceInventoryApp(ctx context.Context, arg AddDeviceInventoryAppParams) error {
+	_, err := q.exec(ctx, q.addDeviceInventoryAppStmt, addDeviceInventoryApp,
+		arg.DeviceID,
+		arg.PackageFamilyName,
+		arg.Source,
+		arg.Upn,
+	)
 	return err
 }
 
-const deleteDeviceCacheNode = `-- name: DeleteDeviceCacheNode :exec
-DELETE FROM device_cache WHERE device_id = $1 AND payload_id = $2
+const addGroupDevices = `-- name: AddGroupDevices :exec
+INSERT INTO group_devices(group_id, device_id) SELECT $1::integer, devices.id FROM devices WHERE devices.id = ANY($2::integer[]) ON CONFLICT DO NOTHING
 `
 
-type DeleteDeviceCacheNodeParams struct {
-	DeviceID  int32         `json:"device_id"`
-	PayloadID sql.NullInt32 `json:"payload_id"`
+type AddGroupDevicesParams struct {
+	GroupID   int32   `json:"group_id"`
+	DeviceIds []int32 `json:"device_ids"`
 }
 
-func (q *Queries) DeleteDeviceCacheNode(ctx context.Context, arg DeleteDeviceCacheNodeParams) error {
-	_, err := q.exec(ctx, q.deleteDeviceCacheNodeStmt, deleteDeviceCacheNode, arg.DeviceID, arg.PayloadID)
+// Exposed via API. Unknown devices are ignored.
+func (q *Queries) AddGroupDevices(ctx context.Context, arg AddGroupDevicesParams) error {
+	_, err := q.exec(ctx, q.addGroupDevicesStmt, addGroupDevices, arg.GroupID, pq.Array(arg.DeviceIds))
 	return err
 }
 
-const deviceCheckinStatus = `-- name: DeviceCheckinStatus :exec
-UPDATE devices SET lastseen=NOW(), lastseen_status=$2 WHERE id = $1
+const addUserGroupMembers = `-- name: AddUserGroupMembers :exec
+INSERT INTO user_group_members(group_id, upn) SELECT $1::integer, users.upn FROM users WHERE users.upn = ANY($2::text[]) ON CONFLICT DO NOTHING
 `
 
-type DeviceCheckinStatusParams struct {
-	ID             int32 `json:"id"`
-	LastseenStatus int32 `json:"lastseen_status"`
+type AddUserGroupMembersParams struct {
+	GroupID int32    `json:"group_id"`
+	Upns    []string `json:"upns"`
 }
 
-func (q *Queries) DeviceCheckinStatus(ctx context.Context, arg DeviceCheckinStatusParams) error {
-	_, err := q.exec(ctx, q.deviceCheckinStatusStmt, deviceCheckinStatus, arg.ID, arg.LastseenStatus)
+// Exposed via API. Unknown users are ignored.
+func (q *Queries) AddUserGroupMembers(ctx context.Context, arg AddUserGroupMembersParams) error {
+	_, err := q.exec(ctx, q.addUserGroupMembersStmt, addUserGroupMembers, arg.GroupID, pq.Array(arg.Upns))
 	return err
 }
 
-const deviceUserUnenrollment = `-- name: DeviceUserUnenrollment :exec
-UPDATE devices SET state='user_unenrolled', enrollment_type='Unenrolled', azure_did='', nodecache_version='', lastseen=to_timestamp(CAST(0 as bigint)/1000), lastseen_status=0, enrolled_at=to_timestamp(CAST(0 as bigint)/1000), enrolled_by=NULL WHERE id = $1
+const attachGroupPolicy = `-- name: AttachGroupPolicy :exec
+INSERT INTO group_policies(group_id, policy_id) VALUES ($1, $2) ON CONFLICT DO NOTHING
 `
 
-func (q *Queries) DeviceUserUnenrollment(ctx context.Context, id int32) error {
-	_, err := q.exec(ctx, q.deviceUserUnenrollmentStmt, deviceUserUnenrollment, id)
+type AttachGroupPolicyParams struct {
+	GroupID  sql.NullInt32 `json:"group_id"`
+	PolicyID sql.NullInt32 `json:"policy_id"`
+}
+
+// Exposed via API
+func (q *Queries) AttachGroupPolicy(ctx context.Context, arg AttachGroupPolicyParams) error {
+	_, err := q.exec(ctx, q.attachGroupPolicyStmt, attachGroupPolicy, arg.GroupID, arg.PolicyID)
 	return err
 }
 
-const getBasicDevice = `-- name: GetBasicDevice :one
-SELECT id, name, description, model FROM devices WHERE id = $1 LIMIT 1
+const attachUserGroupPolicy = `-- name: AttachUserGroupPolicy :exec
+INSERT INTO user_group_policies(group_id, policy_id) VALUES ($1, $2) ON CONFLICT DO NOTHING
 `
 
-type GetBasicDeviceRow struct {
-	ID          int32       `json:"id"`
-	Name        string      `json:"name"`
-	Description null.String `json:"description"`
-	Model       string      `json:"model"`
+type AttachUserGroupPolicyParams struct {
+	GroupID  int32 `json:"group_id"`
+	PolicyID int32 `json:"policy_id"`
 }
 
 // Exposed via API
-func (q *Queries) GetBasicDevice(ctx context.Context, id int32) (GetBasicDeviceRow, error) {
-	row := q.queryRow(ctx, q.getBasicDeviceStmt, getBasicDevice, id)
-	var i GetBasicDeviceRow
+func (q *Queries) AttachUserGroupPolicy(ctx context.Context, arg AttachUserGroupPolicyParams) error {
+	_, err := q.exec(ctx, q.attachUserGroupPolicyStmt, attachUserGroupPolicy, arg.GroupID, arg.PolicyID)
+	return err
+}
+
+const claimCertificateChallenge = `-- name: ClaimCertificateChallenge :one
+DELETE FROM certificate_challenges WHERE challenge_hash = $1 RETURNING device_id, profile_id, upn, challenge_hash, subject, created_at
+`
+
+// The challenge is deleted as it is retrieved so concurrent requests can't both use it
+func (q *Queries) ClaimCertificateChallenge(ctx context.Context, challengeHash string) (CertificateChallenge, error) {
+	row := q.queryRow(ctx, q.claimCertificateChallengeStmt, claimCertificateChallenge, challengeHash)
+	var i CertificateChallenge
 	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Description,
-		&i.Model,
+		&i.DeviceID,
+		&i.ProfileID,
+		&i.Upn,
+		&i.ChallengeHash,
+		&i.Subject,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getBasicDeviceScopedGroups = `-- name: GetBasicDeviceScopedGroups :many
-SELECT groups.id, groups.name FROM groups INNER JOIN group_devices ON group_devices.group_id=groups.id WHERE group_devices.device_id = $1
+const clearDeviceInventoryApps = `-- name: ClearDeviceInventoryApps :exec
+DELETE FROM device_apps WHERE device_id = $1 AND source = $2 AND upn = $3
 `
 
-type GetBasicDeviceScopedGroupsRow struct {
-	ID   int32  `json:"id"`
-	Name string `json:"name"`
+type ClearDeviceInventoryAppsParams struct {
+	DeviceID int32  `json:"device_id"`
+	Source   string `json:"source"`
+	Upn      string `json:"upn"`
 }
 
-// Exposed via API
-func (q *Queries) GetBasicDeviceScopedGroups(ctx context.Context, deviceID int32) ([]GetBasicDeviceScopedGroupsRow, error) {
-	rows, err := q.query(ctx, q.getBasicDeviceScopedGroupsStmt, getBasicDeviceScopedGroups, deviceID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetBasicDeviceScopedGroupsRow
-	for rows.Next() {
-		var i GetBasicDeviceScopedGroupsRow
-		if err := rows.Scan(&i.ID, &i.Name); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) ClearDeviceInventoryApps(ctx context.Context, arg ClearDeviceInventoryAppsParams) error {
+	_, err := q.exec(ctx, q.clearDeviceInventoryAppsStmt, clearDeviceInventoryApps, arg.DeviceID, arg.Source, arg.Upn)
+	return err
 }
 
-const getBasicDeviceScopedPolicies = `-- name: GetBasicDeviceScopedPolicies :many
-SELECT id, name, description, priority, group_policies.group_id, policy_id, group_devices.group_id, device_id FROM policies INNER JOIN group_policies ON group_policies.policy_id = policies.id INNER JOIN group_devices ON group_devices.group_id=group_policies.group_id WHERE group_devices.device_id = $1
+const confirmLocalAdminPassword = `-- name: ConfirmLocalAdminPassword :exec
+UPDATE local_admin_passwords SET encrypted_password=pending_encrypted_password, set_at=NOW(), pending_encrypted_password=NULL, pending_since=NULL, rotate_at=NULL, last_error='' WHERE device_id = $1 AND profile_id = $2 AND pending_encrypted_password IS NOT NULL
 `
 
-type GetBasicDeviceScopedPoliciesRow struct {
-	ID          int32         `json:"id"`
-	Name        string        `json:"name"`
-	Description string        `json:"description"`
-	Priority    int16         `json:"priority"`
-	GroupID     sql.NullInt32 `json:"group_id"`
-	PolicyID    sql.NullInt32 `json:"policy_id"`
-	GroupID_2   int32         `json:"group_id_2"`
-	DeviceID    int32         `json:"device_id"`
+type ConfirmLocalAdminPasswordParams struct {
+	DeviceID  int32 `json:"device_id"`
+	ProfileID int32 `json:"profile_id"`
 }
 
-// Exposed via API
-func (q *Queries) GetBasicDeviceScopedPolicies(ctx context.Context, deviceID int32) ([]GetBasicDeviceScopedPoliciesRow, error) {
-	rows, err := q.query(ctx, q.getBasicDeviceScopedPoliciesStmt, getBasicDeviceScopedPolicies, deviceID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetBasicDeviceScopedPoliciesRow
-	for rows.Next() {
-		var i GetBasicDeviceScopedPoliciesRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Description,
-			&i.Priority,
-			&i.GroupID,
-			&i.PolicyID,
-			&i.GroupID_2,
-			&i.DeviceID,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+// The pending password is set on the device so it replaces the current password
+func (q *Queries) ConfirmLocalAdminPassword(ctx context.Context, arg ConfirmLocalAdminPasswordParams) error {
+	_, err := q.exec(ctx, q.confirmLocalAdminPasswordStmt, confirmLocalAdminPassword, arg.DeviceID, arg.ProfileID)
+	return err
 }
 
-const getDevice = `-- name: GetDevice :one
-SELECT id, udid, state, enrollment_type, name, description, model, hw_dev_id, operating_system, azure_did, nodecache_version, lastseen, lastseen_status, enrolled_at, enrolled_by FROM devices WHERE id = $1 LIMIT 1
+const countDeviceScripts = `-- name: CountDeviceScripts :one
+SELECT COUNT(*) FROM scripts INNER JOIN group_devices ON group_devices.group_id = scripts.group_id WHERE group_devices.device_id = $1
 `
 
-func (q *Queries) GetDevice(ctx context.Context, id int32) (Device, error) {
-	row := q.queryRow(ctx, q.getDeviceStmt, getDevice, id)
-	var i Device
-	err := row.Scan(
-		&i.ID,
-		&i.Udid,
-		&i.State,
-		&i.EnrollmentType,
-		&i.Name,
-		&i.Description,
-		&i.Model,
-		&i.HwDevID,
-		&i.OperatingSystem,
-		&i.AzureDid,
-		&i.NodecacheVersion,
-		&i.Lastseen,
-		&i.LastseenStatus,
-		&i.EnrolledAt,
-		&i.EnrolledBy,
+func (q *Queries) CountDeviceScripts(ctx context.Context, deviceID int32) (int64, error) {
+	row := q.queryRow(ctx, q.countDeviceScriptsStmt, countDeviceScripts, deviceID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createApp = `-- name: CreateApp :one
+INSERT INTO apps(type, name, publisher, identifier, version, file_hash, file_size, file_name, command_line, store_id, store_sku, user_context) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id
+`
+
+type CreateAppParams struct {
+	Type        AppType `json:"type"`
+	Name        string  `json:"name"`
+	Publisher   string  `json:"publisher"`
+	Identifier  string  `json:"identifier"`
+	Version     string  `json:"version"`
+	FileHash    string  `json:"file_hash"`
+	FileSize    int64   `json:"file_size"`
+	FileName    string  `json:"file_name"`
+	CommandLine string  `json:"command_line"`
+	StoreID     string  `json:"store_id"`
+	StoreSku    string  `json:"store_sku"`
+	UserContext bool    `json:"user_context"`
+}
+
+func (q *Queries) CreateApp(ctx context.Context, arg CreateAppParams) (int32, error) {
+	row := q.queryRow(ctx, q.createAppStmt, createApp,
+		arg.Type,
+		arg.Name,
+		arg.Publisher,
+		arg.Identifier,
+		arg.Version,
+		arg.FileHash,
+		arg.FileSize,
+		arg.FileName,
+		arg.CommandLine,
+		arg.StoreID,
+		arg.StoreSku,
+		arg.UserContext,
 	)
-	return i, err
+	var id int32
+	err := row.Scan(&id)
+	return id, err
 }
 
-const getDeviceByUDID = `-- name: GetDeviceByUDID :one
-SELECT id, udid, state, enrollment_type, name, description, model, hw_dev_id, operating_system, azure_did, nodecache_version, lastseen, lastseen_status, enrolled_at, enrolled_by FROM devices WHERE udid = $1 LIMIT 1
+const createBitLockerRecoveryKey = `-- name: CreateBitLockerRecoveryKey :exec
+INSERT INTO bitlocker_recovery_keys(device_id, protector_id, volume, encrypted_password) VALUES ($1, $2, $3, $4)
 `
 
-func (q *Queries) GetDeviceByUDID(ctx context.Context, udid string) (Device, error) {
-	row := q.queryRow(ctx, q.getDeviceByUDIDStmt, getDeviceByUDID, udid)
-	var i Device
-	err := row.Scan(
-		&i.ID,
-		&i.Udid,
-		&i.State,
-		&i.EnrollmentType,
-		&i.Name,
-		&i.Description,
-		&i.Model,
-		&i.HwDevID,
-		&i.OperatingSystem,
-		&i.AzureDid,
-		&i.NodecacheVersion,
-		&i.Lastseen,
-		&i.LastseenStatus,
-		&i.EnrolledAt,
-		&i.EnrolledBy,
+type CreateBitLockerRecoveryKeyParams struct {
+	DeviceID          int32  `json:"device_id"`
+	ProtectorID       string `json:"protector_id"`
+	Volume            string `json:"volume"`
+	EncryptedPassword []byte `json:"encrypted_password"`
+}
+
+func (q *Queries) CreateBitLockerRecoveryKey(ctx context.Context, arg CreateBitLockerRecoveryKeyParams) error {
+	_, err := q.exec(ctx, q.createBitLockerRecoveryKeyStmt, createBitLockerRecoveryKey,
+		arg.DeviceID,
+		arg.ProtectorID,
+		arg.Volume,
+		arg.EncryptedPassword,
 	)
-	return i, err
+	return err
 }
 
-const getDevices = `-- name: GetDevices :many
-SELECT id, name, model FROM devices LIMIT 100
+const createCertificateProfile = `-- name: CreateCertificateProfile :one
+INSERT INTO certificate_profiles(name, description, subject_template, key_usages, extended_key_usages, key_length, validity_days, renewal_threshold, user_context, group_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id
 `
 
-type GetDevicesRow struct {
-	ID    int32  `json:"id"`
-	Name  string `json:"name"`
-	Model string `json:"model"`
+type CreateCertificateProfileParams struct {
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	SubjectTemplate   string   `json:"subject_template"`
+	KeyUsages         []string `json:"key_usages"`
+	ExtendedKeyUsages []string `json:"extended_key_usages"`
+	KeyLength         int32    `json:"key_length"`
+	ValidityDays      int32    `json:"validity_days"`
+	RenewalThreshold  int32    `json:"renewal_threshold"`
+	UserContext       bool     `json:"user_context"`
+	GroupID           int32    `json:"group_id"`
 }
 
-// Exposed via API
-func (q *Queries) GetDevices(ctx context.Context) ([]GetDevicesRow, error) {
-	rows, err := q.query(ctx, q.getDevicesStmt, getDevices)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetDevicesRow
-	for rows.Next() {
-		var i GetDevicesRow
-		if err := rows.Scan(&i.ID, &i.Name, &i.Model); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) CreateCertificateProfile(ctx context.Context, arg CreateCertificateProfileParams) (int32, error) {
+	row := q.queryRow(ctx, q.createCertificateProfileStmt, createCertificateProfile,
+		arg.Name,
+		arg.Description,
+		arg.SubjectTemplate,
+		pq.Array(arg.KeyUsages),
+		pq.Array(arg.ExtendedKeyUsages),
+		arg.KeyLength,
+		arg.ValidityDays,
+		arg.RenewalThreshold,
+		arg.UserContext,
+		arg.GroupID,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
 }
 
-const getDevicesDetachedPayloads = `-- name: GetDevicesDetachedPayloads :many
-SELECT id, uri, exec FROM device_cache INNER JOIN policies_payload ON policies_payload.id=device_cache.payload_id WHERE device_cache.device_id = $1 AND NOT EXISTS (SELECT policies_payload.id, policies_payload.policy_id, policies_payload.uri, policies_payload.format, policies_payload.type, policies_payload.value, policies_payload.exec FROM group_devices INNER JOIN group_policies ON group_policies.group_id=group_devices.group_id INNER JOIN policies_payload ON policies_payload.policy_id=group_policies.policy_id WHERE group_devices.device_id = device_cache.device_id)
+const createGroup = `-- name: CreateGroup :one
+INSERT INTO groups(name, description, priority, rules, timezone) VALUES ($1, $2, $3, $4, $5) RETURNING id
 `
 
-type GetDevicesDetachedPayloadsRow struct {
-	ID   int32  `json:"id"`
-	Uri  string `json:"uri"`
-	Exec bool   `json:"exec"`
+type CreateGroupParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Priority    int16  `json:"priority"`
+	Rules       string `json:"rules"`
+	Timezone    string `json:"timezone"`
 }
 
-func (q *Queries) GetDevicesDetachedPayloads(ctx context.Context, deviceID int32) ([]GetDevicesDetachedPayloadsRow, error) {
-	rows, err := q.query(ctx, q.getDevicesDetachedPayloadsStmt, getDevicesDetachedPayloads, deviceID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetDevicesDetachedPayloadsRow
-	for rows.Next() {
-		var i GetDevicesDetachedPayloadsRow
-		if err := rows.Scan(&i.ID, &i.Uri, &i.Exec); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+// Exposed via API
+func (q *Queries) CreateGroup(ctx context.Context, arg CreateGroupParams) (int32, error) {
+	row := q.queryRow(ctx, q.createGroupStmt, createGroup,
+		arg.Name,
+		arg.Description,
+		arg.Priority,
+		arg.Rules,
+		arg.Timezone,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
 }
 
-const getDevicesPayloads = `-- name: GetDevicesPayloads :many
+const createGroupPolicyWindow = `-- name: CreateGroupPolicyWindow :exec
+INSERT INTO group_policy_windows(group_id, policy_id, weekday, start_minute, end_minute) VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateGroupPolicyWindowParams struct {
+	GroupID     int32 `json:"group_id"`
+	PolicyID    int32 `json:"policy_id"`
+	Weekday     int16 `json:"weekday"`
+	StartMinute int16 `json:"start_minute"`
+	EndMinute   int16 `json:"end_minute"`
+}
+
+func (q *Queries) CreateGroupPolicyWindow(ctx context.Context, arg CreateGroupPolicyWindowParams) error {
+	_, err := q.exec(ctx, q.createGroupPolicyWindowStmt, createGroupPolicyWindow,
+		arg.GroupID,
+		arg.PolicyID,
+		arg.Weekday,
+		arg.StartMinute,
+		arg.EndMinute,
+	)
+	return err
+}
 
-SELECT policies_payload.id, policies_payload.policy_id, policies_payload.uri, policies_payload.format, policies_payload.type, policies_payload.value, policies_payload.exec FROM group_devices INNER JOIN group_policies ON group_policies.group_id=group_devices.group_id INNER JOIN policies_payload ON policies_payload.policy_id=group_policies.policy_id WHERE group_devices.device_id = $1
+const createIssuedCertificate = `-- name: CreateIssuedCertificate :exec
+INSERT INTO issued_certificates(serial, device_id, profile_id, upn, subject, thumbprint, not_before, not_after) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 `
 
-// TODO: Merge this with last checkin status
-func (q *Queries) GetDevicesPayloads(ctx context.Context, deviceID int32) ([]PoliciesPayload, error) {
-	rows, err := q.query(ctx, q.getDevicesPayloadsStmt, getDevicesPayloads, deviceID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []PoliciesPayload
-	for rows.Next() {
-		var i PoliciesPayload
-		if err := rows.Scan(
-			&i.ID,
-			&i.PolicyID,
-			&i.Uri,
-			&i.Format,
-			&i.Type,
-			&i.Value,
-			&i.Exec,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type CreateIssuedCertificateParams struct {
+	Serial     string        `json:"serial"`
+	DeviceID   int32         `json:"device_id"`
+	ProfileID  sql.NullInt32 `json:"profile_id"`
+	Upn        string        `json:"upn"`
+	Subject    string        `json:"subject"`
+	Thumbprint string        `json:"thumbprint"`
+	NotBefore  time.Time     `json:"not_before"`
+	NotAfter   time.Time     `json:"not_after"`
+}
+
+func (q *Queries) CreateIssuedCertificate(ctx context.Context, arg CreateIssuedCertificateParams) error {
+	_, err := q.exec(ctx, q.createIssuedCertificateStmt, createIssuedCertificate,
+		arg.Serial,
+		arg.DeviceID,
+		arg.ProfileID,
+		arg.Upn,
+		arg.Subject,
+		arg.Thumbprint,
+		arg.NotBefore,
+		arg.NotAfter,
+	)
+	return err
 }
 
-const getDevicesPayloadsAwaitingDeployment = `-- name: GetDevicesPayloadsAwaitingDeployment :many
-SELECT id, uri, format, type, value, exec FROM group_devices INNER JOIN group_policies ON group_policies.group_id=group_devices.group_id INNER JOIN policies_payload ON policies_payload.policy_id=group_policies.policy_id WHERE group_devices.device_id = $1 AND NOT EXISTS (SELECT 1 FROM device_cache WHERE device_cache.payload_id = policies_payload.id AND device_cache.device_id=group_devices.device_id)
+const createLocalAdminProfile = `-- name: CreateLocalAdminProfile :one
+INSERT INTO local_admin_profiles(name, description, account_name, password_length, rotation_days, rotate_after_view_hours, group_id) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id
 `
 
-type GetDevicesPayloadsAwaitingDeploymentRow struct {
-	ID     int32  `json:"id"`
-	Uri    string `json:"uri"`
-	Format string `json:"format"`
-	Type   string `json:"type"`
-	Value  string `json:"value"`
-	Exec   bool   `json:"exec"`
+type CreateLocalAdminProfileParams struct {
+	Name                 string `json:"name"`
+	Description          string `json:"description"`
+	AccountName          string `json:"account_name"`
+	PasswordLength       int32  `json:"password_length"`
+	RotationDays         int32  `json:"rotation_days"`
+	RotateAfterViewHours int32  `json:"rotate_after_view_hours"`
+	GroupID              int32  `json:"group_id"`
 }
 
-func (q *Queries) GetDevicesPayloadsAwaitingDeployment(ctx context.Context, deviceID int32) ([]GetDevicesPayloadsAwaitingDeploymentRow, error) {
-	rows, err := q.query(ctx, q.getDevicesPayloadsAwaitingDeploymentStmt, getDevicesPayloadsAwaitingDeployment, deviceID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetDevicesPayloadsAwaitingDeploymentRow
-	for rows.Next() {
-		var i GetDevicesPayloadsAwaitingDeploymentRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Uri,
-			&i.Format,
-			&i.Type,
-			&i.Value,
-			&i.Exec,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) CreateLocalAdminProfile(ctx context.Context, arg CreateLocalAdminProfileParams) (int32, error) {
+	row := q.queryRow(ctx, q.createLocalAdminProfileStmt, createLocalAdminProfile,
+		arg.Name,
+		arg.Description,
+		arg.AccountName,
+		arg.PasswordLength,
+		arg.RotationDays,
+		arg.RotateAfterViewHours,
+		arg.GroupID,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
 }
 
-const getGroup = `-- name: GetGroup :one
-SELECT id, name, description, priority FROM groups WHERE id = $1 LIMIT 1
+const createPolicy = `-- name: CreatePolicy :one
+INSERT INTO policies(name, description, priority) VALUES ($1, $2, $3) RETURNING id
 `
 
-// Exposed via API
-func (q *Queries) GetGroup(ctx context.Context, id int32) (Group, error) {
-	row := q.queryRow(ctx, q.getGroupStmt, getGroup, id)
-	var i Group
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Description,
-		&i.Priority,
-	)
-	return i, err
+type CreatePolicyParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Priority    int16  `json:"priority"`
 }
 
-const getGroups = `-- name: GetGroups :many
-SELECT id, name, description, priority FROM groups LIMIT 100
-`
-
 // Exposed via API
-func (q *Queries) GetGroups(ctx context.Context) ([]Group, error) {
-	rows, err := q.query(ctx, q.getGroupsStmt, getGroups)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Group
-	for rows.Next() {
-		var i Group
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Description,
-			&i.Priority,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) CreatePolicy(ctx context.Context, arg CreatePolicyParams) (int32, error) {
+	row := q.queryRow(ctx, q.createPolicyStmt, createPolicy, arg.Name, arg.Description, arg.Priority)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
 }
 
-const getPolicies = `-- name: GetPolicies :many
-
-SELECT id, name FROM policies LIMIT 100
+const createPolicyPayload = `-- name: CreatePolicyPayload :one
+INSERT INTO policies_payload(policy_id, uri, format, type, value, exec) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id
 `
 
-type GetPoliciesRow struct {
-	ID   int32  `json:"id"`
-	Name string `json:"name"`
+type CreatePolicyPayloadParams struct {
+	PolicyID sql.NullInt32 `json:"policy_id"`
+	Uri      string        `json:"uri"`
+	Format   string        `json:"format"`
+	Type     string        `json:"type"`
+	Value    string        `json:"value"`
+	Exec     bool          `json:"exec"`
 }
 
-// TODO: Update or Replace
 // Exposed via API
-func (q *Queries) GetPolicies(ctx context.Context) ([]GetPoliciesRow, error) {
-	rows, err := q.query(ctx, q.getPoliciesStmt, getPolicies)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetPoliciesRow
-	for rows.Next() {
-		var i GetPoliciesRow
-		if err := rows.Scan(&i.ID, &i.Name); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) CreatePolicyPayload(ctx context.Context, arg CreatePolicyPayloadParams) (int32, error) {
+	row := q.queryRow(ctx, q.createPolicyPayloadStmt, createPolicyPayload,
+		arg.PolicyID,
+		arg.Uri,
+		arg.Format,
+		arg.Type,
+		arg.Value,
+		arg.Exec,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
 }
 
-const getPoliciesPayloads = `-- name: GetPoliciesPayloads :many
-SELECT id, policy_id, uri, format, type, value, exec FROM policies_payload WHERE policy_id = $1
+const createPolicyVersion = `-- name: CreatePolicyVersion :exec
+INSERT INTO policy_versions(policy_id, version, author, name, description, priority, payloads) VALUES ($1, $2, $3, $4, $5, $6, $7)
 `
 
-func (q *Queries) GetPoliciesPayloads(ctx context.Context, policyID sql.NullInt32) ([]PoliciesPayload, error) {
-	rows, err := q.query(ctx, q.getPoliciesPayloadsStmt, getPoliciesPayloads, policyID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []PoliciesPayload
-	for rows.Next() {
-		var i PoliciesPayload
-		if err := rows.Scan(
-			&i.ID,
-			&i.PolicyID,
-			&i.Uri,
-			&i.Format,
-			&i.Type,
-			&i.Value,
-			&i.Exec,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type CreatePolicyVersionParams struct {
+	PolicyID    int32           `json:"policy_id"`
+	Version     int32           `json:"version"`
+	Author      string          `json:"author"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Priority    int16           `json:"priority"`
+	Payloads    json.RawMessage `json:"payloads"`
 }
 
-const getPolicy = `-- name: GetPolicy :one
-SELECT id, name, description, priority FROM policies WHERE id = $1 LIMIT 1
-`
-
-// Exposed via API
-func (q *Queries) GetPolicy(ctx context.Context, id int32) (Policy, error) {
-	row := q.queryRow(ctx, q.getPolicyStmt, getPolicy, id)
-	var i Policy
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Description,
-		&i.Priority,
+func (q *Queries) CreatePolicyVersion(ctx context.Context, arg CreatePolicyVersionParams) error {
+	_, err := q.exec(ctx, q.createPolicyVersionStmt, createPolicyVersion,
+		arg.PolicyID,
+		arg.Version,
+		arg.Author,
+		arg.Name,
+		arg.Description,
+		arg.Priority,
+		arg.Payloads,
 	)
-	return i, err
+	return err
 }
 
-const getRawCert = `-- name: GetRawCert :one
-SELECT cert, key FROM certificates WHERE id = $1 LIMIT 1
+const createRawCert = `-- name: CreateRawCert :exec
+INSERT INTO certificates(id, cert, key) VALUES ($1, $2, $3)
 `
 
-type GetRawCertRow struct {
+type CreateRawCertParams struct {
+	ID   string `json:"id"`
 	Cert []byte `json:"cert"`
 	Key  []byte `json:"key"`
 }
 
-func (q *Queries) GetRawCert(ctx context.Context, id string) (GetRawCertRow, error) {
-	row := q.queryRow(ctx, q.getRawCertStmt, getRawCert, id)
-	var i GetRawCertRow
-	err := row.Scan(&i.Cert, &i.Key)
-	return i, err
+func (q *Queries) CreateRawCert(ctx context.Context, arg CreateRawCertParams) error {
+	_, err := q.exec(ctx, q.createRawCertStmt, createRawCert, arg.ID, arg.Cert, arg.Key)
+	return err
 }
 
-const getUser = `-- name: GetUser :one
-SELECT upn, fullname, azuread_oid, permission_level FROM users WHERE upn = $1 LIMIT 1
+const createRollout = `-- name: CreateRollout :one
+INSERT INTO rollouts(policy_id, group_id, failure_threshold, success_threshold) VALUES ($1, $2, $3, $4) RETURNING id
 `
 
-type GetUserRow struct {
-	Upn             string              `json:"upn"`
-	Fullname        string              `json:"fullname"`
-	AzureadOid      null.String         `json:"azuread_oid"`
-	PermissionLevel UserPermissionLevel `json:"permission_level"`
+type CreateRolloutParams struct {
+	PolicyID         int32 `json:"policy_id"`
+	GroupID          int32 `json:"group_id"`
+	FailureThreshold int16 `json:"failure_threshold"`
+	SuccessThreshold int16 `json:"success_threshold"`
 }
 
 // Exposed via API
-func (q *Queries) GetUser(ctx context.Context, upn string) (GetUserRow, error) {
-	row := q.queryRow(ctx, q.getUserStmt, getUser, upn)
-	var i GetUserRow
-	err := row.Scan(
-		&i.Upn,
-		&i.Fullname,
-		&i.AzureadOid,
-		&i.PermissionLevel,
+func (q *Queries) CreateRollout(ctx context.Context, arg CreateRolloutParams) (int32, error) {
+	row := q.queryRow(ctx, q.createRolloutStmt, createRollout,
+		arg.PolicyID,
+		arg.GroupID,
+		arg.FailureThreshold,
+		arg.SuccessThreshold,
 	)
-	return i, err
+	var id int32
+	err := row.Scan(&id)
+	return id, err
 }
 
-const getUserForLogin = `-- name: GetUserForLogin :one
-SELECT fullname, password, mfa_token, permission_level FROM users WHERE upn = $1 LIMIT 1
+const createRolloutStage = `-- name: CreateRolloutStage :exec
+INSERT INTO rollout_stages(rollout_id, stage, percentage, ring_group_id) VALUES ($1, $2, $3, $4)
 `
 
-type GetUserForLoginRow struct {
-	Fullname        string              `json:"fullname"`
-	Password        null.String         `json:"password"`
-	MfaToken        null.String         `json:"mfa_token"`
-	PermissionLevel UserPermissionLevel `json:"permission_level"`
+type CreateRolloutStageParams struct {
+	RolloutID   int32         `json:"rollout_id"`
+	Stage       int16         `json:"stage"`
+	Percentage  int16         `json:"percentage"`
+	RingGroupID sql.NullInt32 `json:"ring_group_id"`
 }
 
-func (q *Queries) GetUserForLogin(ctx context.Context, upn string) (GetUserForLoginRow, error) {
-	row := q.queryRow(ctx, q.getUserForLoginStmt, getUserForLogin, upn)
-	var i GetUserForLoginRow
-	err := row.Scan(
-		&i.Fullname,
-		&i.Password,
-		&i.MfaToken,
-		&i.PermissionLevel,
+func (q *Queries) CreateRolloutStage(ctx context.Context, arg CreateRolloutStageParams) error {
+	_, err := q.exec(ctx, q.createRolloutStageStmt, createRolloutStage,
+		arg.RolloutID,
+		arg.Stage,
+		arg.Percentage,
+		arg.RingGroupID,
 	)
-	return i, err
+	return err
 }
 
-const getUsers = `-- name: GetUsers :many
+const createScript = `-- name: CreateScript :one
+INSERT INTO scripts(name, description, content, run_context, schedule, group_id) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id
+`
 
-SELECT upn, fullname, permission_level FROM users LIMIT 100
+type CreateScriptParams struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Content     string         `json:"content"`
+	RunContext  ScriptContext  `json:"run_context"`
+	Schedule    ScriptSchedule `json:"schedule"`
+	GroupID     int32          `json:"group_id"`
+}
+
+func (q *Queries) CreateScript(ctx context.Context, arg CreateScriptParams) (int32, error) {
+	row := q.queryRow(ctx, q.createScriptStmt, createScript,
+		arg.Name,
+		arg.Description,
+		arg.Content,
+		arg.RunContext,
+		arg.Schedule,
+		arg.GroupID,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createTermsOfService = `-- name: CreateTermsOfService :one
+INSERT INTO terms_of_service(title, content) VALUES ($1, $2) RETURNING version
+`
+
+type CreateTermsOfServiceParams struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// Exposed via API
+func (q *Queries) CreateTermsOfService(ctx context.Context, arg CreateTermsOfServiceParams) (int32, error) {
+	row := q.queryRow(ctx, q.createTermsOfServiceStmt, createTermsOfService, arg.Title, arg.Content)
+	var version int32
+	err := row.Scan(&version)
+	return version, err
+}
+
+const createUser = `-- name: CreateUser :exec
+INSERT INTO users(upn, fullname, password) VALUES ($1, $2, $3)
+`
+
+type CreateUserParams struct {
+	Upn      string      `json:"upn"`
+	Fullname string      `json:"fullname"`
+	Password null.String `json:"password"`
+}
+
+// Exposed via API
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
+	_, err := q.exec(ctx, q.createUserStmt, createUser, arg.Upn, arg.Fullname, arg.Password)
+	return err
+}
+
+const createUserGroup = `-- name: CreateUserGroup :one
+INSERT INTO user_groups(name, description, priority) VALUES ($1, $2, $3) RETURNING id
+`
+
+type CreateUserGroupParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Priority    int16  `json:"priority"`
+}
+
+// Exposed via API
+func (q *Queries) CreateUserGroup(ctx context.Context, arg CreateUserGroupParams) (int32, error) {
+	row := q.queryRow(ctx, q.createUserGroupStmt, createUserGroup, arg.Name, arg.Description, arg.Priority)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deleteApp = `-- name: DeleteApp :exec
+DELETE FROM apps WHERE id = $1
+`
+
+func (q *Queries) DeleteApp(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.deleteAppStmt, deleteApp, id)
+	return err
+}
+
+const deleteAppAssignment = `-- name: DeleteAppAssignment :exec
+DELETE FROM app_assignments WHERE app_id = $1 AND group_id = $2
+`
+
+type DeleteAppAssignmentParams struct {
+	AppID   int32 `json:"app_id"`
+	GroupID int32 `json:"group_id"`
+}
+
+func (q *Queries) DeleteAppAssignment(ctx context.Context, arg DeleteAppAssignmentParams) error {
+	_, err := q.exec(ctx, q.deleteAppAssignmentStmt, deleteAppAssignment, arg.AppID, arg.GroupID)
+	return err
+}
+
+const deleteBitLockerRotation = `-- name: DeleteBitLockerRotation :exec
+DELETE FROM bitlocker_rotations WHERE device_id = $1
+`
+
+func (q *Queries) DeleteBitLockerRotation(ctx context.Context, deviceID int32) error {
+	_, err := q.exec(ctx, q.deleteBitLockerRotationStmt, deleteBitLockerRotation, deviceID)
+	return err
+}
+
+const deleteCertificateProfile = `-- name: DeleteCertificateProfile :exec
+DELETE FROM certificate_profiles WHERE id = $1
+`
+
+func (q *Queries) DeleteCertificateProfile(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.deleteCertificateProfileStmt, deleteCertificateProfile, id)
+	return err
+}
+
+const deleteDeviceCacheNode = `-- name: DeleteDeviceCacheNode :exec
+DELETE FROM device_cache WHERE device_id = $1 AND payload_id = $2 AND upn IS NOT DISTINCT FROM $3
+`
+
+type DeleteDeviceCacheNodeParams struct {
+	DeviceID  int32         `json:"device_id"`
+	PayloadID sql.NullInt32 `json:"payload_id"`
+	Upn       null.String   `json:"upn"`
+}
+
+func (q *Queries) DeleteDeviceCacheNode(ctx context.Context, arg DeleteDeviceCacheNodeParams) error {
+	_, err := q.exec(ctx, q.deleteDeviceCacheNodeStmt, deleteDeviceCacheNode, arg.DeviceID, arg.PayloadID, arg.Upn)
+	return err
+}
+
+const deleteEnrollmentBranding = `-- name: DeleteEnrollmentBranding :exec
+DELETE FROM enrollment_branding WHERE language = $1
+`
+
+// Exposed via API
+func (q *Queries) DeleteEnrollmentBranding(ctx context.Context, language string) error {
+	_, err := q.exec(ctx, q.deleteEnrollmentBrandingStmt, deleteEnrollmentBranding, language)
+	return err
+}
+
+const deleteGroup = `-- name: DeleteGroup :exec
+DELETE FROM groups WHERE id = $1
+`
+
+// Exposed via API
+func (q *Queries) DeleteGroup(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.deleteGroupStmt, deleteGroup, id)
+	return err
+}
+
+const deleteGroupAppAssignments = `-- name: DeleteGroupAppAssignments :exec
+DELETE FROM app_assignments WHERE group_id = $1
+`
+
+func (q *Queries) DeleteGroupAppAssignments(ctx context.Context, groupID int32) error {
+	_, err := q.exec(ctx, q.deleteGroupAppAssignmentsStmt, deleteGroupAppAssignments, groupID)
+	return err
+}
+
+const deleteGroupCertificateProfiles = `-- name: DeleteGroupCertificateProfiles :exec
+DELETE FROM certificate_profiles WHERE group_id = $1
+`
+
+func (q *Queries) DeleteGroupCertificateProfiles(ctx context.Context, groupID int32) error {
+	_, err := q.exec(ctx, q.deleteGroupCertificateProfilesStmt, deleteGroupCertificateProfiles, groupID)
+	return err
+}
+
+const deleteGroupDevices = `-- name: DeleteGroupDevices :exec
+DELETE FROM group_devices WHERE group_id = $1
+`
+
+func (q *Queries) DeleteGroupDevices(ctx context.Context, groupID int32) error {
+	_, err := q.exec(ctx, q.deleteGroupDevicesStmt, deleteGroupDevices, groupID)
+	return err
+}
+
+const deleteGroupLocalAdminProfiles = `-- name: DeleteGroupLocalAdminProfiles :exec
+DELETE FROM local_admin_profiles WHERE group_id = $1
+`
+
+func (q *Queries) DeleteGroupLocalAdminProfiles(ctx context.Context, groupID int32) error {
+	_, err := q.exec(ctx, q.deleteGroupLocalAdminProfilesStmt, deleteGroupLocalAdminProfiles, groupID)
+	return err
+}
+
+const deleteGroupPolicies = `-- name: DeleteGroupPolicies :exec
+DELETE FROM group_policies WHERE group_id = $1
+`
+
+func (q *Queries) DeleteGroupPolicies(ctx context.Context, groupID sql.NullInt32) error {
+	_, err := q.exec(ctx, q.deleteGroupPoliciesStmt, deleteGroupPolicies, groupID)
+	return err
+}
+
+const deleteGroupPolicyWindows = `-- name: DeleteGroupPolicyWindows :exec
+DELETE FROM group_policy_windows WHERE group_id = $1 AND policy_id = $2
+`
+
+type DeleteGroupPolicyWindowsParams struct {
+	GroupID  int32 `json:"group_id"`
+	PolicyID int32 `json:"policy_id"`
+}
+
+func (q *Queries) DeleteGroupPolicyWindows(ctx context.Context, arg DeleteGroupPolicyWindowsParams) error {
+	_, err := q.exec(ctx, q.deleteGroupPolicyWindowsStmt, deleteGroupPolicyWindows, arg.GroupID, arg.PolicyID)
+	return err
+}
+
+const deleteGroupRollouts = `-- name: DeleteGroupRollouts :exec
+DELETE FROM rollouts WHERE group_id = $1 OR id IN (SELECT rollout_id FROM rollout_stages WHERE ring_group_id = $1)
+`
+
+func (q *Queries) DeleteGroupRollouts(ctx context.Context, groupID int32) error {
+	_, err := q.exec(ctx, q.deleteGroupRolloutsStmt, deleteGroupRollouts, groupID)
+	return err
+}
+
+const deleteGroupScripts = `-- name: DeleteGroupScripts :exec
+DELETE FROM scripts WHERE group_id = $1
+`
+
+func (q *Queries) DeleteGroupScripts(ctx context.Context, groupID int32) error {
+	_, err := q.exec(ctx, q.deleteGroupScriptsStmt, deleteGroupScripts, groupID)
+	return err
+}
+
+const deleteLocalAdminProfile = `-- name: DeleteLocalAdminProfile :exec
+DELETE FROM local_admin_profiles WHERE id = $1
+`
+
+func (q *Queries) DeleteLocalAdminProfile(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.deleteLocalAdminProfileStmt, deleteLocalAdminProfile, id)
+	return err
+}
+
+const deleteOrphanedPayloads = `-- name: DeleteOrphanedPayloads :exec
+DELETE FROM policies_payload WHERE policy_id IS NULL AND NOT EXISTS (SELECT 1 FROM device_cache WHERE device_cache.payload_id = policies_payload.id)
+`
+
+func (q *Queries) DeleteOrphanedPayloads(ctx context.Context) error {
+	_, err := q.exec(ctx, q.deleteOrphanedPayloadsStmt, deleteOrphanedPayloads)
+	return err
+}
+
+const deletePolicy = `-- name: DeletePolicy :exec
+DELETE FROM policies WHERE id = $1
+`
+
+// Exposed via API
+func (q *Queries) DeletePolicy(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.deletePolicyStmt, deletePolicy, id)
+	return err
+}
+
+const deletePolicyGroups = `-- name: DeletePolicyGroups :exec
+DELETE FROM group_policies WHERE policy_id = $1
+`
+
+func (q *Queries) DeletePolicyGroups(ctx context.Context, policyID sql.NullInt32) error {
+	_, err := q.exec(ctx, q.deletePolicyGroupsStmt, deletePolicyGroups, policyID)
+	return err
+}
+
+const deletePolicyRollouts = `-- name: DeletePolicyRollouts :exec
+DELETE FROM rollouts WHERE policy_id = $1
+`
+
+func (q *Queries) DeletePolicyRollouts(ctx context.Context, policyID int32) error {
+	_, err := q.exec(ctx, q.deletePolicyRolloutsStmt, deletePolicyRollouts, policyID)
+	return err
+}
+
+const deletePolicyUserGroups = `-- name: DeletePolicyUserGroups :exec
+DELETE FROM user_group_policies WHERE policy_id = $1
+`
+
+func (q *Queries) DeletePolicyUserGroups(ctx context.Context, policyID int32) error {
+	_, err := q.exec(ctx, q.deletePolicyUserGroupsStmt, deletePolicyUserGroups, policyID)
+	return err
+}
+
+const deleteScript = `-- name: DeleteScript :exec
+DELETE FROM scripts WHERE id = $1
+`
+
+func (q *Queries) DeleteScript(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.deleteScriptStmt, deleteScript, id)
+	return err
+}
+
+const deleteUserGroup = `-- name: DeleteUserGroup :exec
+DELETE FROM user_groups WHERE id = $1
+`
+
+// Exposed via API
+func (q *Queries) DeleteUserGroup(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.deleteUserGroupStmt, deleteUserGroup, id)
+	return err
+}
+
+const deleteUserGroupMembers = `-- name: DeleteUserGroupMembers :exec
+DELETE FROM user_group_members WHERE group_id = $1
+`
+
+func (q *Queries) DeleteUserGroupMembers(ctx context.Context, groupID int32) error {
+	_, err := q.exec(ctx, q.deleteUserGroupMembersStmt, deleteUserGroupMembers, groupID)
+	return err
+}
+
+const deleteUserGroupPolicies = `-- name: DeleteUserGroupPolicies :exec
+DELETE FROM user_group_policies WHERE group_id = $1
+`
+
+func (q *Queries) DeleteUserGroupPolicies(ctx context.Context, groupID int32) error {
+	_, err := q.exec(ctx, q.deleteUserGroupPoliciesStmt, deleteUserGroupPolicies, groupID)
+	return err
+}
+
+const detachGroupPolicy = `-- name: DetachGroupPolicy :exec
+DELETE FROM group_policies WHERE group_id = $1 AND policy_id = $2
+`
+
+type DetachGroupPolicyParams struct {
+	GroupID  sql.NullInt32 `json:"group_id"`
+	PolicyID sql.NullInt32 `json:"policy_id"`
+}
+
+// Exposed via API
+func (q *Queries) DetachGroupPolicy(ctx context.Context, arg DetachGroupPolicyParams) error {
+	_, err := q.exec(ctx, q.detachGroupPolicyStmt, detachGroupPolicy, arg.GroupID, arg.PolicyID)
+	return err
+}
+
+const detachPoliciesPayloads = `-- name: DetachPoliciesPayloads :exec
+UPDATE policies_payload SET policy_id=NULL WHERE policy_id = $1
+`
+
+func (q *Queries) DetachPoliciesPayloads(ctx context.Context, policyID sql.NullInt32) error {
+	_, err := q.exec(ctx, q.detachPoliciesPayloadsStmt, detachPoliciesPayloads, policyID)
+	return err
+}
+
+const detachPolicyPayload = `-- name: DetachPolicyPayload :exec
+UPDATE policies_payload SET policy_id=NULL WHERE id = $1
+`
+
+// Detached payloads are removed from devices on their next checkin and then deleted
+func (q *Queries) DetachPolicyPayload(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.detachPolicyPayloadStmt, detachPolicyPayload, id)
+	return err
+}
+
+const detachUserGroupPolicy = `-- name: DetachUserGroupPolicy :exec
+DELETE FROM user_group_policies WHERE group_id = $1 AND policy_id = $2
+`
+
+type DetachUserGroupPolicyParams struct {
+	GroupID  int32 `json:"group_id"`
+	PolicyID int32 `json:"policy_id"`
+}
+
+// Exposed via API
+func (q *Queries) DetachUserGroupPolicy(ctx context.Context, arg DetachUserGroupPolicyParams) error {
+	_, err := q.exec(ctx, q.detachUserGroupPolicyStmt, detachUserGroupPolicy, arg.GroupID, arg.PolicyID)
+	return err
+}
+
+const deviceCheckinStatus = `-- name: DeviceCheckinStatus :exec
+UPDATE devices SET lastseen=NOW(), lastseen_status=$2 WHERE id = $1
+`
+
+type DeviceCheckinStatusParams struct {
+	ID             int32 `json:"id"`
+	LastseenStatus int32 `json:"lastseen_status"`
+}
+
+func (q *Queries) DeviceCheckinStatus(ctx context.Context, arg DeviceCheckinStatusParams) error {
+	_, err := q.exec(ctx, q.deviceCheckinStatusStmt, deviceCheckinStatus, arg.ID, arg.LastseenStatus)
+	return err
+}
+
+const deviceCommandSent = `-- name: DeviceCommandSent :exec
+UPDATE device_commands SET sent_at=NOW() WHERE id = $1
+`
+
+func (q *Queries) DeviceCommandSent(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.deviceCommandSentStmt, deviceCommandSent, id)
+	return err
+}
+
+const deviceNameInUse = `-- name: DeviceNameInUse :one
+SELECT EXISTS(SELECT 1 FROM devices WHERE name = $1 AND udid != $2)
+`
+
+type DeviceNameInUseParams struct {
+	Name string `json:"name"`
+	Udid string `json:"udid"`
+}
+
+func (q *Queries) DeviceNameInUse(ctx context.Context, arg DeviceNameInUseParams) (bool, error) {
+	row := q.queryRow(ctx, q.deviceNameInUseStmt, deviceNameInUse, arg.Name, arg.Udid)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const deviceUserUnenrollment = `-- name: DeviceUserUnenrollment :exec
+UPDATE devices SET state='user_unenrolled', enrollment_type='Unenrolled', azure_did='', nodecache_version='', lastseen=to_timestamp(CAST(0 as bigint)/1000), lastseen_status=0, enrolled_at=to_timestamp(CAST(0 as bigint)/1000), enrolled_by=NULL WHERE id = $1
+`
+
+func (q *Queries) DeviceUserUnenrollment(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.deviceUserUnenrollmentStmt, deviceUserUnenrollment, id)
+	return err
+}
+
+const getAllDevices = `-- name: GetAllDevices :many
+SELECT id, udid, state, enrollment_type, name, description, model, hw_dev_id, operating_system, azure_did, nodecache_version, lastseen, lastseen_status, enrolled_at, enrolled_by FROM devices
+`
+
+func (q *Queries) GetAllDevices(ctx context.Context) ([]Device, error) {
+	rows, err := q.query(ctx, q.getAllDevicesStmt, getAllDevices)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Device
+	for rows.Next() {
+		var i Device
+		if err := rows.Scan(
+			&i.ID,
+			&i.Udid,
+			&i.State,
+			&i.EnrollmentType,
+			&i.Name,
+			&i.Description,
+			&i.Model,
+			&i.HwDevID,
+			&i.OperatingSystem,
+			&i.AzureDid,
+			&i.NodecacheVersion,
+			&i.Lastseen,
+			&i.LastseenStatus,
+			&i.EnrolledAt,
+			&i.EnrolledBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllGroups = `-- name: GetAllGroups :many
+SELECT id, name, description, priority, rules, timezone FROM groups ORDER BY name
+`
+
+// Used for declarative configuration
+func (q *Queries) GetAllGroups(ctx context.Context) ([]Group, error) {
+	rows, err := q.query(ctx, q.getAllGroupsStmt, getAllGroups)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Group
+	for rows.Next() {
+		var i Group
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Priority,
+			&i.Rules,
+			&i.Timezone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllPolicies = `-- name: GetAllPolicies :many
+SELECT id, name, description, priority FROM policies ORDER BY name
+`
+
+type GetAllPoliciesRow struct {
+	ID          int32  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Priority    int16  `json:"priority"`
+}
+
+// Used for declarative configuration
+func (q *Queries) GetAllPolicies(ctx context.Context) ([]GetAllPoliciesRow, error) {
+	rows, err := q.query(ctx, q.getAllPoliciesStmt, getAllPolicies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAllPoliciesRow
+	for rows.Next() {
+		var i GetAllPoliciesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Priority,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllUserGroups = `-- name: GetAllUserGroups :many
+SELECT id, name, description, priority FROM user_groups ORDER BY name
+`
+
+// Used for declarative configuration
+func (q *Queries) GetAllUserGroups(ctx context.Context) ([]UserGroup, error) {
+	rows, err := q.query(ctx, q.getAllUserGroupsStmt, getAllUserGroups)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserGroup
+	for rows.Next() {
+		var i UserGroup
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Priority,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getApp = `-- name: GetApp :one
+SELECT id, type, name, publisher, identifier, version, file_hash, file_size, file_name, command_line, store_id, store_sku, user_context, created_at FROM apps WHERE id = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetApp(ctx context.Context, id int32) (App, error) {
+	row := q.queryRow(ctx, q.getAppStmt, getApp, id)
+	var i App
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Name,
+		&i.Publisher,
+		&i.Identifier,
+		&i.Version,
+		&i.FileHash,
+		&i.FileSize,
+		&i.FileName,
+		&i.CommandLine,
+		&i.StoreID,
+		&i.StoreSku,
+		&i.UserContext,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAppAssignments = `-- name: GetAppAssignments :many
+SELECT app_assignments.group_id, groups.name, app_assignments.intent FROM app_assignments INNER JOIN groups ON groups.id = app_assignments.group_id WHERE app_assignments.app_id = $1 ORDER BY groups.name
+`
+
+type GetAppAssignmentsRow struct {
+	GroupID int32     `json:"group_id"`
+	Name    string    `json:"name"`
+	Intent  AppIntent `json:"intent"`
+}
+
+// Exposed via API
+func (q *Queries) GetAppAssignments(ctx context.Context, appID int32) ([]GetAppAssignmentsRow, error) {
+	rows, err := q.query(ctx, q.getAppAssignmentsStmt, getAppAssignments, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAppAssignmentsRow
+	for rows.Next() {
+		var i GetAppAssignmentsRow
+		if err := rows.Scan(&i.GroupID, &i.Name, &i.Intent); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAppByHash = `-- name: GetAppByHash :one
+SELECT id, type, name, publisher, identifier, version, file_hash, file_size, file_name, command_line, store_id, store_sku, user_context, created_at FROM apps WHERE file_hash = $1 AND file_hash != '' LIMIT 1
+`
+
+func (q *Queries) GetAppByHash(ctx context.Context, fileHash string) (App, error) {
+	row := q.queryRow(ctx, q.getAppByHashStmt, getAppByHash, fileHash)
+	var i App
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Name,
+		&i.Publisher,
+		&i.Identifier,
+		&i.Version,
+		&i.FileHash,
+		&i.FileSize,
+		&i.FileName,
+		&i.CommandLine,
+		&i.StoreID,
+		&i.StoreSku,
+		&i.UserContext,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAppByIdentifier = `-- name: GetAppByIdentifier :one
+SELECT id, type, name, publisher, identifier, version, file_hash, file_size, file_name, command_line, store_id, store_sku, user_context, created_at FROM apps WHERE type = $1 AND identifier = $2 LIMIT 1
+`
+
+type GetAppByIdentifierParams struct {
+	Type       AppType `json:"type"`
+	Identifier string  `json:"identifier"`
+}
+
+func (q *Queries) GetAppByIdentifier(ctx context.Context, arg GetAppByIdentifierParams) (App, error) {
+	row := q.queryRow(ctx, q.getAppByIdentifierStmt, getAppByIdentifier, arg.Type, arg.Identifier)
+	var i App
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Name,
+		&i.Publisher,
+		&i.Identifier,
+		&i.Version,
+		&i.FileHash,
+		&i.FileSize,
+		&i.FileName,
+		&i.CommandLine,
+		&i.StoreID,
+		&i.StoreSku,
+		&i.UserContext,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAppInstalls = `-- name: GetAppInstalls :many
+SELECT app_installs.device_id, devices.name, app_installs.state, app_installs.status, app_installs.updated_at FROM app_installs INNER JOIN devices ON devices.id = app_installs.device_id WHERE app_installs.app_id = $1 ORDER BY app_installs.updated_at DESC
+`
+
+type GetAppInstallsRow struct {
+	DeviceID  int32           `json:"device_id"`
+	Name      string          `json:"name"`
+	State     AppInstallState `json:"state"`
+	Status    string          `json:"status"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Exposed via API
+func (q *Queries) GetAppInstalls(ctx context.Context, appID int32) ([]GetAppInstallsRow, error) {
+	rows, err := q.query(ctx, q.getAppInstallsStmt, getAppInstalls, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAppInstallsRow
+	for rows.Next() {
+		var i GetAppInstallsRow
+		if err := rows.Scan(
+			&i.DeviceID,
+			&i.Name,
+			&i.State,
+			&i.Status,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getApps = `-- name: GetApps :many
+SELECT id, type, name, publisher, identifier, version, file_hash, file_size, file_name, command_line, store_id, store_sku, user_context, created_at FROM apps ORDER BY name
+`
+
+// Exposed via API
+func (q *Queries) GetApps(ctx context.Context) ([]App, error) {
+	rows, err := q.query(ctx, q.getAppsStmt, getApps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []App
+	for rows.Next() {
+		var i App
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Name,
+			&i.Publisher,
+			&i.Identifier,
+			&i.Version,
+			&i.FileHash,
+			&i.FileSize,
+			&i.FileName,
+			&i.CommandLine,
+			&i.StoreID,
+			&i.StoreSku,
+			&i.UserContext,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBasicDevice = `-- name: GetBasicDevice :one
+SELECT id, name, description, model FROM devices WHERE id = $1 LIMIT 1
+`
+
+type GetBasicDeviceRow struct {
+	ID          int32       `json:"id"`
+	Name        string      `json:"name"`
+	Description null.String `json:"description"`
+	Model       string      `json:"model"`
+}
+
+// Exposed via API
+func (q *Queries) GetBasicDevice(ctx context.Context, id int32) (GetBasicDeviceRow, error) {
+	row := q.queryRow(ctx, q.getBasicDeviceStmt, getBasicDevice, id)
+	var i GetBasicDeviceRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Model,
+	)
+	return i, err
+}
+
+const getBasicDeviceScopedGroups = `-- name: GetBasicDeviceScopedGroups :many
+SELECT groups.id, groups.name FROM groups INNER JOIN group_devices ON group_devices.group_id=groups.id WHERE group_devices.device_id = $1
+`
+
+type GetBasicDeviceScopedGroupsRow struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Exposed via API
+func (q *Queries) GetBasicDeviceScopedGroups(ctx context.Context, deviceID int32) ([]GetBasicDeviceScopedGroupsRow, error) {
+	rows, err := q.query(ctx, q.getBasicDeviceScopedGroupsStmt, getBasicDeviceScopedGroups, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBasicDeviceScopedGroupsRow
+	for rows.Next() {
+		var i GetBasicDeviceScopedGroupsRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBasicDeviceScopedPolicies = `-- name: GetBasicDeviceScopedPolicies :many
+SELECT id, name, description, priority, version, group_policies.group_id, policy_id, not_before, group_devices.group_id, device_id FROM policies INNER JOIN group_policies ON group_policies.policy_id = policies.id INNER JOIN group_devices ON group_devices.group_id=group_policies.group_id WHERE group_devices.device_id = $1
+`
+
+type GetBasicDeviceScopedPoliciesRow struct {
+	ID          int32         `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Priority    int16         `json:"priority"`
+	Version     int32         `json:"version"`
+	GroupID     sql.NullInt32 `json:"group_id"`
+	PolicyID    sql.NullInt32 `json:"policy_id"`
+	NotBefore   sql.NullTime  `json:"not_before"`
+	GroupID_2   int32         `json:"group_id_2"`
+	DeviceID    int32         `json:"device_id"`
+}
+
+// Exposed via API
+func (q *Queries) GetBasicDeviceScopedPolicies(ctx context.Context, deviceID int32) ([]GetBasicDeviceScopedPoliciesRow, error) {
+	rows, err := q.query(ctx, q.getBasicDeviceScopedPoliciesStmt, getBasicDeviceScopedPolicies, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBasicDeviceScopedPoliciesRow
+	for rows.Next() {
+		var i GetBasicDeviceScopedPoliciesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Priority,
+			&i.Version,
+			&i.GroupID,
+			&i.PolicyID,
+			&i.NotBefore,
+			&i.GroupID_2,
+			&i.DeviceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBitLockerRecoveryKeyAccess = `-- name: GetBitLockerRecoveryKeyAccess :many
+SELECT id, device_id, protector_id, upn, reason, accessed_at FROM bitlocker_recovery_key_access WHERE device_id = $1 ORDER BY accessed_at DESC LIMIT 100
+`
+
+// Exposed via API
+func (q *Queries) GetBitLockerRecoveryKeyAccess(ctx context.Context, deviceID int32) ([]BitlockerRecoveryKeyAccess, error) {
+	rows, err := q.query(ctx, q.getBitLockerRecoveryKeyAccessStmt, getBitLockerRecoveryKeyAccess, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BitlockerRecoveryKeyAccess
+	for rows.Next() {
+		var i BitlockerRecoveryKeyAccess
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeviceID,
+			&i.ProtectorID,
+			&i.Upn,
+			&i.Reason,
+			&i.AccessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBitLockerRecoveryKeys = `-- name: GetBitLockerRecoveryKeys :many
+SELECT id, device_id, protector_id, volume, encrypted_password, escrowed_at, removed_at FROM bitlocker_recovery_keys WHERE device_id = $1 AND protector_id = $2 ORDER BY escrowed_at DESC
+`
+
+type GetBitLockerRecoveryKeysParams struct {
+	DeviceID    int32  `json:"device_id"`
+	ProtectorID string `json:"protector_id"`
+}
+
+func (q *Queries) GetBitLockerRecoveryKeys(ctx context.Context, arg GetBitLockerRecoveryKeysParams) ([]BitlockerRecoveryKey, error) {
+	rows, err := q.query(ctx, q.getBitLockerRecoveryKeysStmt, getBitLockerRecoveryKeys, arg.DeviceID, arg.ProtectorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BitlockerRecoveryKey
+	for rows.Next() {
+		var i BitlockerRecoveryKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeviceID,
+			&i.ProtectorID,
+			&i.Volume,
+			&i.EncryptedPassword,
+			&i.EscrowedAt,
+			&i.RemovedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBitLockerRotation = `-- name: GetBitLockerRotation :one
+SELECT device_id, requested_by, requested_at FROM bitlocker_rotations WHERE device_id = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetBitLockerRotation(ctx context.Context, deviceID int32) (BitlockerRotation, error) {
+	row := q.queryRow(ctx, q.getBitLockerRotationStmt, getBitLockerRotation, deviceID)
+	var i BitlockerRotation
+	err := row.Scan(&i.DeviceID, &i.RequestedBy, &i.RequestedAt)
+	return i, err
+}
+
+const getCertificateChallenge = `-- name: GetCertificateChallenge :one
+SELECT device_id, profile_id, upn, challenge_hash, subject, created_at FROM certificate_challenges WHERE device_id = $1 AND profile_id = $2 AND upn = $3 LIMIT 1
+`
+
+type GetCertificateChallengeParams struct {
+	DeviceID  int32  `json:"device_id"`
+	ProfileID int32  `json:"profile_id"`
+	Upn       string `json:"upn"`
+}
+
+func (q *Queries) GetCertificateChallenge(ctx context.Context, arg GetCertificateChallengeParams) (CertificateChallenge, error) {
+	row := q.queryRow(ctx, q.getCertificateChallengeStmt, getCertificateChallenge, arg.DeviceID, arg.ProfileID, arg.Upn)
+	var i CertificateChallenge
+	err := row.Scan(
+		&i.DeviceID,
+		&i.ProfileID,
+		&i.Upn,
+		&i.ChallengeHash,
+		&i.Subject,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCertificateProfile = `-- name: GetCertificateProfile :one
+SELECT id, name, description, subject_template, key_usages, extended_key_usages, key_length, validity_days, renewal_threshold, user_context, group_id, created_at, updated_at FROM certificate_profiles WHERE id = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetCertificateProfile(ctx context.Context, id int32) (CertificateProfile, error) {
+	row := q.queryRow(ctx, q.getCertificateProfileStmt, getCertificateProfile, id)
+	var i CertificateProfile
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.SubjectTemplate,
+		pq.Array(&i.KeyUsages),
+		pq.Array(&i.ExtendedKeyUsages),
+		&i.KeyLength,
+		&i.ValidityDays,
+		&i.RenewalThreshold,
+		&i.UserContext,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCertificateProfiles = `-- name: GetCertificateProfiles :many
+SELECT id, name, description, subject_template, key_usages, extended_key_usages, key_length, validity_days, renewal_threshold, user_context, group_id, created_at, updated_at FROM certificate_profiles ORDER BY name
+`
+
+// Exposed via API
+func (q *Queries) GetCertificateProfiles(ctx context.Context) ([]CertificateProfile, error) {
+	rows, err := q.query(ctx, q.getCertificateProfilesStmt, getCertificateProfiles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CertificateProfile
+	for rows.Next() {
+		var i CertificateProfile
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.SubjectTemplate,
+			pq.Array(&i.KeyUsages),
+			pq.Array(&i.ExtendedKeyUsages),
+			&i.KeyLength,
+			&i.ValidityDays,
+			&i.RenewalThreshold,
+			&i.UserContext,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeployedPayloads = `-- name: GetDeployedPayloads :many
+SELECT policies_payload.id, policies_payload.uri, policies_payload.exec, device_cache.policy_version FROM device_cache INNER JOIN policies_payload ON policies_payload.id=device_cache.payload_id WHERE device_cache.device_id = $1 AND device_cache.upn IS NOT DISTINCT FROM $2
+`
+
+type GetDeployedPayloadsParams struct {
+	DeviceID int32       `json:"device_id"`
+	Upn      null.String `json:"upn"`
+}
+
+type GetDeployedPayloadsRow struct {
+	ID            int32  `json:"id"`
+	Uri           string `json:"uri"`
+	Exec          bool   `json:"exec"`
+	PolicyVersion int32  `json:"policy_version"`
+}
+
+func (q *Queries) GetDeployedPayloads(ctx context.Context, arg GetDeployedPayloadsParams) ([]GetDeployedPayloadsRow, error) {
+	rows, err := q.query(ctx, q.getDeployedPayloadsStmt, getDeployedPayloads, arg.DeviceID, arg.Upn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDeployedPayloadsRow
+	for rows.Next() {
+		var i GetDeployedPayloadsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uri,
+			&i.Exec,
+			&i.PolicyVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDevice = `-- name: GetDevice :one
+SELECT id, udid, state, enrollment_type, name, description, model, hw_dev_id, operating_system, azure_did, nodecache_version, lastseen, lastseen_status, enrolled_at, enrolled_by FROM devices WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetDevice(ctx context.Context, id int32) (Device, error) {
+	row := q.queryRow(ctx, q.getDeviceStmt, getDevice, id)
+	var i Device
+	err := row.Scan(
+		&i.ID,
+		&i.Udid,
+		&i.State,
+		&i.EnrollmentType,
+		&i.Name,
+		&i.Description,
+		&i.Model,
+		&i.HwDevID,
+		&i.OperatingSystem,
+		&i.AzureDid,
+		&i.NodecacheVersion,
+		&i.Lastseen,
+		&i.LastseenStatus,
+		&i.EnrolledAt,
+		&i.EnrolledBy,
+	)
+	return i, err
+}
+
+const getDeviceAppAssignments = `-- name: GetDeviceAppAssignments :many
+SELECT DISTINCT apps.id, apps.type, apps.name, apps.identifier, apps.version, apps.file_hash, apps.file_name, apps.command_line, apps.store_id, apps.store_sku, apps.user_context, app_assignments.intent, COALESCE(app_installs.state::text, '')::text AS install_state, COALESCE(app_installs.updated_at, NOW())::timestamptz AS install_updated_at, EXISTS(SELECT 1 FROM device_apps WHERE device_apps.device_id = group_devices.device_id AND device_apps.package_family_name = apps.identifier)::boolean AS in_inventory FROM app_assignments INNER JOIN group_devices ON group_devices.group_id = app_assignments.group_id INNER JOIN apps ON apps.id = app_assignments.app_id LEFT JOIN app_installs ON app_installs.app_id = apps.id AND app_installs.device_id = group_devices.device_id WHERE group_devices.device_id = $1 ORDER BY apps.id
+`
+
+type GetDeviceAppAssignmentsRow struct {
+	ID               int32     `json:"id"`
+	Type             AppType   `json:"type"`
+	Name             string    `json:"name"`
+	Identifier       string    `json:"identifier"`
+	Version          string    `json:"version"`
+	FileHash         string    `json:"file_hash"`
+	FileName         string    `json:"file_name"`
+	CommandLine      string    `json:"command_line"`
+	StoreID          string    `json:"store_id"`
+	StoreSku         string    `json:"store_sku"`
+	UserContext      bool      `json:"user_context"`
+	Intent           AppIntent `json:"intent"`
+	InstallState     string    `json:"install_state"`
+	InstallUpdatedAt time.Time `json:"install_updated_at"`
+	InInventory      bool      `json:"in_inventory"`
+}
+
+// The apps assigned to the groups the device is in with their install state. An app assigned to multiple groups is returned once for each intent.
+func (q *Queries) GetDeviceAppAssignments(ctx context.Context, deviceID int32) ([]GetDeviceAppAssignmentsRow, error) {
+	rows, err := q.query(ctx, q.getDeviceAppAssignmentsStmt, getDeviceAppAssignments, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDeviceAppAssignmentsRow
+	for rows.Next() {
+		var i GetDeviceAppAssignmentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Name,
+			&i.Identifier,
+			&i.Version,
+			&i.FileHash,
+			&i.FileName,
+			&i.CommandLine,
+			&i.StoreID,
+			&i.StoreSku,
+			&i.UserContext,
+			&i.Intent,
+			&i.InstallState,
+			&i.InstallUpdatedAt,
+			&i.InInventory,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeviceAppInstalls = `-- name: GetDeviceAppInstalls :many
+SELECT app_installs.app_id, apps.name, apps.version, app_installs.state, app_installs.status, app_installs.updated_at FROM app_installs INNER JOIN apps ON apps.id = app_installs.app_id WHERE app_installs.device_id = $1 ORDER BY apps.name
+`
+
+type GetDeviceAppInstallsRow struct {
+	AppID     int32           `json:"app_id"`
+	Name      string          `json:"name"`
+	Version   string          `json:"version"`
+	State     AppInstallState `json:"state"`
+	Status    string          `json:"status"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Exposed via API
+func (q *Queries) GetDeviceAppInstalls(ctx context.Context, deviceID int32) ([]GetDeviceAppInstallsRow, error) {
+	rows, err := q.query(ctx, q.getDeviceAppInstallsStmt, getDeviceAppInstalls, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDeviceAppInstallsRow
+	for rows.Next() {
+		var i GetDeviceAppInstallsRow
+		if err := rows.Scan(
+			&i.AppID,
+			&i.Name,
+			&i.Version,
+			&i.State,
+			&i.Status,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeviceBitLockerRecoveryKeys = `-- name: GetDeviceBitLockerRecoveryKeys :many
+SELECT id, device_id, protector_id, volume, escrowed_at, removed_at FROM bitlocker_recovery_keys WHERE device_id = $1 ORDER BY removed_at DESC NULLS FIRST, escrowed_at DESC
+`
+
+type GetDeviceBitLockerRecoveryKeysRow struct {
+	ID          int32        `json:"id"`
+	DeviceID    int32        `json:"device_id"`
+	ProtectorID string       `json:"protector_id"`
+	Volume      string       `json:"volume"`
+	EscrowedAt  time.Time    `json:"escrowed_at"`
+	RemovedAt   sql.NullTime `json:"removed_at"`
+}
+
+// Exposed via API. The recovery passwords are not returned.
+func (q *Queries) GetDeviceBitLockerRecoveryKeys(ctx context.Context, deviceID int32) ([]GetDeviceBitLockerRecoveryKeysRow, error) {
+	rows, err := q.query(ctx, q.getDeviceBitLockerRecoveryKeysStmt, getDeviceBitLockerRecoveryKeys, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDeviceBitLockerRecoveryKeysRow
+	for rows.Next() {
+		var i GetDeviceBitLockerRecoveryKeysRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeviceID,
+			&i.ProtectorID,
+			&i.Volume,
+			&i.EscrowedAt,
+			&i.RemovedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeviceBitLockerRecoveryPasswords = `-- name: GetDeviceBitLockerRecoveryPasswords :many
+SELECT id, device_id, protector_id, volume, encrypted_password, escrowed_at, removed_at FROM bitlocker_recovery_keys WHERE device_id = $1
+`
+
+func (q *Queries) GetDeviceBitLockerRecoveryPasswords(ctx context.Context, deviceID int32) ([]BitlockerRecoveryKey, error) {
+	rows, err := q.query(ctx, q.getDeviceBitLockerRecoveryPasswordsStmt, getDeviceBitLockerRecoveryPasswords, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BitlockerRecoveryKey
+	for rows.Next() {
+		var i BitlockerRecoveryKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeviceID,
+			&i.ProtectorID,
+			&i.Volume,
+			&i.EncryptedPassword,
+			&i.EscrowedAt,
+			&i.RemovedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeviceByUDID = `-- name: GetDeviceByUDID :one
+SELECT id, udid, state, enrollment_type, name, description, model, hw_dev_id, operating_system, azure_did, nodecache_version, lastseen, lastseen_status, enrolled_at, enrolled_by FROM devices WHERE udid = $1 LIMIT 1
+`
+
+func (q *Queries) GetDeviceByUDID(ctx context.Context, udid string) (Device, error) {
+	row := q.queryRow(ctx, q.getDeviceByUDIDStmt, getDeviceByUDID, udid)
+	var i Device
+	err := row.Scan(
+		&i.ID,
+		&i.Udid,
+		&i.State,
+		&i.EnrollmentType,
+		&i.Name,
+		&i.Description,
+		&i.Model,
+		&i.HwDevID,
+		&i.OperatingSystem,
+		&i.AzureDid,
+		&i.NodecacheVersion,
+		&i.Lastseen,
+		&i.LastseenStatus,
+		&i.EnrolledAt,
+		&i.EnrolledBy,
+	)
+	return i, err
+}
+
+const getDeviceByUDIDForUpdate = `-- name: GetDeviceByUDIDForUpdate :one
+SELECT id, udid, state, enrollment_type, name, description, model, hw_dev_id, operating_system, azure_did, nodecache_version, lastseen, lastseen_status, enrolled_at, enrolled_by FROM devices WHERE udid = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetDeviceByUDIDForUpdate(ctx context.Context, udid string) (Device, error) {
+	row := q.queryRow(ctx, q.getDeviceByUDIDForUpdateStmt, getDeviceByUDIDForUpdate, udid)
+	var i Device
+	err := row.Scan(
+		&i.ID,
+		&i.Udid,
+		&i.State,
+		&i.EnrollmentType,
+		&i.Name,
+		&i.Description,
+		&i.Model,
+		&i.HwDevID,
+		&i.OperatingSystem,
+		&i.AzureDid,
+		&i.NodecacheVersion,
+		&i.Lastseen,
+		&i.LastseenStatus,
+		&i.EnrolledAt,
+		&i.EnrolledBy,
+	)
+	return i, err
+}
+
+const getDeviceCertificateProfiles = `-- name: GetDeviceCertificateProfiles :many
+SELECT DISTINCT certificate_profiles.id, certificate_profiles.name, certificate_profiles.description, certificate_profiles.subject_template, certificate_profiles.key_usages, certificate_profiles.extended_key_usages, certificate_profiles.key_length, certificate_profiles.validity_days, certificate_profiles.renewal_threshold, certificate_profiles.user_context, certificate_profiles.group_id, certificate_profiles.created_at, certificate_profiles.updated_at FROM certificate_profiles INNER JOIN group_devices ON group_devices.group_id = certificate_profiles.group_id WHERE group_devices.device_id = $1 ORDER BY certificate_profiles.id
+`
+
+// The certificate profiles assigned to the groups the device is in
+func (q *Queries) GetDeviceCertificateProfiles(ctx context.Context, deviceID int32) ([]CertificateProfile, error) {
+	rows, err := q.query(ctx, q.getDeviceCertificateProfilesStmt, getDeviceCertificateProfiles, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CertificateProfile
+	for rows.Next() {
+		var i CertificateProfile
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.SubjectTemplate,
+			pq.Array(&i.KeyUsages),
+			pq.Array(&i.ExtendedKeyUsages),
+			&i.KeyLength,
+			&i.ValidityDays,
+			&i.RenewalThreshold,
+			&i.UserContext,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeviceInventory = `-- name: GetDeviceInventory :many
+SELECT uri, format, value FROM device_inventory WHERE device_id = $1
+`
+
+type GetDeviceInventoryRow struct {
+	Uri    string `json:"uri"`
+	Format string `json:"format"`
+	Value  string `json:"value"`
+}
+
+func (q *Queries) GetDeviceInventory(ctx context.Context, deviceID int32) ([]GetDeviceInventoryRow, error) {
+	rows, err := q.query(ctx, q.getDeviceInventoryStmt, getDeviceInventory, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDeviceInventoryRow
+	for rows.Next() {
+		var i GetDeviceInventoryRow
+		if err := rows.Scan(&i.Uri, &i.Format, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeviceInventoryApps = `-- name: GetDeviceInventoryApps :many
+SELECT package_family_name, source, upn, updated_at FROM device_apps WHERE device_id = $1 ORDER BY package_family_name, upn
+`
+
+type GetDeviceInventoryAppsRow struct {
+	PackageFamilyName string    `json:"package_family_name"`
+	Source            string    `json:"source"`
+	Upn               string    `json:"upn"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Exposed via API
+func (q *Queries) GetDeviceInventoryApps(ctx context.Context, deviceID int32) ([]GetDeviceInventoryAppsRow, error) {
+	rows, err := q.query(ctx, q.getDeviceInventoryAppsStmt, getDeviceInventoryApps, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDeviceInventoryAppsRow
+	for rows.Next() {
+		var i GetDeviceInventoryAppsRow
+		if err := rows.Scan(
+			&i.PackageFamilyName,
+			&i.Source,
+			&i.Upn,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeviceIssuedCertificates = `-- name: GetDeviceIssuedCertificates :many
+SELECT serial, device_id, profile_id, upn, subject, thumbprint, not_before, not_after, issued_at, revoked_at FROM issued_certificates WHERE device_id = $1 ORDER BY issued_at DESC
+`
+
+// Exposed via API
+func (q *Queries) GetDeviceIssuedCertificates(ctx context.Context, deviceID int32) ([]IssuedCertificate, error) {
+	rows, err := q.query(ctx, q.getDeviceIssuedCertificatesStmt, getDeviceIssuedCertificates, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []IssuedCertificate
+	for rows.Next() {
+		var i IssuedCertificate
+		if err := rows.Scan(
+			&i.Serial,
+			&i.DeviceID,
+			&i.ProfileID,
+			&i.Upn,
+			&i.Subject,
+			&i.Thumbprint,
+			&i.NotBefore,
+			&i.NotAfter,
+			&i.IssuedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeviceLocalAdminPasswords = `-- name: GetDeviceLocalAdminPasswords :many
+SELECT local_admin_passwords.profile_id, local_admin_profiles.name AS profile_name, local_admin_profiles.account_name, local_admin_passwords.set_at, local_admin_passwords.pending_since, local_admin_passwords.rotate_at, local_admin_passwords.last_error FROM local_admin_passwords INNER JOIN local_admin_profiles ON local_admin_profiles.id = local_admin_passwords.profile_id WHERE local_admin_passwords.device_id = $1 ORDER BY local_admin_passwords.profile_id
+`
+
+type GetDeviceLocalAdminPasswordsRow struct {
+	ProfileID    int32        `json:"profile_id"`
+	ProfileName  string       `json:"profile_name"`
+	AccountName  string       `json:"account_name"`
+	SetAt        sql.NullTime `json:"set_at"`
+	PendingSince sql.NullTime `json:"pending_since"`
+	RotateAt     sql.NullTime `json:"rotate_at"`
+	LastError    string       `json:"last_error"`
+}
+
+// Exposed via API. The passwords are not returned.
+func (q *Queries) GetDeviceLocalAdminPasswords(ctx context.Context, deviceID int32) ([]GetDeviceLocalAdminPasswordsRow, error) {
+	rows, err := q.query(ctx, q.getDeviceLocalAdminPasswordsStmt, getDeviceLocalAdminPasswords, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDeviceLocalAdminPasswordsRow
+	for rows.Next() {
+		var i GetDeviceLocalAdminPasswordsRow
+		if err := rows.Scan(
+			&i.ProfileID,
+			&i.ProfileName,
+			&i.AccountName,
+			&i.SetAt,
+			&i.PendingSince,
+			&i.RotateAt,
+			&i.LastError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeviceLocalAdminProfiles = `-- name: GetDeviceLocalAdminProfiles :many
+SELECT DISTINCT local_admin_profiles.id, local_admin_profiles.name, local_admin_profiles.description, local_admin_profiles.account_name, local_admin_profiles.password_length, local_admin_profiles.rotation_days, local_admin_profiles.rotate_after_view_hours, local_admin_profiles.group_id, local_admin_profiles.created_at, local_admin_profiles.updated_at FROM local_admin_profiles INNER JOIN group_devices ON group_devices.group_id = local_admin_profiles.group_id WHERE group_devices.device_id = $1 ORDER BY local_admin_profiles.id
+`
+
+// The local admin profiles assigned to the groups the device is in
+func (q *Queries) GetDeviceLocalAdminProfiles(ctx context.Context, deviceID int32) ([]LocalAdminProfile, error) {
+	rows, err := q.query(ctx, q.getDeviceLocalAdminProfilesStmt, getDeviceLocalAdminProfiles, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LocalAdminProfile
+	for rows.Next() {
+		var i LocalAdminProfile
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.AccountName,
+			&i.PasswordLength,
+			&i.RotationDays,
+			&i.RotateAfterViewHours,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeviceScriptRuns = `-- name: GetDeviceScriptRuns :many
+SELECT script_runs.script_id, scripts.name, script_runs.version, script_runs.exit_code, script_runs.stdout, script_runs.stderr, script_runs.finished_at FROM script_runs INNER JOIN scripts ON scripts.id = script_runs.script_id WHERE script_runs.device_id = $1 ORDER BY scripts.name
+`
+
+type GetDeviceScriptRunsRow struct {
+	ScriptID   int32     `json:"script_id"`
+	Name       string    `json:"name"`
+	Version    int32     `json:"version"`
+	ExitCode   int32     `json:"exit_code"`
+	Stdout     string    `json:"stdout"`
+	Stderr     string    `json:"stderr"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Exposed via API
+func (q *Queries) GetDeviceScriptRuns(ctx context.Context, deviceID int32) ([]GetDeviceScriptRunsRow, error) {
+	rows, err := q.query(ctx, q.getDeviceScriptRunsStmt, getDeviceScriptRuns, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDeviceScriptRunsRow
+	for rows.Next() {
+		var i GetDeviceScriptRunsRow
+		if err := rows.Scan(
+			&i.ScriptID,
+			&i.Name,
+			&i.Version,
+			&i.ExitCode,
+			&i.Stdout,
+			&i.Stderr,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeviceScripts = `-- name: GetDeviceScripts :many
+SELECT DISTINCT scripts.id, scripts.name, scripts.content, scripts.run_context, scripts.schedule, scripts.version, COALESCE(script_runs.version, 0)::integer AS run_version, COALESCE(script_runs.finished_at, to_timestamp(0))::timestamptz AS run_finished_at FROM scripts INNER JOIN group_devices ON group_devices.group_id = scripts.group_id LEFT JOIN script_runs ON script_runs.script_id = scripts.id AND script_runs.device_id = group_devices.device_id WHERE group_devices.device_id = $1 ORDER BY scripts.id
+`
+
+type GetDeviceScriptsRow struct {
+	ID            int32          `json:"id"`
+	Name          string         `json:"name"`
+	Content       string         `json:"content"`
+	RunContext    ScriptContext  `json:"run_context"`
+	Schedule      ScriptSchedule `json:"schedule"`
+	Version       int32          `json:"version"`
+	RunVersion    int32          `json:"run_version"`
+	RunFinishedAt time.Time      `json:"run_finished_at"`
+}
+
+// The scripts assigned to the groups the device is in with the version and time of their last run on the device
+func (q *Queries) GetDeviceScripts(ctx context.Context, deviceID int32) ([]GetDeviceScriptsRow, error) {
+	rows, err := q.query(ctx, q.getDeviceScriptsStmt, getDeviceScripts, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDeviceScriptsRow
+	for rows.Next() {
+		var i GetDeviceScriptsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Content,
+			&i.RunContext,
+			&i.Schedule,
+			&i.Version,
+			&i.RunVersion,
+			&i.RunFinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDevices = `-- name: GetDevices :many
+SELECT id, name, model FROM devices LIMIT 100
+`
+
+type GetDevicesRow struct {
+	ID    int32  `json:"id"`
+	Name  string `json:"name"`
+	Model string `json:"model"`
+}
+
+// Exposed via API
+func (q *Queries) GetDevices(ctx context.Context) ([]GetDevicesRow, error) {
+	rows, err := q.query(ctx, q.getDevicesStmt, getDevices)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDevicesRow
+	for rows.Next() {
+		var i GetDevicesRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Model); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDevicesAssignmentSchedules = `-- name: GetDevicesAssignmentSchedules :many
+SELECT group_policies.group_id, group_policies.policy_id, groups.timezone, group_policies.not_before FROM group_devices INNER JOIN groups ON groups.id=group_devices.group_id INNER JOIN group_policies ON group_policies.group_id=groups.id WHERE group_devices.device_id = $1 AND (group_policies.not_before IS NOT NULL OR EXISTS (SELECT 1 FROM group_policy_windows WHERE group_policy_windows.group_id=group_policies.group_id AND group_policy_windows.policy_id=group_policies.policy_id))
+`
+
+type GetDevicesAssignmentSchedulesRow struct {
+	GroupID   sql.NullInt32 `json:"group_id"`
+	PolicyID  sql.NullInt32 `json:"policy_id"`
+	Timezone  string        `json:"timezone"`
+	NotBefore sql.NullTime  `json:"not_before"`
+}
+
+// The schedules of the device's group policies which aren't always deployed
+func (q *Queries) GetDevicesAssignmentSchedules(ctx context.Context, deviceID int32) ([]GetDevicesAssignmentSchedulesRow, error) {
+	rows, err := q.query(ctx, q.getDevicesAssignmentSchedulesStmt, getDevicesAssignmentSchedules, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDevicesAssignmentSchedulesRow
+	for rows.Next() {
+		var i GetDevicesAssignmentSchedulesRow
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.PolicyID,
+			&i.Timezone,
+			&i.NotBefore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDevicesAssignmentWindows = `-- name: GetDevicesAssignmentWindows :many
+SELECT group_policy_windows.group_id, group_policy_windows.policy_id, group_policy_windows.weekday, group_policy_windows.start_minute, group_policy_windows.end_minute FROM group_policy_windows INNER JOIN group_devices ON group_devices.group_id=group_policy_windows.group_id WHERE group_devices.device_id = $1
+`
+
+func (q *Queries) GetDevicesAssignmentWindows(ctx context.Context, deviceID int32) ([]GroupPolicyWindow, error) {
+	rows, err := q.query(ctx, q.getDevicesAssignmentWindowsStmt, getDevicesAssignmentWindows, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GroupPolicyWindow
+	for rows.Next() {
+		var i GroupPolicyWindow
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.PolicyID,
+			&i.Weekday,
+			&i.StartMinute,
+			&i.EndMinute,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDevicesPayloadCandidates = `-- name: GetDevicesPayloadCandidates :many
+SELECT policies_payload.id, policies_payload.uri, policies_payload.format, policies_payload.type, policies_payload.value, policies_payload.exec, policies.id AS policy_id, policies.name AS policy_name, policies.priority AS policy_priority, groups.id AS group_id, groups.name AS group_name, groups.priority AS group_priority FROM group_devices INNER JOIN groups ON groups.id=group_devices.group_id INNER JOIN group_policies ON group_policies.group_id=groups.id INNER JOIN policies ON policies.id=group_policies.policy_id INNER JOIN policies_payload ON policies_payload.policy_id=policies.id WHERE group_devices.device_id = $1
+`
+
+type GetDevicesPayloadCandidatesRow struct {
+	ID             int32  `json:"id"`
+	Uri            string `json:"uri"`
+	Format         string `json:"format"`
+	Type           string `json:"type"`
+	Value          string `json:"value"`
+	Exec           bool   `json:"exec"`
+	PolicyID       int32  `json:"policy_id"`
+	PolicyName     string `json:"policy_name"`
+	PolicyPriority int16  `json:"policy_priority"`
+	GroupID        int32  `json:"group_id"`
+	GroupName      string `json:"group_name"`
+	GroupPriority  int16  `json:"group_priority"`
+}
+
+func (q *Queries) GetDevicesPayloadCandidates(ctx context.Context, deviceID int32) ([]GetDevicesPayloadCandidatesRow, error) {
+	rows, err := q.query(ctx, q.getDevicesPayloadCandidatesStmt, getDevicesPayloadCandidates, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDevicesPayloadCandidatesRow
+	for rows.Next() {
+		var i GetDevicesPayloadCandidatesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uri,
+			&i.Format,
+			&i.Type,
+			&i.Value,
+			&i.Exec,
+			&i.PolicyID,
+			&i.PolicyName,
+			&i.PolicyPriority,
+			&i.GroupID,
+			&i.GroupName,
+			&i.GroupPriority,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDevicesPayloads = `-- name: GetDevicesPayloads :many
+
+SELECT DISTINCT policies_payload.id, policies_payload.policy_id, policies_payload.uri, policies_payload.format, policies_payload.type, policies_payload.value, policies_payload.exec FROM group_devices INNER JOIN group_policies ON group_policies.group_id=group_devices.group_id INNER JOIN policies_payload ON policies_payload.policy_id=group_policies.policy_id WHERE group_devices.device_id = $1
+`
+
+// TODO: Merge this with last checkin status
+func (q *Queries) GetDevicesPayloads(ctx context.Context, deviceID int32) ([]PoliciesPayload, error) {
+	rows, err := q.query(ctx, q.getDevicesPayloadsStmt, getDevicesPayloads, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PoliciesPayload
+	for rows.Next() {
+		var i PoliciesPayload
+		if err := rows.Scan(
+			&i.ID,
+			&i.PolicyID,
+			&i.Uri,
+			&i.Format,
+			&i.Type,
+			&i.Value,
+			&i.Exec,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDevicesPendingCommands = `-- name: GetDevicesPendingCommands :many
+SELECT id, command, uri, format, type, value FROM device_commands WHERE device_id = $1 AND sent_at IS NULL ORDER BY id
+`
+
+type GetDevicesPendingCommandsRow struct {
+	ID      int32  `json:"id"`
+	Command string `json:"command"`
+	Uri     string `json:"uri"`
+	Format  string `json:"format"`
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+}
+
+func (q *Queries) GetDevicesPendingCommands(ctx context.Context, deviceID int32) ([]GetDevicesPendingCommandsRow, error) {
+	rows, err := q.query(ctx, q.getDevicesPendingCommandsStmt, getDevicesPendingCommands, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDevicesPendingCommandsRow
+	for rows.Next() {
+		var i GetDevicesPendingCommandsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Command,
+			&i.Uri,
+			&i.Format,
+			&i.Type,
+			&i.Value,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDevicesRolloutCandidates = `-- name: GetDevicesRolloutCandidates :many
+SELECT policies_payload.id, policies_payload.uri, policies_payload.format, policies_payload.type, policies_payload.value, policies_payload.exec, policies.id AS policy_id, policies.name AS policy_name, policies.priority AS policy_priority, groups.id AS group_id, groups.name AS group_name, groups.priority AS group_priority FROM rollouts INNER JOIN group_devices ON group_devices.group_id=rollouts.group_id INNER JOIN groups ON groups.id=rollouts.group_id INNER JOIN policies ON policies.id=rollouts.policy_id INNER JOIN policies_payload ON policies_payload.policy_id=policies.id WHERE group_devices.device_id = $1 AND (
+    rollouts.state = 'active'
+    OR (rollouts.state = 'paused' AND EXISTS (SELECT 1 FROM device_cache WHERE device_cache.device_id=group_devices.device_id AND device_cache.payload_id=policies_payload.id AND device_cache.upn IS NULL))
+) AND (
+    EXISTS (SELECT 1 FROM rollout_stages INNER JOIN group_devices AS ring_devices ON ring_devices.group_id=rollout_stages.ring_group_id WHERE rollout_stages.rollout_id=rollouts.id AND rollout_stages.stage <= rollouts.stage AND ring_devices.device_id=group_devices.device_id)
+    OR ('x' || substr(md5(rollouts.id || '-' || group_devices.device_id), 1, 7))::bit(28)::integer % 100 < (SELECT COALESCE(MAX(rollout_stages.percentage), 0) FROM rollout_stages WHERE rollout_stages.rollout_id=rollouts.id AND rollout_stages.stage <= rollouts.stage)
+)
+`
+
+type GetDevicesRolloutCandidatesRow struct {
+	ID             int32  `json:"id"`
+	Uri            string `json:"uri"`
+	Format         string `json:"format"`
+	Type           string `json:"type"`
+	Value          string `json:"value"`
+	Exec           bool   `json:"exec"`
+	PolicyID       int32  `json:"policy_id"`
+	PolicyName     string `json:"policy_name"`
+	PolicyPriority int16  `json:"policy_priority"`
+	GroupID        int32  `json:"group_id"`
+	GroupName      string `json:"group_name"`
+	GroupPriority  int16  `json:"group_priority"`
+}
+
+// Devices are in a rollout once a stage up to the current one includes their ring group or their (stable) percentage bucket.
+// Paused rollouts only keep the payloads the device has already received so they aren't deployed any further.
+func (q *Queries) GetDevicesRolloutCandidates(ctx context.Context, deviceID int32) ([]GetDevicesRolloutCandidatesRow, error) {
+	rows, err := q.query(ctx, q.getDevicesRolloutCandidatesStmt, getDevicesRolloutCandidates, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDevicesRolloutCandidatesRow
+	for rows.Next() {
+		var i GetDevicesRolloutCandidatesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uri,
+			&i.Format,
+			&i.Type,
+			&i.Value,
+			&i.Exec,
+			&i.PolicyID,
+			&i.PolicyName,
+			&i.PolicyPriority,
+			&i.GroupID,
+			&i.GroupName,
+			&i.GroupPriority,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDynamicGroups = `-- name: GetDynamicGroups :many
+SELECT id, name, rules FROM groups WHERE rules != ''
+`
+
+type GetDynamicGroupsRow struct {
+	ID    int32  `json:"id"`
+	Name  string `json:"name"`
+	Rules string `json:"rules"`
+}
+
+func (q *Queries) GetDynamicGroups(ctx context.Context) ([]GetDynamicGroupsRow, error) {
+	rows, err := q.query(ctx, q.getDynamicGroupsStmt, getDynamicGroups)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDynamicGroupsRow
+	for rows.Next() {
+		var i GetDynamicGroupsRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Rules); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEnrollmentAttempt = `-- name: GetEnrollmentAttempt :one
+SELECT trace_id, endpoint, message_id, upn, udid, hw_dev_id, device_id, result, fault_code, fault_type, fault_reason, error, created_at FROM enrollment_attempts WHERE trace_id = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetEnrollmentAttempt(ctx context.Context, traceID string) (EnrollmentAttempt, error) {
+	row := q.queryRow(ctx, q.getEnrollmentAttemptStmt, getEnrollmentAttempt, traceID)
+	var i EnrollmentAttempt
+	err := row.Scan(
+		&i.TraceID,
+		&i.Endpoint,
+		&i.MessageID,
+		&i.Upn,
+		&i.Udid,
+		&i.HwDevID,
+		&i.DeviceID,
+		&i.Result,
+		&i.FaultCode,
+		&i.FaultType,
+		&i.FaultReason,
+		&i.Error,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getEnrollmentAttempts = `-- name: GetEnrollmentAttempts :many
+SELECT trace_id, endpoint, message_id, upn, udid, hw_dev_id, device_id, result, fault_code, fault_type, fault_reason, error, created_at FROM enrollment_attempts ORDER BY created_at DESC LIMIT 100
+`
+
+// Exposed via API
+func (q *Queries) GetEnrollmentAttempts(ctx context.Context) ([]EnrollmentAttempt, error) {
+	rows, err := q.query(ctx, q.getEnrollmentAttemptsStmt, getEnrollmentAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EnrollmentAttempt
+	for rows.Next() {
+		var i EnrollmentAttempt
+		if err := rows.Scan(
+			&i.TraceID,
+			&i.Endpoint,
+			&i.MessageID,
+			&i.Upn,
+			&i.Udid,
+			&i.HwDevID,
+			&i.DeviceID,
+			&i.Result,
+			&i.FaultCode,
+			&i.FaultType,
+			&i.FaultReason,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEnrollmentBranding = `-- name: GetEnrollmentBranding :one
+SELECT language, application_name, title, body_template, support_url, logo_url, support_text FROM enrollment_branding WHERE language = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetEnrollmentBranding(ctx context.Context, language string) (EnrollmentBranding, error) {
+	row := q.queryRow(ctx, q.getEnrollmentBrandingStmt, getEnrollmentBranding, language)
+	var i EnrollmentBranding
+	err := row.Scan(
+		&i.Language,
+		&i.ApplicationName,
+		&i.Title,
+		&i.BodyTemplate,
+		&i.SupportUrl,
+		&i.LogoUrl,
+		&i.SupportText,
+	)
+	return i, err
+}
+
+const getEnrollmentBrandings = `-- name: GetEnrollmentBrandings :many
+SELECT language, application_name, title, body_template, support_url, logo_url, support_text FROM enrollment_branding ORDER BY language
+`
+
+// Exposed via API
+func (q *Queries) GetEnrollmentBrandings(ctx context.Context) ([]EnrollmentBranding, error) {
+	rows, err := q.query(ctx, q.getEnrollmentBrandingsStmt, getEnrollmentBrandings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EnrollmentBranding
+	for rows.Next() {
+		var i EnrollmentBranding
+		if err := rows.Scan(
+			&i.Language,
+			&i.ApplicationName,
+			&i.Title,
+			&i.BodyTemplate,
+			&i.SupportUrl,
+			&i.LogoUrl,
+			&i.SupportText,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEnrollmentResponse = `-- name: GetEnrollmentResponse :one
+SELECT enrollment_responses.device_id, enrollment_responses.provisioning_profile FROM enrollment_responses INNER JOIN devices ON devices.id = enrollment_responses.device_id WHERE devices.udid = $1 AND enrollment_responses.message_id = $2 AND enrollment_responses.upn = $3 AND enrollment_responses.created_at > NOW() - INTERVAL '10 minutes' LIMIT 1
+`
+
+type GetEnrollmentResponseParams struct {
+	Udid      string `json:"udid"`
+	MessageID string `json:"message_id"`
+	Upn       string `json:"upn"`
+}
+
+type GetEnrollmentResponseRow struct {
+	DeviceID            int32  `json:"device_id"`
+	ProvisioningProfile []byte `json:"provisioning_profile"`
+}
+
+// Only enrollments from the last 10 minutes can be retried
+func (q *Queries) GetEnrollmentResponse(ctx context.Context, arg GetEnrollmentResponseParams) (GetEnrollmentResponseRow, error) {
+	row := q.queryRow(ctx, q.getEnrollmentResponseStmt, getEnrollmentResponse, arg.Udid, arg.MessageID, arg.Upn)
+	var i GetEnrollmentResponseRow
+	err := row.Scan(&i.DeviceID, &i.ProvisioningProfile)
+	return i, err
+}
+
+const getExpiringIssuedCertificates = `-- name: GetExpiringIssuedCertificates :many
+SELECT issued_certificates.serial, issued_certificates.device_id, issued_certificates.profile_id, issued_certificates.upn, issued_certificates.subject, issued_certificates.thumbprint, issued_certificates.not_before, issued_certificates.not_after, issued_certificates.issued_at, issued_certificates.revoked_at, devices.name AS device_name FROM issued_certificates INNER JOIN devices ON devices.id = issued_certificates.device_id WHERE issued_certificates.revoked_at IS NULL AND issued_certificates.not_after > NOW() AND issued_certificates.not_after < $1 AND NOT EXISTS (SELECT 1 FROM issued_certificates newer WHERE newer.device_id = issued_certificates.device_id AND newer.profile_id = issued_certificates.profile_id AND newer.upn = issued_certificates.upn AND newer.issued_at > issued_certificates.issued_at) ORDER BY issued_certificates.not_after
+`
+
+type GetExpiringIssuedCertificatesRow struct {
+	Serial     string        `json:"serial"`
+	DeviceID   int32         `json:"device_id"`
+	ProfileID  sql.NullInt32 `json:"profile_id"`
+	Upn        string        `json:"upn"`
+	Subject    string        `json:"subject"`
+	Thumbprint string        `json:"thumbprint"`
+	NotBefore  time.Time     `json:"not_before"`
+	NotAfter   time.Time     `json:"not_after"`
+	IssuedAt   time.Time     `json:"issued_at"`
+	RevokedAt  sql.NullTime  `json:"revoked_at"`
+	DeviceName string        `json:"device_name"`
+}
+
+// Exposed via API. The unrevoked certificates which expire before the time and haven't been replaced by a newer certificate.
+func (q *Queries) GetExpiringIssuedCertificates(ctx context.Context, notAfter time.Time) ([]GetExpiringIssuedCertificatesRow, error) {
+	rows, err := q.query(ctx, q.getExpiringIssuedCertificatesStmt, getExpiringIssuedCertificates, notAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetExpiringIssuedCertificatesRow
+	for rows.Next() {
+		var i GetExpiringIssuedCertificatesRow
+		if err := rows.Scan(
+			&i.Serial,
+			&i.DeviceID,
+			&i.ProfileID,
+			&i.Upn,
+			&i.Subject,
+			&i.Thumbprint,
+			&i.NotBefore,
+			&i.NotAfter,
+			&i.IssuedAt,
+			&i.RevokedAt,
+			&i.DeviceName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFailedScriptRuns = `-- name: GetFailedScriptRuns :many
+SELECT script_runs.script_id, scripts.name AS script_name, script_runs.device_id, devices.name AS device_name, script_runs.version, script_runs.exit_code, script_runs.stderr, script_runs.finished_at FROM script_runs INNER JOIN scripts ON scripts.id = script_runs.script_id INNER JOIN devices ON devices.id = script_runs.device_id WHERE script_runs.exit_code != 0 ORDER BY script_runs.finished_at DESC
+`
+
+type GetFailedScriptRunsRow struct {
+	ScriptID   int32     `json:"script_id"`
+	ScriptName string    `json:"script_name"`
+	DeviceID   int32     `json:"device_id"`
+	DeviceName string    `json:"device_name"`
+	Version    int32     `json:"version"`
+	ExitCode   int32     `json:"exit_code"`
+	Stderr     string    `json:"stderr"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Exposed via API
+func (q *Queries) GetFailedScriptRuns(ctx context.Context) ([]GetFailedScriptRunsRow, error) {
+	rows, err := q.query(ctx, q.getFailedScriptRunsStmt, getFailedScriptRuns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFailedScriptRunsRow
+	for rows.Next() {
+		var i GetFailedScriptRunsRow
+		if err := rows.Scan(
+			&i.ScriptID,
+			&i.ScriptName,
+			&i.DeviceID,
+			&i.DeviceName,
+			&i.Version,
+			&i.ExitCode,
+			&i.Stderr,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGroup = `-- name: GetGroup :one
+SELECT id, name, description, priority, rules, timezone FROM groups WHERE id = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetGroup(ctx context.Context, id int32) (Group, error) {
+	row := q.queryRow(ctx, q.getGroupStmt, getGroup, id)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Priority,
+		&i.Rules,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const getGroupDevices = `-- name: GetGroupDevices :many
+SELECT devices.id, devices.name, devices.model FROM devices INNER JOIN group_devices ON group_devices.device_id=devices.id WHERE group_devices.group_id = $1 ORDER BY devices.id
+`
+
+type GetGroupDevicesRow struct {
+	ID    int32  `json:"id"`
+	Name  string `json:"name"`
+	Model string `json:"model"`
+}
+
+// Exposed via API
+func (q *Queries) GetGroupDevices(ctx context.Context, groupID int32) ([]GetGroupDevicesRow, error) {
+	rows, err := q.query(ctx, q.getGroupDevicesStmt, getGroupDevices, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGroupDevicesRow
+	for rows.Next() {
+		var i GetGroupDevicesRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Model); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGroupPolicies = `-- name: GetGroupPolicies :many
+SELECT policies.id, policies.name FROM policies INNER JOIN group_policies ON group_policies.policy_id=policies.id WHERE group_policies.group_id = $1 ORDER BY policies.id
+`
+
+type GetGroupPoliciesRow struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Exposed via API
+func (q *Queries) GetGroupPolicies(ctx context.Context, groupID sql.NullInt32) ([]GetGroupPoliciesRow, error) {
+	rows, err := q.query(ctx, q.getGroupPoliciesStmt, getGroupPolicies, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGroupPoliciesRow
+	for rows.Next() {
+		var i GetGroupPoliciesRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGroupPolicySchedule = `-- name: GetGroupPolicySchedule :one
+SELECT not_before FROM group_policies WHERE group_id = $1 AND policy_id = $2 LIMIT 1
+`
+
+type GetGroupPolicyScheduleParams struct {
+	GroupID  sql.NullInt32 `json:"group_id"`
+	PolicyID sql.NullInt32 `json:"policy_id"`
+}
+
+// Exposed via API
+func (q *Queries) GetGroupPolicySchedule(ctx context.Context, arg GetGroupPolicyScheduleParams) (sql.NullTime, error) {
+	row := q.queryRow(ctx, q.getGroupPolicyScheduleStmt, getGroupPolicySchedule, arg.GroupID, arg.PolicyID)
+	var not_before sql.NullTime
+	err := row.Scan(&not_before)
+	return not_before, err
+}
+
+const getGroupPolicyWindows = `-- name: GetGroupPolicyWindows :many
+SELECT weekday, start_minute, end_minute FROM group_policy_windows WHERE group_id = $1 AND policy_id = $2 ORDER BY weekday, start_minute
+`
+
+type GetGroupPolicyWindowsParams struct {
+	GroupID  int32 `json:"group_id"`
+	PolicyID int32 `json:"policy_id"`
+}
+
+type GetGroupPolicyWindowsRow struct {
+	Weekday     int16 `json:"weekday"`
+	StartMinute int16 `json:"start_minute"`
+	EndMinute   int16 `json:"end_minute"`
+}
+
+// Exposed via API
+func (q *Queries) GetGroupPolicyWindows(ctx context.Context, arg GetGroupPolicyWindowsParams) ([]GetGroupPolicyWindowsRow, error) {
+	rows, err := q.query(ctx, q.getGroupPolicyWindowsStmt, getGroupPolicyWindows, arg.GroupID, arg.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGroupPolicyWindowsRow
+	for rows.Next() {
+		var i GetGroupPolicyWindowsRow
+		if err := rows.Scan(&i.Weekday, &i.StartMinute, &i.EndMinute); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGroups = `-- name: GetGroups :many
+SELECT id, name, description, priority, rules, timezone FROM groups LIMIT 100
+`
+
+// Exposed via API
+func (q *Queries) GetGroups(ctx context.Context) ([]Group, error) {
+	rows, err := q.query(ctx, q.getGroupsStmt, getGroups)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Group
+	for rows.Next() {
+		var i Group
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Priority,
+			&i.Rules,
+			&i.Timezone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGroupsPayloadCandidates = `-- name: GetGroupsPayloadCandidates :many
+SELECT policies_payload.id, policies_payload.uri, policies_payload.format, policies_payload.type, policies_payload.value, policies_payload.exec, policies.id AS policy_id, policies.name AS policy_name, policies.priority AS policy_priority, groups.id AS group_id, groups.name AS group_name, groups.priority AS group_priority FROM groups INNER JOIN group_policies ON group_policies.group_id=groups.id INNER JOIN policies ON policies.id=group_policies.policy_id INNER JOIN policies_payload ON policies_payload.policy_id=policies.id WHERE groups.id = ANY($1::integer[])
+`
+
+type GetGroupsPayloadCandidatesRow struct {
+	ID             int32  `json:"id"`
+	Uri            string `json:"uri"`
+	Format         string `json:"format"`
+	Type           string `json:"type"`
+	Value          string `json:"value"`
+	Exec           bool   `json:"exec"`
+	PolicyID       int32  `json:"policy_id"`
+	PolicyName     string `json:"policy_name"`
+	PolicyPriority int16  `json:"policy_priority"`
+	GroupID        int32  `json:"group_id"`
+	GroupName      string `json:"group_name"`
+	GroupPriority  int16  `json:"group_priority"`
+}
+
+// Used to preview the effective payloads of a device with a different group membership
+func (q *Queries) GetGroupsPayloadCandidates(ctx context.Context, groupIds []int32) ([]GetGroupsPayloadCandidatesRow, error) {
+	rows, err := q.query(ctx, q.getGroupsPayloadCandidatesStmt, getGroupsPayloadCandidates, pq.Array(groupIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGroupsPayloadCandidatesRow
+	for rows.Next() {
+		var i GetGroupsPayloadCandidatesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uri,
+			&i.Format,
+			&i.Type,
+			&i.Value,
+			&i.Exec,
+			&i.PolicyID,
+			&i.PolicyName,
+			&i.PolicyPriority,
+			&i.GroupID,
+			&i.GroupName,
+			&i.GroupPriority,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIssuedCertificate = `-- name: GetIssuedCertificate :one
+SELECT serial, device_id, profile_id, upn, subject, thumbprint, not_before, not_after, issued_at, revoked_at FROM issued_certificates WHERE serial = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetIssuedCertificate(ctx context.Context, serial string) (IssuedCertificate, error) {
+	row := q.queryRow(ctx, q.getIssuedCertificateStmt, getIssuedCertificate, serial)
+	var i IssuedCertificate
+	err := row.Scan(
+		&i.Serial,
+		&i.DeviceID,
+		&i.ProfileID,
+		&i.Upn,
+		&i.Subject,
+		&i.Thumbprint,
+		&i.NotBefore,
+		&i.NotAfter,
+		&i.IssuedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getLatestIssuedCertificate = `-- name: GetLatestIssuedCertificate :one
+SELECT serial, device_id, profile_id, upn, subject, thumbprint, not_before, not_after, issued_at, revoked_at FROM issued_certificates WHERE device_id = $1 AND profile_id = $2 AND upn = $3 ORDER BY issued_at DESC LIMIT 1
+`
+
+type GetLatestIssuedCertificateParams struct {
+	DeviceID  int32         `json:"device_id"`
+	ProfileID sql.NullInt32 `json:"profile_id"`
+	Upn       string        `json:"upn"`
+}
+
+// The certificate most recently issued to the device (or user on the device) from the profile, including revoked certificates
+func (q *Queries) GetLatestIssuedCertificate(ctx context.Context, arg GetLatestIssuedCertificateParams) (IssuedCertificate, error) {
+	row := q.queryRow(ctx, q.getLatestIssuedCertificateStmt, getLatestIssuedCertificate, arg.DeviceID, arg.ProfileID, arg.Upn)
+	var i IssuedCertificate
+	err := row.Scan(
+		&i.Serial,
+		&i.DeviceID,
+		&i.ProfileID,
+		&i.Upn,
+		&i.Subject,
+		&i.Thumbprint,
+		&i.NotBefore,
+		&i.NotAfter,
+		&i.IssuedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getLatestTermsOfService = `-- name: GetLatestTermsOfService :one
+SELECT version, title, content, created_at FROM terms_of_service ORDER BY version DESC LIMIT 1
+`
+
+func (q *Queries) GetLatestTermsOfService(ctx context.Context) (TermsOfService, error) {
+	row := q.queryRow(ctx, q.getLatestTermsOfServiceStmt, getLatestTermsOfService)
+	var i TermsOfService
+	err := row.Scan(
+		&i.Version,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLocalAdminPassword = `-- name: GetLocalAdminPassword :one
+SELECT device_id, profile_id, encrypted_password, set_at, pending_encrypted_password, pending_since, rotate_at, last_error FROM local_admin_passwords WHERE device_id = $1 AND profile_id = $2 LIMIT 1
+`
+
+type GetLocalAdminPasswordParams struct {
+	DeviceID  int32 `json:"device_id"`
+	ProfileID int32 `json:"profile_id"`
+}
+
+func (q *Queries) GetLocalAdminPassword(ctx context.Context, arg GetLocalAdminPasswordParams) (LocalAdminPassword, error) {
+	row := q.queryRow(ctx, q.getLocalAdminPasswordStmt, getLocalAdminPassword, arg.DeviceID, arg.ProfileID)
+	var i LocalAdminPassword
+	err := row.Scan(
+		&i.DeviceID,
+		&i.ProfileID,
+		&i.EncryptedPassword,
+		&i.SetAt,
+		&i.PendingEncryptedPassword,
+		&i.PendingSince,
+		&i.RotateAt,
+		&i.LastError,
+	)
+	return i, err
+}
+
+const getLocalAdminPasswordAccess = `-- name: GetLocalAdminPasswordAccess :many
+SELECT id, device_id, profile_id, account_name, upn, reason, accessed_at FROM local_admin_password_access WHERE device_id = $1 ORDER BY accessed_at DESC LIMIT 100
+`
+
+// Exposed via API
+func (q *Queries) GetLocalAdminPasswordAccess(ctx context.Context, deviceID int32) ([]LocalAdminPasswordAccess, error) {
+	rows, err := q.query(ctx, q.getLocalAdminPasswordAccessStmt, getLocalAdminPasswordAccess, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LocalAdminPasswordAccess
+	for rows.Next() {
+		var i LocalAdminPasswordAccess
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeviceID,
+			&i.ProfileID,
+			&i.AccountName,
+			&i.Upn,
+			&i.Reason,
+			&i.AccessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLocalAdminProfile = `-- name: GetLocalAdminProfile :one
+SELECT id, name, description, account_name, password_length, rotation_days, rotate_after_view_hours, group_id, created_at, updated_at FROM local_admin_profiles WHERE id = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetLocalAdminProfile(ctx context.Context, id int32) (LocalAdminProfile, error) {
+	row := q.queryRow(ctx, q.getLocalAdminProfileStmt, getLocalAdminProfile, id)
+	var i LocalAdminProfile
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.AccountName,
+		&i.PasswordLength,
+		&i.RotationDays,
+		&i.RotateAfterViewHours,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getLocalAdminProfiles = `-- name: GetLocalAdminProfiles :many
+SELECT id, name, description, account_name, password_length, rotation_days, rotate_after_view_hours, group_id, created_at, updated_at FROM local_admin_profiles ORDER BY name
+`
+
+// Exposed via API
+func (q *Queries) GetLocalAdminProfiles(ctx context.Context) ([]LocalAdminProfile, error) {
+	rows, err := q.query(ctx, q.getLocalAdminProfilesStmt, getLocalAdminProfiles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LocalAdminProfile
+	for rows.Next() {
+		var i LocalAdminProfile
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.AccountName,
+			&i.PasswordLength,
+			&i.RotationDays,
+			&i.RotateAfterViewHours,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPayloadsRollouts = `-- name: GetPayloadsRollouts :many
+SELECT rollouts.id, rollouts.stage, rollouts.state, rollouts.failure_threshold, (SELECT MIN(rollout_stages.stage) FROM rollout_stages WHERE rollout_stages.rollout_id=rollouts.id AND rollout_stages.stage <= rollouts.stage AND (
+    EXISTS (SELECT 1 FROM group_devices AS ring_devices WHERE ring_devices.group_id=rollout_stages.ring_group_id AND ring_devices.device_id=group_devices.device_id)
+    OR ('x' || substr(md5(rollouts.id || '-' || group_devices.device_id), 1, 7))::bit(28)::integer % 100 < rollout_stages.percentage
+))::smallint AS included_stage FROM rollouts INNER JOIN policies_payload ON policies_payload.policy_id=rollouts.policy_id INNER JOIN group_devices ON group_devices.group_id=rollouts.group_id WHERE policies_payload.id = $1 AND group_devices.device_id = $2 AND rollouts.state IN ('active', 'paused') AND (
+    EXISTS (SELECT 1 FROM rollout_stages INNER JOIN group_devices AS ring_devices ON ring_devices.group_id=rollout_stages.ring_group_id WHERE rollout_stages.rollout_id=rollouts.id AND rollout_stages.stage <= rollouts.stage AND ring_devices.device_id=group_devices.device_id)
+    OR ('x' || substr(md5(rollouts.id || '-' || group_devices.device_id), 1, 7))::bit(28)::integer % 100 < (SELECT COALESCE(MAX(rollout_stages.percentage), 0) FROM rollout_stages WHERE rollout_stages.rollout_id=rollouts.id AND rollout_stages.stage <= rollouts.stage)
+)
+`
+
+type GetPayloadsRolloutsParams struct {
+	ID       int32 `json:"id"`
+	DeviceID int32 `json:"device_id"`
+}
+
+type GetPayloadsRolloutsRow struct {
+	ID               int32        `json:"id"`
+	Stage            int16        `json:"stage"`
+	State            RolloutState `json:"state"`
+	FailureThreshold int16        `json:"failure_threshold"`
+	IncludedStage    int16        `json:"included_stage"`
+}
+
+// The unfinished rollouts which deployed the payload to the device. The device must be included in a stage up to the current one (see GetDevicesRolloutCandidates).
+// The included stage is the first stage which included the device so its results stay with that stage after the rollout is promoted.
+func (q *Queries) GetPayloadsRollouts(ctx context.Context, arg GetPayloadsRolloutsParams) ([]GetPayloadsRolloutsRow, error) {
+	rows, err := q.query(ctx, q.getPayloadsRolloutsStmt, getPayloadsRollouts, arg.ID, arg.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPayloadsRolloutsRow
+	for rows.Next() {
+		var i GetPayloadsRolloutsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Stage,
+			&i.State,
+			&i.FailureThreshold,
+			&i.IncludedStage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPolicies = `-- name: GetPolicies :many
+SELECT id, name FROM policies LIMIT 100
+`
+
+type GetPoliciesRow struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Exposed via API
+func (q *Queries) GetPolicies(ctx context.Context) ([]GetPoliciesRow, error) {
+	rows, err := q.query(ctx, q.getPoliciesStmt, getPolicies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPoliciesRow
+	for rows.Next() {
+		var i GetPoliciesRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPoliciesPayloads = `-- name: GetPoliciesPayloads :many
+SELECT id, policy_id, uri, format, type, value, exec FROM policies_payload WHERE policy_id = $1 ORDER BY id
+`
+
+// Exposed via API
+func (q *Queries) GetPoliciesPayloads(ctx context.Context, policyID sql.NullInt32) ([]PoliciesPayload, error) {
+	rows, err := q.query(ctx, q.getPoliciesPayloadsStmt, getPoliciesPayloads, policyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PoliciesPayload
+	for rows.Next() {
+		var i PoliciesPayload
+		if err := rows.Scan(
+			&i.ID,
+			&i.PolicyID,
+			&i.Uri,
+			&i.Format,
+			&i.Type,
+			&i.Value,
+			&i.Exec,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPolicy = `-- name: GetPolicy :one
+SELECT id, name, description, priority, version FROM policies WHERE id = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetPolicy(ctx context.Context, id int32) (Policy, error) {
+	row := q.queryRow(ctx, q.getPolicyStmt, getPolicy, id)
+	var i Policy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Priority,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getPolicyPayload = `-- name: GetPolicyPayload :one
+SELECT id, policy_id, uri, format, type, value, exec FROM policies_payload WHERE id = $1 AND policy_id = $2 LIMIT 1
+`
+
+type GetPolicyPayloadParams struct {
+	ID       int32         `json:"id"`
+	PolicyID sql.NullInt32 `json:"policy_id"`
+}
+
+// Exposed via API
+func (q *Queries) GetPolicyPayload(ctx context.Context, arg GetPolicyPayloadParams) (PoliciesPayload, error) {
+	row := q.queryRow(ctx, q.getPolicyPayloadStmt, getPolicyPayload, arg.ID, arg.PolicyID)
+	var i PoliciesPayload
+	err := row.Scan(
+		&i.ID,
+		&i.PolicyID,
+		&i.Uri,
+		&i.Format,
+		&i.Type,
+		&i.Value,
+		&i.Exec,
+	)
+	return i, err
+}
+
+const getPolicyVersion = `-- name: GetPolicyVersion :one
+SELECT policy_id, version, author, name, description, priority, payloads, created_at FROM policy_versions WHERE policy_id = $1 AND version = $2 LIMIT 1
+`
+
+type GetPolicyVersionParams struct {
+	PolicyID int32 `json:"policy_id"`
+	Version  int32 `json:"version"`
+}
+
+// Exposed via API
+func (q *Queries) GetPolicyVersion(ctx context.Context, arg GetPolicyVersionParams) (PolicyVersion, error) {
+	row := q.queryRow(ctx, q.getPolicyVersionStmt, getPolicyVersion, arg.PolicyID, arg.Version)
+	var i PolicyVersion
+	err := row.Scan(
+		&i.PolicyID,
+		&i.Version,
+		&i.Author,
+		&i.Name,
+		&i.Description,
+		&i.Priority,
+		&i.Payloads,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPolicyVersions = `-- name: GetPolicyVersions :many
+SELECT policy_id, version, author, created_at FROM policy_versions WHERE policy_id = $1 ORDER BY version DESC LIMIT 100
+`
+
+type GetPolicyVersionsRow struct {
+	PolicyID  int32     `json:"policy_id"`
+	Version   int32     `json:"version"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Exposed via API
+func (q *Queries) GetPolicyVersions(ctx context.Context, policyID int32) ([]GetPolicyVersionsRow, error) {
+	rows, err := q.query(ctx, q.getPolicyVersionsStmt, getPolicyVersions, policyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPolicyVersionsRow
+	for rows.Next() {
+		var i GetPolicyVersionsRow
+		if err := rows.Scan(
+			&i.PolicyID,
+			&i.Version,
+			&i.Author,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProfileIssuedCertificates = `-- name: GetProfileIssuedCertificates :many
+SELECT issued_certificates.serial, issued_certificates.device_id, issued_certificates.profile_id, issued_certificates.upn, issued_certificates.subject, issued_certificates.thumbprint, issued_certificates.not_before, issued_certificates.not_after, issued_certificates.issued_at, issued_certificates.revoked_at, devices.name AS device_name FROM issued_certificates INNER JOIN devices ON devices.id = issued_certificates.device_id WHERE issued_certificates.profile_id = $1 ORDER BY issued_certificates.issued_at DESC
+`
+
+type GetProfileIssuedCertificatesRow struct {
+	Serial     string        `json:"serial"`
+	DeviceID   int32         `json:"device_id"`
+	ProfileID  sql.NullInt32 `json:"profile_id"`
+	Upn        string        `json:"upn"`
+	Subject    string        `json:"subject"`
+	Thumbprint string        `json:"thumbprint"`
+	NotBefore  time.Time     `json:"not_before"`
+	NotAfter   time.Time     `json:"not_after"`
+	IssuedAt   time.Time     `json:"issued_at"`
+	RevokedAt  sql.NullTime  `json:"revoked_at"`
+	DeviceName string        `json:"device_name"`
+}
+
+// Exposed via API
+func (q *Queries) GetProfileIssuedCertificates(ctx context.Context, profileID sql.NullInt32) ([]GetProfileIssuedCertificatesRow, error) {
+	rows, err := q.query(ctx, q.getProfileIssuedCertificatesStmt, getProfileIssuedCertificates, profileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetProfileIssuedCertificatesRow
+	for rows.Next() {
+		var i GetProfileIssuedCertificatesRow
+		if err := rows.Scan(
+			&i.Serial,
+			&i.DeviceID,
+			&i.ProfileID,
+			&i.Upn,
+			&i.Subject,
+			&i.Thumbprint,
+			&i.NotBefore,
+			&i.NotAfter,
+			&i.IssuedAt,
+			&i.RevokedAt,
+			&i.DeviceName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRawCert = `-- name: GetRawCert :one
+SELECT cert, key FROM certificates WHERE id = $1 LIMIT 1
+`
+
+type GetRawCertRow struct {
+	Cert []byte `json:"cert"`
+	Key  []byte `json:"key"`
+}
+
+func (q *Queries) GetRawCert(ctx context.Context, id string) (GetRawCertRow, error) {
+	row := q.queryRow(ctx, q.getRawCertStmt, getRawCert, id)
+	var i GetRawCertRow
+	err := row.Scan(&i.Cert, &i.Key)
+	return i, err
+}
+
+const getRevokedCertificates = `-- name: GetRevokedCertificates :many
+SELECT serial, revoked_at FROM issued_certificates WHERE revoked_at IS NOT NULL AND not_after > NOW() ORDER BY revoked_at
+`
+
+type GetRevokedCertificatesRow struct {
+	Serial    string       `json:"serial"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+}
+
+// The revoked certificates which haven't expired. They are published in the SCEP CA's CRL.
+func (q *Queries) GetRevokedCertificates(ctx context.Context) ([]GetRevokedCertificatesRow, error) {
+	rows, err := q.query(ctx, q.getRevokedCertificatesStmt, getRevokedCertificates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRevokedCertificatesRow
+	for rows.Next() {
+		var i GetRevokedCertificatesRow
+		if err := rows.Scan(&i.Serial, &i.RevokedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRollout = `-- name: GetRollout :one
+SELECT id, policy_id, group_id, stage, state, failure_threshold, success_threshold, created_at FROM rollouts WHERE id = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetRollout(ctx context.Context, id int32) (Rollout, error) {
+	row := q.queryRow(ctx, q.getRolloutStmt, getRollout, id)
+	var i Rollout
+	err := row.Scan(
+		&i.ID,
+		&i.PolicyID,
+		&i.GroupID,
+		&i.Stage,
+		&i.State,
+		&i.FailureThreshold,
+		&i.SuccessThreshold,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRolloutResults = `-- name: GetRolloutResults :many
+SELECT stage, COUNT(*) AS reported, COUNT(*) FILTER (WHERE failed) AS failed FROM rollout_results WHERE rollout_id = $1 GROUP BY stage ORDER BY stage
+`
+
+type GetRolloutResultsRow struct {
+	Stage    int16 `json:"stage"`
+	Reported int64 `json:"reported"`
+	Failed   int64 `json:"failed"`
+}
+
+// Exposed via API
+func (q *Queries) GetRolloutResults(ctx context.Context, rolloutID int32) ([]GetRolloutResultsRow, error) {
+	rows, err := q.query(ctx, q.getRolloutResultsStmt, getRolloutResults, rolloutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRolloutResultsRow
+	for rows.Next() {
+		var i GetRolloutResultsRow
+		if err := rows.Scan(&i.Stage, &i.Reported, &i.Failed); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRolloutStageResults = `-- name: GetRolloutStageResults :one
+SELECT COUNT(*) AS reported, COUNT(*) FILTER (WHERE failed) AS failed FROM rollout_results WHERE rollout_id = $1 AND stage = $2
+`
+
+type GetRolloutStageResultsParams struct {
+	RolloutID int32 `json:"rollout_id"`
+	Stage     int16 `json:"stage"`
+}
+
+type GetRolloutStageResultsRow struct {
+	Reported int64 `json:"reported"`
+	Failed   int64 `json:"failed"`
+}
+
+func (q *Queries) GetRolloutStageResults(ctx context.Context, arg GetRolloutStageResultsParams) (GetRolloutStageResultsRow, error) {
+	row := q.queryRow(ctx, q.getRolloutStageResultsStmt, getRolloutStageResults, arg.RolloutID, arg.Stage)
+	var i GetRolloutStageResultsRow
+	err := row.Scan(&i.Reported, &i.Failed)
+	return i, err
+}
+
+const getRolloutStages = `-- name: GetRolloutStages :many
+SELECT stage, percentage, ring_group_id FROM rollout_stages WHERE rollout_id = $1 ORDER BY stage
+`
+
+type GetRolloutStagesRow struct {
+	Stage       int16         `json:"stage"`
+	Percentage  int16         `json:"percentage"`
+	RingGroupID sql.NullInt32 `json:"ring_group_id"`
+}
+
+// Exposed via API
+func (q *Queries) GetRolloutStages(ctx context.Context, rolloutID int32) ([]GetRolloutStagesRow, error) {
+	rows, err := q.query(ctx, q.getRolloutStagesStmt, getRolloutStages, rolloutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRolloutStagesRow
+	for rows.Next() {
+		var i GetRolloutStagesRow
+		if err := rows.Scan(&i.Stage, &i.Percentage, &i.RingGroupID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRollouts = `-- name: GetRollouts :many
+SELECT id, policy_id, group_id, stage, state, failure_threshold, success_threshold, created_at FROM rollouts ORDER BY id DESC LIMIT 100
+`
+
+// Exposed via API
+func (q *Queries) GetRollouts(ctx context.Context) ([]Rollout, error) {
+	rows, err := q.query(ctx, q.getRolloutsStmt, getRollouts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Rollout
+	for rows.Next() {
+		var i Rollout
+		if err := rows.Scan(
+			&i.ID,
+			&i.PolicyID,
+			&i.GroupID,
+			&i.Stage,
+			&i.State,
+			&i.FailureThreshold,
+			&i.SuccessThreshold,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScript = `-- name: GetScript :one
+SELECT id, name, description, content, run_context, schedule, group_id, version, created_at, updated_at FROM scripts WHERE id = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetScript(ctx context.Context, id int32) (Script, error) {
+	row := q.queryRow(ctx, q.getScriptStmt, getScript, id)
+	var i Script
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Content,
+		&i.RunContext,
+		&i.Schedule,
+		&i.GroupID,
+		&i.Version,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getScriptRuns = `-- name: GetScriptRuns :many
+SELECT script_runs.device_id, devices.name, script_runs.version, script_runs.exit_code, script_runs.stdout, script_runs.stderr, script_runs.finished_at FROM script_runs INNER JOIN devices ON devices.id = script_runs.device_id WHERE script_runs.script_id = $1 AND (NOT $2::boolean OR script_runs.exit_code != 0) ORDER BY script_runs.finished_at DESC
+`
+
+type GetScriptRunsParams struct {
+	ScriptID int32 `json:"script_id"`
+	Failed   bool  `json:"failed"`
+}
+
+type GetScriptRunsRow struct {
+	DeviceID   int32     `json:"device_id"`
+	Name       string    `json:"name"`
+	Version    int32     `json:"version"`
+	ExitCode   int32     `json:"exit_code"`
+	Stdout     string    `json:"stdout"`
+	Stderr     string    `json:"stderr"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Exposed via API
+func (q *Queries) GetScriptRuns(ctx context.Context, arg GetScriptRunsParams) ([]GetScriptRunsRow, error) {
+	rows, err := q.query(ctx, q.getScriptRunsStmt, getScriptRuns, arg.ScriptID, arg.Failed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetScriptRunsRow
+	for rows.Next() {
+		var i GetScriptRunsRow
+		if err := rows.Scan(
+			&i.DeviceID,
+			&i.Name,
+			&i.Version,
+			&i.ExitCode,
+			&i.Stdout,
+			&i.Stderr,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScripts = `-- name: GetScripts :many
+SELECT id, name, description, run_context, schedule, group_id, version, created_at, updated_at FROM scripts ORDER BY name
+`
+
+type GetScriptsRow struct {
+	ID          int32          `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	RunContext  ScriptContext  `json:"run_context"`
+	Schedule    ScriptSchedule `json:"schedule"`
+	GroupID     int32          `json:"group_id"`
+	Version     int32          `json:"version"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// Exposed via API
+func (q *Queries) GetScripts(ctx context.Context) ([]GetScriptsRow, error) {
+	rows, err := q.query(ctx, q.getScriptsStmt, getScripts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetScriptsRow
+	for rows.Next() {
+		var i GetScriptsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.RunContext,
+			&i.Schedule,
+			&i.GroupID,
+			&i.Version,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTermsOfService = `-- name: GetTermsOfService :one
+SELECT version, title, content, created_at FROM terms_of_service WHERE version = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetTermsOfService(ctx context.Context, version int32) (TermsOfService, error) {
+	row := q.queryRow(ctx, q.getTermsOfServiceStmt, getTermsOfService, version)
+	var i TermsOfService
+	err := row.Scan(
+		&i.Version,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTermsOfServiceAcceptances = `-- name: GetTermsOfServiceAcceptances :many
+SELECT users.upn, users.fullname, terms_of_service_acceptances.accepted_at FROM terms_of_service_acceptances INNER JOIN users ON users.upn = terms_of_service_acceptances.upn WHERE terms_of_service_acceptances.version = $1 ORDER BY terms_of_service_acceptances.accepted_at
+`
+
+type GetTermsOfServiceAcceptancesRow struct {
+	Upn        string    `json:"upn"`
+	Fullname   string    `json:"fullname"`
+	AcceptedAt time.Time `json:"accepted_at"`
+}
+
+// Exposed via API
+func (q *Queries) GetTermsOfServiceAcceptances(ctx context.Context, version int32) ([]GetTermsOfServiceAcceptancesRow, error) {
+	rows, err := q.query(ctx, q.getTermsOfServiceAcceptancesStmt, getTermsOfServiceAcceptances, version)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTermsOfServiceAcceptancesRow
+	for rows.Next() {
+		var i GetTermsOfServiceAcceptancesRow
+		if err := rows.Scan(&i.Upn, &i.Fullname, &i.AcceptedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTermsOfServiceReport = `-- name: GetTermsOfServiceReport :many
+SELECT DISTINCT ON (users.upn) users.upn, users.fullname, (terms_of_service_acceptances.version IS NOT NULL)::boolean AS accepted, COALESCE(terms_of_service_acceptances.version, 0)::integer AS version, COALESCE(terms_of_service_acceptances.accepted_at, to_timestamp(0))::timestamptz AS accepted_at FROM users LEFT JOIN terms_of_service_acceptances ON terms_of_service_acceptances.upn = users.upn ORDER BY users.upn, terms_of_service_acceptances.version DESC NULLS LAST
+`
+
+type GetTermsOfServiceReportRow struct {
+	Upn        string    `json:"upn"`
+	Fullname   string    `json:"fullname"`
+	Accepted   bool      `json:"accepted"`
+	Version    int32     `json:"version"`
+	AcceptedAt time.Time `json:"accepted_at"`
+}
+
+// Exposed via API
+// The version and accepted_at are only set if accepted is true
+func (q *Queries) GetTermsOfServiceReport(ctx context.Context) ([]GetTermsOfServiceReportRow, error) {
+	rows, err := q.query(ctx, q.getTermsOfServiceReportStmt, getTermsOfServiceReport)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTermsOfServiceReportRow
+	for rows.Next() {
+		var i GetTermsOfServiceReportRow
+		if err := rows.Scan(
+			&i.Upn,
+			&i.Fullname,
+			&i.Accepted,
+			&i.Version,
+			&i.AcceptedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTermsOfServiceVersions = `-- name: GetTermsOfServiceVersions :many
+SELECT version, title, created_at FROM terms_of_service ORDER BY version DESC
+`
+
+type GetTermsOfServiceVersionsRow struct {
+	Version   int32     `json:"version"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Exposed via API
+func (q *Queries) GetTermsOfServiceVersions(ctx context.Context) ([]GetTermsOfServiceVersionsRow, error) {
+	rows, err := q.query(ctx, q.getTermsOfServiceVersionsStmt, getTermsOfServiceVersions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTermsOfServiceVersionsRow
+	for rows.Next() {
+		var i GetTermsOfServiceVersionsRow
+		if err := rows.Scan(&i.Version, &i.Title, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnfinishedRollout = `-- name: GetUnfinishedRollout :one
+SELECT id FROM rollouts WHERE policy_id = $1 AND group_id = $2 AND state IN ('active', 'paused') LIMIT 1
+`
+
+type GetUnfinishedRolloutParams struct {
+	PolicyID int32 `json:"policy_id"`
+	GroupID  int32 `json:"group_id"`
+}
+
+func (q *Queries) GetUnfinishedRollout(ctx context.Context, arg GetUnfinishedRolloutParams) (int32, error) {
+	row := q.queryRow(ctx, q.getUnfinishedRolloutStmt, getUnfinishedRollout, arg.PolicyID, arg.GroupID)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT upn, fullname, azuread_oid, permission_level FROM users WHERE upn = $1 LIMIT 1
+`
+
+type GetUserRow struct {
+	Upn             string              `json:"upn"`
+	Fullname        string              `json:"fullname"`
+	AzureadOid      null.String         `json:"azuread_oid"`
+	PermissionLevel UserPermissionLevel `json:"permission_level"`
+}
+
+// Exposed via API
+func (q *Queries) GetUser(ctx context.Context, upn string) (GetUserRow, error) {
+	row := q.queryRow(ctx, q.getUserStmt, getUser, upn)
+	var i GetUserRow
+	err := row.Scan(
+		&i.Upn,
+		&i.Fullname,
+		&i.AzureadOid,
+		&i.PermissionLevel,
+	)
+	return i, err
+}
+
+const getUserForLogin = `-- name: GetUserForLogin :one
+SELECT fullname, password, mfa_token, permission_level FROM users WHERE upn = $1 LIMIT 1
+`
+
+type GetUserForLoginRow struct {
+	Fullname        string              `json:"fullname"`
+	Password        null.String         `json:"password"`
+	MfaToken        null.String         `json:"mfa_token"`
+	PermissionLevel UserPermissionLevel `json:"permission_level"`
+}
+
+func (q *Queries) GetUserForLogin(ctx context.Context, upn string) (GetUserForLoginRow, error) {
+	row := q.queryRow(ctx, q.getUserForLoginStmt, getUserForLogin, upn)
+	var i GetUserForLoginRow
+	err := row.Scan(
+		&i.Fullname,
+		&i.Password,
+		&i.MfaToken,
+		&i.PermissionLevel,
+	)
+	return i, err
+}
+
+const getUserGroup = `-- name: GetUserGroup :one
+SELECT id, name, description, priority FROM user_groups WHERE id = $1 LIMIT 1
+`
+
+// Exposed via API
+func (q *Queries) GetUserGroup(ctx context.Context, id int32) (UserGroup, error) {
+	row := q.queryRow(ctx, q.getUserGroupStmt, getUserGroup, id)
+	var i UserGroup
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Priority,
+	)
+	return i, err
+}
+
+const getUserGroupMembers = `-- name: GetUserGroupMembers :many
+SELECT users.upn, users.fullname FROM users INNER JOIN user_group_members ON user_group_members.upn=users.upn WHERE user_group_members.group_id = $1 ORDER BY users.upn
+`
+
+type GetUserGroupMembersRow struct {
+	Upn      string `json:"upn"`
+	Fullname string `json:"fullname"`
+}
+
+// Exposed via API
+func (q *Queries) GetUserGroupMembers(ctx context.Context, groupID int32) ([]GetUserGroupMembersRow, error) {
+	rows, err := q.query(ctx, q.getUserGroupMembersStmt, getUserGroupMembers, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserGroupMembersRow
+	for rows.Next() {
+		var i GetUserGroupMembersRow
+		if err := rows.Scan(&i.Upn, &i.Fullname); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserGroupPolicies = `-- name: GetUserGroupPolicies :many
+SELECT policies.id, policies.name FROM policies INNER JOIN user_group_policies ON user_group_policies.policy_id=policies.id WHERE user_group_policies.group_id = $1 ORDER BY policies.id
+`
+
+type GetUserGroupPoliciesRow struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Exposed via API
+func (q *Queries) GetUserGroupPolicies(ctx context.Context, groupID int32) ([]GetUserGroupPoliciesRow, error) {
+	rows, err := q.query(ctx, q.getUserGroupPoliciesStmt, getUserGroupPolicies, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserGroupPoliciesRow
+	for rows.Next() {
+		var i GetUserGroupPoliciesRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserGroups = `-- name: GetUserGroups :many
+SELECT id, name, description, priority FROM user_groups LIMIT 100
+`
+
+// Exposed via API
+func (q *Queries) GetUserGroups(ctx context.Context) ([]UserGroup, error) {
+	rows, err := q.query(ctx, q.getUserGroupsStmt, getUserGroups)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserGroup
+	for rows.Next() {
+		var i UserGroup
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Priority,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsers = `-- name: GetUsers :many
+
+SELECT upn, fullname, permission_level FROM users LIMIT 100
+`
+
+type GetUsersRow struct {
+	Upn             string              `json:"upn"`
+	Fullname        string              `json:"fullname"`
+	PermissionLevel UserPermissionLevel `json:"permission_level"`
+}
+
+// DO NOT RUN THIS FILE. It is used along with sqlc to generate type safe Go from SQL
+// Exposed via API
+func (q *Queries) GetUsers(ctx context.Context) ([]GetUsersRow, error) {
+	rows, err := q.query(ctx, q.getUsersStmt, getUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersRow
+	for rows.Next() {
+		var i GetUsersRow
+		if err := rows.Scan(&i.Upn, &i.Fullname, &i.PermissionLevel); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersPayloadCandidates = `-- name: GetUsersPayloadCandidates :many
+SELECT policies_payload.id, policies_payload.uri, policies_payload.format, policies_payload.type, policies_payload.value, policies_payload.exec, policies.id AS policy_id, policies.name AS policy_name, policies.priority AS policy_priority, user_groups.id AS group_id, user_groups.name AS group_name, user_groups.priority AS group_priority FROM user_group_members INNER JOIN user_groups ON user_groups.id=user_group_members.group_id INNER JOIN user_group_policies ON user_group_policies.group_id=user_groups.id INNER JOIN policies ON policies.id=user_group_policies.policy_id INNER JOIN policies_payload ON policies_payload.policy_id=policies.id WHERE user_group_members.upn = $1
+`
+
+type GetUsersPayloadCandidatesRow struct {
+	ID             int32  `json:"id"`
+	Uri            string `json:"uri"`
+	Format         string `json:"format"`
+	Type           string `json:"type"`
+	Value          string `json:"value"`
+	Exec           bool   `json:"exec"`
+	PolicyID       int32  `json:"policy_id"`
+	PolicyName     string `json:"policy_name"`
+	PolicyPriority int16  `json:"policy_priority"`
+	GroupID        int32  `json:"group_id"`
+	GroupName      string `json:"group_name"`
+	GroupPriority  int16  `json:"group_priority"`
+}
+
+func (q *Queries) GetUsersPayloadCandidates(ctx context.Context, upn string) ([]GetUsersPayloadCandidatesRow, error) {
+	rows, err := q.query(ctx, q.getUsersPayloadCandidatesStmt, getUsersPayloadCandidates, upn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersPayloadCandidatesRow
+	for rows.Next() {
+		var i GetUsersPayloadCandidatesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uri,
+			&i.Format,
+			&i.Type,
+			&i.Value,
+			&i.Exec,
+			&i.PolicyID,
+			&i.PolicyName,
+			&i.PolicyPriority,
+			&i.GroupID,
+			&i.GroupName,
+			&i.GroupPriority,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hasAcceptedTermsOfService = `-- name: HasAcceptedTermsOfService :one
+SELECT EXISTS(SELECT 1 FROM terms_of_service_acceptances WHERE upn = $1 AND version = $2)
+`
+
+type HasAcceptedTermsOfServiceParams struct {
+	Upn     string `json:"upn"`
+	Version int32  `json:"version"`
+}
+
+func (q *Queries) HasAcceptedTermsOfService(ctx context.Context, arg HasAcceptedTermsOfServiceParams) (bool, error) {
+	row := q.queryRow(ctx, q.hasAcceptedTermsOfServiceStmt, hasAcceptedTermsOfService, arg.Upn, arg.Version)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const incrementPolicyVersion = `-- name: IncrementPolicyVersion :one
+UPDATE policies SET version=version+1 WHERE id = $1 RETURNING version
+`
+
+func (q *Queries) IncrementPolicyVersion(ctx context.Context, id int32) (int32, error) {
+	row := q.queryRow(ctx, q.incrementPolicyVersionStmt, incrementPolicyVersion, id)
+	var version int32
+	err := row.Scan(&version)
+	return version, err
+}
+
+const installedDeviceApps = `-- name: InstalledDeviceApps :exec
+UPDATE app_installs SET state='installed', status='', updated_at=NOW() FROM apps WHERE apps.id = app_installs.app_id AND app_installs.device_id = $1 AND app_installs.state IN ('installing', 'failed') AND apps.type IN ('store', 'appx') AND apps.identifier = ANY($2::text[])
+`
+
+type InstalledDeviceAppsParams struct {
+	DeviceID           int32    `json:"device_id"`
+	PackageFamilyNames []string `json:"package_family_names"`
+}
+
+// Marks the modern apps being installed on the device which it reported in its inventory as installed
+func (q *Queries) InstalledDeviceApps(ctx context.Context, arg InstalledDeviceAppsParams) error {
+	_, err := q.exec(ctx, q.installedDeviceAppsStmt, installedDeviceApps, arg.DeviceID, pq.Array(arg.PackageFamilyNames))
+	return err
+}
+
+const invalidatePayloadCache = `-- name: InvalidatePayloadCache :exec
+DELETE FROM device_cache WHERE payload_id = $1
+`
+
+func (q *Queries) InvalidatePayloadCache(ctx context.Context, payloadID sql.NullInt32) error {
+	_, err := q.exec(ctx, q.invalidatePayloadCacheStmt, invalidatePayloadCache, payloadID)
+	return err
+}
+
+const isDeviceInGroup = `-- name: IsDeviceInGroup :one
+SELECT EXISTS(SELECT 1 FROM group_devices INNER JOIN groups ON groups.id=group_devices.group_id WHERE group_devices.device_id = $1 AND groups.name = $2)
+`
+
+type IsDeviceInGroupParams struct {
+	DeviceID int32  `json:"device_id"`
+	Name     string `json:"name"`
+}
+
+func (q *Queries) IsDeviceInGroup(ctx context.Context, arg IsDeviceInGroupParams) (bool, error) {
+	row := q.queryRow(ctx, q.isDeviceInGroupStmt, isDeviceInGroup, arg.DeviceID, arg.Name)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isUserInGroup = `-- name: IsUserInGroup :one
+SELECT EXISTS(SELECT 1 FROM user_group_members INNER JOIN user_groups ON user_groups.id=user_group_members.group_id WHERE user_group_members.upn = $1 AND user_groups.name = $2)
+`
+
+type IsUserInGroupParams struct {
+	Upn  string `json:"upn"`
+	Name string `json:"name"`
+}
+
+func (q *Queries) IsUserInGroup(ctx context.Context, arg IsUserInGroupParams) (bool, error) {
+	row := q.queryRow(ctx, q.isUserInGroupStmt, isUserInGroup, arg.Upn, arg.Name)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const localAdminPasswordFailed = `-- name: LocalAdminPasswordFailed :exec
+UPDATE local_admin_passwords SET last_error=$3 WHERE device_id = $1 AND profile_id = $2 AND pending_encrypted_password IS NOT NULL
+`
+
+type LocalAdminPasswordFailedParams struct {
+	DeviceID  int32  `json:"device_id"`
+	ProfileID int32  `json:"profile_id"`
+	LastError string `json:"last_error"`
+}
+
+// The pending password is kept as the device may have set it and it is resent in the next session
+func (q *Queries) LocalAdminPasswordFailed(ctx context.Context, arg LocalAdminPasswordFailedParams) error {
+	_, err := q.exec(ctx, q.localAdminPasswordFailedStmt, localAdminPasswordFailed, arg.DeviceID, arg.ProfileID, arg.LastError)
+	return err
+}
+
+const lockDeviceNames = `-- name: LockDeviceNames :exec
+SELECT pg_advisory_xact_lock(hashtext('device_names'))
+`
+
+// The lock is held until the transaction ends so concurrent enrollments and renames can't choose the same name
+func (q *Queries) LockDeviceNames(ctx context.Context) error {
+	_, err := q.exec(ctx, q.lockDeviceNamesStmt, lockDeviceNames)
+	return err
+}
+
+const logBitLockerRecoveryKeyAccess = `-- name: LogBitLockerRecoveryKeyAccess :exec
+INSERT INTO bitlocker_recovery_key_access(device_id, protector_id, upn, reason) VALUES ($1, $2, $3, $4)
+`
+
+type LogBitLockerRecoveryKeyAccessParams struct {
+	DeviceID    int32  `json:"device_id"`
+	ProtectorID string `json:"protector_id"`
+	Upn         string `json:"upn"`
+	Reason      string `json:"reason"`
+}
+
+func (q *Queries) LogBitLockerRecoveryKeyAccess(ctx context.Context, arg LogBitLockerRecoveryKeyAccessParams) error {
+	_, err := q.exec(ctx, q.logBitLockerRecoveryKeyAccessStmt, logBitLockerRecoveryKeyAccess,
+		arg.DeviceID,
+		arg.ProtectorID,
+		arg.Upn,
+		arg.Reason,
+	)
+	return err
+}
+
+const logLocalAdminPasswordAccess = `-- name: LogLocalAdminPasswordAccess :exec
+INSERT INTO local_admin_password_access(device_id, profile_id, account_name, upn, reason) VALUES ($1, $2, $3, $4, $5)
+`
+
+type LogLocalAdminPasswordAccessParams struct {
+	DeviceID    int32  `json:"device_id"`
+	ProfileID   int32  `json:"profile_id"`
+	AccountName string `json:"account_name"`
+	Upn         string `json:"upn"`
+	Reason      string `json:"reason"`
+}
+
+func (q *Queries) LogLocalAdminPasswordAccess(ctx context.Context, arg LogLocalAdminPasswordAccessParams) error {
+	_, err := q.exec(ctx, q.logLocalAdminPasswordAccessStmt, logLocalAdminPasswordAccess,
+		arg.DeviceID,
+		arg.ProfileID,
+		arg.AccountName,
+		arg.Upn,
+		arg.Reason,
+	)
+	return err
+}
+
+const newAzureADUser = `-- name: NewAzureADUser :one
+INSERT INTO users(upn, fullname, azuread_oid) VALUES($1, $2, $3) ON CONFLICT (upn) DO UPDATE SET fullname=$2, azuread_oid=$3 WHERE users.azuread_oid IS NOT NULL RETURNING upn, fullname, azuread_oid, permission_level
+`
+
+type NewAzureADUserParams struct {
+	Upn        string      `json:"upn"`
+	Fullname   string      `json:"fullname"`
+	AzureadOid null.String `json:"azuread_oid"`
+}
+
+type NewAzureADUserRow struct {
+	Upn             string              `json:"upn"`
+	Fullname        string              `json:"fullname"`
+	AzureadOid      null.String         `json:"azuread_oid"`
+	PermissionLevel UserPermissionLevel `json:"permission_level"`
+}
+
+// Existing users are only updated if they are AzureAD users so no row is returned for a local user with the same upn
+func (q *Queries) NewAzureADUser(ctx context.Context, arg NewAzureADUserParams) (NewAzureADUserRow, error) {
+	row := q.queryRow(ctx, q.newAzureADUserStmt, newAzureADUser, arg.Upn, arg.Fullname, arg.AzureadOid)
+	var i NewAzureADUserRow
+	err := row.Scan(
+		&i.Upn,
+		&i.Fullname,
+		&i.AzureadOid,
+		&i.PermissionLevel,
+	)
+	return i, err
+}
+
+const newDevice = `-- name: NewDevice :one
+
+INSERT INTO devices(udid, state, enrollment_type, name, hw_dev_id, operating_system, azure_did, enrolled_by) VALUES($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (udid) DO NOTHING RETURNING id
+`
+
+type NewDeviceParams struct {
+	Udid            string         `json:"udid"`
+	State           DeviceState    `json:"state"`
+	EnrollmentType  EnrollmentType `json:"enrollment_type"`
+	Name            string         `json:"name"`
+	HwDevID         string         `json:"hw_dev_id"`
+	OperatingSystem string         `json:"operating_system"`
+	AzureDid        null.String    `json:"azure_did"`
+	EnrolledBy      null.String    `json:"enrolled_by"`
+}
+
+// TODO: Merge all NewDevice functions to single query
+// A device enrolled concurrently with the same udid returns no rows
+func (q *Queries) NewDevice(ctx context.Context, arg NewDeviceParams) (int32, error) {
+	row := q.queryRow(ctx, q.newDeviceStmt, newDevice,
+		arg.Udid,
+		arg.State,
+		arg.EnrollmentType,
+		arg.Name,
+		arg.HwDevID,
+		arg.OperatingSystem,
+		arg.AzureDid,
+		arg.EnrolledBy,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const newDeviceCacheNode = `-- name: NewDeviceCacheNode :one
+INSERT INTO device_cache(device_id, payload_id, upn) VALUES ($1, $2, $3) RETURNING cache_id
+`
+
+type NewDeviceCacheNodeParams struct {
+	DeviceID  int32         `json:"device_id"`
+	PayloadID sql.NullInt32 `json:"payload_id"`
+	Upn       null.String   `json:"upn"`
+}
+
+func (q *Queries) NewDeviceCacheNode(ctx context.Context, arg NewDeviceCacheNodeParams) (int32, error) {
+	row := q.queryRow(ctx, q.newDeviceCacheNodeStmt, newDeviceCacheNode, arg.DeviceID, arg.PayloadID, arg.Upn)
+	var cache_id int32
+	err := row.Scan(&cache_id)
+	return cache_id, err
+}
+
+const newDeviceCommand = `-- name: NewDeviceCommand :exec
+INSERT INTO device_commands(device_id, command, uri, format, value) VALUES ($1, $2, $3, $4, $5)
+`
+
+type NewDeviceCommandParams struct {
+	DeviceID int32  `json:"device_id"`
+	Command  string `json:"command"`
+	Uri      string `json:"uri"`
+	Format   string `json:"format"`
+	Value    string `json:"value"`
+}
+
+func (q *Queries) NewDeviceCommand(ctx context.Context, arg NewDeviceCommandParams) error {
+	_, err := q.exec(ctx, q.newDeviceCommandStmt, newDeviceCommand,
+		arg.DeviceID,
+		arg.Command,
+		arg.Uri,
+		arg.Format,
+		arg.Value,
+	)
+	return err
+}
+
+const newDeviceReplacingExisting = `-- name: NewDeviceReplacingExisting :exec
+UPDATE devices SET state=$2, enrollment_type=$3, name=$4, hw_dev_id=$5, operating_system=$6, azure_did=$7, nodecache_version='', lastseen=NOW(), lastseen_status=0, enrolled_at=NOW(), enrolled_by=$8 WHERE udid = $1
+`
+
+type NewDeviceReplacingExistingParams struct {
+	Udid            string         `json:"udid"`
+	State           DeviceState    `json:"state"`
+	EnrollmentType  EnrollmentType `json:"enrollment_type"`
+	Name            string         `json:"name"`
+	HwDevID         string         `json:"hw_dev_id"`
+	OperatingSystem string         `json:"operating_system"`
+	AzureDid        null.String    `json:"azure_did"`
+	EnrolledBy      null.String    `json:"enrolled_by"`
+}
+
+func (q *Queries) NewDeviceReplacingExisting(ctx context.Context, arg NewDeviceReplacingExistingParams) error {
+	_, err := q.exec(ctx, q.newDeviceReplacingExistingStmt, newDeviceReplacingExisting,
+		arg.Udid,
+		arg.State,
+		arg.EnrollmentType,
+		arg.Name,
+		arg.HwDevID,
+		arg.OperatingSystem,
+		arg.AzureDid,
+		arg.EnrolledBy,
+	)
+	return err
+}
+
+const newDeviceReplacingExistingResetCache = `-- name: NewDeviceReplacingExistingResetCache :exec
+DELETE FROM device_cache WHERE device_id=$1
+`
+
+func (q *Queries) NewDeviceReplacingExistingResetCache(ctx context.Context, deviceID int32) error {
+	_, err := q.exec(ctx, q.newDeviceReplacingExistingResetCacheStmt, newDeviceReplacingExistingResetCache, deviceID)
+	return err
+}
+
+const newDeviceReplacingExistingResetCommands = `-- name: NewDeviceReplacingExistingResetCommands :exec
+DELETE FROM device_commands WHERE device_id=$1
+`
+
+func (q *Queries) NewDeviceReplacingExistingResetCommands(ctx context.Context, deviceID int32) error {
+	_, err := q.exec(ctx, q.newDeviceReplacingExistingResetCommandsStmt, newDeviceReplacingExistingResetCommands, deviceID)
+	return err
+}
+
+const newDeviceReplacingExistingResetInventory = `-- name: NewDeviceReplacingExistingResetInventory :exec
+DELETE FROM device_inventory WHERE device_id=$1
+`
+
+func (q *Queries) NewDeviceReplacingExistingResetInventory(ctx context.Context, deviceID int32) error {
+	_, err := q.exec(ctx, q.newDeviceReplacingExistingResetInventoryStmt, newDeviceReplacingExistingResetInventory, deviceID)
+	return err
+}
+
+const newEnrollmentAttempt = `-- name: NewEnrollmentAttempt :exec
+INSERT INTO enrollment_attempts(trace_id, endpoint, message_id, upn, udid, hw_dev_id, device_id, result, fault_code, fault_type, fault_reason, error) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+`
+
+type NewEnrollmentAttemptParams struct {
+	TraceID     string                  `json:"trace_id"`
+	Endpoint    string                  `json:"endpoint"`
+	MessageID   string                  `json:"message_id"`
+	Upn         string                  `json:"upn"`
+	Udid        string                  `json:"udid"`
+	HwDevID     string                  `json:"hw_dev_id"`
+	DeviceID    sql.NullInt32           `json:"device_id"`
+	Result      EnrollmentAttemptResult `json:"result"`
+	FaultCode   string                  `json:"fault_code"`
+	FaultType   string                  `json:"fault_type"`
+	FaultReason string                  `json:"fault_reason"`
+	Error       string                  `json:"error"`
+}
+
+func (q *Queries) NewEnrollmentAttempt(ctx context.Context, arg NewEnrollmentAttemptParams) error {
+	_, err := q.exec(ctx, q.newEnrollmentAttemptStmt, newEnrollmentAttempt,
+		arg.TraceID,
+		arg.Endpoint,
+		arg.MessageID,
+		arg.Upn,
+		arg.Udid,
+		arg.HwDevID,
+		arg.DeviceID,
+		arg.Result,
+		arg.FaultCode,
+		arg.FaultType,
+		arg.FaultReason,
+		arg.Error,
+	)
+	return err
+}
+
+const promoteRollout = `-- name: PromoteRollout :exec
+UPDATE rollouts SET stage=stage+1, state='active' WHERE id = $1
+`
+
+func (q *Queries) PromoteRollout(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.promoteRolloutStmt, promoteRollout, id)
+	return err
+}
+
+const pruneEnrollmentAttempts = `-- name: PruneEnrollmentAttempts :exec
+DELETE FROM enrollment_attempts WHERE enrollment_attempts.created_at < $1 OR enrollment_attempts.trace_id IN (SELECT ranked.trace_id FROM enrollment_attempts AS ranked ORDER BY ranked.created_at DESC OFFSET $2)
+`
+
+type PruneEnrollmentAttemptsParams struct {
+	CreatedBefore time.Time `json:"created_before"`
+	Keep          int32     `json:"keep"`
+}
+
+// Attempts older than the retention are deleted and only the newest attempts are kept so failing requests can't grow the table without bound
+func (q *Queries) PruneEnrollmentAttempts(ctx context.Context, arg PruneEnrollmentAttemptsParams) error {
+	_, err := q.exec(ctx, q.pruneEnrollmentAttemptsStmt, pruneEnrollmentAttempts, arg.CreatedBefore, arg.Keep)
+	return err
+}
+
+const recordRolloutResult = `-- name: RecordRolloutResult :exec
+INSERT INTO rollout_results(rollout_id, device_id, stage, status, failed) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (rollout_id, device_id, stage) DO UPDATE SET status=CASE WHEN rollout_results.failed THEN rollout_results.status ELSE EXCLUDED.status END, failed=rollout_results.failed OR EXCLUDED.failed, updated_at=NOW()
+`
+
+type RecordRolloutResultParams struct {
+	RolloutID int32 `json:"rollout_id"`
+	DeviceID  int32 `json:"device_id"`
+	Stage     int16 `json:"stage"`
+	Status    int32 `json:"status"`
+	Failed    bool  `json:"failed"`
+}
+
+// A device which failed any of the policies payloads remains failed for the stage
+func (q *Queries) RecordRolloutResult(ctx context.Context, arg RecordRolloutResultParams) error {
+	_, err := q.exec(ctx, q.recordRolloutResultStmt, recordRolloutResult,
+		arg.RolloutID,
+		arg.DeviceID,
+		arg.Stage,
+		arg.Status,
+		arg.Failed,
+	)
+	return err
+}
+
+const recordScriptRun = `-- name: RecordScriptRun :exec
+INSERT INTO script_runs(script_id, device_id, version, exit_code, stdout, stderr) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (script_id, device_id) DO UPDATE SET version=EXCLUDED.version, exit_code=EXCLUDED.exit_code, stdout=EXCLUDED.stdout, stderr=EXCLUDED.stderr, finished_at=NOW()
+`
+
+type RecordScriptRunParams struct {
+	ScriptID int32  `json:"script_id"`
+	DeviceID int32  `json:"device_id"`
+	Version  int32  `json:"version"`
+	ExitCode int32  `json:"exit_code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+}
+
+func (q *Queries) RecordScriptRun(ctx context.Context, arg RecordScriptRunParams) error {
+	_, err := q.exec(ctx, q.recordScriptRunStmt, recordScriptRun,
+		arg.ScriptID,
+		arg.DeviceID,
+		arg.Version,
+		arg.ExitCode,
+		arg.Stdout,
+		arg.Stderr,
+	)
+	return err
+}
+
+const removeBitLockerRecoveryKey = `-- name: RemoveBitLockerRecoveryKey :exec
+UPDATE bitlocker_recovery_keys SET removed_at=NOW() WHERE device_id = $1 AND protector_id = $2 AND removed_at IS NULL
+`
+
+type RemoveBitLockerRecoveryKeyParams struct {
+	DeviceID    int32  `json:"device_id"`
+	ProtectorID string `json:"protector_id"`
+}
+
+func (q *Queries) RemoveBitLockerRecoveryKey(ctx context.Context, arg RemoveBitLockerRecoveryKeyParams) error {
+	_, err := q.exec(ctx, q.removeBitLockerRecoveryKeyStmt, removeBitLockerRecoveryKey, arg.DeviceID, arg.ProtectorID)
+	return err
+}
+
+const removeGroupDevices = `-- name: RemoveGroupDevices :exec
+DELETE FROM group_devices WHERE group_id = $1 AND device_id = ANY($2::integer[])
+`
+
+type RemoveGroupDevicesParams struct {
+	GroupID   int32   `json:"group_id"`
+	DeviceIds []int32 `json:"device_ids"`
+}
+
+// Exposed via API
+func (q *Queries) RemoveGroupDevices(ctx context.Context, arg RemoveGroupDevicesParams) error {
+	_, err := q.exec(ctx, q.removeGroupDevicesStmt, removeGroupDevices, arg.GroupID, pq.Array(arg.DeviceIds))
+	return err
+}
+
+const removeUserGroupMembers = `-- name: RemoveUserGroupMembers :exec
+DELETE FROM user_group_members WHERE group_id = $1 AND upn = ANY($2::text[])
 `
 
-type GetUsersRow struct {
-	Upn             string              `json:"upn"`
-	Fullname        string              `json:"fullname"`
-	PermissionLevel UserPermissionLevel `json:"permission_level"`
+type RemoveUserGroupMembersParams struct {
+	GroupID int32    `json:"group_id"`
+	Upns    []string `json:"upns"`
 }
 
-// DO NOT RUN THIS FILE. It is used along with sqlc to generate type safe Go from SQL
 // Exposed via API
-func (q *Queries) GetUsers(ctx context.Context) ([]GetUsersRow, error) {
-	rows, err := q.query(ctx, q.getUsersStmt, getUsers)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetUsersRow
-	for rows.Next() {
-		var i GetUsersRow
-		if err := rows.Scan(&i.Upn, &i.Fullname, &i.PermissionLevel); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) RemoveUserGroupMembers(ctx context.Context, arg RemoveUserGroupMembersParams) error {
+	_, err := q.exec(ctx, q.removeUserGroupMembersStmt, removeUserGroupMembers, arg.GroupID, pq.Array(arg.Upns))
+	return err
 }
 
-const newAzureADUser = `-- name: NewAzureADUser :one
-INSERT INTO users(upn, fullname, azuread_oid) VALUES($1, $2, $3) RETURNING upn, fullname, azuread_oid, permission_level
+const requestBitLockerRotation = `-- name: RequestBitLockerRotation :exec
+INSERT INTO bitlocker_rotations(device_id, requested_by) VALUES ($1, $2) ON CONFLICT (device_id) DO UPDATE SET requested_by=EXCLUDED.requested_by, requested_at=NOW()
 `
 
-type NewAzureADUserParams struct {
-	Upn        string      `json:"upn"`
-	Fullname   string      `json:"fullname"`
-	AzureadOid null.String `json:"azuread_oid"`
+type RequestBitLockerRotationParams struct {
+	DeviceID    int32  `json:"device_id"`
+	RequestedBy string `json:"requested_by"`
 }
 
-type NewAzureADUserRow struct {
-	Upn             string              `json:"upn"`
-	Fullname        string              `json:"fullname"`
-	AzureadOid      null.String         `json:"azuread_oid"`
-	PermissionLevel UserPermissionLevel `json:"permission_level"`
+func (q *Queries) RequestBitLockerRotation(ctx context.Context, arg RequestBitLockerRotationParams) error {
+	_, err := q.exec(ctx, q.requestBitLockerRotationStmt, requestBitLockerRotation, arg.DeviceID, arg.RequestedBy)
+	return err
 }
 
-func (q *Queries) NewAzureADUser(ctx context.Context, arg NewAzureADUserParams) (NewAzureADUserRow, error) {
-	row := q.queryRow(ctx, q.newAzureADUserStmt, newAzureADUser, arg.Upn, arg.Fullname, arg.AzureadOid)
-	var i NewAzureADUserRow
-	err := row.Scan(
-		&i.Upn,
-		&i.Fullname,
-		&i.AzureadOid,
-		&i.PermissionLevel,
-	)
-	return i, err
+const restoreBitLockerRecoveryKey = `-- name: RestoreBitLockerRecoveryKey :exec
+UPDATE bitlocker_recovery_keys SET removed_at=NULL WHERE device_id = $1 AND protector_id = $2
+`
+
+type RestoreBitLockerRecoveryKeyParams struct {
+	DeviceID    int32  `json:"device_id"`
+	ProtectorID string `json:"protector_id"`
 }
 
-const newDevice = `-- name: NewDevice :one
+func (q *Queries) RestoreBitLockerRecoveryKey(ctx context.Context, arg RestoreBitLockerRecoveryKeyParams) error {
+	_, err := q.exec(ctx, q.restoreBitLockerRecoveryKeyStmt, restoreBitLockerRecoveryKey, arg.DeviceID, arg.ProtectorID)
+	return err
+}
+
+const revokeIssuedCertificate = `-- name: RevokeIssuedCertificate :exec
+UPDATE issued_certificates SET revoked_at=NOW() WHERE serial = $1 AND revoked_at IS NULL
+`
 
+func (q *Queries) RevokeIssuedCertificate(ctx context.Context, serial string) error {
+	_, err := q.exec(ctx, q.revokeIssuedCertificateStmt, revokeIssuedCertificate, serial)
+	return err
+}
 
-INSERT INTO devices(udid, state, enrollment_type, name, hw_dev_id, operating_system, azure_did, enrolled_by) VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id
+const scheduleLocalAdminRotation = `-- name: ScheduleLocalAdminRotation :exec
+UPDATE local_admin_passwords SET rotate_at=LEAST(rotate_at, $3) WHERE device_id = $1 AND profile_id = $2
 `
 
-type NewDeviceParams struct {
-	Udid            string         `json:"udid"`
-	State           DeviceState    `json:"state"`
-	EnrollmentType  EnrollmentType `json:"enrollment_type"`
-	Name            string         `json:"name"`
-	HwDevID         string         `json:"hw_dev_id"`
-	OperatingSystem string         `json:"operating_system"`
-	AzureDid        null.String    `json:"azure_did"`
-	EnrolledBy      null.String    `json:"enrolled_by"`
+type ScheduleLocalAdminRotationParams struct {
+	DeviceID  int32        `json:"device_id"`
+	ProfileID int32        `json:"profile_id"`
+	RotateAt  sql.NullTime `json:"rotate_at"`
 }
 
-// TODO: Insert or Update
-// TODO: Merge all NewDevice functions to single query
-func (q *Queries) NewDevice(ctx context.Context, arg NewDeviceParams) (int32, error) {
-	row := q.queryRow(ctx, q.newDeviceStmt, newDevice,
-		arg.Udid,
-		arg.State,
-		arg.EnrollmentType,
-		arg.Name,
-		arg.HwDevID,
-		arg.OperatingSystem,
-		arg.AzureDid,
-		arg.EnrolledBy,
-	)
-	var id int32
-	err := row.Scan(&id)
-	return id, err
+// The earlier of the scheduled rotation and the new rotation is kept
+func (q *Queries) ScheduleLocalAdminRotation(ctx context.Context, arg ScheduleLocalAdminRotationParams) error {
+	_, err := q.exec(ctx, q.scheduleLocalAdminRotationStmt, scheduleLocalAdminRotation, arg.DeviceID, arg.ProfileID, arg.RotateAt)
+	return err
 }
 
-const newDeviceCacheNode = `-- name: NewDeviceCacheNode :one
-INSERT INTO device_cache(device_id, payload_id) VALUES ($1, $2) RETURNING cache_id
+const setAppAssignment = `-- name: SetAppAssignment :exec
+INSERT INTO app_assignments(app_id, group_id, intent) VALUES ($1, $2, $3) ON CONFLICT (app_id, group_id) DO UPDATE SET intent=EXCLUDED.intent
 `
 
-type NewDeviceCacheNodeParams struct {
-	DeviceID  int32         `json:"device_id"`
-	PayloadID sql.NullInt32 `json:"payload_id"`
+type SetAppAssignmentParams struct {
+	AppID   int32     `json:"app_id"`
+	GroupID int32     `json:"group_id"`
+	Intent  AppIntent `json:"intent"`
 }
 
-func (q *Queries) NewDeviceCacheNode(ctx context.Context, arg NewDeviceCacheNodeParams) (int32, error) {
-	row := q.queryRow(ctx, q.newDeviceCacheNodeStmt, newDeviceCacheNode, arg.DeviceID, arg.PayloadID)
-	var cache_id int32
-	err := row.Scan(&cache_id)
-	return cache_id, err
+func (q *Queries) SetAppAssignment(ctx context.Context, arg SetAppAssignmentParams) error {
+	_, err := q.exec(ctx, q.setAppAssignmentStmt, setAppAssignment, arg.AppID, arg.GroupID, arg.Intent)
+	return err
 }
 
-const newDeviceReplacingExisting = `-- name: NewDeviceReplacingExisting :exec
-UPDATE devices SET state=$2, enrollment_type=$3, name=$4, hw_dev_id=$5, operating_system=$6, azure_did=$7, nodecache_version='', lastseen=NOW(), lastseen_status=0, enrolled_at=NOW(), enrolled_by=$8 WHERE udid = $1
+const setAppInstallState = `-- name: SetAppInstallState :exec
+INSERT INTO app_installs(app_id, device_id, state, status) VALUES ($1, $2, $3, $4) ON CONFLICT (app_id, device_id) DO UPDATE SET state=EXCLUDED.state, status=EXCLUDED.status, updated_at=NOW()
 `
 
-type NewDeviceReplacingExistingParams struct {
-	Udid            string         `json:"udid"`
-	State           DeviceState    `json:"state"`
-	EnrollmentType  EnrollmentType `json:"enrollment_type"`
-	Name            string         `json:"name"`
-	HwDevID         string         `json:"hw_dev_id"`
-	OperatingSystem string         `json:"operating_system"`
-	AzureDid        null.String    `json:"azure_did"`
-	EnrolledBy      null.String    `json:"enrolled_by"`
+type SetAppInstallStateParams struct {
+	AppID    int32           `json:"app_id"`
+	DeviceID int32           `json:"device_id"`
+	State    AppInstallState `json:"state"`
+	Status   string          `json:"status"`
 }
 
-func (q *Queries) NewDeviceReplacingExisting(ctx context.Context, arg NewDeviceReplacingExistingParams) error {
-	_, err := q.exec(ctx, q.newDeviceReplacingExistingStmt, newDeviceReplacingExisting,
-		arg.Udid,
+func (q *Queries) SetAppInstallState(ctx context.Context, arg SetAppInstallStateParams) error {
+	_, err := q.exec(ctx, q.setAppInstallStateStmt, setAppInstallState,
+		arg.AppID,
+		arg.DeviceID,
 		arg.State,
-		arg.EnrollmentType,
-		arg.Name,
-		arg.HwDevID,
-		arg.OperatingSystem,
-		arg.AzureDid,
-		arg.EnrolledBy,
+		arg.Status,
 	)
 	return err
 }
 
-const newDeviceReplacingExistingResetCache = `-- name: NewDeviceReplacingExistingResetCache :exec
-DELETE FROM device_cache WHERE device_id=$1
+const setCertificateChallenge = `-- name: SetCertificateChallenge :exec
+INSERT INTO certificate_challenges(device_id, profile_id, upn, challenge_hash, subject) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (device_id, profile_id, upn) DO UPDATE SET challenge_hash=EXCLUDED.challenge_hash, subject=EXCLUDED.subject, created_at=NOW()
 `
 
-func (q *Queries) NewDeviceReplacingExistingResetCache(ctx context.Context, deviceID int32) error {
-	_, err := q.exec(ctx, q.newDeviceReplacingExistingResetCacheStmt, newDeviceReplacingExistingResetCache, deviceID)
+type SetCertificateChallengeParams struct {
+	DeviceID      int32  `json:"device_id"`
+	ProfileID     int32  `json:"profile_id"`
+	Upn           string `json:"upn"`
+	ChallengeHash string `json:"challenge_hash"`
+	Subject       string `json:"subject"`
+}
+
+func (q *Queries) SetCertificateChallenge(ctx context.Context, arg SetCertificateChallengeParams) error {
+	_, err := q.exec(ctx, q.setCertificateChallengeStmt, setCertificateChallenge,
+		arg.DeviceID,
+		arg.ProfileID,
+		arg.Upn,
+		arg.ChallengeHash,
+		arg.Subject,
+	)
 	return err
 }
 
-const newDeviceReplacingExistingResetInventory = `-- name: NewDeviceReplacingExistingResetInventory :exec
-DELETE FROM device_cache WHERE device_id=$1
+const setDeviceName = `-- name: SetDeviceName :exec
+UPDATE devices SET name=$2 WHERE id = $1
 `
 
-func (q *Queries) NewDeviceReplacingExistingResetInventory(ctx context.Context, deviceID int32) error {
-	_, err := q.exec(ctx, q.newDeviceReplacingExistingResetInventoryStmt, newDeviceReplacingExistingResetInventory, deviceID)
+type SetDeviceNameParams struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+func (q *Queries) SetDeviceName(ctx context.Context, arg SetDeviceNameParams) error {
+	_, err := q.exec(ctx, q.setDeviceNameStmt, setDeviceName, arg.ID, arg.Name)
+	return err
+}
+
+const setDeviceNaming = `-- name: SetDeviceNaming :exec
+UPDATE settings SET device_name_template=$1, device_name_prefix=$2
+`
+
+type SetDeviceNamingParams struct {
+	DeviceNameTemplate string `json:"device_name_template"`
+	DeviceNamePrefix   string `json:"device_name_prefix"`
+}
+
+func (q *Queries) SetDeviceNaming(ctx context.Context, arg SetDeviceNamingParams) error {
+	_, err := q.exec(ctx, q.setDeviceNamingStmt, setDeviceNaming, arg.DeviceNameTemplate, arg.DeviceNamePrefix)
 	return err
 }
 
@@ -761,8 +4844,101 @@ func (q *Queries) SetDeviceState(ctx context.Context, arg SetDeviceStateParams)
 	return err
 }
 
+const setEnrollmentBranding = `-- name: SetEnrollmentBranding :exec
+INSERT INTO enrollment_branding(language, application_name, title, body_template, support_url, logo_url, support_text) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (language) DO UPDATE SET application_name=$2, title=$3, body_template=$4, support_url=$5, logo_url=$6, support_text=$7
+`
+
+type SetEnrollmentBrandingParams struct {
+	Language        string `json:"language"`
+	ApplicationName string `json:"application_name"`
+	Title           string `json:"title"`
+	BodyTemplate    string `json:"body_template"`
+	SupportUrl      string `json:"support_url"`
+	LogoUrl         string `json:"logo_url"`
+	SupportText     string `json:"support_text"`
+}
+
+// Exposed via API
+func (q *Queries) SetEnrollmentBranding(ctx context.Context, arg SetEnrollmentBrandingParams) error {
+	_, err := q.exec(ctx, q.setEnrollmentBrandingStmt, setEnrollmentBranding,
+		arg.Language,
+		arg.ApplicationName,
+		arg.Title,
+		arg.BodyTemplate,
+		arg.SupportUrl,
+		arg.LogoUrl,
+		arg.SupportText,
+	)
+	return err
+}
+
+const setEnrollmentResponse = `-- name: SetEnrollmentResponse :exec
+INSERT INTO enrollment_responses(device_id, message_id, upn, provisioning_profile) VALUES ($1, $2, $3, $4) ON CONFLICT (device_id) DO UPDATE SET message_id=$2, upn=$3, provisioning_profile=$4, created_at=NOW()
+`
+
+type SetEnrollmentResponseParams struct {
+	DeviceID            int32  `json:"device_id"`
+	MessageID           string `json:"message_id"`
+	Upn                 string `json:"upn"`
+	ProvisioningProfile []byte `json:"provisioning_profile"`
+}
+
+func (q *Queries) SetEnrollmentResponse(ctx context.Context, arg SetEnrollmentResponseParams) error {
+	_, err := q.exec(ctx, q.setEnrollmentResponseStmt, setEnrollmentResponse,
+		arg.DeviceID,
+		arg.MessageID,
+		arg.Upn,
+		arg.ProvisioningProfile,
+	)
+	return err
+}
+
+const setGroupPolicySchedule = `-- name: SetGroupPolicySchedule :exec
+UPDATE group_policies SET not_before=$3 WHERE group_id = $1 AND policy_id = $2
+`
+
+type SetGroupPolicyScheduleParams struct {
+	GroupID   sql.NullInt32 `json:"group_id"`
+	PolicyID  sql.NullInt32 `json:"policy_id"`
+	NotBefore sql.NullTime  `json:"not_before"`
+}
+
+func (q *Queries) SetGroupPolicySchedule(ctx context.Context, arg SetGroupPolicyScheduleParams) error {
+	_, err := q.exec(ctx, q.setGroupPolicyScheduleStmt, setGroupPolicySchedule, arg.GroupID, arg.PolicyID, arg.NotBefore)
+	return err
+}
+
+const setLocalAdminPendingPassword = `-- name: SetLocalAdminPendingPassword :exec
+INSERT INTO local_admin_passwords(device_id, profile_id, pending_encrypted_password, pending_since) VALUES ($1, $2, $3, NOW()) ON CONFLICT (device_id, profile_id) DO UPDATE SET pending_encrypted_password=EXCLUDED.pending_encrypted_password, pending_since=NOW()
+`
+
+type SetLocalAdminPendingPasswordParams struct {
+	DeviceID                 int32  `json:"device_id"`
+	ProfileID                int32  `json:"profile_id"`
+	PendingEncryptedPassword []byte `json:"pending_encrypted_password"`
+}
+
+func (q *Queries) SetLocalAdminPendingPassword(ctx context.Context, arg SetLocalAdminPendingPasswordParams) error {
+	_, err := q.exec(ctx, q.setLocalAdminPendingPasswordStmt, setLocalAdminPendingPassword, arg.DeviceID, arg.ProfileID, arg.PendingEncryptedPassword)
+	return err
+}
+
+const setRolloutState = `-- name: SetRolloutState :exec
+UPDATE rollouts SET state=$2 WHERE id = $1
+`
+
+type SetRolloutStateParams struct {
+	ID    int32        `json:"id"`
+	State RolloutState `json:"state"`
+}
+
+func (q *Queries) SetRolloutState(ctx context.Context, arg SetRolloutStateParams) error {
+	_, err := q.exec(ctx, q.setRolloutStateStmt, setRolloutState, arg.ID, arg.State)
+	return err
+}
+
 const settings = `-- name: Settings :one
-SELECT tenant_name, tenant_email, tenant_website, tenant_phone, tenant_azureid, disable_enrollment FROM settings LIMIT 1
+SELECT tenant_name, tenant_email, tenant_website, tenant_phone, tenant_azureid, disable_enrollment, device_name_template, device_name_prefix FROM settings LIMIT 1
 `
 
 func (q *Queries) Settings(ctx context.Context) (Setting, error) {
@@ -775,12 +4951,66 @@ func (q *Queries) Settings(ctx context.Context) (Setting, error) {
 		&i.TenantPhone,
 		&i.TenantAzureid,
 		&i.DisableEnrollment,
+		&i.DeviceNameTemplate,
+		&i.DeviceNamePrefix,
 	)
 	return i, err
 }
 
+const updateCertificateProfile = `-- name: UpdateCertificateProfile :exec
+UPDATE certificate_profiles SET name=$2, description=$3, subject_template=$4, key_usages=$5, extended_key_usages=$6, key_length=$7, validity_days=$8, renewal_threshold=$9, user_context=$10, group_id=$11, updated_at=NOW() WHERE id = $1
+`
+
+type UpdateCertificateProfileParams struct {
+	ID                int32    `json:"id"`
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	SubjectTemplate   string   `json:"subject_template"`
+	KeyUsages         []string `json:"key_usages"`
+	ExtendedKeyUsages []string `json:"extended_key_usages"`
+	KeyLength         int32    `json:"key_length"`
+	ValidityDays      int32    `json:"validity_days"`
+	RenewalThreshold  int32    `json:"renewal_threshold"`
+	UserContext       bool     `json:"user_context"`
+	GroupID           int32    `json:"group_id"`
+}
+
+func (q *Queries) UpdateCertificateProfile(ctx context.Context, arg UpdateCertificateProfileParams) error {
+	_, err := q.exec(ctx, q.updateCertificateProfileStmt, updateCertificateProfile,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.SubjectTemplate,
+		pq.Array(arg.KeyUsages),
+		pq.Array(arg.ExtendedKeyUsages),
+		arg.KeyLength,
+		arg.ValidityDays,
+		arg.RenewalThreshold,
+		arg.UserContext,
+		arg.GroupID,
+	)
+	return err
+}
+
+const updateDeployedPolicyVersion = `-- name: UpdateDeployedPolicyVersion :exec
+UPDATE device_cache SET policy_version=policies.version FROM policies_payload, policies WHERE device_cache.payload_id=policies_payload.id AND policies.id=policies_payload.policy_id AND device_cache.device_id = $1 AND (device_cache.payload_id = $2 OR device_cache.policy_version != 0) AND device_cache.upn IS NOT DISTINCT FROM $3 AND policies_payload.policy_id = (SELECT policy_id FROM policies_payload AS deployed WHERE deployed.id = $2)
+`
+
+type UpdateDeployedPolicyVersionParams struct {
+	DeviceID  int32         `json:"device_id"`
+	PayloadID sql.NullInt32 `json:"payload_id"`
+	Upn       null.String   `json:"upn"`
+}
+
+// Called when the device returns a successful status for a payload to record that it has the latest version of the payload's policy.
+// The policy's other payloads which the device has confirmed are also updated as changed payloads are redeployed, so they are awaiting their status with version 0.
+func (q *Queries) UpdateDeployedPolicyVersion(ctx context.Context, arg UpdateDeployedPolicyVersionParams) error {
+	_, err := q.exec(ctx, q.updateDeployedPolicyVersionStmt, updateDeployedPolicyVersion, arg.DeviceID, arg.PayloadID, arg.Upn)
+	return err
+}
+
 const updateDeviceInventoryNode = `-- name: UpdateDeviceInventoryNode :exec
-INSERT INTO device_inventory(device_id, uri, format, value) VALUES ($1, $2, $3, $4)
+INSERT INTO device_inventory(device_id, uri, format, value) VALUES ($1, $2, $3, $4) ON CONFLICT (device_id, uri) DO UPDATE SET format=$3, value=$4
 `
 
 type UpdateDeviceInventoryNodeParams struct {
@@ -799,3 +5029,152 @@ func (q *Queries) UpdateDeviceInventoryNode(ctx context.Context, arg UpdateDevic
 	)
 	return err
 }
+
+const updateGroup = `-- name: UpdateGroup :exec
+UPDATE groups SET name=$2, description=$3, priority=$4, rules=$5, timezone=$6 WHERE id = $1
+`
+
+type UpdateGroupParams struct {
+	ID          int32  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Priority    int16  `json:"priority"`
+	Rules       string `json:"rules"`
+	Timezone    string `json:"timezone"`
+}
+
+// Exposed via API
+func (q *Queries) UpdateGroup(ctx context.Context, arg UpdateGroupParams) error {
+	_, err := q.exec(ctx, q.updateGroupStmt, updateGroup,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.Priority,
+		arg.Rules,
+		arg.Timezone,
+	)
+	return err
+}
+
+const updateLocalAdminProfile = `-- name: UpdateLocalAdminProfile :exec
+UPDATE local_admin_profiles SET name=$2, description=$3, password_length=$4, rotation_days=$5, rotate_after_view_hours=$6, group_id=$7, updated_at=NOW() WHERE id = $1
+`
+
+type UpdateLocalAdminProfileParams struct {
+	ID                   int32  `json:"id"`
+	Name                 string `json:"name"`
+	Description          string `json:"description"`
+	PasswordLength       int32  `json:"password_length"`
+	RotationDays         int32  `json:"rotation_days"`
+	RotateAfterViewHours int32  `json:"rotate_after_view_hours"`
+	GroupID              int32  `json:"group_id"`
+}
+
+func (q *Queries) UpdateLocalAdminProfile(ctx context.Context, arg UpdateLocalAdminProfileParams) error {
+	_, err := q.exec(ctx, q.updateLocalAdminProfileStmt, updateLocalAdminProfile,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.PasswordLength,
+		arg.RotationDays,
+		arg.RotateAfterViewHours,
+		arg.GroupID,
+	)
+	return err
+}
+
+const updatePolicy = `-- name: UpdatePolicy :exec
+UPDATE policies SET name=$2, description=$3, priority=$4 WHERE id = $1
+`
+
+type UpdatePolicyParams struct {
+	ID          int32  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Priority    int16  `json:"priority"`
+}
+
+// Exposed via API
+func (q *Queries) UpdatePolicy(ctx context.Context, arg UpdatePolicyParams) error {
+	_, err := q.exec(ctx, q.updatePolicyStmt, updatePolicy,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.Priority,
+	)
+	return err
+}
+
+const updatePolicyPayload = `-- name: UpdatePolicyPayload :exec
+UPDATE policies_payload SET format=$2, type=$3, value=$4, exec=$5 WHERE id = $1
+`
+
+type UpdatePolicyPayloadParams struct {
+	ID     int32  `json:"id"`
+	Format string `json:"format"`
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	Exec   bool   `json:"exec"`
+}
+
+// Exposed via API
+func (q *Queries) UpdatePolicyPayload(ctx context.Context, arg UpdatePolicyPayloadParams) error {
+	_, err := q.exec(ctx, q.updatePolicyPayloadStmt, updatePolicyPayload,
+		arg.ID,
+		arg.Format,
+		arg.Type,
+		arg.Value,
+		arg.Exec,
+	)
+	return err
+}
+
+const updateScript = `-- name: UpdateScript :exec
+UPDATE scripts SET name=$2, description=$3, version=CASE WHEN content = $4 THEN version ELSE version + 1 END, content=$4, run_context=$5, schedule=$6, group_id=$7, updated_at=NOW() WHERE id = $1
+`
+
+type UpdateScriptParams struct {
+	ID          int32          `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Content     string         `json:"content"`
+	RunContext  ScriptContext  `json:"run_context"`
+	Schedule    ScriptSchedule `json:"schedule"`
+	GroupID     int32          `json:"group_id"`
+}
+
+// The version is incremented when the content changes
+func (q *Queries) UpdateScript(ctx context.Context, arg UpdateScriptParams) error {
+	_, err := q.exec(ctx, q.updateScriptStmt, updateScript,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.Content,
+		arg.RunContext,
+		arg.Schedule,
+		arg.GroupID,
+	)
+	return err
+}
+
+const updateUserGroup = `-- name: UpdateUserGroup :exec
+UPDATE user_groups SET name=$2, description=$3, priority=$4 WHERE id = $1
+`
+
+type UpdateUserGroupParams struct {
+	ID          int32  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Priority    int16  `json:"priority"`
+}
+
+// Exposed via API
+func (q *Queries) UpdateUserGroup(ctx context.Context, arg UpdateUserGroupParams) error {
+	_, err := q.exec(ctx, q.updateUserGroupStmt, updateUserGroup,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.Priority,
+	)
+	return err
+}
@@ -0,0 +1,297 @@
+// Package declarative imports and exports Mattrax's policies, groups and their assignments as a YAML or JSON file.
+// The file is the desired state so applying it creates, updates and deletes resources (matched by name) until Mattrax matches it.
+package declarative
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mattrax/Mattrax/internal/catalog"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/dynamicgroups"
+	"github.com/mattrax/Mattrax/pkg/syncml"
+	"gopkg.in/yaml.v2"
+)
+
+// Format is the encoding of a configuration file
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// Config is the declarative configuration of Mattrax
+type Config struct {
+	Policies   []Policy    `json:"policies" yaml:"policies"`
+	Groups     []Group     `json:"groups" yaml:"groups"`
+	UserGroups []UserGroup `json:"user_groups" yaml:"user_groups"`
+}
+
+// Policy is a policy and its payloads
+type Policy struct {
+	Name        string    `json:"name" yaml:"name"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+	Priority    int16     `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Payloads    []Payload `json:"payloads" yaml:"payloads"`
+}
+
+// Payload is a node on the device set by a policy
+type Payload struct {
+	URI    string `json:"uri" yaml:"uri"`
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	Type   string `json:"type,omitempty" yaml:"type,omitempty"`
+	Value  string `json:"value,omitempty" yaml:"value,omitempty"`
+	Exec   bool   `json:"exec,omitempty" yaml:"exec,omitempty"`
+}
+
+// Group is a device group and the names of the policies assigned to it. Device membership isn't managed by the configuration.
+type Group struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Priority    int16    `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Rules       string   `json:"rules,omitempty" yaml:"rules,omitempty"`
+	Timezone    string   `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	Policies    []string `json:"policies" yaml:"policies"`
+}
+
+// UserGroup is a user group and the names of the policies assigned to it. User membership isn't managed by the configuration.
+type UserGroup struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Priority    int16    `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Policies    []string `json:"policies" yaml:"policies"`
+}
+
+// Parse decodes a configuration file
+func Parse(data []byte, format Format) (Config, error) {
+	var config Config
+	var err error
+	if format == FormatJSON {
+		err = json.Unmarshal(data, &config)
+	} else {
+		err = yaml.UnmarshalStrict(data, &config)
+	}
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Values are stored normalised so an equivalent value in the file (eg. "True" for a bool) doesn't show as a change
+	for _, policy := range config.Policies {
+		for i, payload := range policy.Payloads {
+			policy.Payloads[i].Value = syncml.NormaliseValue(payload.Format, payload.Value)
+		}
+	}
+
+	// An omitted timezone is the same as the database default so it doesn't show as a change
+	for i := range config.Groups {
+		if config.Groups[i].Timezone == "" {
+			config.Groups[i].Timezone = "UTC"
+		}
+	}
+	return config, config.Validate()
+}
+
+// Marshal encodes the configuration as a file
+func (c Config) Marshal(format Format) ([]byte, error) {
+	if format == FormatJSON {
+		return json.MarshalIndent(c, "", "  ")
+	}
+	return yaml.Marshal(c)
+}
+
+// Validate verifies the configuration can be applied
+func (c Config) Validate() error {
+	var policies = make(map[string]bool, len(c.Policies))
+	for _, policy := range c.Policies {
+		if policy.Name == "" {
+			return fmt.Errorf("policies must have a name")
+		} else if policies[policy.Name] {
+			return fmt.Errorf("policy '%s' is defined multiple times", policy.Name)
+		}
+		policies[policy.Name] = true
+
+		var uris = make(map[string]bool, len(policy.Payloads))
+		for _, payload := range policy.Payloads {
+			if err := syncml.ValidateURI(payload.URI); err != nil {
+				return fmt.Errorf("policy '%s': %w", policy.Name, err)
+			} else if err := syncml.ValidateFormat(payload.Format, payload.Value); err != nil {
+				return fmt.Errorf("policy '%s' payload '%s': %w", policy.Name, payload.URI, err)
+			} else if err := syncml.ValidateType(payload.Type); err != nil {
+				return fmt.Errorf("policy '%s' payload '%s': %w", policy.Name, payload.URI, err)
+			} else if uris[payload.URI] {
+				return fmt.Errorf("policy '%s' sets '%s' multiple times", policy.Name, payload.URI)
+			}
+			uris[payload.URI] = true
+		}
+	}
+
+	var groups = make(map[string]bool, len(c.Groups))
+	for _, group := range c.Groups {
+		if group.Name == "" {
+			return fmt.Errorf("groups must have a name")
+		} else if groups[group.Name] {
+			return fmt.Errorf("group '%s' is defined multiple times", group.Name)
+		}
+		groups[group.Name] = true
+
+		if group.Rules != "" {
+			if err := dynamicgroups.ValidateRules(group.Rules); err != nil {
+				return fmt.Errorf("group '%s': %w", group.Name, err)
+			}
+		}
+
+		if _, err := time.LoadLocation(group.Timezone); err != nil {
+			return fmt.Errorf("group '%s' has an invalid timezone '%s'", group.Name, group.Timezone)
+		}
+
+		if err := validateAssignments("group", group.Name, group.Policies, policies); err != nil {
+			return err
+		}
+	}
+
+	// Dynamic groups can't test membership of dynamic groups as it would depend on the order the groups are evaluated in
+	var dynamic = make(map[string]bool, len(c.Groups))
+	for _, group := range c.Groups {
+		if group.Rules != "" {
+			dynamic[group.Name] = true
+		}
+	}
+	for _, group := range c.Groups {
+		for _, referenced := range dynamicgroups.DeviceGroups(group.Rules) {
+			if dynamic[referenced] {
+				return dynamicgroups.ReferenceError{Group: group.Name, Referenced: referenced}
+			}
+		}
+	}
+
+	var userGroups = make(map[string]bool, len(c.UserGroups))
+	for _, group := range c.UserGroups {
+		if group.Name == "" {
+			return fmt.Errorf("user groups must have a name")
+		} else if userGroups[group.Name] {
+			return fmt.Errorf("user group '%s' is defined multiple times", group.Name)
+		}
+		userGroups[group.Name] = true
+
+		if err := validateAssignments("user group", group.Name, group.Policies, policies); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateCatalog verifies the policies' payloads match the settings described by the catalog
+func (c Config) ValidateCatalog(catalog *catalog.Service) error {
+	for _, policy := range c.Policies {
+		for _, payload := range policy.Payloads {
+			if err := catalog.Validate(payload.URI, payload.Format, payload.Value, payload.Exec); err != nil {
+				return fmt.Errorf("policy '%s': %w", policy.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateAssignments(kind, name string, assigned []string, policies map[string]bool) error {
+	var seen = make(map[string]bool, len(assigned))
+	for _, policy := range assigned {
+		if !policies[policy] {
+			return fmt.Errorf("%s '%s' is assigned policy '%s' which isn't defined", kind, name, policy)
+		} else if seen[policy] {
+			return fmt.Errorf("%s '%s' is assigned policy '%s' multiple times", kind, name, policy)
+		}
+		seen[policy] = true
+	}
+	return nil
+}
+
+// Export returns the current configuration of Mattrax
+func Export(ctx context.Context, q *db.Queries) (Config, error) {
+	var config = Config{
+		Policies:   make([]Policy, 0),
+		Groups:     make([]Group, 0),
+		UserGroups: make([]UserGroup, 0),
+	}
+
+	policies, err := q.GetAllPolicies(ctx)
+	if err != nil {
+		return Config{}, err
+	}
+
+	for _, policy := range policies {
+		payloads, err := q.GetPoliciesPayloads(ctx, sql.NullInt32{Int32: policy.ID, Valid: true})
+		if err != nil {
+			return Config{}, err
+		}
+
+		var exported = Policy{
+			Name:        policy.Name,
+			Description: policy.Description,
+			Priority:    policy.Priority,
+			Payloads:    make([]Payload, len(payloads)),
+		}
+		for i, payload := range payloads {
+			exported.Payloads[i] = Payload{
+				URI:    payload.Uri,
+				Format: payload.Format,
+				Type:   payload.Type,
+				Value:  payload.Value,
+				Exec:   payload.Exec,
+			}
+		}
+		config.Policies = append(config.Policies, exported)
+	}
+
+	groups, err := q.GetAllGroups(ctx)
+	if err != nil {
+		return Config{}, err
+	}
+
+	for _, group := range groups {
+		assigned, err := q.GetGroupPolicies(ctx, sql.NullInt32{Int32: group.ID, Valid: true})
+		if err != nil {
+			return Config{}, err
+		}
+
+		var exported = Group{
+			Name:        group.Name,
+			Description: group.Description,
+			Priority:    group.Priority,
+			Rules:       group.Rules,
+			Timezone:    group.Timezone,
+			Policies:    make([]string, len(assigned)),
+		}
+		for i, policy := range assigned {
+			exported.Policies[i] = policy.Name
+		}
+		config.Groups = append(config.Groups, exported)
+	}
+
+	userGroups, err := q.GetAllUserGroups(ctx)
+	if err != nil {
+		return Config{}, err
+	}
+
+	for _, group := range userGroups {
+		assigned, err := q.GetUserGroupPolicies(ctx, group.ID)
+		if err != nil {
+			return Config{}, err
+		}
+
+		var exported = UserGroup{
+			Name:        group.Name,
+			Description: group.Description,
+			Priority:    group.Priority,
+			Policies:    make([]string, len(assigned)),
+		}
+		for i, policy := range assigned {
+			exported.Policies[i] = policy.Name
+		}
+		config.UserGroups = append(config.UserGroups, exported)
+	}
+	return config, nil
+}
@@ -0,0 +1,549 @@
+package declarative
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/dynamicgroups"
+	"github.com/mattrax/Mattrax/internal/policies"
+	"github.com/pkg/errors"
+)
+
+// Action is what applying a change does to a resource
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Kind is the type of resource a change applies to
+type Kind string
+
+const (
+	KindPolicy              Kind = "policy"
+	KindPayload             Kind = "payload"
+	KindGroup               Kind = "group"
+	KindGroupAssignment     Kind = "group_assignment"
+	KindUserGroup           Kind = "user_group"
+	KindUserGroupAssignment Kind = "user_group_assignment"
+)
+
+// Change is a single difference between the configuration and Mattrax. Parent is the policy or group of payloads and assignments.
+type Change struct {
+	Action Action `json:"action"`
+	Kind   Kind   `json:"kind"`
+	Parent string `json:"parent,omitempty"`
+	Name   string `json:"name"`
+}
+
+// String formats the change as a line of a diff
+func (c Change) String() string {
+	var symbol = "~"
+	if c.Action == ActionCreate {
+		symbol = "+"
+	} else if c.Action == ActionDelete {
+		symbol = "-"
+	}
+
+	if c.Parent != "" {
+		return symbol + " " + string(c.Kind) + " " + c.Parent + " " + c.Name
+	}
+	return symbol + " " + string(c.Kind) + " " + c.Name
+}
+
+// Plan computes the changes required for Mattrax to match the configuration without making them
+func Plan(ctx context.Context, q *db.Queries, config Config) ([]Change, error) {
+	var p = planner{q: q}
+	err := p.run(ctx, config)
+	return p.changes, err
+}
+
+// Apply makes Mattrax match the configuration and returns the changes made. It must be called within a transaction.
+// The author is recorded against the policy versions created by the changes.
+func Apply(ctx context.Context, q *db.Queries, config Config, author string) ([]Change, error) {
+	var p = planner{q: q, apply: true, author: author}
+	err := p.run(ctx, config)
+	return p.changes, err
+}
+
+// planner walks the differences between the configuration and Mattrax. Queries modifying Mattrax are only run when applying.
+type planner struct {
+	q       *db.Queries
+	apply   bool
+	author  string
+	changes []Change
+}
+
+func (p *planner) change(action Action, kind Kind, parent, name string) {
+	p.changes = append(p.changes, Change{
+		Action: action,
+		Kind:   kind,
+		Parent: parent,
+		Name:   name,
+	})
+}
+
+func (p *planner) run(ctx context.Context, config Config) error {
+	p.changes = make([]Change, 0)
+
+	policyIDs, err := p.policies(ctx, config.Policies)
+	if err != nil {
+		return errors.Wrap(err, "error applying policies")
+	}
+
+	var policyChanges = len(p.changes)
+	if err := p.groups(ctx, config.Groups, policyIDs); err != nil {
+		return errors.Wrap(err, "error applying groups")
+	}
+
+	if err := p.userGroups(ctx, config.UserGroups, policyIDs); err != nil {
+		return errors.Wrap(err, "error applying user groups")
+	}
+
+	// Group rules can reference other groups by name so every dynamic group is reevaluated when groups change
+	if p.apply && len(p.changes) != policyChanges {
+		if err := dynamicgroups.EvaluateAll(ctx, p.q); err != nil {
+			return err
+		}
+	}
+
+	// Policies are deleted last so the groups no longer assigned them have been updated first
+	if err := p.deletePolicies(ctx, config.Policies); err != nil {
+		return errors.Wrap(err, "error deleting policies")
+	}
+	return nil
+}
+
+// policies creates and updates the policies returning the ID of every policy by name. Policies which would be created while planning don't have an ID.
+func (p *planner) policies(ctx context.Context, desired []Policy) (map[string]int32, error) {
+	existing, err := p.q.GetAllPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var policyIDs = make(map[string]int32, len(existing))
+	var current = make(map[string]db.GetAllPoliciesRow, len(existing))
+	for _, policy := range existing {
+		policyIDs[policy.Name] = policy.ID
+		current[policy.Name] = policy
+	}
+
+	for _, policy := range desired {
+		previous, found := current[policy.Name]
+		if !found {
+			p.change(ActionCreate, KindPolicy, "", policy.Name)
+			if !p.apply {
+				continue
+			}
+
+			id, err := p.q.CreatePolicy(ctx, db.CreatePolicyParams{
+				Name:        policy.Name,
+				Description: policy.Description,
+				Priority:    policy.Priority,
+			})
+			if err != nil {
+				return nil, err
+			}
+			policyIDs[policy.Name] = id
+
+			for _, payload := range policy.Payloads {
+				if _, err := p.q.CreatePolicyPayload(ctx, payloadParams(id, payload)); err != nil {
+					return nil, err
+				}
+			}
+
+			if _, err := policies.Snapshot(ctx, p.q, id, p.author); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var changed = previous.Description != policy.Description || previous.Priority != policy.Priority
+		if p.apply {
+			if err := policies.Baseline(ctx, p.q, previous.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		if changed {
+			p.change(ActionUpdate, KindPolicy, "", policy.Name)
+			if p.apply {
+				if err := p.q.UpdatePolicy(ctx, db.UpdatePolicyParams{
+					ID:          previous.ID,
+					Name:        policy.Name,
+					Description: policy.Description,
+					Priority:    policy.Priority,
+				}); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		payloadsChanged, err := p.payloads(ctx, previous.ID, policy)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.apply && (changed || payloadsChanged) {
+			if _, err := policies.Snapshot(ctx, p.q, previous.ID, p.author); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return policyIDs, nil
+}
+
+// payloads makes the payloads of an existing policy match the configuration. Changed payloads are redeployed and removed payloads are deleted from devices.
+func (p *planner) payloads(ctx context.Context, policyID int32, policy Policy) (bool, error) {
+	existing, err := p.q.GetPoliciesPayloads(ctx, sql.NullInt32{Int32: policyID, Valid: true})
+	if err != nil {
+		return false, err
+	}
+
+	var current = make(map[string]db.PoliciesPayload, len(existing))
+	for _, payload := range existing {
+		current[payload.Uri] = payload
+	}
+
+	var changed bool
+	for _, payload := range policy.Payloads {
+		previous, found := current[payload.URI]
+		delete(current, payload.URI)
+
+		if !found {
+			changed = true
+			p.change(ActionCreate, KindPayload, policy.Name, payload.URI)
+			if p.apply {
+				if _, err := p.q.CreatePolicyPayload(ctx, payloadParams(policyID, payload)); err != nil {
+					return false, err
+				}
+			}
+			continue
+		} else if previous.Format == payload.Format && previous.Type == payload.Type && previous.Value == payload.Value && previous.Exec == payload.Exec {
+			continue
+		}
+
+		changed = true
+		p.change(ActionUpdate, KindPayload, policy.Name, payload.URI)
+		if !p.apply {
+			continue
+		}
+
+		if err := p.q.UpdatePolicyPayload(ctx, db.UpdatePolicyPayloadParams{
+			ID:     previous.ID,
+			Format: payload.Format,
+			Type:   payload.Type,
+			Value:  payload.Value,
+			Exec:   payload.Exec,
+		}); err != nil {
+			return false, err
+		}
+
+		if err := p.q.InvalidatePayloadCache(ctx, sql.NullInt32{Int32: previous.ID, Valid: true}); err != nil {
+			return false, err
+		}
+	}
+
+	// The remaining payloads aren't in the configuration
+	for _, payload := range existing {
+		if _, removed := current[payload.Uri]; !removed {
+			continue
+		}
+
+		changed = true
+		p.change(ActionDelete, KindPayload, policy.Name, payload.Uri)
+		if p.apply {
+			if err := p.q.DetachPolicyPayload(ctx, payload.ID); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if p.apply && changed {
+		return true, p.q.DeleteOrphanedPayloads(ctx)
+	}
+	return changed, nil
+}
+
+func (p *planner) deletePolicies(ctx context.Context, desired []Policy) error {
+	existing, err := p.q.GetAllPolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	var names = make(map[string]bool, len(desired))
+	for _, policy := range desired {
+		names[policy.Name] = true
+	}
+
+	for _, policy := range existing {
+		if names[policy.Name] {
+			continue
+		}
+
+		p.change(ActionDelete, KindPolicy, "", policy.Name)
+		if p.apply {
+			if err := policies.Delete(ctx, p.q, policy.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *planner) groups(ctx context.Context, desired []Group, policyIDs map[string]int32) error {
+	existing, err := p.q.GetAllGroups(ctx)
+	if err != nil {
+		return err
+	}
+
+	var current = make(map[string]db.Group, len(existing))
+	for _, group := range existing {
+		current[group.Name] = group
+	}
+
+	for _, group := range desired {
+		previous, found := current[group.Name]
+		delete(current, group.Name)
+
+		var assigned []string
+		if !found {
+			p.change(ActionCreate, KindGroup, "", group.Name)
+			if p.apply {
+				if previous.ID, err = p.q.CreateGroup(ctx, db.CreateGroupParams{
+					Name:        group.Name,
+					Description: group.Description,
+					Priority:    group.Priority,
+					Rules:       group.Rules,
+					Timezone:    group.Timezone,
+				}); err != nil {
+					return err
+				}
+			}
+		} else {
+			if previous.Description != group.Description || previous.Priority != group.Priority || previous.Rules != group.Rules || previous.Timezone != group.Timezone {
+				p.change(ActionUpdate, KindGroup, "", group.Name)
+				if p.apply {
+					if err := p.q.UpdateGroup(ctx, db.UpdateGroupParams{
+						ID:          previous.ID,
+						Name:        group.Name,
+						Description: group.Description,
+						Priority:    group.Priority,
+						Rules:       group.Rules,
+						Timezone:    group.Timezone,
+					}); err != nil {
+						return err
+					}
+				}
+			}
+
+			rows, err := p.q.GetGroupPolicies(ctx, sql.NullInt32{Int32: previous.ID, Valid: true})
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				assigned = append(assigned, row.Name)
+			}
+		}
+
+		added, removed := diffAssignments(assigned, group.Policies)
+		for _, policy := range added {
+			p.change(ActionCreate, KindGroupAssignment, group.Name, policy)
+			if p.apply {
+				if err := p.q.AttachGroupPolicy(ctx, db.AttachGroupPolicyParams{
+					GroupID:  sql.NullInt32{Int32: previous.ID, Valid: true},
+					PolicyID: sql.NullInt32{Int32: policyIDs[policy], Valid: true},
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, policy := range removed {
+			p.change(ActionDelete, KindGroupAssignment, group.Name, policy)
+			if p.apply {
+				if err := p.q.DetachGroupPolicy(ctx, db.DetachGroupPolicyParams{
+					GroupID:  sql.NullInt32{Int32: previous.ID, Valid: true},
+					PolicyID: sql.NullInt32{Int32: policyIDs[policy], Valid: true},
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// The remaining groups aren't in the configuration. Their devices have the groups policies removed on their next checkin.
+	for _, group := range existing {
+		if _, removed := current[group.Name]; !removed {
+			continue
+		}
+
+		p.change(ActionDelete, KindGroup, "", group.Name)
+		if !p.apply {
+			continue
+		}
+
+		if err := p.q.DeleteGroupDevices(ctx, group.ID); err != nil {
+			return err
+		}
+		if err := p.q.DeleteGroupPolicies(ctx, sql.NullInt32{Int32: group.ID, Valid: true}); err != nil {
+			return err
+		}
+		if err := p.q.DeleteGroupRollouts(ctx, group.ID); err != nil {
+			return err
+		}
+		if err := p.q.DeleteGroupAppAssignments(ctx, group.ID); err != nil {
+			return err
+		}
+		if err := p.q.DeleteGroupScripts(ctx, group.ID); err != nil {
+			return err
+		}
+		if err := p.q.DeleteGroupCertificateProfiles(ctx, group.ID); err != nil {
+			return err
+		}
+		if err := p.q.DeleteGroupLocalAdminProfiles(ctx, group.ID); err != nil {
+			return err
+		}
+		if err := p.q.DeleteGroup(ctx, group.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *planner) userGroups(ctx context.Context, desired []UserGroup, policyIDs map[string]int32) error {
+	existing, err := p.q.GetAllUserGroups(ctx)
+	if err != nil {
+		return err
+	}
+
+	var current = make(map[string]db.UserGroup, len(existing))
+	for _, group := range existing {
+		current[group.Name] = group
+	}
+
+	for _, group := range desired {
+		previous, found := current[group.Name]
+		delete(current, group.Name)
+
+		var assigned []string
+		if !found {
+			p.change(ActionCreate, KindUserGroup, "", group.Name)
+			if p.apply {
+				if previous.ID, err = p.q.CreateUserGroup(ctx, db.CreateUserGroupParams{
+					Name:        group.Name,
+					Description: group.Description,
+					Priority:    group.Priority,
+				}); err != nil {
+					return err
+				}
+			}
+		} else {
+			if previous.Description != group.Description || previous.Priority != group.Priority {
+				p.change(ActionUpdate, KindUserGroup, "", group.Name)
+				if p.apply {
+					if err := p.q.UpdateUserGroup(ctx, db.UpdateUserGroupParams{
+						ID:          previous.ID,
+						Name:        group.Name,
+						Description: group.Description,
+						Priority:    group.Priority,
+					}); err != nil {
+						return err
+					}
+				}
+			}
+
+			rows, err := p.q.GetUserGroupPolicies(ctx, previous.ID)
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				assigned = append(assigned, row.Name)
+			}
+		}
+
+		added, removed := diffAssignments(assigned, group.Policies)
+		for _, policy := range added {
+			p.change(ActionCreate, KindUserGroupAssignment, group.Name, policy)
+			if p.apply {
+				if err := p.q.AttachUserGroupPolicy(ctx, db.AttachUserGroupPolicyParams{
+					GroupID:  previous.ID,
+					PolicyID: policyIDs[policy],
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, policy := range removed {
+			p.change(ActionDelete, KindUserGroupAssignment, group.Name, policy)
+			if p.apply {
+				if err := p.q.DetachUserGroupPolicy(ctx, db.DetachUserGroupPolicyParams{
+					GroupID:  previous.ID,
+					PolicyID: policyIDs[policy],
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, group := range existing {
+		if _, removed := current[group.Name]; !removed {
+			continue
+		}
+
+		p.change(ActionDelete, KindUserGroup, "", group.Name)
+		if !p.apply {
+			continue
+		}
+
+		if err := p.q.DeleteUserGroupMembers(ctx, group.ID); err != nil {
+			return err
+		}
+		if err := p.q.DeleteUserGroupPolicies(ctx, group.ID); err != nil {
+			return err
+		}
+		if err := p.q.DeleteUserGroup(ctx, group.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffAssignments returns the policies which are assigned in the configuration but not Mattrax (added) and the reverse (removed)
+func diffAssignments(current, desired []string) (added, removed []string) {
+	var currentNames = make(map[string]bool, len(current))
+	for _, name := range current {
+		currentNames[name] = true
+	}
+
+	var desiredNames = make(map[string]bool, len(desired))
+	for _, name := range desired {
+		desiredNames[name] = true
+		if !currentNames[name] {
+			added = append(added, name)
+		}
+	}
+
+	for _, name := range current {
+		if !desiredNames[name] {
+			removed = append(removed, name)
+		}
+	}
+	return added, removed
+}
+
+func payloadParams(policyID int32, payload Payload) db.CreatePolicyPayloadParams {
+	return db.CreatePolicyPayloadParams{
+		PolicyID: sql.NullInt32{Int32: policyID, Valid: true},
+		Uri:      payload.URI,
+		Format:   payload.Format,
+		Type:     payload.Type,
+		Value:    payload.Value,
+		Exec:     payload.Exec,
+	}
+}
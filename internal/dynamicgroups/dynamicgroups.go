@@ -0,0 +1,284 @@
+// Package dynamicgroups materialises the membership of groups with rules into group_devices so they can be used like any other group
+package dynamicgroups
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/pkg/rules"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// fields are the device fields which can be used in rules
+var fields = map[string]bool{
+	"id":               true,
+	"udid":             true,
+	"name":             true,
+	"description":      true,
+	"model":            true,
+	"hw_dev_id":        true,
+	"operating_system": true,
+	"azure_did":        true,
+	"enrollment_type":  true,
+	"state":            true,
+	"enrolled_by":      true,
+	"inventory":        true,
+}
+
+// groupFields are the fields which can be used with IN GROUP. The device is tested against device groups and the user who enrolled it against user groups.
+var groupFields = map[string]bool{
+	"device":      true,
+	"id":          true,
+	"enrolled_by": true,
+}
+
+// ValidateRules verifies the rules can be parsed and only reference supported fields
+func ValidateRules(rule string) error {
+	r, err := rules.Parse(rule)
+	if err != nil {
+		return err
+	}
+	return validateFields(r)
+}
+
+func validateFields(r rules.Rule) error {
+	for _, membership := range r.Memberships() {
+		if !groupFields[membership.Field.Name] {
+			return fmt.Errorf("the field '%s' doesn't support group membership", membership.Field.Name)
+		} else if membership.Field.Key != "" {
+			return fmt.Errorf("the field '%s' doesn't support a key", membership.Field.Name)
+		}
+	}
+
+	for _, field := range r.Fields() {
+		if !fields[field.Name] {
+			return fmt.Errorf("the field '%s' is not supported", field.Name)
+		} else if field.Name == "inventory" && field.Key == "" {
+			return fmt.Errorf("the inventory field requires a node uri, eg. inventory['./DevDetail/SwV']")
+		} else if field.Name != "inventory" && field.Key != "" {
+			return fmt.Errorf("the field '%s' doesn't support a key", field.Name)
+		}
+	}
+	return nil
+}
+
+// DeviceGroups returns the device groups whose membership is tested by the rules. The rules must be valid.
+func DeviceGroups(rule string) []string {
+	r, err := rules.Parse(rule)
+	if err != nil {
+		return nil
+	}
+
+	var groups []string
+	for _, membership := range r.Memberships() {
+		if membership.Field.Name == "device" || membership.Field.Name == "id" {
+			groups = append(groups, membership.Group)
+		}
+	}
+	return groups
+}
+
+// ReferenceError is returned when a dynamic group's rules test membership of a dynamic group.
+// It isn't supported as the membership would depend on the order the groups are evaluated in.
+type ReferenceError struct {
+	Group      string
+	Referenced string
+}
+
+func (e ReferenceError) Error() string {
+	return fmt.Sprintf("the rules of group '%s' can't test membership of the dynamic group '%s'", e.Group, e.Referenced)
+}
+
+// ValidateReferences verifies the rules of the dynamic group don't test membership of a dynamic group (including itself) and no other dynamic group tests membership of it.
+// It returns a ReferenceError if they do. It must be called within the transaction which saves the group.
+func ValidateReferences(ctx context.Context, q *db.Queries, name, rule string) error {
+	groups, err := q.GetDynamicGroups(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error retrieving dynamic groups")
+	}
+
+	var dynamic = map[string]bool{name: true}
+	for _, group := range groups {
+		dynamic[group.Name] = true
+	}
+
+	for _, referenced := range DeviceGroups(rule) {
+		if dynamic[referenced] {
+			return ReferenceError{Group: name, Referenced: referenced}
+		}
+	}
+
+	for _, group := range groups {
+		if group.Name == name {
+			continue
+		}
+
+		for _, referenced := range DeviceGroups(group.Rules) {
+			if referenced == name {
+				return ReferenceError{Group: group.Name, Referenced: name}
+			}
+		}
+	}
+	return nil
+}
+
+// EvaluateDevice updates the device's membership of every dynamic group. It should be called whenever the device or its inventory changes.
+func EvaluateDevice(ctx context.Context, q *db.Queries, deviceID int32) error {
+	device, err := q.GetDevice(ctx, deviceID)
+	if err != nil {
+		return errors.Wrap(err, "error retrieving device")
+	}
+
+	groups, err := q.GetDynamicGroups(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error retrieving dynamic groups")
+	}
+
+	// A group which can't be evaluated keeps its current members so it doesn't stop the other groups being evaluated
+	var env = &deviceEnvironment{ctx: ctx, q: q, device: device}
+	for _, group := range groups {
+		rule, err := rules.Parse(group.Rules)
+		if err == nil {
+			err = validateFields(rule)
+		}
+		if err != nil {
+			log.Error().Int32("group", group.ID).Int32("device", deviceID).Err(err).Msg("error parsing dynamic group rules")
+			continue
+		}
+
+		if err := setMembership(ctx, q, group.ID, rule, env); err != nil {
+			log.Error().Int32("group", group.ID).Int32("device", deviceID).Err(err).Msg("error evaluating dynamic group")
+		}
+	}
+	return nil
+}
+
+// EvaluateGroup updates the group's membership for every device. It should be called whenever the group's rules change.
+func EvaluateGroup(ctx context.Context, q *db.Queries, groupID int32, rule string) error {
+	r, err := rules.Parse(rule)
+	if err != nil {
+		return err
+	}
+
+	devices, err := q.GetAllDevices(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error retrieving devices")
+	}
+
+	for _, device := range devices {
+		if err := setMembership(ctx, q, groupID, r, &deviceEnvironment{ctx: ctx, q: q, device: device}); err != nil {
+			return errors.Wrap(err, "error evaluating device "+strconv.Itoa(int(device.ID)))
+		}
+	}
+	return nil
+}
+
+// EvaluateAll updates the membership of every dynamic group for every device. It should be called when something referenced by rules, like user group membership, changes.
+func EvaluateAll(ctx context.Context, q *db.Queries) error {
+	groups, err := q.GetDynamicGroups(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error retrieving dynamic groups")
+	}
+
+	for _, group := range groups {
+		if err := EvaluateGroup(ctx, q, group.ID, group.Rules); err != nil {
+			return errors.Wrap(err, "error evaluating group "+group.Name)
+		}
+	}
+	return nil
+}
+
+func setMembership(ctx context.Context, q *db.Queries, groupID int32, rule rules.Rule, env *deviceEnvironment) error {
+	member, err := rule.Evaluate(env)
+	if err != nil {
+		return err
+	}
+
+	if member {
+		return q.AddGroupDevices(ctx, db.AddGroupDevicesParams{
+			GroupID:   groupID,
+			DeviceIds: []int32{env.device.ID},
+		})
+	}
+	return q.RemoveGroupDevices(ctx, db.RemoveGroupDevicesParams{
+		GroupID:   groupID,
+		DeviceIds: []int32{env.device.ID},
+	})
+}
+
+// deviceEnvironment resolves the fields used in rules from the device. The inventory is only loaded if a rule uses it.
+type deviceEnvironment struct {
+	ctx       context.Context
+	q         *db.Queries
+	device    db.Device
+	inventory map[string]string
+}
+
+func (e *deviceEnvironment) Value(field rules.Field) (string, error) {
+	switch field.Name {
+	case "id":
+		return strconv.Itoa(int(e.device.ID)), nil
+	case "udid":
+		return e.device.Udid, nil
+	case "name":
+		return e.device.Name, nil
+	case "description":
+		return e.device.Description.String, nil
+	case "model":
+		return e.device.Model, nil
+	case "hw_dev_id":
+		return e.device.HwDevID, nil
+	case "operating_system":
+		return e.device.OperatingSystem, nil
+	case "azure_did":
+		return e.device.AzureDid.String, nil
+	case "enrollment_type":
+		return string(e.device.EnrollmentType), nil
+	case "state":
+		return string(e.device.State), nil
+	case "enrolled_by":
+		return e.device.EnrolledBy.String, nil
+	case "inventory":
+		if e.inventory == nil {
+			nodes, err := e.q.GetDeviceInventory(e.ctx, e.device.ID)
+			if err != nil {
+				return "", errors.Wrap(err, "error retrieving device inventory")
+			}
+
+			e.inventory = make(map[string]string, len(nodes))
+			for _, node := range nodes {
+				e.inventory[node.Uri] = node.Value
+			}
+		}
+
+		// The "./" prefix is optional in rules
+		if value, ok := e.inventory[field.Key]; ok {
+			return value, nil
+		}
+		return e.inventory["./"+strings.TrimPrefix(field.Key, "./")], nil
+	}
+	return "", fmt.Errorf("the field '%s' is not supported", field.Name)
+}
+
+func (e *deviceEnvironment) InGroup(field rules.Field, group string) (bool, error) {
+	switch field.Name {
+	case "device", "id":
+		return e.q.IsDeviceInGroup(e.ctx, db.IsDeviceInGroupParams{
+			DeviceID: e.device.ID,
+			Name:     group,
+		})
+	case "enrolled_by":
+		if !e.device.EnrolledBy.Valid {
+			return false, nil
+		}
+		return e.q.IsUserInGroup(e.ctx, db.IsUserInGroupParams{
+			Upn:  e.device.EnrolledBy.String,
+			Name: group,
+		})
+	}
+	return false, fmt.Errorf("the field '%s' doesn't support group membership", field.Name)
+}
@@ -0,0 +1,110 @@
+package dynamicgroups
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/pkg/null"
+	"github.com/mattrax/Mattrax/pkg/rules"
+)
+
+func TestValidateRules(t *testing.T) {
+	var tests = []struct {
+		rule  string
+		valid bool
+	}{
+		{"operating_system >= 10.0.19041", true},
+		{"enrollment_type = Device AND state = managed", true},
+		{"inventory['./DevDetail/SwV'] contains 19041", true},
+		{`device IN GROUP "Finance" OR enrolled_by IN GROUP Finance`, true},
+		{"id IN GROUP Finance", true},
+		{"NOT (name contains kiosk)", true},
+		{"", false},
+		{"name = ", false},
+		{"serial_number = ABC123", false},              // unknown field
+		{"name = a AND serial_number = ABC123", false}, // unknown field after a known one
+		{"inventory = 1", false},                       // inventory requires a node uri
+		{"name['key'] = 1", false},                     // only inventory has a key
+		{"device = 1", false},                          // device can only be used with IN GROUP
+		{"model IN GROUP Finance", false},              // model doesn't support group membership
+		{"inventory['./DevDetail/SwV'] IN GROUP Finance", false},
+		{"device['key'] IN GROUP Finance", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateRules(tt.rule); (err == nil) != tt.valid {
+			t.Errorf("ValidateRules(%q) returned %v, expected valid to be %v", tt.rule, err, tt.valid)
+		}
+	}
+}
+
+func TestDeviceGroups(t *testing.T) {
+	var tests = []struct {
+		rule   string
+		groups []string
+	}{
+		{"name = a", nil},
+		{`device IN GROUP "Finance Laptops"`, []string{"Finance Laptops"}},
+		{"id IN GROUP A OR (model = X AND NOT device IN GROUP B)", []string{"A", "B"}},
+		{"enrolled_by IN GROUP Users", nil}, // user groups aren't device groups
+		{"name =", nil},                     // invalid rules have no groups
+	}
+	for _, tt := range tests {
+		if groups := DeviceGroups(tt.rule); !reflect.DeepEqual(groups, tt.groups) {
+			t.Errorf("DeviceGroups(%q) returned %v, expected %v", tt.rule, groups, tt.groups)
+		}
+	}
+}
+
+func TestEvaluateDeviceFields(t *testing.T) {
+	var env = &deviceEnvironment{
+		ctx: context.Background(),
+		device: db.Device{
+			ID:              42,
+			Udid:            "6F9619FF-8B86-D011-B42D-00CF4FC964FF",
+			Name:            "FINANCE-PC01",
+			Model:           "Surface Laptop 3",
+			OperatingSystem: "10.0.19041.508",
+			EnrollmentType:  db.EnrollmentTypeDevice,
+			State:           db.DeviceStateManaged,
+			EnrolledBy:      null.String{String: "alice@example.com", Valid: true},
+		},
+	}
+
+	var tests = []struct {
+		rule     string
+		expected bool
+	}{
+		{"id = 42", true},
+		{"id > 9", true},
+		{"name contains finance", true},
+		{"model = 'Surface Laptop 3'", true},
+		{"operating_system >= 10.0.19041 AND operating_system < 10.0.19042", true},
+		{"enrollment_type = Device AND state = managed", true},
+		{"enrollment_type = User OR state = unenrolled", false},
+		{"enrolled_by = alice@example.com", true},
+		{"description = ''", true},
+		{"azure_did != ''", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateRules(tt.rule); err != nil {
+			t.Errorf("ValidateRules(%q) returned %q", tt.rule, err)
+			continue
+		}
+
+		rule, _ := rules.Parse(tt.rule)
+		if result, err := rule.Evaluate(env); err != nil {
+			t.Errorf("evaluating %q returned %q", tt.rule, err)
+		} else if result != tt.expected {
+			t.Errorf("evaluating %q returned %v, expected %v", tt.rule, result, tt.expected)
+		}
+	}
+
+	if _, err := env.Value(rules.Field{Name: "serial_number"}); err == nil {
+		t.Error("the value of an unknown field was returned")
+	}
+	if _, err := env.InGroup(rules.Field{Name: "model"}, "Finance"); err == nil {
+		t.Error("the group membership of a field which doesn't support it was returned")
+	}
+}
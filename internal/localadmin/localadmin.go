@@ -0,0 +1,221 @@
+// Package localadmin manages a local administrator account on devices with a unique random password which is set through the Accounts CSP.
+// Passwords are encrypted by the secrets service, rotated on a schedule and after they are revealed, and every time one is revealed it is logged.
+package localadmin
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/secrets"
+)
+
+// administratorsGroup is the Accounts CSP LocalUserGroup value of the local Administrators group
+const administratorsGroup = "2"
+
+// Ambiguous characters (eg. 0, O, 1, l and I) are left out as passwords are typed in by hand
+var passwordClasses = []string{
+	"ABCDEFGHJKLMNPQRSTUVWXYZ",
+	"abcdefghijkmnopqrstuvwxyz",
+	"23456789",
+	"!#%+-.:=?@_",
+}
+
+// ErrNoPassword is returned when a password is revealed before the device confirmed it set one
+var ErrNoPassword = errors.New("the device hasn't set a password for the account")
+
+// GeneratePassword returns a random password of the length which contains upper and lower case letters, digits and symbols
+func GeneratePassword(length int) (string, error) {
+	var alphabet = strings.Join(passwordClasses, "")
+	var max = big.NewInt(int64(len(alphabet)))
+	for {
+		var password = make([]byte, length)
+		for i := range password {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			password[i] = alphabet[n.Int64()]
+		}
+
+		var complex = true
+		for _, class := range passwordClasses {
+			if !strings.ContainsAny(string(password), class) {
+				complex = false
+			}
+		}
+		if complex {
+			return string(password), nil
+		}
+	}
+}
+
+// associatedData binds an encrypted password to its device and profile
+func associatedData(deviceID, profileID int32) []byte {
+	return []byte(strconv.Itoa(int(deviceID)) + "/" + strconv.Itoa(int(profileID)))
+}
+
+// Due returns whether the device's password must be rotated because it is older than the profile's rotation days or an earlier rotation was scheduled
+func Due(password db.LocalAdminPassword, profile db.LocalAdminProfile, now time.Time) bool {
+	if !password.SetAt.Valid {
+		return true
+	} else if password.RotateAt.Valid && !now.Before(password.RotateAt.Time) {
+		return true
+	}
+	return !now.Before(password.SetAt.Time.Add(time.Duration(profile.RotationDays) * 24 * time.Hour))
+}
+
+// rotateAfterView returns when a password revealed at the time must be rotated and false if the profile doesn't rotate passwords after they are revealed
+func rotateAfterView(profile db.LocalAdminProfile, revealedAt time.Time) (time.Time, bool) {
+	if profile.RotateAfterViewHours <= 0 {
+		return time.Time{}, false
+	}
+	return revealedAt.Add(time.Duration(profile.RotateAfterViewHours) * time.Hour), true
+}
+
+// Rotation is a password which should be set on the device
+type Rotation struct {
+	Profile  db.LocalAdminProfile
+	Password string
+}
+
+// Provision returns the passwords to set on the device. A new password is generated when the device's password is due for rotation and a pending password is
+// resent until the device confirms it was set. New passwords are stored before they are sent so they must be sent to the device in the same session.
+func Provision(ctx context.Context, q *db.Queries, s *secrets.Service, deviceID int32) ([]Rotation, error) {
+	profiles, err := q.GetDeviceLocalAdminProfiles(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var now = time.Now()
+	var rotations []Rotation
+	for _, profile := range profiles {
+		current, err := q.GetLocalAdminPassword(ctx, db.GetLocalAdminPasswordParams{
+			DeviceID:  deviceID,
+			ProfileID: profile.ID,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		if current.PendingEncryptedPassword != nil {
+			password, err := s.Decrypt(current.PendingEncryptedPassword, associatedData(deviceID, profile.ID))
+			if err != nil {
+				return nil, err
+			}
+
+			rotations = append(rotations, Rotation{Profile: profile, Password: string(password)})
+			continue
+		} else if err == nil && !Due(current, profile, now) {
+			continue
+		}
+
+		password, err := GeneratePassword(int(profile.PasswordLength))
+		if err != nil {
+			return nil, err
+		}
+
+		encryptedPassword, err := s.Encrypt([]byte(password), associatedData(deviceID, profile.ID))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := q.SetLocalAdminPendingPassword(ctx, db.SetLocalAdminPendingPasswordParams{
+			DeviceID:                 deviceID,
+			ProfileID:                profile.ID,
+			PendingEncryptedPassword: encryptedPassword,
+		}); err != nil {
+			return nil, err
+		}
+
+		rotations = append(rotations, Rotation{Profile: profile, Password: password})
+	}
+	return rotations, nil
+}
+
+// Command is a command which sets the password of the local administrator account
+type Command struct {
+	Command string
+	URI     string
+	Format  string
+	Value   string
+	Tracked bool // Whether the device setting the password is confirmed by the command's status
+}
+
+// Commands returns the commands which create the account (if it doesn't exist), add it to the Administrators group and set its password.
+// The Accounts CSP only creates accounts with Add which fails on existing accounts so the password is also set with Replace.
+func Commands(rotation Rotation) []Command {
+	var node = "./Device/Vendor/MSFT/Accounts/Users/" + rotation.Profile.AccountName
+	return []Command{
+		{Command: "Add", URI: node + "/Password", Format: "chr", Value: rotation.Password, Tracked: true},
+		{Command: "Add", URI: node + "/LocalUserGroup", Format: "int", Value: administratorsGroup},
+		{Command: "Replace", URI: node + "/Password", Format: "chr", Value: rotation.Password, Tracked: true},
+	}
+}
+
+// Revealed is a revealed password. The pending password is set if the device hasn't confirmed setting a new password as it may be using either.
+type Revealed struct {
+	AccountName     string    `json:"account_name"`
+	Password        string    `json:"password"`
+	SetAt           time.Time `json:"set_at"`
+	PendingPassword string    `json:"pending_password,omitempty"`
+}
+
+// Reveal decrypts the device's password, logs that the user accessed it and schedules its rotation if the profile rotates passwords after they are revealed.
+// It must be called within a transaction so the access is only logged if the password is revealed.
+func Reveal(ctx context.Context, q *db.Queries, s *secrets.Service, deviceID int32, profile db.LocalAdminProfile, upn, reason string) (Revealed, error) {
+	current, err := q.GetLocalAdminPassword(ctx, db.GetLocalAdminPasswordParams{
+		DeviceID:  deviceID,
+		ProfileID: profile.ID,
+	})
+	if err == sql.ErrNoRows || (err == nil && current.EncryptedPassword == nil) {
+		return Revealed{}, ErrNoPassword
+	} else if err != nil {
+		return Revealed{}, err
+	}
+
+	password, err := s.Decrypt(current.EncryptedPassword, associatedData(deviceID, profile.ID))
+	if err != nil {
+		return Revealed{}, err
+	}
+
+	var revealed = Revealed{
+		AccountName: profile.AccountName,
+		Password:    string(password),
+		SetAt:       current.SetAt.Time,
+	}
+	if current.PendingEncryptedPassword != nil {
+		pending, err := s.Decrypt(current.PendingEncryptedPassword, associatedData(deviceID, profile.ID))
+		if err != nil {
+			return Revealed{}, err
+		}
+		revealed.PendingPassword = string(pending)
+	}
+
+	if err := q.LogLocalAdminPasswordAccess(ctx, db.LogLocalAdminPasswordAccessParams{
+		DeviceID:    deviceID,
+		ProfileID:   profile.ID,
+		AccountName: profile.AccountName,
+		Upn:         upn,
+		Reason:      reason,
+	}); err != nil {
+		return Revealed{}, err
+	}
+
+	if rotateAt, ok := rotateAfterView(profile, time.Now()); ok {
+		if err := q.ScheduleLocalAdminRotation(ctx, db.ScheduleLocalAdminRotationParams{
+			DeviceID:  deviceID,
+			ProfileID: profile.ID,
+			RotateAt:  sql.NullTime{Time: rotateAt, Valid: true},
+		}); err != nil {
+			return Revealed{}, err
+		}
+	}
+	return revealed, nil
+}
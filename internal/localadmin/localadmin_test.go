@@ -0,0 +1,111 @@
+package localadmin
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	var alphabet = strings.Join(passwordClasses, "")
+	var seen = make(map[string]bool)
+	for _, length := range []int{minPasswordLength, 20, maxPasswordLength} {
+		for i := 0; i < 100; i++ {
+			password, err := GeneratePassword(length)
+			if err != nil {
+				t.Fatal(err)
+			} else if len(password) != length {
+				t.Fatalf("GeneratePassword(%d) returned %q which is %d characters", length, password, len(password))
+			}
+
+			for _, class := range passwordClasses {
+				if !strings.ContainsAny(password, class) {
+					t.Errorf("the password %q doesn't contain any of %q", password, class)
+				}
+			}
+			for _, c := range password {
+				if !strings.ContainsRune(alphabet, c) {
+					t.Errorf("the password %q contains %q which isn't in the alphabet", password, c)
+				}
+			}
+
+			if seen[password] {
+				t.Errorf("the password %q was generated twice", password)
+			}
+			seen[password] = true
+		}
+	}
+}
+
+// The shortest password which can contain every class has one character of each
+func TestGeneratePasswordShortest(t *testing.T) {
+	password, err := GeneratePassword(len(passwordClasses))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(password) != len(passwordClasses) {
+		t.Fatalf("GeneratePassword(%d) returned %q", len(passwordClasses), password)
+	}
+	for _, class := range passwordClasses {
+		if !strings.ContainsAny(password, class) {
+			t.Errorf("the password %q doesn't contain any of %q", password, class)
+		}
+	}
+}
+
+func TestPasswordAlphabetIsUnambiguous(t *testing.T) {
+	for _, c := range "0O1lI" {
+		if strings.ContainsRune(strings.Join(passwordClasses, ""), c) {
+			t.Errorf("the alphabet contains the ambiguous character %q", c)
+		}
+	}
+}
+
+func TestDue(t *testing.T) {
+	var setAt = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	var profile = db.LocalAdminProfile{RotationDays: 30, RotateAfterViewHours: 24}
+	var viewedAt = setAt.Add(10 * 24 * time.Hour)
+	rotateAt, _ := rotateAfterView(profile, viewedAt)
+
+	var tests = []struct {
+		name     string
+		password db.LocalAdminPassword
+		now      time.Time
+		expected bool
+	}{
+		{"never set", db.LocalAdminPassword{}, setAt, true},
+		{"just set", db.LocalAdminPassword{SetAt: sql.NullTime{Time: setAt, Valid: true}}, setAt, false},
+		{"before the rotation days", db.LocalAdminPassword{SetAt: sql.NullTime{Time: setAt, Valid: true}}, setAt.Add(30*24*time.Hour - time.Second), false},
+		{"at the rotation days", db.LocalAdminPassword{SetAt: sql.NullTime{Time: setAt, Valid: true}}, setAt.Add(30 * 24 * time.Hour), true},
+		{"after the rotation days", db.LocalAdminPassword{SetAt: sql.NullTime{Time: setAt, Valid: true}}, setAt.Add(31 * 24 * time.Hour), true},
+		{"before the rotation after it was viewed", db.LocalAdminPassword{SetAt: sql.NullTime{Time: setAt, Valid: true}, RotateAt: sql.NullTime{Time: rotateAt, Valid: true}}, viewedAt.Add(24*time.Hour - time.Second), false},
+		{"at the rotation after it was viewed", db.LocalAdminPassword{SetAt: sql.NullTime{Time: setAt, Valid: true}, RotateAt: sql.NullTime{Time: rotateAt, Valid: true}}, viewedAt.Add(24 * time.Hour), true},
+		{"a rotation scheduled after the rotation days", db.LocalAdminPassword{SetAt: sql.NullTime{Time: setAt, Valid: true}, RotateAt: sql.NullTime{Time: setAt.Add(60 * 24 * time.Hour), Valid: true}}, setAt.Add(30 * 24 * time.Hour), true},
+	}
+	for _, tt := range tests {
+		if due := Due(tt.password, profile, tt.now); due != tt.expected {
+			t.Errorf("%s: Due returned %v, expected %v", tt.name, due, tt.expected)
+		}
+	}
+}
+
+func TestRotateAfterView(t *testing.T) {
+	var viewedAt = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		hours    int32
+		expected time.Time
+		ok       bool
+	}{
+		{0, time.Time{}, false}, // rotating after the password is viewed is disabled
+		{1, viewedAt.Add(time.Hour), true},
+		{24, viewedAt.Add(24 * time.Hour), true},
+		{720, viewedAt.Add(30 * 24 * time.Hour), true},
+	}
+	for _, tt := range tests {
+		if rotateAt, ok := rotateAfterView(db.LocalAdminProfile{RotateAfterViewHours: tt.hours}, viewedAt); ok != tt.ok || !rotateAt.Equal(tt.expected) {
+			t.Errorf("rotateAfterView with %d hours returned %s %v, expected %s %v", tt.hours, rotateAt, ok, tt.expected, tt.ok)
+		}
+	}
+}
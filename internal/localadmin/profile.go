@@ -0,0 +1,45 @@
+package localadmin
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	minPasswordLength = 14
+	maxPasswordLength = 64
+)
+
+// Profile is the configuration of the local administrator account managed by a local admin profile
+type Profile struct {
+	AccountName          string `json:"account_name"`
+	PasswordLength       int32  `json:"password_length"`
+	RotationDays         int32  `json:"rotation_days"`
+	RotateAfterViewHours int32  `json:"rotate_after_view_hours"` // 0 disables rotating passwords after they are revealed
+}
+
+// DefaultProfile returns a profile with a 20 character password which is rotated every 30 days and 24 hours after it is revealed.
+// Requests are decoded into it so fields which aren't set keep their default.
+func DefaultProfile() Profile {
+	return Profile{
+		PasswordLength:       20,
+		RotationDays:         30,
+		RotateAfterViewHours: 24,
+	}
+}
+
+// Validate verifies the account name is a valid local account which isn't a built-in account and the password length and rotation schedule
+func (p Profile) Validate() error {
+	if p.AccountName == "" || len(p.AccountName) > 20 || strings.ContainsAny(p.AccountName, `"/\[]:;|=,+*?<>@`) || strings.Trim(p.AccountName, ". ") == "" {
+		return errors.New("the account name must be at most 20 characters and can't contain any of \"/\\[]:;|=,+*?<>@")
+	} else if strings.EqualFold(p.AccountName, "Administrator") || strings.EqualFold(p.AccountName, "Guest") {
+		return errors.New("the account name can't be a built-in account")
+	} else if p.PasswordLength < minPasswordLength || p.PasswordLength > maxPasswordLength {
+		return errors.New("the password length must be between 14 and 64")
+	} else if p.RotationDays < 1 || p.RotationDays > 365 {
+		return errors.New("the rotation days must be between 1 and 365")
+	} else if p.RotateAfterViewHours < 0 || p.RotateAfterViewHours > 30*24 {
+		return errors.New("the rotate after view hours must be between 0 and 720")
+	}
+	return nil
+}
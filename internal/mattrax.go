@@ -1,10 +1,15 @@
 package mattrax
 
 import (
+	"context"
+	"database/sql"
+
 	"github.com/gorilla/mux"
 	"github.com/mattrax/Mattrax/internal/authentication"
+	"github.com/mattrax/Mattrax/internal/catalog"
 	"github.com/mattrax/Mattrax/internal/certificates"
 	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/secrets"
 	"github.com/mattrax/Mattrax/internal/settings"
 	"github.com/patrickmn/go-cache"
 )
@@ -17,24 +22,64 @@ type Server struct {
 	Args         Arguments
 	GlobalRouter *mux.Router
 	Router       *mux.Router // Subrouter which is only accessible via secure origins (configured by admin)
+	HTTPRouter   *mux.Router // Router of the plain http server which only serves resources which are fetched without TLS (eg. CRLs)
+	AgentRouter  *mux.Router // Router of the https server which requires client certificates. It only serves the Mattrax agent's API.
 
-	DB    *db.Queries
-	Cache *cache.Cache
+	DB     *db.Queries
+	DBConn *sql.DB
+	Cache  *cache.Cache
 
 	Cert     *certificates.Service
 	Auth     *authentication.Service
 	Settings *settings.Service
+	Catalog  *catalog.Service
+	Secrets  *secrets.Service
+}
+
+// Tx runs fn inside a database transaction. The transaction is committed if fn succeeds and otherwise rolled back.
+func (srv *Server) Tx(ctx context.Context, fn func(ctx context.Context, q *db.Queries) error) error {
+	tx, err := srv.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(ctx, srv.DB.WithTx(tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // Arguments are the command line flags
 type Arguments struct {
-	Domain  string `placeholder:"\"mdm.example.com\"" help:"The domain your server is accessible from"`
-	DB      string `placeholder:"\"postgres://localhost/Mattrax\"" help:"The Postgres database connection url"`
-	Addr    string `default:":443" placeholder:"\":443\"" help:"The listen address of the https server"`
-	TLSCert string `default:"./certs/tls.crt" placeholder:"\"./certs/tls.crt\"" help:"The path for the tls certificate"`
-	TLSKey  string `default:"./certs/tls.key" placeholder:"\"./certs/tls.key\"" help:"The path for the tls certificates key"`
+	Domain           string `placeholder:"\"mdm.example.com\"" help:"The domain your server is accessible from"`
+	DB               string `placeholder:"\"postgres://localhost/Mattrax\"" help:"The Postgres database connection url"`
+	Addr             string `default:":443" placeholder:"\":443\"" help:"The listen address of the https server"`
+	HTTPAddr         string `default:":80" placeholder:"\":80\"" help:"The listen address of the http server which serves the SCEP CA's CRL as clients fetch CRLs without TLS"`
+	AgentAddr        string `default:":8443" placeholder:"\":8443\"" help:"The listen address of the https server which serves the Mattrax agent's API. It requires the device's MDM client certificate"`
+	TLSCert          string `default:"./certs/tls.crt" placeholder:"\"./certs/tls.crt\"" help:"The path for the tls certificate"`
+	TLSKey           string `default:"./certs/tls.key" placeholder:"\"./certs/tls.key\"" help:"The path for the tls certificates key"`
+	AppsDir          string `default:"./apps" placeholder:"\"./apps\"" help:"The directory uploaded app installers are stored in"`
+	DDF              string `default:"./ddf" placeholder:"\"./ddf\"" help:"The directory containing Microsoft's DDF v2 files which describe the settings policies can configure"`
+	AttemptRetention int    `default:"30" placeholder:"30" help:"The number of days enrollment attempts are kept for"`
+	Secrets          string `default:"./certs/secrets.key" placeholder:"\"./certs/secrets.key\"" help:"The path of the key which encrypts secrets (eg. BitLocker recovery keys) stored in the database. It is generated if it doesn't exist."`
 
 	Debug bool `help:"Enabled development mode. PLEASE DO NOT USE IN PRODUCTION!"`
+
+	Apply  *ApplyCommand  `arg:"subcommand:apply" help:"Apply a declarative configuration file instead of starting the server"`
+	Export *ExportCommand `arg:"subcommand:export" help:"Export the declarative configuration instead of starting the server"`
+}
+
+// ApplyCommand are the flags of the apply subcommand
+type ApplyCommand struct {
+	File   string `arg:"-f,required" placeholder:"\"mattrax.yaml\"" help:"The YAML or JSON configuration file to apply"`
+	DryRun bool   `arg:"--dry-run" help:"Only show the changes which would be made"`
+}
+
+// ExportCommand are the flags of the export subcommand
+type ExportCommand struct {
+	Format string `default:"yaml" placeholder:"\"yaml\"" help:"The format of the exported configuration (yaml or json)"`
 }
 
 // Description is for alexflint/go-args
@@ -0,0 +1,229 @@
+// Package policies resolves which payload wins when multiple assigned policies configure the same URI
+package policies
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/mattrax/Mattrax/internal/admx"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/pkg/null"
+)
+
+// Candidate is a payload assigned to a device (or user) along with the policy and group which assigned it
+type Candidate db.GetDevicesPayloadCandidatesRow
+
+// EffectivePayload is the payload which won for its URI. Groups contains every group which assigned the winning payload
+// and Conflicts contains the losing candidates which set a different value.
+type EffectivePayload struct {
+	Candidate
+	Groups    []int32     `json:"groups"`
+	Conflicts []Candidate `json:"conflicts"`
+}
+
+// Resolve computes one effective payload per URI. The candidate from the policy with the highest priority wins,
+// then the candidate from the group with the highest priority. Remaining ties are won by the oldest payload.
+// The effective payloads are in the order they must be deployed.
+func Resolve(candidates []Candidate) []EffectivePayload {
+	var sorted = make([]Candidate, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Uri != b.Uri {
+			return a.Uri < b.Uri
+		} else if a.PolicyPriority != b.PolicyPriority {
+			return a.PolicyPriority > b.PolicyPriority
+		} else if a.GroupPriority != b.GroupPriority {
+			return a.GroupPriority > b.GroupPriority
+		}
+		return a.ID < b.ID
+	})
+
+	var effective []EffectivePayload
+	for _, candidate := range sorted {
+		if len(effective) == 0 || effective[len(effective)-1].Uri != candidate.Uri {
+			effective = append(effective, EffectivePayload{
+				Candidate: candidate,
+				Groups:    []int32{candidate.GroupID},
+			})
+			continue
+		}
+
+		// The same payload assigned through multiple groups or a payload setting the same value isn't a conflict
+		var winner = &effective[len(effective)-1]
+		if candidate.ID == winner.ID {
+			winner.Groups = append(winner.Groups, candidate.GroupID)
+		} else if !sameValue(candidate, winner.Candidate) {
+			winner.Conflicts = append(winner.Conflicts, candidate)
+		}
+	}
+
+	// ADMX files must be ingested before the policies they define can be configured so they are deployed first
+	sort.SliceStable(effective, func(i, j int) bool {
+		return admx.IsInstallURI(effective[i].Uri) && !admx.IsInstallURI(effective[j].Uri)
+	})
+	return effective
+}
+
+func sameValue(a, b Candidate) bool {
+	return a.Format == b.Format && a.Type == b.Type && a.Value == b.Value && a.Exec == b.Exec
+}
+
+// DevicePayloads returns the effective payloads of the device from its groups and the rollouts it is included in
+func DevicePayloads(ctx context.Context, q *db.Queries, deviceID int32) ([]EffectivePayload, error) {
+	candidates, err := deviceCandidates(ctx, q, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	return Resolve(candidates), nil
+}
+
+func deviceCandidates(ctx context.Context, q *db.Queries, deviceID int32) ([]Candidate, error) {
+	rows, err := q.GetDevicesPayloadCandidates(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	rolloutRows, err := q.GetDevicesRolloutCandidates(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates = make([]Candidate, 0, len(rows)+len(rolloutRows))
+	for _, row := range rows {
+		candidates = append(candidates, Candidate(row))
+	}
+	for _, row := range rolloutRows {
+		candidates = append(candidates, Candidate(row))
+	}
+	return candidates, nil
+}
+
+// UserPayloads returns the effective payloads of the user from their user groups
+func UserPayloads(ctx context.Context, q *db.Queries, upn string) ([]EffectivePayload, error) {
+	rows, err := q.GetUsersPayloadCandidates(ctx, upn)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates = make([]Candidate, len(rows))
+	for i, row := range rows {
+		candidates[i] = Candidate(row)
+	}
+	return Resolve(candidates), nil
+}
+
+// Conflicts filters the effective payloads to those which had conflicting candidates
+func Conflicts(effective []EffectivePayload) []EffectivePayload {
+	var conflicts = make([]EffectivePayload, 0)
+	for _, payload := range effective {
+		if len(payload.Conflicts) > 0 {
+			conflicts = append(conflicts, payload)
+		}
+	}
+	return conflicts
+}
+
+// Deployment compares the effective payloads to those already deployed. Awaiting are the winners which must be sent to the device
+// and detached are the deployed payloads which are no longer effective. The upn is only set for payloads deployed to a user's session.
+func Deployment(ctx context.Context, q *db.Queries, deviceID int32, upn null.String, effective []EffectivePayload) (awaiting []Candidate, detached []db.GetDeployedPayloadsRow, err error) {
+	deployed, err := q.GetDeployedPayloads(ctx, db.GetDeployedPayloadsParams{
+		DeviceID: deviceID,
+		Upn:      upn,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var deployedIDs = make(map[int32]bool, len(deployed))
+	for _, payload := range deployed {
+		deployedIDs[payload.ID] = true
+	}
+
+	var effectiveIDs = make(map[int32]bool, len(effective))
+	for _, payload := range effective {
+		effectiveIDs[payload.ID] = true
+		if !deployedIDs[payload.ID] {
+			awaiting = append(awaiting, payload.Candidate)
+		}
+	}
+
+	for _, payload := range deployed {
+		if !effectiveIDs[payload.ID] {
+			detached = append(detached, payload)
+		}
+	}
+	return awaiting, detached, nil
+}
+
+// Delete removes the policy and its assignments. Its payloads are detached instead of deleted so they are removed from devices on their next checkin.
+func Delete(ctx context.Context, q *db.Queries, policyID int32) error {
+	if err := q.DetachPoliciesPayloads(ctx, sql.NullInt32{Int32: policyID, Valid: true}); err != nil {
+		return err
+	}
+	if err := q.DeletePolicyGroups(ctx, sql.NullInt32{Int32: policyID, Valid: true}); err != nil {
+		return err
+	}
+	if err := q.DeletePolicyUserGroups(ctx, policyID); err != nil {
+		return err
+	}
+	if err := q.DeletePolicyRollouts(ctx, policyID); err != nil {
+		return err
+	}
+	if err := q.DeletePolicy(ctx, policyID); err != nil {
+		return err
+	}
+	return q.DeleteOrphanedPayloads(ctx)
+}
+
+// SetPayloads creates or updates the policy's payloads for each URI and returns their IDs. The policy's other payloads are left unchanged.
+func SetPayloads(ctx context.Context, q *db.Queries, policyID int32, payloads []VersionPayload) ([]int32, error) {
+	current, err := q.GetPoliciesPayloads(ctx, sql.NullInt32{Int32: policyID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var existing = make(map[string]db.PoliciesPayload, len(current))
+	for _, payload := range current {
+		existing[payload.Uri] = payload
+	}
+
+	var ids = make([]int32, len(payloads))
+	for i, payload := range payloads {
+		previous, found := existing[payload.Uri]
+		if !found {
+			if ids[i], err = q.CreatePolicyPayload(ctx, db.CreatePolicyPayloadParams{
+				PolicyID: sql.NullInt32{Int32: policyID, Valid: true},
+				Uri:      payload.Uri,
+				Format:   payload.Format,
+				Type:     payload.Type,
+				Value:    payload.Value,
+				Exec:     payload.Exec,
+			}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ids[i] = previous.ID
+
+		if payload == (VersionPayload{Uri: previous.Uri, Format: previous.Format, Type: previous.Type, Value: previous.Value, Exec: previous.Exec}) {
+			continue
+		}
+
+		if err := q.UpdatePolicyPayload(ctx, db.UpdatePolicyPayloadParams{
+			ID:     previous.ID,
+			Format: payload.Format,
+			Type:   payload.Type,
+			Value:  payload.Value,
+			Exec:   payload.Exec,
+		}); err != nil {
+			return nil, err
+		}
+
+		if err := q.InvalidatePayloadCache(ctx, sql.NullInt32{Int32: previous.ID, Valid: true}); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,181 @@
+package policies
+
+import (
+	"reflect"
+	"testing"
+)
+
+const admxInstallURI = "./Vendor/MSFT/Policy/ConfigOperations/ADMXInstall/Chrome/Policy/ChromeAdmx"
+
+// candidate returns a chr payload candidate assigned by the policy and group with the priorities
+func candidate(id int32, uri, value string, policyID int32, policyPriority int16, groupID int32, groupPriority int16) Candidate {
+	return Candidate{
+		ID:             id,
+		Uri:            uri,
+		Format:         "chr",
+		Value:          value,
+		PolicyID:       policyID,
+		PolicyPriority: policyPriority,
+		GroupID:        groupID,
+		GroupPriority:  groupPriority,
+	}
+}
+
+func TestResolve(t *testing.T) {
+	var tests = []struct {
+		name       string
+		candidates []Candidate
+		winners    []int32 // The IDs of the effective payloads in their deployment order
+		groups     [][]int32
+		conflicts  [][]int32
+	}{
+		{
+			name:       "no candidates",
+			candidates: nil,
+		},
+		{
+			name: "one candidate per uri",
+			candidates: []Candidate{
+				candidate(2, "./Device/B", "1", 1, 0, 1, 0),
+				candidate(1, "./Device/A", "1", 1, 0, 1, 0),
+			},
+			winners:   []int32{1, 2},
+			groups:    [][]int32{{1}, {1}},
+			conflicts: [][]int32{nil, nil},
+		},
+		{
+			name: "policy priority wins over group priority",
+			candidates: []Candidate{
+				candidate(1, "./Device/A", "low", 1, 0, 1, 100),
+				candidate(2, "./Device/A", "high", 2, 10, 2, 0),
+			},
+			winners:   []int32{2},
+			groups:    [][]int32{{2}},
+			conflicts: [][]int32{{1}},
+		},
+		{
+			name: "group priority breaks policy priority ties",
+			candidates: []Candidate{
+				candidate(1, "./Device/A", "low", 1, 5, 1, 0),
+				candidate(2, "./Device/A", "high", 2, 5, 2, 1),
+			},
+			winners:   []int32{2},
+			groups:    [][]int32{{2}},
+			conflicts: [][]int32{{1}},
+		},
+		{
+			name: "the oldest payload breaks remaining ties",
+			candidates: []Candidate{
+				candidate(7, "./Device/A", "newer", 2, 5, 2, 1),
+				candidate(3, "./Device/A", "older", 1, 5, 1, 1),
+			},
+			winners:   []int32{3},
+			groups:    [][]int32{{1}},
+			conflicts: [][]int32{{7}},
+		},
+		{
+			name: "negative priorities lose to the default",
+			candidates: []Candidate{
+				candidate(1, "./Device/A", "a", 1, -1, 1, 0),
+				candidate(2, "./Device/A", "b", 2, 0, 2, 0),
+			},
+			winners:   []int32{2},
+			groups:    [][]int32{{2}},
+			conflicts: [][]int32{{1}},
+		},
+		{
+			name: "the same payload assigned through multiple groups isn't a conflict",
+			candidates: []Candidate{
+				candidate(1, "./Device/A", "a", 1, 0, 3, 0),
+				candidate(1, "./Device/A", "a", 1, 0, 1, 5),
+			},
+			winners:   []int32{1},
+			groups:    [][]int32{{1, 3}},
+			conflicts: [][]int32{nil},
+		},
+		{
+			name: "a payload setting the same value isn't a conflict",
+			candidates: []Candidate{
+				candidate(1, "./Device/A", "a", 1, 1, 1, 0),
+				candidate(2, "./Device/A", "a", 2, 0, 2, 0),
+				candidate(3, "./Device/A", "b", 3, 0, 3, 0),
+			},
+			winners:   []int32{1},
+			groups:    [][]int32{{1}},
+			conflicts: [][]int32{{3}},
+		},
+		{
+			name: "admx installs are deployed first",
+			candidates: []Candidate{
+				candidate(1, "./Device/Vendor/MSFT/Policy/Config/Chrome~Policy~googlechrome/HomepageLocation", "<enabled/>", 1, 0, 1, 0),
+				candidate(2, "./Device/A", "a", 1, 0, 1, 0),
+				candidate(3, admxInstallURI, "<policyDefinitions/>", 1, 0, 1, 0),
+				candidate(4, "./Vendor/MSFT/Policy/ConfigOperations/ADMXInstall/Acme/Policy/AcmeAdmx", "<policyDefinitions/>", 2, 0, 1, 0),
+			},
+			winners:   []int32{4, 3, 2, 1}, // Within each class payloads are in URI order
+			groups:    [][]int32{{1}, {1}, {1}, {1}},
+			conflicts: [][]int32{nil, nil, nil, nil},
+		},
+		{
+			name: "admx installs are resolved like any other payload",
+			candidates: []Candidate{
+				candidate(1, "./Device/A", "a", 1, 0, 1, 0),
+				candidate(2, admxInstallURI, "<v1/>", 1, 0, 1, 0),
+				candidate(3, admxInstallURI, "<v2/>", 2, 1, 2, 0),
+			},
+			winners:   []int32{3, 1},
+			groups:    [][]int32{{2}, {1}},
+			conflicts: [][]int32{{2}, nil},
+		},
+	}
+	for _, tt := range tests {
+		var effective = Resolve(tt.candidates)
+
+		var winners []int32
+		var groups, conflicts [][]int32
+		for _, payload := range effective {
+			winners = append(winners, payload.ID)
+			groups = append(groups, payload.Groups)
+
+			var ids []int32
+			for _, conflict := range payload.Conflicts {
+				ids = append(ids, conflict.ID)
+			}
+			conflicts = append(conflicts, ids)
+		}
+
+		if !reflect.DeepEqual(winners, tt.winners) {
+			t.Errorf("%s: the winners were %v, expected %v", tt.name, winners, tt.winners)
+		} else if !reflect.DeepEqual(groups, tt.groups) {
+			t.Errorf("%s: the winners' groups were %v, expected %v", tt.name, groups, tt.groups)
+		} else if !reflect.DeepEqual(conflicts, tt.conflicts) {
+			t.Errorf("%s: the conflicts were %v, expected %v", tt.name, conflicts, tt.conflicts)
+		}
+	}
+}
+
+func TestResolveDoesntModifyCandidates(t *testing.T) {
+	var candidates = []Candidate{
+		candidate(2, "./Device/B", "1", 1, 0, 1, 0),
+		candidate(1, "./Device/A", "1", 1, 0, 1, 0),
+	}
+	var original = append([]Candidate(nil), candidates...)
+
+	Resolve(candidates)
+	if !reflect.DeepEqual(candidates, original) {
+		t.Errorf("Resolve reordered its candidates to %v", candidates)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	var effective = Resolve([]Candidate{
+		candidate(1, "./Device/A", "a", 1, 1, 1, 0),
+		candidate(2, "./Device/A", "b", 2, 0, 2, 0),
+		candidate(3, "./Device/B", "a", 1, 0, 1, 0),
+	})
+
+	conflicts := Conflicts(effective)
+	if len(conflicts) != 1 || conflicts[0].Uri != "./Device/A" {
+		t.Errorf("Conflicts returned %v, expected only ./Device/A", conflicts)
+	}
+}
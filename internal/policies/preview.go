@@ -0,0 +1,144 @@
+package policies
+
+import (
+	"context"
+
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/pkg/null"
+)
+
+// Status is the deployment status of an effective payload on a device
+type Status string
+
+const (
+	StatusDeployed Status = "deployed"
+	StatusPending  Status = "pending"
+	// StatusScheduled is a pending payload being held back until its schedule allows it to be deployed
+	StatusScheduled Status = "scheduled"
+)
+
+// ResultantPayload is an effective payload along with whether it has been deployed to the device.
+// DeployedVersion is the version of the payload's policy the device has and is 0 until the device confirms it applied the payload.
+type ResultantPayload struct {
+	EffectivePayload
+	Status          Status `json:"status"`
+	DeployedVersion int32  `json:"deployed_version"`
+}
+
+// Resultant returns the deployment status of each effective payload. Removing are the deployed payloads which will be deleted on the device's next checkin.
+func Resultant(ctx context.Context, q *db.Queries, deviceID int32, upn null.String, effective []EffectivePayload) (payloads []ResultantPayload, removing []db.GetDeployedPayloadsRow, err error) {
+	deployed, err := q.GetDeployedPayloads(ctx, db.GetDeployedPayloadsParams{
+		DeviceID: deviceID,
+		Upn:      upn,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var deployedVersions = make(map[int32]int32, len(deployed))
+	for _, payload := range deployed {
+		deployedVersions[payload.ID] = payload.PolicyVersion
+	}
+
+	var effectiveIDs = make(map[int32]bool, len(effective))
+	payloads = make([]ResultantPayload, 0, len(effective))
+	for _, payload := range effective {
+		effectiveIDs[payload.ID] = true
+
+		var status = StatusPending
+		version, found := deployedVersions[payload.ID]
+		if found {
+			status = StatusDeployed
+		}
+		payloads = append(payloads, ResultantPayload{
+			EffectivePayload: payload,
+			Status:           status,
+			DeployedVersion:  version,
+		})
+	}
+
+	removing = make([]db.GetDeployedPayloadsRow, 0)
+	for _, payload := range deployed {
+		if !effectiveIDs[payload.ID] {
+			removing = append(removing, payload)
+		}
+	}
+	return payloads, removing, nil
+}
+
+// PreviewDevicePayloads returns the effective payloads the device would have if it was added to and removed from the groups
+func PreviewDevicePayloads(ctx context.Context, q *db.Queries, deviceID int32, addGroups, removeGroups []int32) ([]EffectivePayload, error) {
+	current, err := deviceCandidates(ctx, q, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var excluded = make(map[int32]bool, len(addGroups)+len(removeGroups))
+	for _, id := range addGroups {
+		excluded[id] = true
+	}
+	for _, id := range removeGroups {
+		excluded[id] = true
+	}
+
+	// Candidates from the added groups are loaded separately so groups the device is already in aren't counted twice
+	var candidates = make([]Candidate, 0, len(current))
+	for _, candidate := range current {
+		if !excluded[candidate.GroupID] {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	if len(addGroups) > 0 {
+		rows, err := q.GetGroupsPayloadCandidates(ctx, addGroups)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			candidates = append(candidates, Candidate(row))
+		}
+	}
+	return Resolve(candidates), nil
+}
+
+// Change is a URI whose effective payload differs between two sets of effective payloads.
+// Before is nil for URIs which are newly configured and After is nil for URIs which are no longer configured.
+type Change struct {
+	Uri    string            `json:"uri"`
+	Before *EffectivePayload `json:"before"`
+	After  *EffectivePayload `json:"after"`
+}
+
+// Diff compares two sets of effective payloads by URI
+func Diff(before, after []EffectivePayload) []Change {
+	var beforeURIs = make(map[string]*EffectivePayload, len(before))
+	for i := range before {
+		beforeURIs[before[i].Uri] = &before[i]
+	}
+
+	var changes = make([]Change, 0)
+	var afterURIs = make(map[string]bool, len(after))
+	for i := range after {
+		afterURIs[after[i].Uri] = true
+
+		previous, found := beforeURIs[after[i].Uri]
+		if !found || (previous.ID != after[i].ID && !sameValue(previous.Candidate, after[i].Candidate)) {
+			changes = append(changes, Change{
+				Uri:    after[i].Uri,
+				Before: previous,
+				After:  &after[i],
+			})
+		}
+	}
+
+	for i := range before {
+		if !afterURIs[before[i].Uri] {
+			changes = append(changes, Change{
+				Uri:    before[i].Uri,
+				Before: &before[i],
+			})
+		}
+	}
+	return changes
+}
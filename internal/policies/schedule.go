@@ -0,0 +1,119 @@
+package policies
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+// Window is a weekly maintenance window. Start and End are minutes after midnight in the group's timezone.
+// Windows which end before they start finish on the following day.
+type Window struct {
+	Weekday int16 `json:"weekday"`
+	Start   int16 `json:"start"`
+	End     int16 `json:"end"`
+}
+
+// Validate verifies the window is within a week
+func (w Window) Validate() error {
+	if w.Weekday < 0 || w.Weekday > 6 {
+		return errors.New("window weekdays must be between 0 (Sunday) and 6 (Saturday)")
+	} else if w.Start < 0 || w.Start >= 24*60 || w.End < 0 || w.End > 24*60 {
+		return errors.New("window times must be minutes after midnight")
+	} else if w.Start == w.End {
+		return errors.New("windows must not be empty")
+	}
+	return nil
+}
+
+// Contains returns whether the time, which must be in the window's timezone, is within the window
+func (w Window) Contains(t time.Time) bool {
+	var weekday, minute = int16(t.Weekday()), int16(t.Hour()*60 + t.Minute())
+	if w.Start < w.End {
+		return weekday == w.Weekday && minute >= w.Start && minute < w.End
+	}
+	return (weekday == w.Weekday && minute >= w.Start) || (weekday == (w.Weekday+1)%7 && minute < w.End)
+}
+
+// Schedule restricts when a group's policy is deployed to its devices
+type Schedule struct {
+	Location  *time.Location
+	NotBefore time.Time
+	Windows   []Window
+}
+
+// Allows returns whether the policy can be deployed at the time
+func (s Schedule) Allows(now time.Time) bool {
+	if now.Before(s.NotBefore) {
+		return false
+	} else if len(s.Windows) == 0 {
+		return true
+	}
+
+	var local = now.In(s.Location)
+	for _, window := range s.Windows {
+		if window.Contains(local) {
+			return true
+		}
+	}
+	return false
+}
+
+type assignment struct {
+	GroupID  int32
+	PolicyID int32
+}
+
+// Schedules are the schedules of a device's group policies. Assignments without a schedule are always deployed.
+type Schedules map[assignment]*Schedule
+
+// DeviceSchedules returns the schedules of the device's group policies
+func DeviceSchedules(ctx context.Context, q *db.Queries, deviceID int32) (Schedules, error) {
+	rows, err := q.GetDevicesAssignmentSchedules(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var schedules = make(Schedules, len(rows))
+	for _, row := range rows {
+		// The timezone is validated when it's set so this only fails if the system's timezone database changes
+		location, err := time.LoadLocation(row.Timezone)
+		if err != nil {
+			location = time.UTC
+		}
+
+		schedules[assignment{row.GroupID.Int32, row.PolicyID.Int32}] = &Schedule{
+			Location:  location,
+			NotBefore: row.NotBefore.Time,
+		}
+	}
+
+	windows, err := q.GetDevicesAssignmentWindows(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, window := range windows {
+		if schedule, found := schedules[assignment{window.GroupID, window.PolicyID}]; found {
+			schedule.Windows = append(schedule.Windows, Window{
+				Weekday: window.Weekday,
+				Start:   window.StartMinute,
+				End:     window.EndMinute,
+			})
+		}
+	}
+	return schedules, nil
+}
+
+// Allows returns whether the payload can be deployed at the time. It can be once any of the groups which assigned it allow it.
+func (s Schedules) Allows(payload EffectivePayload, now time.Time) bool {
+	for _, groupID := range payload.Groups {
+		schedule, found := s[assignment{groupID, payload.PolicyID}]
+		if !found || schedule.Allows(now) {
+			return true
+		}
+	}
+	return len(payload.Groups) == 0
+}
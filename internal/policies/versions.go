@@ -0,0 +1,254 @@
+package policies
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+// VersionPayload is a payload as stored in a policy version
+type VersionPayload struct {
+	Uri    string `json:"uri"`
+	Format string `json:"format"`
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	Exec   bool   `json:"exec"`
+}
+
+// Snapshot stores the current state of the policy and its payloads as a new version. It must be called within the transaction which modified the policy.
+func Snapshot(ctx context.Context, q *db.Queries, policyID int32, author string) (int32, error) {
+	policy, err := q.GetPolicy(ctx, policyID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := q.GetPoliciesPayloads(ctx, sql.NullInt32{Int32: policyID, Valid: true})
+	if err != nil {
+		return 0, err
+	}
+
+	var payloads = make([]VersionPayload, len(rows))
+	for i, row := range rows {
+		payloads[i] = VersionPayload{
+			Uri:    row.Uri,
+			Format: row.Format,
+			Type:   row.Type,
+			Value:  row.Value,
+			Exec:   row.Exec,
+		}
+	}
+
+	raw, err := json.Marshal(payloads)
+	if err != nil {
+		return 0, err
+	}
+
+	// The policy row is locked by the increment so concurrent changes get sequential versions
+	version, err := q.IncrementPolicyVersion(ctx, policyID)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, q.CreatePolicyVersion(ctx, db.CreatePolicyVersionParams{
+		PolicyID:    policyID,
+		Version:     version,
+		Author:      author,
+		Name:        policy.Name,
+		Description: policy.Description,
+		Priority:    policy.Priority,
+		Payloads:    raw,
+	})
+}
+
+// Baseline stores the current state of a policy which has never been versioned (eg. it was created before policies were versioned) so its state before the edit can be restored.
+// It must be called within the transaction which modifies the policy before it is modified.
+func Baseline(ctx context.Context, q *db.Queries, policyID int32) error {
+	policy, err := q.GetPolicy(ctx, policyID)
+	if err != nil || policy.Version != 0 {
+		return err
+	}
+
+	_, err = Snapshot(ctx, q, policyID, "")
+	return err
+}
+
+// VersionPayloads decodes the payloads stored in a policy version
+func VersionPayloads(version db.PolicyVersion) ([]VersionPayload, error) {
+	var payloads []VersionPayload
+	if err := json.Unmarshal(version.Payloads, &payloads); err != nil {
+		return nil, err
+	}
+	return payloads, nil
+}
+
+// Rollback restores the policy and its payloads to a previous version and stores the result as a new version.
+// Changed payloads have their device cache invalidated and removed payloads are detached so devices are redeployed on their next checkin.
+func Rollback(ctx context.Context, q *db.Queries, policyID, version int32, author string) (int32, error) {
+	target, err := q.GetPolicyVersion(ctx, db.GetPolicyVersionParams{
+		PolicyID: policyID,
+		Version:  version,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	targetPayloads, err := VersionPayloads(target)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := q.UpdatePolicy(ctx, db.UpdatePolicyParams{
+		ID:          policyID,
+		Name:        target.Name,
+		Description: target.Description,
+		Priority:    target.Priority,
+	}); err != nil {
+		return 0, err
+	}
+
+	var targetURIs = make(map[string]VersionPayload, len(targetPayloads))
+	for _, payload := range targetPayloads {
+		targetURIs[payload.Uri] = payload
+	}
+
+	current, err := q.GetPoliciesPayloads(ctx, sql.NullInt32{Int32: policyID, Valid: true})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, payload := range current {
+		previous, found := targetURIs[payload.Uri]
+		if !found {
+			if err := q.DetachPolicyPayload(ctx, payload.ID); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		delete(targetURIs, payload.Uri)
+
+		if previous == (VersionPayload{Uri: payload.Uri, Format: payload.Format, Type: payload.Type, Value: payload.Value, Exec: payload.Exec}) {
+			continue
+		}
+
+		if err := q.UpdatePolicyPayload(ctx, db.UpdatePolicyPayloadParams{
+			ID:     payload.ID,
+			Format: previous.Format,
+			Type:   previous.Type,
+			Value:  previous.Value,
+			Exec:   previous.Exec,
+		}); err != nil {
+			return 0, err
+		}
+
+		if err := q.InvalidatePayloadCache(ctx, sql.NullInt32{Int32: payload.ID, Valid: true}); err != nil {
+			return 0, err
+		}
+	}
+
+	// Ordered by the target version so recreated payloads keep their original order
+	for _, payload := range targetPayloads {
+		if _, create := targetURIs[payload.Uri]; !create {
+			continue
+		}
+
+		if _, err := q.CreatePolicyPayload(ctx, db.CreatePolicyPayloadParams{
+			PolicyID: sql.NullInt32{Int32: policyID, Valid: true},
+			Uri:      payload.Uri,
+			Format:   payload.Format,
+			Type:     payload.Type,
+			Value:    payload.Value,
+			Exec:     payload.Exec,
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := q.DeleteOrphanedPayloads(ctx); err != nil {
+		return 0, err
+	}
+	return Snapshot(ctx, q, policyID, author)
+}
+
+// FieldChange is a policy field which differs between two versions
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// PayloadChange is a URI whose payload differs between two versions.
+// Before is nil for payloads which were added and After is nil for payloads which were removed.
+type PayloadChange struct {
+	Uri    string          `json:"uri"`
+	Before *VersionPayload `json:"before"`
+	After  *VersionPayload `json:"after"`
+}
+
+// VersionDiff is the changes made to a policy between two of its versions
+type VersionDiff struct {
+	From     int32           `json:"from"`
+	To       int32           `json:"to"`
+	Fields   []FieldChange   `json:"fields"`
+	Payloads []PayloadChange `json:"payloads"`
+}
+
+// DiffVersions compares two versions of a policy
+func DiffVersions(from, to db.PolicyVersion) (VersionDiff, error) {
+	var diff = VersionDiff{
+		From:     from.Version,
+		To:       to.Version,
+		Fields:   make([]FieldChange, 0),
+		Payloads: make([]PayloadChange, 0),
+	}
+
+	if from.Name != to.Name {
+		diff.Fields = append(diff.Fields, FieldChange{Field: "name", Before: from.Name, After: to.Name})
+	}
+	if from.Description != to.Description {
+		diff.Fields = append(diff.Fields, FieldChange{Field: "description", Before: from.Description, After: to.Description})
+	}
+	if from.Priority != to.Priority {
+		diff.Fields = append(diff.Fields, FieldChange{Field: "priority", Before: from.Priority, After: to.Priority})
+	}
+
+	fromPayloads, err := VersionPayloads(from)
+	if err != nil {
+		return VersionDiff{}, err
+	}
+
+	toPayloads, err := VersionPayloads(to)
+	if err != nil {
+		return VersionDiff{}, err
+	}
+
+	var fromURIs = make(map[string]*VersionPayload, len(fromPayloads))
+	for i := range fromPayloads {
+		fromURIs[fromPayloads[i].Uri] = &fromPayloads[i]
+	}
+
+	var toURIs = make(map[string]bool, len(toPayloads))
+	for i := range toPayloads {
+		toURIs[toPayloads[i].Uri] = true
+
+		previous, found := fromURIs[toPayloads[i].Uri]
+		if !found || *previous != toPayloads[i] {
+			diff.Payloads = append(diff.Payloads, PayloadChange{
+				Uri:    toPayloads[i].Uri,
+				Before: previous,
+				After:  &toPayloads[i],
+			})
+		}
+	}
+
+	for i := range fromPayloads {
+		if !toURIs[fromPayloads[i].Uri] {
+			diff.Payloads = append(diff.Payloads, PayloadChange{
+				Uri:    fromPayloads[i].Uri,
+				Before: &fromPayloads[i],
+			})
+		}
+	}
+	return diff, nil
+}
@@ -0,0 +1,159 @@
+package profiles
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/mattrax/Mattrax/internal/policies"
+)
+
+// BitLockerURIPrefix is the prefix of the BitLocker CSP's nodes
+const BitLockerURIPrefix = "./Device/Vendor/MSFT/BitLocker/"
+
+// BitLockerEncryptionMethod is the cipher a type of drive is encrypted with
+type BitLockerEncryptionMethod string
+
+const (
+	BitLockerAESCBC128 BitLockerEncryptionMethod = "aes_cbc_128"
+	BitLockerAESCBC256 BitLockerEncryptionMethod = "aes_cbc_256"
+	BitLockerXTSAES128 BitLockerEncryptionMethod = "xts_aes_128"
+	BitLockerXTSAES256 BitLockerEncryptionMethod = "xts_aes_256"
+)
+
+// bitLockerEncryptionMethods are the EncryptionMethodByDriveType values of the encryption methods
+var bitLockerEncryptionMethods = map[BitLockerEncryptionMethod]int{
+	BitLockerAESCBC128: 3,
+	BitLockerAESCBC256: 4,
+	BitLockerXTSAES128: 6,
+	BitLockerXTSAES256: 7,
+}
+
+// BitLockerStartupAuthentication is what unlocks the OS drive when the device starts
+type BitLockerStartupAuthentication string
+
+const (
+	BitLockerStartupTPM    BitLockerStartupAuthentication = "tpm"
+	BitLockerStartupTPMPIN BitLockerStartupAuthentication = "tpm_pin"
+)
+
+// BitLocker is a BitLocker drive encryption profile. Drives are encrypted with a recovery password protector which the Mattrax agent escrows to Mattrax.
+// Silent encryption doesn't prompt the user (or allow standard users to be prompted) which requires the TPM only startup authentication.
+type BitLocker struct {
+	RequireDeviceEncryption   bool                           `json:"require_device_encryption"`
+	OSEncryptionMethod        BitLockerEncryptionMethod      `json:"os_encryption_method,omitempty"`
+	FixedEncryptionMethod     BitLockerEncryptionMethod      `json:"fixed_encryption_method,omitempty"`
+	RemovableEncryptionMethod BitLockerEncryptionMethod      `json:"removable_encryption_method,omitempty"`
+	StartupAuthentication     BitLockerStartupAuthentication `json:"startup_authentication,omitempty"`
+	AllowWithoutTPM           bool                           `json:"allow_without_tpm"`
+	Silent                    bool                           `json:"silent"`
+}
+
+// Defaults sets the unset encryption methods and startup authentication to Windows' defaults
+func (b *BitLocker) Defaults() {
+	if b.OSEncryptionMethod == "" {
+		b.OSEncryptionMethod = BitLockerXTSAES128
+	}
+	if b.FixedEncryptionMethod == "" {
+		b.FixedEncryptionMethod = BitLockerXTSAES128
+	}
+	if b.RemovableEncryptionMethod == "" {
+		b.RemovableEncryptionMethod = BitLockerAESCBC128
+	}
+	if b.StartupAuthentication == "" {
+		b.StartupAuthentication = BitLockerStartupTPM
+	}
+}
+
+// Validate verifies the encryption methods and startup authentication
+func (b BitLocker) Validate() error {
+	for _, method := range []BitLockerEncryptionMethod{b.OSEncryptionMethod, b.FixedEncryptionMethod, b.RemovableEncryptionMethod} {
+		if _, found := bitLockerEncryptionMethods[method]; !found {
+			return fmt.Errorf("the encryption methods must be one of: %s, %s, %s, %s", BitLockerAESCBC128, BitLockerAESCBC256, BitLockerXTSAES128, BitLockerXTSAES256)
+		}
+	}
+
+	switch b.StartupAuthentication {
+	case BitLockerStartupTPM:
+	case BitLockerStartupTPMPIN:
+		if b.Silent {
+			return fmt.Errorf("silent encryption requires the %s startup authentication", BitLockerStartupTPM)
+		}
+	default:
+		return fmt.Errorf("the startup authentication must be one of: %s, %s", BitLockerStartupTPM, BitLockerStartupTPMPIN)
+	}
+
+	if b.Silent && b.AllowWithoutTPM {
+		return errors.New("silent encryption requires a TPM")
+	}
+	return nil
+}
+
+// Payloads validates the profile and renders the BitLocker CSP payloads which configure it
+func (b BitLocker) Payloads() ([]policies.VersionPayload, error) {
+	b.Defaults()
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Startup authentication options: 0 is blocked, 1 is required and 2 is allowed
+	var tpm, tpmPIN = "1", "0"
+	if b.StartupAuthentication == BitLockerStartupTPMPIN {
+		tpm, tpmPIN = "0", "1"
+	}
+
+	var payloads = []policies.VersionPayload{
+		{
+			Uri:    BitLockerURIPrefix + "RequireDeviceEncryption",
+			Format: "int",
+			Value:  boolInt(b.RequireDeviceEncryption),
+		},
+		{
+			Uri:    BitLockerURIPrefix + "EncryptionMethodByDriveType",
+			Format: "chr",
+			Value: `<enabled/>` +
+				`<data id="EncryptionMethodWithXtsOsDropDown_Name" value="` + strconv.Itoa(bitLockerEncryptionMethods[b.OSEncryptionMethod]) + `"/>` +
+				`<data id="EncryptionMethodWithXtsFdvDropDown_Name" value="` + strconv.Itoa(bitLockerEncryptionMethods[b.FixedEncryptionMethod]) + `"/>` +
+				`<data id="EncryptionMethodWithXtsRdvDropDown_Name" value="` + strconv.Itoa(bitLockerEncryptionMethods[b.RemovableEncryptionMethod]) + `"/>`,
+		},
+		{
+			Uri:    BitLockerURIPrefix + "SystemDrivesRequireStartupAuthentication",
+			Format: "chr",
+			Value: `<enabled/>` +
+				`<data id="ConfigureNonTPMStartupKeyUsage_Name" value="` + strconv.FormatBool(b.AllowWithoutTPM) + `"/>` +
+				`<data id="ConfigureTPMStartupKeyUsageDropDown_Name" value="0"/>` +
+				`<data id="ConfigurePINUsageDropDown_Name" value="` + tpmPIN + `"/>` +
+				`<data id="ConfigureTPMPINKeyUsageDropDown_Name" value="0"/>` +
+				`<data id="ConfigureTPMUsageDropDown_Name" value="` + tpm + `"/>`,
+		},
+		// A recovery password is required so every encrypted drive has a password for the agent to escrow
+		{
+			Uri:    BitLockerURIPrefix + "SystemDrivesRecoveryOptions",
+			Format: "chr",
+			Value: `<enabled/>` +
+				`<data id="OSAllowDRA_Name" value="false"/>` +
+				`<data id="OSRecoveryPasswordUsageDropDown_Name" value="1"/>` +
+				`<data id="OSRecoveryKeyUsageDropDown_Name" value="2"/>` +
+				`<data id="OSHideRecoveryPage_Name" value="` + strconv.FormatBool(b.Silent) + `"/>` +
+				`<data id="OSActiveDirectoryBackup_Name" value="false"/>` +
+				`<data id="OSActiveDirectoryBackupDropDown_Name" value="1"/>` +
+				`<data id="OSRequireActiveDirectoryBackup_Name" value="false"/>`,
+		},
+	}
+
+	if b.Silent {
+		payloads = append(payloads,
+			policies.VersionPayload{Uri: BitLockerURIPrefix + "AllowWarningForOtherDiskEncryption", Format: "int", Value: "0"},
+			policies.VersionPayload{Uri: BitLockerURIPrefix + "AllowStandardUserEncryption", Format: "int", Value: "1"},
+		)
+	}
+	return payloads, nil
+}
+
+// boolInt returns the int format value of the boolean
+func boolInt(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,107 @@
+package profiles
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattrax/Mattrax/internal/policies"
+)
+
+// CertificateStore is the store a trusted certificate is added to
+type CertificateStore string
+
+const (
+	CertificateStoreRoot         CertificateStore = "root"
+	CertificateStoreIntermediate CertificateStore = "intermediate"
+)
+
+// certificateStoreNodes are the RootCATrustedCertificates CSP nodes of the stores
+var certificateStoreNodes = map[CertificateStore]string{
+	CertificateStoreRoot:         "Root",
+	CertificateStoreIntermediate: "CA",
+}
+
+// Certificate is a trusted root or intermediate CA certificate. The certificate is PEM or base64 encoded DER.
+type Certificate struct {
+	Store       CertificateStore `json:"store"`
+	Certificate string           `json:"certificate"`
+	User        bool             `json:"user"`
+}
+
+// parse decodes the PEM or base64 encoded DER certificate
+func (c Certificate) parse() (*x509.Certificate, error) {
+	var der []byte
+	if block, _ := pem.Decode([]byte(c.Certificate)); block != nil {
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("the PEM block must be a CERTIFICATE not a %s", block.Type)
+		}
+		der = block.Bytes
+	} else {
+		var err error
+		if der, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(c.Certificate), "")); err != nil {
+			return nil, errors.New("the certificate must be PEM or base64 encoded DER")
+		}
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("the certificate is invalid: %w", err)
+	}
+	return cert, nil
+}
+
+// Validate verifies the certificate is a CA certificate which belongs in the store. Root certificates must be self-signed and intermediate certificates must not be.
+func (c Certificate) Validate() error {
+	_, err := c.validate()
+	return err
+}
+
+func (c Certificate) validate() (*x509.Certificate, error) {
+	if _, found := certificateStoreNodes[c.Store]; !found {
+		return nil, fmt.Errorf("the store must be one of: %s, %s", CertificateStoreRoot, CertificateStoreIntermediate)
+	}
+
+	cert, err := c.parse()
+	if err != nil {
+		return nil, err
+	} else if !cert.IsCA {
+		return nil, errors.New("the certificate must be a CA certificate")
+	}
+
+	var selfSigned = bytes.Equal(cert.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(cert) == nil
+	if c.Store == CertificateStoreRoot && !selfSigned {
+		return nil, errors.New("root certificates must be self-signed")
+	} else if c.Store == CertificateStoreIntermediate && selfSigned {
+		return nil, errors.New("intermediate certificates must not be self-signed")
+	}
+	return cert, nil
+}
+
+// Thumbprint returns the SHA1 thumbprint of the certificate which identifies it on the device
+func Thumbprint(cert *x509.Certificate) string {
+	var sum = sha1.Sum(cert.Raw)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// Payloads validates the certificate and renders the RootCATrustedCertificates CSP payload which adds it to the store
+func (c Certificate) Payloads() ([]policies.VersionPayload, error) {
+	cert, err := c.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return []policies.VersionPayload{
+		{
+			Uri:    scopeURIPrefix(c.User) + "/Vendor/MSFT/RootCATrustedCertificates/" + certificateStoreNodes[c.Store] + "/" + Thumbprint(cert) + "/EncodedCertificate",
+			Format: "b64",
+			Value:  base64.StdEncoding.EncodeToString(cert.Raw),
+		},
+	}, nil
+}
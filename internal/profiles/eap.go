@@ -0,0 +1,134 @@
+package profiles
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// EAPMethod is the EAP method used to authenticate to an enterprise Wi-Fi network or VPN
+type EAPMethod string
+
+const (
+	EAPMethodPEAP EAPMethod = "peap" // PEAP with MSCHAPv2 (a username and password)
+	EAPMethodTLS  EAPMethod = "tls"  // EAP-TLS (a client certificate)
+)
+
+// EAP configures how the device authenticates and validates the authentication server.
+// TrustedRootCAs are the SHA1 thumbprints of the root certificates the server's certificate must chain to.
+type EAP struct {
+	Method         EAPMethod `json:"method"`
+	ServerNames    []string  `json:"server_names"`
+	TrustedRootCAs []string  `json:"trusted_root_cas"`
+}
+
+var thumbprintRegex = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+// Validate verifies the EAP method and the server validation settings
+func (e EAP) Validate() error {
+	if e.Method != EAPMethodPEAP && e.Method != EAPMethodTLS {
+		return fmt.Errorf("the eap method must be one of: %s, %s", EAPMethodPEAP, EAPMethodTLS)
+	} else if len(e.ServerNames) == 0 || len(e.TrustedRootCAs) == 0 {
+		return errors.New("the eap configuration must have server names and trusted root CAs so the server can be validated")
+	}
+
+	for _, thumbprint := range e.TrustedRootCAs {
+		if !thumbprintRegex.MatchString(thumbprint) {
+			return fmt.Errorf("the trusted root CA '%s' must be a SHA1 thumbprint", thumbprint)
+		}
+	}
+	return nil
+}
+
+// eapTypes are the EAP type numbers of the methods
+var eapTypes = map[EAPMethod]int{
+	EAPMethodPEAP: 25,
+	EAPMethodTLS:  13,
+}
+
+// thumbprints returns the thumbprints in the form the EAP configuration expects (lowercase with spaces between each byte)
+func (e EAP) thumbprints() []string {
+	var thumbprints = make([]string, len(e.TrustedRootCAs))
+	for i, thumbprint := range e.TrustedRootCAs {
+		var hex = strings.ToLower(thumbprint)
+		var bytes = make([]string, 0, len(hex)/2)
+		for j := 0; j < len(hex); j += 2 {
+			bytes = append(bytes, hex[j:j+2])
+		}
+		thumbprints[i] = strings.Join(bytes, " ")
+	}
+	return thumbprints
+}
+
+var eapTemplate = template.Must(template.New("EAP configuration").Funcs(templateFuncs).Parse(compact(`
+<EapHostConfig xmlns="http://www.microsoft.com/provisioning/EapHostConfig">
+  <EapMethod>
+    <Type xmlns="http://www.microsoft.com/provisioning/EapCommon">{{.Type}}</Type>
+    <VendorId xmlns="http://www.microsoft.com/provisioning/EapCommon">0</VendorId>
+    <VendorType xmlns="http://www.microsoft.com/provisioning/EapCommon">0</VendorType>
+    <AuthorId xmlns="http://www.microsoft.com/provisioning/EapCommon">0</AuthorId>
+  </EapMethod>
+  <Config xmlns="http://www.microsoft.com/provisioning/EapHostConfig">
+    <Eap xmlns="http://www.microsoft.com/provisioning/BaseEapConnectionPropertiesV1">
+      <Type>{{.Type}}</Type>
+      {{- if eq .Method "peap"}}
+      <EapType xmlns="http://www.microsoft.com/provisioning/MsPeapConnectionPropertiesV1">
+        <ServerValidation>
+          <DisableUserPromptForServerValidation>true</DisableUserPromptForServerValidation>
+          <ServerNames>{{escape (join .ServerNames ";")}}</ServerNames>
+          {{- range .Thumbprints}}
+          <TrustedRootCA>{{.}}</TrustedRootCA>
+          {{- end}}
+        </ServerValidation>
+        <FastReconnect>true</FastReconnect>
+        <InnerEapOptional>false</InnerEapOptional>
+        <Eap xmlns="http://www.microsoft.com/provisioning/BaseEapConnectionPropertiesV1">
+          <Type>26</Type>
+          <EapType xmlns="http://www.microsoft.com/provisioning/MsChapV2ConnectionPropertiesV1">
+            <UseWinLogonCredentials>false</UseWinLogonCredentials>
+          </EapType>
+        </Eap>
+        <EnableQuarantineChecks>false</EnableQuarantineChecks>
+        <RequireCryptoBinding>false</RequireCryptoBinding>
+        <PeapExtensions>
+          <PerformServerValidation xmlns="http://www.microsoft.com/provisioning/MsPeapConnectionPropertiesV2">true</PerformServerValidation>
+          <AcceptServerName xmlns="http://www.microsoft.com/provisioning/MsPeapConnectionPropertiesV2">true</AcceptServerName>
+        </PeapExtensions>
+      </EapType>
+      {{- else}}
+      <EapType xmlns="http://www.microsoft.com/provisioning/EapTlsConnectionPropertiesV1">
+        <CredentialsSource>
+          <CertificateStore>
+            <SimpleCertSelection>true</SimpleCertSelection>
+          </CertificateStore>
+        </CredentialsSource>
+        <ServerValidation>
+          <DisableUserPromptForServerValidation>true</DisableUserPromptForServerValidation>
+          <ServerNames>{{escape (join .ServerNames ";")}}</ServerNames>
+          {{- range .Thumbprints}}
+          <TrustedRootCA>{{.}}</TrustedRootCA>
+          {{- end}}
+        </ServerValidation>
+        <DifferentUsername>false</DifferentUsername>
+      </EapType>
+      {{- end}}
+    </Eap>
+  </Config>
+</EapHostConfig>`)))
+
+// render returns the EapHostConfig document which is embedded in Wi-Fi and VPN profiles
+func (e EAP) render() (string, error) {
+	return render(eapTemplate, struct {
+		Method      EAPMethod
+		Type        int
+		ServerNames []string
+		Thumbprints []string
+	}{
+		Method:      e.Method,
+		Type:        eapTypes[e.Method],
+		ServerNames: e.ServerNames,
+		Thumbprints: e.thumbprints(),
+	})
+}
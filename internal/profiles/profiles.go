@@ -0,0 +1,105 @@
+// Package profiles renders typed Wi-Fi, VPN, certificate and BitLocker profiles into the CSP payloads which configure them on devices.
+// Profiles replace writing the WlanXml and ProfileXML documents of the WiFi and VPNv2 CSPs by hand.
+package profiles
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/mattrax/Mattrax/internal/policies"
+)
+
+// Type is the kind of configuration a profile contains
+type Type string
+
+const (
+	TypeWiFi        Type = "wifi"
+	TypeVPN         Type = "vpn"
+	TypeCertificate Type = "certificate"
+	TypeBitLocker   Type = "bitlocker"
+)
+
+// Profile is a typed configuration. Only the field matching its type is set.
+type Profile struct {
+	Type        Type         `json:"type"`
+	WiFi        *WiFi        `json:"wifi,omitempty"`
+	VPN         *VPN         `json:"vpn,omitempty"`
+	Certificate *Certificate `json:"certificate,omitempty"`
+	BitLocker   *BitLocker   `json:"bitlocker,omitempty"`
+}
+
+// Payloads validates the profile and renders the payloads which configure it
+func (p Profile) Payloads() ([]policies.VersionPayload, error) {
+	switch p.Type {
+	case TypeWiFi:
+		if p.WiFi == nil {
+			return nil, errors.New("the wifi profile is missing")
+		}
+		return p.WiFi.Payloads()
+	case TypeVPN:
+		if p.VPN == nil {
+			return nil, errors.New("the vpn profile is missing")
+		}
+		return p.VPN.Payloads()
+	case TypeCertificate:
+		if p.Certificate == nil {
+			return nil, errors.New("the certificate profile is missing")
+		}
+		return p.Certificate.Payloads()
+	case TypeBitLocker:
+		if p.BitLocker == nil {
+			return nil, errors.New("the bitlocker profile is missing")
+		}
+		return p.BitLocker.Payloads()
+	}
+	return nil, fmt.Errorf("the profile type must be one of: %s, %s, %s, %s", TypeWiFi, TypeVPN, TypeCertificate, TypeBitLocker)
+}
+
+// scopeURIPrefix returns the prefix of URIs which configure the device or, if user is set, the user signed into the device
+func scopeURIPrefix(user bool) string {
+	if user {
+		return "./User"
+	}
+	return "./Device"
+}
+
+var templateFuncs = template.FuncMap{
+	"escape": func(s string) string {
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(s))
+		return buf.String()
+	},
+	"join": strings.Join,
+}
+
+// render executes the template and verifies the result is a well formed XML document
+func render(tmpl *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	var decoder = xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("the rendered %s is not valid xml: %w", tmpl.Name(), err)
+		}
+	}
+	return buf.String(), nil
+}
+
+// compact removes the indentation between the elements of a template so the rendered XML is on one line
+func compact(s string) string {
+	var lines = strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,198 @@
+package profiles
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/mattrax/Mattrax/internal/policies"
+)
+
+// VPNProtocol is the tunnel type of a native VPN profile
+type VPNProtocol string
+
+const (
+	VPNAutomatic VPNProtocol = "automatic"
+	VPNIKEv2     VPNProtocol = "ikev2"
+	VPNSSTP      VPNProtocol = "sstp"
+	VPNL2TP      VPNProtocol = "l2tp"
+)
+
+// vpnProtocols are the NativeProtocolType values of the protocols
+var vpnProtocols = map[VPNProtocol]string{
+	VPNAutomatic: "Automatic",
+	VPNIKEv2:     "IKEv2",
+	VPNSSTP:      "Sstp",
+	VPNL2TP:      "L2tp",
+}
+
+// VPNAuthentication is how the VPN authenticates the user or device
+type VPNAuthentication string
+
+const (
+	VPNAuthenticationEAP                VPNAuthentication = "eap"
+	VPNAuthenticationMSCHAPv2           VPNAuthentication = "mschapv2"
+	VPNAuthenticationMachineCertificate VPNAuthentication = "machine_certificate"
+)
+
+// VPN is a native (built-in client) VPNv2 profile. Routes are the networks (eg. 10.0.0.0/8) sent through a split tunnel.
+// Device tunnels connect before a user signs in so they must be always on and authenticate with a machine certificate over IKEv2.
+type VPN struct {
+	Name            string            `json:"name"`
+	Servers         []string          `json:"servers"`
+	Protocol        VPNProtocol       `json:"protocol"`
+	Authentication  VPNAuthentication `json:"authentication"`
+	EAP             *EAP              `json:"eap,omitempty"`
+	L2TPPSK         string            `json:"l2tp_psk,omitempty"`
+	SplitTunnel     bool              `json:"split_tunnel"`
+	Routes          []string          `json:"routes,omitempty"`
+	DNSSuffix       string            `json:"dns_suffix,omitempty"`
+	TrustedNetworks []string          `json:"trusted_networks,omitempty"` // DNS suffixes of networks the VPN doesn't connect on
+	AlwaysOn        bool              `json:"always_on"`
+	DeviceTunnel    bool              `json:"device_tunnel"`
+	User            bool              `json:"user"`
+}
+
+// Validate verifies the VPN's connection, authentication and routing settings
+func (v VPN) Validate() error {
+	if v.Name == "" || strings.ContainsAny(v.Name, `/\`) {
+		return errors.New("the vpn must have a name which doesn't contain slashes")
+	} else if len(v.Servers) == 0 {
+		return errors.New("the vpn must have at least one server")
+	} else if _, found := vpnProtocols[v.Protocol]; !found {
+		return fmt.Errorf("the protocol must be one of: %s, %s, %s, %s", VPNAutomatic, VPNIKEv2, VPNSSTP, VPNL2TP)
+	}
+
+	switch v.Authentication {
+	case VPNAuthenticationEAP:
+		if v.EAP == nil {
+			return errors.New("eap authentication must have an eap configuration")
+		} else if err := v.EAP.Validate(); err != nil {
+			return err
+		}
+	case VPNAuthenticationMSCHAPv2, VPNAuthenticationMachineCertificate:
+	default:
+		return fmt.Errorf("the authentication must be one of: %s, %s, %s", VPNAuthenticationEAP, VPNAuthenticationMSCHAPv2, VPNAuthenticationMachineCertificate)
+	}
+
+	if v.Protocol == VPNL2TP && v.L2TPPSK == "" && v.Authentication != VPNAuthenticationMachineCertificate {
+		return errors.New("l2tp must have a preshared key or use machine certificate authentication")
+	} else if v.Protocol != VPNL2TP && v.L2TPPSK != "" {
+		return errors.New("only l2tp uses a preshared key")
+	}
+
+	if v.DeviceTunnel {
+		if v.User {
+			return errors.New("device tunnels can't be installed for a user")
+		} else if !v.AlwaysOn || v.Protocol != VPNIKEv2 || v.Authentication != VPNAuthenticationMachineCertificate {
+			return errors.New("device tunnels must be always on and use ikev2 with machine certificate authentication")
+		}
+	}
+
+	if len(v.Routes) != 0 && !v.SplitTunnel {
+		return errors.New("routes are only used by split tunnels")
+	}
+	for _, route := range v.Routes {
+		if _, _, err := net.ParseCIDR(route); err != nil {
+			return fmt.Errorf("the route '%s' must be a network in CIDR notation", route)
+		}
+	}
+	return nil
+}
+
+type vpnRoute struct {
+	Address    string
+	PrefixSize int
+}
+
+var vpnTemplate = template.Must(template.New("VPN profile").Funcs(templateFuncs).Parse(compact(`
+<VPNProfile>
+  <AlwaysOn>{{.AlwaysOn}}</AlwaysOn>
+  {{- if .DeviceTunnel}}
+  <DeviceTunnel>true</DeviceTunnel>
+  {{- end}}
+  <RememberCredentials>true</RememberCredentials>
+  {{- if .DNSSuffix}}
+  <DnsSuffix>{{escape .DNSSuffix}}</DnsSuffix>
+  {{- end}}
+  {{- if .TrustedNetworks}}
+  <TrustedNetworkDetection>{{escape (join .TrustedNetworks ",")}}</TrustedNetworkDetection>
+  {{- end}}
+  <NativeProfile>
+    <Servers>{{escape (join .Servers ";")}}</Servers>
+    <NativeProtocolType>{{.NativeProtocol}}</NativeProtocolType>
+    <Authentication>
+      {{- if eq .Authentication "machine_certificate"}}
+      <MachineMethod>Certificate</MachineMethod>
+      {{- else if eq .Authentication "mschapv2"}}
+      <UserMethod>Mschapv2</UserMethod>
+      {{- else}}
+      <UserMethod>Eap</UserMethod>
+      <Eap>
+        <Configuration>{{.EAPConfiguration}}</Configuration>
+      </Eap>
+      {{- end}}
+    </Authentication>
+    <RoutingPolicyType>{{if .SplitTunnel}}SplitTunnel{{else}}ForceTunnel{{end}}</RoutingPolicyType>
+    {{- if .L2TPPSK}}
+    <L2tpPsk>{{escape .L2TPPSK}}</L2tpPsk>
+    {{- end}}
+  </NativeProfile>
+  {{- range .ParsedRoutes}}
+  <Route>
+    <Address>{{.Address}}</Address>
+    <PrefixSize>{{.PrefixSize}}</PrefixSize>
+  </Route>
+  {{- end}}
+</VPNProfile>`)))
+
+// Payloads validates the VPN and renders the VPNv2 CSP payload which adds it
+func (v VPN) Payloads() ([]policies.VersionPayload, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
+	var eap string
+	if v.Authentication == VPNAuthenticationEAP {
+		var err error
+		if eap, err = v.EAP.render(); err != nil {
+			return nil, err
+		}
+	}
+
+	var routes = make([]vpnRoute, 0, len(v.Routes))
+	for _, route := range v.Routes {
+		_, network, _ := net.ParseCIDR(route)
+		prefixSize, _ := network.Mask.Size()
+		routes = append(routes, vpnRoute{
+			Address:    network.IP.String(),
+			PrefixSize: prefixSize,
+		})
+	}
+
+	profile, err := render(vpnTemplate, struct {
+		VPN
+		NativeProtocol   string
+		EAPConfiguration string
+		ParsedRoutes     []vpnRoute
+	}{
+		VPN:              v,
+		NativeProtocol:   vpnProtocols[v.Protocol],
+		EAPConfiguration: eap,
+		ParsedRoutes:     routes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []policies.VersionPayload{
+		{
+			Uri:    scopeURIPrefix(v.User) + "/Vendor/MSFT/VPNv2/" + url.PathEscape(v.Name) + "/ProfileXML",
+			Format: "chr",
+			Value:  profile,
+		},
+	}, nil
+}
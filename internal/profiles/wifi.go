@@ -0,0 +1,145 @@
+package profiles
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"text/template"
+
+	"github.com/mattrax/Mattrax/internal/policies"
+)
+
+// WiFiSecurity is the security type of a Wi-Fi network
+type WiFiSecurity string
+
+const (
+	WiFiWPA2Personal   WiFiSecurity = "wpa2_personal"
+	WiFiWPA3Personal   WiFiSecurity = "wpa3_personal"
+	WiFiWPA2Enterprise WiFiSecurity = "wpa2_enterprise"
+	WiFiWPA3Enterprise WiFiSecurity = "wpa3_enterprise"
+)
+
+// wifiAuthentications are the WLAN profile authentication types of the security types
+var wifiAuthentications = map[WiFiSecurity]string{
+	WiFiWPA2Personal:   "WPA2PSK",
+	WiFiWPA3Personal:   "WPA3SAE",
+	WiFiWPA2Enterprise: "WPA2",
+	WiFiWPA3Enterprise: "WPA3ENT",
+}
+
+// WiFi is a Wi-Fi network. Personal networks use the passphrase and enterprise networks authenticate with EAP.
+// Networks are added for every user of the device unless user is set.
+type WiFi struct {
+	SSID       string       `json:"ssid"`
+	Security   WiFiSecurity `json:"security"`
+	Passphrase string       `json:"passphrase,omitempty"`
+	EAP        *EAP         `json:"eap,omitempty"`
+	Hidden     bool         `json:"hidden"`
+	Manual     bool         `json:"manual"` // Whether the user must connect to the network instead of it connecting automatically
+	User       bool         `json:"user"`
+}
+
+var hexPassphraseRegex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// enterprise returns whether the network authenticates with EAP
+func (w WiFi) enterprise() bool {
+	return w.Security == WiFiWPA2Enterprise || w.Security == WiFiWPA3Enterprise
+}
+
+// Validate verifies the SSID and the credentials for the network's security type
+func (w WiFi) Validate() error {
+	if len(w.SSID) == 0 || len(w.SSID) > 32 {
+		return errors.New("the ssid must be between 1 and 32 bytes")
+	} else if _, found := wifiAuthentications[w.Security]; !found {
+		return fmt.Errorf("the security must be one of: %s, %s, %s, %s", WiFiWPA2Personal, WiFiWPA3Personal, WiFiWPA2Enterprise, WiFiWPA3Enterprise)
+	}
+
+	if w.enterprise() {
+		if w.EAP == nil {
+			return errors.New("enterprise networks must have an eap configuration")
+		}
+		return w.EAP.Validate()
+	}
+
+	if (len(w.Passphrase) < 8 || len(w.Passphrase) > 63) && !hexPassphraseRegex.MatchString(w.Passphrase) {
+		return errors.New("the passphrase must be between 8 and 63 characters or 64 hex digits")
+	}
+	return nil
+}
+
+var wlanTemplate = template.Must(template.New("WLAN profile").Funcs(templateFuncs).Parse(compact(`
+<WLANProfile xmlns="http://www.microsoft.com/networking/WLAN/profile/v1">
+  <name>{{escape .SSID}}</name>
+  <SSIDConfig>
+    <SSID>
+      <hex>{{.HexSSID}}</hex>
+      <name>{{escape .SSID}}</name>
+    </SSID>
+    <nonBroadcast>{{.Hidden}}</nonBroadcast>
+  </SSIDConfig>
+  <connectionType>ESS</connectionType>
+  <connectionMode>{{if .Manual}}manual{{else}}auto{{end}}</connectionMode>
+  <MSM>
+    <security>
+      <authEncryption>
+        <authentication>{{.Authentication}}</authentication>
+        <encryption>AES</encryption>
+        <useOneX>{{if .EAP}}true{{else}}false{{end}}</useOneX>
+      </authEncryption>
+      {{- if .EAP}}
+      <OneX xmlns="http://www.microsoft.com/networking/OneX/v1">
+        <authMode>machineOrUser</authMode>
+        <EAPConfig>{{.EAP}}</EAPConfig>
+      </OneX>
+      {{- else}}
+      <sharedKey>
+        <keyType>{{if .HexKey}}networkKey{{else}}passPhrase{{end}}</keyType>
+        <protected>false</protected>
+        <keyMaterial>{{escape .Passphrase}}</keyMaterial>
+      </sharedKey>
+      {{- end}}
+    </security>
+  </MSM>
+</WLANProfile>`)))
+
+// Payloads validates the network and renders the WiFi CSP payload which adds it
+func (w WiFi) Payloads() ([]policies.VersionPayload, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+
+	var eap string
+	if w.enterprise() {
+		var err error
+		if eap, err = w.EAP.render(); err != nil {
+			return nil, err
+		}
+	}
+
+	profile, err := render(wlanTemplate, struct {
+		WiFi
+		HexSSID        string
+		Authentication string
+		HexKey         bool
+		EAP            string
+	}{
+		WiFi:           w,
+		HexSSID:        hex.EncodeToString([]byte(w.SSID)),
+		Authentication: wifiAuthentications[w.Security],
+		HexKey:         len(w.Passphrase) == 64,
+		EAP:            eap,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []policies.VersionPayload{
+		{
+			Uri:    scopeURIPrefix(w.User) + "/Vendor/MSFT/WiFi/Profile/" + url.PathEscape(w.SSID) + "/WlanXml",
+			Format: "chr",
+			Value:  profile,
+		},
+	}, nil
+}
@@ -0,0 +1,144 @@
+// Package rollouts assigns a policy to a group in stages which are gated on the success rate the devices report for the previous stage
+package rollouts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/pkg/syncml"
+)
+
+var (
+	// ErrFinished is returned when changing a rollout which has been completed or aborted
+	ErrFinished = errors.New("the rollout has already finished")
+	// ErrThresholdNotMet is returned when promoting a rollout whose current stage hasn't reached its success threshold
+	ErrThresholdNotMet = errors.New("the current stage hasn't reached the rollout's success threshold")
+)
+
+// Stage is a stage of a rollout. It either deploys to a percentage of the group's devices or to the devices in the ring group.
+type Stage struct {
+	Percentage  int16 `json:"percentage"`
+	RingGroupID int32 `json:"ring_group_id"`
+}
+
+// ValidateStages verifies the stages of a new rollout. Percentages can't decrease as each stage includes the devices of the previous stages.
+func ValidateStages(stages []Stage) error {
+	if len(stages) == 0 {
+		return errors.New("a rollout requires at least one stage")
+	}
+
+	var percentage int16
+	for _, stage := range stages {
+		if (stage.Percentage == 0) == (stage.RingGroupID == 0) {
+			return errors.New("each stage must have either a percentage or a ring group")
+		} else if stage.Percentage < 0 || stage.Percentage > 100 {
+			return errors.New("stage percentages must be between 1 and 100")
+		} else if stage.Percentage != 0 && stage.Percentage < percentage {
+			return errors.New("stage percentages must not decrease")
+		}
+
+		if stage.Percentage > percentage {
+			percentage = stage.Percentage
+		}
+	}
+	return nil
+}
+
+// Succeeded returns whether the SyncML status the device returned for a command means it was applied.
+// Adding a node which already exists is a success as payloads are always deployed using Add.
+func Succeeded(command string, status int) bool {
+	return (status >= 200 && status < 300) || (command == "Add" && status == syncml.StatusAlreadyExists)
+}
+
+// RecordResult records the status the device returned for a payload against the unfinished rollouts which deployed it. The result is recorded for the stage
+// which included the device, not the rollout's current stage, so the devices of earlier stages don't count towards the stages they were promoted into.
+// Rollouts where the stage which included the device passes their failure threshold are paused and their IDs are returned.
+func RecordResult(ctx context.Context, q *db.Queries, deviceID, payloadID int32, status int, failed bool) (paused []int32, err error) {
+	rollouts, err := q.GetPayloadsRollouts(ctx, db.GetPayloadsRolloutsParams{
+		ID:       payloadID,
+		DeviceID: deviceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rollout := range rollouts {
+		if err := q.RecordRolloutResult(ctx, db.RecordRolloutResultParams{
+			RolloutID: rollout.ID,
+			DeviceID:  deviceID,
+			Stage:     rollout.IncludedStage,
+			Status:    int32(status),
+			Failed:    failed,
+		}); err != nil {
+			return nil, err
+		}
+
+		if !failed || rollout.State != db.RolloutStateActive {
+			continue
+		}
+
+		results, err := q.GetRolloutStageResults(ctx, db.GetRolloutStageResultsParams{
+			RolloutID: rollout.ID,
+			Stage:     rollout.IncludedStage,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if results.Failed*100 > results.Reported*int64(rollout.FailureThreshold) {
+			if err := q.SetRolloutState(ctx, db.SetRolloutStateParams{
+				ID:    rollout.ID,
+				State: db.RolloutStatePaused,
+			}); err != nil {
+				return nil, err
+			}
+			paused = append(paused, rollout.ID)
+		}
+	}
+	return paused, nil
+}
+
+// Promote advances the rollout to its next stage. Unless forced the current stage must have reached the rollout's success threshold.
+// Promoting the final stage completes the rollout by assigning the policy to the whole group.
+func Promote(ctx context.Context, q *db.Queries, rollout db.Rollout, force bool) error {
+	if rollout.State != db.RolloutStateActive && rollout.State != db.RolloutStatePaused {
+		return ErrFinished
+	}
+
+	if !force {
+		results, err := q.GetRolloutStageResults(ctx, db.GetRolloutStageResultsParams{
+			RolloutID: rollout.ID,
+			Stage:     rollout.Stage,
+		})
+		if err != nil {
+			return err
+		}
+
+		if results.Reported == 0 || (results.Reported-results.Failed)*100 < results.Reported*int64(rollout.SuccessThreshold) {
+			return ErrThresholdNotMet
+		}
+	}
+
+	stages, err := q.GetRolloutStages(ctx, rollout.ID)
+	if err != nil {
+		return err
+	}
+
+	if int(rollout.Stage)+1 < len(stages) {
+		return q.PromoteRollout(ctx, rollout.ID)
+	}
+
+	if err := q.AttachGroupPolicy(ctx, db.AttachGroupPolicyParams{
+		GroupID:  sql.NullInt32{Int32: rollout.GroupID, Valid: true},
+		PolicyID: sql.NullInt32{Int32: rollout.PolicyID, Valid: true},
+	}); err != nil {
+		return err
+	}
+
+	return q.SetRolloutState(ctx, db.SetRolloutStateParams{
+		ID:    rollout.ID,
+		State: db.RolloutStateCompleted,
+	})
+}
@@ -0,0 +1,189 @@
+package scep
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/profiles"
+)
+
+// ChallengeLifetime is how long a challenge password can be used. Devices which didn't request their certificate before it expired are sent a new one.
+const ChallengeLifetime = 24 * time.Hour
+
+const (
+	// keyProtection stores the private key in the TPM if the device has one and otherwise in the software KSP
+	keyProtection = 2
+	retryDelay    = 5 // Minutes
+	retryCount    = 3
+)
+
+// Enrollment is a certificate the device should request from the SCEP endpoint
+type Enrollment struct {
+	Profile   db.CertificateProfile
+	UPN       string // The user signed into the device for user certificates
+	Subject   string
+	Challenge string
+	Replace   bool // Whether the device has a previous enrollment from the profile which must be deleted first
+}
+
+// HashChallenge returns the hex encoded SHA256 hash of the challenge password which is stored instead of the password
+func HashChallenge(challenge string) string {
+	var hash = sha256.Sum256([]byte(challenge))
+	return hex.EncodeToString(hash[:])
+}
+
+// UniqueID returns the ClientCertificateInstall node of the profile's certificate
+func UniqueID(profileID int32) string {
+	return "Mattrax" + strconv.Itoa(int(profileID))
+}
+
+// RenewAt returns when a certificate is renewed. The renewal threshold is the percentage of the certificate's lifetime left at that time.
+func RenewAt(cert db.IssuedCertificate, renewalThreshold int32) time.Time {
+	var lifetime = cert.NotAfter.Sub(cert.NotBefore)
+	return cert.NotAfter.Add(-lifetime * time.Duration(renewalThreshold) / 100)
+}
+
+// Provision returns the certificates the device (and the user signed into it) should request. A certificate is requested when the device doesn't have one,
+// its certificate was revoked, is due for renewal or its subject template changed. The challenges are stored before they are sent so they must be sent to the device in the same session.
+func Provision(ctx context.Context, q *db.Queries, device db.Device, sessionUser string) ([]Enrollment, error) {
+	certificateProfiles, err := q.GetDeviceCertificateProfiles(ctx, device.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var now = time.Now()
+	var enrollments []Enrollment
+	for _, profile := range certificateProfiles {
+		if profile.UserContext && sessionUser == "" {
+			continue
+		}
+
+		var upn, subjectUPN = "", device.EnrolledBy.String
+		if profile.UserContext {
+			upn, subjectUPN = sessionUser, sessionUser
+		}
+
+		var subject = RenderSubject(profile.SubjectTemplate, SubjectValues{
+			DeviceID:   device.ID,
+			DeviceName: device.Name,
+			UDID:       device.Udid,
+			UPN:        subjectUPN,
+		})
+
+		latest, err := q.GetLatestIssuedCertificate(ctx, db.GetLatestIssuedCertificateParams{
+			DeviceID:  device.ID,
+			ProfileID: sql.NullInt32{Int32: profile.ID, Valid: true},
+			Upn:       upn,
+		})
+		var issued = err == nil
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		} else if issued && !latest.RevokedAt.Valid && latest.Subject == subject && now.Before(RenewAt(latest, profile.RenewalThreshold)) {
+			continue
+		}
+
+		challenge, err := q.GetCertificateChallenge(ctx, db.GetCertificateChallengeParams{
+			DeviceID:  device.ID,
+			ProfileID: profile.ID,
+			Upn:       upn,
+		})
+		var pending = err == nil
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		} else if pending && now.Sub(challenge.CreatedAt) < ChallengeLifetime {
+			continue
+		}
+
+		var raw = make([]byte, 24)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		var password = base64.RawURLEncoding.EncodeToString(raw)
+
+		if err := q.SetCertificateChallenge(ctx, db.SetCertificateChallengeParams{
+			DeviceID:      device.ID,
+			ProfileID:     profile.ID,
+			Upn:           upn,
+			ChallengeHash: HashChallenge(password),
+			Subject:       subject,
+		}); err != nil {
+			return nil, err
+		}
+
+		enrollments = append(enrollments, Enrollment{
+			Profile:   profile,
+			UPN:       upn,
+			Subject:   subject,
+			Challenge: password,
+			Replace:   issued || pending,
+		})
+	}
+	return enrollments, nil
+}
+
+// Command is a command which enrolls the device with the SCEP endpoint
+type Command struct {
+	Command string
+	URI     string
+	Type    string
+	Format  string
+	Value   string
+}
+
+// Commands returns the commands which trust the SCEP CA and make the device request the enrollment's certificate from the SCEP endpoint on the domain.
+// A previous enrollment from the profile is deleted first which removes its certificate from the device.
+func Commands(enrollment Enrollment, ca *x509.Certificate, domain string) ([]Command, error) {
+	trust, err := profiles.Certificate{
+		Store:       profiles.CertificateStoreRoot,
+		Certificate: base64.StdEncoding.EncodeToString(ca.Raw),
+	}.Payloads()
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := windowsSubject(enrollment.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var commands []Command
+	for _, payload := range trust {
+		commands = append(commands, Command{Command: "Add", URI: payload.Uri, Format: payload.Format, Value: payload.Value})
+	}
+
+	var scope = "./Device"
+	if enrollment.Profile.UserContext {
+		scope = "./User"
+	}
+	var node = scope + "/Vendor/MSFT/ClientCertificateInstall/SCEP/" + UniqueID(enrollment.Profile.ID)
+	if enrollment.Replace {
+		commands = append(commands, Command{Command: "Delete", URI: node})
+	}
+
+	var install = node + "/Install/"
+	return append(commands,
+		Command{Command: "Add", URI: install + "ServerURL", Format: "chr", Value: "https://" + domain + Path},
+		Command{Command: "Add", URI: install + "Challenge", Format: "chr", Value: enrollment.Challenge},
+		Command{Command: "Add", URI: install + "SubjectName", Format: "chr", Value: subject},
+		Command{Command: "Add", URI: install + "EKUMapping", Format: "chr", Value: strings.Join(enrollment.Profile.ExtendedKeyUsages, "+")},
+		Command{Command: "Add", URI: install + "KeyUsage", Format: "int", Value: strconv.Itoa(keyUsageBits(enrollment.Profile.KeyUsages))},
+		Command{Command: "Add", URI: install + "KeyLength", Format: "int", Value: strconv.Itoa(int(enrollment.Profile.KeyLength))},
+		Command{Command: "Add", URI: install + "KeyProtection", Format: "int", Value: strconv.Itoa(keyProtection)},
+		Command{Command: "Add", URI: install + "HashAlgorithm", Format: "chr", Value: "SHA-2"},
+		Command{Command: "Add", URI: install + "CAThumbprint", Format: "chr", Value: profiles.Thumbprint(ca)},
+		Command{Command: "Add", URI: install + "ValidPeriod", Format: "chr", Value: "Days"},
+		Command{Command: "Add", URI: install + "ValidPeriodUnits", Format: "int", Value: strconv.Itoa(int(enrollment.Profile.ValidityDays))},
+		Command{Command: "Add", URI: install + "RetryDelay", Format: "int", Value: strconv.Itoa(retryDelay)},
+		Command{Command: "Add", URI: install + "RetryCount", Format: "int", Value: strconv.Itoa(retryCount)},
+		Command{Command: "Exec", URI: install + "Enroll"},
+	), nil
+}
@@ -0,0 +1,124 @@
+package scep
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+)
+
+// The pkcs7 package can only encrypt with DES or AES-GCM which Windows doesn't accept so the PKCS#7 enveloped data is built here using AES-256-CBC
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidAES256CBC     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+var errNotRSARecipient = errors.New("the message can only be encrypted to a certificate with an RSA key")
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue // The explicitly tagged content which is encoded by hand as raw values ignore tags
+}
+
+type envelopedData struct {
+	Version              int
+	RecipientInfos       []recipientInfo `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type recipientInfo struct {
+	Version                int
+	IssuerAndSerialNumber  issuerAndSerial
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type issuerAndSerial struct {
+	IssuerName   asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0,optional"`
+}
+
+// envelope encrypts the content to the recipient's certificate
+func envelope(content []byte, recipient *x509.Certificate) ([]byte, error) {
+	publicKey, ok := recipient.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, errNotRSARecipient
+	}
+
+	var key = make([]byte, 32)
+	var iv = make([]byte, aes.BlockSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	} else if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// PKCS#7 padding always adds at least one byte
+	var padding = aes.BlockSize - len(content)%aes.BlockSize
+	var encrypted = append(append([]byte{}, content...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+	encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, publicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIV, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+
+	inner, err := asn1.Marshal(envelopedData{
+		Version: 0,
+		RecipientInfos: []recipientInfo{
+			{
+				Version: 0,
+				IssuerAndSerialNumber: issuerAndSerial{
+					IssuerName:   asn1.RawValue{FullBytes: recipient.RawIssuer},
+					SerialNumber: recipient.SerialNumber,
+				},
+				KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+					Algorithm:  oidRSAEncryption,
+					Parameters: asn1.NullRawValue,
+				},
+				EncryptedKey: encryptedKey,
+			},
+		},
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType: oidData,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  oidAES256CBC,
+				Parameters: asn1.RawValue{FullBytes: rawIV},
+			},
+			EncryptedContent: encrypted,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(contentInfo{
+		ContentType: oidEnvelopedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner},
+	})
+}
@@ -0,0 +1,143 @@
+package scep
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/profiles"
+)
+
+// crlLifetime is how long the CRL is valid for. Clients cache the CRL for this long so it is how long a revocation can take to apply.
+const crlLifetime = 24 * time.Hour
+
+var (
+	// ErrUnknownChallenge is returned when the challenge password doesn't exist (or was already used)
+	ErrUnknownChallenge = errors.New("the challenge password is unknown")
+	// ErrExpiredChallenge is returned when the challenge password is older than the ChallengeLifetime
+	ErrExpiredChallenge = errors.New("the challenge password has expired")
+	// ErrWeakKey is returned when the CSR's key is shorter than the profile requires
+	ErrWeakKey = errors.New("the certificate request must have an RSA key at least as long as the profile's key length")
+)
+
+// SerialString returns the upper case hex encoded serial number which identifies an issued certificate
+func SerialString(serial *big.Int) string {
+	return strings.ToUpper(serial.Text(16))
+}
+
+// IssueQueries are the queries used by Issue. They are implemented by *db.Queries.
+type IssueQueries interface {
+	ClaimCertificateChallenge(ctx context.Context, challengeHash string) (db.CertificateChallenge, error)
+	GetCertificateProfile(ctx context.Context, id int32) (db.CertificateProfile, error)
+	CreateIssuedCertificate(ctx context.Context, arg db.CreateIssuedCertificateParams) error
+}
+
+// Issue signs the certificate requested with the challenge password and records it as issued. The certificate is issued with the subject stored with the challenge, not the one in the CSR.
+// The challenge is deleted as it is looked up so it can only be used once. It must be called within a transaction so the challenge is kept if the certificate isn't issued.
+func Issue(ctx context.Context, q IssueQueries, ca *x509.Certificate, key *rsa.PrivateKey, csr *x509.CertificateRequest, challengePassword string, domain string) (*x509.Certificate, db.CertificateChallenge, error) {
+	challenge, err := q.ClaimCertificateChallenge(ctx, HashChallenge(challengePassword))
+	if err == sql.ErrNoRows {
+		return nil, challenge, ErrUnknownChallenge
+	} else if err != nil {
+		return nil, challenge, err
+	} else if time.Since(challenge.CreatedAt) > ChallengeLifetime {
+		return nil, challenge, ErrExpiredChallenge
+	}
+
+	profile, err := q.GetCertificateProfile(ctx, challenge.ProfileID)
+	if err != nil {
+		return nil, challenge, err
+	}
+
+	if publicKey, ok := csr.PublicKey.(*rsa.PublicKey); !ok || publicKey.N.BitLen() < int(profile.KeyLength) {
+		return nil, challenge, ErrWeakKey
+	}
+
+	subject, err := ParseSubject(challenge.Subject)
+	if err != nil {
+		return nil, challenge, err
+	}
+
+	extKeyUsages, err := x509ExtKeyUsages(profile.ExtendedKeyUsages)
+	if err != nil {
+		return nil, challenge, err
+	}
+
+	// Serial numbers are random so they are unique without a counter. One is added as they must be positive.
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, challenge, err
+	}
+
+	// Certificates can't outlive the CA which issued them
+	var notBefore = time.Now().Add(-5 * time.Minute)
+	var notAfter = notBefore.Add(time.Duration(profile.ValidityDays) * 24 * time.Hour)
+	if notAfter.After(ca.NotAfter) {
+		notAfter = ca.NotAfter
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:          serial.Add(serial, big.NewInt(1)),
+		Subject:               subject,
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509KeyUsage(profile.KeyUsages),
+		UnknownExtKeyUsage:    extKeyUsages,
+		BasicConstraintsValid: true,
+		CRLDistributionPoints: []string{"http://" + domain + CRLPath}, // CRLs are fetched over http as fetching them over https could require checking the revocation of the server's certificate
+	}, ca, csr.PublicKey, key)
+	if err != nil {
+		return nil, challenge, err
+	}
+
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return nil, challenge, err
+	}
+
+	if err := q.CreateIssuedCertificate(ctx, db.CreateIssuedCertificateParams{
+		Serial:     SerialString(cert.SerialNumber),
+		DeviceID:   challenge.DeviceID,
+		ProfileID:  sql.NullInt32{Int32: profile.ID, Valid: true},
+		Upn:        challenge.Upn,
+		Subject:    challenge.Subject,
+		Thumbprint: profiles.Thumbprint(cert),
+		NotBefore:  cert.NotBefore,
+		NotAfter:   cert.NotAfter,
+	}); err != nil {
+		return nil, challenge, err
+	}
+	return cert, challenge, nil
+}
+
+// CRL returns the DER encoded revocation list of the revoked certificates which haven't expired
+func CRL(ctx context.Context, q *db.Queries, ca *x509.Certificate, key *rsa.PrivateKey) ([]byte, error) {
+	revoked, err := q.GetRevokedCertificates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var revokedCertificates = make([]pkix.RevokedCertificate, 0, len(revoked))
+	for _, cert := range revoked {
+		serial, ok := new(big.Int).SetString(cert.Serial, 16)
+		if !ok {
+			return nil, errors.New("the revoked certificate's serial '" + cert.Serial + "' is invalid")
+		}
+
+		revokedCertificates = append(revokedCertificates, pkix.RevokedCertificate{
+			SerialNumber:   serial,
+			RevocationTime: cert.RevokedAt.Time,
+		})
+	}
+
+	var now = time.Now()
+	return ca.CreateCRL(rand.Reader, key, revokedCertificates, now, now.Add(crlLifetime))
+}
@@ -0,0 +1,135 @@
+package scep
+
+import (
+	"context"
+	"crypto/x509"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+// fakeIssueQueries stores the challenges and issued certificates in memory
+type fakeIssueQueries struct {
+	challenges map[string]db.CertificateChallenge
+	profile    db.CertificateProfile
+	issued     []db.CreateIssuedCertificateParams
+}
+
+func (q *fakeIssueQueries) ClaimCertificateChallenge(ctx context.Context, challengeHash string) (db.CertificateChallenge, error) {
+	challenge, ok := q.challenges[challengeHash]
+	if !ok {
+		return challenge, sql.ErrNoRows
+	}
+	delete(q.challenges, challengeHash)
+	return challenge, nil
+}
+
+func (q *fakeIssueQueries) GetCertificateProfile(ctx context.Context, id int32) (db.CertificateProfile, error) {
+	if id != q.profile.ID {
+		return db.CertificateProfile{}, sql.ErrNoRows
+	}
+	return q.profile, nil
+}
+
+func (q *fakeIssueQueries) CreateIssuedCertificate(ctx context.Context, arg db.CreateIssuedCertificateParams) error {
+	q.issued = append(q.issued, arg)
+	return nil
+}
+
+// newFakeIssueQueries returns queries with a challenge for the password created at the time
+func newFakeIssueQueries(password string, createdAt time.Time) *fakeIssueQueries {
+	return &fakeIssueQueries{
+		challenges: map[string]db.CertificateChallenge{
+			HashChallenge(password): {
+				DeviceID:      7,
+				ProfileID:     3,
+				Upn:           "alice@example.com",
+				ChallengeHash: HashChallenge(password),
+				Subject:       "CN=DESKTOP-1,O=Acme",
+				CreatedAt:     createdAt,
+			},
+		},
+		profile: db.CertificateProfile{
+			ID:                3,
+			KeyUsages:         []string{KeyUsageDigitalSignature, KeyUsageKeyEncipherment},
+			ExtendedKeyUsages: []string{OIDClientAuthentication},
+			KeyLength:         2048,
+			ValidityDays:      365,
+		},
+	}
+}
+
+func TestIssue(t *testing.T) {
+	var caKey, clientKey = testKey(t, 2048), testKey(t, 2048)
+	var ca = testCertificate(t, "Mattrax SCEP CA", caKey, caKey, true, 30*24*time.Hour)
+	csr, err := x509.ParseCertificateRequest(testCSR(t, clientKey, "Requested By The Device", "password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var q = newFakeIssueQueries("password", time.Now())
+	cert, challenge, err := Issue(context.Background(), q, ca, caKey, csr, "password", "mdm.example.com")
+	if err != nil {
+		t.Fatal(err)
+	} else if challenge.DeviceID != 7 {
+		t.Errorf("the challenge of the device %d was claimed, expected 7", challenge.DeviceID)
+	}
+
+	if err := cert.CheckSignatureFrom(ca); err != nil {
+		t.Errorf("the certificate isn't signed by the CA: %s", err)
+	} else if cert.Subject.String() != "CN=DESKTOP-1,O=Acme" {
+		t.Errorf("the certificate was issued to %q, expected the subject of the challenge", cert.Subject)
+	} else if !cert.NotAfter.Equal(ca.NotAfter) {
+		t.Errorf("the certificate expires at %s, expected it to be clamped to the CA's expiry %s", cert.NotAfter, ca.NotAfter)
+	} else if len(cert.CRLDistributionPoints) != 1 || cert.CRLDistributionPoints[0] != "http://mdm.example.com"+CRLPath {
+		t.Errorf("the certificate's CRL distribution points were %v", cert.CRLDistributionPoints)
+	}
+
+	if len(q.issued) != 1 || q.issued[0].Serial != SerialString(cert.SerialNumber) || q.issued[0].DeviceID != 7 || q.issued[0].Upn != "alice@example.com" {
+		t.Errorf("the issued certificates were recorded as %+v", q.issued)
+	}
+
+	// The challenge was claimed so it can't be used to issue another certificate
+	if _, _, err := Issue(context.Background(), q, ca, caKey, csr, "password", "mdm.example.com"); err != ErrUnknownChallenge {
+		t.Errorf("replaying the challenge returned %v, expected %q", err, ErrUnknownChallenge)
+	} else if len(q.issued) != 1 {
+		t.Errorf("replaying the challenge recorded %d issued certificates, expected 1", len(q.issued))
+	}
+}
+
+func TestIssueRejected(t *testing.T) {
+	var caKey, clientKey, weakKey = testKey(t, 2048), testKey(t, 2048), testKey(t, 1024)
+	var ca = testCertificate(t, "Mattrax SCEP CA", caKey, caKey, true, 30*24*time.Hour)
+
+	var tests = []struct {
+		name      string
+		key       bool // Whether the CSR has the weak key
+		password  string
+		createdAt time.Time
+		expected  error
+	}{
+		{"unknown challenge", false, "wrong", time.Now(), ErrUnknownChallenge},
+		{"empty challenge", false, "", time.Now(), ErrUnknownChallenge},
+		{"expired challenge", false, "password", time.Now().Add(-ChallengeLifetime - time.Minute), ErrExpiredChallenge},
+		{"weak key", true, "password", time.Now(), ErrWeakKey},
+	}
+	for _, tt := range tests {
+		var key = clientKey
+		if tt.key {
+			key = weakKey
+		}
+		csr, err := x509.ParseCertificateRequest(testCSR(t, key, "DESKTOP-1", tt.password))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var q = newFakeIssueQueries("password", tt.createdAt)
+		if _, _, err := Issue(context.Background(), q, ca, caKey, csr, tt.password, "mdm.example.com"); err != tt.expected {
+			t.Errorf("%s: Issue returned %v, expected %q", tt.name, err, tt.expected)
+		} else if len(q.issued) != 0 {
+			t.Errorf("%s: a certificate was recorded as issued", tt.name)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package scep
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattrax/Mattrax/pkg/placeholder"
+)
+
+// Profile is the configuration of the certificates issued by a certificate profile
+type Profile struct {
+	SubjectTemplate   string   `json:"subject_template"`
+	KeyUsages         []string `json:"key_usages"`
+	ExtendedKeyUsages []string `json:"extended_key_usages"`
+	KeyLength         int32    `json:"key_length"`
+	ValidityDays      int32    `json:"validity_days"`
+	RenewalThreshold  int32    `json:"renewal_threshold"`
+}
+
+const (
+	KeyUsageDigitalSignature = "digital_signature"
+	KeyUsageKeyEncipherment  = "key_encipherment"
+)
+
+// keyUsages are the ClientCertificateInstall KeyUsage bits and x509 key usages of the key usages
+var keyUsages = map[string]struct {
+	bit   int
+	usage x509.KeyUsage
+}{
+	KeyUsageDigitalSignature: {0x80, x509.KeyUsageDigitalSignature},
+	KeyUsageKeyEncipherment:  {0x20, x509.KeyUsageKeyEncipherment},
+}
+
+// OIDClientAuthentication is the extended key usage of certificates used to authenticate a client (eg. with EAP-TLS)
+const OIDClientAuthentication = "1.3.6.1.5.5.7.3.2"
+
+var oidRegex = regexp.MustCompile(`^[0-2](\.[0-9]+)+$`)
+
+// Defaults fills in the unset fields of the profile with the defaults for a client authentication certificate
+func (p *Profile) Defaults() {
+	if len(p.KeyUsages) == 0 {
+		p.KeyUsages = []string{KeyUsageDigitalSignature, KeyUsageKeyEncipherment}
+	}
+	if len(p.ExtendedKeyUsages) == 0 {
+		p.ExtendedKeyUsages = []string{OIDClientAuthentication}
+	}
+	if p.KeyLength == 0 {
+		p.KeyLength = 2048
+	}
+	if p.ValidityDays == 0 {
+		p.ValidityDays = 365
+	}
+	if p.RenewalThreshold == 0 {
+		p.RenewalThreshold = 20
+	}
+}
+
+// Validate verifies the profile's subject template, key and lifetime
+func (p Profile) Validate() error {
+	if _, err := ParseSubject(RenderSubject(p.SubjectTemplate, SubjectValues{
+		DeviceID:   1,
+		DeviceName: "DESKTOP-1",
+		UDID:       "00000000-0000-0000-0000-000000000000",
+		UPN:        "user@example.com",
+	})); err != nil {
+		return fmt.Errorf("the subject template is invalid: %w", err)
+	}
+
+	if len(p.KeyUsages) == 0 {
+		return errors.New("the profile must have at least one key usage")
+	}
+	for _, usage := range p.KeyUsages {
+		if _, found := keyUsages[usage]; !found {
+			return fmt.Errorf("the key usages must be one of: %s, %s", KeyUsageDigitalSignature, KeyUsageKeyEncipherment)
+		}
+	}
+	for _, oid := range p.ExtendedKeyUsages {
+		if !oidRegex.MatchString(oid) {
+			return fmt.Errorf("the extended key usage '%s' must be an OID", oid)
+		}
+	}
+
+	if p.KeyLength != 2048 && p.KeyLength != 4096 {
+		return errors.New("the key length must be 2048 or 4096")
+	} else if p.ValidityDays < 1 || p.ValidityDays > 3650 {
+		return errors.New("the validity must be between 1 and 3650 days")
+	} else if p.RenewalThreshold < 1 || p.RenewalThreshold > 50 {
+		return errors.New("the renewal threshold must be between 1 and 50 percent")
+	}
+	return nil
+}
+
+// ValidateCA verifies certificates issued with the profile now won't outlive the CA. They would be issued with a shorter validity than the profile's as
+// certificates can't outlive the CA which issued them.
+func (p Profile) ValidateCA(ca *x509.Certificate, now time.Time) error {
+	if now.Add(time.Duration(p.ValidityDays) * 24 * time.Hour).After(ca.NotAfter) {
+		return fmt.Errorf("the validity can be at most %d days as the SCEP CA expires on %s", int(ca.NotAfter.Sub(now).Hours()/24), ca.NotAfter.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// keyUsageBits returns the ClientCertificateInstall KeyUsage value of the key usages
+func keyUsageBits(usages []string) int {
+	var bits int
+	for _, usage := range usages {
+		bits |= keyUsages[usage].bit
+	}
+	return bits
+}
+
+// x509KeyUsage returns the key usage certificates with the key usages are issued with
+func x509KeyUsage(usages []string) x509.KeyUsage {
+	var keyUsage x509.KeyUsage
+	for _, usage := range usages {
+		keyUsage |= keyUsages[usage].usage
+	}
+	return keyUsage
+}
+
+// x509ExtKeyUsages returns the extended key usages certificates with the OIDs are issued with
+func x509ExtKeyUsages(oids []string) ([]asn1.ObjectIdentifier, error) {
+	var usages = make([]asn1.ObjectIdentifier, 0, len(oids))
+	for _, oid := range oids {
+		var parsed asn1.ObjectIdentifier
+		for _, part := range strings.Split(oid, ".") {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("the extended key usage '%s' must be an OID", oid)
+			}
+			parsed = append(parsed, n)
+		}
+		usages = append(usages, parsed)
+	}
+	return usages, nil
+}
+
+// SubjectValues are the values of the subject template's placeholders
+type SubjectValues struct {
+	DeviceID   int32
+	DeviceName string
+	UDID       string
+	UPN        string // The user the certificate is issued to or, for device certificates, the user who enrolled the device
+}
+
+// RenderSubject expands the {deviceid}, {devicename}, {udid}, {upn} and {upnprefix} placeholders of the subject template.
+// The values are escaped so they can't add attributes to the subject.
+func RenderSubject(template string, values SubjectValues) string {
+	return placeholder.Expand(template, map[string]string{
+		"deviceid":   escapeDN(fmt.Sprint(values.DeviceID)),
+		"devicename": escapeDN(values.DeviceName),
+		"udid":       escapeDN(values.UDID),
+		"upn":        escapeDN(values.UPN),
+		"upnprefix":  escapeDN(strings.SplitN(values.UPN, "@", 2)[0]),
+	})
+}
+
+var dnEscaper = strings.NewReplacer(`\`, `\\`, `,`, `\,`, `+`, `\+`, `"`, `\"`, `<`, `\<`, `>`, `\>`, `;`, `\;`, `=`, `\=`)
+
+// escapeDN escapes the characters which have a meaning in a distinguished name
+func escapeDN(value string) string {
+	return dnEscaper.Replace(value)
+}
+
+// subjectAttributes are the OIDs of the attributes a subject may contain
+var subjectAttributes = map[string]asn1.ObjectIdentifier{
+	"CN":           {2, 5, 4, 3},
+	"SERIALNUMBER": {2, 5, 4, 5},
+	"C":            {2, 5, 4, 6},
+	"L":            {2, 5, 4, 7},
+	"S":            {2, 5, 4, 8},
+	"ST":           {2, 5, 4, 8},
+	"O":            {2, 5, 4, 10},
+	"OU":           {2, 5, 4, 11},
+	"E":            {1, 2, 840, 113549, 1, 9, 1},
+	"DC":           {0, 9, 2342, 19200300, 100, 1, 25},
+}
+
+type dnAttribute struct {
+	Type  string
+	Value string
+}
+
+// parseDN splits a distinguished name into its attributes in the order they are written
+func parseDN(dn string) ([]dnAttribute, error) {
+	var attributes []dnAttribute
+	var attributeType, value strings.Builder
+	var inValue, escaped bool
+	var add = func() error {
+		var attribute = dnAttribute{
+			Type:  strings.ToUpper(strings.TrimSpace(attributeType.String())),
+			Value: strings.TrimSpace(value.String()),
+		}
+		if !inValue {
+			return fmt.Errorf("the attribute '%s' must be in the form TYPE=value", attribute.Type)
+		} else if _, found := subjectAttributes[attribute.Type]; !found {
+			return fmt.Errorf("the attribute type '%s' is not supported", attribute.Type)
+		} else if attribute.Value == "" {
+			return fmt.Errorf("the %s attribute must have a value", attribute.Type)
+		}
+
+		attributes = append(attributes, attribute)
+		attributeType.Reset()
+		value.Reset()
+		inValue = false
+		return nil
+	}
+
+	for _, r := range dn {
+		switch {
+		case escaped:
+			value.WriteRune(r)
+			escaped = false
+		case r == '\\' && inValue:
+			escaped = true
+		case r == ',':
+			if err := add(); err != nil {
+				return nil, err
+			}
+		case r == '=' && !inValue:
+			inValue = true
+		case inValue:
+			value.WriteRune(r)
+		default:
+			attributeType.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, errors.New("the distinguished name must not end with an escape")
+	} else if err := add(); err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+// ParseSubject parses a distinguished name (eg. CN=DESKTOP-1,O=Mattrax) into the subject certificates are issued with.
+// Like RFC 4514 the first attribute is the most specific so the attributes are encoded in the reverse order.
+func ParseSubject(dn string) (pkix.Name, error) {
+	attributes, err := parseDN(dn)
+	if err != nil {
+		return pkix.Name{}, err
+	}
+
+	var name pkix.Name
+	for i := len(attributes) - 1; i >= 0; i-- {
+		name.ExtraNames = append(name.ExtraNames, pkix.AttributeTypeAndValue{
+			Type:  subjectAttributes[attributes[i].Type],
+			Value: attributes[i].Value,
+		})
+	}
+	return name, nil
+}
+
+// windowsSubject converts a distinguished name into the format of the ClientCertificateInstall SubjectName which quotes values instead of escaping them
+func windowsSubject(dn string) (string, error) {
+	attributes, err := parseDN(dn)
+	if err != nil {
+		return "", err
+	}
+
+	var parts = make([]string, 0, len(attributes))
+	for _, attribute := range attributes {
+		parts = append(parts, attribute.Type+`="`+strings.Replace(attribute.Value, `"`, `""`, -1)+`"`)
+	}
+	return strings.Join(parts, ", "), nil
+}
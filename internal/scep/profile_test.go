@@ -0,0 +1,28 @@
+package scep
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+func TestValidateCA(t *testing.T) {
+	var now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ca = &x509.Certificate{NotAfter: now.Add(400 * 24 * time.Hour)}
+
+	var tests = []struct {
+		validityDays int32
+		valid        bool
+	}{
+		{1, true},
+		{365, true},
+		{400, true},
+		{401, false},
+		{3650, false},
+	}
+	for _, tt := range tests {
+		if err := (Profile{ValidityDays: tt.validityDays}).ValidateCA(ca, now); (err == nil) != tt.valid {
+			t.Errorf("ValidateCA with a validity of %d days returned %v, expected valid to be %v", tt.validityDays, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Package scep implements the SCEP (RFC 8894) endpoint which issues certificates from the SCEP CA and the ClientCertificateInstall CSP payloads which enroll devices with it.
+// Devices are only issued a certificate when their request contains the single use challenge password generated for them, so the subject of the certificate is decided by Mattrax and not the device.
+package scep
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	"github.com/fullsailor/pkcs7"
+)
+
+// Path is the path of the SCEP endpoint. Clients also append /pkiclient.exe to it.
+const Path = "/ManagementServer/SCEP"
+
+// CRLPath is the path of the SCEP CA's certificate revocation list
+const CRLPath = Path + "/CRL"
+
+// Capabilities are the capabilities returned by GetCACaps
+var Capabilities = []string{"POSTPKIOperation", "SHA-256", "AES", "DES3", "SCEPStandard"}
+
+// MessageType is the type of a SCEP message
+type MessageType string
+
+const (
+	MessageTypeCertRep        MessageType = "3"
+	MessageTypeRenewalReq     MessageType = "17"
+	MessageTypePKCSReq        MessageType = "19"
+	MessageTypeGetCertInitial MessageType = "20"
+)
+
+// FailInfo is the reason a request failed
+type FailInfo string
+
+const (
+	FailBadAlg          FailInfo = "0"
+	FailBadMessageCheck FailInfo = "1"
+	FailBadRequest      FailInfo = "2"
+	FailBadTime         FailInfo = "3"
+	FailBadCertID       FailInfo = "4"
+)
+
+type pkiStatus string
+
+const (
+	pkiStatusSuccess pkiStatus = "0"
+	pkiStatusFailure pkiStatus = "2"
+)
+
+var (
+	oidMessageType       = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 2}
+	oidPKIStatus         = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 3}
+	oidFailInfo          = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 4}
+	oidSenderNonce       = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 5}
+	oidRecipientNonce    = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 6}
+	oidTransactionID     = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 7}
+	oidChallengePassword = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7}
+)
+
+// Request is a PKIOperation message sent by a client
+type Request struct {
+	MessageType   MessageType
+	TransactionID string
+	SenderNonce   []byte
+	Signer        *x509.Certificate // The (usually self-signed) certificate the response is encrypted to
+
+	p7 *pkcs7.PKCS7
+}
+
+// ParseRequest decodes and verifies the signature of a PKIOperation message. The CSR is decrypted separately so failures to decrypt it can be responded to.
+func ParseRequest(data []byte) (*Request, error) {
+	p7, err := pkcs7.Parse(data)
+	if err != nil {
+		return nil, err
+	} else if err := p7.Verify(); err != nil {
+		return nil, err
+	}
+
+	var req = Request{
+		Signer: p7.GetOnlySigner(),
+		p7:     p7,
+	}
+	if req.Signer == nil {
+		return nil, errors.New("the message must have a single signer")
+	}
+
+	var messageType string
+	if err := p7.UnmarshalSignedAttribute(oidMessageType, &messageType); err != nil {
+		return nil, fmt.Errorf("error decoding the message type: %w", err)
+	}
+	req.MessageType = MessageType(messageType)
+
+	if err := p7.UnmarshalSignedAttribute(oidTransactionID, &req.TransactionID); err != nil {
+		return nil, fmt.Errorf("error decoding the transaction id: %w", err)
+	} else if err := p7.UnmarshalSignedAttribute(oidSenderNonce, &req.SenderNonce); err != nil {
+		return nil, fmt.Errorf("error decoding the sender nonce: %w", err)
+	}
+	return &req, nil
+}
+
+// CSR decrypts the certificate request in the message using the CA it was encrypted to and returns it with its challenge password
+func (req *Request) CSR(ca *x509.Certificate, key *rsa.PrivateKey) (*x509.CertificateRequest, string, error) {
+	envelope, err := pkcs7.Parse(req.p7.Content)
+	if err != nil {
+		return nil, "", err
+	}
+
+	der, err := envelope.Decrypt(ca, key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, "", err
+	} else if err := csr.CheckSignature(); err != nil {
+		return nil, "", err
+	}
+
+	challenge, err := challengePassword(csr)
+	return csr, challenge, err
+}
+
+// challengePassword returns the challenge password attribute of the CSR. The x509 package skips the attribute as its value isn't a set of names.
+func challengePassword(csr *x509.CertificateRequest) (string, error) {
+	var tbs struct {
+		Version       int
+		Subject       asn1.RawValue
+		PublicKey     asn1.RawValue
+		RawAttributes []asn1.RawValue `asn1:"tag:0"`
+	}
+	if _, err := asn1.Unmarshal(csr.RawTBSCertificateRequest, &tbs); err != nil {
+		return "", err
+	}
+
+	for _, rawAttribute := range tbs.RawAttributes {
+		var attribute struct {
+			Type   asn1.ObjectIdentifier
+			Values []asn1.RawValue `asn1:"set"`
+		}
+		if _, err := asn1.Unmarshal(rawAttribute.FullBytes, &attribute); err != nil {
+			return "", err
+		} else if !attribute.Type.Equal(oidChallengePassword) || len(attribute.Values) == 0 {
+			continue
+		}
+
+		var password string
+		if _, err := asn1.Unmarshal(attribute.Values[0].FullBytes, &password); err != nil {
+			return "", err
+		}
+		return password, nil
+	}
+	return "", nil
+}
+
+// Success returns the CertRep message which delivers the issued certificate to the client. The certificate is encrypted to the request's signer.
+func Success(req *Request, ca *x509.Certificate, key *rsa.PrivateKey, cert *x509.Certificate) ([]byte, error) {
+	degenerate, err := pkcs7.DegenerateCertificate(cert.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := envelope(degenerate, req.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return certRep(req, ca, key, content, []pkcs7.Attribute{
+		{Type: oidPKIStatus, Value: string(pkiStatusSuccess)},
+	})
+}
+
+// Failure returns the CertRep message which rejects the request
+func Failure(req *Request, ca *x509.Certificate, key *rsa.PrivateKey, info FailInfo) ([]byte, error) {
+	return certRep(req, ca, key, nil, []pkcs7.Attribute{
+		{Type: oidPKIStatus, Value: string(pkiStatusFailure)},
+		{Type: oidFailInfo, Value: string(info)},
+	})
+}
+
+// certRep signs a CertRep message replying to the request
+func certRep(req *Request, ca *x509.Certificate, key *rsa.PrivateKey, content []byte, attributes []pkcs7.Attribute) ([]byte, error) {
+	var senderNonce = make([]byte, 16)
+	if _, err := rand.Read(senderNonce); err != nil {
+		return nil, err
+	}
+
+	signedData, err := pkcs7.NewSignedData(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := signedData.AddSigner(ca, key, pkcs7.SignerInfoConfig{
+		ExtraSignedAttributes: append([]pkcs7.Attribute{
+			{Type: oidMessageType, Value: string(MessageTypeCertRep)},
+			{Type: oidTransactionID, Value: req.TransactionID},
+			{Type: oidSenderNonce, Value: senderNonce},
+			{Type: oidRecipientNonce, Value: req.SenderNonce},
+		}, attributes...),
+	}); err != nil {
+		return nil, err
+	}
+	return signedData.Finish()
+}
@@ -0,0 +1,317 @@
+package scep
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fullsailor/pkcs7"
+)
+
+// testCertificate returns a self-signed certificate for the key which is a CA when ca is set
+func testCertificate(t *testing.T, commonName string, publicKey, key crypto.Signer, ca bool, lifetime time.Duration) *x509.Certificate {
+	t.Helper()
+	var template = x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(lifetime),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+	}
+	if ca {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, &template, &template, publicKey.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// testKey returns a new RSA key of the length
+func testKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// testCSR returns a DER encoded CSR with the challenge password attribute when it isn't empty. It is built by hand as the x509 package can only encode attributes which are sets of names.
+func testCSR(t *testing.T, key *rsa.PrivateKey, commonName string, challengePassword string) []byte {
+	t.Helper()
+	raw, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: commonName}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := x509.ParseCertificateRequest(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var attributes []asn1.RawValue
+	if challengePassword != "" {
+		value, err := asn1.Marshal(challengePassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+		attribute, err := asn1.Marshal(struct {
+			Type   asn1.ObjectIdentifier
+			Values []asn1.RawValue `asn1:"set"`
+		}{oidChallengePassword, []asn1.RawValue{{FullBytes: value}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		attributes = append(attributes, asn1.RawValue{FullBytes: attribute})
+	}
+
+	tbs, err := asn1.Marshal(struct {
+		Version       int
+		Subject       asn1.RawValue
+		PublicKey     asn1.RawValue
+		RawAttributes []asn1.RawValue `asn1:"tag:0"`
+	}{0, asn1.RawValue{FullBytes: csr.RawSubject}, asn1.RawValue{FullBytes: csr.RawSubjectPublicKeyInfo}, attributes})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hash = sha256.Sum256(tbs)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := asn1.Marshal(struct {
+		TBS                asn1.RawValue
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+	}{
+		asn1.RawValue{FullBytes: tbs},
+		pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}, Parameters: asn1.NullRawValue},
+		asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// testPKCSReq returns a PKCSReq message like the ones sent by Windows with the CSR encrypted to the CA and signed by the client's self-signed certificate
+func testPKCSReq(t *testing.T, csr []byte, ca, client *x509.Certificate, clientKey *rsa.PrivateKey, senderNonce []byte) []byte {
+	t.Helper()
+	enveloped, err := envelope(csr, ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signedData, err := pkcs7.NewSignedData(enveloped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signedData.AddSigner(client, clientKey, pkcs7.SignerInfoConfig{
+		ExtraSignedAttributes: []pkcs7.Attribute{
+			{Type: oidMessageType, Value: string(MessageTypePKCSReq)},
+			{Type: oidTransactionID, Value: "transaction-1"},
+			{Type: oidSenderNonce, Value: senderNonce},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := signedData.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestEnvelope(t *testing.T) {
+	var key = testKey(t, 2048)
+	var recipient = testCertificate(t, "Recipient", key, key, false, time.Hour)
+
+	// Lengths around the block size check the padding
+	for _, length := range []int{0, 1, 15, 16, 17, 32, 1000} {
+		var content = make([]byte, length)
+		if _, err := rand.Read(content); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := envelope(content, recipient)
+		if err != nil {
+			t.Fatalf("enveloping %d bytes returned %q", length, err)
+		}
+
+		p7, err := pkcs7.Parse(data)
+		if err != nil {
+			t.Errorf("parsing the envelope of %d bytes returned %q", length, err)
+			continue
+		}
+
+		decrypted, err := p7.Decrypt(recipient, key)
+		if err != nil {
+			t.Errorf("decrypting the envelope of %d bytes returned %q", length, err)
+		} else if !bytes.Equal(decrypted, content) {
+			t.Errorf("decrypting the envelope of %d bytes returned %x, expected %x", length, decrypted, content)
+		}
+	}
+}
+
+func TestEnvelopeWrongRecipient(t *testing.T) {
+	var key, otherKey = testKey(t, 2048), testKey(t, 2048)
+	var recipient = testCertificate(t, "Recipient", key, key, false, time.Hour)
+
+	data, err := envelope([]byte("content"), recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p7, err := pkcs7.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p7.Decrypt(recipient, otherKey); err == nil {
+		t.Error("the envelope was decrypted with another key")
+	}
+}
+
+func TestEnvelopeNotRSARecipient(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := envelope([]byte("content"), testCertificate(t, "Recipient", key, key, false, time.Hour)); err != errNotRSARecipient {
+		t.Errorf("enveloping to an ECDSA certificate returned %v, expected %q", err, errNotRSARecipient)
+	}
+}
+
+func TestChallengePassword(t *testing.T) {
+	var key = testKey(t, 2048)
+	var tests = []struct {
+		password string
+	}{
+		{""},
+		{"D0E0F1A2B3C4D5E6"},
+		{"pässwörd"}, // encoded as a UTF8String instead of a PrintableString
+	}
+	for _, tt := range tests {
+		csr, err := x509.ParseCertificateRequest(testCSR(t, key, "DESKTOP-1", tt.password))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if password, err := challengePassword(csr); err != nil {
+			t.Errorf("challengePassword of a CSR with the password %q returned %q", tt.password, err)
+		} else if password != tt.password {
+			t.Errorf("challengePassword returned %q, expected %q", password, tt.password)
+		}
+	}
+}
+
+func TestRequestRoundTrip(t *testing.T) {
+	var caKey, clientKey = testKey(t, 2048), testKey(t, 2048)
+	var ca = testCertificate(t, "Mattrax SCEP CA", caKey, caKey, true, 24*time.Hour)
+	var client = testCertificate(t, "DESKTOP-1", clientKey, clientKey, false, time.Hour)
+	var senderNonce = []byte("0123456789abcdef")
+
+	req, err := ParseRequest(testPKCSReq(t, testCSR(t, clientKey, "DESKTOP-1", "password"), ca, client, clientKey, senderNonce))
+	if err != nil {
+		t.Fatal(err)
+	} else if req.MessageType != MessageTypePKCSReq || req.TransactionID != "transaction-1" || !bytes.Equal(req.SenderNonce, senderNonce) || !req.Signer.Equal(client) {
+		t.Fatalf("the request was parsed as %+v", req)
+	}
+
+	csr, challenge, err := req.CSR(ca, caKey)
+	if err != nil {
+		t.Fatal(err)
+	} else if challenge != "password" {
+		t.Errorf("the challenge password was %q, expected %q", challenge, "password")
+	} else if csr.Subject.CommonName != "DESKTOP-1" {
+		t.Errorf("the CSR's subject was %q, expected %q", csr.Subject.CommonName, "DESKTOP-1")
+	}
+
+	// The CSR can only be decrypted by the CA it was encrypted to
+	var otherKey = testKey(t, 2048)
+	if _, _, err := req.CSR(testCertificate(t, "Other CA", otherKey, otherKey, true, time.Hour), otherKey); err == nil {
+		t.Error("the CSR was decrypted by another CA")
+	}
+
+	var issued = testCertificate(t, "Issued", clientKey, caKey, false, time.Hour)
+	data, err := Success(req, ca, caKey, issued)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := parseCertRep(t, data, req, pkiStatusSuccess)
+	envelope, err := pkcs7.Parse(res.Content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	degenerate, err := envelope.Decrypt(client, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certs, err := pkcs7.Parse(degenerate)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(certs.Certificates) != 1 || !certs.Certificates[0].Equal(issued) {
+		t.Errorf("the response contains the certificates %v, expected the issued certificate", certs.Certificates)
+	}
+
+	data, err = Failure(req, ca, caKey, FailBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failInfo string
+	if err := parseCertRep(t, data, req, pkiStatusFailure).UnmarshalSignedAttribute(oidFailInfo, &failInfo); err != nil {
+		t.Fatal(err)
+	} else if FailInfo(failInfo) != FailBadRequest {
+		t.Errorf("the fail info was %q, expected %q", failInfo, FailBadRequest)
+	}
+}
+
+// parseCertRep verifies the CertRep message is signed by the CA and replies to the request with the status
+func parseCertRep(t *testing.T, data []byte, req *Request, status pkiStatus) *pkcs7.PKCS7 {
+	t.Helper()
+	p7, err := pkcs7.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := p7.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	var messageType, transactionID, pkiStatus string
+	var recipientNonce []byte
+	if err := p7.UnmarshalSignedAttribute(oidMessageType, &messageType); err != nil {
+		t.Fatal(err)
+	} else if err := p7.UnmarshalSignedAttribute(oidTransactionID, &transactionID); err != nil {
+		t.Fatal(err)
+	} else if err := p7.UnmarshalSignedAttribute(oidPKIStatus, &pkiStatus); err != nil {
+		t.Fatal(err)
+	} else if err := p7.UnmarshalSignedAttribute(oidRecipientNonce, &recipientNonce); err != nil {
+		t.Fatal(err)
+	}
+
+	if MessageType(messageType) != MessageTypeCertRep || transactionID != req.TransactionID || !bytes.Equal(recipientNonce, req.SenderNonce) {
+		t.Errorf("the CertRep has the message type %q, transaction id %q and recipient nonce %x", messageType, transactionID, recipientNonce)
+	} else if pkiStatus != string(status) {
+		t.Errorf("the CertRep's status was %q, expected %q", pkiStatus, status)
+	}
+	return p7
+}
@@ -0,0 +1,6 @@
+package scripts
+
+// AgentPath is the path of the API the Mattrax agent retrieves its scripts from and reports their results (and the device's BitLocker recovery passwords) to.
+// The agent authenticates with the device's MDM client certificate, which only exists in the machine's certificate store for devices enrolled with the Device
+// enrollment type so only SYSTEM (and administrators) can use its private key.
+const AgentPath = "/ManagementServer/Agent/"
@@ -0,0 +1,56 @@
+// Package scripts schedules the PowerShell scripts run by the Mattrax agent.
+// Windows has no CSP which runs scripts so they are delivered by the agent. The agent isn't part of this repository and nothing installs it: it is a prerequisite
+// which administrators deploy to devices as a required MSI app (see the README), and scripts don't run on devices without it.
+package scripts
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+// MaxSize is the largest script which can be uploaded
+const MaxSize = 1 << 20
+
+// MaxOutputSize is the most of each of a run's stdout and stderr which is stored
+const MaxOutputSize = 64 << 10
+
+// intervals is how long after a run a scheduled script is run again. Scripts which run once are only run again when their content changes.
+var intervals = map[db.ScriptSchedule]time.Duration{
+	db.ScriptScheduleHourly: time.Hour,
+	db.ScriptScheduleDaily:  24 * time.Hour,
+	db.ScriptScheduleWeekly: 7 * 24 * time.Hour,
+}
+
+// Validate verifies the script's content, run context and schedule
+func Validate(content string, runContext db.ScriptContext, schedule db.ScriptSchedule) error {
+	if content == "" {
+		return errors.New("the script must not be empty")
+	} else if len(content) > MaxSize {
+		return errors.New("the script must be smaller than 1MB")
+	} else if runContext != db.ScriptContextSystem && runContext != db.ScriptContextUser {
+		return errors.New("the run context must be one of: system, user")
+	} else if _, found := intervals[schedule]; !found && schedule != db.ScriptScheduleOnce {
+		return errors.New("the schedule must be one of: once, hourly, daily, weekly")
+	}
+	return nil
+}
+
+// Due returns whether the script should be run on the device. Scripts which never ran or changed since their last run are always due.
+func Due(script db.GetDeviceScriptsRow, now time.Time) bool {
+	if script.RunVersion != script.Version {
+		return true
+	} else if script.Schedule == db.ScriptScheduleOnce {
+		return false
+	}
+	return now.Sub(script.RunFinishedAt) >= intervals[script.Schedule]
+}
+
+// Truncate limits the output of a run to MaxOutputSize
+func Truncate(output string) string {
+	if len(output) > MaxOutputSize {
+		return output[:MaxOutputSize]
+	}
+	return output
+}
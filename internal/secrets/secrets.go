@@ -0,0 +1,79 @@
+// Package secrets encrypts sensitive values (eg. BitLocker recovery passwords) with AES-256-GCM before they are stored in the database.
+// The key is stored in a file instead of the database so a copy of the database can't be used to decrypt them on its own.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io/ioutil"
+	"os"
+
+	"github.com/rs/zerolog/log"
+)
+
+// keySize is the length of the AES-256 key
+const keySize = 32
+
+// ErrInvalidCiphertext is returned when a value can't be decrypted because it was modified or encrypted with different associated data or another key
+var ErrInvalidCiphertext = errors.New("the secret could not be decrypted")
+
+// Service encrypts and decrypts secrets
+type Service struct {
+	aead cipher.AEAD
+}
+
+// Encrypt encrypts the plaintext. The associated data (eg. the ID of the row the secret is stored in) must be the same when the secret is decrypted which stops secrets being swapped between rows.
+func (s *Service) Encrypt(plaintext, associatedData []byte) ([]byte, error) {
+	var nonce = make([]byte, s.aead.NonceSize(), s.aead.NonceSize()+len(plaintext)+s.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+// Decrypt decrypts a secret returned by Encrypt
+func (s *Service) Decrypt(ciphertext, associatedData []byte) ([]byte, error) {
+	if len(ciphertext) < s.aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	plaintext, err := s.aead.Open(nil, ciphertext[:s.aead.NonceSize()], ciphertext[s.aead.NonceSize():], associatedData)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+// New initialises the secrets service with the key stored in the file. The key is generated if the file doesn't exist.
+// The file must be backed up with the database as the secrets can't be decrypted without it.
+func New(path string) (*Service, error) {
+	key, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		key = make([]byte, keySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+
+		if err := ioutil.WriteFile(path, key, 0600); err != nil {
+			return nil, err
+		}
+		log.Info().Str("path", path).Msg("Generated new secrets key")
+	} else if err != nil {
+		return nil, err
+	} else if len(key) != keySize {
+		return nil, errors.New("the secrets key must be 32 bytes")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{aead: aead}, nil
+}
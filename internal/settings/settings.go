@@ -21,6 +21,13 @@ func (s *Service) Get() db.Setting {
 	return settings
 }
 
+// Set safely replaces the servers settings once they have been stored in the database
+func (s *Service) Set(settings db.Setting) {
+	s.settingsLock.Lock()
+	s.settings = settings
+	s.settingsLock.Unlock()
+}
+
 // New initialises a new settings service
 func New(q *db.Queries) (*Service, error) {
 	settings, err := q.Settings(context.Background())
@@ -0,0 +1,144 @@
+package windows
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/apps"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/rollouts"
+	"github.com/mattrax/Mattrax/pkg/syncml"
+	"github.com/patrickmn/go-cache"
+	"github.com/rs/zerolog/log"
+)
+
+// MSIInstallAlertType is the type of the alert the device sends with the result of an MSI install
+const MSIInstallAlertType = "com.microsoft.mdm.win32csp_install"
+
+// appCommand is a command sent to install or uninstall an app which is awaiting the device's Status for it
+type appCommand struct {
+	AppID     int32
+	Uninstall bool
+}
+
+// AppDownload serves the installer files which devices download while installing an app. Files are only served if an app uses them.
+// The file name after the hash is ignored as it is only used by the device to detect the package type.
+func AppDownload(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var hash = mux.Vars(r)["hash"]
+		if !apps.ValidHash(hash) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		app, err := srv.DB.GetAppByHash(r.Context(), hash)
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error().Str("hash", hash).Err(err).Msg("Error retrieving app")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		f, err := os.Open(apps.Path(srv.Args.AppsDir, app.FileHash))
+		if err != nil {
+			log.Error().Int32("app", app.ID).Err(err).Msg("Error opening app installer")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+
+		// ServeContent supports the range requests BITS uses to resume downloads
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, "", app.CreatedAt, f)
+	}
+}
+
+// deployApps sends the commands which make the apps assigned to the device match their intent. Apps installed for a user are only deployed in a user's session.
+// The installed modern apps are retrieved at the start of each session so the install state of modern apps can be confirmed.
+func deployApps(ctx context.Context, srv *mattrax.Server, cmd syncml.Message, res *syncml.Response, deviceID int32, sessionUser string) error {
+	if cmd.Header.MsgID == "1" {
+		for _, uri := range apps.InventoryURIs(sessionUser != "") {
+			res.Set("Get", uri, "", "", "")
+		}
+	}
+
+	assignments, err := srv.DB.GetDeviceAppAssignments(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+
+	var now = time.Now()
+	for _, app := range apps.Resolve(assignments) {
+		if app.UserContext && sessionUser == "" {
+			continue
+		}
+
+		switch apps.ActionFor(app, now) {
+		case apps.ActionInstall:
+			commands, err := apps.InstallCommands(app, srv.Args.Domain)
+			if err != nil {
+				return err
+			}
+
+			for _, command := range commands {
+				var cmdID = res.Set(command.Command, command.URI, command.Type, command.Format, command.Value)
+				if command.Command == "Exec" {
+					srv.Cache.Set(deployedCommandCacheKey(cmd, res.MsgID(), cmdID), appCommand{
+						AppID: app.ID,
+					}, cache.DefaultExpiration)
+				}
+			}
+
+			if err := apps.SetState(ctx, srv.DB, app.ID, deviceID, db.AppInstallStateInstalling, ""); err != nil {
+				return err
+			}
+		case apps.ActionUninstall:
+			for _, command := range apps.UninstallCommands(app) {
+				var cmdID = res.Set(command.Command, command.URI, command.Type, command.Format, command.Value)
+				srv.Cache.Set(deployedCommandCacheKey(cmd, res.MsgID(), cmdID), appCommand{
+					AppID:     app.ID,
+					Uninstall: true,
+				}, cache.DefaultExpiration)
+			}
+
+			if err := apps.SetState(ctx, srv.DB, app.ID, deviceID, db.AppInstallStateUninstalling, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// handleAppStatus records an install as failed if the device rejected the command which started it. Successful installs are reported later by an alert or the device's inventory.
+// Uninstalls are complete once the device deletes the app, which it also reports as not found if the app was already removed.
+func handleAppStatus(ctx context.Context, srv *mattrax.Server, deviceID int32, command appCommand, code int) {
+	if command.Uninstall && (rollouts.Succeeded("Delete", code) || code == syncml.StatusNotFound) {
+		if err := apps.SetState(ctx, srv.DB, command.AppID, deviceID, db.AppInstallStateUninstalled, ""); err != nil {
+			log.Error().Int32("id", deviceID).Int32("app", command.AppID).Err(err).Msg("Error recording app uninstall")
+		}
+		return
+	} else if command.Uninstall {
+		log.Debug().Int32("id", deviceID).Int32("app", command.AppID).Int("status", code).Msg("Device failed to uninstall app")
+		if err := apps.SetState(ctx, srv.DB, command.AppID, deviceID, db.AppInstallStateFailed, strconv.Itoa(code)); err != nil {
+			log.Error().Int32("id", deviceID).Int32("app", command.AppID).Err(err).Msg("Error recording app uninstall failure")
+		}
+		return
+	}
+
+	if rollouts.Succeeded("Exec", code) {
+		return
+	}
+
+	log.Debug().Int32("id", deviceID).Int32("app", command.AppID).Int("status", code).Msg("Device failed to install app")
+	if err := apps.SetState(ctx, srv.DB, command.AppID, deviceID, db.AppInstallStateFailed, strconv.Itoa(code)); err != nil {
+		log.Error().Int32("id", deviceID).Int32("app", command.AppID).Err(err).Msg("Error recording app install failure")
+	}
+}
@@ -0,0 +1,95 @@
+package windows
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/pkg/soap"
+	"github.com/rs/zerolog/log"
+)
+
+// maxEnrollmentAttempts is the number of enrollment attempts kept regardless of the retention
+const maxEnrollmentAttempts = 10000
+
+// pruneInterval is how often old enrollment attempts are deleted
+const pruneInterval = time.Hour
+
+// enrollmentAttempt records the outcome of a single Discovery, Policy or Enrollment request. Discovery requests are only logged.
+// Its trace ID is returned to the device in faults so administrators can look up why an enrollment failed.
+type enrollmentAttempt struct {
+	srv *mattrax.Server
+	db.NewEnrollmentAttemptParams
+}
+
+func newEnrollmentAttempt(srv *mattrax.Server, endpoint string) *enrollmentAttempt {
+	return &enrollmentAttempt{
+		srv: srv,
+		NewEnrollmentAttemptParams: db.NewEnrollmentAttemptParams{
+			TraceID:  uuid.New().String(),
+			Endpoint: endpoint,
+		},
+	}
+}
+
+// Success records the attempt as successful. The deviceID is 0 for endpoints which don't create a device.
+func (a *enrollmentAttempt) Success(ctx context.Context, deviceID int32) {
+	a.Result = db.EnrollmentAttemptResultSuccess
+	a.DeviceID = sql.NullInt32{Int32: deviceID, Valid: deviceID != 0}
+	a.record(ctx)
+}
+
+// Failed records the attempt as failed without responding. It is used when the request couldn't be read so no fault can be returned.
+func (a *enrollmentAttempt) Failed(ctx context.Context, err error) {
+	a.Result = db.EnrollmentAttemptResultFault
+	if err != nil {
+		a.Error = err.Error()
+	}
+	a.record(ctx)
+}
+
+// Fault records the attempt as failed and responds with a fault containing the attempts trace ID.
+// The err is the internal cause of the fault which is stored with the attempt but never sent to the device.
+func (a *enrollmentAttempt) Fault(w http.ResponseWriter, r *http.Request, causer, code, errortype, reason string, err error) {
+	a.FaultCode = code
+	a.FaultType = errortype
+	a.FaultReason = reason
+	a.Failed(r.Context(), err)
+
+	soap.Respond(soap.NewFault(causer, code, errortype, reason, a.TraceID), w)
+}
+
+// record stores the attempt. Discovery requests are unauthenticated and sent before every enrollment so they are only logged, which stops anyone filling the table by sending them.
+func (a *enrollmentAttempt) record(ctx context.Context) {
+	if a.Endpoint == "discovery" {
+		if a.Result == db.EnrollmentAttemptResultSuccess {
+			log.Debug().Str("traceid", a.TraceID).Str("upn", a.Upn).Msg("Discovery request")
+		} else {
+			log.Warn().Str("traceid", a.TraceID).Str("upn", a.Upn).Str("fault", a.FaultReason).Str("error", a.Error).Msg("Discovery request failed")
+		}
+		return
+	}
+
+	if err := a.srv.DB.NewEnrollmentAttempt(ctx, a.NewEnrollmentAttemptParams); err != nil {
+		log.Error().Str("traceid", a.TraceID).Err(err).Msg("error recording enrollment attempt")
+	}
+}
+
+// pruneEnrollmentAttempts periodically deletes the enrollment attempts older than the retention and all but the newest maxEnrollmentAttempts
+func pruneEnrollmentAttempts(srv *mattrax.Server) {
+	var ticker = time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		if err := srv.DB.PruneEnrollmentAttempts(context.Background(), db.PruneEnrollmentAttemptsParams{
+			CreatedBefore: time.Now().Add(-time.Duration(srv.Args.AttemptRetention) * 24 * time.Hour),
+			Keep:          maxEnrollmentAttempts,
+		}); err != nil {
+			log.Error().Err(err).Msg("error pruning enrollment attempts")
+		}
+		<-ticker.C
+	}
+}
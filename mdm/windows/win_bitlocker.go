@@ -0,0 +1,62 @@
+package windows
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/bitlocker"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/rs/zerolog/log"
+)
+
+// maxBitLockerReportSize is the largest list of recovery passwords the agent can report
+const maxBitLockerReportSize = 64 << 10
+
+// AgentBitLockerResponse tells the agent whether to replace the device's recovery passwords and report the new ones
+type AgentBitLockerResponse struct {
+	Rotate bool `json:"rotate"`
+}
+
+// AgentBitLocker escrows the recovery passwords of the agent's device's encrypted volumes
+func AgentBitLocker(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deviceID, ok := agentDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		var keys []bitlocker.RecoveryKey
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBitLockerReportSize)).Decode(&keys); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		for _, key := range keys {
+			if err := key.Validate(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		var rotate bool
+		if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+			var err error
+			rotate, err = bitlocker.Escrow(ctx, q, srv.Secrets, deviceID, keys)
+			return err
+		}); err != nil {
+			log.Error().Int32("id", deviceID).Err(err).Msg("Error escrowing BitLocker recovery keys")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(AgentBitLockerResponse{
+			Rotate: rotate,
+		}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
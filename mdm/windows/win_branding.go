@@ -0,0 +1,91 @@
+package windows
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/pkg/placeholder"
+)
+
+// DefaultEnrollmentBranding is used for any branding values the administrator hasn't configured
+var DefaultEnrollmentBranding = db.EnrollmentBranding{
+	Title:        "Mattrax Enrollment Complete",
+	BodyTemplate: "Welcome {user}, Your device is now being managed by '{tenant}'. Please contact your IT administrators for support if you have any problems.",
+}
+
+// EnrollmentBranding returns the branding for the first of the users preferred languages which has been configured.
+// Each language is tried as given (eg. "en-US") and then by its base language (eg. "en") before falling back to the default branding.
+func EnrollmentBranding(ctx context.Context, q *db.Queries, settings db.Setting, languages ...string) (db.EnrollmentBranding, error) {
+	brandings, err := q.GetEnrollmentBrandings(ctx)
+	if err != nil {
+		return db.EnrollmentBranding{}, err
+	}
+
+	var brandingByLanguage = make(map[string]db.EnrollmentBranding, len(brandings))
+	for _, branding := range brandings {
+		brandingByLanguage[strings.ToLower(branding.Language)] = branding
+	}
+
+	var branding db.EnrollmentBranding
+	for _, language := range languages {
+		language = strings.ToLower(strings.TrimSpace(language))
+		if b, ok := brandingByLanguage[language]; ok && language != "" {
+			branding = b
+			break
+		} else if b, ok := brandingByLanguage[strings.SplitN(language, "-", 2)[0]]; ok && language != "" {
+			branding = b
+			break
+		}
+	}
+
+	var defaultBranding = DefaultEnrollmentBranding
+	defaultBranding.ApplicationName = settings.TenantName
+	for _, fallback := range []db.EnrollmentBranding{brandingByLanguage[""], defaultBranding} {
+		if branding.ApplicationName == "" {
+			branding.ApplicationName = fallback.ApplicationName
+		}
+		if branding.Title == "" {
+			branding.Title = fallback.Title
+		}
+		if branding.BodyTemplate == "" {
+			branding.BodyTemplate = fallback.BodyTemplate
+		}
+		if branding.SupportUrl == "" {
+			branding.SupportUrl = fallback.SupportUrl
+		}
+		if branding.LogoUrl == "" {
+			branding.LogoUrl = fallback.LogoUrl
+		}
+		if branding.SupportText == "" {
+			branding.SupportText = fallback.SupportText
+		}
+	}
+	if branding.SupportText == "" {
+		branding.SupportText = "Get support from " + branding.ApplicationName
+	}
+
+	return branding, nil
+}
+
+// EnrollmentBodyText renders the branding's body template. The supported placeholders are {user}, {upn}, {device} and {tenant}.
+func EnrollmentBodyText(branding db.EnrollmentBranding, user db.GetUserRow, deviceName, tenantName string) string {
+	return placeholder.Expand(branding.BodyTemplate, map[string]string{
+		"user":   user.Fullname,
+		"upn":    user.Upn,
+		"device": deviceName,
+		"tenant": tenantName,
+	})
+}
+
+// AcceptLanguages returns the languages from the requests Accept-Language header in the order they were sent
+func AcceptLanguages(r *http.Request) []string {
+	var languages []string
+	for _, language := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		if language = strings.TrimSpace(strings.SplitN(language, ";", 2)[0]); language != "" && language != "*" {
+			languages = append(languages, language)
+		}
+	}
+	return languages
+}
@@ -1,20 +1,24 @@
 package windows
 
 import (
+	"context"
 	"crypto/x509/pkix"
 	"database/sql"
 	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 
 	mattrax "github.com/mattrax/Mattrax/internal"
 	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/dynamicgroups"
 	"github.com/mattrax/Mattrax/pkg"
 	"github.com/mattrax/Mattrax/pkg/null"
 	"github.com/mattrax/Mattrax/pkg/soap"
 	wap "github.com/mattrax/Mattrax/pkg/wap_provisioning_doc"
 	"github.com/mattrax/xml"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
@@ -33,10 +37,14 @@ func Discovery(srv *mattrax.Server) http.HandlerFunc {
 			return
 		}
 
+		var attempt = newEnrollmentAttempt(srv, "discovery")
 		var cmd soap.DiscoverRequest
 		if errored := soap.Read(&cmd, r, w); errored {
+			attempt.Failed(r.Context(), errors.New("error reading discovery request"))
 			return
 		}
+		attempt.MessageID = cmd.Header.MessageID
+		attempt.Upn = cmd.Body.EmailAddress
 
 		var res = soap.NewDiscoverResponse(cmd.Header.MessageID)
 		res.Body.Body = soap.DiscoverResponse{
@@ -46,6 +54,7 @@ func Discovery(srv *mattrax.Server) http.HandlerFunc {
 			EnrollmentServiceURL:       enrollmentServiceURL,
 			AuthenticationServiceURL:   federationServiceURL,
 		}
+		attempt.Success(r.Context(), 0)
 		soap.Respond(res, w)
 	}
 }
@@ -54,32 +63,41 @@ func Discovery(srv *mattrax.Server) http.HandlerFunc {
 // This endpoint is part of the spec MS-XCEP.
 func Policy(srv *mattrax.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		var attempt = newEnrollmentAttempt(srv, "policy")
 		var cmd soap.PolicyRequest
 		if errored := soap.Read(&cmd, r, w); errored {
+			attempt.Failed(r.Context(), errors.New("error reading policy request"))
 			return
 		}
+		attempt.MessageID = cmd.Header.MessageID
 
 		if url, err := url.ParseRequestURI(cmd.Header.To); cmd.Header.Action != "http://schemas.microsoft.com/windows/pki/2009/01/enrollmentpolicy/IPolicy/GetPolicies" || err != nil || url.Host != srv.Args.Domain {
-			w.WriteHeader(http.StatusBadRequest)
+			attempt.Fault(w, r, "s:Receiver", "s:MessageFormat", "", "The request was not destined for this server", err)
 			return
 		}
 
 		authenticationToken, err := base64.StdEncoding.DecodeString(cmd.Header.WSSESecurity.BinarySecurityToken)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
+			attempt.Fault(w, r, "s:Receiver", "a:InvalidSecurity", "", "The security header could not be parsed", err)
 			return
 		}
 
-		if _, err := srv.Auth.Token(string(authenticationToken)); err != nil {
-			log.Error().Err(err).Msg("error verifying authentication token")
-			w.WriteHeader(http.StatusUnauthorized)
+		authClaims, err := srv.Auth.Token(string(authenticationToken))
+		if err != nil {
+			log.Error().Str("traceid", attempt.TraceID).Err(err).Msg("error verifying authentication token")
+			attempt.Fault(w, r, "s:Receiver", "s:Authentication", "", "The user's authenticity could not be verified", err)
 			return
 		}
+		attempt.Upn = authClaims.Subject
 
+		attempt.Success(r.Context(), 0)
 		soap.Respond(soap.NewPolicyResponse(cmd.Header.MessageID, "mattrax-identity", "Mattrax Identity Certificate Policy"), w)
 	}
 }
 
+// errDeviceAlreadyEnrolled is returned from the enrollment transaction when the device is enrolled and must be removed before it can enroll again
+var errDeviceAlreadyEnrolled = errors.New("device already enrolled")
+
 // Enrollment provisions the device's management client and issues it a certificate which is used for authentication
 func Enrollment(srv *mattrax.Server) http.HandlerFunc {
 	managementServiceURL, err := pkg.GetNamedRouteURL(srv.GlobalRouter, "winmdm-manage") // TODO: Move error handling to main package
@@ -88,36 +106,53 @@ func Enrollment(srv *mattrax.Server) http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
+		var attempt = newEnrollmentAttempt(srv, "enrollment")
 		var cmd soap.EnrollmentRequest
 		if errored := soap.Read(&cmd, r, w); errored {
+			attempt.Failed(r.Context(), errors.New("error reading enrollment request"))
 			return
 		}
+		attempt.MessageID = cmd.Header.MessageID
+		attempt.Udid = cmd.GetAdditionalContextItem("DeviceID")
+		attempt.HwDevID = cmd.GetAdditionalContextItem("HWDevID")
 
 		if url, err := url.ParseRequestURI(cmd.Header.To); cmd.Header.Action != "http://schemas.microsoft.com/windows/pki/2009/01/enrollment/RST/wstep" || err != nil || url.Host != srv.Args.Domain {
-			var res = soap.NewFault("s:Receiver", "s:MessageFormat", "", "The request was not destined for this server", "")
-			soap.Respond(res, w)
+			attempt.Fault(w, r, "s:Receiver", "s:MessageFormat", "", "The request was not destined for this server", err)
 			return
 		}
 
 		authenticationToken, err := base64.StdEncoding.DecodeString(cmd.Header.WSSESecurity.BinarySecurityToken)
 		if err != nil {
-			var res = soap.NewFault("s:Receiver", "a:InvalidSecurity", "", "The security header could not be parsed", "")
-			soap.Respond(res, w)
+			attempt.Fault(w, r, "s:Receiver", "a:InvalidSecurity", "", "The security header could not be parsed", err)
 			return
 		}
 
 		authClaims, err := srv.Auth.Token(string(authenticationToken))
 		if err != nil {
-			log.Error().Err(err).Msg("error verifying authentication token")
-			var res = soap.NewFault("s:Receiver", "s:Authentication", "", "The user's authenticity could not be verified", "")
-			soap.Respond(res, w)
+			log.Error().Str("traceid", attempt.TraceID).Err(err).Msg("error verifying authentication token")
+			attempt.Fault(w, r, "s:Receiver", "s:Authentication", "", "The user's authenticity could not be verified", err)
+			return
+		}
+		attempt.Upn = authClaims.Subject
+
+		// Retries of an enrollment which succeeded (eg. because the response was lost) are returned the original response
+		if previous, err := srv.DB.GetEnrollmentResponse(r.Context(), db.GetEnrollmentResponseParams{
+			Udid:      cmd.GetAdditionalContextItem("DeviceID"),
+			MessageID: cmd.Header.MessageID,
+			Upn:       authClaims.Subject,
+		}); err != nil && err != sql.ErrNoRows {
+			log.Error().Str("traceid", attempt.TraceID).Err(err).Msg("error retrieving previous enrollment response")
+			attempt.Fault(w, r, "s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", err)
+			return
+		} else if err == nil {
+			attempt.Success(r.Context(), previous.DeviceID)
+			soap.Respond(soap.NewEnrollmentResponse(cmd.Header.MessageID, previous.ProvisioningProfile), w)
 			return
 		}
 
 		settings := srv.Settings.Get()
 		if settings.DisableEnrollment {
-			var res = soap.NewFault("s:Receiver", "s:Authorization", "NotSupported", "Mattrax device enrollments have been disabled", "")
-			soap.Respond(res, w)
+			attempt.Fault(w, r, "s:Receiver", "s:Authorization", "NotSupported", "Mattrax device enrollments have been disabled", nil)
 			return
 		}
 
@@ -128,227 +163,316 @@ func Enrollment(srv *mattrax.Server) http.HandlerFunc {
 				Fullname:   authClaims.Name,
 				AzureadOid: null.String{authClaims.MicrosoftSpecificAuthClaims.ObjectID, true},
 			})
+			if err == sql.ErrNoRows {
+				// A local user has the same upn so they are enrolled as the local user
+				user, err = srv.DB.GetUser(r.Context(), authClaims.Subject)
+			} else if err == nil {
+				user = db.GetUserRow(aadUser)
+			}
 			if err != nil {
-				log.Error().Str("upn", authClaims.Subject).Str("oid", authClaims.MicrosoftSpecificAuthClaims.ObjectID).Err(err).Msg("error importing AzureAD user")
-				var res = soap.NewFault("s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", "")
-				soap.Respond(res, w)
+				log.Error().Str("traceid", attempt.TraceID).Str("upn", authClaims.Subject).Str("oid", authClaims.MicrosoftSpecificAuthClaims.ObjectID).Err(err).Msg("error importing AzureAD user")
+				attempt.Fault(w, r, "s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", err)
 				return
 			}
-			user = db.GetUserRow(aadUser) // TODO: Error handling??
 		} else if user, err = srv.DB.GetUser(r.Context(), authClaims.Subject); err != nil {
-			log.Error().Str("upn", authClaims.Subject).Err(err).Msg("error retrieving user")
-			var res = soap.NewFault("s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", "")
-			soap.Respond(res, w)
+			log.Error().Str("traceid", attempt.TraceID).Str("upn", authClaims.Subject).Err(err).Msg("error retrieving user")
+			attempt.Fault(w, r, "s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", err)
 			return
 		}
 
-		existingDevice, err := srv.DB.GetDeviceByUDID(r.Context(), cmd.GetAdditionalContextItem("DeviceID"))
-		if err != nil && err != sql.ErrNoRows {
-			log.Error().Err(err).Msg("error checking if managed device already exists")
-			var res = soap.NewFault("s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", "")
-			soap.Respond(res, w)
-			return
-		} else if err == nil && existingDevice.State != db.DeviceStateUserUnenrolled && existingDevice.State != db.DeviceStateDeploying {
-			log.Debug().Int32("id", existingDevice.ID).Msg("Device already enrolled in Mattrax.")
-			var res = soap.NewFault("s:Receiver", "s:Authorization", "DeviceCapReached", "This device is already enrolled into Mattrax. Please remove before enrolling again", "")
-			soap.Respond(res, w)
-			return
+		// AzureAD users are shown the terms of service before enrolling so they must have accepted the latest version
+		if authClaims.MicrosoftSpecificAuthClaims.TenantID != "" {
+			if terms, err := srv.DB.GetLatestTermsOfService(r.Context()); err != nil && err != sql.ErrNoRows {
+				log.Error().Str("traceid", attempt.TraceID).Err(err).Msg("error retrieving terms of service")
+				attempt.Fault(w, r, "s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", err)
+				return
+			} else if err == nil {
+				accepted, err := srv.DB.HasAcceptedTermsOfService(r.Context(), db.HasAcceptedTermsOfServiceParams{
+					Upn:     user.Upn,
+					Version: terms.Version,
+				})
+				if err != nil {
+					log.Error().Str("traceid", attempt.TraceID).Err(err).Msg("error checking terms of service acceptance")
+					attempt.Fault(w, r, "s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", err)
+					return
+				} else if !accepted {
+					attempt.Fault(w, r, "s:Receiver", "s:Authorization", "Authorization", "The latest terms of service must be accepted before enrolling", nil)
+					return
+				}
+			}
 		}
 
 		csr, err := cmd.Body.BinarySecurityToken.ParseVerifyCSR(srv.Cert.IsIssuerIdentity)
 		if err != nil {
 			if aerr, ok := err.(pkg.AdvancedError); ok {
 				if err != nil && aerr.InternalDescription != "" {
-					log.Error().Err(err).Msg(aerr.InternalDescription)
+					log.Error().Str("traceid", attempt.TraceID).Err(err).Msg(aerr.InternalDescription)
 				}
 
-				var res = soap.NewFault(aerr.FaultCauser, aerr.FaultType, "", aerr.FaultReason, "")
-				soap.Respond(res, w)
+				attempt.Fault(w, r, aerr.FaultCauser, aerr.FaultType, "", aerr.FaultReason, err)
+			} else {
+				log.Error().Str("traceid", attempt.TraceID).Err(err).Msg("error parsing certificate signing request")
+				attempt.Fault(w, r, "s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", err)
 			}
 			return
 		}
 
-		device := db.NewDeviceParams{
-			Udid:            cmd.GetAdditionalContextItem("DeviceID"),
-			State:           db.DeviceStateDeploying,
-			Name:            cmd.GetAdditionalContextItem("DeviceName"),
-			HwDevID:         cmd.GetAdditionalContextItem("HWDevID"),
-			OperatingSystem: cmd.GetAdditionalContextItem("OSVersion"),
-			EnrolledBy:      null.String{user.Upn, true},
-			AzureDid:        null.String{authClaims.MicrosoftSpecificAuthClaims.DeviceID, authClaims.MicrosoftSpecificAuthClaims.DeviceID != ""},
-		}
+		// The device, its inventory and certificate are created atomically so a failure never leaves a half enrolled device
+		var rawProvisioningProfile []byte
+		var deviceID int32
+		if err := srv.Tx(r.Context(), func(ctx context.Context, q *db.Queries) error {
+			// The existing device is locked so concurrent enrollments of the same device can't both replace it
+			existingDevice, err := q.GetDeviceByUDIDForUpdate(ctx, cmd.GetAdditionalContextItem("DeviceID"))
+			if err != nil && err != sql.ErrNoRows {
+				return errors.Wrap(err, "error checking if managed device already exists")
+			} else if err == nil && existingDevice.State != db.DeviceStateUserUnenrolled && existingDevice.State != db.DeviceStateDeploying {
+				log.Debug().Str("traceid", attempt.TraceID).Int32("id", existingDevice.ID).Msg("Device already enrolled in Mattrax.")
+				return errDeviceAlreadyEnrolled
+			}
 
-		var certStore = "User"
-		var clientCertSubject = pkix.Name{
-			OrganizationalUnit: []string{"WinMDM"},
-		}
-		if cmd.GetAdditionalContextItem("EnrollmentType") == "Device" {
-			certStore = "System"
-			device.EnrollmentType = db.EnrollmentTypeDevice
-			clientCertSubject.CommonName = cmd.GetAdditionalContextItem("DeviceID")
-		} else {
-			device.EnrollmentType = db.EnrollmentTypeUser
-			clientCertSubject.CommonName = user.Upn
-		}
+			// The serial number is only known after the first management session so devices using it are renamed then
+			var reportedDeviceName = cmd.GetAdditionalContextItem("DeviceName")
+			var deviceName = reportedDeviceName
+			var awaitingSerialNumber = strings.Contains(settings.DeviceNameTemplate, "{serial}")
+			if settings.DeviceNameTemplate != "" && !awaitingSerialNumber {
+				deviceName = DeviceName(settings.DeviceNameTemplate, settings, reportedDeviceName, "", user.Upn)
+			}
 
-		var deviceID int32
-		if existingDevice.ID == 0 {
-			if deviceID, err = srv.DB.NewDevice(r.Context(), device); err != nil {
-				log.Error().Err(err).Msg("error creating new device")
-				var res = soap.NewFault("s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", "")
-				soap.Respond(res, w)
-				return
+			if deviceName, err = UniqueDeviceName(ctx, q, deviceName, cmd.GetAdditionalContextItem("DeviceID")); err != nil {
+				return errors.Wrap(err, "error determining unique device name")
 			}
-		} else {
-			deviceID = existingDevice.ID
-			if err := srv.DB.NewDeviceReplacingExisting(r.Context(), db.NewDeviceReplacingExistingParams(device)); err != nil {
-				log.Error().Err(err).Msg("error updating existing device as new device")
-				var res = soap.NewFault("s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", "")
-				soap.Respond(res, w)
-				return
+
+			device := db.NewDeviceParams{
+				Udid:            cmd.GetAdditionalContextItem("DeviceID"),
+				State:           db.DeviceStateDeploying,
+				Name:            deviceName,
+				HwDevID:         cmd.GetAdditionalContextItem("HWDevID"),
+				OperatingSystem: cmd.GetAdditionalContextItem("OSVersion"),
+				EnrolledBy:      null.String{user.Upn, true},
+				AzureDid:        null.String{authClaims.MicrosoftSpecificAuthClaims.DeviceID, authClaims.MicrosoftSpecificAuthClaims.DeviceID != ""},
 			}
-			if err := srv.DB.NewDeviceReplacingExistingResetCache(r.Context(), existingDevice.ID); err != nil {
-				log.Error().Err(err).Msg("error resetting cache for device reenrollment")
-				var res = soap.NewFault("s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", "")
-				soap.Respond(res, w)
-				return
+
+			var certStore = "User"
+			var clientCertSubject = pkix.Name{
+				OrganizationalUnit: []string{"WinMDM"},
 			}
-			if err := srv.DB.NewDeviceReplacingExistingResetInventory(r.Context(), existingDevice.ID); err != nil {
-				log.Error().Err(err).Msg("error resetting inventory for device reenrollment")
-				var res = soap.NewFault("s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", "")
-				soap.Respond(res, w)
-				return
+			if cmd.GetAdditionalContextItem("EnrollmentType") == "Device" {
+				certStore = "System"
+				device.EnrollmentType = db.EnrollmentTypeDevice
+				clientCertSubject.CommonName = cmd.GetAdditionalContextItem("DeviceID")
+			} else {
+				device.EnrollmentType = db.EnrollmentTypeUser
+				clientCertSubject.CommonName = user.Upn
 			}
-		}
 
-		identityCertificate, signedClientCertificate, rawSignedClientCertificate, err := srv.Cert.IdentitySignCSR(csr, clientCertSubject)
-		if err != nil {
-			log.Error().Err(err).Msg("error creating client certificate")
-			var res = soap.NewFault("s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", "")
-			soap.Respond(res, w)
-			return
-		}
+			if existingDevice.ID == 0 {
+				if deviceID, err = q.NewDevice(ctx, device); err == sql.ErrNoRows {
+					return errDeviceAlreadyEnrolled
+				} else if err != nil {
+					return errors.Wrap(err, "error creating new device")
+				}
+			} else {
+				deviceID = existingDevice.ID
+				if err := q.NewDeviceReplacingExisting(ctx, db.NewDeviceReplacingExistingParams(device)); err != nil {
+					return errors.Wrap(err, "error updating existing device as new device")
+				}
+				if err := q.NewDeviceReplacingExistingResetCache(ctx, existingDevice.ID); err != nil {
+					return errors.Wrap(err, "error resetting cache for device reenrollment")
+				}
+				if err := q.NewDeviceReplacingExistingResetInventory(ctx, existingDevice.ID); err != nil {
+					return errors.Wrap(err, "error resetting inventory for device reenrollment")
+				}
+				if err := q.NewDeviceReplacingExistingResetCommands(ctx, existingDevice.ID); err != nil {
+					return errors.Wrap(err, "error resetting commands for device reenrollment")
+				}
+			}
 
-		var DMCLientProviderParameters = []wap.Parameter{
-			{
-				Name:     "EntDeviceName",
-				Value:    cmd.GetAdditionalContextItem("DeviceName"),
-				DataType: "string",
-			},
-			{
-				Name:     "EntDMID",
-				Value:    fmt.Sprintf("%d", deviceID),
-				DataType: "string",
-			},
-			{
-				Name:     "UPN",
-				Value:    user.Upn,
-				DataType: "string",
-			},
-		}
+			var deviceCommand db.NewDeviceCommandParams
+			if awaitingSerialNumber {
+				deviceCommand = db.NewDeviceCommandParams{
+					DeviceID: deviceID,
+					Command:  "Get",
+					Uri:      SerialNumberURI,
+				}
+			} else if deviceName != reportedDeviceName {
+				deviceCommand = db.NewDeviceCommandParams{
+					DeviceID: deviceID,
+					Command:  "Add",
+					Uri:      ComputerNameURI,
+					Format:   "chr",
+					Value:    deviceName,
+				}
+			}
+			if deviceCommand.Command != "" {
+				if err := q.NewDeviceCommand(ctx, deviceCommand); err != nil {
+					return errors.Wrap(err, "error queuing device naming command")
+				}
+			}
 
-		if authClaims.MicrosoftSpecificAuthClaims.DeviceID != "" {
-			DMCLientProviderParameters = append(DMCLientProviderParameters, wap.Parameter{
-				Name:     "AADResourceID",
-				Value:    authClaims.MicrosoftSpecificAuthClaims.DeviceID,
-				DataType: "string",
-			})
-		}
+			identityCertificate, signedClientCertificate, rawSignedClientCertificate, err := srv.Cert.IdentitySignCSR(csr, clientCertSubject)
+			if err != nil {
+				return errors.Wrap(err, "error creating client certificate")
+			}
 
-		if settings.TenantEmail != "" {
-			DMCLientProviderParameters = append(DMCLientProviderParameters, wap.Parameter{
-				Name:     "HelpEmailAddress",
-				Value:    settings.TenantEmail,
-				DataType: "string",
-			})
+			var DMCLientProviderParameters = []wap.Parameter{
+				{
+					Name:     "EntDeviceName",
+					Value:    deviceName,
+					DataType: "string",
+				},
+				{
+					Name:     "EntDMID",
+					Value:    fmt.Sprintf("%d", deviceID),
+					DataType: "string",
+				},
+				{
+					Name:     "UPN",
+					Value:    user.Upn,
+					DataType: "string",
+				},
+			}
 
-			var node = "./Vendor/MSFT/DMClient/Provider/" + ProviderID + "/HelpEmailAddress"
-			if err := srv.DB.UpdateDeviceInventoryNode(r.Context(), db.UpdateDeviceInventoryNodeParams{
-				DeviceID: deviceID,
-				Uri:      node,
-				Format:   "chr",
-				Value:    settings.TenantEmail,
-			}); err != nil {
-				log.Error().Err(err).Str("node", node).Msg("Error updating device inventory node")
+			if authClaims.MicrosoftSpecificAuthClaims.DeviceID != "" {
+				DMCLientProviderParameters = append(DMCLientProviderParameters, wap.Parameter{
+					Name:     "AADResourceID",
+					Value:    authClaims.MicrosoftSpecificAuthClaims.DeviceID,
+					DataType: "string",
+				})
 			}
-		}
 
-		if settings.TenantWebsite != "" {
-			DMCLientProviderParameters = append(DMCLientProviderParameters, wap.Parameter{
-				Name:     "HelpWebsite",
-				Value:    settings.TenantWebsite,
-				DataType: "string",
-			})
+			if settings.TenantEmail != "" {
+				DMCLientProviderParameters = append(DMCLientProviderParameters, wap.Parameter{
+					Name:     "HelpEmailAddress",
+					Value:    settings.TenantEmail,
+					DataType: "string",
+				})
+
+				var node = "./Vendor/MSFT/DMClient/Provider/" + ProviderID + "/HelpEmailAddress"
+				if err := q.UpdateDeviceInventoryNode(ctx, db.UpdateDeviceInventoryNodeParams{
+					DeviceID: deviceID,
+					Uri:      node,
+					Format:   "chr",
+					Value:    settings.TenantEmail,
+				}); err != nil {
+					return errors.Wrap(err, "error updating device inventory node "+node)
+				}
+			}
 
-			var node = "./Vendor/MSFT/DMClient/Provider/" + ProviderID + "/HelpWebsite"
-			if err := srv.DB.UpdateDeviceInventoryNode(r.Context(), db.UpdateDeviceInventoryNodeParams{
-				DeviceID: deviceID,
-				Uri:      node,
-				Format:   "chr",
-				Value:    settings.TenantWebsite,
-			}); err != nil {
-				log.Error().Err(err).Str("node", node).Msg("Error updating device inventory node")
+			if settings.TenantWebsite != "" {
+				DMCLientProviderParameters = append(DMCLientProviderParameters, wap.Parameter{
+					Name:     "HelpWebsite",
+					Value:    settings.TenantWebsite,
+					DataType: "string",
+				})
+
+				var node = "./Vendor/MSFT/DMClient/Provider/" + ProviderID + "/HelpWebsite"
+				if err := q.UpdateDeviceInventoryNode(ctx, db.UpdateDeviceInventoryNodeParams{
+					DeviceID: deviceID,
+					Uri:      node,
+					Format:   "chr",
+					Value:    settings.TenantWebsite,
+				}); err != nil {
+					return errors.Wrap(err, "error updating device inventory node "+node)
+				}
 			}
-		}
 
-		if settings.TenantPhone != "" {
-			DMCLientProviderParameters = append(DMCLientProviderParameters, wap.Parameter{
-				Name:     "HelpPhoneNumber",
-				Value:    settings.TenantPhone,
-				DataType: "string",
-			})
+			if settings.TenantPhone != "" {
+				DMCLientProviderParameters = append(DMCLientProviderParameters, wap.Parameter{
+					Name:     "HelpPhoneNumber",
+					Value:    settings.TenantPhone,
+					DataType: "string",
+				})
+
+				var node = "./Vendor/MSFT/DMClient/Provider/" + ProviderID + "/HelpPhoneNumber"
+				if err := q.UpdateDeviceInventoryNode(ctx, db.UpdateDeviceInventoryNodeParams{
+					DeviceID: deviceID,
+					Uri:      node,
+					Format:   "chr",
+					Value:    settings.TenantPhone,
+				}); err != nil {
+					return errors.Wrap(err, "error updating device inventory node "+node)
+				}
+			}
 
-			var node = "./Vendor/MSFT/DMClient/Provider/" + ProviderID + "/HelpPhoneNumber"
-			if err := srv.DB.UpdateDeviceInventoryNode(r.Context(), db.UpdateDeviceInventoryNodeParams{
+			var node = "./Vendor/MSFT/DMClient/Provider/" + ProviderID + "/ManagementServiceAddress"
+			if err := q.UpdateDeviceInventoryNode(ctx, db.UpdateDeviceInventoryNodeParams{
 				DeviceID: deviceID,
 				Uri:      node,
 				Format:   "chr",
-				Value:    settings.TenantPhone,
+				Value:    managementServiceURL,
 			}); err != nil {
-				log.Error().Err(err).Str("node", node).Msg("Error updating device inventory node")
+				return errors.Wrap(err, "error updating device inventory node "+node)
 			}
-		}
 
-		var node = "./Vendor/MSFT/DMClient/Provider/" + ProviderID + "/ManagementServiceAddress"
-		if err := srv.DB.UpdateDeviceInventoryNode(r.Context(), db.UpdateDeviceInventoryNodeParams{
-			DeviceID: deviceID,
-			Uri:      node,
-			Format:   "chr",
-			Value:    managementServiceURL,
-		}); err != nil {
-			log.Error().Err(err).Str("node", node).Msg("Error updating device inventory node")
-		}
+			branding, err := EnrollmentBranding(ctx, q, settings, cmd.GetAdditionalContextItem("Locale"))
+			if err != nil {
+				return errors.Wrap(err, "error retrieving enrollment branding")
+			}
 
-		var wapProvisioningDoc = wap.NewProvisioningDoc()
-		wapProvisioningDoc.NewCertStore(identityCertificate, certStore, rawSignedClientCertificate)
-		wapProvisioningDoc.NewW7Application(ProviderID, settings.TenantName, managementServiceURL, certStore, signedClientCertificate.Subject.String())
-		wapProvisioningDoc.NewDMClient(ProviderID, DMCLientProviderParameters, []wap.Characteristic{
-			wap.DefaultPollCharacteristic,
-			{
-				Type: "CustomEnrollmentCompletePage",
-				Params: []wap.Parameter{
-					{
-						Name:     "Title",
-						Value:    "Mattrax Enrollment Complete",
-						DataType: "string",
-					},
-					{
-						Name:     "BodyText",
-						Value:    "Welcome " + user.Fullname + ", Your device is now being managed by '" + settings.TenantName + "'. Please contact your IT administrators for support if you have any problems.",
-						DataType: "string",
-					},
+			var enrollmentCompletePageParameters = []wap.Parameter{
+				{
+					Name:     "Title",
+					Value:    branding.Title,
+					DataType: "string",
 				},
-			},
-		})
+				{
+					Name:     "BodyText",
+					Value:    EnrollmentBodyText(branding, user, deviceName, settings.TenantName),
+					DataType: "string",
+				},
+			}
 
-		rawProvisioningProfile, err := xml.Marshal(wapProvisioningDoc)
-		if err != nil {
-			log.Error().Err(err).Msg("error marshalling wap provisioning profile")
-			var res = soap.NewFault("s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", "")
-			soap.Respond(res, w)
+			if branding.SupportUrl != "" {
+				enrollmentCompletePageParameters = append(enrollmentCompletePageParameters, wap.Parameter{
+					Name:     "HyperlinkHref",
+					Value:    branding.SupportUrl,
+					DataType: "string",
+				}, wap.Parameter{
+					Name:     "HyperlinkText",
+					Value:    branding.SupportText,
+					DataType: "string",
+				})
+			}
+
+			var wapProvisioningDoc = wap.NewProvisioningDoc()
+			wapProvisioningDoc.NewCertStore(identityCertificate, certStore, rawSignedClientCertificate)
+			wapProvisioningDoc.NewW7Application(ProviderID, branding.ApplicationName, managementServiceURL, certStore, signedClientCertificate.Subject.String())
+			wapProvisioningDoc.NewDMClient(ProviderID, DMCLientProviderParameters, []wap.Characteristic{
+				wap.DefaultPollCharacteristic,
+				{
+					Type:   "CustomEnrollmentCompletePage",
+					Params: enrollmentCompletePageParameters,
+				},
+			})
+
+			rawProvisioningProfile, err = xml.Marshal(wapProvisioningDoc)
+			if err != nil {
+				return errors.Wrap(err, "error marshalling wap provisioning profile")
+			}
+
+			if err := q.SetEnrollmentResponse(ctx, db.SetEnrollmentResponseParams{
+				DeviceID:            deviceID,
+				MessageID:           cmd.Header.MessageID,
+				Upn:                 authClaims.Subject,
+				ProvisioningProfile: rawProvisioningProfile,
+			}); err != nil {
+				return errors.Wrap(err, "error saving enrollment response")
+			}
+			return nil
+		}); err == errDeviceAlreadyEnrolled {
+			attempt.Fault(w, r, "s:Receiver", "s:Authorization", "DeviceCapReached", "This device is already enrolled into Mattrax. Please remove before enrolling again", nil)
+			return
+		} else if err != nil {
+			log.Error().Str("traceid", attempt.TraceID).Err(err).Msg("error enrolling device")
+			attempt.Fault(w, r, "s:Receiver", "s:InternalServiceFault", "", "Mattrax encountered an error. Please check the server logs for more info", err)
 			return
 		}
 
+		if err := dynamicgroups.EvaluateDevice(r.Context(), srv.DB, deviceID); err != nil {
+			log.Error().Str("traceid", attempt.TraceID).Int32("id", deviceID).Err(err).Msg("error evaluating dynamic groups for enrolled device")
+		}
+		attempt.Success(r.Context(), deviceID)
+
 		fmt.Println(string(rawProvisioningProfile))
 
 		soap.Respond(soap.NewEnrollmentResponse(cmd.Header.MessageID, rawProvisioningProfile), w)
@@ -0,0 +1,65 @@
+package windows
+
+import (
+	"context"
+	"strconv"
+
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/localadmin"
+	"github.com/mattrax/Mattrax/pkg/syncml"
+	"github.com/patrickmn/go-cache"
+	"github.com/rs/zerolog/log"
+)
+
+// localAdminCommand is a command sent to set a local administrator password which is awaiting the device's Status for it
+type localAdminCommand struct {
+	Command   string
+	ProfileID int32
+}
+
+// deployLocalAdmins sends the commands which set the passwords of the device's managed local administrator accounts which are due for rotation or awaiting confirmation
+func deployLocalAdmins(ctx context.Context, srv *mattrax.Server, cmd syncml.Message, res *syncml.Response, deviceID int32) error {
+	rotations, err := localadmin.Provision(ctx, srv.DB, srv.Secrets, deviceID)
+	if err != nil {
+		return err
+	}
+
+	for _, rotation := range rotations {
+		for _, command := range localadmin.Commands(rotation) {
+			var cmdID = res.Set(command.Command, command.URI, "", command.Format, command.Value)
+			if command.Tracked {
+				srv.Cache.Set(deployedCommandCacheKey(cmd, res.MsgID(), cmdID), localAdminCommand{
+					Command:   command.Command,
+					ProfileID: rotation.Profile.ID,
+				}, cache.DefaultExpiration)
+			}
+		}
+		log.Debug().Int32("id", deviceID).Int32("profile", rotation.Profile.ID).Msg("Sent local administrator password to device")
+	}
+	return nil
+}
+
+// handleLocalAdminStatus confirms the device set the pending password if either command which sets it succeeded. Add fails on existing accounts so only a failed Replace is recorded.
+func handleLocalAdminStatus(ctx context.Context, srv *mattrax.Server, deviceID int32, command localAdminCommand, code int) {
+	if code >= 200 && code < 300 {
+		if err := srv.DB.ConfirmLocalAdminPassword(ctx, db.ConfirmLocalAdminPasswordParams{
+			DeviceID:  deviceID,
+			ProfileID: command.ProfileID,
+		}); err != nil {
+			log.Error().Int32("id", deviceID).Int32("profile", command.ProfileID).Err(err).Msg("Error confirming local administrator password")
+		}
+		return
+	} else if command.Command != "Replace" {
+		return
+	}
+
+	log.Debug().Int32("id", deviceID).Int32("profile", command.ProfileID).Int("status", code).Msg("Device failed to set local administrator password")
+	if err := srv.DB.LocalAdminPasswordFailed(ctx, db.LocalAdminPasswordFailedParams{
+		DeviceID:  deviceID,
+		ProfileID: command.ProfileID,
+		LastError: strconv.Itoa(code),
+	}); err != nil {
+		log.Error().Int32("id", deviceID).Int32("profile", command.ProfileID).Err(err).Msg("Error recording local administrator password failure")
+	}
+}
@@ -0,0 +1,58 @@
+package windows
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/rs/zerolog/log"
+)
+
+var loginTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Branding.ApplicationName}}</title></head>
+<body>
+{{if .Branding.LogoUrl}}<img src="{{.Branding.LogoUrl}}" alt="{{.Branding.ApplicationName}}" style="max-height: 64px" />{{end}}
+<h3>{{.Branding.ApplicationName}} Federated Login</h3>
+<form method="post" action="{{.AppRU}}"><p><input type="hidden" name="wresult" value="VIRTUAL_DEVICE_AUTH_TOKEN" /></p><input type="submit" value="Login" /></form>
+{{if .Branding.SupportUrl}}<p><a href="{{.Branding.SupportUrl}}">{{.Branding.SupportText}}</a></p>{{end}}
+</body>
+</html>`))
+
+// validAppRU returns whether the appru the device passed to the login page is a URL the form can be posted to.
+// Windows passes an ms-app:// URL identifying the enrollment app so only it and https URLs are allowed.
+func validAppRU(appru string) bool {
+	u, err := url.Parse(appru)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "ms-app" || u.Scheme == "https"
+}
+
+// Login is the federated login page shown by the device during enrollment
+// TODO: Replace with UI based Login Route
+func Login(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var appru = r.URL.Query().Get("appru")
+		if !validAppRU(appru) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		branding, err := EnrollmentBranding(r.Context(), srv.DB, srv.Settings.Get(), AcceptLanguages(r)...)
+		if err != nil {
+			log.Error().Err(err).Msg("error retrieving enrollment branding")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		if err := loginTemplate.Execute(w, map[string]interface{}{
+			"Branding": branding,
+			"AppRU":    template.URL(appru),
+		}); err != nil {
+			log.Error().Err(err).Msg("error rendering login page")
+		}
+	}
+}
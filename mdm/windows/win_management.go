@@ -6,10 +6,16 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/apps"
 	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/dynamicgroups"
+	"github.com/mattrax/Mattrax/internal/policies"
+	"github.com/mattrax/Mattrax/pkg/null"
 	"github.com/mattrax/Mattrax/pkg/syncml"
+	"github.com/patrickmn/go-cache"
 	"github.com/rs/zerolog/log"
 )
 
@@ -47,7 +53,7 @@ func Manage(srv *mattrax.Server) http.HandlerFunc {
 			return
 		}
 
-		ManagementHandler(r.Context(), srv, cmd, res, device)
+		ManagementHandler(r.Context(), srv, cmd, &res, device)
 
 		if err := srv.DB.DeviceCheckinStatus(r.Context(), db.DeviceCheckinStatusParams{
 			ID:             device.ID,
@@ -64,7 +70,7 @@ func Manage(srv *mattrax.Server) http.HandlerFunc {
 }
 
 // ManagementHandler handles deploying configuration and handling its response from the device
-func ManagementHandler(ctx context.Context, srv *mattrax.Server, cmd syncml.Message, res syncml.Response, device db.Device) {
+func ManagementHandler(ctx context.Context, srv *mattrax.Server, cmd syncml.Message, res *syncml.Response, device db.Device) {
 	if device.State == db.DeviceStateDeploying {
 		if err := srv.DB.SetDeviceState(ctx, db.SetDeviceStateParams{
 			ID:    device.ID,
@@ -80,6 +86,9 @@ func ManagementHandler(ctx context.Context, srv *mattrax.Server, cmd syncml.Mess
 		return
 	}
 
+	var inventoryUpdated bool
+	var sessionUser = cachedSessionUser(srv, cmd)
+
 	// TODO: Make this look nicer
 	for _, command := range cmd.Body.Commands {
 		var final bool
@@ -88,12 +97,22 @@ func ManagementHandler(ctx context.Context, srv *mattrax.Server, cmd syncml.Mess
 			if command.Data == "1201" {
 				continue
 			} else if command.Data == "1224" {
-				// TODO: ADD login status
-				if command.Source != nil && command.Source.URI != "" && command.Meta != nil && command.Meta.Type == "come.microsoft.mdm.win32csp_install" {
-					fmt.Println("MSI Install Status", command.Meta.Format, command.Meta.Mark, command.Data)
-					// TODO: Handle This
+				for _, item := range command.Body {
+					if item.Source != nil && item.Meta != nil && item.Meta.Type == MSIInstallAlertType {
+						if err := apps.RecordInstallStatus(ctx, srv.DB, device.ID, item.Source.URI, item.Data); err != nil {
+							log.Error().Int32("id", device.ID).Str("uri", item.Source.URI).Err(err).Msg("Error recording MSI install status")
+						}
+						continue
+					}
+
+					upn, found, err := SessionUser(ctx, srv, device, item)
+					if err != nil {
+						log.Error().Int32("id", device.ID).Err(err).Msg("Error resolving the user logged in to the device")
+					} else if found {
+						sessionUser = upn
+						cacheSessionUser(srv, cmd, upn)
+					}
 				}
-				// TODO: MBES Apps
 				continue
 			} else if command.Data == "1226" {
 				// TODO: Check for body element
@@ -124,7 +143,25 @@ func ManagementHandler(ctx context.Context, srv *mattrax.Server, cmd syncml.Mess
 					res.SetStatus(syncml.StatusCommandFailed)
 					return
 				}
+				inventoryUpdated = true
+
+				if err := srv.Tx(ctx, func(ctx context.Context, q *db.Queries) error {
+					_, err := apps.RecordInventory(ctx, q, device.ID, sessionUser, command.Source.URI, command.Data)
+					return err
+				}); err != nil {
+					log.Error().Int32("id", device.ID).Str("uri", command.Source.URI).Err(err).Msg("Unable to update device app inventory")
+				}
+
+				if command.Source.URI == SerialNumberURI {
+					if err := srv.Tx(ctx, func(ctx context.Context, q *db.Queries) error {
+						return RenameDevice(ctx, q, srv.Settings.Get(), device, command.Data)
+					}); err != nil {
+						log.Error().Int32("id", device.ID).Err(err).Msg("Unable to rename device using naming template")
+					}
+				}
 			}
+		case "Status":
+			handleStatus(ctx, srv, cmd, device, command)
 		case "Final":
 			final = true
 			break
@@ -137,6 +174,27 @@ func ManagementHandler(ctx context.Context, srv *mattrax.Server, cmd syncml.Mess
 		}
 	}
 
+	// Dynamic group membership is updated before deploying so changes apply in this session
+	if inventoryUpdated {
+		if err := dynamicgroups.EvaluateDevice(ctx, srv.DB, device.ID); err != nil {
+			log.Error().Int32("id", device.ID).Err(err).Msg("Error evaluating dynamic groups")
+		}
+	}
+
+	pendingCommands, err := srv.DB.GetDevicesPendingCommands(ctx, device.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Error retrieving devices pending commands")
+		return
+	}
+
+	// Commands are only marked as sent once the device returns their Status so they are resent if the response is lost
+	for _, command := range pendingCommands {
+		var cmdID = res.Set(command.Command, command.Uri, command.Type, command.Format, command.Value)
+		srv.Cache.Set(deployedCommandCacheKey(cmd, res.MsgID(), cmdID), pendingCommand{
+			ID: command.ID,
+		}, cache.DefaultExpiration)
+	}
+
 	// TODO: NodeCache global version check like CSP defines server should do
 
 	// TODO: Work out data that the inventory needs about the device then ask for it and NodeCache!
@@ -144,54 +202,138 @@ func ManagementHandler(ctx context.Context, srv *mattrax.Server, cmd syncml.Mess
 
 	// TODO: Parse Request Data and Store in Inventory + Detect and handle User Unenroll + Add/Replace switch
 
-	payloadsAwaitingDeploy, err := srv.DB.GetDevicesPayloadsAwaitingDeployment(ctx, device.ID) // TODO: Replace SQL type because its a required arg
+	effectivePayloads, err := policies.DevicePayloads(ctx, srv.DB, device.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Error resolving devices effective payloads")
+		return
+	}
+
+	schedules, err := policies.DeviceSchedules(ctx, srv.DB, device.ID)
 	if err != nil {
-		log.Error().Err(err).Msg("Error retrieving devices policies that are awaiting deploy")
+		log.Error().Err(err).Msg("Error retrieving devices policy schedules")
+		return
+	}
+
+	if err := deployPayloads(ctx, srv, cmd, res, device.ID, null.String{}, effectivePayloads, schedules); err != nil {
+		log.Error().Err(err).Msg("Error deploying device payloads")
+		res.SetStatus(syncml.StatusCommandFailed)
 		return
 	}
 
+	if err := deployApps(ctx, srv, cmd, res, device.ID, sessionUser); err != nil {
+		log.Error().Err(err).Msg("Error deploying device apps")
+		res.SetStatus(syncml.StatusCommandFailed)
+		return
+	}
+
+	if err := deployCertificates(ctx, srv, res, device, sessionUser); err != nil {
+		log.Error().Err(err).Msg("Error deploying device certificates")
+		res.SetStatus(syncml.StatusCommandFailed)
+		return
+	}
+
+	if err := deployLocalAdmins(ctx, srv, cmd, res, device.ID); err != nil {
+		log.Error().Err(err).Msg("Error deploying device local administrator passwords")
+		res.SetStatus(syncml.StatusCommandFailed)
+		return
+	}
+
+	// User scoped payloads are only deployed to the session of the user they are assigned to
+	if sessionUser != "" {
+		effectiveUserPayloads, err := policies.UserPayloads(ctx, srv.DB, sessionUser)
+		if err != nil {
+			log.Error().Err(err).Msg("Error resolving users effective payloads")
+			return
+		}
+
+		if err := deployPayloads(ctx, srv, cmd, res, device.ID, null.String{String: sessionUser, Valid: true}, effectiveUserPayloads, nil); err != nil {
+			log.Error().Str("upn", sessionUser).Err(err).Msg("Error deploying user payloads")
+			res.SetStatus(syncml.StatusCommandFailed)
+			return
+		}
+	}
+}
+
+// deployPayloads sends the effective payloads which haven't been deployed to the device and deletes deployed payloads which are no longer effective.
+// The upn is only set for payloads being deployed to a user's session. Payloads outside of their schedule are held back until a later checkin.
+func deployPayloads(ctx context.Context, srv *mattrax.Server, cmd syncml.Message, res *syncml.Response, deviceID int32, upn null.String, effectivePayloads []policies.EffectivePayload, schedules policies.Schedules) error {
+	payloadsAwaitingDeploy, detachedPayloads, err := policies.Deployment(ctx, srv.DB, deviceID, upn, effectivePayloads)
+	if err != nil {
+		return err
+	}
+
+	var awaitingURIs = make(map[string]bool, len(payloadsAwaitingDeploy))
+	var awaitingIDs = make(map[int32]bool, len(payloadsAwaitingDeploy))
 	for _, payload := range payloadsAwaitingDeploy {
+		awaitingURIs[payload.Uri] = true
+		awaitingIDs[payload.ID] = true
+	}
+
+	// The payload previously deployed to a held back URI is kept until the new one is deployed
+	var now = time.Now()
+	var heldURIs = make(map[string]bool)
+	for _, payload := range effectivePayloads {
+		if awaitingIDs[payload.ID] && !schedules.Allows(payload, now) {
+			heldURIs[payload.Uri] = true
+		}
+	}
+
+	for _, payload := range detachedPayloads {
+		if heldURIs[payload.Uri] {
+			continue
+		}
+
+		// A payload replaced by another winner for the same URI is overwritten instead of deleted
+		if !awaitingURIs[payload.Uri] {
+			res.Set("Delete", payload.Uri, "", "", "")
+		}
+		// TODO: Remove NodeCache nodes
+
+		if err := srv.DB.DeleteDeviceCacheNode(ctx, db.DeleteDeviceCacheNodeParams{
+			DeviceID:  deviceID,
+			PayloadID: sql.NullInt32{Int32: payload.ID, Valid: true},
+			Upn:       upn,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, payload := range payloadsAwaitingDeploy {
+		if heldURIs[payload.Uri] {
+			continue
+		}
+
 		if payload.Exec {
+			// Only the Exec's status is tracked as the Add only ensures the node exists
 			res.Set("Add", payload.Uri, "", "", "")
-			res.Set("Exec", payload.Uri, payload.Type, payload.Format, payload.Value)
+			trackDeployedCommand(srv, cmd, res, "Exec", res.Set("Exec", payload.Uri, payload.Type, payload.Format, payload.Value), payload.ID, upn)
 		} else {
-			res.Set("Add", payload.Uri, payload.Type, payload.Format, payload.Value)
+			trackDeployedCommand(srv, cmd, res, "Add", res.Set("Add", payload.Uri, payload.Type, payload.Format, payload.Value), payload.ID, upn)
 
 			// TODO: NodeCache
 			// r.SetRaw("Add", "./Vendor/MSFT/NodeCache/" + ProviderID + "/Nodes/"+node+"/NodeURI", "", "", payload.Uri)
 			// r.SetRaw("Add", "./Vendor/MSFT/NodeCache/" + ProviderID + "/Nodes/"+node+"/ExpectedValue", "", "", payload.Value)
 		}
 
-		var nodecacheNode int32
-		if nodecacheNode, err = srv.DB.NewDeviceCacheNode(ctx, db.NewDeviceCacheNodeParams{
-			DeviceID:  device.ID,
-			PayloadID: sql.NullInt32{payload.ID, true},
-		}); err != nil {
-			log.Error().Err(err).Msg("Error updating device cache node")
-			res.SetStatus(syncml.StatusCommandFailed)
-			return
+		nodecacheNode, err := srv.DB.NewDeviceCacheNode(ctx, db.NewDeviceCacheNodeParams{
+			DeviceID:  deviceID,
+			PayloadID: sql.NullInt32{Int32: payload.ID, Valid: true},
+			Upn:       upn,
+		})
+		if err != nil {
+			return err
 		}
 
 		fmt.Println("NodeCache Destined" + strconv.Itoa(int(nodecacheNode))) // TODO: NodeCache + Use Atomics for it
 		// TODO: Method for checking if nodecache was set/if it successed?
 	}
 
-	detachedPayloads, err := srv.DB.GetDevicesDetachedPayloads(ctx, device.ID)
-	if err != nil {
-		log.Error().Err(err).Msg("Error retrieving devices detached payloads")
-		return
-	}
-	for _, payload := range detachedPayloads {
-		res.Set("Delete", payload.Uri, "", "", "")
-		// TODO: Remove NodeCache nodes
-
-		if err := srv.DB.DeleteDeviceCacheNode(ctx, db.DeleteDeviceCacheNodeParams{
-			DeviceID:  device.ID,
-			PayloadID: sql.NullInt32{payload.ID, true},
-		}); err != nil {
-			log.Error().Err(err).Msg("Error updating device cache node")
-			res.SetStatus(syncml.StatusCommandFailed)
-			return
+	// Payloads removed from their policy are deleted once no device still has them deployed
+	if len(detachedPayloads) > 0 {
+		if err := srv.DB.DeleteOrphanedPayloads(ctx); err != nil {
+			return err
 		}
 	}
+
+	return nil
 }
@@ -0,0 +1,139 @@
+package windows
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/pkg/placeholder"
+)
+
+// ComputerNameURI is the Accounts CSP node which renames the device. The new name is applied after the next reboot.
+const ComputerNameURI = "./Device/Vendor/MSFT/Accounts/Domain/ComputerName"
+
+// SerialNumberURI is the DevDetail CSP node containing the devices SMBIOS serial number.
+// The serial number isn't sent during enrollment so it must be requested on the first management session.
+const SerialNumberURI = "./DevDetail/Ext/Microsoft/SMBIOSSerialNumber"
+
+// MaxComputerNameLength is the longest (NetBIOS) computer name Windows supports
+const MaxComputerNameLength = 15
+
+var (
+	namingPlaceholderRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+	devicePrefixRegex      = regexp.MustCompile(`^[A-Za-z0-9-]*$`)
+)
+
+// ValidateNaming verifies the naming template only uses supported placeholders and the prefix can be part of a computer name. An empty template disables naming.
+func ValidateNaming(template, prefix string) error {
+	for _, match := range namingPlaceholderRegex.FindAllStringSubmatch(template, -1) {
+		switch match[1] {
+		case "prefix", "devicename", "serial", "upnprefix":
+		default:
+			return fmt.Errorf("the naming template placeholder '%s' must be one of {prefix}, {devicename}, {serial} or {upnprefix}", match[0])
+		}
+	}
+
+	if len(prefix) > MaxComputerNameLength || !devicePrefixRegex.MatchString(prefix) {
+		return fmt.Errorf("the device name prefix can only contain at most %d letters, numbers and hyphens", MaxComputerNameLength)
+	}
+	return nil
+}
+
+// truncateName shortens the name to at most length characters without a trailing hyphen.
+// Names are truncated by character as a name reported by the device can contain non-ASCII characters.
+func truncateName(name string, length int) string {
+	if runes := []rune(name); len(runes) > length {
+		name = string(runes[:length])
+	}
+	return strings.TrimRight(name, "-")
+}
+
+// DeviceName renders the naming template into a valid Windows computer name.
+// The supported placeholders are {prefix}, {devicename}, {serial} and {upnprefix}.
+func DeviceName(template string, settings db.Setting, deviceName, serial, upn string) string {
+	var name = placeholder.Expand(template, map[string]string{
+		"prefix":     settings.DeviceNamePrefix,
+		"devicename": deviceName,
+		"serial":     serial,
+		"upnprefix":  strings.SplitN(upn, "@", 2)[0],
+	})
+
+	// Computer names may only contain letters, numbers and hyphens
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, name)
+	for strings.Contains(name, "--") {
+		name = strings.Replace(name, "--", "-", -1)
+	}
+	name = strings.Trim(name, "-")
+
+	name = truncateName(name, MaxComputerNameLength)
+	if name == "" {
+		return deviceName
+	}
+	return name
+}
+
+// UniqueDeviceName resolves naming collisions by appending an increasing numeric suffix to the name.
+// The device being named (identified by its udid) is ignored so re-enrolling devices can keep their name.
+// It must be called within the transaction which stores the name as device names are locked until it ends, so concurrent enrollments can't choose the same name.
+func UniqueDeviceName(ctx context.Context, q *db.Queries, name, udid string) (string, error) {
+	if err := q.LockDeviceNames(ctx); err != nil {
+		return "", err
+	}
+
+	var candidate = name
+	for i := 2; ; i++ {
+		inUse, err := q.DeviceNameInUse(ctx, db.DeviceNameInUseParams{
+			Name: candidate,
+			Udid: udid,
+		})
+		if err != nil {
+			return "", err
+		} else if !inUse {
+			return candidate, nil
+		}
+
+		var suffix = "-" + strconv.Itoa(i)
+		candidate = truncateName(name, MaxComputerNameLength-len(suffix)) + suffix
+	}
+}
+
+// RenameDevice applies the naming template once the devices serial number is known and pushes the new name to the device.
+// It must be called within a transaction.
+func RenameDevice(ctx context.Context, q *db.Queries, settings db.Setting, device db.Device, serial string) error {
+	if !strings.Contains(settings.DeviceNameTemplate, "{serial}") {
+		return nil
+	}
+
+	name := DeviceName(settings.DeviceNameTemplate, settings, device.Name, serial, device.EnrolledBy.String)
+	if name == device.Name {
+		return nil
+	}
+
+	name, err := UniqueDeviceName(ctx, q, name, device.Udid)
+	if err != nil {
+		return err
+	}
+
+	if err := q.SetDeviceName(ctx, db.SetDeviceNameParams{
+		ID:   device.ID,
+		Name: name,
+	}); err != nil {
+		return err
+	}
+
+	return q.NewDeviceCommand(ctx, db.NewDeviceCommandParams{
+		DeviceID: device.ID,
+		Command:  "Add",
+		Uri:      ComputerNameURI,
+		Format:   "chr",
+		Value:    name,
+	})
+}
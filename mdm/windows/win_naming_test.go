@@ -0,0 +1,74 @@
+package windows
+
+import (
+	"testing"
+
+	"github.com/mattrax/Mattrax/internal/db"
+)
+
+func TestDeviceName(t *testing.T) {
+	var settings = db.Setting{DeviceNamePrefix: "ACME"}
+	var tests = []struct {
+		template string
+		expected string
+	}{
+		{"{prefix}-{serial}", "ACME-PF1ABCDE"},
+		{"{prefix}-{upnprefix}", "ACME-oscar-b"},
+		{"{prefix}-{devicename}", "ACME-DESKTOP-1"},
+		{"{prefix}-{serial}-{serial}", "ACME-PF1ABCDE-P"},     // shortened to 15 characters
+		{"{prefix}1-{serial}-{devicename}", "ACME1-PF1ABCDE"}, // shortened without a trailing hyphen
+		{"{prefix} {serial}", "ACME-PF1ABCDE"},
+		{"--{prefix}__{serial}--", "ACME-PF1ABCDE"},
+		{"Ōsaka-{serial}", "saka-PF1ABCDE"},
+		{"---", "DESKTOP-1"}, // an empty name keeps the device's name
+	}
+	for _, tt := range tests {
+		if name := DeviceName(tt.template, settings, "DESKTOP-1", "PF1ABCDE", "oscar.b@example.com"); name != tt.expected {
+			t.Errorf("DeviceName(%q) returned %q, expected %q", tt.template, name, tt.expected)
+		}
+	}
+}
+
+func TestTruncateName(t *testing.T) {
+	var tests = []struct {
+		name     string
+		length   int
+		expected string
+	}{
+		{"DESKTOP-1", 15, "DESKTOP-1"},
+		{"DESKTOP-ABCDEFGHIJ", 15, "DESKTOP-ABCDEFG"},
+		{"DESKTOP-ABCDEFGHIJ", 8, "DESKTOP"},
+		{"ÖSTERREICH-LAPTOP-1", 13, "ÖSTERREICH-LA"}, // non-ASCII characters aren't split
+		{"日本語のコンピューター名です", 13, "日本語のコンピューター名で"},
+		{"", 15, ""},
+	}
+	for _, tt := range tests {
+		if name := truncateName(tt.name, tt.length); name != tt.expected {
+			t.Errorf("truncateName(%q, %d) returned %q, expected %q", tt.name, tt.length, name, tt.expected)
+		}
+	}
+}
+
+func TestValidateNaming(t *testing.T) {
+	var tests = []struct {
+		template string
+		prefix   string
+		valid    bool
+	}{
+		{"", "", true},
+		{"{prefix}-{serial}", "ACME", true},
+		{"{prefix}-{devicename}-{upnprefix}", "ACME-NSW", true},
+		{"LAPTOP-{serial}", "", true},
+		{"{prefix}-{udid}", "ACME", false},
+		{"{Serial}", "", false},
+		{"{}", "", false},
+		{"{prefix}", "ACME NSW", false},
+		{"{prefix}", "ACME_NSW", false},
+		{"{prefix}", "ABCDEFGHIJKLMNOP", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateNaming(tt.template, tt.prefix); (err == nil) != tt.valid {
+			t.Errorf("ValidateNaming(%q, %q) returned %v, expected valid to be %v", tt.template, tt.prefix, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package windows
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/scep"
+	"github.com/mattrax/Mattrax/pkg/syncml"
+	"github.com/rs/zerolog/log"
+)
+
+// maxSCEPMessageSize is the largest PKIOperation message a client can send
+const maxSCEPMessageSize = 64 << 10
+
+// deployCertificates sends the commands which make the device request the certificates from its certificate profiles which it doesn't have or are due for renewal
+func deployCertificates(ctx context.Context, srv *mattrax.Server, res *syncml.Response, device db.Device, sessionUser string) error {
+	enrollments, err := scep.Provision(ctx, srv.DB, device, sessionUser)
+	if err != nil {
+		return err
+	}
+
+	ca, _ := srv.Cert.SCEP()
+	for _, enrollment := range enrollments {
+		commands, err := scep.Commands(enrollment, ca, srv.Args.Domain)
+		if err != nil {
+			return err
+		}
+
+		for _, command := range commands {
+			res.Set(command.Command, command.URI, command.Type, command.Format, command.Value)
+		}
+		log.Debug().Int32("id", device.ID).Int32("profile", enrollment.Profile.ID).Str("upn", enrollment.UPN).Msg("Sent certificate enrollment to device")
+	}
+	return nil
+}
+
+// SCEP handles the SCEP operations which issue certificates to devices enrolled by their certificate profiles
+func SCEP(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ca, key := srv.Cert.SCEP()
+
+		switch r.URL.Query().Get("operation") {
+		case "GetCACert":
+			w.Header().Set("Content-Type", "application/x-x509-ca-cert")
+			w.Write(ca.Raw)
+		case "GetCACaps":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(strings.Join(scep.Capabilities, "\n")))
+		case "PKIOperation":
+			var message []byte
+			var err error
+			if r.Method == http.MethodPost {
+				message, err = ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxSCEPMessageSize))
+			} else {
+				message, err = base64.StdEncoding.DecodeString(r.URL.Query().Get("message"))
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			req, err := scep.ParseRequest(message)
+			if err != nil {
+				log.Debug().Err(err).Msg("Invalid SCEP message")
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			cert, failInfo := issueCertificate(r.Context(), srv, req)
+
+			var response []byte
+			if failInfo != "" {
+				response, err = scep.Failure(req, ca, key, failInfo)
+			} else {
+				response, err = scep.Success(req, ca, key, cert)
+			}
+			if err != nil {
+				log.Error().Str("transaction", req.TransactionID).Err(err).Msg("Error creating SCEP response")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/x-pki-message")
+			w.Write(response)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}
+}
+
+// issueCertificate issues the certificate requested by the PKIOperation message or returns why the request failed
+func issueCertificate(ctx context.Context, srv *mattrax.Server, req *scep.Request) (cert *x509.Certificate, failInfo scep.FailInfo) {
+	if req.MessageType != scep.MessageTypePKCSReq && req.MessageType != scep.MessageTypeRenewalReq {
+		return nil, scep.FailBadRequest
+	}
+
+	ca, key := srv.Cert.SCEP()
+	csr, challengePassword, err := req.CSR(ca, key)
+	if err != nil {
+		log.Debug().Str("transaction", req.TransactionID).Err(err).Msg("Error decrypting SCEP certificate request")
+		return nil, scep.FailBadMessageCheck
+	} else if challengePassword == "" {
+		return nil, scep.FailBadRequest
+	}
+
+	var challenge db.CertificateChallenge
+	if err := srv.Tx(ctx, func(ctx context.Context, q *db.Queries) error {
+		var err error
+		cert, challenge, err = scep.Issue(ctx, q, ca, key, csr, challengePassword, srv.Args.Domain)
+		return err
+	}); err == scep.ErrUnknownChallenge {
+		log.Debug().Str("transaction", req.TransactionID).Msg("SCEP certificate request has an unknown challenge")
+		return nil, scep.FailBadRequest
+	} else if err == scep.ErrExpiredChallenge {
+		log.Debug().Int32("id", challenge.DeviceID).Int32("profile", challenge.ProfileID).Msg("SCEP certificate request has an expired challenge")
+		return nil, scep.FailBadTime
+	} else if err == scep.ErrWeakKey {
+		return nil, scep.FailBadAlg
+	} else if err != nil {
+		log.Error().Int32("id", challenge.DeviceID).Int32("profile", challenge.ProfileID).Err(err).Msg("Error issuing certificate")
+		return nil, scep.FailBadRequest
+	}
+
+	log.Info().Int32("id", challenge.DeviceID).Int32("profile", challenge.ProfileID).Str("serial", scep.SerialString(cert.SerialNumber)).Msg("Issued certificate")
+	return cert, ""
+}
+
+// SCEPCRL returns the SCEP CA's certificate revocation list
+func SCEPCRL(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ca, key := srv.Cert.SCEP()
+		crl, err := scep.CRL(r.Context(), srv.DB, ca, key)
+		if err != nil {
+			log.Error().Err(err).Msg("Error generating the SCEP CA's CRL")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pkix-crl")
+		w.Write(crl)
+	}
+}
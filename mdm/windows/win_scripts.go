@@ -0,0 +1,166 @@
+package windows
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/scripts"
+	"github.com/rs/zerolog/log"
+)
+
+// maxScriptResultSize is the largest script result the agent can report
+const maxScriptResultSize = 4 * scripts.MaxOutputSize
+
+// The handlers below are the API of the Mattrax agent, which isn't part of this repository. It is a prerequisite for scripts which administrators deploy as a required MSI app (see the README).
+
+// AgentScript is a script the agent should run. Scripts with the user run context are run in the session of the user signed into the device.
+type AgentScript struct {
+	ID         int32            `json:"id"`
+	Name       string           `json:"name"`
+	Version    int32            `json:"version"`
+	RunContext db.ScriptContext `json:"run_context"`
+	Content    string           `json:"content"`
+}
+
+// AgentScriptResult is the result of running a script which the agent reports
+type AgentScriptResult struct {
+	Version  int32  `json:"version"`
+	ExitCode int32  `json:"exit_code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+}
+
+// agentDevice returns the device whose MDM client certificate authenticates the agent's request.
+// Only devices enrolled with the Device enrollment type are accepted as the certificates of user enrollments are in the user's certificate store.
+func agentDevice(srv *mattrax.Server, w http.ResponseWriter, r *http.Request) (int32, bool) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return 0, false
+	}
+
+	var cert = r.TLS.PeerCertificates[0]
+	if err := srv.Cert.VerifyIdentityClient(cert); err != nil || len(cert.Subject.OrganizationalUnit) == 0 || cert.Subject.OrganizationalUnit[0] != "WinMDM" {
+		log.Debug().Str("CN", cert.Subject.CommonName).Err(err).Msg("Invalid agent authentication certificate")
+		w.WriteHeader(http.StatusUnauthorized)
+		return 0, false
+	}
+
+	device, err := srv.DB.GetDeviceByUDID(r.Context(), cert.Subject.CommonName)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusUnauthorized)
+		return 0, false
+	} else if err != nil {
+		log.Error().Err(err).Msg("Error retrieving agent's device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return 0, false
+	}
+
+	if device.EnrollmentType != db.EnrollmentTypeDevice || (device.State != db.DeviceStateManaged && device.State != db.DeviceStateMissing) {
+		w.WriteHeader(http.StatusForbidden)
+		return 0, false
+	}
+	return device.ID, true
+}
+
+// AgentScripts returns the scripts which are due to run on the agent's device
+func AgentScripts(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deviceID, ok := agentDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		deviceScripts, err := srv.DB.GetDeviceScripts(r.Context(), deviceID)
+		if err != nil {
+			log.Error().Int32("id", deviceID).Err(err).Msg("Error retrieving device scripts")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var now = time.Now()
+		var due = make([]AgentScript, 0, len(deviceScripts))
+		for _, script := range deviceScripts {
+			if !scripts.Due(script, now) {
+				continue
+			}
+
+			due = append(due, AgentScript{
+				ID:         script.ID,
+				Name:       script.Name,
+				Version:    script.Version,
+				RunContext: script.RunContext,
+				Content:    script.Content,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(due); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// AgentScriptRun records the result of running a script on the agent's device
+func AgentScriptRun(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deviceID, ok := agentDevice(srv, w, r)
+		if !ok {
+			return
+		}
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		deviceScripts, err := srv.DB.GetDeviceScripts(r.Context(), deviceID)
+		if err != nil {
+			log.Error().Int32("id", deviceID).Err(err).Msg("Error retrieving device scripts")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var assigned bool
+		for _, script := range deviceScripts {
+			if script.ID == int32(id) {
+				assigned = true
+			}
+		}
+		if !assigned {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var result AgentScriptResult
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxScriptResultSize)).Decode(&result); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if err := srv.DB.RecordScriptRun(r.Context(), db.RecordScriptRunParams{
+			ScriptID: int32(id),
+			DeviceID: deviceID,
+			Version:  result.Version,
+			ExitCode: result.ExitCode,
+			Stdout:   scripts.Truncate(result.Stdout),
+			Stderr:   scripts.Truncate(result.Stderr),
+		}); err != nil {
+			log.Error().Int32("id", deviceID).Int("script", id).Err(err).Msg("Error recording script run")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if result.ExitCode != 0 {
+			log.Debug().Int32("id", deviceID).Int("script", id).Int32("exit_code", result.ExitCode).Msg("Script failed on device")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
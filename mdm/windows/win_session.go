@@ -0,0 +1,73 @@
+package windows
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/pkg/null"
+	"github.com/mattrax/Mattrax/pkg/syncml"
+	"github.com/patrickmn/go-cache"
+)
+
+// LoginStatusAlertType is the 1224 alert sent at the start of each session containing who is logged in ("user", "others" or "none")
+const LoginStatusAlertType = "com.microsoft/MDM/LoginStatus"
+
+// AADUserTokenAlertType is the 1224 alert containing the AzureAD token of the user logged in to the device
+const AADUserTokenAlertType = "com.microsoft/MDM/AADUserToken"
+
+// sessionUserCacheKey is the key which stores the logged in user for the rest of the management session
+func sessionUserCacheKey(cmd syncml.Message) string {
+	return "session-user-" + cmd.Header.SourceURI + "-" + cmd.Header.SessionID
+}
+
+// SessionUser resolves the user logged in to the device from a 1224 alert item. The found return is false if the item doesn't identify the user.
+// An empty upn means no user (which Mattrax knows about) is logged in.
+func SessionUser(ctx context.Context, srv *mattrax.Server, device db.Device, item syncml.Command) (upn string, found bool, err error) {
+	if item.Meta == nil {
+		return "", false, nil
+	}
+
+	switch item.Meta.Type {
+	case LoginStatusAlertType:
+		// Only the enrolling user is known to be logged in for user enrollments. Device enrollments send their users AzureAD token.
+		if item.Data == "user" && device.EnrollmentType == db.EnrollmentTypeUser {
+			return device.EnrolledBy.String, true, nil
+		} else if item.Data != "user" {
+			return "", true, nil
+		}
+	case AADUserTokenAlertType:
+		claims, err := srv.Auth.Token(item.Data)
+		if err != nil {
+			return "", false, err
+		} else if claims.Subject == "" {
+			return "", false, errors.New("the AzureAD user token didn't contain a user")
+		}
+
+		// AzureAD users may not have enrolled a device yet so they are imported. Local users with the same upn are left unchanged.
+		if _, err := srv.DB.NewAzureADUser(ctx, db.NewAzureADUserParams{
+			Upn:        claims.Subject,
+			Fullname:   claims.FullName,
+			AzureadOid: null.String{String: claims.MicrosoftSpecificAuthClaims.ObjectID, Valid: claims.MicrosoftSpecificAuthClaims.ObjectID != ""},
+		}); err != nil && err != sql.ErrNoRows {
+			return "", false, err
+		}
+		return claims.Subject, true, nil
+	}
+	return "", false, nil
+}
+
+// cacheSessionUser stores the logged in user so later messages in the management session can deploy to them
+func cacheSessionUser(srv *mattrax.Server, cmd syncml.Message, upn string) {
+	srv.Cache.Set(sessionUserCacheKey(cmd), upn, cache.DefaultExpiration)
+}
+
+// cachedSessionUser returns the logged in user which was resolved earlier in the management session
+func cachedSessionUser(srv *mattrax.Server, cmd syncml.Message) string {
+	if upn, found := srv.Cache.Get(sessionUserCacheKey(cmd)); found {
+		return upn.(string)
+	}
+	return ""
+}
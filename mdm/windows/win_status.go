@@ -0,0 +1,97 @@
+package windows
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/internal/rollouts"
+	"github.com/mattrax/Mattrax/pkg/null"
+	"github.com/mattrax/Mattrax/pkg/syncml"
+	"github.com/patrickmn/go-cache"
+	"github.com/rs/zerolog/log"
+)
+
+// deployedCommand is a command sent to deploy a payload which is awaiting the device's Status for it. The Upn is set for payloads deployed to a user's session.
+type deployedCommand struct {
+	Command   string
+	PayloadID int32
+	Upn       null.String
+}
+
+// pendingCommand is a command queued for the device (eg. by naming or enrollment) which is awaiting the device's Status for it
+type pendingCommand struct {
+	ID int32
+}
+
+// deployedCommandCacheKey is the key which stores the payload a command deployed until the device returns its Status in the management session
+func deployedCommandCacheKey(cmd syncml.Message, msgRef, cmdRef string) string {
+	return "deployed-command-" + cmd.Header.SourceURI + "-" + cmd.Header.SessionID + "-" + msgRef + "-" + cmdRef
+}
+
+// trackDeployedCommand stores the payload a command deployed so the device's Status for it can be attributed to the payload
+func trackDeployedCommand(srv *mattrax.Server, cmd syncml.Message, res *syncml.Response, command, cmdID string, payloadID int32, upn null.String) {
+	srv.Cache.Set(deployedCommandCacheKey(cmd, res.MsgID(), cmdID), deployedCommand{
+		Command:   command,
+		PayloadID: payloadID,
+		Upn:       upn,
+	}, cache.DefaultExpiration)
+}
+
+// handleStatus records the result of a command the server sent. The results of commands which deployed a payload are recorded against the rollouts which deployed it
+// and successful ones record the version of the payload's policy the device has.
+func handleStatus(ctx context.Context, srv *mattrax.Server, cmd syncml.Message, device db.Device, status syncml.Command) {
+	var key = deployedCommandCacheKey(cmd, status.MsgRef, status.CmdRef)
+	deployed, found := srv.Cache.Get(key)
+	if !found {
+		return
+	}
+	srv.Cache.Delete(key)
+
+	code, err := strconv.Atoi(status.Data)
+	if err != nil {
+		log.Debug().Int32("id", device.ID).Str("status", status.Data).Msg("Device returned an invalid status")
+		return
+	}
+
+	if command, ok := deployed.(pendingCommand); ok {
+		if err := srv.DB.DeviceCommandSent(ctx, command.ID); err != nil {
+			log.Error().Int32("id", device.ID).Int32("command", command.ID).Err(err).Msg("Error marking device command as sent")
+		}
+		return
+	}
+
+	if command, ok := deployed.(appCommand); ok {
+		handleAppStatus(ctx, srv, device.ID, command, code)
+		return
+	}
+
+	if command, ok := deployed.(localAdminCommand); ok {
+		handleLocalAdminStatus(ctx, srv, device.ID, command, code)
+		return
+	}
+
+	var command = deployed.(deployedCommand)
+	var succeeded = rollouts.Succeeded(command.Command, code)
+	if !succeeded {
+		log.Debug().Int32("id", device.ID).Int32("payload", command.PayloadID).Int("status", code).Msg("Device failed to apply payload")
+	} else if err := srv.DB.UpdateDeployedPolicyVersion(ctx, db.UpdateDeployedPolicyVersionParams{
+		DeviceID:  device.ID,
+		PayloadID: sql.NullInt32{Int32: command.PayloadID, Valid: true},
+		Upn:       command.Upn,
+	}); err != nil {
+		log.Error().Int32("id", device.ID).Int32("payload", command.PayloadID).Err(err).Msg("Error updating deployed policy version")
+	}
+
+	paused, err := rollouts.RecordResult(ctx, srv.DB, device.ID, command.PayloadID, code, !succeeded)
+	if err != nil {
+		log.Error().Int32("id", device.ID).Int32("payload", command.PayloadID).Err(err).Msg("Error recording rollout result")
+		return
+	}
+
+	for _, rolloutID := range paused {
+		log.Warn().Int32("rollout", rolloutID).Int32("id", device.ID).Msg("Rollout paused as its current stage passed the failure threshold")
+	}
+}
@@ -0,0 +1,183 @@
+package windows
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/db"
+	"github.com/mattrax/Mattrax/pkg/null"
+	"github.com/rs/zerolog/log"
+)
+
+var termsOfServiceTemplate = template.Must(template.New("tos").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Terms.Title}}</title></head>
+<body>
+{{if .Branding.LogoUrl}}<img src="{{.Branding.LogoUrl}}" alt="{{.Branding.ApplicationName}}" style="max-height: 64px" />{{end}}
+<h3>{{.Terms.Title}}</h3>
+<p style="white-space: pre-wrap">{{.Terms.Content}}</p>
+<form method="post">
+<input type="hidden" name="nonce" value="{{.Nonce}}" />
+<input type="hidden" name="version" value="{{.Terms.Version}}" />
+<button type="submit" name="accepted" value="false">Decline</button>
+<button type="submit" name="accepted" value="true">Accept</button>
+</form>
+{{if .Branding.SupportUrl}}<p><a href="{{.Branding.SupportUrl}}">{{.Branding.SupportText}}</a></p>{{end}}
+</body>
+</html>`))
+
+// termsOfServiceLifetime is how long the user has to accept the terms of service after they are shown
+const termsOfServiceLifetime = 30 * time.Minute
+
+// termsOfServiceSession is the AzureAD user and redirect uri of a terms of service page which was shown. It is stored server side and referenced by a nonce in the form so the access token isn't sent back to the browser.
+type termsOfServiceSession struct {
+	RedirectURI string
+	Upn         string
+	FullName    string
+	ObjectID    string
+}
+
+// termsOfServiceCacheKey is the key which stores the terms of service session of the nonce
+func termsOfServiceCacheKey(nonce string) string {
+	return "terms-of-service-" + nonce
+}
+
+// validRedirectURI returns whether the redirect_uri passed to the terms of service page is a URL the user can be redirected to.
+// Windows passes an ms-appx-web:// URL identifying the AzureAD broker so only it is allowed, otherwise the page could redirect to any site.
+func validRedirectURI(redirectURI string) bool {
+	u, err := url.Parse(redirectURI)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "ms-appx-web"
+}
+
+// TermsOfService shows the AzureAD user the current terms of service and records their acceptance.
+// Windows passes the users AzureAD access token in the Authorization header and the page must redirect to the redirect_uri once complete.
+func TermsOfService(srv *mattrax.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var session termsOfServiceSession
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			// The nonce can only be used once
+			var key = termsOfServiceCacheKey(r.PostForm.Get("nonce"))
+			cached, found := srv.Cache.Get(key)
+			if !found {
+				w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`<h3>The terms of service have expired. Please restart enrollment.</h3>`))
+				return
+			}
+			srv.Cache.Delete(key)
+			session = cached.(termsOfServiceSession)
+		} else {
+			session.RedirectURI = r.URL.Query().Get("redirect_uri")
+			if !validRedirectURI(session.RedirectURI) {
+				w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`<h3>Redirect url not found. Did you open this in your browser?</h3>`))
+				return
+			}
+
+			claims, err := srv.Auth.Token(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+			if err != nil {
+				log.Error().Err(err).Msg("error verifying terms of service authentication token")
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			session.Upn, session.FullName, session.ObjectID = claims.Subject, claims.FullName, claims.MicrosoftSpecificAuthClaims.ObjectID
+		}
+		redirectURL, _ := url.Parse(session.RedirectURI)
+
+		terms, err := srv.DB.GetLatestTermsOfService(r.Context())
+		if err == sql.ErrNoRows {
+			redirectTermsOfService(w, r, *redirectURL, true, "")
+			return
+		} else if err != nil {
+			log.Error().Err(err).Msg("error retrieving terms of service")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method != http.MethodPost || r.PostForm.Get("version") != strconv.Itoa(int(terms.Version)) {
+			branding, err := EnrollmentBranding(r.Context(), srv.DB, srv.Settings.Get(), AcceptLanguages(r)...)
+			if err != nil {
+				log.Error().Err(err).Msg("error retrieving enrollment branding")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			var raw = make([]byte, 24)
+			if _, err := rand.Read(raw); err != nil {
+				log.Error().Err(err).Msg("error generating terms of service nonce")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			var nonce = base64.RawURLEncoding.EncodeToString(raw)
+			srv.Cache.Set(termsOfServiceCacheKey(nonce), session, termsOfServiceLifetime)
+
+			w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+			if err := termsOfServiceTemplate.Execute(w, map[string]interface{}{
+				"Branding": branding,
+				"Terms":    terms,
+				"Nonce":    nonce,
+			}); err != nil {
+				log.Error().Err(err).Msg("error rendering terms of service")
+			}
+			return
+		}
+
+		if r.PostForm.Get("accepted") != "true" {
+			redirectTermsOfService(w, r, *redirectURL, false, "")
+			return
+		}
+
+		if _, err := srv.DB.NewAzureADUser(r.Context(), db.NewAzureADUserParams{
+			Upn:        session.Upn,
+			Fullname:   session.FullName,
+			AzureadOid: null.String{String: session.ObjectID, Valid: session.ObjectID != ""},
+		}); err != nil && err != sql.ErrNoRows {
+			log.Error().Str("upn", session.Upn).Err(err).Msg("error importing AzureAD user")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if err := srv.DB.AcceptTermsOfService(r.Context(), db.AcceptTermsOfServiceParams{
+			Upn:     session.Upn,
+			Version: terms.Version,
+		}); err != nil {
+			log.Error().Str("upn", session.Upn).Err(err).Msg("error recording terms of service acceptance")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		redirectTermsOfService(w, r, *redirectURL, true, strconv.Itoa(int(terms.Version)))
+	}
+}
+
+// redirectTermsOfService returns the user to the Windows enrollment flow with the outcome of the terms of service
+func redirectTermsOfService(w http.ResponseWriter, r *http.Request, redirectURL url.URL, accepted bool, opaqueBlob string) {
+	var query = redirectURL.Query()
+	if accepted {
+		query.Set("IsAccepted", "true")
+		query.Set("OpaqueBlob", opaqueBlob)
+	} else {
+		query.Set("IsAccepted", "false")
+		query.Set("error", "access_denied")
+		query.Set("error_description", "The user declined the terms of service")
+	}
+	redirectURL.RawQuery = query.Encode()
+	http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+}
@@ -0,0 +1,29 @@
+package windows
+
+import "testing"
+
+func TestValidRedirectURI(t *testing.T) {
+	var tests = []struct {
+		redirectURI string
+		valid       bool
+	}{
+		{"ms-appx-web://Microsoft.AAD.BrokerPlugin", true},
+		{"ms-appx-web://Microsoft.AAD.BrokerPlugin/path?query=1", true},
+		{"MS-APPX-WEB://Microsoft.AAD.BrokerPlugin", true}, // schemes are case insensitive
+		{"", false},
+		{"https://evil.example.com", false},
+		{"http://evil.example.com", false},
+		{"javascript:alert(1)", false},
+		{"//evil.example.com", false},
+		{"/relative", false},
+		{"ms-appx-web:", false},
+		{"ms-appx-web:///path", false},
+		{"ms-app://Microsoft.AAD.BrokerPlugin", false},
+		{"ms-appx-web://%zz", false},
+	}
+	for _, tt := range tests {
+		if valid := validRedirectURI(tt.redirectURI); valid != tt.valid {
+			t.Errorf("validRedirectURI(%q) returned %v, expected %v", tt.redirectURI, valid, tt.valid)
+		}
+	}
+}
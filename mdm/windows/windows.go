@@ -1,9 +1,10 @@
 package windows
 
 import (
-	"net/http"
-
 	mattrax "github.com/mattrax/Mattrax/internal"
+	"github.com/mattrax/Mattrax/internal/apps"
+	"github.com/mattrax/Mattrax/internal/scep"
+	"github.com/mattrax/Mattrax/internal/scripts"
 	"github.com/mattrax/Mattrax/pkg"
 	"github.com/rs/zerolog/log"
 )
@@ -22,19 +23,21 @@ func Mount(srv *mattrax.Server) {
 		log.Error().Err(err).Msg(errDescription)
 	}
 
-	// TODO: Replace with UI based Login Route
-	srv.Router.HandleFunc("/Login.svc", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
-		w.Write([]byte(`<h3>MDM Federated Login</h3><form method="post" action="` + r.URL.Query().Get("appru") + `"><p><input type="hidden" name="wresult" value="VIRTUAL_DEVICE_AUTH_TOKEN" /></p><input type="submit" value="Login" /></form>`))
-	}).Name("login").Methods("GET")
+	go pruneEnrollmentAttempts(srv)
 
-	// TODO: Replace with UI based Login Route
-	srv.Router.HandleFunc("/EnrollmentServer/TermsOfService.svc", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
-		w.Write([]byte(`<h3>AzureAD Term Of Service</h3><button onClick="acceptBtn()">Accept</button><script>function acceptBtn() { var urlParams = new URLSearchParams(window.location.search); if (!urlParams.has('redirect_uri')) { alert('Redirect url not found. Did you open this in your broswer?'); } else { window.location = urlParams.get('redirect_uri') + "?IsAccepted=true&OpaqueBlob=TODOCustomDataFromAzureAD"; } }</script>`))
-	}).Name("azuread-tos").Methods("GET")
+	srv.Router.HandleFunc("/Login.svc", Login(srv)).Name("login").Methods("GET")
+	srv.Router.HandleFunc("/EnrollmentServer/TermsOfService.svc", TermsOfService(srv)).Name("azuread-tos").Methods("GET", "POST")
 
 	srv.Router.HandleFunc("/ManagementServer/Manage.svc", Manage(srv)).Name("winmdm-manage").Methods("POST")
+	srv.Router.HandleFunc(apps.DownloadPath+"{hash}", AppDownload(srv)).Name("winmdm-apps").Methods("GET", "HEAD")
+	srv.Router.HandleFunc(apps.DownloadPath+"{hash}/{name}", AppDownload(srv)).Name("winmdm-apps-named").Methods("GET", "HEAD")
+	srv.AgentRouter.HandleFunc(scripts.AgentPath+"Scripts", AgentScripts(srv)).Name("winmdm-agent-scripts").Methods("GET")
+	srv.AgentRouter.HandleFunc(scripts.AgentPath+"Scripts/{id}", AgentScriptRun(srv)).Name("winmdm-agent-script-run").Methods("POST")
+	srv.AgentRouter.HandleFunc(scripts.AgentPath+"BitLocker", AgentBitLocker(srv)).Name("winmdm-agent-bitlocker").Methods("POST")
+	srv.Router.HandleFunc(scep.Path, SCEP(srv)).Name("winmdm-scep").Methods("GET", "POST")
+	srv.Router.HandleFunc(scep.Path+"/pkiclient.exe", SCEP(srv)).Name("winmdm-scep-pkiclient").Methods("GET", "POST")
+	srv.Router.HandleFunc(scep.CRLPath, SCEPCRL(srv)).Name("winmdm-scep-crl").Methods("GET")
+	srv.HTTPRouter.HandleFunc(scep.CRLPath, SCEPCRL(srv)).Host(srv.Args.Domain).Name("winmdm-scep-crl-http").Methods("GET")
 	srv.Router.HandleFunc("/EnrollmentServer/Policy.svc", Policy(srv)).Name("winmdm-policy").Methods("POST")
 	srv.Router.HandleFunc("/EnrollmentServer/Enrollment.svc", Enrollment(srv)).Name("winmdm-enrollment").Methods("POST")
 
@@ -0,0 +1,13 @@
+package placeholder
+
+import "strings"
+
+// Expand replaces each "{name}" placeholder in the template with its value.
+// Placeholders without a value are left untouched so misconfigured templates are easy to spot.
+func Expand(template string, values map[string]string) string {
+	var replacements = make([]string, 0, len(values)*2)
+	for name, value := range values {
+		replacements = append(replacements, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(replacements...).Replace(template)
+}
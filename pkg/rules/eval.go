@@ -0,0 +1,154 @@
+package rules
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Environment provides the values of the device a rule is being evaluated against
+type Environment interface {
+	// Value returns the value of the field. An error should be returned for unknown fields.
+	Value(field Field) (string, error)
+	// InGroup returns whether the field's value (eg. the device or the user who enrolled it) is a member of the named group
+	InGroup(field Field, group string) (bool, error)
+}
+
+// Evaluate returns whether the device described by the environment matches the rule
+func (r Rule) Evaluate(env Environment) (bool, error) {
+	return r.expr.eval(env)
+}
+
+type expr interface {
+	eval(env Environment) (bool, error)
+	fields(fields *[]Field)
+}
+
+type andExpr struct{ left, right expr }
+
+func (e andExpr) eval(env Environment) (bool, error) {
+	if ok, err := e.left.eval(env); err != nil || !ok {
+		return false, err
+	}
+	return e.right.eval(env)
+}
+
+func (e andExpr) fields(fields *[]Field) {
+	e.left.fields(fields)
+	e.right.fields(fields)
+}
+
+type orExpr struct{ left, right expr }
+
+func (e orExpr) eval(env Environment) (bool, error) {
+	if ok, err := e.left.eval(env); err != nil || ok {
+		return ok, err
+	}
+	return e.right.eval(env)
+}
+
+func (e orExpr) fields(fields *[]Field) {
+	e.left.fields(fields)
+	e.right.fields(fields)
+}
+
+type notExpr struct{ expr expr }
+
+func (e notExpr) eval(env Environment) (bool, error) {
+	ok, err := e.expr.eval(env)
+	return !ok, err
+}
+
+func (e notExpr) fields(fields *[]Field) {
+	e.expr.fields(fields)
+}
+
+type inGroupExpr struct {
+	field Field
+	group string
+}
+
+func (e inGroupExpr) eval(env Environment) (bool, error) {
+	return env.InGroup(e.field, e.group)
+}
+
+// The field isn't compared so it is returned by Memberships instead of Fields
+func (e inGroupExpr) fields(fields *[]Field) {}
+
+type comparisonExpr struct {
+	field Field
+	op    string
+	value string
+}
+
+func (e comparisonExpr) eval(env Environment) (bool, error) {
+	value, err := env.Value(e.field)
+	if err != nil {
+		return false, err
+	}
+
+	if e.op == "contains" {
+		return strings.Contains(strings.ToLower(value), strings.ToLower(e.value)), nil
+	}
+
+	var result = Compare(value, e.value)
+	switch e.op {
+	case "=":
+		return result == 0, nil
+	case "!=":
+		return result != 0, nil
+	case "<":
+		return result < 0, nil
+	case "<=":
+		return result <= 0, nil
+	case ">":
+		return result > 0, nil
+	case ">=":
+		return result >= 0, nil
+	}
+	return false, nil
+}
+
+func (e comparisonExpr) fields(fields *[]Field) {
+	*fields = append(*fields, e.field)
+}
+
+// Compare compares two values returning -1, 0 or 1. Versions (eg. 10.0.19041) and numbers are compared numerically, everything else is compared case insensitively.
+func Compare(a, b string) int {
+	if aParts, ok := version(a); ok {
+		if bParts, ok := version(b); ok {
+			for i := 0; i < len(aParts) || i < len(bParts); i++ {
+				var aPart, bPart int64
+				if i < len(aParts) {
+					aPart = aParts[i]
+				}
+				if i < len(bParts) {
+					bPart = bParts[i]
+				}
+				if aPart < bPart {
+					return -1
+				} else if aPart > bPart {
+					return 1
+				}
+			}
+			return 0
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// version splits a dot separated version into its numeric parts
+func version(s string) ([]int64, bool) {
+	if s == "" {
+		return nil, false
+	}
+	var parts = strings.Split(s, ".")
+	var numbers = make([]int64, len(parts))
+	for i, part := range parts {
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		numbers[i] = n
+	}
+	return numbers, true
+}
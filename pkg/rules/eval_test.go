@@ -0,0 +1,121 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+)
+
+// testEnvironment is a device with the values and group memberships, in the form "field/group", in the maps
+type testEnvironment struct {
+	values map[string]string
+	groups map[string]bool
+}
+
+func (e testEnvironment) Value(field Field) (string, error) {
+	if value, ok := e.values[field.String()]; ok {
+		return value, nil
+	}
+	return "", errors.New("unknown field " + field.String())
+}
+
+func (e testEnvironment) InGroup(field Field, group string) (bool, error) {
+	return e.groups[field.Name+"/"+group], nil
+}
+
+func TestEvaluate(t *testing.T) {
+	var env = testEnvironment{
+		values: map[string]string{
+			"a":                            "1",
+			"b":                            "0",
+			"c":                            "0",
+			"name":                         "Finance-PC01",
+			"operating_system":             "10.0.19041.508",
+			"enrollment_type":              "Device",
+			"inventory['./DevDetail/SwV']": "10.0.19041.1",
+		},
+		groups: map[string]bool{
+			"device/Finance":           true,
+			"enrolled_by/Finance Team": true,
+		},
+	}
+
+	var tests = []struct {
+		rule     string
+		expected bool
+	}{
+		{"a = 1", true},
+		{"a != 1", false},
+		{"enrollment_type = device", true},
+		{"name contains finance", true},
+		{"name contains HR", false},
+		{"operating_system >= 10.0.19041", true},
+		{"operating_system < 10.0.9600", false},
+		{"operating_system > 10.0.19041.99", true},
+		{"operating_system <= 10.0.19041.508", true},
+		{"inventory['./DevDetail/SwV'] contains 19041", true},
+		{"device IN GROUP Finance", true},
+		{"device IN GROUP HR", false},
+		{`enrolled_by IN GROUP "Finance Team"`, true},
+		// AND binds tighter than OR and NOT binds tighter than AND
+		{"a = 1 OR b = 1 AND c = 1", true},
+		{"(a = 1 OR b = 1) AND c = 1", false},
+		{"b = 1 AND c = 1 OR a = 1", true},
+		{"NOT a = 1 AND b = 1", false},
+		{"NOT (a = 1 AND b = 1)", true},
+		{"NOT NOT a = 1", true},
+		{"a = 1 and b = 0 and c = 0", true},
+		// The right side isn't evaluated when the left side decides the result so it can reference unknown fields
+		{"a = 0 AND unknown = 1", false},
+		{"a = 1 OR unknown = 1", true},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %q", tt.rule, err)
+			continue
+		}
+
+		if result, err := rule.Evaluate(env); err != nil {
+			t.Errorf("evaluating %q returned %q", tt.rule, err)
+		} else if result != tt.expected {
+			t.Errorf("evaluating %q returned %v, expected %v", tt.rule, result, tt.expected)
+		}
+	}
+}
+
+func TestEvaluateUnknownField(t *testing.T) {
+	for _, rule := range []string{"unknown = 1", "a = 1 AND unknown = 1", "b = 1 OR unknown = 1", "NOT unknown = 1"} {
+		r, err := Parse(rule)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %q", rule, err)
+			continue
+		}
+
+		if _, err := r.Evaluate(testEnvironment{values: map[string]string{"a": "1", "b": "0"}}); err == nil {
+			t.Errorf("evaluating %q with an unknown field succeeded", rule)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	var tests = []struct {
+		a, b     string
+		expected int
+	}{
+		{"10.0.19041", "10.0.19041", 0},
+		{"10.0.19041", "10.0.9600", 1},
+		{"10.0.9600", "10.0.19041", -1},
+		{"10.0", "10.0.0", 0},
+		{"10.0", "10.0.1", -1},
+		{"2", "10", -1}, // numbers are compared numerically
+		{"Device", "device", 0},
+		{"apple", "Banana", -1},
+		{"10.0.a", "10.0.b", -1},
+		{"", "0", -1},
+	}
+	for _, tt := range tests {
+		if result := Compare(tt.a, tt.b); result != tt.expected {
+			t.Errorf("Compare(%q, %q) returned %d, expected %d", tt.a, tt.b, result, tt.expected)
+		}
+	}
+}
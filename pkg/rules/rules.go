@@ -0,0 +1,293 @@
+// Package rules implements the expression language used by dynamic groups to select devices.
+//
+// A rule compares a field to a value, eg. `operating_system >= 10.0.19041`, `enrollment_type = Device`
+// or `inventory['./DevDetail/SwV'] contains 19041`. Rules can be combined using AND, OR, NOT and parentheses.
+// Group membership is tested using `<field> IN GROUP "Name"`.
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Field is a value of the device which is referenced from a rule. The Key is only set for map fields, eg. inventory['./DevDetail/SwV'].
+type Field struct {
+	Name string
+	Key  string
+}
+
+func (f Field) String() string {
+	if f.Key != "" {
+		return f.Name + "['" + f.Key + "']"
+	}
+	return f.Name
+}
+
+// Rule is a parsed expression which can be evaluated against a device
+type Rule struct {
+	expr expr
+}
+
+// Fields returns every field compared by the rule. The fields whose group membership is tested are returned by Memberships.
+func (r Rule) Fields() []Field {
+	var fields []Field
+	r.expr.fields(&fields)
+	return fields
+}
+
+// Membership is a test of whether the field is a member of the group, eg. `device IN GROUP "Name"`
+type Membership struct {
+	Field Field
+	Group string
+}
+
+// Memberships returns every group membership tested by the rule
+func (r Rule) Memberships() []Membership {
+	var memberships []Membership
+	walk(r.expr, func(e expr) {
+		if e, ok := e.(inGroupExpr); ok {
+			memberships = append(memberships, Membership{Field: e.field, Group: e.group})
+		}
+	})
+	return memberships
+}
+
+func walk(e expr, fn func(e expr)) {
+	fn(e)
+	switch e := e.(type) {
+	case andExpr:
+		walk(e.left, fn)
+		walk(e.right, fn)
+	case orExpr:
+		walk(e.left, fn)
+		walk(e.right, fn)
+	case notExpr:
+		walk(e.expr, fn)
+	}
+}
+
+// Parse parses the rule. An empty rule is invalid.
+func Parse(rule string) (Rule, error) {
+	tokens, err := lex(rule)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	var p = parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return Rule{}, fmt.Errorf("the rule is empty")
+	}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return Rule{}, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return Rule{}, fmt.Errorf("unexpected '%s' at position %d", t.value, t.pos)
+	}
+	return Rule{expr: e}, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func lex(rule string) ([]token, error) {
+	var tokens []token
+	var runes = []rune(rule)
+	for i := 0; i < len(runes); {
+		var r = runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case r == '[':
+			tokens = append(tokens, token{tokenLBracket, "[", i})
+			i++
+		case r == ']':
+			tokens = append(tokens, token{tokenRBracket, "]", i})
+			i++
+		case r == '\'' || r == '"':
+			var end = i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{tokenString, string(runes[i+1 : end]), i})
+			i = end + 1
+		case r == '=' || r == '!' || r == '<' || r == '>':
+			var op = string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" || op == "==" {
+				return nil, fmt.Errorf("unexpected '%s' at position %d", op, i)
+			}
+			tokens = append(tokens, token{tokenOperator, op, i})
+			i += len(op)
+		default:
+			var end = i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("()[]'\"=!<>", runes[end]) {
+				end++
+			}
+			tokens = append(tokens, token{tokenWord, string(runes[i:end]), i})
+			i = end
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(runes)}), nil
+}
+
+// keywords are the words which can't be used as field names
+var keywords = map[string]bool{
+	"AND": true,
+	"OR":  true,
+	"NOT": true,
+	"IN":  true,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	var t = p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword consumes the next token if it is the case insensitive keyword
+func (p *parser) keyword(keyword string) bool {
+	if t := p.peek(); t.kind == tokenWord && strings.EqualFold(t.value, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.keyword("NOT") {
+		e, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{e}, nil
+	}
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ')' at position %d", t.pos)
+		}
+		return e, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	var t = p.next()
+	if t.kind != tokenWord || keywords[strings.ToUpper(t.value)] {
+		return nil, fmt.Errorf("expected a field at position %d", t.pos)
+	}
+	var field = Field{Name: strings.ToLower(t.value)}
+
+	if p.peek().kind == tokenLBracket {
+		p.next()
+		key := p.next()
+		if key.kind != tokenString {
+			return nil, fmt.Errorf("expected a quoted key at position %d", key.pos)
+		}
+		if t := p.next(); t.kind != tokenRBracket {
+			return nil, fmt.Errorf("expected ']' at position %d", t.pos)
+		}
+		field.Key = key.value
+	}
+
+	if p.keyword("IN") {
+		if !p.keyword("GROUP") {
+			return nil, fmt.Errorf("expected 'GROUP' at position %d", p.peek().pos)
+		}
+		group := p.next()
+		if group.kind != tokenString && group.kind != tokenWord {
+			return nil, fmt.Errorf("expected a group name at position %d", group.pos)
+		}
+		return inGroupExpr{field, group.value}, nil
+	}
+
+	var op = p.next()
+	if op.kind == tokenWord && strings.EqualFold(op.value, "contains") {
+		op.value = "contains"
+	} else if op.kind != tokenOperator {
+		return nil, fmt.Errorf("expected an operator at position %d", op.pos)
+	}
+
+	value := p.next()
+	if value.kind != tokenString && value.kind != tokenWord {
+		return nil, fmt.Errorf("expected a value at position %d", value.pos)
+	}
+	return comparisonExpr{field, op.value, value.value}, nil
+}
@@ -0,0 +1,93 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMalformed(t *testing.T) {
+	var tests = []struct {
+		rule string
+		err  string
+	}{
+		{"", "the rule is empty"},
+		{"   ", "the rule is empty"},
+		{"name", "expected an operator at position 4"},
+		{"name =", "expected a value at position 6"},
+		{"= Device", "expected a field at position 0"},
+		{"name == Device", "unexpected '==' at position 5"},
+		{"name ! Device", "unexpected '!' at position 5"},
+		{"name = 'Device", "unterminated string at position 7"},
+		{"(name = Device", "expected ')' at position 14"},
+		{"name = Device)", "unexpected ')' at position 13"},
+		{"name = Device AND", "expected a field at position 17"},
+		{"name = Device OR OR model = X", "expected a field at position 17"},
+		{"name = Device model = X", "unexpected 'model' at position 14"},
+		{"NOT", "expected a field at position 3"},
+		{"inventory[./DevDetail/SwV] = 1", "expected a quoted key at position 10"},
+		{"inventory['./DevDetail/SwV' = 1", "expected ']' at position 28"},
+		{"device IN 'Group'", "expected 'GROUP' at position 10"},
+		{"device IN GROUP", "expected a group name at position 15"},
+		{"name contains", "expected a value at position 13"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.rule); err == nil {
+			t.Errorf("Parse(%q) succeeded, expected %q", tt.rule, tt.err)
+		} else if err.Error() != tt.err {
+			t.Errorf("Parse(%q) returned %q, expected %q", tt.rule, err, tt.err)
+		}
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	var tests = []struct {
+		rule        string
+		fields      []Field
+		memberships []Membership
+	}{
+		{
+			rule:   "operating_system >= 10.0.19041",
+			fields: []Field{{Name: "operating_system"}},
+		},
+		{
+			rule:   "Enrollment_Type = Device",
+			fields: []Field{{Name: "enrollment_type"}},
+		},
+		{
+			rule:   `inventory['./DevDetail/SwV'] contains "19041" and NOT name = 'Kiosk PC'`,
+			fields: []Field{{Name: "inventory", Key: "./DevDetail/SwV"}, {Name: "name"}},
+		},
+		{
+			rule:        `device IN GROUP "Finance Laptops" OR enrolled_by in group Finance`,
+			memberships: []Membership{{Field: Field{Name: "device"}, Group: "Finance Laptops"}, {Field: Field{Name: "enrolled_by"}, Group: "Finance"}},
+		},
+		{
+			rule:        "(model = Surface OR model = XPS) AND NOT (device IN GROUP Excluded)",
+			fields:      []Field{{Name: "model"}, {Name: "model"}},
+			memberships: []Membership{{Field: Field{Name: "device"}, Group: "Excluded"}},
+		},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %q", tt.rule, err)
+			continue
+		}
+
+		if fields := rule.Fields(); !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("Parse(%q).Fields() returned %v, expected %v", tt.rule, fields, tt.fields)
+		}
+		if memberships := rule.Memberships(); !reflect.DeepEqual(memberships, tt.memberships) {
+			t.Errorf("Parse(%q).Memberships() returned %v, expected %v", tt.rule, memberships, tt.memberships)
+		}
+	}
+}
+
+func TestFieldString(t *testing.T) {
+	if s := (Field{Name: "name"}).String(); s != "name" {
+		t.Errorf("Field.String() returned %q, expected %q", s, "name")
+	}
+	if s := (Field{Name: "inventory", Key: "./DevDetail/SwV"}).String(); s != "inventory['./DevDetail/SwV']" {
+		t.Errorf("Field.String() returned %q, expected %q", s, "inventory['./DevDetail/SwV']")
+	}
+}
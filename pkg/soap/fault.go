@@ -24,15 +24,15 @@ type FaultReason struct {
 
 // FaultDeviceEnrollmentServiceError contains extra error codes (which sometimes have special UI's) and a traceid which can be used trace requests between the client and server logs
 type FaultDeviceEnrollmentServiceError struct {
-	ErrorType string `xml:"ErrorType"`
+	ErrorType string `xml:"ErrorType,omitempty"`
 	Message   string `xml:"Message"`
-	TraceID   string `xml:"TraceId"`
+	TraceID   string `xml:"TraceId,omitempty"`
 }
 
-// NewFault creates a new fault
+// NewFault creates a new fault. The detail is only included if an errortype or traceid is set and its empty fields are omitted.
 func NewFault(causer, code, errortype, reason, traceid string) ResponseEnvelope {
 	var deviceEnrollmentServiceError FaultDeviceEnrollmentServiceError
-	if errortype != "" {
+	if errortype != "" || traceid != "" {
 		deviceEnrollmentServiceError = FaultDeviceEnrollmentServiceError{
 			ErrorType: errortype,
 			Message:   reason,
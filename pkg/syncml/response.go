@@ -11,34 +11,76 @@ import (
 
 // Response is a SyncML response body. It has helpers to make generating responses easier
 type Response struct {
-	res Message
+	res responseMessage
 }
 
-// Set creates a generic command on the response
-func (r *Response) Set(command, uri, dtype, format, data string) {
+// responseMessage is a Message sent to the device. Its commands are responseCommands so the Data of their items can be raw XML.
+type responseMessage struct {
+	XMLName xml.Name     `xml:"SYNCML:SYNCML1.2 SyncML"`
+	XmlnA   string       `xml:"xmlns:A,attr"`
+	Header  Header       `xml:"SyncHdr"`
+	Body    responseBody `xml:"SyncBody"`
+}
+
+type responseBody struct {
+	Commands []responseCommand `xml:",any"`
+	Final    string            `xml:",innerxml"`
+}
+
+// responseCommand is a command sent to the device. Unlike a Command it is only encoded.
+type responseCommand struct {
+	XMLName xml.Name
+	CmdID   string         `xml:",omitempty"`
+	MsgRef  string         `xml:",omitempty"`
+	CmdRef  string         `xml:",omitempty"`
+	Cmd     string         `xml:",omitempty"`
+	Data    string         `xml:",omitempty"`
+	Items   []responseItem `xml:"Item,omitempty"`
+}
+
+// responseItem is the node a responseCommand applies to and its value
+type responseItem struct {
+	Target  *LocURI `xml:"Target,omitempty"`
+	Meta    *Meta   `xml:",omitempty"`
+	Data    string  `xml:",omitempty"`
+	RawData string  `xml:",innerxml"` // RawData is written unescaped after the other fields. It is used to send the Data of xml payloads as XML.
+}
+
+// Set creates a generic command on the response and returns its CmdID which the device's Status for it will reference
+func (r *Response) Set(command, uri, dtype, format, data string) string {
 	var meta *Meta = &Meta{
 		Format: format,
 		Type:   dtype,
 	}
 
-	r.res.Body.Commands = append(r.res.Body.Commands, Command{
+	var item = responseItem{
+		Target: &LocURI{
+			URI: uri,
+		},
+		Meta: meta,
+		Data: data,
+	}
+
+	// The value of xml payloads is embedded as XML (it is validated to be well-formed when the payload is saved). Every other format is escaped text.
+	if format == "xml" && data != "" {
+		item.Data = ""
+		item.RawData = "<Data>" + data + "</Data>"
+	}
+
+	var cmdID = fmt.Sprintf("%x", len(r.res.Body.Commands)+1)
+	r.res.Body.Commands = append(r.res.Body.Commands, responseCommand{
 		XMLName: xml.Name{
 			Local: command,
 		},
-		CmdID: fmt.Sprintf("%x", len(r.res.Body.Commands)+1),
-		Body: []Command{
-			{
-				XMLName: xml.Name{
-					Local: "Item",
-				},
-				Target: &LocURI{
-					URI: uri,
-				},
-				Meta: meta,
-				Data: data,
-			},
-		},
+		CmdID: cmdID,
+		Items: []responseItem{item},
 	})
+	return cmdID
+}
+
+// MsgID returns the MsgID of the response which the device's Status commands will reference
+func (r *Response) MsgID() string {
+	return r.res.Header.MsgID
 }
 
 // Respond creates the final element and encodes the response
@@ -70,7 +112,7 @@ func (r *Response) FinalStatus() int32 {
 // NewResponse creates a new SyncML Envelope for the response
 func NewResponse(cmd Message) Response {
 	return Response{
-		res: Message{
+		res: responseMessage{
 			XmlnA: "syncml:metinf",
 			Header: Header{
 				VerDTD:         cmd.Header.VerDTD,
@@ -81,8 +123,8 @@ func NewResponse(cmd Message) Response {
 				SourceURI:      cmd.Header.TargetURI,
 				MetaMaxMsgSize: MaxRequestBodySize,
 			},
-			Body: Body{
-				Commands: []Command{
+			Body: responseBody{
+				Commands: []responseCommand{
 					{
 						XMLName: xml.Name{
 							Local: "Status",
@@ -0,0 +1,53 @@
+package syncml
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattrax/xml"
+)
+
+func TestResponseSet(t *testing.T) {
+	var res = NewResponse(Message{Header: Header{MsgID: "1"}})
+	res.Set("Replace", "./Device/A", "", "int", "1")
+	res.Set("Add", "./Device/B", "", "xml", "<a><b>x</b></a>")
+	res.Set("Replace", "./Device/C", "", "chr", "<escaped & text>")
+	res.Set("Add", "./Device/D", "", "xml", "")
+
+	var w = httptest.NewRecorder()
+	res.Respond(w)
+
+	for _, expected := range []string{
+		`<Replace><CmdID>2</CmdID><Item><Target><LocURI>./Device/A</LocURI></Target><Meta><Format xmlns="syncml:metinf">int</Format></Meta><Data>1</Data></Item></Replace>`,
+		`<Add><CmdID>3</CmdID><Item><Target><LocURI>./Device/B</LocURI></Target><Meta><Format xmlns="syncml:metinf">xml</Format></Meta><Data><a><b>x</b></a></Data></Item></Add>`,
+		`<Replace><CmdID>4</CmdID><Item><Target><LocURI>./Device/C</LocURI></Target><Meta><Format xmlns="syncml:metinf">chr</Format></Meta><Data>&lt;escaped &amp; text&gt;</Data></Item></Replace>`,
+		`<Add><CmdID>5</CmdID><Item><Target><LocURI>./Device/D</LocURI></Target><Meta><Format xmlns="syncml:metinf">xml</Format></Meta></Item></Add>`,
+		`<Final /></SyncBody>`,
+	} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("the response doesn't contain %s:\n%s", expected, w.Body.String())
+		}
+	}
+}
+
+// A decoded command encoded again must contain its children once
+func TestCommandRoundTrip(t *testing.T) {
+	var raw = `<Results><CmdID>2</CmdID><MsgRef>1</MsgRef><CmdRef>3</CmdRef><Item><Source><LocURI>./DevDetail/SwV</LocURI></Source><Data>10.0.19041</Data></Item></Results>`
+
+	var cmd Command
+	if err := xml.Unmarshal([]byte(raw), &cmd); err != nil {
+		t.Fatal(err)
+	} else if len(cmd.Body) != 1 || cmd.Body[0].Source == nil || cmd.Body[0].Source.URI != "./DevDetail/SwV" || cmd.Body[0].Data != "10.0.19041" {
+		t.Fatalf("the command was decoded as %+v", cmd)
+	}
+
+	encoded, err := xml.Marshal(cmd)
+	if err != nil {
+		t.Fatal(err)
+	} else if n := strings.Count(string(encoded), "<Item>"); n != 1 {
+		t.Errorf("the encoded command contains %d items, expected 1: %s", n, encoded)
+	} else if n := strings.Count(string(encoded), "10.0.19041"); n != 1 {
+		t.Errorf("the encoded command contains its data %d times, expected 1: %s", n, encoded)
+	}
+}
@@ -0,0 +1,113 @@
+package syncml
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mattrax/xml"
+)
+
+// ValidateURI verifies the uri is a valid OMA-URI for a node on the device's management tree (eg. ./Device/Vendor/MSFT/Policy/Config/Camera/AllowCamera)
+func ValidateURI(uri string) error {
+	if !strings.HasPrefix(uri, "./") {
+		return errors.New("the uri must begin with './'")
+	} else if strings.HasSuffix(uri, "/") {
+		return errors.New("the uri must not end with '/'")
+	}
+
+	for _, segment := range strings.Split(uri[2:], "/") {
+		if segment == "" {
+			return errors.New("the uri must not contain empty segments")
+		} else if segment == "." || segment == ".." {
+			return errors.New("the uri must not contain relative segments")
+		}
+
+		for _, r := range segment {
+			if unicode.IsSpace(r) || unicode.IsControl(r) || r == '?' || r == '#' {
+				return fmt.Errorf("the uri segment '%s' contains the invalid character '%c'", segment, r)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateFormat verifies the value can be represented using the SyncML format (eg. int, chr)
+func ValidateFormat(format, value string) error {
+	switch format {
+	case "", "chr":
+		return nil
+	case "int":
+		if _, err := strconv.ParseInt(value, 10, 32); err != nil {
+			return fmt.Errorf("the value '%s' is not a valid int", value)
+		}
+	case "bool":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("the value '%s' is not a valid bool", value)
+		}
+	case "b64", "bin":
+		// Binary data can't be sent in an XML SyncML message so it is base64 encoded like b64
+		if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+			return fmt.Errorf("the value is not valid %s: %s", format, err)
+		}
+	case "xml":
+		var v struct {
+			Inner string `xml:",innerxml"`
+		}
+		if err := xml.Unmarshal([]byte("<root>"+value+"</root>"), &v); err != nil {
+			return fmt.Errorf("the value is not valid xml: %s", err)
+		}
+	case "node", "null":
+		if value != "" {
+			return fmt.Errorf("the format '%s' can't have a value", format)
+		}
+	default:
+		return fmt.Errorf("the format '%s' is not supported", format)
+	}
+	return nil
+}
+
+// ValidateType verifies the SyncML type is a MIME type (eg. text/plain)
+func ValidateType(dtype string) error {
+	if dtype == "" {
+		return nil
+	}
+
+	var parts = strings.SplitN(dtype, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.IndexFunc(dtype, unicode.IsSpace) != -1 {
+		return fmt.Errorf("the type '%s' is not a MIME type", dtype)
+	}
+	return nil
+}
+
+// NormaliseValue returns the canonical representation of the value in the SyncML format so equivalent values are stored the same.
+// Whitespace around int, bool and base64 values is removed, bools are lowercase and base64 values are re-encoded with padding.
+// Values which aren't valid in the format are returned unchanged as ValidateFormat reports them.
+func NormaliseValue(format, value string) string {
+	switch format {
+	case "int":
+		if v, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32); err == nil {
+			return strconv.FormatInt(v, 10)
+		}
+	case "bool":
+		if v := strings.ToLower(strings.TrimSpace(value)); v == "true" || v == "false" {
+			return v
+		}
+	case "b64", "bin":
+		var stripped = strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, value)
+		if data, err := base64.StdEncoding.DecodeString(stripped); err == nil {
+			return base64.StdEncoding.EncodeToString(data)
+		} else if data, err := base64.RawStdEncoding.DecodeString(stripped); err == nil {
+			return base64.StdEncoding.EncodeToString(data)
+		}
+	}
+	return value
+}
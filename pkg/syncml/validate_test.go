@@ -0,0 +1,125 @@
+package syncml
+
+import "testing"
+
+func TestValidateFormat(t *testing.T) {
+	var tests = []struct {
+		format string
+		value  string
+		valid  bool
+	}{
+		{"", "anything", true},
+		{"chr", "", true},
+		{"chr", "<not xml", true},
+		{"int", "0", true},
+		{"int", "-5", true},
+		{"int", "2147483647", true},
+		{"int", "2147483648", false}, // larger than an int32
+		{"int", "1.5", false},
+		{"int", "one", false},
+		{"int", "", false},
+		{"int", " 1", false}, // values are normalised before they are validated
+		{"bool", "true", true},
+		{"bool", "false", true},
+		{"bool", "True", false},
+		{"bool", "1", false},
+		{"b64", "", true},
+		{"b64", "aGVsbG8=", true},
+		{"b64", "aGVsbG8", false},
+		{"b64", "not base64!", false},
+		{"bin", "AAEC", true},
+		{"bin", "AAE", false},
+		{"xml", "", true},
+		{"xml", "<a/>", true},
+		{"xml", "<a><b>text</b></a><c/>", true},
+		{"xml", "text only", true},
+		{"xml", "<a>", false},
+		{"xml", "<a></b>", false},
+		{"xml", "</a>", false},
+		{"node", "", true},
+		{"node", "value", false},
+		{"null", "", true},
+		{"null", "value", false},
+		{"float", "1.5", false},
+		{"CHR", "value", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateFormat(tt.format, tt.value); (err == nil) != tt.valid {
+			t.Errorf("ValidateFormat(%q, %q) returned %v, expected valid to be %v", tt.format, tt.value, err, tt.valid)
+		}
+	}
+}
+
+func TestNormaliseValue(t *testing.T) {
+	var tests = []struct {
+		format   string
+		value    string
+		expected string
+	}{
+		{"chr", " value ", " value "},
+		{"", " value ", " value "},
+		{"xml", " <a/> ", " <a/> "},
+		{"int", " 42\n", "42"},
+		{"int", "+7", "7"},
+		{"int", "007", "7"},
+		{"int", "-0", "0"},
+		{"int", "one", "one"},
+		{"int", "2147483648", "2147483648"},
+		{"bool", " TRUE ", "true"},
+		{"bool", "False", "false"},
+		{"bool", "yes", "yes"},
+		{"b64", "aGVsbG8=", "aGVsbG8="},
+		{"b64", "aGVs\r\nbG8=", "aGVsbG8="},
+		{"b64", "aGVsbG8", "aGVsbG8="}, // unpadded values are padded
+		{"bin", " AAEC ", "AAEC"},
+		{"b64", "not base64!", "not base64!"},
+	}
+	for _, tt := range tests {
+		if value := NormaliseValue(tt.format, tt.value); value != tt.expected {
+			t.Errorf("NormaliseValue(%q, %q) returned %q, expected %q", tt.format, tt.value, value, tt.expected)
+		}
+	}
+}
+
+// Every normalised value of a valid value must still be valid and normalising must be idempotent
+func TestNormaliseValueIsValid(t *testing.T) {
+	var tests = []struct {
+		format string
+		value  string
+	}{
+		{"int", " 42 "},
+		{"bool", "TRUE"},
+		{"b64", "aGVs bG8"},
+		{"bin", "AAEC\n"},
+	}
+	for _, tt := range tests {
+		var value = NormaliseValue(tt.format, tt.value)
+		if err := ValidateFormat(tt.format, value); err != nil {
+			t.Errorf("the normalised value %q of %q isn't valid %s: %s", value, tt.value, tt.format, err)
+		} else if again := NormaliseValue(tt.format, value); again != value {
+			t.Errorf("normalising %q again returned %q", value, again)
+		}
+	}
+}
+
+func TestValidateURI(t *testing.T) {
+	var tests = []struct {
+		uri   string
+		valid bool
+	}{
+		{"./Device/Vendor/MSFT/Policy/Config/Camera/AllowCamera", true},
+		{"./Vendor/MSFT/Policy/Config/Chrome~Policy~googlechrome/HomepageLocation", true},
+		{"./DevDetail/SwV", true},
+		{"Device/Vendor/MSFT", false},
+		{"./Device/Vendor/", false},
+		{"./Device//MSFT", false},
+		{"./Device/../MSFT", false},
+		{"./Device/Vendor MSFT", false},
+		{"./Device/Vendor?x", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateURI(tt.uri); (err == nil) != tt.valid {
+			t.Errorf("ValidateURI(%q) returned %v, expected valid to be %v", tt.uri, err, tt.valid)
+		}
+	}
+}
@@ -15,9 +15,7 @@ INSERT INTO policies VALUES ('2', 'Student Restrictions');
 INSERT INTO policies_payload VALUES (DEFAULT, '2', './Vendor/MSFT/Policy/Config/Camera/AllowCamera', 'int', DEFAULT, '0');
 INSERT INTO policies_payload VALUES (DEFAULT, '2', './Device/Vendor/MSFT/Policy/Config/Connectivity/AllowBluetooth', 'int', DEFAULT, '0');
 
-INSERT INTO policies VALUES ('3', 'Local Admin Account');
-INSERT INTO policies_payload VALUES (DEFAULT, '3', './Device/Vendor/MSFT/Accounts/Users/mttx/Password', 'chr', DEFAULT, 'password');
+INSERT INTO local_admin_profiles(name, account_name, password_length, rotation_days, rotate_after_view_hours, group_id) VALUES ('Local Admin Account', 'mttx', 20, 30, 24, '1');
 
--- Primitive Application Management. Will be replaced with propper application management in future update!
-INSERT INTO policies VALUES ('4', '[TEMP] Spotify MBES Application');
-INSERT INTO policies_payload VALUES (DEFAULT, '4', './User/Vendor/MSFT/EnterpriseModernAppManagement/AppInstallation/SpotifyAB.SpotifyMusic_zpdnekdrzrea0/StoreInstall', 'xml', 'text/plain', '<Application id="9NCBCSZSJRSB" flags="0" skuid="0016" />', TRUE);
\ No newline at end of file
+INSERT INTO apps(type, name, publisher, identifier, store_id, store_sku, user_context) VALUES ('store', 'Spotify', 'Spotify AB', 'SpotifyAB.SpotifyMusic_zpdnekdrzrea0', '9NCBCSZSJRSB', '0016', TRUE);
+INSERT INTO app_assignments VALUES ((SELECT id FROM apps WHERE identifier = 'SpotifyAB.SpotifyMusic_zpdnekdrzrea0'), '1', 'required');
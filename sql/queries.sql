@@ -16,12 +16,14 @@ SELECT fullname, password, mfa_token, permission_level FROM users WHERE upn = $1
 INSERT INTO users(upn, fullname, password) VALUES ($1, $2, $3);
 
 -- name: NewAzureADUser :one
-INSERT INTO users(upn, fullname, azuread_oid) VALUES($1, $2, $3) RETURNING upn, fullname, azuread_oid, permission_level; -- TODO: Insert or Update
+-- Existing users are only updated if they are AzureAD users so no row is returned for a local user with the same upn
+INSERT INTO users(upn, fullname, azuread_oid) VALUES($1, $2, $3) ON CONFLICT (upn) DO UPDATE SET fullname=$2, azuread_oid=$3 WHERE users.azuread_oid IS NOT NULL RETURNING upn, fullname, azuread_oid, permission_level;
 
 -- TODO: Merge all NewDevice functions to single query
 
 -- name: NewDevice :one
-INSERT INTO devices(udid, state, enrollment_type, name, hw_dev_id, operating_system, azure_did, enrolled_by) VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id;
+-- A device enrolled concurrently with the same udid returns no rows
+INSERT INTO devices(udid, state, enrollment_type, name, hw_dev_id, operating_system, azure_did, enrolled_by) VALUES($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (udid) DO NOTHING RETURNING id;
 
 -- name: NewDeviceReplacingExisting :exec
 UPDATE devices SET state=$2, enrollment_type=$3, name=$4, hw_dev_id=$5, operating_system=$6, azure_did=$7, nodecache_version='', lastseen=NOW(), lastseen_status=0, enrolled_at=NOW(), enrolled_by=$8 WHERE udid = $1;
@@ -30,7 +32,20 @@ UPDATE devices SET state=$2, enrollment_type=$3, name=$4, hw_dev_id=$5, operatin
 DELETE FROM device_cache WHERE device_id=$1;
 
 -- name: NewDeviceReplacingExistingResetInventory :exec
-DELETE FROM device_cache WHERE device_id=$1;
+DELETE FROM device_inventory WHERE device_id=$1;
+
+-- name: NewDeviceReplacingExistingResetCommands :exec
+DELETE FROM device_commands WHERE device_id=$1;
+
+-- name: LockDeviceNames :exec
+-- The lock is held until the transaction ends so concurrent enrollments and renames can't choose the same name
+SELECT pg_advisory_xact_lock(hashtext('device_names'));
+
+-- name: DeviceNameInUse :one
+SELECT EXISTS(SELECT 1 FROM devices WHERE name = $1 AND udid != $2);
+
+-- name: SetDeviceName :exec
+UPDATE devices SET name=$2 WHERE id = $1;
 
 -- name: SetDeviceState :exec
 UPDATE devices SET state=$2 WHERE id = $1;
@@ -56,11 +71,142 @@ SELECT * FROM policies INNER JOIN group_policies ON group_policies.policy_id = p
 
 -- name: GetGroups :many
 -- Exposed via API
-SELECT id, name, description, priority FROM groups LIMIT 100;
+SELECT id, name, description, priority, rules, timezone FROM groups LIMIT 100;
 
 -- name: GetGroup :one
 -- Exposed via API
-SELECT id, name, description, priority FROM groups WHERE id = $1 LIMIT 1;
+SELECT id, name, description, priority, rules, timezone FROM groups WHERE id = $1 LIMIT 1;
+
+-- name: CreateGroup :one
+-- Exposed via API
+INSERT INTO groups(name, description, priority, rules, timezone) VALUES ($1, $2, $3, $4, $5) RETURNING id;
+
+-- name: UpdateGroup :exec
+-- Exposed via API
+UPDATE groups SET name=$2, description=$3, priority=$4, rules=$5, timezone=$6 WHERE id = $1;
+
+-- name: DeleteGroup :exec
+-- Exposed via API
+DELETE FROM groups WHERE id = $1;
+
+-- name: GetDynamicGroups :many
+SELECT id, name, rules FROM groups WHERE rules != '';
+
+-- name: IsDeviceInGroup :one
+SELECT EXISTS(SELECT 1 FROM group_devices INNER JOIN groups ON groups.id=group_devices.group_id WHERE group_devices.device_id = $1 AND groups.name = $2);
+
+-- name: DeleteGroupDevices :exec
+DELETE FROM group_devices WHERE group_id = $1;
+
+-- name: DeleteGroupPolicies :exec
+DELETE FROM group_policies WHERE group_id = $1;
+
+-- name: GetGroupDevices :many
+-- Exposed via API
+SELECT devices.id, devices.name, devices.model FROM devices INNER JOIN group_devices ON group_devices.device_id=devices.id WHERE group_devices.group_id = $1 ORDER BY devices.id;
+
+-- name: AddGroupDevices :exec
+-- Exposed via API. Unknown devices are ignored.
+INSERT INTO group_devices(group_id, device_id) SELECT sqlc.arg(group_id)::integer, devices.id FROM devices WHERE devices.id = ANY(sqlc.arg(device_ids)::integer[]) ON CONFLICT DO NOTHING;
+
+-- name: RemoveGroupDevices :exec
+-- Exposed via API
+DELETE FROM group_devices WHERE group_id = sqlc.arg(group_id) AND device_id = ANY(sqlc.arg(device_ids)::integer[]);
+
+-- name: GetGroupPolicies :many
+-- Exposed via API
+SELECT policies.id, policies.name FROM policies INNER JOIN group_policies ON group_policies.policy_id=policies.id WHERE group_policies.group_id = $1 ORDER BY policies.id;
+
+-- name: AttachGroupPolicy :exec
+-- Exposed via API
+INSERT INTO group_policies(group_id, policy_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: DetachGroupPolicy :exec
+-- Exposed via API
+DELETE FROM group_policies WHERE group_id = $1 AND policy_id = $2;
+
+-- name: GetGroupPolicySchedule :one
+-- Exposed via API
+SELECT not_before FROM group_policies WHERE group_id = $1 AND policy_id = $2 LIMIT 1;
+
+-- name: SetGroupPolicySchedule :exec
+UPDATE group_policies SET not_before=$3 WHERE group_id = $1 AND policy_id = $2;
+
+-- name: GetGroupPolicyWindows :many
+-- Exposed via API
+SELECT weekday, start_minute, end_minute FROM group_policy_windows WHERE group_id = $1 AND policy_id = $2 ORDER BY weekday, start_minute;
+
+-- name: CreateGroupPolicyWindow :exec
+INSERT INTO group_policy_windows(group_id, policy_id, weekday, start_minute, end_minute) VALUES ($1, $2, $3, $4, $5);
+
+-- name: DeleteGroupPolicyWindows :exec
+DELETE FROM group_policy_windows WHERE group_id = $1 AND policy_id = $2;
+
+-- name: GetDevicesAssignmentSchedules :many
+-- The schedules of the device's group policies which aren't always deployed
+SELECT group_policies.group_id, group_policies.policy_id, groups.timezone, group_policies.not_before FROM group_devices INNER JOIN groups ON groups.id=group_devices.group_id INNER JOIN group_policies ON group_policies.group_id=groups.id WHERE group_devices.device_id = $1 AND (group_policies.not_before IS NOT NULL OR EXISTS (SELECT 1 FROM group_policy_windows WHERE group_policy_windows.group_id=group_policies.group_id AND group_policy_windows.policy_id=group_policies.policy_id));
+
+-- name: GetDevicesAssignmentWindows :many
+SELECT group_policy_windows.group_id, group_policy_windows.policy_id, group_policy_windows.weekday, group_policy_windows.start_minute, group_policy_windows.end_minute FROM group_policy_windows INNER JOIN group_devices ON group_devices.group_id=group_policy_windows.group_id WHERE group_devices.device_id = $1;
+
+-- name: GetAllDevices :many
+SELECT * FROM devices;
+
+-- name: GetDeviceInventory :many
+SELECT uri, format, value FROM device_inventory WHERE device_id = $1;
+
+-- name: GetUserGroups :many
+-- Exposed via API
+SELECT id, name, description, priority FROM user_groups LIMIT 100;
+
+-- name: GetUserGroup :one
+-- Exposed via API
+SELECT id, name, description, priority FROM user_groups WHERE id = $1 LIMIT 1;
+
+-- name: CreateUserGroup :one
+-- Exposed via API
+INSERT INTO user_groups(name, description, priority) VALUES ($1, $2, $3) RETURNING id;
+
+-- name: UpdateUserGroup :exec
+-- Exposed via API
+UPDATE user_groups SET name=$2, description=$3, priority=$4 WHERE id = $1;
+
+-- name: DeleteUserGroup :exec
+-- Exposed via API
+DELETE FROM user_groups WHERE id = $1;
+
+-- name: DeleteUserGroupMembers :exec
+DELETE FROM user_group_members WHERE group_id = $1;
+
+-- name: DeleteUserGroupPolicies :exec
+DELETE FROM user_group_policies WHERE group_id = $1;
+
+-- name: GetUserGroupMembers :many
+-- Exposed via API
+SELECT users.upn, users.fullname FROM users INNER JOIN user_group_members ON user_group_members.upn=users.upn WHERE user_group_members.group_id = $1 ORDER BY users.upn;
+
+-- name: AddUserGroupMembers :exec
+-- Exposed via API. Unknown users are ignored.
+INSERT INTO user_group_members(group_id, upn) SELECT sqlc.arg(group_id)::integer, users.upn FROM users WHERE users.upn = ANY(sqlc.arg(upns)::text[]) ON CONFLICT DO NOTHING;
+
+-- name: RemoveUserGroupMembers :exec
+-- Exposed via API
+DELETE FROM user_group_members WHERE group_id = sqlc.arg(group_id) AND upn = ANY(sqlc.arg(upns)::text[]);
+
+-- name: IsUserInGroup :one
+SELECT EXISTS(SELECT 1 FROM user_group_members INNER JOIN user_groups ON user_groups.id=user_group_members.group_id WHERE user_group_members.upn = $1 AND user_groups.name = $2);
+
+-- name: GetUserGroupPolicies :many
+-- Exposed via API
+SELECT policies.id, policies.name FROM policies INNER JOIN user_group_policies ON user_group_policies.policy_id=policies.id WHERE user_group_policies.group_id = $1 ORDER BY policies.id;
+
+-- name: AttachUserGroupPolicy :exec
+-- Exposed via API
+INSERT INTO user_group_policies(group_id, policy_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: DetachUserGroupPolicy :exec
+-- Exposed via API
+DELETE FROM user_group_policies WHERE group_id = $1 AND policy_id = $2;
 
 -- name: GetDevice :one
 SELECT * FROM devices WHERE id = $1 LIMIT 1;
@@ -68,26 +214,61 @@ SELECT * FROM devices WHERE id = $1 LIMIT 1;
 -- name: GetDeviceByUDID :one
 SELECT * FROM devices WHERE udid = $1 LIMIT 1;
 
+-- name: GetDeviceByUDIDForUpdate :one
+SELECT * FROM devices WHERE udid = $1 LIMIT 1 FOR UPDATE;
+
 -- name: DeviceCheckinStatus :exec
 UPDATE devices SET lastseen=NOW(), lastseen_status=$2 WHERE id = $1; -- TODO: Merge this with last checkin status
 
 -- name: GetDevicesPayloads :many
-SELECT policies_payload.* FROM group_devices INNER JOIN group_policies ON group_policies.group_id=group_devices.group_id INNER JOIN policies_payload ON policies_payload.policy_id=group_policies.policy_id WHERE group_devices.device_id = $1;
+SELECT DISTINCT policies_payload.* FROM group_devices INNER JOIN group_policies ON group_policies.group_id=group_devices.group_id INNER JOIN policies_payload ON policies_payload.policy_id=group_policies.policy_id WHERE group_devices.device_id = $1;
+
+-- name: GetDevicesPayloadCandidates :many
+SELECT policies_payload.id, policies_payload.uri, policies_payload.format, policies_payload.type, policies_payload.value, policies_payload.exec, policies.id AS policy_id, policies.name AS policy_name, policies.priority AS policy_priority, groups.id AS group_id, groups.name AS group_name, groups.priority AS group_priority FROM group_devices INNER JOIN groups ON groups.id=group_devices.group_id INNER JOIN group_policies ON group_policies.group_id=groups.id INNER JOIN policies ON policies.id=group_policies.policy_id INNER JOIN policies_payload ON policies_payload.policy_id=policies.id WHERE group_devices.device_id = $1;
+
+-- name: GetUsersPayloadCandidates :many
+SELECT policies_payload.id, policies_payload.uri, policies_payload.format, policies_payload.type, policies_payload.value, policies_payload.exec, policies.id AS policy_id, policies.name AS policy_name, policies.priority AS policy_priority, user_groups.id AS group_id, user_groups.name AS group_name, user_groups.priority AS group_priority FROM user_group_members INNER JOIN user_groups ON user_groups.id=user_group_members.group_id INNER JOIN user_group_policies ON user_group_policies.group_id=user_groups.id INNER JOIN policies ON policies.id=user_group_policies.policy_id INNER JOIN policies_payload ON policies_payload.policy_id=policies.id WHERE user_group_members.upn = $1;
+
+-- name: GetGroupsPayloadCandidates :many
+-- Used to preview the effective payloads of a device with a different group membership
+SELECT policies_payload.id, policies_payload.uri, policies_payload.format, policies_payload.type, policies_payload.value, policies_payload.exec, policies.id AS policy_id, policies.name AS policy_name, policies.priority AS policy_priority, groups.id AS group_id, groups.name AS group_name, groups.priority AS group_priority FROM groups INNER JOIN group_policies ON group_policies.group_id=groups.id INNER JOIN policies ON policies.id=group_policies.policy_id INNER JOIN policies_payload ON policies_payload.policy_id=policies.id WHERE groups.id = ANY(sqlc.arg(group_ids)::integer[]);
 
--- name: GetDevicesPayloadsAwaitingDeployment :many
-SELECT id, uri, format, type, value, exec FROM group_devices INNER JOIN group_policies ON group_policies.group_id=group_devices.group_id INNER JOIN policies_payload ON policies_payload.policy_id=group_policies.policy_id WHERE group_devices.device_id = $1 AND NOT EXISTS (SELECT 1 FROM device_cache WHERE device_cache.payload_id = policies_payload.id AND device_cache.device_id=group_devices.device_id);
+-- name: GetDevicesRolloutCandidates :many
+-- Devices are in a rollout once a stage up to the current one includes their ring group or their (stable) percentage bucket.
+-- Paused rollouts only keep the payloads the device has already received so they aren't deployed any further.
+SELECT policies_payload.id, policies_payload.uri, policies_payload.format, policies_payload.type, policies_payload.value, policies_payload.exec, policies.id AS policy_id, policies.name AS policy_name, policies.priority AS policy_priority, groups.id AS group_id, groups.name AS group_name, groups.priority AS group_priority FROM rollouts INNER JOIN group_devices ON group_devices.group_id=rollouts.group_id INNER JOIN groups ON groups.id=rollouts.group_id INNER JOIN policies ON policies.id=rollouts.policy_id INNER JOIN policies_payload ON policies_payload.policy_id=policies.id WHERE group_devices.device_id = $1 AND (
+    rollouts.state = 'active'
+    OR (rollouts.state = 'paused' AND EXISTS (SELECT 1 FROM device_cache WHERE device_cache.device_id=group_devices.device_id AND device_cache.payload_id=policies_payload.id AND device_cache.upn IS NULL))
+) AND (
+    EXISTS (SELECT 1 FROM rollout_stages INNER JOIN group_devices AS ring_devices ON ring_devices.group_id=rollout_stages.ring_group_id WHERE rollout_stages.rollout_id=rollouts.id AND rollout_stages.stage <= rollouts.stage AND ring_devices.device_id=group_devices.device_id)
+    OR ('x' || substr(md5(rollouts.id || '-' || group_devices.device_id), 1, 7))::bit(28)::integer % 100 < (SELECT COALESCE(MAX(rollout_stages.percentage), 0) FROM rollout_stages WHERE rollout_stages.rollout_id=rollouts.id AND rollout_stages.stage <= rollouts.stage)
+);
 
--- name: GetDevicesDetachedPayloads :many
-SELECT id, uri, exec FROM device_cache INNER JOIN policies_payload ON policies_payload.id=device_cache.payload_id WHERE device_cache.device_id = $1 AND NOT EXISTS (SELECT policies_payload.* FROM group_devices INNER JOIN group_policies ON group_policies.group_id=group_devices.group_id INNER JOIN policies_payload ON policies_payload.policy_id=group_policies.policy_id WHERE group_devices.device_id = device_cache.device_id);
+-- name: GetDeployedPayloads :many
+SELECT policies_payload.id, policies_payload.uri, policies_payload.exec, device_cache.policy_version FROM device_cache INNER JOIN policies_payload ON policies_payload.id=device_cache.payload_id WHERE device_cache.device_id = $1 AND device_cache.upn IS NOT DISTINCT FROM $2;
 
 -- name: NewDeviceCacheNode :one
-INSERT INTO device_cache(device_id, payload_id) VALUES ($1, $2) RETURNING cache_id;
+INSERT INTO device_cache(device_id, payload_id, upn) VALUES ($1, $2, $3) RETURNING cache_id;
 
 -- name: DeleteDeviceCacheNode :exec
-DELETE FROM device_cache WHERE device_id = $1 AND payload_id = $2;
+DELETE FROM device_cache WHERE device_id = $1 AND payload_id = $2 AND upn IS NOT DISTINCT FROM $3;
+
+-- name: UpdateDeployedPolicyVersion :exec
+-- Called when the device returns a successful status for a payload to record that it has the latest version of the payload's policy.
+-- The policy's other payloads which the device has confirmed are also updated as changed payloads are redeployed, so they are awaiting their status with version 0.
+UPDATE device_cache SET policy_version=policies.version FROM policies_payload, policies WHERE device_cache.payload_id=policies_payload.id AND policies.id=policies_payload.policy_id AND device_cache.device_id = $1 AND (device_cache.payload_id = $2 OR device_cache.policy_version != 0) AND device_cache.upn IS NOT DISTINCT FROM $3 AND policies_payload.policy_id = (SELECT policy_id FROM policies_payload AS deployed WHERE deployed.id = $2);
 
 -- name: UpdateDeviceInventoryNode :exec
-INSERT INTO device_inventory(device_id, uri, format, value) VALUES ($1, $2, $3, $4); -- TODO: Update or Replace
+INSERT INTO device_inventory(device_id, uri, format, value) VALUES ($1, $2, $3, $4) ON CONFLICT (device_id, uri) DO UPDATE SET format=$3, value=$4;
+
+-- name: NewDeviceCommand :exec
+INSERT INTO device_commands(device_id, command, uri, format, value) VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetDevicesPendingCommands :many
+SELECT id, command, uri, format, type, value FROM device_commands WHERE device_id = $1 AND sent_at IS NULL ORDER BY id;
+
+-- name: DeviceCommandSent :exec
+UPDATE device_commands SET sent_at=NOW() WHERE id = $1;
 
 -- name: GetPolicies :many
 -- Exposed via API
@@ -95,14 +276,469 @@ SELECT id, name FROM policies LIMIT 100;
 
 -- name: GetPolicy :one
 -- Exposed via API
-SELECT id, name, description, priority FROM policies WHERE id = $1 LIMIT 1;
+SELECT id, name, description, priority, version FROM policies WHERE id = $1 LIMIT 1;
+
+-- name: CreatePolicy :one
+-- Exposed via API
+INSERT INTO policies(name, description, priority) VALUES ($1, $2, $3) RETURNING id;
+
+-- name: UpdatePolicy :exec
+-- Exposed via API
+UPDATE policies SET name=$2, description=$3, priority=$4 WHERE id = $1;
+
+-- name: DeletePolicy :exec
+-- Exposed via API
+DELETE FROM policies WHERE id = $1;
+
+-- name: DeletePolicyGroups :exec
+DELETE FROM group_policies WHERE policy_id = $1;
+
+-- name: DeletePolicyUserGroups :exec
+DELETE FROM user_group_policies WHERE policy_id = $1;
+
+-- name: IncrementPolicyVersion :one
+UPDATE policies SET version=version+1 WHERE id = $1 RETURNING version;
+
+-- name: CreatePolicyVersion :exec
+INSERT INTO policy_versions(policy_id, version, author, name, description, priority, payloads) VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: GetPolicyVersions :many
+-- Exposed via API
+SELECT policy_id, version, author, created_at FROM policy_versions WHERE policy_id = $1 ORDER BY version DESC LIMIT 100;
+
+-- name: GetPolicyVersion :one
+-- Exposed via API
+SELECT * FROM policy_versions WHERE policy_id = $1 AND version = $2 LIMIT 1;
 
 -- name: GetPoliciesPayloads :many
-SELECT * FROM policies_payload WHERE policy_id = $1;
+-- Exposed via API
+SELECT * FROM policies_payload WHERE policy_id = $1 ORDER BY id;
+
+-- name: GetPolicyPayload :one
+-- Exposed via API
+SELECT * FROM policies_payload WHERE id = $1 AND policy_id = $2 LIMIT 1;
+
+-- name: CreatePolicyPayload :one
+-- Exposed via API
+INSERT INTO policies_payload(policy_id, uri, format, type, value, exec) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
+
+-- name: UpdatePolicyPayload :exec
+-- Exposed via API
+UPDATE policies_payload SET format=$2, type=$3, value=$4, exec=$5 WHERE id = $1;
+
+-- name: DetachPolicyPayload :exec
+-- Detached payloads are removed from devices on their next checkin and then deleted
+UPDATE policies_payload SET policy_id=NULL WHERE id = $1;
+
+-- name: DetachPoliciesPayloads :exec
+UPDATE policies_payload SET policy_id=NULL WHERE policy_id = $1;
+
+-- name: DeleteOrphanedPayloads :exec
+DELETE FROM policies_payload WHERE policy_id IS NULL AND NOT EXISTS (SELECT 1 FROM device_cache WHERE device_cache.payload_id = policies_payload.id);
+
+-- name: InvalidatePayloadCache :exec
+DELETE FROM device_cache WHERE payload_id = $1;
+
+-- name: GetRollouts :many
+-- Exposed via API
+SELECT * FROM rollouts ORDER BY id DESC LIMIT 100;
+
+-- name: GetRollout :one
+-- Exposed via API
+SELECT * FROM rollouts WHERE id = $1 LIMIT 1;
+
+-- name: GetRolloutStages :many
+-- Exposed via API
+SELECT stage, percentage, ring_group_id FROM rollout_stages WHERE rollout_id = $1 ORDER BY stage;
+
+-- name: GetRolloutResults :many
+-- Exposed via API
+SELECT stage, COUNT(*) AS reported, COUNT(*) FILTER (WHERE failed) AS failed FROM rollout_results WHERE rollout_id = $1 GROUP BY stage ORDER BY stage;
+
+-- name: GetRolloutStageResults :one
+SELECT COUNT(*) AS reported, COUNT(*) FILTER (WHERE failed) AS failed FROM rollout_results WHERE rollout_id = $1 AND stage = $2;
+
+-- name: GetUnfinishedRollout :one
+SELECT id FROM rollouts WHERE policy_id = $1 AND group_id = $2 AND state IN ('active', 'paused') LIMIT 1;
+
+-- name: CreateRollout :one
+-- Exposed via API
+INSERT INTO rollouts(policy_id, group_id, failure_threshold, success_threshold) VALUES ($1, $2, $3, $4) RETURNING id;
+
+-- name: CreateRolloutStage :exec
+INSERT INTO rollout_stages(rollout_id, stage, percentage, ring_group_id) VALUES ($1, $2, $3, $4);
+
+-- name: SetRolloutState :exec
+UPDATE rollouts SET state=$2 WHERE id = $1;
+
+-- name: PromoteRollout :exec
+UPDATE rollouts SET stage=stage+1, state='active' WHERE id = $1;
+
+-- name: DeletePolicyRollouts :exec
+DELETE FROM rollouts WHERE policy_id = $1;
+
+-- name: DeleteGroupRollouts :exec
+DELETE FROM rollouts WHERE group_id = $1 OR id IN (SELECT rollout_id FROM rollout_stages WHERE ring_group_id = $1);
+
+-- name: GetPayloadsRollouts :many
+-- The unfinished rollouts which deployed the payload to the device. The device must be included in a stage up to the current one (see GetDevicesRolloutCandidates).
+-- The included stage is the first stage which included the device so its results stay with that stage after the rollout is promoted.
+SELECT rollouts.id, rollouts.stage, rollouts.state, rollouts.failure_threshold, (SELECT MIN(rollout_stages.stage) FROM rollout_stages WHERE rollout_stages.rollout_id=rollouts.id AND rollout_stages.stage <= rollouts.stage AND (
+    EXISTS (SELECT 1 FROM group_devices AS ring_devices WHERE ring_devices.group_id=rollout_stages.ring_group_id AND ring_devices.device_id=group_devices.device_id)
+    OR ('x' || substr(md5(rollouts.id || '-' || group_devices.device_id), 1, 7))::bit(28)::integer % 100 < rollout_stages.percentage
+))::smallint AS included_stage FROM rollouts INNER JOIN policies_payload ON policies_payload.policy_id=rollouts.policy_id INNER JOIN group_devices ON group_devices.group_id=rollouts.group_id WHERE policies_payload.id = $1 AND group_devices.device_id = $2 AND rollouts.state IN ('active', 'paused') AND (
+    EXISTS (SELECT 1 FROM rollout_stages INNER JOIN group_devices AS ring_devices ON ring_devices.group_id=rollout_stages.ring_group_id WHERE rollout_stages.rollout_id=rollouts.id AND rollout_stages.stage <= rollouts.stage AND ring_devices.device_id=group_devices.device_id)
+    OR ('x' || substr(md5(rollouts.id || '-' || group_devices.device_id), 1, 7))::bit(28)::integer % 100 < (SELECT COALESCE(MAX(rollout_stages.percentage), 0) FROM rollout_stages WHERE rollout_stages.rollout_id=rollouts.id AND rollout_stages.stage <= rollouts.stage)
+);
+
+-- name: RecordRolloutResult :exec
+-- A device which failed any of the policies payloads remains failed for the stage
+INSERT INTO rollout_results(rollout_id, device_id, stage, status, failed) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (rollout_id, device_id, stage) DO UPDATE SET status=CASE WHEN rollout_results.failed THEN rollout_results.status ELSE EXCLUDED.status END, failed=rollout_results.failed OR EXCLUDED.failed, updated_at=NOW();
+
+-- name: GetApps :many
+-- Exposed via API
+SELECT * FROM apps ORDER BY name;
+
+-- name: GetApp :one
+-- Exposed via API
+SELECT * FROM apps WHERE id = $1 LIMIT 1;
+
+-- name: GetAppByIdentifier :one
+SELECT * FROM apps WHERE type = $1 AND identifier = $2 LIMIT 1;
+
+-- name: GetAppByHash :one
+SELECT * FROM apps WHERE file_hash = $1 AND file_hash != '' LIMIT 1;
+
+-- name: CreateApp :one
+INSERT INTO apps(type, name, publisher, identifier, version, file_hash, file_size, file_name, command_line, store_id, store_sku, user_context) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id;
+
+-- name: DeleteApp :exec
+DELETE FROM apps WHERE id = $1;
+
+-- name: GetAppAssignments :many
+-- Exposed via API
+SELECT app_assignments.group_id, groups.name, app_assignments.intent FROM app_assignments INNER JOIN groups ON groups.id = app_assignments.group_id WHERE app_assignments.app_id = $1 ORDER BY groups.name;
+
+-- name: SetAppAssignment :exec
+INSERT INTO app_assignments(app_id, group_id, intent) VALUES ($1, $2, $3) ON CONFLICT (app_id, group_id) DO UPDATE SET intent=EXCLUDED.intent;
+
+-- name: DeleteAppAssignment :exec
+DELETE FROM app_assignments WHERE app_id = $1 AND group_id = $2;
+
+-- name: DeleteGroupAppAssignments :exec
+DELETE FROM app_assignments WHERE group_id = $1;
+
+-- name: GetDeviceAppAssignments :many
+-- The apps assigned to the groups the device is in with their install state. An app assigned to multiple groups is returned once for each intent.
+SELECT DISTINCT apps.id, apps.type, apps.name, apps.identifier, apps.version, apps.file_hash, apps.file_name, apps.command_line, apps.store_id, apps.store_sku, apps.user_context, app_assignments.intent, COALESCE(app_installs.state::text, '')::text AS install_state, COALESCE(app_installs.updated_at, NOW())::timestamptz AS install_updated_at, EXISTS(SELECT 1 FROM device_apps WHERE device_apps.device_id = group_devices.device_id AND device_apps.package_family_name = apps.identifier)::boolean AS in_inventory FROM app_assignments INNER JOIN group_devices ON group_devices.group_id = app_assignments.group_id INNER JOIN apps ON apps.id = app_assignments.app_id LEFT JOIN app_installs ON app_installs.app_id = apps.id AND app_installs.device_id = group_devices.device_id WHERE group_devices.device_id = $1 ORDER BY apps.id;
+
+-- name: GetAppInstalls :many
+-- Exposed via API
+SELECT app_installs.device_id, devices.name, app_installs.state, app_installs.status, app_installs.updated_at FROM app_installs INNER JOIN devices ON devices.id = app_installs.device_id WHERE app_installs.app_id = $1 ORDER BY app_installs.updated_at DESC;
+
+-- name: GetDeviceAppInstalls :many
+-- Exposed via API
+SELECT app_installs.app_id, apps.name, apps.version, app_installs.state, app_installs.status, app_installs.updated_at FROM app_installs INNER JOIN apps ON apps.id = app_installs.app_id WHERE app_installs.device_id = $1 ORDER BY apps.name;
+
+-- name: SetAppInstallState :exec
+INSERT INTO app_installs(app_id, device_id, state, status) VALUES ($1, $2, $3, $4) ON CONFLICT (app_id, device_id) DO UPDATE SET state=EXCLUDED.state, status=EXCLUDED.status, updated_at=NOW();
+
+-- name: InstalledDeviceApps :exec
+-- Marks the modern apps being installed on the device which it reported in its inventory as installed
+UPDATE app_installs SET state='installed', status='', updated_at=NOW() FROM apps WHERE apps.id = app_installs.app_id AND app_installs.device_id = sqlc.arg(device_id) AND app_installs.state IN ('installing', 'failed') AND apps.type IN ('store', 'appx') AND apps.identifier = ANY(sqlc.arg(package_family_names)::text[]);
+
+-- name: GetDeviceInventoryApps :many
+-- Exposed via API
+SELECT package_family_name, source, upn, updated_at FROM device_apps WHERE device_id = $1 ORDER BY package_family_name, upn;
+
+-- name: ClearDeviceInventoryApps :exec
+DELETE FROM device_apps WHERE device_id = $1 AND source = $2 AND upn = $3;
+
+-- name: AddDeviceInventoryApp :exec
+INSERT INTO device_apps(device_id, package_family_name, source, upn) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING;
+
+-- name: GetScripts :many
+-- Exposed via API
+SELECT id, name, description, run_context, schedule, group_id, version, created_at, updated_at FROM scripts ORDER BY name;
+
+-- name: GetScript :one
+-- Exposed via API
+SELECT * FROM scripts WHERE id = $1 LIMIT 1;
+
+-- name: CreateScript :one
+INSERT INTO scripts(name, description, content, run_context, schedule, group_id) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
+
+-- name: UpdateScript :exec
+-- The version is incremented when the content changes
+UPDATE scripts SET name=$2, description=$3, version=CASE WHEN content = $4 THEN version ELSE version + 1 END, content=$4, run_context=$5, schedule=$6, group_id=$7, updated_at=NOW() WHERE id = $1;
+
+-- name: DeleteScript :exec
+DELETE FROM scripts WHERE id = $1;
+
+-- name: DeleteGroupScripts :exec
+DELETE FROM scripts WHERE group_id = $1;
+
+-- name: CountDeviceScripts :one
+SELECT COUNT(*) FROM scripts INNER JOIN group_devices ON group_devices.group_id = scripts.group_id WHERE group_devices.device_id = $1;
+
+-- name: GetDeviceScripts :many
+-- The scripts assigned to the groups the device is in with the version and time of their last run on the device
+SELECT DISTINCT scripts.id, scripts.name, scripts.content, scripts.run_context, scripts.schedule, scripts.version, COALESCE(script_runs.version, 0)::integer AS run_version, COALESCE(script_runs.finished_at, to_timestamp(0))::timestamptz AS run_finished_at FROM scripts INNER JOIN group_devices ON group_devices.group_id = scripts.group_id LEFT JOIN script_runs ON script_runs.script_id = scripts.id AND script_runs.device_id = group_devices.device_id WHERE group_devices.device_id = $1 ORDER BY scripts.id;
+
+-- name: RecordScriptRun :exec
+INSERT INTO script_runs(script_id, device_id, version, exit_code, stdout, stderr) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (script_id, device_id) DO UPDATE SET version=EXCLUDED.version, exit_code=EXCLUDED.exit_code, stdout=EXCLUDED.stdout, stderr=EXCLUDED.stderr, finished_at=NOW();
+
+-- name: GetScriptRuns :many
+-- Exposed via API
+SELECT script_runs.device_id, devices.name, script_runs.version, script_runs.exit_code, script_runs.stdout, script_runs.stderr, script_runs.finished_at FROM script_runs INNER JOIN devices ON devices.id = script_runs.device_id WHERE script_runs.script_id = sqlc.arg(script_id) AND (NOT sqlc.arg(failed)::boolean OR script_runs.exit_code != 0) ORDER BY script_runs.finished_at DESC;
+
+-- name: GetDeviceScriptRuns :many
+-- Exposed via API
+SELECT script_runs.script_id, scripts.name, script_runs.version, script_runs.exit_code, script_runs.stdout, script_runs.stderr, script_runs.finished_at FROM script_runs INNER JOIN scripts ON scripts.id = script_runs.script_id WHERE script_runs.device_id = $1 ORDER BY scripts.name;
+
+-- name: GetFailedScriptRuns :many
+-- Exposed via API
+SELECT script_runs.script_id, scripts.name AS script_name, script_runs.device_id, devices.name AS device_name, script_runs.version, script_runs.exit_code, script_runs.stderr, script_runs.finished_at FROM script_runs INNER JOIN scripts ON scripts.id = script_runs.script_id INNER JOIN devices ON devices.id = script_runs.device_id WHERE script_runs.exit_code != 0 ORDER BY script_runs.finished_at DESC;
+
+-- name: GetCertificateProfiles :many
+-- Exposed via API
+SELECT * FROM certificate_profiles ORDER BY name;
+
+-- name: GetCertificateProfile :one
+-- Exposed via API
+SELECT * FROM certificate_profiles WHERE id = $1 LIMIT 1;
+
+-- name: CreateCertificateProfile :one
+INSERT INTO certificate_profiles(name, description, subject_template, key_usages, extended_key_usages, key_length, validity_days, renewal_threshold, user_context, group_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id;
+
+-- name: UpdateCertificateProfile :exec
+UPDATE certificate_profiles SET name=$2, description=$3, subject_template=$4, key_usages=$5, extended_key_usages=$6, key_length=$7, validity_days=$8, renewal_threshold=$9, user_context=$10, group_id=$11, updated_at=NOW() WHERE id = $1;
+
+-- name: DeleteCertificateProfile :exec
+DELETE FROM certificate_profiles WHERE id = $1;
+
+-- name: DeleteGroupCertificateProfiles :exec
+DELETE FROM certificate_profiles WHERE group_id = $1;
+
+-- name: GetDeviceCertificateProfiles :many
+-- The certificate profiles assigned to the groups the device is in
+SELECT DISTINCT certificate_profiles.* FROM certificate_profiles INNER JOIN group_devices ON group_devices.group_id = certificate_profiles.group_id WHERE group_devices.device_id = $1 ORDER BY certificate_profiles.id;
+
+-- name: GetCertificateChallenge :one
+SELECT * FROM certificate_challenges WHERE device_id = $1 AND profile_id = $2 AND upn = $3 LIMIT 1;
+
+-- name: ClaimCertificateChallenge :one
+-- The challenge is deleted as it is retrieved so concurrent requests can't both use it
+DELETE FROM certificate_challenges WHERE challenge_hash = $1 RETURNING *;
+
+-- name: SetCertificateChallenge :exec
+INSERT INTO certificate_challenges(device_id, profile_id, upn, challenge_hash, subject) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (device_id, profile_id, upn) DO UPDATE SET challenge_hash=EXCLUDED.challenge_hash, subject=EXCLUDED.subject, created_at=NOW();
+
+-- name: GetLatestIssuedCertificate :one
+-- The certificate most recently issued to the device (or user on the device) from the profile, including revoked certificates
+SELECT * FROM issued_certificates WHERE device_id = $1 AND profile_id = $2 AND upn = $3 ORDER BY issued_at DESC LIMIT 1;
+
+-- name: CreateIssuedCertificate :exec
+INSERT INTO issued_certificates(serial, device_id, profile_id, upn, subject, thumbprint, not_before, not_after) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: GetIssuedCertificate :one
+-- Exposed via API
+SELECT * FROM issued_certificates WHERE serial = $1 LIMIT 1;
+
+-- name: GetProfileIssuedCertificates :many
+-- Exposed via API
+SELECT issued_certificates.*, devices.name AS device_name FROM issued_certificates INNER JOIN devices ON devices.id = issued_certificates.device_id WHERE issued_certificates.profile_id = $1 ORDER BY issued_certificates.issued_at DESC;
+
+-- name: GetDeviceIssuedCertificates :many
+-- Exposed via API
+SELECT * FROM issued_certificates WHERE device_id = $1 ORDER BY issued_at DESC;
+
+-- name: GetExpiringIssuedCertificates :many
+-- Exposed via API. The unrevoked certificates which expire before the time and haven't been replaced by a newer certificate.
+SELECT issued_certificates.*, devices.name AS device_name FROM issued_certificates INNER JOIN devices ON devices.id = issued_certificates.device_id WHERE issued_certificates.revoked_at IS NULL AND issued_certificates.not_after > NOW() AND issued_certificates.not_after < $1 AND NOT EXISTS (SELECT 1 FROM issued_certificates newer WHERE newer.device_id = issued_certificates.device_id AND newer.profile_id = issued_certificates.profile_id AND newer.upn = issued_certificates.upn AND newer.issued_at > issued_certificates.issued_at) ORDER BY issued_certificates.not_after;
+
+-- name: RevokeIssuedCertificate :exec
+UPDATE issued_certificates SET revoked_at=NOW() WHERE serial = $1 AND revoked_at IS NULL;
+
+-- name: GetRevokedCertificates :many
+-- The revoked certificates which haven't expired. They are published in the SCEP CA's CRL.
+SELECT serial, revoked_at FROM issued_certificates WHERE revoked_at IS NOT NULL AND not_after > NOW() ORDER BY revoked_at;
+
+-- name: GetDeviceBitLockerRecoveryKeys :many
+-- Exposed via API. The recovery passwords are not returned.
+SELECT id, device_id, protector_id, volume, escrowed_at, removed_at FROM bitlocker_recovery_keys WHERE device_id = $1 ORDER BY removed_at DESC NULLS FIRST, escrowed_at DESC;
+
+-- name: GetDeviceBitLockerRecoveryPasswords :many
+SELECT * FROM bitlocker_recovery_keys WHERE device_id = $1;
+
+-- name: GetBitLockerRecoveryKeys :many
+SELECT * FROM bitlocker_recovery_keys WHERE device_id = $1 AND protector_id = $2 ORDER BY escrowed_at DESC;
+
+-- name: CreateBitLockerRecoveryKey :exec
+INSERT INTO bitlocker_recovery_keys(device_id, protector_id, volume, encrypted_password) VALUES ($1, $2, $3, $4);
+
+-- name: RemoveBitLockerRecoveryKey :exec
+UPDATE bitlocker_recovery_keys SET removed_at=NOW() WHERE device_id = $1 AND protector_id = $2 AND removed_at IS NULL;
+
+-- name: RestoreBitLockerRecoveryKey :exec
+UPDATE bitlocker_recovery_keys SET removed_at=NULL WHERE device_id = $1 AND protector_id = $2;
+
+-- name: GetBitLockerRotation :one
+-- Exposed via API
+SELECT * FROM bitlocker_rotations WHERE device_id = $1 LIMIT 1;
+
+-- name: RequestBitLockerRotation :exec
+INSERT INTO bitlocker_rotations(device_id, requested_by) VALUES ($1, $2) ON CONFLICT (device_id) DO UPDATE SET requested_by=EXCLUDED.requested_by, requested_at=NOW();
+
+-- name: DeleteBitLockerRotation :exec
+DELETE FROM bitlocker_rotations WHERE device_id = $1;
+
+-- name: LogBitLockerRecoveryKeyAccess :exec
+INSERT INTO bitlocker_recovery_key_access(device_id, protector_id, upn, reason) VALUES ($1, $2, $3, $4);
+
+-- name: GetBitLockerRecoveryKeyAccess :many
+-- Exposed via API
+SELECT * FROM bitlocker_recovery_key_access WHERE device_id = $1 ORDER BY accessed_at DESC LIMIT 100;
+
+-- name: GetLocalAdminProfiles :many
+-- Exposed via API
+SELECT * FROM local_admin_profiles ORDER BY name;
+
+-- name: GetLocalAdminProfile :one
+-- Exposed via API
+SELECT * FROM local_admin_profiles WHERE id = $1 LIMIT 1;
+
+-- name: CreateLocalAdminProfile :one
+INSERT INTO local_admin_profiles(name, description, account_name, password_length, rotation_days, rotate_after_view_hours, group_id) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;
+
+-- name: UpdateLocalAdminProfile :exec
+UPDATE local_admin_profiles SET name=$2, description=$3, password_length=$4, rotation_days=$5, rotate_after_view_hours=$6, group_id=$7, updated_at=NOW() WHERE id = $1;
+
+-- name: DeleteLocalAdminProfile :exec
+DELETE FROM local_admin_profiles WHERE id = $1;
+
+-- name: DeleteGroupLocalAdminProfiles :exec
+DELETE FROM local_admin_profiles WHERE group_id = $1;
+
+-- name: GetDeviceLocalAdminProfiles :many
+-- The local admin profiles assigned to the groups the device is in
+SELECT DISTINCT local_admin_profiles.* FROM local_admin_profiles INNER JOIN group_devices ON group_devices.group_id = local_admin_profiles.group_id WHERE group_devices.device_id = $1 ORDER BY local_admin_profiles.id;
+
+-- name: GetLocalAdminPassword :one
+SELECT * FROM local_admin_passwords WHERE device_id = $1 AND profile_id = $2 LIMIT 1;
+
+-- name: GetDeviceLocalAdminPasswords :many
+-- Exposed via API. The passwords are not returned.
+SELECT local_admin_passwords.profile_id, local_admin_profiles.name AS profile_name, local_admin_profiles.account_name, local_admin_passwords.set_at, local_admin_passwords.pending_since, local_admin_passwords.rotate_at, local_admin_passwords.last_error FROM local_admin_passwords INNER JOIN local_admin_profiles ON local_admin_profiles.id = local_admin_passwords.profile_id WHERE local_admin_passwords.device_id = $1 ORDER BY local_admin_passwords.profile_id;
+
+-- name: SetLocalAdminPendingPassword :exec
+INSERT INTO local_admin_passwords(device_id, profile_id, pending_encrypted_password, pending_since) VALUES ($1, $2, $3, NOW()) ON CONFLICT (device_id, profile_id) DO UPDATE SET pending_encrypted_password=EXCLUDED.pending_encrypted_password, pending_since=NOW();
+
+-- name: ConfirmLocalAdminPassword :exec
+-- The pending password is set on the device so it replaces the current password
+UPDATE local_admin_passwords SET encrypted_password=pending_encrypted_password, set_at=NOW(), pending_encrypted_password=NULL, pending_since=NULL, rotate_at=NULL, last_error='' WHERE device_id = $1 AND profile_id = $2 AND pending_encrypted_password IS NOT NULL;
+
+-- name: LocalAdminPasswordFailed :exec
+-- The pending password is kept as the device may have set it and it is resent in the next session
+UPDATE local_admin_passwords SET last_error=$3 WHERE device_id = $1 AND profile_id = $2 AND pending_encrypted_password IS NOT NULL;
+
+-- name: ScheduleLocalAdminRotation :exec
+-- The earlier of the scheduled rotation and the new rotation is kept
+UPDATE local_admin_passwords SET rotate_at=LEAST(rotate_at, $3) WHERE device_id = $1 AND profile_id = $2;
+
+-- name: LogLocalAdminPasswordAccess :exec
+INSERT INTO local_admin_password_access(device_id, profile_id, account_name, upn, reason) VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetLocalAdminPasswordAccess :many
+-- Exposed via API
+SELECT * FROM local_admin_password_access WHERE device_id = $1 ORDER BY accessed_at DESC LIMIT 100;
+
+-- name: GetAllPolicies :many
+-- Used for declarative configuration
+SELECT id, name, description, priority FROM policies ORDER BY name;
+
+-- name: GetAllGroups :many
+-- Used for declarative configuration
+SELECT id, name, description, priority, rules, timezone FROM groups ORDER BY name;
+
+-- name: GetAllUserGroups :many
+-- Used for declarative configuration
+SELECT id, name, description, priority FROM user_groups ORDER BY name;
 
 -- name: Settings :one
 SELECT * FROM settings LIMIT 1;
 
+-- name: SetDeviceNaming :exec
+UPDATE settings SET device_name_template=$1, device_name_prefix=$2;
+
+-- name: GetEnrollmentBrandings :many
+-- Exposed via API
+SELECT * FROM enrollment_branding ORDER BY language;
+
+-- name: GetEnrollmentBranding :one
+-- Exposed via API
+SELECT * FROM enrollment_branding WHERE language = $1 LIMIT 1;
+
+-- name: SetEnrollmentBranding :exec
+-- Exposed via API
+INSERT INTO enrollment_branding(language, application_name, title, body_template, support_url, logo_url, support_text) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (language) DO UPDATE SET application_name=$2, title=$3, body_template=$4, support_url=$5, logo_url=$6, support_text=$7;
+
+-- name: DeleteEnrollmentBranding :exec
+-- Exposed via API
+DELETE FROM enrollment_branding WHERE language = $1;
+
+-- name: GetLatestTermsOfService :one
+SELECT * FROM terms_of_service ORDER BY version DESC LIMIT 1;
+
+-- name: GetTermsOfServiceVersions :many
+-- Exposed via API
+SELECT version, title, created_at FROM terms_of_service ORDER BY version DESC;
+
+-- name: GetTermsOfService :one
+-- Exposed via API
+SELECT * FROM terms_of_service WHERE version = $1 LIMIT 1;
+
+-- name: CreateTermsOfService :one
+-- Exposed via API
+INSERT INTO terms_of_service(title, content) VALUES ($1, $2) RETURNING version;
+
+-- name: AcceptTermsOfService :exec
+INSERT INTO terms_of_service_acceptances(upn, version) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: HasAcceptedTermsOfService :one
+SELECT EXISTS(SELECT 1 FROM terms_of_service_acceptances WHERE upn = $1 AND version = $2);
+
+-- name: GetTermsOfServiceAcceptances :many
+-- Exposed via API
+SELECT users.upn, users.fullname, terms_of_service_acceptances.accepted_at FROM terms_of_service_acceptances INNER JOIN users ON users.upn = terms_of_service_acceptances.upn WHERE terms_of_service_acceptances.version = $1 ORDER BY terms_of_service_acceptances.accepted_at;
+
+-- name: GetTermsOfServiceReport :many
+-- Exposed via API
+-- The version and accepted_at are only set if accepted is true
+SELECT DISTINCT ON (users.upn) users.upn, users.fullname, (terms_of_service_acceptances.version IS NOT NULL)::boolean AS accepted, COALESCE(terms_of_service_acceptances.version, 0)::integer AS version, COALESCE(terms_of_service_acceptances.accepted_at, to_timestamp(0))::timestamptz AS accepted_at FROM users LEFT JOIN terms_of_service_acceptances ON terms_of_service_acceptances.upn = users.upn ORDER BY users.upn, terms_of_service_acceptances.version DESC NULLS LAST;
+
+-- name: GetEnrollmentResponse :one
+-- Only enrollments from the last 10 minutes can be retried
+SELECT enrollment_responses.device_id, enrollment_responses.provisioning_profile FROM enrollment_responses INNER JOIN devices ON devices.id = enrollment_responses.device_id WHERE devices.udid = $1 AND enrollment_responses.message_id = $2 AND enrollment_responses.upn = $3 AND enrollment_responses.created_at > NOW() - INTERVAL '10 minutes' LIMIT 1;
+
+-- name: SetEnrollmentResponse :exec
+INSERT INTO enrollment_responses(device_id, message_id, upn, provisioning_profile) VALUES ($1, $2, $3, $4) ON CONFLICT (device_id) DO UPDATE SET message_id=$2, upn=$3, provisioning_profile=$4, created_at=NOW();
+
+-- name: NewEnrollmentAttempt :exec
+INSERT INTO enrollment_attempts(trace_id, endpoint, message_id, upn, udid, hw_dev_id, device_id, result, fault_code, fault_type, fault_reason, error) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+
+-- name: PruneEnrollmentAttempts :exec
+-- Attempts older than the retention are deleted and only the newest attempts are kept so failing requests can't grow the table without bound
+DELETE FROM enrollment_attempts WHERE enrollment_attempts.created_at < sqlc.arg(created_before) OR enrollment_attempts.trace_id IN (SELECT ranked.trace_id FROM enrollment_attempts AS ranked ORDER BY ranked.created_at DESC OFFSET sqlc.arg(keep));
+
+-- name: GetEnrollmentAttempts :many
+-- Exposed via API
+SELECT * FROM enrollment_attempts ORDER BY created_at DESC LIMIT 100;
+
+-- name: GetEnrollmentAttempt :one
+-- Exposed via API
+SELECT * FROM enrollment_attempts WHERE trace_id = $1 LIMIT 1;
+
 -- name: GetRawCert :one
 SELECT cert, key FROM certificates WHERE id = $1 LIMIT 1;
 
@@ -39,6 +39,18 @@ CREATE TABLE device_inventory (
     UNIQUE (device_id, uri)
 );
 
+CREATE TABLE device_commands (
+    id SERIAL PRIMARY KEY,
+    device_id INTEGER REFERENCES devices(id) NOT NULL,
+    command TEXT NOT NULL,
+    uri TEXT NOT NULL,
+    format TEXT DEFAULT '' NOT NULL,
+    type TEXT DEFAULT '' NOT NULL,
+    value TEXT DEFAULT '' NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
 CREATE TABLE device_session_cache (
 
 );
@@ -47,7 +59,20 @@ CREATE TABLE policies (
     id SERIAL PRIMARY KEY,
     name TEXT UNIQUE NOT NULL,
     description TEXT DEFAULT '' NOT NULL,
-    priority SMALLINT DEFAULT '0' NOT NULL
+    priority SMALLINT DEFAULT '0' NOT NULL,
+    version INTEGER DEFAULT '0' NOT NULL -- The latest policy_versions version
+);
+
+CREATE TABLE policy_versions (
+    policy_id INTEGER NOT NULL, -- Not a reference so the history outlives the policy
+    version INTEGER NOT NULL,
+    author TEXT DEFAULT '' NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    priority SMALLINT NOT NULL,
+    payloads JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (policy_id, version)
 );
 
 CREATE TABLE policies_payload (
@@ -65,6 +90,8 @@ CREATE TABLE device_cache (
     device_id INTEGER REFERENCES devices(id) NOT NULL,
     payload_id INTEGER REFERENCES policies_payload(id),
     inventory_id INTEGER REFERENCES device_inventory(id),
+    upn TEXT REFERENCES users(upn), -- Set for payloads deployed to a user's session through a user group
+    policy_version INTEGER DEFAULT '0' NOT NULL, -- The version of the payloads policy which the device confirmed it has. It is 0 until the device returns the payload's status.
     cache_id SERIAL NOT NULL,
     PRIMARY KEY (device_id, cache_id),
     CONSTRAINT chk_reference check ((payload_id is not null and inventory_id is null) or (payload_id is null and inventory_id is not null))
@@ -74,7 +101,9 @@ CREATE TABLE groups (
     id SERIAL PRIMARY KEY,
     name TEXT UNIQUE NOT NULL,
     description TEXT DEFAULT '' NOT NULL,
-    priority SMALLINT DEFAULT '0' NOT NULL
+    priority SMALLINT DEFAULT '0' NOT NULL,
+    rules TEXT DEFAULT '' NOT NULL, -- Groups with rules are dynamic and their group_devices are managed by Mattrax
+    timezone TEXT DEFAULT 'UTC' NOT NULL -- The IANA time zone the maintenance windows of the groups policies are in
 );
 
 CREATE TABLE group_devices (
@@ -86,6 +115,261 @@ CREATE TABLE group_devices (
 CREATE TABLE group_policies (
     group_id INTEGER REFERENCES groups(id),
     policy_id INTEGER REFERENCES policies(id),
+    not_before TIMESTAMP WITH TIME ZONE, -- The policies payloads are held back from the groups devices until this time
+    PRIMARY KEY (group_id, policy_id)
+);
+
+-- When a group's policy has maintenance windows its payloads are only deployed to devices which checkin during one of them
+CREATE TABLE group_policy_windows (
+    group_id INTEGER NOT NULL,
+    policy_id INTEGER NOT NULL,
+    weekday SMALLINT NOT NULL, -- 0 is Sunday
+    start_minute SMALLINT NOT NULL, -- Minutes after midnight in the group's timezone
+    end_minute SMALLINT NOT NULL, -- Windows ending before they start finish on the next day
+    FOREIGN KEY (group_id, policy_id) REFERENCES group_policies(group_id, policy_id) ON DELETE CASCADE
+);
+
+CREATE TYPE rollout_state AS ENUM ('active', 'paused', 'aborted', 'completed');
+
+-- Rollouts assign a policy to a group in stages. Once completed the policy is assigned to the group through group_policies.
+CREATE TABLE rollouts (
+    id SERIAL PRIMARY KEY,
+    policy_id INTEGER REFERENCES policies(id) NOT NULL,
+    group_id INTEGER REFERENCES groups(id) NOT NULL,
+    stage SMALLINT DEFAULT '0' NOT NULL,
+    state rollout_state DEFAULT 'active' NOT NULL,
+    failure_threshold SMALLINT DEFAULT '10' NOT NULL, -- The percentage of failed devices in a stage which pauses the rollout
+    success_threshold SMALLINT DEFAULT '90' NOT NULL, -- The percentage of successful devices in a stage required to promote the rollout
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Each stage either deploys to a percentage of the group's devices or to the devices in a ring group. Stages include the devices of all previous stages.
+CREATE TABLE rollout_stages (
+    rollout_id INTEGER REFERENCES rollouts(id) ON DELETE CASCADE NOT NULL,
+    stage SMALLINT NOT NULL,
+    percentage SMALLINT DEFAULT '0' NOT NULL,
+    ring_group_id INTEGER REFERENCES groups(id),
+    PRIMARY KEY (rollout_id, stage)
+);
+
+CREATE TABLE rollout_results (
+    rollout_id INTEGER REFERENCES rollouts(id) ON DELETE CASCADE NOT NULL,
+    device_id INTEGER REFERENCES devices(id) NOT NULL,
+    stage SMALLINT NOT NULL,
+    status INTEGER NOT NULL, -- The SyncML status code returned by the device
+    failed BOOLEAN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (rollout_id, device_id, stage)
+);
+
+CREATE TYPE app_type AS ENUM ('msi', 'store', 'appx');
+
+-- Apps are installed on devices by assigning them to groups. The files of MSI and appx/msix apps are stored in the app storage directory (named by their hash) while Store apps are downloaded from the Microsoft Store.
+CREATE TABLE apps (
+    id SERIAL PRIMARY KEY,
+    type app_type NOT NULL,
+    name TEXT NOT NULL,
+    publisher TEXT DEFAULT '' NOT NULL,
+    identifier TEXT NOT NULL, -- The MSI's ProductCode or the package family name of Store and appx/msix apps
+    version TEXT DEFAULT '' NOT NULL,
+    file_hash TEXT DEFAULT '' NOT NULL, -- The hex encoded SHA256 hash of the installer. Store apps have no installer.
+    file_size BIGINT DEFAULT '0' NOT NULL,
+    file_name TEXT DEFAULT '' NOT NULL, -- The name of the uploaded installer which is used in its download URL
+    command_line TEXT DEFAULT '' NOT NULL, -- Arguments for the installer
+    store_id TEXT DEFAULT '' NOT NULL, -- The Microsoft Store product ID (eg. 9NCBCSZSJRSB)
+    store_sku TEXT DEFAULT '' NOT NULL,
+    user_context BOOLEAN DEFAULT false NOT NULL, -- Whether the app is installed for the user signed into the device instead of the device
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE (type, identifier)
+);
+
+CREATE TYPE app_intent AS ENUM ('required', 'available', 'uninstall');
+
+CREATE TABLE app_assignments (
+    app_id INTEGER REFERENCES apps(id) ON DELETE CASCADE NOT NULL,
+    group_id INTEGER REFERENCES groups(id) NOT NULL,
+    intent app_intent NOT NULL,
+    PRIMARY KEY (app_id, group_id)
+);
+
+-- Pending is an available app which was requested for the device
+CREATE TYPE app_install_state AS ENUM ('pending', 'installing', 'installed', 'failed', 'uninstalling', 'uninstalled');
+
+CREATE TABLE app_installs (
+    app_id INTEGER REFERENCES apps(id) ON DELETE CASCADE NOT NULL,
+    device_id INTEGER REFERENCES devices(id) NOT NULL,
+    state app_install_state NOT NULL,
+    status TEXT DEFAULT '' NOT NULL, -- The status reported by the device (eg. the MSI's HRESULT)
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (app_id, device_id)
+);
+
+-- The modern apps installed on the device reported by the EnterpriseModernAppManagement CSP
+CREATE TABLE device_apps (
+    device_id INTEGER REFERENCES devices(id) NOT NULL,
+    package_family_name TEXT NOT NULL,
+    source TEXT NOT NULL, -- The AppManagement node the app was listed under (AppStore or nonStore)
+    upn TEXT DEFAULT '' NOT NULL, -- The user signed into the device the app is installed for. It is empty for apps installed for the device.
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (device_id, upn, source, package_family_name)
+);
+
+CREATE TYPE script_context AS ENUM ('system', 'user');
+
+CREATE TYPE script_schedule AS ENUM ('once', 'hourly', 'daily', 'weekly');
+
+-- Scripts are PowerShell scripts run by the Mattrax agent on the devices in their group
+CREATE TABLE scripts (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT DEFAULT '' NOT NULL,
+    content TEXT NOT NULL,
+    run_context script_context NOT NULL, -- Whether the script runs as SYSTEM or as the user signed into the device
+    schedule script_schedule NOT NULL,
+    group_id INTEGER REFERENCES groups(id) NOT NULL,
+    version INTEGER DEFAULT '1' NOT NULL, -- Incremented when the content changes so scripts which run once run again
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- The result of the last run of each script on each device
+CREATE TABLE script_runs (
+    script_id INTEGER REFERENCES scripts(id) ON DELETE CASCADE NOT NULL,
+    device_id INTEGER REFERENCES devices(id) NOT NULL,
+    version INTEGER NOT NULL,
+    exit_code INTEGER NOT NULL,
+    stdout TEXT DEFAULT '' NOT NULL,
+    stderr TEXT DEFAULT '' NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (script_id, device_id)
+);
+
+-- Certificate profiles issue certificates from the SCEP CA to the devices in their group through the ClientCertificateInstall CSP
+CREATE TABLE certificate_profiles (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT DEFAULT '' NOT NULL,
+    subject_template TEXT NOT NULL, -- The subject's distinguished name which may contain placeholders (eg. CN={devicename})
+    key_usages TEXT[] NOT NULL,
+    extended_key_usages TEXT[] NOT NULL, -- The OIDs of the extended key usages (eg. 1.3.6.1.5.5.7.3.2 for client authentication)
+    key_length INTEGER NOT NULL,
+    validity_days INTEGER NOT NULL,
+    renewal_threshold INTEGER NOT NULL, -- The percentage of the certificate's lifetime which is left when it is renewed
+    user_context BOOLEAN NOT NULL, -- Whether the certificate is issued to the user signed into the device
+    group_id INTEGER REFERENCES groups(id) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- The challenge password which lets a device request a certificate from the SCEP endpoint once
+CREATE TABLE certificate_challenges (
+    device_id INTEGER REFERENCES devices(id) NOT NULL,
+    profile_id INTEGER REFERENCES certificate_profiles(id) ON DELETE CASCADE NOT NULL,
+    upn TEXT DEFAULT '' NOT NULL, -- The user the certificate is issued to. Empty for device certificates.
+    challenge_hash TEXT UNIQUE NOT NULL, -- The hex encoded SHA256 hash of the challenge password
+    subject TEXT NOT NULL, -- The rendered subject template which the certificate is issued with
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (device_id, profile_id, upn)
+);
+
+-- The certificates issued by the SCEP endpoint
+CREATE TABLE issued_certificates (
+    serial TEXT PRIMARY KEY, -- The upper case hex encoded serial number
+    device_id INTEGER REFERENCES devices(id) NOT NULL,
+    profile_id INTEGER REFERENCES certificate_profiles(id) ON DELETE SET NULL,
+    upn TEXT DEFAULT '' NOT NULL,
+    subject TEXT NOT NULL,
+    thumbprint TEXT NOT NULL,
+    not_before TIMESTAMP WITH TIME ZONE NOT NULL,
+    not_after TIMESTAMP WITH TIME ZONE NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+-- The BitLocker recovery passwords escrowed by the Mattrax agent. Escrow is append-only: a password is never replaced and is kept after its protector is removed from the device
+-- as a backup of the drive may still need it. A protector has more than one row if the agent reported a different password for it.
+CREATE TABLE bitlocker_recovery_keys (
+    id SERIAL PRIMARY KEY,
+    device_id INTEGER REFERENCES devices(id) NOT NULL,
+    protector_id TEXT NOT NULL, -- The GUID of the recovery password key protector
+    volume TEXT NOT NULL, -- The mount point of the encrypted volume (eg. C:) when the password was escrowed
+    encrypted_password BYTEA NOT NULL, -- The recovery password encrypted by the secrets service
+    escrowed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    removed_at TIMESTAMP WITH TIME ZONE -- When the protector was replaced by a rotation
+);
+
+-- The devices whose agent has been asked to replace their recovery passwords. The request is removed once the agent escrows a new recovery password.
+CREATE TABLE bitlocker_rotations (
+    device_id INTEGER PRIMARY KEY REFERENCES devices(id),
+    requested_by TEXT NOT NULL,
+    requested_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Every time a BitLocker recovery password was revealed through the API
+CREATE TABLE bitlocker_recovery_key_access (
+    id SERIAL PRIMARY KEY,
+    device_id INTEGER REFERENCES devices(id) NOT NULL,
+    protector_id TEXT NOT NULL,
+    upn TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    accessed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Local admin profiles manage a local administrator account with a unique random password on the devices in their group through the Accounts CSP
+CREATE TABLE local_admin_profiles (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT DEFAULT '' NOT NULL,
+    account_name TEXT NOT NULL,
+    password_length INTEGER NOT NULL,
+    rotation_days INTEGER NOT NULL, -- How often the password is rotated
+    rotate_after_view_hours INTEGER NOT NULL, -- How long after a password is revealed it is rotated. 0 disables rotating revealed passwords.
+    group_id INTEGER REFERENCES groups(id) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- The password of each device's managed local administrator account. The passwords are encrypted by the secrets service.
+-- A new password is pending until the device confirms it was set. Both are kept until then as the device may have set the pending password without its confirmation reaching Mattrax.
+CREATE TABLE local_admin_passwords (
+    device_id INTEGER REFERENCES devices(id) NOT NULL,
+    profile_id INTEGER REFERENCES local_admin_profiles(id) ON DELETE CASCADE NOT NULL,
+    encrypted_password BYTEA,
+    set_at TIMESTAMP WITH TIME ZONE,
+    pending_encrypted_password BYTEA,
+    pending_since TIMESTAMP WITH TIME ZONE,
+    rotate_at TIMESTAMP WITH TIME ZONE, -- When the password is rotated before it is due (eg. after it was revealed)
+    last_error TEXT DEFAULT '' NOT NULL, -- The status of the device's last failure to set a new password
+    PRIMARY KEY (device_id, profile_id)
+);
+
+-- Every time a local administrator password was revealed through the API
+CREATE TABLE local_admin_password_access (
+    id SERIAL PRIMARY KEY,
+    device_id INTEGER REFERENCES devices(id) NOT NULL,
+    profile_id INTEGER NOT NULL, -- Not a reference so the access outlives the profile
+    account_name TEXT NOT NULL,
+    upn TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    accessed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE user_groups (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT DEFAULT '' NOT NULL,
+    priority SMALLINT DEFAULT '0' NOT NULL
+);
+
+CREATE TABLE user_group_members (
+    group_id INTEGER REFERENCES user_groups(id) NOT NULL,
+    upn TEXT REFERENCES users(upn) NOT NULL,
+    PRIMARY KEY (group_id, upn)
+);
+
+CREATE TABLE user_group_policies (
+    group_id INTEGER REFERENCES user_groups(id) NOT NULL,
+    policy_id INTEGER REFERENCES policies(id) NOT NULL,
     PRIMARY KEY (group_id, policy_id)
 );
 
@@ -95,7 +379,62 @@ CREATE TABLE settings (
     tenant_website TEXT DEFAULT '' NOT NULL,
     tenant_phone TEXT DEFAULT '' NOT NULL,
     tenant_azureid TEXT NOT NULL,
-    disable_enrollment BOOLEAN DEFAULT false NOT NULL
+    disable_enrollment BOOLEAN DEFAULT false NOT NULL,
+    device_name_template TEXT DEFAULT '' NOT NULL,
+    device_name_prefix TEXT DEFAULT '' NOT NULL
+);
+
+CREATE TABLE enrollment_branding (
+    language TEXT PRIMARY KEY, -- An empty language is the default used when no variant matches the user's language
+    application_name TEXT DEFAULT '' NOT NULL,
+    title TEXT DEFAULT '' NOT NULL,
+    body_template TEXT DEFAULT '' NOT NULL,
+    support_url TEXT DEFAULT '' NOT NULL,
+    logo_url TEXT DEFAULT '' NOT NULL,
+    support_text TEXT DEFAULT '' NOT NULL -- The text of the support link
+);
+
+CREATE TABLE terms_of_service (
+    version SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE terms_of_service_acceptances (
+    upn TEXT REFERENCES users(upn) NOT NULL,
+    version INTEGER REFERENCES terms_of_service(version) NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (upn, version)
+);
+
+CREATE TYPE enrollment_attempt_result AS ENUM ('success', 'fault');
+
+CREATE TABLE enrollment_attempts (
+    trace_id TEXT PRIMARY KEY,
+    endpoint TEXT NOT NULL,
+    message_id TEXT DEFAULT '' NOT NULL,
+    upn TEXT DEFAULT '' NOT NULL,
+    udid TEXT DEFAULT '' NOT NULL,
+    hw_dev_id TEXT DEFAULT '' NOT NULL,
+    device_id INTEGER, -- Not a reference so the attempt outlives the device
+    result enrollment_attempt_result NOT NULL,
+    fault_code TEXT DEFAULT '' NOT NULL,
+    fault_type TEXT DEFAULT '' NOT NULL,
+    fault_reason TEXT DEFAULT '' NOT NULL,
+    error TEXT DEFAULT '' NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX enrollment_attempts_created_at ON enrollment_attempts(created_at);
+
+-- The provisioning profile returned to a device's latest enrollment so a retry of the enrollment (eg. because the response was lost) is returned the same response
+CREATE TABLE enrollment_responses (
+    device_id INTEGER PRIMARY KEY REFERENCES devices(id) NOT NULL,
+    message_id TEXT NOT NULL,
+    upn TEXT NOT NULL,
+    provisioning_profile BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
 );
 
 CREATE TABLE certificates (